@@ -32,7 +32,9 @@ func parseOptions() (options *server.Options, err error) {
 
 	replicationOptions.
 		WithIsReplica(viper.GetBool("replication-is-replica")).
-		WithSyncReplication(viper.GetBool("replication-sync-enabled"))
+		WithSyncReplication(viper.GetBool("replication-sync-enabled")).
+		WithMaxConcurrentExports(viper.GetInt("replication-max-concurrent-exports")).
+		WithExportQueueTimeout(viper.GetDuration("replication-export-queue-timeout"))
 
 	if replicationOptions.IsReplica {
 		replicationOptions.
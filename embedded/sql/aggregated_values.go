@@ -152,6 +152,11 @@ func (v *SumValue) Compare(val TypedValue) (int, error) {
 }
 
 func (v *SumValue) updateWith(val TypedValue) error {
+	if val.IsNull() {
+		// NULL values are not aggregated
+		return nil
+	}
+
 	if val.Type() != IntegerType {
 		return ErrNotComparableValues
 	}
@@ -216,7 +221,7 @@ func (v *MinValue) Type() SQLValueType {
 }
 
 func (v *MinValue) IsNull() bool {
-	return false
+	return v.val != nil && v.val.IsNull()
 }
 
 func (v *MinValue) Value() interface{} {
@@ -228,11 +233,17 @@ func (v *MinValue) Compare(val TypedValue) (int, error) {
 }
 
 func (v *MinValue) updateWith(val TypedValue) error {
-	if v.val == nil {
+	// NULL values are not aggregated but the first one is kept
+	// until a non-NULL value is found, so the type is known
+	if v.val == nil || (v.val.IsNull() && !val.IsNull()) {
 		v.val = val
 		return nil
 	}
 
+	if val.IsNull() {
+		return nil
+	}
+
 	cmp, err := v.val.Compare(val)
 	if err != nil {
 		return err
@@ -309,7 +320,7 @@ func (v *MaxValue) Type() SQLValueType {
 }
 
 func (v *MaxValue) IsNull() bool {
-	return false
+	return v.val != nil && v.val.IsNull()
 }
 
 func (v *MaxValue) Value() interface{} {
@@ -321,11 +332,17 @@ func (v *MaxValue) Compare(val TypedValue) (int, error) {
 }
 
 func (v *MaxValue) updateWith(val TypedValue) error {
-	if v.val == nil {
+	// NULL values are not aggregated but the first one is kept
+	// until a non-NULL value is found, so the type is known
+	if v.val == nil || (v.val.IsNull() && !val.IsNull()) {
 		v.val = val
 		return nil
 	}
 
+	if val.IsNull() {
+		return nil
+	}
+
 	cmp, err := v.val.Compare(val)
 	if err != nil {
 		return err
@@ -407,6 +424,15 @@ func (v *AVGValue) IsNull() bool {
 }
 
 func (v *AVGValue) Value() interface{} {
+	return v.avg()
+}
+
+// avg is zero when there are only NULL values, as when there are no values at all
+func (v *AVGValue) avg() int64 {
+	if v.c == 0 {
+		return 0
+	}
+
 	return v.s / v.c
 }
 
@@ -415,7 +441,7 @@ func (v *AVGValue) Compare(val TypedValue) (int, error) {
 		return 0, ErrNotComparableValues
 	}
 
-	avg := v.s / v.c
+	avg := v.avg()
 	nv := val.Value().(int64)
 
 	if avg == nv {
@@ -430,6 +456,11 @@ func (v *AVGValue) Compare(val TypedValue) (int, error) {
 }
 
 func (v *AVGValue) updateWith(val TypedValue) error {
+	if val.IsNull() {
+		// NULL values are not aggregated
+		return nil
+	}
+
 	if val.Type() != IntegerType {
 		return ErrNotComparableValues
 	}
@@ -477,3 +508,113 @@ func (v *AVGValue) isConstant() bool {
 func (v *AVGValue) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
 	return nil
 }
+
+// BoolAggValue aggregates BOOLEAN values with either AND (BOOL_AND) or OR (BOOL_OR),
+// it's NULL until a non-NULL value is aggregated
+type BoolAggValue struct {
+	and bool
+	b   bool
+	set bool
+	sel string
+}
+
+func (v *BoolAggValue) Selector() string {
+	return v.sel
+}
+
+func (v *BoolAggValue) ColBounded() bool {
+	return true
+}
+
+func (v *BoolAggValue) Type() SQLValueType {
+	return BooleanType
+}
+
+func (v *BoolAggValue) IsNull() bool {
+	return !v.set
+}
+
+func (v *BoolAggValue) Value() interface{} {
+	if !v.set {
+		return nil
+	}
+
+	return v.b
+}
+
+func (v *BoolAggValue) typedValue() TypedValue {
+	if !v.set {
+		return &NullValue{t: BooleanType}
+	}
+
+	return &Bool{val: v.b}
+}
+
+func (v *BoolAggValue) Compare(val TypedValue) (int, error) {
+	return v.typedValue().Compare(val)
+}
+
+func (v *BoolAggValue) updateWith(val TypedValue) error {
+	if val.IsNull() {
+		// NULL values are not aggregated
+		return nil
+	}
+
+	if val.Type() != BooleanType {
+		return ErrNotComparableValues
+	}
+
+	b := val.Value().(bool)
+
+	if !v.set {
+		v.b = b
+		v.set = true
+		return nil
+	}
+
+	if v.and {
+		v.b = v.b && b
+	} else {
+		v.b = v.b || b
+	}
+
+	return nil
+}
+
+// ValueExp
+
+func (v *BoolAggValue) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return BooleanType, nil
+}
+
+func (v *BoolAggValue) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != BooleanType {
+		return ErrNotComparableValues
+	}
+
+	return nil
+}
+
+func (v *BoolAggValue) jointColumnTo(col *Column, tableAlias string) (*ColSelector, error) {
+	return nil, ErrUnexpected
+}
+
+func (v *BoolAggValue) substitute(params map[string]interface{}) (ValueExp, error) {
+	return nil, ErrUnexpected
+}
+
+func (v *BoolAggValue) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return nil, ErrUnexpected
+}
+
+func (v *BoolAggValue) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return nil
+}
+
+func (v *BoolAggValue) isConstant() bool {
+	return false
+}
+
+func (v *BoolAggValue) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
@@ -0,0 +1,590 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+const arrayTypeSuffix = "[]"
+
+// ArrayTypeOf returns the type of arrays holding values of the given scalar type e.g. VARCHAR[]
+func ArrayTypeOf(elemType SQLValueType) SQLValueType {
+	return elemType + arrayTypeSuffix
+}
+
+// arrayElemType returns the type of the values held by arrays of type t,
+// it's false when t is not an array type
+func arrayElemType(t SQLValueType) (SQLValueType, bool) {
+	if !strings.HasSuffix(t, arrayTypeSuffix) {
+		return AnyType, false
+	}
+
+	return strings.TrimSuffix(t, arrayTypeSuffix), true
+}
+
+func isArrayType(t SQLValueType) bool {
+	_, isArray := arrayElemType(t)
+	return isArray
+}
+
+// Array is a list of non-NULL values of the same scalar type.
+// Arrays are equal when they hold equal values in the same order, and they are ordered
+// by comparing their values one by one, an array comes before any longer array it's a prefix of
+type Array struct {
+	elemType SQLValueType
+	vals     []TypedValue
+}
+
+// NewArray returns an array holding the given values, all of them of the given scalar type
+func NewArray(elemType SQLValueType, vals []TypedValue) TypedValue {
+	return &Array{elemType: elemType, vals: vals}
+}
+
+func (v *Array) Type() SQLValueType {
+	return ArrayTypeOf(v.elemType)
+}
+
+func (v *Array) IsNull() bool {
+	return false
+}
+
+func (v *Array) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	if v.elemType == AnyType {
+		// empty arrays may be of any array type
+		return AnyType, nil
+	}
+
+	return v.Type(), nil
+}
+
+func (v *Array) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t == v.Type() || (v.elemType == AnyType && isArrayType(t)) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, v.Type(), t)
+}
+
+func (v *Array) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *Array) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *Array) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *Array) isConstant() bool {
+	return true
+}
+
+func (v *Array) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// Value returns the values held by the array
+func (v *Array) Value() interface{} {
+	vals := make([]interface{}, len(v.vals))
+
+	for i, val := range v.vals {
+		vals[i] = val.Value()
+	}
+
+	return vals
+}
+
+func (v *Array) Compare(val TypedValue) (int, error) {
+	if val.IsNull() {
+		return 1, nil
+	}
+
+	rval, ok := val.(*Array)
+	if !ok || (v.elemType != rval.elemType && v.elemType != AnyType && rval.elemType != AnyType) {
+		return 0, ErrNotComparableValues
+	}
+
+	for i := 0; i < len(v.vals) && i < len(rval.vals); i++ {
+		r, err := v.vals[i].Compare(rval.vals[i])
+		if err != nil {
+			return 0, err
+		}
+
+		if r != 0 {
+			return r, nil
+		}
+	}
+
+	switch {
+	case len(v.vals) < len(rval.vals):
+		return -1, nil
+	case len(v.vals) > len(rval.vals):
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+// contains tells if every value of the given array is also held by this one
+func (v *Array) contains(arr *Array) (bool, error) {
+	for _, rv := range arr.vals {
+		var found bool
+
+		for _, lv := range v.vals {
+			r, err := lv.Compare(rv)
+			if err != nil {
+				return false, err
+			}
+
+			if r == 0 {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// encodeArray encodes the number of values followed by every value encoded as the array element type
+func encodeArray(val interface{}, elemType SQLValueType) ([]byte, error) {
+	vals, ok := val.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf(
+			"value is not an array: %w", ErrInvalidValue,
+		)
+	}
+
+	// len(v) + count + v1 + ... + vn
+	encv := make([]byte, 2*EncLenLen)
+	binary.BigEndian.PutUint32(encv[EncLenLen:], uint32(len(vals)))
+
+	for i, v := range vals {
+		encElem, err := EncodeValue(v, elemType, 0)
+		if err != nil {
+			return nil, fmt.Errorf("array element %d: %w", i+1, err)
+		}
+
+		encv = append(encv, encElem...)
+	}
+
+	binary.BigEndian.PutUint32(encv, uint32(len(encv)-EncLenLen))
+
+	return encv, nil
+}
+
+func decodeArray(b []byte, elemType SQLValueType) (*Array, error) {
+	if len(b) < EncLenLen {
+		return nil, ErrCorruptedData
+	}
+
+	count := int(binary.BigEndian.Uint32(b))
+	off := EncLenLen
+
+	if count > len(b)-off {
+		return nil, ErrCorruptedData
+	}
+
+	vals := make([]TypedValue, count)
+
+	for i := range vals {
+		val, n, err := DecodeValue(b[off:], elemType)
+		if err != nil {
+			return nil, err
+		}
+
+		vals[i] = val
+		off += n
+	}
+
+	if off != len(b) {
+		return nil, ErrCorruptedData
+	}
+
+	return &Array{elemType: elemType, vals: vals}, nil
+}
+
+// ArrayExp builds an array from a list of values e.g. `ARRAY['a', 'b']`
+type ArrayExp struct {
+	elems []ValueExp
+}
+
+func (v *ArrayExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	elemType := AnyType
+
+	for i, e := range v.elems {
+		t, err := e.inferType(cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, err
+		}
+
+		if t == AnyType || t == elemType {
+			continue
+		}
+
+		if elemType != AnyType {
+			return AnyType, fmt.Errorf("%w: array element %d is of type %v but %v was expected", ErrInvalidTypes, i+1, t, elemType)
+		}
+
+		elemType = t
+	}
+
+	if elemType == AnyType {
+		return AnyType, nil
+	}
+
+	arrType := ArrayTypeOf(elemType)
+
+	err := v.requiresType(arrType, cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
+	}
+
+	return arrType, nil
+}
+
+func (v *ArrayExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	elemType, isArray := arrayElemType(t)
+	if !isArray || isArrayType(elemType) {
+		return fmt.Errorf("%w: array can not be interpreted as type %v", ErrInvalidTypes, t)
+	}
+
+	for i, e := range v.elems {
+		err := e.requiresType(elemType, cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return fmt.Errorf("invalid array element %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func (v *ArrayExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	elems := make([]ValueExp, len(v.elems))
+
+	for i, e := range v.elems {
+		elem, err := e.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		elems[i] = elem
+	}
+
+	return &ArrayExp{elems: elems}, nil
+}
+
+func (v *ArrayExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	arr := &Array{elemType: AnyType, vals: make([]TypedValue, len(v.elems))}
+
+	for i, e := range v.elems {
+		val, err := e.reduce(tx, row, implicitDB, implicitTable)
+		if err != nil {
+			return nil, err
+		}
+
+		if val.IsNull() {
+			return nil, fmt.Errorf("%w: array element %d is NULL", ErrInvalidValue, i+1)
+		}
+
+		if isArrayType(val.Type()) {
+			return nil, fmt.Errorf("%w: array element %d is an array", ErrInvalidTypes, i+1)
+		}
+
+		if arr.elemType != AnyType && arr.elemType != val.Type() {
+			return nil, fmt.Errorf("%w: array element %d is of type %v but %v was expected", ErrInvalidTypes, i+1, val.Type(), arr.elemType)
+		}
+
+		arr.elemType = val.Type()
+		arr.vals[i] = val
+	}
+
+	return arr, nil
+}
+
+func (v *ArrayExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	elems := make([]ValueExp, len(v.elems))
+
+	for i, e := range v.elems {
+		elems[i] = e.reduceSelectors(row, implicitDB, implicitTable)
+	}
+
+	return &ArrayExp{elems: elems}
+}
+
+func (v *ArrayExp) isConstant() bool {
+	for _, e := range v.elems {
+		if !e.isConstant() {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (v *ArrayExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// reduceArray reduces exp into an array, NULL values are returned as nil
+func reduceArray(exp ValueExp, tx *SQLTx, row *Row, implicitDB, implicitTable string) (*Array, error) {
+	val, err := exp.reduce(tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	if val.IsNull() {
+		return nil, nil
+	}
+
+	arr, ok := val.(*Array)
+	if !ok {
+		return nil, fmt.Errorf("%w: %v is not an array type", ErrInvalidTypes, val.Type())
+	}
+
+	return arr, nil
+}
+
+// CmpAnyBoolExp compares a value against every value of an array e.g. `'a' = ANY(tags)`,
+// it's true when any of the comparisons is satisfied. It's false for NULL or empty arrays
+type CmpAnyBoolExp struct {
+	op  CmpOperator
+	val ValueExp
+	arr ValueExp
+}
+
+func (bexp *CmpAnyBoolExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	tarr, err := bexp.arr.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, fmt.Errorf("error inferring type in 'ANY' clause: %w", err)
+	}
+
+	tval, err := bexp.val.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, fmt.Errorf("error inferring type in 'ANY' clause: %w", err)
+	}
+
+	if tarr == AnyType {
+		if tval != AnyType {
+			err = bexp.arr.requiresType(ArrayTypeOf(tval), cols, params, implicitDB, implicitTable)
+			if err != nil {
+				return AnyType, fmt.Errorf("error inferring type in 'ANY' clause: %w", err)
+			}
+		}
+
+		return BooleanType, nil
+	}
+
+	elemType, isArray := arrayElemType(tarr)
+	if !isArray {
+		return AnyType, fmt.Errorf("error inferring type in 'ANY' clause: %w: %v is not an array type", ErrInvalidTypes, tarr)
+	}
+
+	err = bexp.val.requiresType(elemType, cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, fmt.Errorf("error inferring type in 'ANY' clause: %w", err)
+	}
+
+	return BooleanType, nil
+}
+
+func (bexp *CmpAnyBoolExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != BooleanType {
+		return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, BooleanType, t)
+	}
+
+	_, err := bexp.inferType(cols, params, implicitDB, implicitTable)
+
+	return err
+}
+
+func (bexp *CmpAnyBoolExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	val, err := bexp.val.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	arr, err := bexp.arr.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CmpAnyBoolExp{op: bexp.op, val: val, arr: arr}, nil
+}
+
+func (bexp *CmpAnyBoolExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	val, err := bexp.val.reduce(tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating 'ANY' clause: %w", err)
+	}
+
+	arr, err := reduceArray(bexp.arr, tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating 'ANY' clause: %w", err)
+	}
+
+	if arr == nil {
+		return &Bool{val: false}, nil
+	}
+
+	for _, elem := range arr.vals {
+		r, err := val.Compare(elem)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating 'ANY' clause: %w", err)
+		}
+
+		if cmpSatisfiesOp(r, bexp.op) {
+			return &Bool{val: true}, nil
+		}
+	}
+
+	return &Bool{val: false}, nil
+}
+
+func (bexp *CmpAnyBoolExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return &CmpAnyBoolExp{
+		op:  bexp.op,
+		val: bexp.val.reduceSelectors(row, implicitDB, implicitTable),
+		arr: bexp.arr.reduceSelectors(row, implicitDB, implicitTable),
+	}
+}
+
+func (bexp *CmpAnyBoolExp) isConstant() bool {
+	return false
+}
+
+func (bexp *CmpAnyBoolExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// ArrayContainsExp is true when the left array holds every value of the right one e.g. `tags @> ARRAY['a']`,
+// regardless of their order and repetitions. It's false when any of the arrays is NULL
+type ArrayContainsExp struct {
+	left, right ValueExp
+}
+
+func (bexp *ArrayContainsExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	tleft, err := bexp.left.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
+	}
+
+	tright, err := bexp.right.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
+	}
+
+	for _, t := range []SQLValueType{tleft, tright} {
+		if t != AnyType && !isArrayType(t) {
+			return AnyType, fmt.Errorf("%w: %v is not an array type", ErrInvalidTypes, t)
+		}
+	}
+
+	if tleft == tright {
+		return BooleanType, nil
+	}
+
+	if tleft != AnyType && tright != AnyType {
+		return AnyType, fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, tleft, tright)
+	}
+
+	if tleft == AnyType {
+		err = bexp.left.requiresType(tright, cols, params, implicitDB, implicitTable)
+	} else {
+		err = bexp.right.requiresType(tleft, cols, params, implicitDB, implicitTable)
+	}
+	if err != nil {
+		return AnyType, err
+	}
+
+	return BooleanType, nil
+}
+
+func (bexp *ArrayContainsExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != BooleanType {
+		return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, BooleanType, t)
+	}
+
+	_, err := bexp.inferType(cols, params, implicitDB, implicitTable)
+
+	return err
+}
+
+func (bexp *ArrayContainsExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	left, err := bexp.left.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := bexp.right.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ArrayContainsExp{left: left, right: right}, nil
+}
+
+func (bexp *ArrayContainsExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	left, err := reduceArray(bexp.left, tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := reduceArray(bexp.right, tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	if left == nil || right == nil {
+		return &Bool{val: false}, nil
+	}
+
+	if left.elemType != right.elemType && left.elemType != AnyType && right.elemType != AnyType {
+		return nil, fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, right.Type(), left.Type())
+	}
+
+	contains, err := left.contains(right)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bool{val: contains}, nil
+}
+
+func (bexp *ArrayContainsExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return &ArrayContainsExp{
+		left:  bexp.left.reduceSelectors(row, implicitDB, implicitTable),
+		right: bexp.right.reduceSelectors(row, implicitDB, implicitTable),
+	}
+}
+
+func (bexp *ArrayContainsExp) isConstant() bool {
+	return false
+}
+
+func (bexp *ArrayContainsExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -29,6 +30,9 @@ import (
 type Catalog struct {
 	dbsByID   map[uint32]*Database
 	dbsByName map[string]*Database
+
+	// functions holds the functions registered in the engine when the catalog was loaded
+	functions map[string]*Function
 }
 
 type Database struct {
@@ -38,6 +42,8 @@ type Database struct {
 	tables       []*Table
 	tablesByID   map[uint32]*Table
 	tablesByName map[string]*Table
+	views        []*View
+	viewsByName  map[string]*View
 }
 
 type Table struct {
@@ -53,12 +59,22 @@ type Table struct {
 	primaryIndex    *Index
 	autoIncrementPK bool
 	maxPK           int64
+
+	// sortCol is the column rows are sorted by when no order is specified in a query
+	sortCol  *Column
+	sortDesc bool
+
+	// exprCols are the virtual columns holding the values of indexed expressions
+	exprCols       []*Column
+	exprColsByID   map[uint32]*Column
+	exprColsByName map[string]*Column
 }
 
 type Index struct {
 	table    *Table
 	id       uint32
 	unique   bool
+	deferred bool
 	cols     []*Column
 	colsByID map[uint32]*Column
 }
@@ -71,12 +87,19 @@ type Column struct {
 	maxLen        int
 	autoIncrement bool
 	notNull       bool
+
+	// defaultValue is assigned to the column when no value is specified on insertion
+	defaultValue ValueExp
+
+	// expr is the indexed expression computing the values of a virtual column
+	expr ValueExp
 }
 
-func newCatalog() *Catalog {
+func newCatalog(functions map[string]*Function) *Catalog {
 	return &Catalog{
 		dbsByID:   map[uint32]*Database{},
 		dbsByName: map[string]*Database{},
+		functions: functions,
 	}
 }
 
@@ -97,6 +120,7 @@ func (c *Catalog) newDatabase(id uint32, name string) (*Database, error) {
 		name:         name,
 		tablesByID:   map[uint32]*Table{},
 		tablesByName: map[string]*Table{},
+		viewsByName:  map[string]*View{},
 	}
 
 	c.dbsByID[db.id] = db
@@ -213,6 +237,59 @@ func (t *Table) IndexesByColID(colID uint32) []*Index {
 	return t.indexesByColID[colID]
 }
 
+// RedundantIndex describes an index made unnecessary by another index of the same table
+type RedundantIndex struct {
+	Index     *Index
+	CoveredBy *Index
+}
+
+// RedundantIndexes returns the indexes of the table whose columns are a prefix of the columns of another index,
+// thus the other index already serves the same lookups and orderings. Unique indexes are never considered
+// redundant as they enforce a constraint the covering index may not enforce
+func (t *Table) RedundantIndexes() []RedundantIndex {
+	var redundant []RedundantIndex
+
+	for _, index := range t.indexes {
+		coveredBy := t.coveringIndex(index)
+		if coveredBy != nil {
+			redundant = append(redundant, RedundantIndex{Index: index, CoveredBy: coveredBy})
+		}
+	}
+
+	return redundant
+}
+
+// coveringIndex returns another index of the table which makes the given one redundant, if any
+func (t *Table) coveringIndex(index *Index) *Index {
+	if index.IsPrimary() || index.IsUnique() {
+		return nil
+	}
+
+	for _, other := range t.indexes {
+		if other != index && coversIndexCols(other.cols, index.cols) {
+			return other
+		}
+	}
+
+	return nil
+}
+
+// coversIndexCols tells if an index over cols serves every lookup an index over prefixCols does,
+// which is the case when prefixCols is a prefix of cols (including the case of identical columns)
+func coversIndexCols(cols, prefixCols []*Column) bool {
+	if len(prefixCols) > len(cols) {
+		return false
+	}
+
+	for i, col := range prefixCols {
+		if cols[i].id != col.id {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (t *Table) GetColumnByName(name string) (*Column, error) {
 	col, exists := t.colsByName[name]
 	if !exists {
@@ -237,10 +314,33 @@ func (i *Index) IsUnique() bool {
 	return i.unique
 }
 
+// IsDeferred tells if the uniqueness of the index is checked when the transaction is committed
+func (i *Index) IsDeferred() bool {
+	return i.deferred
+}
+
+// uniqueKeys tells if index entries are keyed by the indexed values only, so the store rejects duplicated values on write.
+// Entries of deferred unique indexes include the primary key, as duplicates may exist until the transaction is committed
+func (i *Index) uniqueKeys() bool {
+	return i.unique && !i.deferred
+}
+
 func (i *Index) Cols() []*Column {
 	return i.cols
 }
 
+// isSortKeyIndex tells if the index is the one created along with the table to sort rows by its sort key
+func (i *Index) isSortKeyIndex() bool {
+	sortCol := i.table.sortCol
+
+	return sortCol != nil &&
+		!i.IsPrimary() &&
+		!i.unique &&
+		len(i.cols) == 1 &&
+		i.cols[0].id == sortCol.id &&
+		i.table.primaryIndex.cols[0].id != sortCol.id
+}
+
 func (i *Index) IncludesCol(colID uint32) bool {
 	_, ok := i.colsByID[colID]
 	return ok
@@ -268,7 +368,7 @@ func (i *Index) prefix() string {
 		return PIndexPrefix
 	}
 
-	if i.IsUnique() {
+	if i.uniqueKeys() {
 		return UIndexPrefix
 	}
 
@@ -304,7 +404,7 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 		return nil, ErrIllegalArguments
 	}
 
-	exists := db.ExistTable(name)
+	exists := db.ExistTable(name) || db.ExistView(name)
 	if exists {
 		return nil, fmt.Errorf("%w (%s)", ErrTableAlreadyExists, name)
 	}
@@ -320,6 +420,8 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 		colsByName:     make(map[string]*Column),
 		indexesByName:  make(map[string]*Index),
 		indexesByColID: make(map[uint32][]*Index),
+		exprColsByID:   make(map[uint32]*Column),
+		exprColsByName: make(map[string]*Column),
 	}
 
 	for i, cs := range colsSpec {
@@ -336,6 +438,13 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 			return nil, ErrLimitedMaxLen
 		}
 
+		if cs.defaultValue != nil {
+			err := validDefaultValue(cs)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		id := len(table.colsByID) + 1
 
 		col := &Column{
@@ -346,6 +455,7 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 			maxLen:        cs.maxLen,
 			autoIncrement: cs.autoIncrement,
 			notNull:       cs.notNull,
+			defaultValue:  cs.defaultValue,
 		}
 
 		table.cols[i] = col
@@ -370,11 +480,15 @@ func (t *Table) newIndex(unique bool, colIDs []uint32) (index *Index, err error)
 	colsByID := make(map[uint32]*Column, len(colIDs))
 
 	for i, colID := range colIDs {
-		col, err := t.GetColumnByID(colID)
+		col, err := t.indexableColumnByID(colID)
 		if err != nil {
 			return nil, err
 		}
 
+		if isArrayType(col.colType) {
+			return nil, fmt.Errorf("%w: array columns can not be indexed (%s)", ErrLimitedKeyType, col.colName)
+		}
+
 		_, ok := colsByID[colID]
 		if ok {
 			return nil, ErrDuplicatedColumn
@@ -413,6 +527,64 @@ func (t *Table) newIndex(unique bool, colIDs []uint32) (index *Index, err error)
 	return index, nil
 }
 
+// indexableColumnByID returns either a column of the table or the virtual column of an indexed expression
+func (t *Table) indexableColumnByID(id uint32) (*Column, error) {
+	col, exists := t.exprColsByID[id]
+	if exists {
+		return col, nil
+	}
+
+	return t.GetColumnByID(id)
+}
+
+// newExprColumn returns the virtual column holding the values of an indexed expression,
+// the column is created if the expression is not yet indexed
+func (t *Table) newExprColumn(text string, expr ValueExp) (*Column, error) {
+	col, exists := t.exprColsByName[text]
+	if exists {
+		return col, nil
+	}
+
+	cols := make(map[string]ColDescriptor, len(t.cols))
+
+	for _, c := range t.cols {
+		encSel := EncodeSelector("", t.db.name, t.name, c.colName)
+		cols[encSel] = ColDescriptor{Database: t.db.name, Table: t.name, Column: c.colName, Type: c.colType}
+	}
+
+	t.db.catalog.bindExpFunctions(expr)
+
+	colType, err := expr.inferType(cols, make(map[string]SQLValueType), t.db.name, t.name)
+	if err != nil {
+		return nil, err
+	}
+
+	if colType == AnyType {
+		return nil, fmt.Errorf("%w: the type of %s can not be inferred", ErrLimitedIndexExpression, text)
+	}
+
+	maxLen := 0
+	if variableSized(colType) {
+		maxLen = maxKeyLen
+	}
+
+	// virtual columns are identified from the end of the id space to never collide with regular ones
+	col = &Column{
+		id:      math.MaxUint32 - uint32(len(t.exprCols)),
+		table:   t,
+		colName: text,
+		colType: colType,
+		maxLen:  maxLen,
+		expr:    expr,
+	}
+
+	t.exprCols = append(t.exprCols, col)
+	t.exprColsByID[col.id] = col
+	t.exprColsByName[col.colName] = col
+
+	return col, nil
+}
+
 func (t *Table) newColumn(spec *ColSpec) (*Column, error) {
 	if spec.autoIncrement {
 		return nil, fmt.Errorf("%w (%s)", ErrLimitedAutoIncrement, spec.colName)
@@ -422,6 +594,11 @@ func (t *Table) newColumn(spec *ColSpec) (*Column, error) {
 		return nil, fmt.Errorf("%w (%s)", ErrNewColumnMustBeNullable, spec.colName)
 	}
 
+	// existent rows would not get the default value
+	if spec.defaultValue != nil {
+		return nil, fmt.Errorf("%w: new column can not have a default value (%s)", ErrInvalidDefaultValue, spec.colName)
+	}
+
 	if !validMaxLenForType(spec.maxLen, spec.colType) {
 		return nil, fmt.Errorf("%w (%s)", ErrLimitedMaxLen, spec.colName)
 	}
@@ -465,6 +642,13 @@ func (t *Table) renameColumn(oldName, newName string) (*Column, error) {
 		return nil, fmt.Errorf("%w (%s)", ErrColumnAlreadyExists, newName)
 	}
 
+	for _, exprCol := range t.exprCols {
+		if exprReferencesColumn(exprCol.expr, oldName) {
+			return nil, fmt.Errorf("%w: column %s is used in the indexed expression %s", ErrLimitedIndexExpression, oldName, exprCol.colName)
+		}
+	}
+
+	// the id of the column is kept, stored rows, index entries and views reference columns by id
 	col.colName = newName
 
 	delete(t.colsByName, oldName)
@@ -515,6 +699,10 @@ func validMaxLenForType(maxLen int, sqlType SQLValueType) bool {
 		return maxLen == 0 || maxLen == 8
 	}
 
+	if isArrayType(sqlType) {
+		return maxLen == 0
+	}
+
 	return maxLen >= 0
 }
 
@@ -558,6 +746,11 @@ func (c *Catalog) load(sqlPrefix []byte, tx *store.OngoingTx) error {
 		if err != nil {
 			return err
 		}
+
+		err = db.loadViews(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -612,11 +805,26 @@ func (db *Database) loadTables(sqlPrefix []byte, tx *store.OngoingTx) error {
 			return ErrCorruptedData
 		}
 
+		err = table.loadColumnDefaults(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
+
+		err = table.loadIndexExprs(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
+
 		err = table.loadIndexes(sqlPrefix, tx)
 		if err != nil {
 			return err
 		}
 
+		err = table.loadSortKey(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
+
 		if table.autoIncrementPK {
 			encMaxPK, err := loadMaxPK(sqlPrefix, tx, table)
 			if err == store.ErrNoMoreEntries {
@@ -724,6 +932,61 @@ func loadColSpecs(dbID, tableID uint32, tx *store.OngoingTx, sqlPrefix []byte) (
 	return
 }
 
+func (table *Table) loadIndexExprs(sqlPrefix []byte, tx *store.OngoingTx) error {
+	exprReaderSpec := store.KeyReaderSpec{
+		Prefix:  mapKey(sqlPrefix, catalogIndexExprPrefix, EncodeID(table.db.id), EncodeID(table.id)),
+		Filters: []store.FilterFn{store.IgnoreExpired, store.IgnoreDeleted},
+		// virtual columns are created with decreasing ids
+		DescOrder: true,
+	}
+
+	exprReader, err := tx.NewKeyReader(exprReaderSpec)
+	if err != nil {
+		return err
+	}
+	defer exprReader.Close()
+
+	for {
+		mkey, vref, err := exprReader.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		dbID, tableID, colID, err := unmapIndexExpr(sqlPrefix, mkey)
+		if err != nil {
+			return err
+		}
+
+		if table.id != tableID || table.db.id != dbID {
+			return ErrCorruptedData
+		}
+
+		v, err := vref.Resolve()
+		if err != nil {
+			return err
+		}
+
+		expr, err := parseIndexExpr(string(v))
+		if err != nil {
+			return ErrCorruptedData
+		}
+
+		col, err := table.newExprColumn(string(v), expr)
+		if err != nil {
+			return err
+		}
+
+		if col.id != colID {
+			return ErrCorruptedData
+		}
+	}
+
+	return nil
+}
+
 func (table *Table) loadIndexes(sqlPrefix []byte, tx *store.OngoingTx) error {
 	initialKey := mapKey(sqlPrefix, catalogIndexPrefix, EncodeID(table.db.id), EncodeID(table.id))
 
@@ -761,7 +1024,7 @@ func (table *Table) loadIndexes(sqlPrefix []byte, tx *store.OngoingTx) error {
 			return err
 		}
 
-		// v={unique {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)}
+		// v={flags {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)}
 		colSpecLen := EncIDLen + 1
 
 		if len(v) < 1+colSpecLen || len(v)%colSpecLen != 1 {
@@ -781,11 +1044,17 @@ func (table *Table) loadIndexes(sqlPrefix []byte, tx *store.OngoingTx) error {
 			colIDs = append(colIDs, colID)
 		}
 
-		index, err := table.newIndex(v[0] > 0, colIDs)
+		if v[0]&^(uniqueIndexFlag|deferredIndexFlag) != 0 {
+			return ErrCorruptedData
+		}
+
+		index, err := table.newIndex(v[0]&uniqueIndexFlag != 0, colIDs)
 		if err != nil {
 			return err
 		}
 
+		index.deferred = v[0]&deferredIndexFlag != 0
+
 		if indexID != index.id {
 			return ErrCorruptedData
 		}
@@ -794,6 +1063,73 @@ func (table *Table) loadIndexes(sqlPrefix []byte, tx *store.OngoingTx) error {
 	return nil
 }
 
+func (table *Table) loadSortKey(sqlPrefix []byte, tx *store.OngoingTx) error {
+	mkey := mapKey(sqlPrefix, catalogSortKeyPrefix, EncodeID(table.db.id), EncodeID(table.id))
+
+	vref, err := tx.GetWithFilters(mkey, store.IgnoreExpired, store.IgnoreDeleted)
+	if err == store.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	v, err := vref.Resolve()
+	if err != nil {
+		return err
+	}
+
+	// v={colID}(ASC|DESC)
+	if len(v) != EncIDLen+1 {
+		return ErrCorruptedData
+	}
+
+	col, err := table.GetColumnByID(binary.BigEndian.Uint32(v))
+	if err != nil {
+		return ErrCorruptedData
+	}
+
+	table.sortCol = col
+	table.sortDesc = v[EncIDLen] > 0
+
+	return nil
+}
+
+// sortingIndex returns the index used to sort rows by the sort key of the table,
+// the primary index is used if the table has no sort key
+func (table *Table) sortingIndex(rangesByColID map[uint32]*typedValueRange) (index *Index, descOrder bool) {
+	if table.sortCol == nil {
+		return table.primaryIndex, false
+	}
+
+	for _, idx := range table.indexesByColID[table.sortCol.id] {
+		if idx.sortableUsing(table.sortCol.id, rangesByColID) {
+			return idx, table.sortDesc
+		}
+	}
+
+	return table.primaryIndex, false
+}
+
+// exprIndexFor returns an index whose leading column is an indexed expression
+// with a constrained range of values, if any
+func (table *Table) exprIndexFor(rangesByColID map[uint32]*typedValueRange) *Index {
+	for _, col := range table.exprCols {
+		_, ranged := rangesByColID[col.id]
+		if !ranged {
+			continue
+		}
+
+		for _, idx := range table.indexesByColID[col.id] {
+			if idx.cols[0].id == col.id {
+				return idx
+			}
+		}
+	}
+
+	return nil
+}
+
 func trimPrefix(prefix, mkey []byte, mappingPrefix []byte) ([]byte, error) {
 	if len(prefix)+len(mappingPrefix) > len(mkey) ||
 		!bytes.Equal(prefix, mkey[:len(prefix)]) ||
@@ -833,6 +1169,22 @@ func unmapTableID(prefix, mkey []byte) (dbID, tableID uint32, err error) {
 	return
 }
 
+func unmapViewID(prefix, mkey []byte) (dbID, viewID uint32, err error) {
+	encID, err := trimPrefix(prefix, mkey, []byte(catalogViewPrefix))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(encID) != EncIDLen*2 {
+		return 0, 0, ErrCorruptedData
+	}
+
+	dbID = binary.BigEndian.Uint32(encID)
+	viewID = binary.BigEndian.Uint32(encID[EncIDLen:])
+
+	return
+}
+
 func unmapColSpec(prefix, mkey []byte) (dbID, tableID, colID uint32, colType SQLValueType, err error) {
 	encID, err := trimPrefix(prefix, mkey, []byte(catalogColumnPrefix))
 	if err != nil {
@@ -856,6 +1208,15 @@ func unmapColSpec(prefix, mkey []byte) (dbID, tableID, colID uint32, colType SQL
 }
 
 func asType(t string) (SQLValueType, error) {
+	if elemType, isArray := arrayElemType(t); isArray {
+		// arrays only hold scalar values
+		if _, err := asType(elemType); err != nil || isArrayType(elemType) {
+			return t, ErrCorruptedData
+		}
+
+		return t, nil
+	}
+
 	if t == IntegerType ||
 		t == BooleanType ||
 		t == VarcharType ||
@@ -884,6 +1245,40 @@ func unmapIndex(sqlPrefix, mkey []byte) (dbID, tableID, indexID uint32, err erro
 	return
 }
 
+func unmapIndexExpr(sqlPrefix, mkey []byte) (dbID, tableID, colID uint32, err error) {
+	encID, err := trimPrefix(sqlPrefix, mkey, []byte(catalogIndexExprPrefix))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	if len(encID) != EncIDLen*3 {
+		return 0, 0, 0, ErrCorruptedData
+	}
+
+	dbID = binary.BigEndian.Uint32(encID)
+	tableID = binary.BigEndian.Uint32(encID[EncIDLen:])
+	colID = binary.BigEndian.Uint32(encID[2*EncIDLen:])
+
+	return
+}
+
+func unmapColumnDefault(sqlPrefix, mkey []byte) (dbID, tableID, colID uint32, err error) {
+	encID, err := trimPrefix(sqlPrefix, mkey, []byte(catalogColumnDefaultPrefix))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	if len(encID) != EncIDLen*3 {
+		return 0, 0, 0, ErrCorruptedData
+	}
+
+	dbID = binary.BigEndian.Uint32(encID)
+	tableID = binary.BigEndian.Uint32(encID[EncIDLen:])
+	colID = binary.BigEndian.Uint32(encID[2*EncIDLen:])
+
+	return
+}
+
 func unmapIndexEntry(index *Index, sqlPrefix, mkey []byte) (encPKVals []byte, err error) {
 	if index == nil {
 		return nil, ErrIllegalArguments
@@ -1084,6 +1479,10 @@ func EncodeValue(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 		}
 	}
 
+	if elemType, isArray := arrayElemType(colType); isArray {
+		return encodeArray(val, elemType)
+	}
+
 	return nil, ErrInvalidValue
 }
 
@@ -1093,6 +1492,12 @@ const (
 	KeyValPrefixUpperBound byte = 0xFF
 )
 
+// nullsFirstInIndexOrder tells if NULL values come first when rows are read in the order of an index,
+// NULL values are encoded lower than any other value in every index thus they are placed last in descending scans
+func nullsFirstInIndexOrder(descOrder bool) bool {
+	return !descOrder
+}
+
 func EncodeAsKey(val interface{}, colType SQLValueType, maxLen int) ([]byte, error) {
 	if maxLen <= 0 {
 		return nil, ErrInvalidValue
@@ -1282,5 +1687,14 @@ func DecodeValue(b []byte, colType SQLValueType) (TypedValue, int, error) {
 		}
 	}
 
+	if elemType, isArray := arrayElemType(colType); isArray {
+		arr, err := decodeArray(b[voff:voff+vlen], elemType)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return arr, voff + vlen, nil
+	}
+
 	return nil, 0, ErrCorruptedData
 }
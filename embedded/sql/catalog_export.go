@@ -0,0 +1,289 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// CatalogExportVersion is the version of the format produced by ExportCatalog
+const CatalogExportVersion = 1
+
+var ErrInvalidCatalogExport = errors.New("invalid catalog export")
+
+// CatalogExport describes the schema of a database without including any data.
+// It can be serialized as JSON and applied to a different database with ImportCatalog
+type CatalogExport struct {
+	Version int            `json:"version"`
+	Tables  []*TableExport `json:"tables"`
+}
+
+type TableExport struct {
+	Name       string          `json:"name"`
+	Columns    []*ColumnExport `json:"columns"`
+	PrimaryKey []string        `json:"primaryKey"`
+	SortKey    *SortKeyExport  `json:"sortKey,omitempty"`
+	Indexes    []*IndexExport  `json:"indexes,omitempty"`
+}
+
+// SortKeyExport describes the column rows are sorted by when no order is specified in a query
+type SortKeyExport struct {
+	Column string `json:"column"`
+	Desc   bool   `json:"desc,omitempty"`
+}
+
+type ColumnExport struct {
+	Name          string       `json:"name"`
+	Type          SQLValueType `json:"type"`
+	MaxLen        int          `json:"maxLen,omitempty"`
+	AutoIncrement bool         `json:"autoIncrement,omitempty"`
+	NotNull       bool         `json:"notNull,omitempty"`
+	// Default is the text of the default value of the column, if any
+	Default string `json:"default,omitempty"`
+}
+
+// IndexExport describes a secondary index, Columns holds either the names of columns
+// or the text of indexed expressions
+type IndexExport struct {
+	Unique   bool     `json:"unique,omitempty"`
+	Deferred bool     `json:"deferred,omitempty"`
+	Columns  []string `json:"columns"`
+}
+
+// ExportCatalog returns the schema of the database selected in the transaction
+func (e *Engine) ExportCatalog(ctx context.Context, tx *SQLTx) (export *CatalogExport, err error) {
+	qtx := tx
+
+	if qtx == nil {
+		qtx, err = e.NewTx(ctx, DefaultTxOptions().WithReadOnly(true))
+		if err != nil {
+			return nil, err
+		}
+		defer qtx.Cancel()
+	}
+
+	if qtx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	export = &CatalogExport{Version: CatalogExportVersion}
+
+	for _, table := range qtx.currentDB.GetTables() {
+		tableExport := &TableExport{Name: table.name}
+
+		for _, col := range table.cols {
+			colExport := &ColumnExport{
+				Name:          col.colName,
+				Type:          col.colType,
+				MaxLen:        col.maxLen,
+				AutoIncrement: col.autoIncrement,
+				NotNull:       col.notNull,
+			}
+
+			if col.defaultValue != nil {
+				colExport.Default, err = columnDefaultText(col.defaultValue)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			tableExport.Columns = append(tableExport.Columns, colExport)
+		}
+
+		if table.sortCol != nil {
+			tableExport.SortKey = &SortKeyExport{
+				Column: table.sortCol.colName,
+				Desc:   table.sortDesc,
+			}
+		}
+
+		for _, index := range table.indexes {
+			colNames := make([]string, len(index.cols))
+			for i, col := range index.cols {
+				colNames[i] = col.colName
+			}
+
+			if index.IsPrimary() {
+				tableExport.PrimaryKey = colNames
+				continue
+			}
+
+			// the index on the sort key is created along with the table when importing
+			if index.isSortKeyIndex() {
+				continue
+			}
+
+			tableExport.Indexes = append(tableExport.Indexes, &IndexExport{
+				Unique:   index.unique,
+				Deferred: index.deferred,
+				Columns:  colNames,
+			})
+		}
+
+		export.Tables = append(export.Tables, tableExport)
+	}
+
+	return export, nil
+}
+
+// ImportCatalog creates the tables and indexes described in the export into the selected database.
+// The export is fully validated before any change is made
+func (e *Engine) ImportCatalog(ctx context.Context, tx *SQLTx, export *CatalogExport) (ntx *SQLTx, committedTxs []*SQLTx, err error) {
+	stmts, err := export.stmts()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return e.ExecPreparedStmts(ctx, tx, stmts, nil)
+}
+
+func (export *CatalogExport) stmts() ([]SQLStmt, error) {
+	if export == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	if export.Version != CatalogExportVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidCatalogExport, export.Version)
+	}
+
+	if len(export.Tables) == 0 {
+		return nil, fmt.Errorf("%w: no tables", ErrInvalidCatalogExport)
+	}
+
+	tables := make(map[string]struct{}, len(export.Tables))
+
+	var stmts []SQLStmt
+
+	for _, table := range export.Tables {
+		if table == nil || table.Name == "" {
+			return nil, fmt.Errorf("%w: unnamed table", ErrInvalidCatalogExport)
+		}
+
+		_, duplicated := tables[table.Name]
+		if duplicated {
+			return nil, fmt.Errorf("%w: table '%s' is defined more than once", ErrInvalidCatalogExport, table.Name)
+		}
+		tables[table.Name] = struct{}{}
+
+		cols := make(map[string]struct{}, len(table.Columns))
+		colsSpec := make([]*ColSpec, len(table.Columns))
+
+		for i, col := range table.Columns {
+			if col == nil || col.Name == "" {
+				return nil, fmt.Errorf("%w: unnamed column in table '%s'", ErrInvalidCatalogExport, table.Name)
+			}
+
+			_, err := asType(col.Type)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unsupported type '%s' for column '%s.%s'", ErrInvalidCatalogExport, col.Type, table.Name, col.Name)
+			}
+
+			cols[col.Name] = struct{}{}
+
+			colsSpec[i] = &ColSpec{
+				colName:       col.Name,
+				colType:       col.Type,
+				maxLen:        col.MaxLen,
+				autoIncrement: col.AutoIncrement,
+				notNull:       col.NotNull,
+			}
+
+			if col.Default != "" {
+				colsSpec[i].defaultValue, err = parseColumnDefault(col.Default)
+				if err != nil {
+					return nil, fmt.Errorf("%w: invalid default value for column '%s.%s'", ErrInvalidCatalogExport, table.Name, col.Name)
+				}
+			}
+		}
+
+		checkCols := func(colNames []string) error {
+			if len(colNames) == 0 {
+				return fmt.Errorf("%w: index without columns in table '%s'", ErrInvalidCatalogExport, table.Name)
+			}
+
+			for _, colName := range colNames {
+				_, exists := cols[colName]
+				if !exists {
+					return fmt.Errorf("%w: column '%s' does not exist in table '%s'", ErrInvalidCatalogExport, colName, table.Name)
+				}
+			}
+
+			return nil
+		}
+
+		err := checkCols(table.PrimaryKey)
+		if err != nil {
+			return nil, err
+		}
+
+		createTableStmt := &CreateTableStmt{
+			table:      table.Name,
+			colsSpec:   colsSpec,
+			pkColNames: table.PrimaryKey,
+		}
+
+		if table.SortKey != nil {
+			err := checkCols([]string{table.SortKey.Column})
+			if err != nil {
+				return nil, err
+			}
+
+			createTableStmt.sortKey = &OrdCol{
+				sel:       &ColSelector{col: table.SortKey.Column},
+				descOrder: table.SortKey.Desc,
+			}
+		}
+
+		stmts = append(stmts, createTableStmt)
+
+		for _, index := range table.Indexes {
+			if index == nil {
+				return nil, fmt.Errorf("%w: undefined index in table '%s'", ErrInvalidCatalogExport, table.Name)
+			}
+
+			if len(index.Columns) == 0 {
+				return nil, fmt.Errorf("%w: index without columns in table '%s'", ErrInvalidCatalogExport, table.Name)
+			}
+
+			elems := make([]ValueExp, len(index.Columns))
+
+			for i, colName := range index.Columns {
+				_, exists := cols[colName]
+				if exists {
+					elems[i] = &ColSelector{col: colName}
+					continue
+				}
+
+				expr, err := parseIndexExpr(colName)
+				if err != nil {
+					return nil, fmt.Errorf("%w: column '%s' does not exist in table '%s'", ErrInvalidCatalogExport, colName, table.Name)
+				}
+
+				elems[i] = expr
+			}
+
+			stmt := newCreateIndexStmt(index.Unique, false, table.Name, elems)
+			stmt.deferred = index.Deferred
+
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	return stmts, nil
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogExportImport(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE customers (
+			id INTEGER AUTO_INCREMENT,
+			name VARCHAR[64] NOT NULL,
+			email VARCHAR[128],
+			active BOOLEAN,
+			PRIMARY KEY id
+		);
+
+		CREATE UNIQUE INDEX ON customers(email);
+		CREATE INDEX ON customers(active, name);
+
+		CREATE TABLE orders (
+			customer_id INTEGER,
+			order_id INTEGER,
+			created_at TIMESTAMP NOT NULL,
+			payload BLOB,
+			PRIMARY KEY (customer_id, order_id)
+		);
+
+		CREATE INDEX ON orders(created_at);
+		CREATE UNIQUE INDEX ON orders(order_id) DEFERRABLE INITIALLY DEFERRED;
+
+		ALTER TABLE orders RENAME COLUMN payload TO content;
+
+		CREATE TABLE events (
+			id INTEGER AUTO_INCREMENT,
+			ts INTEGER,
+			PRIMARY KEY id
+		) SORT BY ts DESC;
+
+		CREATE INDEX ON events(id, ts);
+	`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO customers(name, email) VALUES ('cust1', 'cust1@email.com')", nil)
+	require.NoError(t, err)
+
+	export, err := engine.ExportCatalog(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, CatalogExportVersion, export.Version)
+	require.Len(t, export.Tables, 3)
+
+	require.Equal(t, "customers", export.Tables[0].Name)
+	require.Equal(t, []string{"id"}, export.Tables[0].PrimaryKey)
+	require.Equal(t, []*IndexExport{
+		{Unique: true, Columns: []string{"email"}},
+		{Columns: []string{"active", "name"}},
+	}, export.Tables[0].Indexes)
+
+	require.Equal(t, "orders", export.Tables[1].Name)
+	require.Equal(t, []string{"customer_id", "order_id"}, export.Tables[1].PrimaryKey)
+	require.Equal(t, &ColumnExport{Name: "content", Type: BLOBType}, export.Tables[1].Columns[3])
+	require.Nil(t, export.Tables[1].SortKey)
+	require.Equal(t, []*IndexExport{
+		{Columns: []string{"created_at"}},
+		{Unique: true, Deferred: true, Columns: []string{"order_id"}},
+	}, export.Tables[1].Indexes)
+
+	require.Equal(t, "events", export.Tables[2].Name)
+	require.Equal(t, &SortKeyExport{Column: "ts", Desc: true}, export.Tables[2].SortKey)
+	require.Equal(t, []*IndexExport{
+		{Columns: []string{"id", "ts"}},
+	}, export.Tables[2].Indexes)
+
+	doc, err := json.Marshal(export)
+	require.NoError(t, err)
+
+	t.Run("round-trip should reproduce the schema", func(t *testing.T) {
+		var imported CatalogExport
+
+		err := json.Unmarshal(doc, &imported)
+		require.NoError(t, err)
+
+		engine2 := setupCommonTest(t)
+
+		_, _, err = engine2.ImportCatalog(context.Background(), nil, &imported)
+		require.NoError(t, err)
+
+		export2, err := engine2.ExportCatalog(context.Background(), nil)
+		require.NoError(t, err)
+		require.Equal(t, export, export2)
+
+		catalog, err := engine2.Catalog(context.Background(), nil)
+		require.NoError(t, err)
+
+		table, err := catalog.GetTableByName("db1", "events")
+		require.NoError(t, err)
+		require.Equal(t, "ts", table.sortCol.colName)
+		require.True(t, table.sortDesc)
+		require.Len(t, table.indexes, 3)
+
+		r, err := engine2.Query(context.Background(), nil, "SELECT COUNT(*) FROM customers", nil)
+		require.NoError(t, err)
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(0), row.ValuesByPosition[0].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("import into a database with conflicting tables should fail", func(t *testing.T) {
+		_, _, err := engine.ImportCatalog(context.Background(), nil, export)
+		require.ErrorIs(t, err, ErrTableAlreadyExists)
+	})
+
+	t.Run("invalid exports should be rejected", func(t *testing.T) {
+		engine2 := setupCommonTest(t)
+
+		_, _, err := engine2.ImportCatalog(context.Background(), nil, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		invalidExports := []string{
+			`{"version": 2, "tables": [{"name": "t1", "columns": [{"name": "id", "type": "INTEGER"}], "primaryKey": ["id"]}]}`,
+			`{"version": 1, "tables": []}`,
+			`{"version": 1, "tables": [{"columns": [{"name": "id", "type": "INTEGER"}], "primaryKey": ["id"]}]}`,
+			`{"version": 1, "tables": [{"name": "t1", "columns": [{"name": "id", "type": "INTEGER"}], "primaryKey": ["id"]}, {"name": "t1", "columns": [{"name": "id", "type": "INTEGER"}], "primaryKey": ["id"]}]}`,
+			`{"version": 1, "tables": [{"name": "t1", "columns": [{"type": "INTEGER"}], "primaryKey": ["id"]}]}`,
+			`{"version": 1, "tables": [{"name": "t1", "columns": [{"name": "id", "type": "FLOAT"}], "primaryKey": ["id"]}]}`,
+			`{"version": 1, "tables": [{"name": "t1", "columns": [{"name": "id", "type": "INTEGER"}]}]}`,
+			`{"version": 1, "tables": [{"name": "t1", "columns": [{"name": "id", "type": "INTEGER"}], "primaryKey": ["pk"]}]}`,
+			`{"version": 1, "tables": [{"name": "t1", "columns": [{"name": "id", "type": "INTEGER"}], "primaryKey": ["id"], "indexes": [null]}]}`,
+			`{"version": 1, "tables": [{"name": "t1", "columns": [{"name": "id", "type": "INTEGER"}], "primaryKey": ["id"], "indexes": [{"columns": ["title"]}]}]}`,
+			`{"version": 1, "tables": [{"name": "t1", "columns": [{"name": "id", "type": "INTEGER"}], "primaryKey": ["id"], "sortKey": {"column": "ts"}}]}`,
+		}
+
+		for _, doc := range invalidExports {
+			var export CatalogExport
+
+			err := json.Unmarshal([]byte(doc), &export)
+			require.NoError(t, err)
+
+			_, _, err = engine2.ImportCatalog(context.Background(), nil, &export)
+			require.ErrorIs(t, err, ErrInvalidCatalogExport, doc)
+		}
+
+		catalog, err := engine2.Catalog(context.Background(), nil)
+		require.NoError(t, err)
+
+		db, err := catalog.GetDatabaseByName("db1")
+		require.NoError(t, err)
+		require.Empty(t, db.GetTables())
+	})
+}
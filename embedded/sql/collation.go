@@ -0,0 +1,100 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Collation defines how values are compared when sorting rows
+type Collation string
+
+const (
+	// BinaryCollation compares values as they are stored, which is the order of indexes
+	BinaryCollation Collation = "BINARY"
+	// NoCaseCollation compares strings regardless of the case of their letters
+	NoCaseCollation Collation = "NOCASE"
+)
+
+// collationByName returns the collation named in a statement, names are case-insensitive
+func collationByName(name string) Collation {
+	return Collation(strings.ToUpper(name))
+}
+
+func (c Collation) validFor(t SQLValueType) error {
+	switch c {
+	case BinaryCollation:
+		return nil
+	case NoCaseCollation:
+		if t != VarcharType && t != AnyType {
+			return fmt.Errorf("%w: collation %s only applies to %s values", ErrInvalidTypes, c, VarcharType)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w (%s)", ErrUnknownCollation, c)
+}
+
+// compare compares two values according to the collation, NULL values come first
+func (c Collation) compare(v1, v2 TypedValue) (int, error) {
+	if v1.IsNull() || v2.IsNull() {
+		switch {
+		case v1.IsNull() && v2.IsNull():
+			return 0, nil
+		case v1.IsNull():
+			return -1, nil
+		default:
+			return 1, nil
+		}
+	}
+
+	if c == NoCaseCollation {
+		s1, ok1 := v1.Value().(string)
+		s2, ok2 := v2.Value().(string)
+
+		if !ok1 || !ok2 {
+			return 0, ErrNotComparableValues
+		}
+
+		return strings.Compare(strings.ToLower(s1), strings.ToLower(s2)), nil
+	}
+
+	return v1.Compare(v2)
+}
+
+// NullsOrder places NULL values either before or after any other value when sorting rows,
+// regardless of the direction of the order
+type NullsOrder string
+
+const (
+	NullsFirst NullsOrder = "FIRST"
+	NullsLast  NullsOrder = "LAST"
+)
+
+// nullsOrderByName returns the placement of NULL values named in a statement, names are case-insensitive
+func nullsOrderByName(name string) NullsOrder {
+	return NullsOrder(strings.ToUpper(name))
+}
+
+func (n NullsOrder) valid() error {
+	if n == "" || n == NullsFirst || n == NullsLast {
+		return nil
+	}
+
+	return fmt.Errorf("%w: unknown placement of NULL values (%s)", ErrIllegalArguments, n)
+}
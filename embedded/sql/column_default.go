@@ -0,0 +1,155 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// columnDefaultText returns the canonical text of the default value of a column.
+// Only constants and the functions returning the current time can be used as default values,
+// the latter are evaluated when each row is inserted
+func columnDefaultText(exp ValueExp) (string, error) {
+	switch e := exp.(type) {
+	case *Number, *Varchar, *Bool, *NullValue:
+		{
+			var b strings.Builder
+
+			_, err := writeIndexExpr(&b, e, nil, "")
+			if err != nil {
+				return "", err
+			}
+
+			return b.String(), nil
+		}
+	case *FnCall:
+		{
+			name := strings.ToUpper(e.fn)
+
+			if len(e.params) > 0 {
+				break
+			}
+
+			switch name {
+			case NowFnCall:
+				return NowFnCall + "()", nil
+			case CurrentDateFnCall, CurrentTimeFnCall, CurrentTimestampFnCall:
+				return name, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("%w: only constants and the current time can be used", ErrInvalidDefaultValue)
+}
+
+// parseColumnDefault parses back the canonical text of a default value
+func parseColumnDefault(text string) (ValueExp, error) {
+	stmts, err := ParseString(fmt.Sprintf("CREATE TABLE t (c INTEGER DEFAULT %s, PRIMARY KEY c)", text))
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, ok := stmts[0].(*CreateTableStmt)
+	if !ok || len(stmts) != 1 || len(stmt.colsSpec) != 1 || stmt.colsSpec[0].defaultValue == nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDefaultValue, text)
+	}
+
+	return stmt.colsSpec[0].defaultValue, nil
+}
+
+func validDefaultValue(spec *ColSpec) error {
+	if spec.autoIncrement {
+		return fmt.Errorf("%w: auto-incremental column can not have a default value (%s)", ErrInvalidDefaultValue, spec.colName)
+	}
+
+	_, err := columnDefaultText(spec.defaultValue)
+	if err != nil {
+		return fmt.Errorf("%w (%s)", err, spec.colName)
+	}
+
+	err = spec.defaultValue.requiresType(spec.colType, make(map[string]ColDescriptor), nil, "", "")
+	if err != nil {
+		return fmt.Errorf("%w: %v (%s)", ErrInvalidDefaultValue, err, spec.colName)
+	}
+
+	return nil
+}
+
+func persistColumnDefault(col *Column, tx *SQLTx) error {
+	text, err := columnDefaultText(col.defaultValue)
+	if err != nil {
+		return err
+	}
+
+	mappedKey := mapKey(tx.sqlPrefix(), catalogColumnDefaultPrefix, EncodeID(col.table.db.id), EncodeID(col.table.id), EncodeID(col.id))
+
+	return tx.set(mappedKey, nil, []byte(text))
+}
+
+func (table *Table) loadColumnDefaults(sqlPrefix []byte, tx *store.OngoingTx) error {
+	defaultReaderSpec := store.KeyReaderSpec{
+		Prefix:  mapKey(sqlPrefix, catalogColumnDefaultPrefix, EncodeID(table.db.id), EncodeID(table.id)),
+		Filters: []store.FilterFn{store.IgnoreExpired, store.IgnoreDeleted},
+	}
+
+	defaultReader, err := tx.NewKeyReader(defaultReaderSpec)
+	if err != nil {
+		return err
+	}
+	defer defaultReader.Close()
+
+	for {
+		mkey, vref, err := defaultReader.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		dbID, tableID, colID, err := unmapColumnDefault(sqlPrefix, mkey)
+		if err != nil {
+			return err
+		}
+
+		if table.id != tableID || table.db.id != dbID {
+			return ErrCorruptedData
+		}
+
+		v, err := vref.Resolve()
+		if err != nil {
+			return err
+		}
+
+		defaultValue, err := parseColumnDefault(string(v))
+		if err != nil {
+			return ErrCorruptedData
+		}
+
+		col, err := table.GetColumnByID(colID)
+		if err != nil {
+			return ErrCorruptedData
+		}
+
+		col.defaultValue = defaultValue
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnDefaults(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	t.Cleanup(func() { closeStore(t, st) })
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE DATABASE db1;
+		USE DATABASE db1;
+	`, nil)
+	require.NoError(t, err)
+
+	t.Run("invalid default values are rejected", func(t *testing.T) {
+		for _, colSpec := range []string{
+			"v INTEGER DEFAULT 'one'",
+			"v TIMESTAMP DEFAULT CURRENT_TIME",
+			"v VARCHAR DEFAULT UPPER('a')",
+			"v VARCHAR DEFAULT @v",
+			"v INTEGER DEFAULT DEFAULT",
+		} {
+			_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE invalid (id INTEGER, "+colSpec+", PRIMARY KEY id)", nil)
+			require.ErrorIs(t, err, ErrInvalidDefaultValue, colSpec)
+		}
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE invalid (id INTEGER AUTO_INCREMENT DEFAULT 1, PRIMARY KEY id)", nil)
+		require.ErrorIs(t, err, ErrInvalidDefaultValue)
+	})
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE TABLE orders (
+			id INTEGER AUTO_INCREMENT,
+			status VARCHAR[16] NOT NULL DEFAULT 'new',
+			qty INTEGER DEFAULT 1,
+			notes VARCHAR DEFAULT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			created_on TIMESTAMP DEFAULT CURRENT_DATE,
+			created_time VARCHAR DEFAULT CURRENT_TIME,
+			updated_at TIMESTAMP DEFAULT NOW(),
+			PRIMARY KEY id
+		)`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE orders ADD COLUMN price INTEGER DEFAULT 0", nil)
+	require.ErrorIs(t, err, ErrInvalidDefaultValue)
+
+	queryRow := func(t *testing.T, engine *Engine, id int64) []TypedValue {
+		r, err := engine.Query(context.Background(), nil,
+			"SELECT status, qty, notes, created_at, created_on, created_time, updated_at FROM orders WHERE id = @id",
+			map[string]interface{}{"id": id})
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		return row.ValuesByPosition
+	}
+
+	t.Run("default values are assigned to unspecified columns", func(t *testing.T) {
+		before := time.Now().UTC().Add(-time.Second)
+
+		_, _, err := engine.Exec(context.Background(), nil, "INSERT INTO orders(qty) VALUES (5)", nil)
+		require.NoError(t, err)
+
+		row := queryRow(t, engine, 1)
+		require.Equal(t, "new", row[0].Value())
+		require.Equal(t, int64(5), row[1].Value())
+		require.True(t, row[2].IsNull())
+
+		createdAt := row[3].Value().(time.Time)
+		require.True(t, createdAt.After(before))
+		require.Equal(t, createdAt, row[6].Value())
+		require.Equal(t, time.Date(createdAt.Year(), createdAt.Month(), createdAt.Day(), 0, 0, 0, 0, time.UTC), row[4].Value())
+		require.Equal(t, createdAt.Format("15:04:05.000000"), row[5].Value())
+	})
+
+	t.Run("default values are assigned to columns specified as DEFAULT", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "INSERT INTO orders(status, qty, created_at) VALUES (DEFAULT, DEFAULT, DEFAULT), ('paid', 2, NULL)", nil)
+		require.NoError(t, err)
+
+		row := queryRow(t, engine, 2)
+		require.Equal(t, "new", row[0].Value())
+		require.Equal(t, int64(1), row[1].Value())
+		require.False(t, row[3].IsNull())
+
+		row = queryRow(t, engine, 3)
+		require.Equal(t, "paid", row[0].Value())
+		require.Equal(t, int64(2), row[1].Value())
+		require.True(t, row[3].IsNull())
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO orders(status) VALUES (NULL)", nil)
+		require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
+	})
+
+	t.Run("default values are loaded with the catalog", func(t *testing.T) {
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		err = engine.SetCurrentDatabase(context.Background(), "db1")
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "UPSERT INTO orders(id, notes) VALUES (10, 'upserted')", nil)
+		require.NoError(t, err)
+
+		row := queryRow(t, engine, 10)
+		require.Equal(t, "new", row[0].Value())
+		require.Equal(t, int64(1), row[1].Value())
+		require.Equal(t, "upserted", row[2].Value())
+		require.False(t, row[3].IsNull())
+
+		export, err := engine.ExportCatalog(context.Background(), nil)
+		require.NoError(t, err)
+
+		defaults := make(map[string]string)
+		for _, col := range export.Tables[0].Columns {
+			defaults[col.Name] = col.Default
+		}
+
+		require.Equal(t, map[string]string{
+			"id":           "",
+			"status":       "'new'",
+			"qty":          "1",
+			"notes":        "NULL",
+			"created_at":   "CURRENT_TIMESTAMP",
+			"created_on":   "CURRENT_DATE",
+			"created_time": "CURRENT_TIME",
+			"updated_at":   "NOW()",
+		}, defaults)
+
+		stmts, err := export.stmts()
+		require.NoError(t, err)
+		require.Equal(t, &Varchar{val: "new"}, stmts[0].(*CreateTableStmt).colsSpec[1].defaultValue)
+		require.Equal(t, &FnCall{fn: CurrentTimestampFnCall}, stmts[0].(*CreateTableStmt).colsSpec[4].defaultValue)
+	})
+}
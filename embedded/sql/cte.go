@@ -0,0 +1,108 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+// CTE is a named subquery defined in the WITH clause of a SELECT statement,
+// e.g. `WITH recent AS (SELECT ...) SELECT ... FROM recent`.
+// A CTE can be referenced as a data source by the statement, including its joins and subqueries,
+// and by the CTEs defined after it. A CTE shadows any table of the same name.
+//
+// CTEs are inlined when the statement is parsed: every reference is resolved as the subquery it names,
+// thus a CTE referenced several times is evaluated once per reference.
+// Recursive CTEs are not supported, a CTE referencing its own name refers to the table of that name
+type CTE struct {
+	name string
+	ds   DataSource
+}
+
+// inlineCTEs replaces the references to the CTEs of the statement with the subqueries they name
+func (stmt *SelectStmt) inlineCTEs() {
+	for i, cte := range stmt.ctes {
+		// a CTE can only reference the ones defined before it
+		cte.ds = inlineCTEs(cte.ds, stmt.ctes[:i])
+	}
+
+	if len(stmt.ctes) > 0 {
+		stmt.inlineCTEsInSources(stmt.ctes)
+	}
+}
+
+func (stmt *SelectStmt) inlineCTEsInSources(ctes []*CTE) {
+	stmt.ds = inlineCTEs(stmt.ds, ctes)
+
+	for _, join := range stmt.joins {
+		join.ds = inlineCTEs(join.ds, ctes)
+	}
+}
+
+func inlineCTEs(ds DataSource, ctes []*CTE) DataSource {
+	switch ds := ds.(type) {
+	case *tableRef:
+		{
+			if ds.db != "" || ds.history || ds.sample != nil || ds.period.start != nil || ds.period.end != nil {
+				return ds
+			}
+
+			// the last definition of a name prevails
+			for i := len(ctes) - 1; i >= 0; i-- {
+				if ctes[i].name == ds.table {
+					return aliasedDataSource(ctes[i].ds, ds.Alias())
+				}
+			}
+		}
+	case *SelectStmt:
+		ds.inlineCTEsInSources(ctes)
+	case *UnionStmt:
+		{
+			ds.left = inlineCTEs(ds.left, ctes)
+			ds.right = inlineCTEs(ds.right, ctes)
+		}
+	case *SetOpStmt:
+		{
+			ds.left = inlineCTEs(ds.left, ctes)
+			ds.right = inlineCTEs(ds.right, ctes)
+		}
+	}
+
+	return ds
+}
+
+// aliasedDataSource returns a copy of the subquery of a CTE named as it's referenced
+func aliasedDataSource(ds DataSource, as string) DataSource {
+	switch ds := ds.(type) {
+	case *SelectStmt:
+		{
+			aliased := *ds
+			aliased.as = as
+			return &aliased
+		}
+	case *UnionStmt:
+		{
+			aliased := *ds
+			aliased.as = as
+			return &aliased
+		}
+	case *SetOpStmt:
+		{
+			aliased := *ds
+			aliased.as = as
+			return &aliased
+		}
+	}
+
+	return ds
+}
@@ -26,6 +26,8 @@ type distinctRowReader struct {
 	cols      []ColDescriptor
 
 	readRows map[[sha256.Size]byte]struct{}
+
+	mem *memoryAccount
 }
 
 func newDistinctRowReader(ctx context.Context, rowReader RowReader) (*distinctRowReader, error) {
@@ -38,6 +40,7 @@ func newDistinctRowReader(ctx context.Context, rowReader RowReader) (*distinctRo
 		rowReader: rowReader,
 		cols:      cols,
 		readRows:  make(map[[sha256.Size]byte]struct{}),
+		mem:       memoryAccountFrom(ctx),
 	}, nil
 }
 
@@ -106,6 +109,11 @@ func (dr *distinctRowReader) Read(ctx context.Context) (*Row, error) {
 			continue
 		}
 
+		err = dr.mem.reserve(rowDigestMemSize)
+		if err != nil {
+			return nil, err
+		}
+
 		dr.readRows[digest] = struct{}{}
 
 		return row, nil
@@ -113,5 +121,8 @@ func (dr *distinctRowReader) Read(ctx context.Context) (*Row, error) {
 }
 
 func (dr *distinctRowReader) Close() error {
+	dr.mem.release(int64(len(dr.readRows)) * rowDigestMemSize)
+	dr.readRows = nil
+
 	return dr.rowReader.Close()
 }
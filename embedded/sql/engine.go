@@ -24,6 +24,7 @@ import (
 	"io"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/codenotary/immudb/embedded/store"
 )
@@ -82,11 +83,24 @@ var ErrMissingParameter = errors.New("missing parameter")
 var ErrUnsupportedParameter = errors.New("unsupported parameter")
 var ErrDuplicatedParameters = errors.New("duplicated parameters")
 var ErrLimitedIndexCreation = errors.New("index creation is only supported on empty tables")
+var ErrNoDefaultValue = errors.New("column has no default value")
+var ErrLimitedIndexExpression = errors.New("index expressions may only use columns, constants and deterministic functions")
 var ErrTooManyRows = errors.New("too many rows")
 var ErrAlreadyClosed = store.ErrAlreadyClosed
 var ErrAmbiguousSelector = errors.New("ambiguous selector")
 var ErrUnsupportedCast = errors.New("unsupported cast")
 var ErrColumnMismatchInUnionStmt = errors.New("column mismatch in union statement")
+var ErrColumnMismatchInSetOpStmt = errors.New("column mismatch in set operation")
+var ErrQueryTooComplex = errors.New("query too complex")
+var ErrMemoryLimitExceeded = errors.New("query memory limit exceeded")
+var ErrUnknownCollation = errors.New("unknown collation")
+var ErrRedundantIndex = errors.New("redundant index")
+var ErrInvalidRegexp = errors.New("invalid regular expression")
+var ErrInvalidDefaultValue = errors.New("invalid default value")
+var ErrAmbiguousUpdate = errors.New("row to be updated matches more than one source row")
+var ErrUniqueConstraintViolation = errors.New("unique constraint violation")
+var ErrViewAlreadyExists = errors.New("view already exists")
+var ErrLimitedView = errors.New("views are limited to queries listing the columns of a single table")
 
 var maxKeyLen = 256
 
@@ -102,6 +116,17 @@ type Engine struct {
 	distinctLimit int
 	autocommit    bool
 
+	maxJoinedSources int
+	maxJoinRows      int64
+
+	queryMemoryLimit int64
+
+	rejectRedundantIndexes bool
+
+	// functions holds the functions registered in addition to the builtin ones, the map is
+	// replaced on each registration so transactions can keep using the one they started with
+	functions map[string]*Function
+
 	currentDatabase string
 
 	multidbHandler MultiDBHandler
@@ -127,10 +152,20 @@ func NewEngine(store *store.ImmuStore, opts *Options) (*Engine, error) {
 	}
 
 	e := &Engine{
-		store:         store,
-		prefix:        make([]byte, len(opts.prefix)),
-		distinctLimit: opts.distinctLimit,
-		autocommit:    opts.autocommit,
+		store:            store,
+		prefix:           make([]byte, len(opts.prefix)),
+		distinctLimit:    opts.distinctLimit,
+		autocommit:       opts.autocommit,
+		maxJoinedSources: opts.maxJoinedSources,
+		maxJoinRows:      opts.maxJoinRows,
+		queryMemoryLimit: opts.queryMemoryLimit,
+
+		rejectRedundantIndexes: opts.rejectRedundantIndexes,
+		functions:              make(map[string]*Function, len(opts.functions)),
+	}
+
+	for name, fn := range opts.functions {
+		e.functions[strings.ToUpper(name)] = fn
 	}
 
 	copy(e.prefix, opts.prefix)
@@ -202,7 +237,7 @@ func (e *Engine) NewTx(ctx context.Context, opts *TxOptions) (*SQLTx, error) {
 		return nil, err
 	}
 
-	catalog := newCatalog()
+	catalog := newCatalog(e.functions)
 
 	err = catalog.load(e.prefix, tx)
 	if err != nil {
@@ -319,6 +354,14 @@ func (e *Engine) execPreparedStmts(ctx context.Context, tx *SQLTx, stmts []SQLSt
 			}
 		}
 
+		err = currTx.inlineViews(stmt)
+		if err != nil {
+			currTx.Cancel()
+			return nil, committedTxs, stmts[execStmts:], err
+		}
+
+		currTx.catalog.bindFunctions(stmt)
+
 		ntx, err := stmt.execAt(ctx, currTx, nparams)
 		if err != nil {
 			currTx.Cancel()
@@ -362,6 +405,27 @@ func (e *Engine) execPreparedStmts(ctx context.Context, tx *SQLTx, stmts []SQLSt
 }
 
 func (e *Engine) Query(ctx context.Context, tx *SQLTx, sql string, params map[string]interface{}) (RowReader, error) {
+	stmt, err := parseQuery(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.QueryPreparedStmt(ctx, tx, stmt, params)
+}
+
+// QueryWithStats executes a query as Query does, measuring its execution, e.g. the number of rows scanned
+// and whether each table was fully scanned or not. The returned statistics are updated as rows are read
+// and are complete once the row reader is closed
+func (e *Engine) QueryWithStats(ctx context.Context, tx *SQLTx, sql string, params map[string]interface{}) (RowReader, *QueryStats, error) {
+	stmt, err := parseQuery(sql)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return e.QueryPreparedStmtWithStats(ctx, tx, stmt, params)
+}
+
+func parseQuery(sql string) (DataSource, error) {
 	stmts, err := Parse(strings.NewReader(sql))
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrParsingError, err)
@@ -375,10 +439,26 @@ func (e *Engine) Query(ctx context.Context, tx *SQLTx, sql string, params map[st
 		return nil, ErrExpectingDQLStmt
 	}
 
-	return e.QueryPreparedStmt(ctx, tx, stmt, params)
+	return stmt, nil
 }
 
 func (e *Engine) QueryPreparedStmt(ctx context.Context, tx *SQLTx, stmt DataSource, params map[string]interface{}) (rowReader RowReader, err error) {
+	return e.queryPreparedStmt(ctx, tx, stmt, params, nil)
+}
+
+// QueryPreparedStmtWithStats executes a prepared query collecting execution statistics, see QueryWithStats
+func (e *Engine) QueryPreparedStmtWithStats(ctx context.Context, tx *SQLTx, stmt DataSource, params map[string]interface{}) (RowReader, *QueryStats, error) {
+	stats := &QueryStats{startedAt: time.Now()}
+
+	r, err := e.queryPreparedStmt(ctx, tx, stmt, params, stats)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &statsRowReader{RowReader: r, stats: stats}, stats, nil
+}
+
+func (e *Engine) queryPreparedStmt(ctx context.Context, tx *SQLTx, stmt DataSource, params map[string]interface{}, stats *QueryStats) (rowReader RowReader, err error) {
 	if stmt == nil {
 		return nil, ErrIllegalArguments
 	}
@@ -402,11 +482,21 @@ func (e *Engine) QueryPreparedStmt(ctx context.Context, tx *SQLTx, stmt DataSour
 		return nil, err
 	}
 
+	err = qtx.inlineViews(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	qtx.catalog.bindFunctions(stmt)
+
 	_, err = stmt.execAt(ctx, qtx, nparams)
 	if err != nil {
 		return nil, err
 	}
 
+	ctx = withMemoryAccount(ctx, newMemoryAccount(e.queryMemoryLimit))
+	ctx = withQueryStats(ctx, stats)
+
 	r, err := stmt.Resolve(ctx, qtx, nparams, nil)
 	if err != nil {
 		return nil, err
@@ -462,6 +552,13 @@ func (e *Engine) InferParametersPreparedStmts(ctx context.Context, tx *SQLTx, st
 	params = make(map[string]SQLValueType)
 
 	for _, stmt := range stmts {
+		err = qtx.inlineViews(stmt)
+		if err != nil {
+			return nil, err
+		}
+
+		qtx.catalog.bindFunctions(stmt)
+
 		err = stmt.inferParameters(ctx, qtx, params)
 		if err != nil {
 			return nil, err
@@ -493,7 +590,7 @@ func (e *Engine) CopyCatalogToTx(ctx context.Context, tx *store.OngoingTx) error
 	e.mutex.RLock()
 	defer e.mutex.RUnlock()
 
-	catalog := newCatalog()
+	catalog := newCatalog(e.functions)
 	err := catalog.addSchemaToTx(e.prefix, tx)
 	if err != nil {
 		return err
@@ -552,6 +649,25 @@ func (t *Table) addIndexesToTx(sqlPrefix []byte, tx *store.OngoingTx) error {
 	return nil
 }
 
+func (t *Table) addSortKeyToTx(sqlPrefix []byte, tx *store.OngoingTx) error {
+	mkey := mapKey(sqlPrefix, catalogSortKeyPrefix, EncodeID(t.db.id), EncodeID(t.id))
+
+	vref, err := tx.GetWithFilters(mkey, store.IgnoreExpired, store.IgnoreDeleted)
+	if err == store.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	v, err := vref.Resolve()
+	if err != nil {
+		return err
+	}
+
+	return tx.Set(mkey, nil, v)
+}
+
 // addSchemaToTx adds the schema of the catalog to the given transaction.
 func (d *Database) addTablesToTx(sqlPrefix []byte, tx *store.OngoingTx) error {
 	dbReaderSpec := store.KeyReaderSpec{
@@ -617,6 +733,12 @@ func (d *Database) addTablesToTx(sqlPrefix []byte, tx *store.OngoingTx) error {
 			return err
 		}
 
+		// read sort key into tx
+		err = table.addSortKeyToTx(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
+
 	}
 
 	return nil
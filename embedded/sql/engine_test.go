@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
@@ -157,6 +158,46 @@ func TestCreateTable(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestFailedDDLLeavesCatalogUnchanged(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE table1 (id INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	exportBefore, err := engine.ExportCatalog(context.Background(), nil)
+	require.NoError(t, err)
+
+	for _, ddl := range []string{
+		// the table is added to the catalog before its primary key is found missing
+		"CREATE TABLE table2 (name VARCHAR[32], PRIMARY KEY id)",
+		// columns are persisted before the auto-incremental one is found not to be the primary key
+		"CREATE TABLE table2 (id INTEGER, counter INTEGER AUTO_INCREMENT, PRIMARY KEY id)",
+		// the table is created before its index fails
+		"CREATE TABLE table2 (id INTEGER, data BLOB, PRIMARY KEY id); CREATE INDEX ON table2(data)",
+		"BEGIN TRANSACTION; CREATE TABLE table2 (id INTEGER, PRIMARY KEY id); CREATE INDEX ON table2(missing); COMMIT;",
+		"ALTER TABLE table1 ADD COLUMN id INTEGER",
+	} {
+		_, _, err = engine.Exec(context.Background(), nil, ddl, nil)
+		require.Error(t, err, ddl)
+
+		exportAfter, err := engine.ExportCatalog(context.Background(), nil)
+		require.NoError(t, err)
+		require.Equal(t, exportBefore, exportAfter, ddl)
+	}
+
+	// the failed statements did not leave any partial change behind
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table2 (id INTEGER, name VARCHAR, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	catalog, err := engine.Catalog(context.Background(), nil)
+	require.NoError(t, err)
+
+	table2, err := catalog.GetTableByName("db1", "table2")
+	require.NoError(t, err)
+	require.Len(t, table2.Cols(), 2)
+	require.Equal(t, uint32(2), table2.ID())
+}
+
 func TestTimestampType(t *testing.T) {
 	engine := setupCommonTest(t)
 
@@ -681,6 +722,191 @@ func TestCreateIndex(t *testing.T) {
 	require.Equal(t, ErrLimitedIndexCreation, err)
 }
 
+func TestDeferredUniqueIndex(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE table1 (id INTEGER, code INTEGER, PRIMARY KEY id);
+		CREATE UNIQUE INDEX ON table1(code) DEFERRABLE INITIALLY DEFERRED;
+
+		CREATE TABLE table2 (id INTEGER, code INTEGER, PRIMARY KEY id);
+		CREATE UNIQUE INDEX ON table2(code) DEFERRABLE INITIALLY IMMEDIATE;
+
+		INSERT INTO table1(id, code) VALUES (1, 1), (2, 2);
+		INSERT INTO table2(id, code) VALUES (1, 1), (2, 2);
+	`, nil)
+	require.NoError(t, err)
+
+	catalog, err := engine.Catalog(context.Background(), nil)
+	require.NoError(t, err)
+
+	table1, err := catalog.GetTableByName("db1", "table1")
+	require.NoError(t, err)
+	require.True(t, table1.indexes[1].IsUnique())
+	require.True(t, table1.indexes[1].IsDeferred())
+
+	table2, err := catalog.GetTableByName("db1", "table2")
+	require.NoError(t, err)
+	require.True(t, table2.indexes[1].IsUnique())
+	require.False(t, table2.indexes[1].IsDeferred())
+
+	queryID := func(t *testing.T, table string, code int) int64 {
+		r, err := engine.Query(context.Background(), nil, fmt.Sprintf("SELECT id FROM %s USE INDEX ON (code) WHERE code = %d", table, code), nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		return row.ValuesByPosition[0].Value().(int64)
+	}
+
+	t.Run("values may be duplicated until the transaction is committed", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, `
+			BEGIN TRANSACTION;
+				UPDATE table1 SET code = 2 WHERE id = 1;
+				UPDATE table1 SET code = 1 WHERE id = 2;
+			COMMIT;
+		`, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, int64(2), queryID(t, "table1", 1))
+		require.Equal(t, int64(1), queryID(t, "table1", 2))
+	})
+
+	t.Run("duplicated values should be rejected when committing", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "INSERT INTO table1(id, code) VALUES (3, 1)", nil)
+		require.ErrorIs(t, err, ErrUniqueConstraintViolation)
+		require.ErrorContains(t, err, "table1")
+
+		_, _, err = engine.Exec(context.Background(), nil, `
+			BEGIN TRANSACTION;
+				UPDATE table1 SET code = 3 WHERE id = 1;
+				INSERT INTO table1(id, code) VALUES (3, 1);
+			COMMIT;
+		`, nil)
+		require.ErrorIs(t, err, ErrUniqueConstraintViolation)
+
+		require.Equal(t, int64(2), queryID(t, "table1", 1))
+		require.Equal(t, int64(1), queryID(t, "table1", 2))
+	})
+
+	t.Run("deleted values should be reusable within the transaction", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, `
+			BEGIN TRANSACTION;
+				INSERT INTO table1(id, code) VALUES (3, 1);
+				DELETE FROM table1 WHERE id = 2;
+			COMMIT;
+		`, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, int64(3), queryID(t, "table1", 1))
+	})
+
+	t.Run("immediate unique indexes should reject duplicated values on write", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, `
+			BEGIN TRANSACTION;
+				UPDATE table2 SET code = 2 WHERE id = 1;
+				UPDATE table2 SET code = 1 WHERE id = 2;
+			COMMIT;
+		`, nil)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+		require.Equal(t, int64(1), queryID(t, "table2", 1))
+		require.Equal(t, int64(2), queryID(t, "table2", 2))
+	})
+
+	t.Run("only unique indexes can be deferred", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "CREATE INDEX ON table1(id, code) DEFERRABLE INITIALLY DEFERRED", nil)
+		require.ErrorIs(t, err, ErrParsingError)
+	})
+}
+
+func TestRedundantIndexes(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	setup := func(t *testing.T, opts *Options) *Engine {
+		engine, err := NewEngine(st, opts.WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE DATABASE IF NOT EXISTS db1; USE DATABASE db1;", nil)
+		require.NoError(t, err)
+
+		return engine
+	}
+
+	t.Run("redundant indexes should be reported as warnings", func(t *testing.T) {
+		engine := setup(t, DefaultOptions())
+
+		_, ctxs, err := engine.Exec(context.Background(), nil, `
+			CREATE TABLE table1 (id INTEGER, name VARCHAR[64], age INTEGER, active BOOLEAN, PRIMARY KEY id);
+			CREATE INDEX ON table1(name, age);
+		`, nil)
+		require.NoError(t, err)
+		require.Len(t, ctxs, 1)
+		require.Empty(t, ctxs[0].Warnings())
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON table1(name, age)", nil)
+		require.ErrorIs(t, err, ErrIndexAlreadyExists)
+
+		_, ctxs, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON table1(name)", nil)
+		require.NoError(t, err)
+		require.Len(t, ctxs, 1)
+		require.Len(t, ctxs[0].Warnings(), 1)
+		require.ErrorIs(t, ctxs[0].Warnings()[0], ErrRedundantIndex)
+
+		_, ctxs, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON table1(age, name)", nil)
+		require.NoError(t, err)
+		require.Empty(t, ctxs[0].Warnings())
+
+		_, ctxs, err = engine.Exec(context.Background(), nil, "CREATE UNIQUE INDEX ON table1(age)", nil)
+		require.NoError(t, err)
+		require.Empty(t, ctxs[0].Warnings())
+
+		catalog, err := engine.Catalog(context.Background(), nil)
+		require.NoError(t, err)
+
+		table, err := catalog.GetTableByName("db1", "table1")
+		require.NoError(t, err)
+
+		redundant := table.RedundantIndexes()
+		require.Len(t, redundant, 1)
+		require.Equal(t, "table1[name]", redundant[0].Index.Name())
+		require.Equal(t, "table1[name,age]", redundant[0].CoveredBy.Name())
+	})
+
+	t.Run("redundant indexes should be rejected when enabled", func(t *testing.T) {
+		engine := setup(t, DefaultOptions().WithRejectRedundantIndexes(true))
+
+		_, _, err := engine.Exec(context.Background(), nil, `
+			CREATE TABLE table2 (id INTEGER, name VARCHAR[64], age INTEGER, PRIMARY KEY (id, name));
+			CREATE INDEX ON table2(age, name);
+		`, nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON table2(age)", nil)
+		require.ErrorIs(t, err, ErrRedundantIndex)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON table2(id)", nil)
+		require.ErrorIs(t, err, ErrRedundantIndex)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON table2(name)", nil)
+		require.NoError(t, err)
+
+		catalog, err := engine.Catalog(context.Background(), nil)
+		require.NoError(t, err)
+
+		table, err := catalog.GetTableByName("db1", "table2")
+		require.NoError(t, err)
+		require.Empty(t, table.RedundantIndexes())
+	})
+}
+
 func TestUpsertInto(t *testing.T) {
 	st, err := store.Open(t.TempDir(), store.DefaultOptions())
 	require.NoError(t, err)
@@ -978,6 +1204,76 @@ func TestAutoIncrementPK(t *testing.T) {
 	require.Equal(t, 2, ctxs[0].UpdatedRows())
 }
 
+func TestInsertDefaultValues(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE table1 (
+			id INTEGER AUTO_INCREMENT,
+			title VARCHAR,
+			active BOOLEAN,
+			PRIMARY KEY id
+		)`, nil)
+	require.NoError(t, err)
+
+	_, ctxs, err := engine.Exec(context.Background(), nil, `
+		INSERT INTO table1(id, title, active)
+		VALUES
+			(DEFAULT, 'title1', true),
+			(DEFAULT, @title, NULL),
+			(DEFAULT, 'title3', DEFAULT)
+	`, map[string]interface{}{"title": "title2"})
+	require.ErrorIs(t, err, ErrNoDefaultValue)
+	require.Empty(t, ctxs)
+
+	_, ctxs, err = engine.Exec(context.Background(), nil, `
+		INSERT INTO table1(id, title, active)
+		VALUES
+			(DEFAULT, 'title1', true),
+			(DEFAULT, @title, NULL)
+	`, map[string]interface{}{"title": "title2"})
+	require.NoError(t, err)
+	require.Len(t, ctxs, 1)
+	require.Equal(t, int64(1), ctxs[0].FirstInsertedPKs()["table1"])
+	require.Equal(t, int64(2), ctxs[0].LastInsertedPKs()["table1"])
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1(title) VALUES ('title3')", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1(id, active) VALUES (DEFAULT, false)", nil)
+	require.NoError(t, err)
+
+	r, err := engine.Query(context.Background(), nil, "SELECT id, title, active FROM table1", nil)
+	require.NoError(t, err)
+	defer r.Close()
+
+	expected := [][]interface{}{
+		{int64(1), "title1", true},
+		{int64(2), "title2", nil},
+		{int64(3), "title3", nil},
+		{int64(4), nil, false},
+	}
+
+	for _, e := range expected {
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		for i, v := range e {
+			require.Equal(t, v, row.ValuesByPosition[i].Value())
+		}
+	}
+
+	_, err = r.Read(context.Background())
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	r, err = engine.Query(context.Background(), nil, "SELECT id FROM table1 WHERE id = DEFAULT", nil)
+	require.NoError(t, err)
+	defer r.Close()
+
+	_, err = r.Read(context.Background())
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
+
 func TestDelete(t *testing.T) {
 	st, err := store.Open(t.TempDir(), store.DefaultOptions())
 	require.NoError(t, err)
@@ -1193,6 +1489,108 @@ func TestUpdate(t *testing.T) {
 	})
 }
 
+func TestUpdateFrom(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE orders (id INTEGER, status VARCHAR, amount INTEGER, PRIMARY KEY id);
+		CREATE TABLE source (id INTEGER AUTO_INCREMENT, order_id INTEGER, status VARCHAR, PRIMARY KEY id);
+
+		INSERT INTO orders (id, status, amount) VALUES (1, 'pending', 10), (2, 'pending', 20), (3, 'pending', 30);
+		INSERT INTO source (order_id, status) VALUES (1, 'shipped'), (3, 'cancelled'), (4, 'shipped');
+	`, nil)
+	require.NoError(t, err)
+
+	assertStatuses := func(t *testing.T, expected map[int64]string) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id, status FROM orders", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		statuses := make(map[int64]string)
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			statuses[row.ValuesByPosition[0].Value().(int64)] = row.ValuesByPosition[1].Value().(string)
+		}
+
+		require.Equal(t, expected, statuses)
+	}
+
+	t.Run("rows should be updated from the matching source row", func(t *testing.T) {
+		_, ctxs, err := engine.Exec(context.Background(), nil, "UPDATE orders o SET o.status = s.status FROM source s WHERE o.id = s.order_id", nil)
+		require.NoError(t, err)
+		require.Len(t, ctxs, 1)
+		require.Equal(t, 2, ctxs[0].UpdatedRows())
+
+		assertStatuses(t, map[int64]string{1: "shipped", 2: "pending", 3: "cancelled"})
+	})
+
+	t.Run("source columns should be usable in expressions and parameters inferred", func(t *testing.T) {
+		params, err := engine.InferParameters(context.Background(), nil,
+			"UPDATE orders SET status = s.status || @suffix FROM source s WHERE orders.id = s.order_id AND s.status = 'shipped'")
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"suffix": VarcharType}, params)
+
+		_, ctxs, err := engine.Exec(context.Background(), nil,
+			"UPDATE orders SET status = s.status || @suffix FROM source s WHERE orders.id = s.order_id AND s.status = 'shipped'",
+			map[string]interface{}{"suffix": "!"})
+		require.NoError(t, err)
+		require.Equal(t, 1, ctxs[0].UpdatedRows())
+
+		assertStatuses(t, map[int64]string{1: "shipped!", 2: "pending", 3: "cancelled"})
+	})
+
+	t.Run("updated columns should belong to the updated table", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "UPDATE orders o SET s.status = 'lost' FROM source s WHERE o.id = s.order_id", nil)
+		require.ErrorIs(t, err, ErrInvalidColumn)
+	})
+
+	t.Run("a row matching more than one source row should not be updated", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "INSERT INTO source (order_id, status) VALUES (2, 'shipped'), (2, 'cancelled')", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "UPDATE orders o SET status = s.status FROM source s WHERE o.id = s.order_id", nil)
+		require.ErrorIs(t, err, ErrAmbiguousUpdate)
+
+		assertStatuses(t, map[int64]string{1: "shipped!", 2: "pending", 3: "cancelled"})
+	})
+
+	t.Run("indexed columns should be updated on every matching row", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, `
+			CREATE TABLE s (id INTEGER, v INTEGER, PRIMARY KEY id);
+			CREATE TABLE t2 (id INTEGER AUTO_INCREMENT, a INTEGER, PRIMARY KEY id);
+			CREATE INDEX ON t2(a);
+
+			INSERT INTO s (id, v) VALUES (1, 100), (2, 200);
+			INSERT INTO t2 (a) VALUES (1), (2), (3), (4);
+		`, nil)
+		require.NoError(t, err)
+
+		_, ctxs, err := engine.Exec(context.Background(), nil, "UPDATE t2 SET a = s.v FROM s WHERE t2.id = s.id", nil)
+		require.NoError(t, err)
+		require.Equal(t, 2, ctxs[0].UpdatedRows())
+
+		r, err := engine.Query(context.Background(), nil, "SELECT id, a FROM t2 USE INDEX ON (a)", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		for _, expected := range [][]int64{{3, 3}, {4, 4}, {1, 100}, {2, 200}} {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, expected[0], row.ValuesByPosition[0].Value())
+			require.Equal(t, expected[1], row.ValuesByPosition[1].Value())
+		}
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+}
+
 func TestTransactions(t *testing.T) {
 	engine := setupCommonTest(t)
 
@@ -2881,46 +3279,311 @@ func TestOrderBy(t *testing.T) {
 	require.NoError(t, err)
 }
 
-func TestQueryWithRowFiltering(t *testing.T) {
+func TestOrderByCollation(t *testing.T) {
 	engine := setupCommonTest(t)
 
-	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE table1 (id INTEGER, title VARCHAR, active BOOLEAN, payload BLOB, PRIMARY KEY id)", nil)
-	require.NoError(t, err)
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE people (id INTEGER AUTO_INCREMENT, name VARCHAR[50], nick VARCHAR[50], age INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON people(name);
 
-	rowCount := 10
+		INSERT INTO people(name, nick, age) VALUES ('bob', 'bob', 30), ('Alice', 'Alice', 25), ('carol', 'carol', 41), ('Bob', 'Bob', 52), ('alice', 'alice', 19);
+	`, nil)
+	require.NoError(t, err)
 
-	for i := 0; i < rowCount; i++ {
-		encPayload := hex.EncodeToString([]byte(fmt.Sprintf("blob%d", i)))
-		_, _, err = engine.Exec(
-			context.Background(), nil,
-			fmt.Sprintf(`
-			UPSERT INTO table1 (id, title, active, payload) VALUES (%d, 'title%d', %v, x'%s')
-		`, i, i, i%2 == 0, encPayload), nil)
+	queryNames := func(t *testing.T, q string) []string {
+		r, err := engine.Query(context.Background(), nil, q, nil)
 		require.NoError(t, err)
-	}
-
-	r, err := engine.Query(context.Background(), nil, "SELECT id, title, active FROM table1 WHERE false", nil)
-	require.NoError(t, err)
+		defer r.Close()
 
-	_, err = r.Read(context.Background())
-	require.Equal(t, ErrNoMoreRows, err)
+		var names []string
 
-	err = r.Close()
-	require.NoError(t, err)
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
 
-	r, err = engine.Query(context.Background(), nil, "SELECT id, title, active FROM table1 WHERE false OR true", nil)
-	require.NoError(t, err)
+			names = append(names, row.ValuesByPosition[0].Value().(string))
+		}
 
-	for i := 0; i < rowCount; i++ {
-		_, err := r.Read(context.Background())
-		require.NoError(t, err)
+		return names
 	}
 
-	err = r.Close()
-	require.NoError(t, err)
-
-	r, err = engine.Query(context.Background(), nil, "SELECT id, title, active FROM table1 WHERE 1 < 2", nil)
-	require.NoError(t, err)
+	t.Run("binary order should be case-sensitive", func(t *testing.T) {
+		require.Equal(t,
+			[]string{"Alice", "Bob", "alice", "bob", "carol"},
+			queryNames(t, "SELECT name FROM people ORDER BY name"),
+		)
+
+		require.Equal(t,
+			[]string{"Alice", "Bob", "alice", "bob", "carol"},
+			queryNames(t, "SELECT name FROM people ORDER BY name COLLATE BINARY"),
+		)
+	})
+
+	t.Run("nocase order should ignore the case of letters", func(t *testing.T) {
+		require.Equal(t,
+			[]string{"Alice", "alice", "bob", "Bob", "carol"},
+			queryNames(t, "SELECT name FROM people ORDER BY name COLLATE NOCASE"),
+		)
+
+		require.Equal(t,
+			[]string{"carol", "bob", "Bob", "Alice", "alice"},
+			queryNames(t, "SELECT name FROM people ORDER BY name COLLATE NOCASE DESC"),
+		)
+	})
+
+	t.Run("nocase order should not require an index", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT nick FROM people ORDER BY nick", nil)
+		require.ErrorIs(t, err, ErrLimitedOrderBy)
+
+		require.Equal(t,
+			[]string{"alice", "bob", "carol"},
+			queryNames(t, "SELECT nick FROM people WHERE age < 50 AND nick = LOWER(nick) ORDER BY nick COLLATE nocase"),
+		)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT nick FROM people ORDER BY nick COLLATE NOCASE", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		orderBy := r.OrderBy()
+		require.Len(t, orderBy, 1)
+		require.Equal(t, "nick", orderBy[0].Column)
+	})
+
+	t.Run("invalid collations should be rejected", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT name FROM people ORDER BY name COLLATE UTF8", nil)
+		require.ErrorIs(t, err, ErrUnknownCollation)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT name FROM people ORDER BY age COLLATE NOCASE", nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT name, COUNT(*) FROM people GROUP BY name ORDER BY name COLLATE NOCASE", nil)
+		require.ErrorIs(t, err, ErrLimitedGroupBy)
+	})
+}
+
+func TestOrderByNulls(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE people (id INTEGER AUTO_INCREMENT, name VARCHAR[50], age INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON people(age);
+
+		INSERT INTO people(name, age) VALUES ('bob', 30), ('alice', NULL), ('carol', 41), ('dave', NULL), ('erin', 19);
+	`, nil)
+	require.NoError(t, err)
+
+	queryNames := func(t *testing.T, q string) []string {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var names []string
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			names = append(names, row.ValuesByPosition[0].Value().(string))
+		}
+
+		return names
+	}
+
+	t.Run("nulls should follow index order by default", func(t *testing.T) {
+		require.Equal(t,
+			[]string{"alice", "dave", "erin", "bob", "carol"},
+			queryNames(t, "SELECT name FROM people ORDER BY age"),
+		)
+
+		require.Equal(t,
+			[]string{"carol", "bob", "erin", "dave", "alice"},
+			queryNames(t, "SELECT name FROM people ORDER BY age DESC"),
+		)
+	})
+
+	t.Run("nulls placed as in index order should be read from the index", func(t *testing.T) {
+		require.Equal(t,
+			[]string{"alice", "dave", "erin", "bob", "carol"},
+			queryNames(t, "SELECT name FROM people ORDER BY age ASC NULLS FIRST"),
+		)
+
+		require.Equal(t,
+			[]string{"carol", "bob", "erin", "dave", "alice"},
+			queryNames(t, "SELECT name FROM people ORDER BY age DESC NULLS LAST"),
+		)
+
+		require.False(t, (&OrdCol{nulls: NullsFirst}).sortedInMemory())
+		require.False(t, (&OrdCol{nulls: NullsLast, descOrder: true}).sortedInMemory())
+	})
+
+	t.Run("nulls placed against index order should be sorted in memory", func(t *testing.T) {
+		require.Equal(t,
+			[]string{"erin", "bob", "carol", "alice", "dave"},
+			queryNames(t, "SELECT name FROM people ORDER BY age NULLS LAST"),
+		)
+
+		require.Equal(t,
+			[]string{"alice", "dave", "carol", "bob", "erin"},
+			queryNames(t, "SELECT name FROM people ORDER BY age DESC nulls first"),
+		)
+
+		require.True(t, (&OrdCol{nulls: NullsLast}).sortedInMemory())
+		require.True(t, (&OrdCol{nulls: NullsFirst, descOrder: true}).sortedInMemory())
+	})
+
+	t.Run("nulls should be placed under a collation", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "UPSERT INTO people(id, name, age) VALUES (2, NULL, 25)", nil)
+		require.NoError(t, err)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT name FROM people ORDER BY name COLLATE NOCASE NULLS LAST", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var names []interface{}
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			names = append(names, row.ValuesByPosition[0].Value())
+		}
+
+		require.Equal(t, []interface{}{"bob", "carol", "dave", "erin", nil}, names)
+	})
+
+	t.Run("invalid placements of nulls should be rejected", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT name FROM people ORDER BY age NULLS MIDDLE", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT age, COUNT(*) FROM people GROUP BY age ORDER BY age NULLS LAST", nil)
+		require.ErrorIs(t, err, ErrLimitedGroupBy)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT age, COUNT(*) FROM people GROUP BY age ORDER BY age NULLS FIRST", nil)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+	})
+}
+
+func TestRegexpMatching(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE codes (id INTEGER AUTO_INCREMENT, code VARCHAR[20], PRIMARY KEY id);
+		CREATE INDEX ON codes(code);
+
+		INSERT INTO codes(code) VALUES ('A1'), ('abc7'), ('B22'), ('Zeta'), ('b3'), (NULL);
+	`, nil)
+	require.NoError(t, err)
+
+	queryCodes := func(t *testing.T, q string, params map[string]interface{}) []string {
+		r, err := engine.Query(context.Background(), nil, q, params)
+		require.NoError(t, err)
+		defer r.Close()
+
+		require.Empty(t, r.ScanSpecs().rangesByColID)
+
+		var codes []string
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			codes = append(codes, row.ValuesByPosition[0].Value().(string))
+		}
+
+		return codes
+	}
+
+	t.Run("matching rows should be returned", func(t *testing.T) {
+		require.Equal(t,
+			[]string{"A1", "B22"},
+			queryCodes(t, "SELECT code FROM codes WHERE code REGEXP '^[A-Z].*[0-9]$'", nil),
+		)
+
+		require.Equal(t,
+			[]string{"A1", "abc7", "B22", "b3"},
+			queryCodes(t, "SELECT code FROM codes WHERE code IREGEXP @pattern", map[string]interface{}{"pattern": "^[a-z].*[0-9]$"}),
+		)
+
+		require.Equal(t,
+			[]string{"Zeta"},
+			queryCodes(t, "SELECT code FROM codes USE INDEX ON (code) WHERE code MATCH 'e'", nil),
+		)
+	})
+
+	t.Run("non-matching rows should be returned when negated", func(t *testing.T) {
+		require.Equal(t,
+			[]string{"abc7", "Zeta", "b3"},
+			queryCodes(t, "SELECT code FROM codes WHERE code NOT REGEXP '^[A-Z].*[0-9]$'", nil),
+		)
+
+		require.Empty(t, queryCodes(t, "SELECT code FROM codes WHERE code REGEXP '^[0-9]+$'", nil))
+	})
+
+	t.Run("invalid patterns should be rejected before reading rows", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT code FROM codes WHERE code REGEXP '[A-Z'", nil)
+		require.ErrorIs(t, err, ErrInvalidRegexp)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT code FROM codes WHERE code REGEXP @pattern", map[string]interface{}{"pattern": "(a"})
+		require.ErrorIs(t, err, ErrInvalidRegexp)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT code FROM codes WHERE code REGEXP 10", nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+}
+
+func TestQueryWithRowFiltering(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE table1 (id INTEGER, title VARCHAR, active BOOLEAN, payload BLOB, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		encPayload := hex.EncodeToString([]byte(fmt.Sprintf("blob%d", i)))
+		_, _, err = engine.Exec(
+			context.Background(), nil,
+			fmt.Sprintf(`
+			UPSERT INTO table1 (id, title, active, payload) VALUES (%d, 'title%d', %v, x'%s')
+		`, i, i, i%2 == 0, encPayload), nil)
+		require.NoError(t, err)
+	}
+
+	r, err := engine.Query(context.Background(), nil, "SELECT id, title, active FROM table1 WHERE false", nil)
+	require.NoError(t, err)
+
+	_, err = r.Read(context.Background())
+	require.Equal(t, ErrNoMoreRows, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.Query(context.Background(), nil, "SELECT id, title, active FROM table1 WHERE false OR true", nil)
+	require.NoError(t, err)
+
+	for i := 0; i < rowCount; i++ {
+		_, err := r.Read(context.Background())
+		require.NoError(t, err)
+	}
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.Query(context.Background(), nil, "SELECT id, title, active FROM table1 WHERE 1 < 2", nil)
+	require.NoError(t, err)
 
 	for i := 0; i < rowCount; i++ {
 		_, err := r.Read(context.Background())
@@ -3131,6 +3794,112 @@ func TestQueryWithInClause(t *testing.T) {
 		err = r.Close()
 		require.NoError(t, err)
 	})
+
+	t.Run("in clause should expand list parameters of different lengths using the same prepared statement", func(t *testing.T) {
+		stmts, err := Parse(strings.NewReader("SELECT id FROM table1 WHERE active AND id IN (@ids)"))
+		require.NoError(t, err)
+		require.Len(t, stmts, 1)
+
+		stmt := stmts[0].(DataSource)
+
+		for _, ids := range [][]int64{{0}, {2, 4, 5, 9}, {1, 3, 6, 8, 0, 2}, {}} {
+			r, err := engine.QueryPreparedStmt(context.Background(), nil, stmt, map[string]interface{}{"ids": ids})
+			require.NoError(t, err)
+
+			var readIDs []int64
+
+			for {
+				row, err := r.Read(context.Background())
+				if errors.Is(err, ErrNoMoreRows) {
+					break
+				}
+				require.NoError(t, err)
+
+				readIDs = append(readIDs, row.ValuesBySelector[EncodeSelector("", "db1", "table1", "id")].Value().(int64))
+			}
+
+			err = r.Close()
+			require.NoError(t, err)
+
+			var expectedIDs []int64
+			for i := int64(0); i < int64(rowCount); i++ {
+				for _, id := range ids {
+					if id == i && i%2 == 0 {
+						expectedIDs = append(expectedIDs, i)
+					}
+				}
+			}
+
+			require.Equal(t, expectedIDs, readIDs)
+		}
+	})
+
+	t.Run("not in clause with an empty list parameter should match every row", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM table1 WHERE id NOT IN (@ids)", map[string]interface{}{"ids": []int{}})
+		require.NoError(t, err)
+
+		for i := 0; i < rowCount; i++ {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, int64(i), row.ValuesBySelector[EncodeSelector("", "db1", "table1", "id")].Value())
+		}
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("in clause with list parameter mixed with other values should succeed", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM table1 WHERE title IN ('title1', @titles)", map[string]interface{}{"titles": []string{"title3"}})
+		require.NoError(t, err)
+
+		for _, id := range []int64{1, 3} {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, id, row.ValuesBySelector[EncodeSelector("", "db1", "table1", "id")].Value())
+		}
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("in clause with list parameter of mixed types should return an error", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM table1 WHERE id IN (@ids)", map[string]interface{}{"ids": []interface{}{1, "title1"}})
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("in clause with list parameter of unsupported values should return an error", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM table1 WHERE id IN (@ids)", map[string]interface{}{"ids": []float64{1.5}})
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrUnsupportedParameter)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("in clause with list parameter of a different type than the column should return an error", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM table1 WHERE id IN (@ids)", map[string]interface{}{"ids": []string{"1"}})
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNotComparableValues)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
 }
 
 func TestAggregations(t *testing.T) {
@@ -3219,24 +3988,468 @@ func TestAggregations(t *testing.T) {
 	require.NoError(t, err)
 }
 
-func TestCount(t *testing.T) {
+func TestMultipleAggregationsPerGroup(t *testing.T) {
 	engine := setupCommonTest(t)
 
-	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE t1(id INTEGER AUTO_INCREMENT, val1 INTEGER, PRIMARY KEY id)", nil)
-	require.NoError(t, err)
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE sales (
+			id INTEGER AUTO_INCREMENT,
+			region VARCHAR[16],
+			amount INTEGER,
+			price INTEGER,
+			created_at TIMESTAMP,
+			PRIMARY KEY id
+		);
 
-	_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON t1(val1)", nil)
+		CREATE INDEX ON sales(region);
+	`, nil)
 	require.NoError(t, err)
 
-	for i := 0; i < 10; i++ {
-		for j := 0; j < 3; j++ {
-			_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO t1(val1) VALUES($1)", map[string]interface{}{"param1": j})
-			require.NoError(t, err)
-		}
+	ts := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	rows := []struct {
+		region    string
+		amount    interface{}
+		price     interface{}
+		createdAt interface{}
+	}{
+		{"eu", 10, 100, ts},
+		{"us", 5, 50, ts.Add(day)},
+		{"eu", 20, 300, ts.Add(3 * day)},
+		{"asia", nil, nil, nil},
+		{"us", 7, 70, ts.Add(2 * day)},
+		{"eu", 30, 200, ts.Add(2 * day)},
+		{"us", nil, 90, nil},
+		{"asia", nil, nil, nil},
 	}
 
-	r, err := engine.Query(context.Background(), nil, "SELECT COUNT(*) as c FROM t1", nil)
-	require.NoError(t, err)
+	for _, r := range rows {
+		_, _, err = engine.Exec(context.Background(), nil,
+			"INSERT INTO sales(region, amount, price, created_at) VALUES (@region, @amount, @price, @created_at)",
+			map[string]interface{}{"region": r.region, "amount": r.amount, "price": r.price, "created_at": r.createdAt},
+		)
+		require.NoError(t, err)
+	}
+
+	r, err := engine.Query(context.Background(), nil, `
+		SELECT region, COUNT(*), SUM(amount), AVG(price), MAX(created_at), MIN(amount)
+		FROM sales
+		GROUP BY region
+		ORDER BY region`, nil)
+	require.NoError(t, err)
+	defer r.Close()
+
+	cols, err := r.Columns(context.Background())
+	require.NoError(t, err)
+	require.Len(t, cols, 6)
+	require.Equal(t, VarcharType, cols[0].Type)
+	require.Equal(t, IntegerType, cols[1].Type)
+	require.Equal(t, IntegerType, cols[2].Type)
+	require.Equal(t, IntegerType, cols[3].Type)
+	require.Equal(t, TimestampType, cols[4].Type)
+	require.Equal(t, IntegerType, cols[5].Type)
+
+	// NULL values are not aggregated, a group with only NULL values has no MIN nor MAX
+	expected := [][]interface{}{
+		{"asia", int64(2), int64(0), int64(0), nil, nil},
+		{"eu", int64(3), int64(60), int64(200), ts.Add(3 * day), int64(10)},
+		{"us", int64(3), int64(12), int64(70), ts.Add(2 * day), int64(5)},
+	}
+
+	for _, values := range expected {
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Len(t, row.ValuesByPosition, len(values))
+
+		for i, v := range values {
+			require.Equal(t, v, row.ValuesByPosition[i].Value(), "region %s, column %d", values[0], i)
+			require.Equal(t, v == nil, row.ValuesByPosition[i].IsNull())
+		}
+	}
+
+	_, err = r.Read(context.Background())
+	require.ErrorIs(t, err, ErrNoMoreRows)
+}
+
+func TestGroupByOrdinal(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE sales (
+			id INTEGER AUTO_INCREMENT,
+			region VARCHAR[16],
+			amount INTEGER,
+			PRIMARY KEY id
+		);
+
+		CREATE INDEX ON sales(region);
+
+		INSERT INTO sales(region, amount) VALUES ('eu', 10), ('us', 5), ('eu', 20), ('asia', 1), ('us', 7);
+	`, nil)
+	require.NoError(t, err)
+
+	queryRows := func(t *testing.T, q string) [][]interface{} {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var rows [][]interface{}
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			values := make([]interface{}, len(row.ValuesByPosition))
+			for i, v := range row.ValuesByPosition {
+				values[i] = v.Value()
+			}
+
+			rows = append(rows, values)
+		}
+
+		return rows
+	}
+
+	t.Run("grouping by ordinal should match grouping by name", func(t *testing.T) {
+		expected := [][]interface{}{
+			{"asia", int64(1), int64(1)},
+			{"eu", int64(2), int64(30)},
+			{"us", int64(2), int64(12)},
+		}
+
+		require.Equal(t, expected, queryRows(t, "SELECT region, COUNT(*), SUM(amount) FROM sales GROUP BY region ORDER BY region"))
+		require.Equal(t, expected, queryRows(t, "SELECT region, COUNT(*), SUM(amount) FROM sales GROUP BY 1 ORDER BY region"))
+		require.Equal(t, expected, queryRows(t, "SELECT s.region AS r, COUNT(*), SUM(amount) FROM sales s GROUP BY 1 ORDER BY region"))
+	})
+
+	t.Run("ordinals should refer to selected columns", func(t *testing.T) {
+		for _, q := range []string{
+			"SELECT region, COUNT(*) FROM sales GROUP BY 0 ORDER BY region",
+			"SELECT region, COUNT(*) FROM sales GROUP BY 3 ORDER BY region",
+			"SELECT * FROM sales GROUP BY 1",
+		} {
+			_, err := engine.Query(context.Background(), nil, q, nil)
+			require.ErrorIs(t, err, ErrIllegalArguments, q)
+		}
+
+		_, err := engine.Query(context.Background(), nil, "SELECT region, COUNT(*) FROM sales GROUP BY 2 ORDER BY region", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+		require.NotErrorIs(t, err, ErrLimitedGroupBy)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT region, amount, COUNT(*) FROM sales GROUP BY 1, 2 ORDER BY region", nil)
+		require.ErrorIs(t, err, ErrLimitedGroupBy)
+	})
+}
+
+func TestBitwiseOperators(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE items (
+			id INTEGER,
+			flags INTEGER,
+			PRIMARY KEY id
+		);
+
+		INSERT INTO items(id, flags) VALUES (1, 5), (2, 6), (3, -8);
+	`, nil)
+	require.NoError(t, err)
+
+	queryValue := func(t *testing.T, exp string) int64 {
+		r, err := engine.Query(context.Background(), nil, "SELECT "+exp+" FROM items WHERE id = 3", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		return row.ValuesByPosition[0].Value().(int64)
+	}
+
+	t.Run("bitwise operators should follow two's complement semantics", func(t *testing.T) {
+		testCases := []struct {
+			exp      string
+			expected int64
+		}{
+			{"12 & 10", 8},
+			{"12 | 10", 14},
+			{"12 ^ 10", 6},
+			{"~12", -13},
+			{"~0", -1},
+			{"~flags", 7},
+			{"flags & 12", 8},
+			{"flags | 3", -5},
+			{"flags ^ -1", 7},
+			{"1 << 3", 8},
+			{"1 << 63", math.MinInt64},
+			{"flags >> 1", -4},
+			{"flags >> 2 << 2", -8},
+			{"1 | 2 ^ 3 & 4 << 1", 3},
+			{"(1 | 2) ^ 3", 0},
+			{"~1 + 1", -1},
+		}
+
+		for _, tc := range testCases {
+			require.Equal(t, tc.expected, queryValue(t, tc.exp), tc.exp)
+		}
+	})
+
+	t.Run("shift amounts of 64 or more should shift out every bit", func(t *testing.T) {
+		require.Zero(t, queryValue(t, "1 << 64"))
+		require.Zero(t, queryValue(t, "flags << 100"))
+		require.Zero(t, queryValue(t, "8 >> 64"))
+		require.Equal(t, int64(-1), queryValue(t, "flags >> 64"))
+	})
+
+	t.Run("negative shift amounts should be rejected", func(t *testing.T) {
+		for _, exp := range []string{"1 << -1", "flags >> (0 - 2)"} {
+			r, err := engine.Query(context.Background(), nil, "SELECT "+exp+" FROM items", nil)
+			require.NoError(t, err)
+
+			_, err = r.Read(context.Background())
+			require.ErrorIs(t, err, ErrIllegalArguments)
+
+			r.Close()
+		}
+	})
+
+	t.Run("bitwise operators should be NULL when any operand is NULL", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, `
+			CREATE TABLE nullable_items (
+				id INTEGER,
+				flags INTEGER,
+				PRIMARY KEY id
+			);
+
+			INSERT INTO nullable_items(id, flags) VALUES (1, 4), (2, NULL), (3, 5);
+		`, nil)
+		require.NoError(t, err)
+
+		for _, exp := range []string{"flags & 4", "flags | 4", "4 ^ flags", "~flags", "flags << 1", "1 >> flags", "id << NULL"} {
+			r, err := engine.Query(context.Background(), nil, "SELECT "+exp+" FROM nullable_items WHERE id = 2", nil)
+			require.NoError(t, err)
+
+			row, err := r.Read(context.Background())
+			require.NoError(t, err, exp)
+			require.True(t, row.ValuesByPosition[0].IsNull(), exp)
+			require.Equal(t, IntegerType, row.ValuesByPosition[0].Type(), exp)
+
+			r.Close()
+		}
+
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM nullable_items WHERE flags & 4 = 4", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var ids []int64
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.ValuesByPosition[0].Value().(int64))
+		}
+
+		require.Equal(t, []int64{1, 3}, ids)
+	})
+
+	t.Run("bitwise operators should be usable as conditions", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM items WHERE flags & 4 != 0", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var ids []int64
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.ValuesByPosition[0].Value().(int64))
+		}
+
+		require.Equal(t, []int64{1, 2}, ids)
+	})
+
+	t.Run("bitwise operators should be restricted to integers", func(t *testing.T) {
+		_, err := engine.InferParameters(context.Background(), nil, "SELECT id FROM items WHERE flags & @mask != 0")
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE texts (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil)
+		require.NoError(t, err)
+
+		_, err = engine.InferParameters(context.Background(), nil, "SELECT id FROM texts WHERE title & 1 != 0")
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT 'a' | 1 FROM items", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrInvalidValue)
+	})
+}
+
+func TestGroupingSets(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE sales (
+			id INTEGER AUTO_INCREMENT,
+			region VARCHAR[16],
+			product VARCHAR[16],
+			amount INTEGER,
+			PRIMARY KEY id
+		);
+
+		CREATE INDEX ON sales(product);
+
+		INSERT INTO sales(region, product, amount) VALUES
+			('eu', 'book', 10),
+			('us', 'pen', 5),
+			('eu', 'pen', 20),
+			('us', 'pen', 7),
+			(NULL, 'book', 1);
+	`, nil)
+	require.NoError(t, err)
+
+	queryRows := func(t *testing.T, q string) [][]interface{} {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var rows [][]interface{}
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			values := make([]interface{}, len(row.ValuesByPosition))
+			for i, v := range row.ValuesByPosition {
+				values[i] = v.Value()
+			}
+
+			rows = append(rows, values)
+		}
+
+		return rows
+	}
+
+	t.Run("cube should aggregate all the combinations of the grouping columns", func(t *testing.T) {
+		rows := queryRows(t, `
+			SELECT region, product, GROUPING(region), GROUPING(product), COUNT(*), SUM(amount)
+			FROM sales
+			GROUP BY CUBE(region, product)`)
+
+		require.Equal(t, [][]interface{}{
+			// (region, product)
+			{nil, "book", int64(0), int64(0), int64(1), int64(1)},
+			{"eu", "book", int64(0), int64(0), int64(1), int64(10)},
+			{"eu", "pen", int64(0), int64(0), int64(1), int64(20)},
+			{"us", "pen", int64(0), int64(0), int64(2), int64(12)},
+			// (region)
+			{nil, nil, int64(0), int64(1), int64(1), int64(1)},
+			{"eu", nil, int64(0), int64(1), int64(2), int64(30)},
+			{"us", nil, int64(0), int64(1), int64(2), int64(12)},
+			// (product)
+			{nil, "book", int64(1), int64(0), int64(2), int64(11)},
+			{nil, "pen", int64(1), int64(0), int64(3), int64(32)},
+			// ()
+			{nil, nil, int64(1), int64(1), int64(5), int64(43)},
+		}, rows)
+	})
+
+	t.Run("rollup should aggregate the prefixes of the grouping columns", func(t *testing.T) {
+		rows := queryRows(t, "SELECT region, product, COUNT(*) FROM sales WHERE region IS NOT NULL GROUP BY ROLLUP(region, product)")
+
+		require.Equal(t, [][]interface{}{
+			{"eu", "book", int64(1)},
+			{"eu", "pen", int64(1)},
+			{"us", "pen", int64(2)},
+			{"eu", nil, int64(2)},
+			{"us", nil, int64(2)},
+			{nil, nil, int64(4)},
+		}, rows)
+	})
+
+	t.Run("grouping sets should aggregate each of the given sets", func(t *testing.T) {
+		expected := [][]interface{}{
+			{"book", int64(2)},
+			{"pen", int64(3)},
+			{nil, int64(5)},
+		}
+
+		rows := queryRows(t, "SELECT product, COUNT(*) FROM sales GROUP BY GROUPING SETS ((product), ())")
+		require.Equal(t, expected, rows)
+
+		rows = queryRows(t, "SELECT product, COUNT(*) FROM sales GROUP BY GROUPING SETS (1, ())")
+		require.Equal(t, expected, rows)
+	})
+
+	t.Run("having should filter the groups of all the sets", func(t *testing.T) {
+		rows := queryRows(t, "SELECT product, COUNT(*) AS c FROM sales GROUP BY ROLLUP(product) HAVING COUNT(*) > 2")
+
+		require.Equal(t, [][]interface{}{
+			{"pen", int64(3)},
+			{nil, int64(5)},
+		}, rows)
+	})
+
+	t.Run("grouping should be zero for a plain group by", func(t *testing.T) {
+		rows := queryRows(t, "SELECT product, GROUPING(product), COUNT(*) FROM sales GROUP BY product ORDER BY product")
+
+		require.Equal(t, [][]interface{}{
+			{"book", int64(0), int64(2)},
+			{"pen", int64(0), int64(3)},
+		}, rows)
+	})
+
+	t.Run("invalid grouping sets should be rejected", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT region, GROUPING(product) FROM sales GROUP BY CUBE(region)", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT GROUPING(product) FROM sales", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT region, COUNT(*) FROM sales GROUP BY CUBE(region) ORDER BY region", nil)
+		require.ErrorIs(t, err, ErrLimitedOrderBy)
+
+		_, err = engine.Query(context.Background(), nil,
+			"SELECT COUNT(*) FROM sales GROUP BY CUBE(id, region, product, amount, id, region, product, amount, id, region, product, amount, id)", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
+
+func TestCount(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE t1(id INTEGER AUTO_INCREMENT, val1 INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON t1(val1)", nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 3; j++ {
+			_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO t1(val1) VALUES($1)", map[string]interface{}{"param1": j})
+			require.NoError(t, err)
+		}
+	}
+
+	r, err := engine.Query(context.Background(), nil, "SELECT COUNT(*) as c FROM t1", nil)
+	require.NoError(t, err)
 
 	row, err := r.Read(context.Background())
 	require.NoError(t, err)
@@ -3381,52 +4594,339 @@ func TestGroupByHaving(t *testing.T) {
 	require.NoError(t, err)
 }
 
-func TestJoins(t *testing.T) {
+func TestBooleanAggregations(t *testing.T) {
 	engine := setupCommonTest(t)
 
-	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE table1 (id INTEGER, title VARCHAR, fkid1 INTEGER, fkid2 INTEGER, PRIMARY KEY id)", nil)
-	require.NoError(t, err)
-
-	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table2 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil)
-	require.NoError(t, err)
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE checks (
+			id INTEGER AUTO_INCREMENT,
+			service VARCHAR[32],
+			is_valid BOOLEAN,
+			has_error BOOLEAN,
+			PRIMARY KEY id
+		);
 
-	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table3 (id INTEGER, age INTEGER, PRIMARY KEY id)", nil)
+		CREATE INDEX ON checks(service);
+
+		INSERT INTO checks(service, is_valid, has_error) VALUES
+			('api', true, false),
+			('api', true, NULL),
+			('api', NULL, false),
+			('db', true, true),
+			('db', false, false),
+			('db', NULL, NULL),
+			('web', NULL, NULL),
+			('web', NULL, NULL);
+	`, nil)
 	require.NoError(t, err)
 
-	rowCount := 10
-
-	for i := 0; i < rowCount; i++ {
-		_, _, err = engine.Exec(context.Background(), nil, fmt.Sprintf(`
-			UPSERT INTO table1 (id, title, fkid1, fkid2) VALUES (%d, 'title%d', %d, %d)`, i, i, rowCount-1-i, i), nil)
+	readRows := func(t *testing.T, q string) [][]TypedValue {
+		r, err := engine.Query(context.Background(), nil, q, nil)
 		require.NoError(t, err)
+		defer r.Close()
 
-		_, _, err = engine.Exec(context.Background(), nil, fmt.Sprintf("UPSERT INTO table2 (id, amount) VALUES (%d, %d)", rowCount-1-i, i*i), nil)
-		require.NoError(t, err)
+		var rows [][]TypedValue
 
-		_, _, err = engine.Exec(context.Background(), nil, fmt.Sprintf("UPSERT INTO table3 (id, age) VALUES (%d, %d)", i, 30+i), nil)
-		require.NoError(t, err)
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				return rows
+			}
+			require.NoError(t, err)
+
+			rows = append(rows, row.ValuesByPosition)
+		}
 	}
 
-	t.Run("should not find any matching row", func(t *testing.T) {
+	t.Run("groups with true, false and NULL values", func(t *testing.T) {
 		r, err := engine.Query(context.Background(), nil, `
-		SELECT table1.title, table2.amount, table3.age
-		FROM (SELECT * FROM table2 WHERE amount = 1)
-		INNER JOIN table1 ON table2.id = table1.fkid1 AND (table2.amount > 0 OR table2.amount > 0+1)
-		INNER JOIN table3 ON table1.fkid2 = table3.id AND table3.age < 30`, nil)
+			SELECT service, BOOL_AND(is_valid), BOOL_OR(has_error), BOOL_AND(has_error), BOOL_OR(is_valid)
+			FROM checks
+			GROUP BY service
+			ORDER BY service
+		`, nil)
 		require.NoError(t, err)
 
-		_, err = r.Read(context.Background())
-		require.Equal(t, ErrNoMoreRows, err)
-
-		err = r.Close()
+		cols, err := r.Columns(context.Background())
 		require.NoError(t, err)
-	})
+		r.Close()
 
-	t.Run("should find one matching row", func(t *testing.T) {
-		r, err := engine.Query(context.Background(), nil, `
-		SELECT t1.title, t2.amount, t3.age
-		FROM (SELECT id, amount FROM table2 WHERE amount = 1) AS t2
-		INNER JOIN table1 AS t1 ON t2.id = t1.fkid1 AND t2.amount > 0
+		for _, col := range cols[1:] {
+			require.Equal(t, BooleanType, col.Type)
+		}
+
+		rows := readRows(t, `
+			SELECT service, BOOL_AND(is_valid), BOOL_OR(has_error), BOOL_AND(has_error), BOOL_OR(is_valid)
+			FROM checks
+			GROUP BY service
+			ORDER BY service
+		`)
+
+		expected := [][]interface{}{
+			{"api", true, false, false, true},
+			{"db", false, true, false, true},
+			// NULL values are ignored, a group with only NULL values aggregates to NULL
+			{"web", nil, nil, nil, nil},
+		}
+
+		require.Len(t, rows, len(expected))
+
+		for i, values := range expected {
+			for j, v := range values {
+				require.Equal(t, v, rows[i][j].Value(), "row %d, column %d", i, j)
+			}
+
+			for _, v := range rows[i][1:] {
+				require.Equal(t, BooleanType, v.Type())
+			}
+		}
+	})
+
+	t.Run("boolean aggregations in the having clause", func(t *testing.T) {
+		rows := readRows(t, `
+			SELECT service, BOOL_OR(has_error), BOOL_AND(is_valid)
+			FROM checks
+			GROUP BY service
+			HAVING BOOL_OR(has_error) = false OR BOOL_AND(is_valid) = false
+			ORDER BY service
+		`)
+		require.Len(t, rows, 2)
+		require.Equal(t, "api", rows[0][0].Value())
+		require.Equal(t, "db", rows[1][0].Value())
+	})
+
+	t.Run("empty set should aggregate to NULL", func(t *testing.T) {
+		rows := readRows(t, "SELECT BOOL_AND(is_valid), BOOL_OR(has_error), COUNT(*) FROM checks WHERE service = 'none'")
+		require.Len(t, rows, 1)
+		require.True(t, rows[0][0].IsNull())
+		require.Equal(t, BooleanType, rows[0][0].Type())
+		require.True(t, rows[0][1].IsNull())
+		require.Equal(t, int64(0), rows[0][2].Value())
+	})
+
+	t.Run("aggregations without grouping", func(t *testing.T) {
+		rows := readRows(t, "SELECT BOOL_AND(is_valid), BOOL_OR(has_error), BOOL_AND(id > 0) FROM checks")
+		require.Len(t, rows, 1)
+		require.Equal(t, false, rows[0][0].Value())
+		require.Equal(t, true, rows[0][1].Value())
+		require.Equal(t, true, rows[0][2].Value())
+	})
+
+	t.Run("non boolean values can not be aggregated", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT BOOL_AND(id) FROM checks", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNotComparableValues)
+	})
+}
+
+func TestAggregationsOverExpressions(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE orders (
+			id INTEGER AUTO_INCREMENT,
+			customer VARCHAR[32],
+			status VARCHAR[16],
+			price INTEGER,
+			qty INTEGER,
+			discount INTEGER,
+			PRIMARY KEY id
+		);
+
+		CREATE INDEX ON orders(customer);
+
+		INSERT INTO orders(customer, status, price, qty, discount) VALUES
+			('alice', 'paid', 10, 2, 1),
+			('alice', 'pending', 5, 4, 0),
+			('alice', 'paid', 20, 1, 3),
+			('bob', 'pending', 7, 3, 2),
+			('bob', 'pending', 1, 1, 0),
+			('carol', 'paid', 3, 10, 5);
+	`, nil)
+	require.NoError(t, err)
+
+	type result struct {
+		customer  string
+		paid      int64
+		avgTotal  int64
+		maxPrice  int64
+		noPayment interface{}
+	}
+
+	r, err := engine.Query(context.Background(), nil, `
+		SELECT
+			customer,
+			SUM(CASE WHEN status = 'paid' THEN price * qty ELSE 0 END) AS paid,
+			AVG(price * qty),
+			MAX(price + discount),
+			MIN(CASE WHEN status = @status THEN price END)
+		FROM orders
+		GROUP BY customer
+		ORDER BY customer
+	`, map[string]interface{}{"status": "pending"})
+	require.NoError(t, err)
+	defer r.Close()
+
+	cols, err := r.Columns(context.Background())
+	require.NoError(t, err)
+	require.Len(t, cols, 5)
+	require.Equal(t, "paid", cols[1].Column)
+	require.Equal(t, IntegerType, cols[1].Type)
+	require.Equal(t, IntegerType, cols[3].Type)
+
+	expected := []result{
+		{customer: "alice", paid: 40, avgTotal: 20, maxPrice: 23, noPayment: int64(5)},
+		{customer: "bob", paid: 0, avgTotal: 11, maxPrice: 9, noPayment: int64(1)},
+		{customer: "carol", paid: 30, avgTotal: 30, maxPrice: 8, noPayment: nil},
+	}
+
+	for _, exp := range expected {
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, exp.customer, row.ValuesByPosition[0].Value())
+		require.Equal(t, exp.paid, row.ValuesByPosition[1].Value())
+		require.Equal(t, exp.avgTotal, row.ValuesByPosition[2].Value())
+		require.Equal(t, exp.maxPrice, row.ValuesByPosition[3].Value())
+		require.Equal(t, exp.noPayment, row.ValuesByPosition[4].Value())
+	}
+
+	_, err = r.Read(context.Background())
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	t.Run("aggregations over expressions in the having clause", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, `
+			SELECT customer, SUM(price * qty)
+			FROM orders
+			GROUP BY customer
+			HAVING SUM(price*qty) > 25
+			ORDER BY customer
+		`, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "alice", row.ValuesByPosition[0].Value())
+		require.Equal(t, int64(60), row.ValuesByPosition[1].Value())
+
+		row, err = r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "carol", row.ValuesByPosition[0].Value())
+		require.Equal(t, int64(30), row.ValuesByPosition[1].Value())
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+
+	t.Run("aggregations over expressions without grouping", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT COUNT(*), SUM(CASE WHEN status = 'paid' THEN 1 ELSE 0 END) FROM orders", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(6), row.ValuesByPosition[0].Value())
+		require.Equal(t, int64(3), row.ValuesByPosition[1].Value())
+	})
+
+	t.Run("aggregations over expressions should skip NULL values", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, `
+			CREATE TABLE readings (id INTEGER AUTO_INCREMENT, a INTEGER, PRIMARY KEY id);
+
+			INSERT INTO readings(a) VALUES (1), (NULL), (3);
+		`, nil)
+		require.NoError(t, err)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT COUNT(*), MAX(a+1), AVG(a*2), SUM(a-1), MIN(a*2) FROM readings", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(3), row.ValuesByPosition[0].Value())
+		require.Equal(t, int64(4), row.ValuesByPosition[1].Value())
+		require.Equal(t, int64(4), row.ValuesByPosition[2].Value())
+		require.Equal(t, int64(2), row.ValuesByPosition[3].Value())
+		require.Equal(t, int64(2), row.ValuesByPosition[4].Value())
+	})
+
+	t.Run("invalid aggregations over expressions", func(t *testing.T) {
+		readFirst := func(q string) error {
+			r, err := engine.Query(context.Background(), nil, q, nil)
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+
+			_, err = r.Read(context.Background())
+			return err
+		}
+
+		err := readFirst("SELECT SUM(customer || status) FROM orders")
+		require.ErrorIs(t, err, ErrNotComparableValues)
+
+		err = readFirst("SELECT SUM(MAX(price) + 1) FROM orders")
+		require.ErrorIs(t, err, ErrNoSupported)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT MAX(CASE WHEN qty > 1 THEN price ELSE status END) FROM orders", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Columns(context.Background())
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+}
+
+func TestJoins(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE table1 (id INTEGER, title VARCHAR, fkid1 INTEGER, fkid2 INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table2 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table3 (id INTEGER, age INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		_, _, err = engine.Exec(context.Background(), nil, fmt.Sprintf(`
+			UPSERT INTO table1 (id, title, fkid1, fkid2) VALUES (%d, 'title%d', %d, %d)`, i, i, rowCount-1-i, i), nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, fmt.Sprintf("UPSERT INTO table2 (id, amount) VALUES (%d, %d)", rowCount-1-i, i*i), nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, fmt.Sprintf("UPSERT INTO table3 (id, age) VALUES (%d, %d)", i, 30+i), nil)
+		require.NoError(t, err)
+	}
+
+	t.Run("should not find any matching row", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, `
+		SELECT table1.title, table2.amount, table3.age
+		FROM (SELECT * FROM table2 WHERE amount = 1)
+		INNER JOIN table1 ON table2.id = table1.fkid1 AND (table2.amount > 0 OR table2.amount > 0+1)
+		INNER JOIN table3 ON table1.fkid2 = table3.id AND table3.age < 30`, nil)
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.Equal(t, ErrNoMoreRows, err)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should find one matching row", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, `
+		SELECT t1.title, t2.amount, t3.age
+		FROM (SELECT id, amount FROM table2 WHERE amount = 1) AS t2
+		INNER JOIN table1 AS t1 ON t2.id = t1.fkid1 AND t2.amount > 0
 		INNER JOIN table3 AS t3 ON t1.fkid2 = t3.id AND t3.age > 30`, nil)
 		require.NoError(t, err)
 
@@ -3529,101 +5029,282 @@ func TestJoinsWithNullIndexes(t *testing.T) {
 	require.NoError(t, err)
 }
 
-func TestJoinsWithJointTable(t *testing.T) {
+func TestArrays(t *testing.T) {
 	engine := setupCommonTest(t)
 
-	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, name VARCHAR, PRIMARY KEY id)", nil)
-	require.NoError(t, err)
-
-	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table2 (id INTEGER AUTO_INCREMENT, amount INTEGER, PRIMARY KEY id)", nil)
-	require.NoError(t, err)
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE posts (id INTEGER, tags VARCHAR[], scores INTEGER[] NOT NULL, PRIMARY KEY id);
 
-	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table12 (id INTEGER AUTO_INCREMENT, fkid1 INTEGER, fkid2 INTEGER, active BOOLEAN, PRIMARY KEY id)", nil)
+		INSERT INTO posts(id, tags, scores) VALUES
+			(1, ARRAY['go', 'db'], ARRAY[3, 1]),
+			(2, ARRAY['db'], ARRAY[5]),
+			(3, NULL, ARRAY[]),
+			(4, ARRAY['rust', 'go', 'sql'], ARRAY[2, 2, 7]);
+	`, nil)
 	require.NoError(t, err)
 
-	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1 (name) VALUES ('name1'), ('name2'), ('name3')", nil)
-	require.NoError(t, err)
+	queryIDs := func(t *testing.T, q string) []int64 {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
 
-	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table2 (amount) VALUES (10), (20), (30)", nil)
-	require.NoError(t, err)
+		var ids []int64
 
-	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table12 (fkid1, fkid2, active) VALUES (1,1,false),(1,2,true),(1,3,true)", nil)
-	require.NoError(t, err)
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
 
-	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table12 (fkid1, fkid2, active) VALUES (2,1,false),(2,2,false),(2,3,true)", nil)
-	require.NoError(t, err)
+			ids = append(ids, row.ValuesByPosition[0].Value().(int64))
+		}
 
-	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table12 (fkid1, fkid2, active) VALUES (3,1,false),(3,2,false),(3,3,false)", nil)
-	require.NoError(t, err)
+		return ids
+	}
 
-	r, err := engine.Query(context.Background(), nil, `
-		SELECT q.name, t2.amount, t12.active
-		FROM (SELECT * FROM table1 where name = 'name1') q
-		INNER JOIN table12 t12 on t12.fkid1 = q.id
-		INNER JOIN table2 t2  on t12.fkid2 = t2.id
-		WHERE t12.active = true`, nil)
-	require.NoError(t, err)
+	t.Run("arrays should be read as inserted", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT tags, scores FROM posts WHERE id = 4", nil)
+		require.NoError(t, err)
+		defer r.Close()
 
-	cols, err := r.Columns(context.Background())
-	require.NoError(t, err)
-	require.Len(t, cols, 3)
+		cols, err := r.Columns(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, ArrayTypeOf(VarcharType), cols[0].Type)
+		require.Equal(t, ArrayTypeOf(IntegerType), cols[1].Type)
 
-	for i := 0; i < 2; i++ {
 		row, err := r.Read(context.Background())
 		require.NoError(t, err)
-		require.NotNil(t, row)
-		require.Len(t, row.ValuesBySelector, 3)
+		require.Equal(t, []interface{}{"rust", "go", "sql"}, row.ValuesByPosition[0].Value())
+		require.Equal(t, []interface{}{int64(2), int64(2), int64(7)}, row.ValuesByPosition[1].Value())
+	})
 
-		require.Equal(t, "name1", row.ValuesBySelector[EncodeSelector("", "db1", "q", "name")].Value())
-		require.Equal(t, int64(20+i*10), row.ValuesBySelector[EncodeSelector("", "db1", "t2", "amount")].Value())
-		require.Equal(t, true, row.ValuesBySelector[EncodeSelector("", "db1", "t12", "active")].Value())
-	}
+	t.Run("contains should match arrays holding every value", func(t *testing.T) {
+		require.Equal(t, []int64{1, 4}, queryIDs(t, "SELECT id FROM posts WHERE tags @> ARRAY['go']"))
+		require.Equal(t, []int64{1}, queryIDs(t, "SELECT id FROM posts WHERE tags @> ARRAY['db', 'go']"))
+		require.Equal(t, []int64{1, 2, 4}, queryIDs(t, "SELECT id FROM posts WHERE tags @> ARRAY[]"))
+		require.Equal(t, []int64{4}, queryIDs(t, "SELECT id FROM posts WHERE scores @> ARRAY[7, 2, 2]"))
+		require.Empty(t, queryIDs(t, "SELECT id FROM posts WHERE tags @> ARRAY['go', 'java']"))
+	})
 
-	err = r.Close()
-	require.NoError(t, err)
-}
+	t.Run("any should compare against every value", func(t *testing.T) {
+		require.Equal(t, []int64{1, 2}, queryIDs(t, "SELECT id FROM posts WHERE 'db' = ANY(tags)"))
+		require.Equal(t, []int64{2, 4}, queryIDs(t, "SELECT id FROM posts WHERE 4 < ANY(scores)"))
+		require.Equal(t, []int64{3, 4}, queryIDs(t, "SELECT id FROM posts WHERE NOT 'db' = ANY(tags) OR tags IS NULL"))
+		require.Empty(t, queryIDs(t, "SELECT id FROM posts WHERE 0 = ANY(ARRAY[])"))
+	})
 
-func TestNestedJoins(t *testing.T) {
-	engine := setupCommonTest(t)
+	t.Run("arrays should be equal when holding the same values in the same order", func(t *testing.T) {
+		require.Equal(t, []int64{1}, queryIDs(t, "SELECT id FROM posts WHERE scores = ARRAY[3, 1]"))
+		require.Equal(t, []int64{3}, queryIDs(t, "SELECT id FROM posts WHERE scores = ARRAY[]"))
+		require.Empty(t, queryIDs(t, "SELECT id FROM posts WHERE scores = ARRAY[1, 3]"))
+	})
 
-	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE table1 (id INTEGER, title VARCHAR, fkid1 INTEGER, PRIMARY KEY id)", nil)
-	require.NoError(t, err)
+	t.Run("arrays should be ordered by their values", func(t *testing.T) {
+		require.Equal(t, []int64{1, 2}, queryIDs(t, "SELECT id FROM posts WHERE scores > ARRAY[3]"))
+		require.Equal(t, []int64{3, 4}, queryIDs(t, "SELECT id FROM posts WHERE scores < ARRAY[3]"))
+	})
 
-	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table2 (id INTEGER, amount INTEGER, fkid1 INTEGER, PRIMARY KEY id)", nil)
-	require.NoError(t, err)
+	t.Run("values of another type should be rejected", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "INSERT INTO posts(id, tags, scores) VALUES (5, ARRAY[1, 2], ARRAY[1])", nil)
+		require.ErrorIs(t, err, ErrInvalidValue)
 
-	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table3 (id INTEGER, age INTEGER, PRIMARY KEY id)", nil)
-	require.NoError(t, err)
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO posts(id, tags, scores) VALUES (5, 'go', ARRAY[1])", nil)
+		require.ErrorIs(t, err, ErrInvalidValue)
 
-	rowCount := 10
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO posts(id, tags, scores) VALUES (5, ARRAY['go', 1], ARRAY[1])", nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
 
-	for i := 0; i < rowCount; i++ {
-		_, _, err = engine.Exec(context.Background(), nil, fmt.Sprintf("UPSERT INTO table1 (id, title, fkid1) VALUES (%d, 'title%d', %d)", i, i, rowCount-1-i), nil)
-		require.NoError(t, err)
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO posts(id, tags, scores) VALUES (5, ARRAY['go', NULL], ARRAY[1])", nil)
+		require.ErrorIs(t, err, ErrInvalidValue)
 
-		_, _, err = engine.Exec(context.Background(), nil, fmt.Sprintf("UPSERT INTO table2 (id, amount, fkid1) VALUES (%d, %d, %d)", rowCount-1-i, i*i, i), nil)
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM posts WHERE tags @> ARRAY[1]", nil)
 		require.NoError(t, err)
+		defer r.Close()
 
-		_, _, err = engine.Exec(context.Background(), nil, fmt.Sprintf("UPSERT INTO table3 (id, age) VALUES (%d, %d)", i, 30+i), nil)
-		require.NoError(t, err)
-	}
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
 
-	r, err := engine.Query(context.Background(), nil, `
-		SELECT id, title, t2.amount AS total_amount, t3.age
-		FROM table1 t1
-		INNER JOIN table2 t2 ON (fkid1 = t2.id AND title != NULL)
-		INNER JOIN table3 t3 ON t2.fkid1 = t3.id
-		ORDER BY id DESC`, nil)
-	require.NoError(t, err)
+	t.Run("array columns should not be indexed", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "CREATE INDEX ON posts(tags)", nil)
+		require.ErrorIs(t, err, ErrLimitedKeyType)
 
-	cols, err := r.Columns(context.Background())
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE tagged (tags VARCHAR[], PRIMARY KEY tags)", nil)
+		require.ErrorIs(t, err, ErrLimitedKeyType)
+	})
+}
+
+func TestDistinctFrom(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE pairs (id INTEGER, a INTEGER, b INTEGER, PRIMARY KEY id);
+		CREATE TABLE owners (id INTEGER AUTO_INCREMENT, code INTEGER, name VARCHAR, PRIMARY KEY id);
+
+		INSERT INTO pairs(id, a, b) VALUES (1, NULL, NULL), (2, NULL, 1), (3, 1, NULL), (4, 1, 1), (5, 1, 2);
+		INSERT INTO owners(code, name) VALUES (1, 'one'), (NULL, 'unknown');
+	`, nil)
 	require.NoError(t, err)
-	require.Len(t, cols, 4)
 
-	for i := 0; i < rowCount; i++ {
-		row, err := r.Read(context.Background())
+	queryIDs := func(t *testing.T, q string) []int64 {
+		r, err := engine.Query(context.Background(), nil, q, nil)
 		require.NoError(t, err)
-		require.NotNil(t, row)
+		defer r.Close()
+
+		var ids []int64
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.ValuesByPosition[0].Value().(int64))
+		}
+
+		return ids
+	}
+
+	t.Run("is distinct from should be null-safe", func(t *testing.T) {
+		require.Equal(t, []int64{2, 3, 5}, queryIDs(t, "SELECT id FROM pairs WHERE a IS DISTINCT FROM b"))
+	})
+
+	t.Run("is not distinct from should be null-safe", func(t *testing.T) {
+		require.Equal(t, []int64{1, 4}, queryIDs(t, "SELECT id FROM pairs WHERE a IS NOT DISTINCT FROM b"))
+		require.Equal(t, []int64{1, 2}, queryIDs(t, "SELECT id FROM pairs WHERE a IS NOT DISTINCT FROM NULL"))
+		require.Equal(t, []int64{4, 5}, queryIDs(t, "SELECT id FROM pairs WHERE a IS NOT DISTINCT FROM 0 + 1 AND b IS DISTINCT FROM NULL"))
+	})
+
+	t.Run("is not distinct from should join on nullable keys", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, `
+			SELECT pairs.id, owners.name
+			FROM pairs INNER JOIN owners ON pairs.a IS NOT DISTINCT FROM owners.code
+			ORDER BY pairs.id`, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var names []string
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			names = append(names, row.ValuesByPosition[1].Value().(string))
+		}
+
+		require.Equal(t, []string{"unknown", "unknown", "one", "one", "one"}, names)
+	})
+
+	t.Run("distinct from should require comparable values", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM pairs WHERE id > 2 AND a IS DISTINCT FROM 'one'", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNotComparableValues)
+	})
+}
+
+func TestJoinsWithJointTable(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, name VARCHAR, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table2 (id INTEGER AUTO_INCREMENT, amount INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table12 (id INTEGER AUTO_INCREMENT, fkid1 INTEGER, fkid2 INTEGER, active BOOLEAN, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1 (name) VALUES ('name1'), ('name2'), ('name3')", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table2 (amount) VALUES (10), (20), (30)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table12 (fkid1, fkid2, active) VALUES (1,1,false),(1,2,true),(1,3,true)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table12 (fkid1, fkid2, active) VALUES (2,1,false),(2,2,false),(2,3,true)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table12 (fkid1, fkid2, active) VALUES (3,1,false),(3,2,false),(3,3,false)", nil)
+	require.NoError(t, err)
+
+	r, err := engine.Query(context.Background(), nil, `
+		SELECT q.name, t2.amount, t12.active
+		FROM (SELECT * FROM table1 where name = 'name1') q
+		INNER JOIN table12 t12 on t12.fkid1 = q.id
+		INNER JOIN table2 t2  on t12.fkid2 = t2.id
+		WHERE t12.active = true`, nil)
+	require.NoError(t, err)
+
+	cols, err := r.Columns(context.Background())
+	require.NoError(t, err)
+	require.Len(t, cols, 3)
+
+	for i := 0; i < 2; i++ {
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, row)
+		require.Len(t, row.ValuesBySelector, 3)
+
+		require.Equal(t, "name1", row.ValuesBySelector[EncodeSelector("", "db1", "q", "name")].Value())
+		require.Equal(t, int64(20+i*10), row.ValuesBySelector[EncodeSelector("", "db1", "t2", "amount")].Value())
+		require.Equal(t, true, row.ValuesBySelector[EncodeSelector("", "db1", "t12", "active")].Value())
+	}
+
+	err = r.Close()
+	require.NoError(t, err)
+}
+
+func TestNestedJoins(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE table1 (id INTEGER, title VARCHAR, fkid1 INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table2 (id INTEGER, amount INTEGER, fkid1 INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table3 (id INTEGER, age INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		_, _, err = engine.Exec(context.Background(), nil, fmt.Sprintf("UPSERT INTO table1 (id, title, fkid1) VALUES (%d, 'title%d', %d)", i, i, rowCount-1-i), nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, fmt.Sprintf("UPSERT INTO table2 (id, amount, fkid1) VALUES (%d, %d, %d)", rowCount-1-i, i*i, i), nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, fmt.Sprintf("UPSERT INTO table3 (id, age) VALUES (%d, %d)", i, 30+i), nil)
+		require.NoError(t, err)
+	}
+
+	r, err := engine.Query(context.Background(), nil, `
+		SELECT id, title, t2.amount AS total_amount, t3.age
+		FROM table1 t1
+		INNER JOIN table2 t2 ON (fkid1 = t2.id AND title != NULL)
+		INNER JOIN table3 t3 ON t2.fkid1 = t3.id
+		ORDER BY id DESC`, nil)
+	require.NoError(t, err)
+
+	cols, err := r.Columns(context.Background())
+	require.NoError(t, err)
+	require.Len(t, cols, 4)
+
+	for i := 0; i < rowCount; i++ {
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, row)
 		require.Len(t, row.ValuesBySelector, 4)
 
 		require.Equal(t, int64(rowCount-1-i), row.ValuesBySelector[EncodeSelector("", "db1", "t1", "id")].Value())
@@ -4953,6 +6634,229 @@ func TestUnionOperator(t *testing.T) {
 		err = r.Close()
 		require.NoError(t, err)
 	})
+
+	t.Run("union as data source should be filtered and limited", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, `
+			SELECT id, title
+			FROM (SELECT id, title FROM table1 UNION ALL SELECT id, name FROM table2)
+			WHERE id > @id
+			LIMIT 3
+		`, map[string]interface{}{"id": 8})
+		require.NoError(t, err)
+
+		for _, title := range []string{"title8", "title9", "name8"} {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, title, row.ValuesBySelector["(db1.table1.title)"].Value())
+		}
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("sorted union as data source should be limited after sorting", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, `
+			SELECT id, title
+			FROM (SELECT id, title FROM table1 UNION ALL SELECT id, name FROM table2)
+			ORDER BY title COLLATE NOCASE DESC
+			LIMIT 2
+		`, nil)
+		require.NoError(t, err)
+
+		for _, title := range []string{"title9", "title8"} {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, title, row.ValuesBySelector["(db1.table1.title)"].Value())
+		}
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("aliased union as data source should be filtered", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, `
+			SELECT u.title
+			FROM (SELECT id, title FROM table1 UNION SELECT id, name AS title FROM table2 t2) AS u
+			WHERE u.id > 8 AND title LIKE '^(title|name)'
+			OFFSET 1
+		`, nil)
+		require.NoError(t, err)
+
+		for _, title := range []string{"title9", "name8", "name9"} {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, title, row.ValuesBySelector["(db1.u.title)"].Value())
+		}
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("predicate and limit should be pushed down into union branches", func(t *testing.T) {
+		stmts, err := Parse(strings.NewReader(`
+			SELECT id, title
+			FROM (SELECT id, title FROM table1 UNION ALL SELECT * FROM table2)
+			WHERE id > 7
+			LIMIT 3 OFFSET 1
+		`))
+		require.NoError(t, err)
+
+		stmt := stmts[0].(*SelectStmt)
+
+		tx, err := engine.NewTx(context.Background(), DefaultTxOptions())
+		require.NoError(t, err)
+		defer tx.Cancel()
+
+		union := stmt.ds.(*UnionStmt).pushdown(tx, stmt.where, stmt.constantBounds())
+
+		for _, ds := range []DataSource{union.left, union.right} {
+			branch := ds.(*SelectStmt)
+			require.NotNil(t, branch.where)
+			require.Equal(t, &Number{val: 4}, branch.limit)
+
+			scanSpecs, err := branch.genScanSpecs(tx, nil)
+			require.NoError(t, err)
+			require.Contains(t, scanSpecs.rangesByColID, uint32(1))
+		}
+
+		// the original statement is not modified
+		require.Nil(t, stmt.ds.(*UnionStmt).left.(*SelectStmt).where)
+		require.Nil(t, stmt.ds.(*UnionStmt).left.(*SelectStmt).limit)
+	})
+
+	t.Run("predicate should not be pushed down into aggregated or limited branches", func(t *testing.T) {
+		stmts, err := Parse(strings.NewReader(`
+			SELECT c
+			FROM (SELECT COUNT(*) AS c FROM table1 UNION SELECT id FROM table2 LIMIT 1)
+			WHERE c > 7
+			LIMIT 3
+		`))
+		require.NoError(t, err)
+
+		stmt := stmts[0].(*SelectStmt)
+
+		tx, err := engine.NewTx(context.Background(), DefaultTxOptions())
+		require.NoError(t, err)
+		defer tx.Cancel()
+
+		union := stmt.ds.(*UnionStmt)
+		require.Same(t, union, union.pushdown(tx, stmt.where, stmt.constantBounds()))
+
+		stmts, err = Parse(strings.NewReader(`
+			SELECT id
+			FROM (SELECT id FROM table1 UNION SELECT id FROM table2 LIMIT 1)
+			WHERE id > 7
+			LIMIT 3
+		`))
+		require.NoError(t, err)
+
+		stmt = stmts[0].(*SelectStmt)
+
+		pushed := stmt.ds.(*UnionStmt).pushdown(tx, stmt.where, stmt.constantBounds())
+		require.NotNil(t, pushed.left.(*SelectStmt).where)
+		require.Nil(t, pushed.left.(*SelectStmt).limit)
+		require.Nil(t, pushed.right.(*SelectStmt).where)
+		require.Equal(t, &Number{val: 1}, pushed.right.(*SelectStmt).limit)
+	})
+}
+
+func TestSetOperators(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix).WithDistinctLimit(4))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE DATABASE db1; USE DATABASE db1;", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE TABLE table1(id INTEGER AUTO_INCREMENT, v INTEGER, title VARCHAR, PRIMARY KEY id);
+		CREATE TABLE table2(id INTEGER AUTO_INCREMENT, v INTEGER, PRIMARY KEY id);
+		CREATE TABLE table3(id INTEGER AUTO_INCREMENT, v INTEGER, PRIMARY KEY id);
+
+		INSERT INTO table1(v) VALUES (1), (1), (2), (3), (3), (3);
+		INSERT INTO table2(v) VALUES (1), (3), (3), (4);
+		INSERT INTO table3(v) VALUES (1), (2), (3), (4), (5);
+	`, nil)
+	require.NoError(t, err)
+
+	queryValues := func(t *testing.T, query string) []int64 {
+		r, err := engine.Query(context.Background(), nil, query, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var vals []int64
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, store.ErrNoMoreEntries) {
+				break
+			}
+			require.NoError(t, err)
+
+			vals = append(vals, row.ValuesByPosition[0].Value().(int64))
+		}
+
+		return vals
+	}
+
+	t.Run("except should return distinct rows not found in the right subquery", func(t *testing.T) {
+		require.Equal(t, []int64{2}, queryValues(t, "SELECT v FROM table1 EXCEPT SELECT v FROM table2"))
+		require.Equal(t, []int64{4}, queryValues(t, "SELECT v FROM table2 EXCEPT SELECT v FROM table1"))
+	})
+
+	t.Run("except all should subtract occurrences of each row", func(t *testing.T) {
+		require.Equal(t, []int64{1, 2, 3}, queryValues(t, "SELECT v FROM table1 EXCEPT ALL SELECT v FROM table2"))
+	})
+
+	t.Run("intersect should return distinct rows found in both subqueries", func(t *testing.T) {
+		require.Equal(t, []int64{1, 3}, queryValues(t, "SELECT v FROM table1 INTERSECT SELECT v FROM table2"))
+	})
+
+	t.Run("intersect all should keep the minimum number of occurrences of each row", func(t *testing.T) {
+		require.Equal(t, []int64{1, 3, 3}, queryValues(t, "SELECT v FROM table1 INTERSECT ALL SELECT v FROM table2"))
+	})
+
+	t.Run("set operations should be evaluated from left to right", func(t *testing.T) {
+		require.Empty(t, queryValues(t, "SELECT v FROM table1 EXCEPT SELECT v FROM table2 EXCEPT SELECT v FROM table1"))
+		require.Equal(t, []int64{1, 3, 2}, queryValues(t, "SELECT v FROM table2 INTERSECT SELECT v FROM table1 UNION SELECT v FROM table1 WHERE v = 2"))
+	})
+
+	t.Run("set operations should be usable as data sources", func(t *testing.T) {
+		require.Equal(t, []int64{2, 3}, queryValues(t, `
+			SELECT v
+			FROM (SELECT v FROM table1 EXCEPT ALL SELECT v FROM table2) AS s
+			WHERE s.v > 1
+		`))
+	})
+
+	t.Run("set operations should fail with mismatching columns", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT v, id FROM table1 EXCEPT SELECT v FROM table2", nil)
+		require.ErrorIs(t, err, ErrColumnMismatchInSetOpStmt)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT title FROM table1 INTERSECT SELECT v FROM table2", nil)
+		require.ErrorIs(t, err, ErrColumnMismatchInSetOpStmt)
+	})
+
+	t.Run("set operations should be bounded by the distinct limit", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT v FROM table1 INTERSECT SELECT v FROM table3", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrTooManyRows)
+	})
 }
 
 func TestTemporalQueriesEdgeCases(t *testing.T) {
@@ -5251,7 +7155,7 @@ func TestMultiDBCatalogQueries(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, tx)
 
-		// doing stmt initialization because cross database references are disabled by grammar
+		// databases managed by the handler can not be referenced from the current one
 		tableRef := &tableRef{
 			db:    "db2",
 			table: "table1",
@@ -5898,8 +7802,76 @@ func TestSQLTxWithClosedContext(t *testing.T) {
 	})
 }
 
-func setupCommonTestWithOptions(t *testing.T, sopts *store.Options) (*Engine, *store.ImmuStore) {
-	st, err := store.Open(t.TempDir(), sopts)
+func TestDMLCommittedTxID(t *testing.T) {
+	engine, st := setupCommonTestWithOptions(t, store.DefaultOptions())
+
+	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE accounts (id INTEGER AUTO_INCREMENT, balance INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	execTxID := func(t *testing.T, sql string) uint64 {
+		_, ctxs, err := engine.Exec(context.Background(), nil, sql, nil)
+		require.NoError(t, err)
+		require.Len(t, ctxs, 1)
+
+		txID := ctxs[0].TxID()
+		require.Equal(t, ctxs[0].TxHeader().ID, txID)
+		require.Equal(t, st.LastCommittedTxID(), txID)
+
+		return txID
+	}
+
+	balanceAsOf := func(t *testing.T, txID uint64) []interface{} {
+		r, err := engine.Query(context.Background(), nil, "SELECT balance FROM accounts UNTIL TX @tx ORDER BY id", map[string]interface{}{"tx": txID})
+		require.NoError(t, err)
+		defer r.Close()
+
+		var balances []interface{}
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				return balances
+			}
+			require.NoError(t, err)
+
+			balances = append(balances, row.ValuesByPosition[0].Value())
+		}
+	}
+
+	insertTxID := execTxID(t, "INSERT INTO accounts(balance) VALUES (100), (200)")
+	updateTxID := execTxID(t, "UPDATE accounts SET balance = balance + 10 WHERE id = 1")
+	deleteTxID := execTxID(t, "DELETE FROM accounts WHERE id = 2")
+
+	require.Less(t, insertTxID, updateTxID)
+	require.Less(t, updateTxID, deleteTxID)
+
+	require.Equal(t, []interface{}{int64(100), int64(200)}, balanceAsOf(t, insertTxID))
+	require.Equal(t, []interface{}{int64(110), int64(200)}, balanceAsOf(t, updateTxID))
+	require.Equal(t, []interface{}{int64(110)}, balanceAsOf(t, deleteTxID))
+
+	t.Run("statements executed together should share their tx", func(t *testing.T) {
+		_, ctxs, err := engine.Exec(context.Background(), nil, "INSERT INTO accounts(balance) VALUES (300); UPDATE accounts SET balance = 0 WHERE id = 3;", nil)
+		require.NoError(t, err)
+		require.Len(t, ctxs, 1)
+		require.Equal(t, deleteTxID+1, ctxs[0].TxID())
+
+		require.Equal(t, []interface{}{int64(110), int64(0)}, balanceAsOf(t, ctxs[0].TxID()))
+	})
+
+	t.Run("the tx id should be zero until committed", func(t *testing.T) {
+		tx, _, err := engine.Exec(context.Background(), nil, "BEGIN TRANSACTION; INSERT INTO accounts(balance) VALUES (400);", nil)
+		require.NoError(t, err)
+		require.Zero(t, tx.TxID())
+
+		_, ctxs, err := engine.Exec(context.Background(), tx, "COMMIT;", nil)
+		require.NoError(t, err)
+		require.Len(t, ctxs, 1)
+		require.Equal(t, st.LastCommittedTxID(), ctxs[0].TxID())
+	})
+}
+
+func setupCommonTestWithOptions(t *testing.T, sopts *store.Options) (*Engine, *store.ImmuStore) {
+	st, err := store.Open(t.TempDir(), sopts)
 	require.NoError(t, err)
 	t.Cleanup(func() { closeStore(t, st) })
 
@@ -6111,3 +8083,1105 @@ func TestCopyCatalogToTx(t *testing.T) {
 
 	})
 }
+
+func TestTableSample(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE DATABASE db1; USE DATABASE db1;", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table1(id INTEGER AUTO_INCREMENT, v INTEGER, PRIMARY KEY id);", nil)
+	require.NoError(t, err)
+
+	rowCount := 1000
+
+	rows := make([]string, rowCount)
+	for i := range rows {
+		rows[i] = fmt.Sprintf("(%d)", i%10)
+	}
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1(v) VALUES "+strings.Join(rows, ", "), nil)
+	require.NoError(t, err)
+
+	queryIDs := func(t *testing.T, query string, params map[string]interface{}) []int64 {
+		r, err := engine.Query(context.Background(), nil, query, params)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var ids []int64
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, store.ErrNoMoreEntries) {
+				break
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.ValuesByPosition[0].Value().(int64))
+		}
+
+		return ids
+	}
+
+	t.Run("the sampled fraction should be within tolerance", func(t *testing.T) {
+		// the expected count is 100 with a standard deviation of ~9.5
+		ids := queryIDs(t, "SELECT id FROM table1 TABLESAMPLE (10 PERCENT)", nil)
+		require.InDelta(t, rowCount/10, len(ids), 50)
+	})
+
+	t.Run("a fixed seed should be reproducible", func(t *testing.T) {
+		ids1 := queryIDs(t, "SELECT id FROM table1 TABLESAMPLE BERNOULLI (20) REPEATABLE (42)", nil)
+		require.InDelta(t, rowCount/5, len(ids1), 75)
+
+		ids2 := queryIDs(t, "SELECT id FROM table1 TABLESAMPLE BERNOULLI (@p) REPEATABLE (@seed)", map[string]interface{}{"p": 20, "seed": 42})
+		require.Equal(t, ids1, ids2)
+
+		ids3 := queryIDs(t, "SELECT id FROM table1 TABLESAMPLE BERNOULLI (20) REPEATABLE (43)", nil)
+		require.NotEqual(t, ids1, ids3)
+	})
+
+	t.Run("sampling should be done before filtering", func(t *testing.T) {
+		ids := queryIDs(t, "SELECT id FROM table1 AS t TABLESAMPLE (50 PERCENT) REPEATABLE (1) WHERE t.v = 0", nil)
+		require.NotEmpty(t, ids)
+		require.Less(t, len(ids), rowCount/10)
+	})
+
+	t.Run("edge percentages", func(t *testing.T) {
+		require.Empty(t, queryIDs(t, "SELECT id FROM table1 TABLESAMPLE (0 PERCENT)", nil))
+		require.Len(t, queryIDs(t, "SELECT id FROM table1 TABLESAMPLE (100 PERCENT)", nil), rowCount)
+	})
+
+	t.Run("invalid percentages should fail", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM table1 TABLESAMPLE (101 PERCENT)", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("parameters should be inferred as integers", func(t *testing.T) {
+		params, err := engine.InferParameters(context.Background(), nil, "SELECT id FROM table1 TABLESAMPLE (@p PERCENT) REPEATABLE (@seed)")
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"p": IntegerType, "seed": IntegerType}, params)
+	})
+}
+
+func TestTableSortKey(t *testing.T) {
+	dir := t.TempDir()
+
+	st, err := store.Open(dir, store.DefaultOptions())
+	require.NoError(t, err)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE DATABASE db1; USE DATABASE db1;", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE TABLE events(id INTEGER AUTO_INCREMENT, ts INTEGER, PRIMARY KEY id) SORT BY ts DESC;
+		CREATE TABLE logs(id INTEGER AUTO_INCREMENT, msg VARCHAR, PRIMARY KEY id) SORT BY id DESC;
+
+		INSERT INTO events(ts) VALUES (20), (10), (40), (30);
+		INSERT INTO logs(msg) VALUES ('a'), ('b'), ('c');
+	`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table1(id INTEGER, PRIMARY KEY id) SORT BY title", nil)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+	queryValues := func(t *testing.T, engine *Engine, query string) []int64 {
+		r, err := engine.Query(context.Background(), nil, query, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var vals []int64
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, store.ErrNoMoreEntries) {
+				break
+			}
+			require.NoError(t, err)
+
+			vals = append(vals, row.ValuesByPosition[0].Value().(int64))
+		}
+
+		return vals
+	}
+
+	t.Run("rows should be returned in the declared order", func(t *testing.T) {
+		require.Equal(t, []int64{40, 30, 20, 10}, queryValues(t, engine, "SELECT ts FROM events"))
+		require.Equal(t, []int64{40, 30}, queryValues(t, engine, "SELECT ts FROM events WHERE ts > 20"))
+		require.Equal(t, []int64{3, 2, 1}, queryValues(t, engine, "SELECT id FROM logs"))
+	})
+
+	t.Run("an explicit order should override the declared one", func(t *testing.T) {
+		require.Equal(t, []int64{20, 10, 40, 30}, queryValues(t, engine, "SELECT ts FROM events ORDER BY id"))
+		require.Equal(t, []int64{10, 20, 30, 40}, queryValues(t, engine, "SELECT ts FROM events ORDER BY ts"))
+		require.Equal(t, []int64{20, 10, 40, 30}, queryValues(t, engine, "SELECT ts FROM events USE INDEX ON (id)"))
+	})
+
+	err = st.Close()
+	require.NoError(t, err)
+
+	t.Run("the declared order should be kept after reopening", func(t *testing.T) {
+		st, err := store.Open(dir, store.DefaultOptions())
+		require.NoError(t, err)
+		defer closeStore(t, st)
+
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "USE DATABASE db1", nil)
+		require.NoError(t, err)
+
+		require.Equal(t, []int64{40, 30, 20, 10}, queryValues(t, engine, "SELECT ts FROM events"))
+		require.Equal(t, []int64{3, 2, 1}, queryValues(t, engine, "SELECT id FROM logs"))
+	})
+}
+
+func TestBooleanPredicates(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE DATABASE db1; USE DATABASE db1;", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE TABLE users(id INTEGER, is_active BOOLEAN, PRIMARY KEY id);
+
+		INSERT INTO users(id, is_active) VALUES (1, TRUE), (2, FALSE), (3, NULL);
+	`, nil)
+	require.NoError(t, err)
+
+	queryIDs := func(t *testing.T, query string) []int64 {
+		r, err := engine.Query(context.Background(), nil, query, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var ids []int64
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, store.ErrNoMoreEntries) {
+				break
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.ValuesByPosition[0].Value().(int64))
+		}
+
+		return ids
+	}
+
+	testCases := []struct {
+		where string
+		ids   []int64
+	}{
+		{where: "is_active", ids: []int64{1}},
+		{where: "NOT is_active", ids: []int64{2}},
+		{where: "is_active = TRUE", ids: []int64{1}},
+		{where: "TRUE", ids: []int64{1, 2, 3}},
+		{where: "FALSE", ids: nil},
+		{where: "is_active OR NOT is_active", ids: []int64{1, 2}},
+		{where: "is_active OR id = 3", ids: []int64{1, 3}},
+		{where: "is_active OR TRUE", ids: []int64{1, 2, 3}},
+		{where: "is_active AND FALSE", ids: nil},
+		{where: "NOT (is_active AND FALSE)", ids: []int64{1, 2, 3}},
+		{where: "NOT (is_active AND TRUE)", ids: []int64{2}},
+		{where: "NOT (is_active OR FALSE)", ids: []int64{2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.where, func(t *testing.T) {
+			require.Equal(t, tc.ids, queryIDs(t, "SELECT id FROM users WHERE "+tc.where))
+		})
+	}
+
+	t.Run("boolean predicates over NULL should be NULL", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT NOT is_active, is_active AND TRUE, is_active OR FALSE FROM users WHERE id = 3", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		for _, v := range row.ValuesByPosition {
+			require.True(t, v.IsNull())
+		}
+	})
+}
+
+func TestQueryWithRowCount(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE DATABASE db1; USE DATABASE db1;", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table1(id INTEGER AUTO_INCREMENT, v INTEGER, PRIMARY KEY id);", nil)
+	require.NoError(t, err)
+
+	rowCount := 1000
+
+	rows := make([]string, rowCount)
+	for i := range rows {
+		rows[i] = fmt.Sprintf("(%d)", i%10)
+	}
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1(v) VALUES "+strings.Join(rows, ", "), nil)
+	require.NoError(t, err)
+
+	queryWithRowCount := func(t *testing.T, query string, countLimit int) (int, *RowCount) {
+		r, count, err := engine.QueryWithRowCount(context.Background(), nil, query, nil, countLimit)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var n int
+
+		for {
+			_, err := r.Read(context.Background())
+			if errors.Is(err, store.ErrNoMoreEntries) {
+				break
+			}
+			require.NoError(t, err)
+
+			n++
+		}
+
+		return n, count
+	}
+
+	t.Run("exact count", func(t *testing.T) {
+		n, count := queryWithRowCount(t, "SELECT id FROM table1 WHERE v = 0 LIMIT 10 OFFSET 5", 0)
+		require.Equal(t, 10, n)
+		require.Equal(t, &RowCount{Rows: 100, Exact: true}, count)
+
+		_, count = queryWithRowCount(t, "SELECT id FROM table1 WHERE v = 0 LIMIT 10", 1000)
+		require.Equal(t, &RowCount{Rows: 100, Exact: true}, count)
+
+		_, count = queryWithRowCount(t, "SELECT DISTINCT v FROM table1 LIMIT 2", 0)
+		require.Equal(t, &RowCount{Rows: 10, Exact: true}, count)
+	})
+
+	t.Run("estimated count", func(t *testing.T) {
+		n, count := queryWithRowCount(t, "SELECT id FROM table1 WHERE v = 0 LIMIT 10", 200)
+		require.Equal(t, 10, n)
+		require.Equal(t, &RowCount{Rows: 100, Exact: false}, count)
+
+		_, count = queryWithRowCount(t, "SELECT id FROM table1 LIMIT 10", 100)
+		require.Equal(t, &RowCount{Rows: uint64(rowCount), Exact: false}, count)
+	})
+
+	t.Run("lower bound count", func(t *testing.T) {
+		// the scan is bounded by the primary key, read rows are not a sample of the table
+		_, count := queryWithRowCount(t, "SELECT id FROM table1 WHERE id > 900 LIMIT 10", 50)
+		require.False(t, count.Exact)
+		require.InDelta(t, 50, count.Rows, 1)
+
+		_, count = queryWithRowCount(t, "SELECT DISTINCT v FROM table1 LIMIT 2", 5)
+		require.Equal(t, &RowCount{Rows: 5, Exact: false}, count)
+	})
+
+	t.Run("invalid count limit", func(t *testing.T) {
+		_, _, err := engine.QueryWithRowCount(context.Background(), nil, "SELECT id FROM table1", nil, -1)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, _, err = engine.QueryWithRowCount(context.Background(), nil, "CREATE TABLE table2(id INTEGER, PRIMARY KEY id)", nil, 0)
+		require.ErrorIs(t, err, ErrExpectingDQLStmt)
+	})
+}
+
+func TestLimitAndOffsetParams(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1 (title) VALUES (@title)", map[string]interface{}{"title": fmt.Sprintf("title%d", i)})
+		require.NoError(t, err)
+	}
+
+	stmts, err := Parse(strings.NewReader("SELECT id FROM table1 LIMIT @limit OFFSET @offset"))
+	require.NoError(t, err)
+
+	stmt := stmts[0].(DataSource)
+
+	queryIDs := func(params map[string]interface{}) ([]int64, error) {
+		r, err := engine.QueryPreparedStmt(context.Background(), nil, stmt, params)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		var ids []int64
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				return ids, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			ids = append(ids, row.ValuesByPosition[0].Value().(int64))
+		}
+	}
+
+	t.Run("the same statement should page through results", func(t *testing.T) {
+		for _, c := range []struct {
+			limit, offset int
+			expected      []int64
+		}{
+			{3, 0, []int64{1, 2, 3}},
+			{3, 3, []int64{4, 5, 6}},
+			{3, 9, []int64{10}},
+			{5, 10, nil},
+			{0, 8, []int64{9, 10}},
+		} {
+			ids, err := queryIDs(map[string]interface{}{"limit": c.limit, "offset": c.offset})
+			require.NoError(t, err)
+			require.Equal(t, c.expected, ids)
+		}
+	})
+
+	t.Run("negative bounds should be rejected", func(t *testing.T) {
+		_, err := queryIDs(map[string]interface{}{"limit": -1, "offset": 0})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = queryIDs(map[string]interface{}{"limit": 1, "offset": -1})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("non-integer bounds should be rejected", func(t *testing.T) {
+		_, err := queryIDs(map[string]interface{}{"limit": "1", "offset": 0})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = queryIDs(map[string]interface{}{"limit": 1})
+		require.ErrorIs(t, err, ErrMissingParameter)
+	})
+
+	t.Run("bounds should be inferred as integers", func(t *testing.T) {
+		params, err := engine.InferParametersPreparedStmts(context.Background(), nil, []SQLStmt{stmts[0]})
+		require.NoError(t, err)
+		require.Equal(t, IntegerType, params["limit"])
+		require.Equal(t, IntegerType, params["offset"])
+	})
+
+	t.Run("bounds should be bound in updates and deletes", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "DELETE FROM table1 LIMIT @limit OFFSET @offset", map[string]interface{}{"limit": 2, "offset": 1})
+		require.NoError(t, err)
+
+		ids, err := queryIDs(map[string]interface{}{"limit": 3, "offset": 0})
+		require.NoError(t, err)
+		require.Equal(t, []int64{1, 4, 5}, ids)
+
+		_, _, err = engine.Exec(context.Background(), nil, "DELETE FROM table1 LIMIT @limit", map[string]interface{}{"limit": -2})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
+
+func TestJoinComplexityGuard(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix).WithMaxJoinedSources(3).WithMaxJoinRows(1000))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE DATABASE db1; USE DATABASE db1;", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE TABLE table1(id INTEGER AUTO_INCREMENT, fkid INTEGER, PRIMARY KEY id);
+		CREATE TABLE table2(id INTEGER AUTO_INCREMENT, v INTEGER, PRIMARY KEY id);
+	`, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 50; i++ {
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1(fkid) VALUES (@fkid); INSERT INTO table2(v) VALUES (@fkid)", map[string]interface{}{"fkid": i + 1})
+		require.NoError(t, err)
+	}
+
+	queryAll := func(t *testing.T, q string) (int, error) {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		if err != nil {
+			return 0, err
+		}
+		defer r.Close()
+
+		n := 0
+
+		for {
+			_, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				return n, nil
+			}
+			if err != nil {
+				return 0, err
+			}
+
+			n++
+		}
+	}
+
+	t.Run("joins resolved by index lookups should be accepted", func(t *testing.T) {
+		n, err := queryAll(t, `
+			SELECT t1.id FROM table1 AS t1
+			INNER JOIN table2 AS t2 ON t1.fkid = t2.id
+			INNER JOIN table2 AS t3 ON t3.id = t2.id
+		`)
+		require.NoError(t, err)
+		require.Equal(t, 50, n)
+	})
+
+	t.Run("too many joined sources should be rejected", func(t *testing.T) {
+		_, err := queryAll(t, `
+			SELECT t1.id FROM table1 AS t1
+			INNER JOIN table2 AS t2 ON t1.fkid = t2.id
+			INNER JOIN table2 AS t3 ON t3.id = t2.id
+			INNER JOIN table2 AS t4 ON t4.id = t3.id
+		`)
+		require.ErrorIs(t, err, ErrQueryTooComplex)
+
+		_, err = queryAll(t, `
+			SELECT t1.id FROM table1 AS t1
+			INNER JOIN (SELECT * FROM table2 UNION SELECT * FROM table2) AS t2 ON t1.fkid = t2.id
+			INNER JOIN table2 AS t3 ON t3.id = t1.id
+		`)
+		require.ErrorIs(t, err, ErrQueryTooComplex)
+	})
+
+	t.Run("cartesian products should be rejected", func(t *testing.T) {
+		_, err := queryAll(t, "SELECT t1.id FROM table1 AS t1 INNER JOIN table2 AS t2 ON t1.fkid > t2.v")
+		require.ErrorIs(t, err, ErrQueryTooComplex)
+
+		_, err = queryAll(t, "SELECT t1.id FROM table1 AS t1 INNER JOIN table2 AS t2 ON t1.fkid = t2.v")
+		require.ErrorIs(t, err, ErrQueryTooComplex)
+	})
+
+	t.Run("small cartesian products should be accepted", func(t *testing.T) {
+		_, _, err = engine.Exec(context.Background(), nil, `
+			CREATE TABLE table3(id INTEGER AUTO_INCREMENT, v INTEGER, PRIMARY KEY id);
+			INSERT INTO table3(v) VALUES (1), (2), (3), (4), (5), (6), (7), (8), (9), (10);
+		`, nil)
+		require.NoError(t, err)
+
+		n, err := queryAll(t, "SELECT t1.id FROM table1 AS t1 INNER JOIN table3 AS t3 ON t1.fkid > t3.v")
+		require.NoError(t, err)
+		require.Equal(t, 445, n)
+	})
+}
+
+func TestQueryMemoryLimit(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix).WithQueryMemoryLimit(20*rowDigestMemSize))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE DATABASE db1;
+		USE DATABASE db1;
+
+		CREATE TABLE orders(id INTEGER AUTO_INCREMENT, customer INTEGER, region INTEGER, note VARCHAR, PRIMARY KEY id);
+		CREATE INDEX ON orders(customer);
+		CREATE INDEX ON orders(region);
+	`, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		_, _, err = engine.Exec(context.Background(), nil,
+			"INSERT INTO orders(customer, region, note) VALUES (@customer, @region, @note)",
+			map[string]interface{}{"customer": i, "region": i % 5, "note": strings.Repeat("n", 10*i)},
+		)
+		require.NoError(t, err)
+	}
+
+	queryAll := func(t *testing.T, q string) (int, error) {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		if err != nil {
+			return 0, err
+		}
+		defer r.Close()
+
+		n := 0
+
+		for {
+			_, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				return n, nil
+			}
+			if err != nil {
+				return 0, err
+			}
+
+			n++
+		}
+	}
+
+	t.Run("queries within the budget should succeed", func(t *testing.T) {
+		n, err := queryAll(t, "SELECT region, COUNT(*) FROM orders GROUP BY region ORDER BY region")
+		require.NoError(t, err)
+		require.Equal(t, 5, n)
+
+		n, err = queryAll(t, "SELECT customer, MAX(note) FROM orders WHERE customer < 20 GROUP BY customer ORDER BY customer")
+		require.NoError(t, err)
+		require.Equal(t, 20, n)
+
+		n, err = queryAll(t, "SELECT DISTINCT region FROM orders")
+		require.NoError(t, err)
+		require.Equal(t, 5, n)
+
+		n, err = queryAll(t, "SELECT region FROM orders INTERSECT SELECT customer FROM orders WHERE customer < 10")
+		require.NoError(t, err)
+		require.Equal(t, 5, n)
+	})
+
+	t.Run("queries over the budget should be aborted", func(t *testing.T) {
+		// groups are built one at a time, the state of the group grows with the longest note
+		_, err := queryAll(t, "SELECT customer, MAX(note), MIN(note) FROM orders GROUP BY customer ORDER BY customer")
+		require.ErrorIs(t, err, ErrMemoryLimitExceeded)
+
+		_, err = queryAll(t, "SELECT region, MAX(note), MIN(note) FROM orders GROUP BY region ORDER BY region")
+		require.ErrorIs(t, err, ErrMemoryLimitExceeded)
+
+		_, err = queryAll(t, "SELECT DISTINCT customer FROM orders")
+		require.ErrorIs(t, err, ErrMemoryLimitExceeded)
+
+		_, err = queryAll(t, "SELECT region FROM orders EXCEPT SELECT customer FROM orders")
+		require.ErrorIs(t, err, ErrMemoryLimitExceeded)
+
+		_, err = queryAll(t, "SELECT o1.id FROM orders o1 INNER JOIN (SELECT DISTINCT customer FROM orders) AS o2 ON o1.customer = o2.customer")
+		require.ErrorIs(t, err, ErrMemoryLimitExceeded)
+	})
+
+	t.Run("the budget applies to each query", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			n, err := queryAll(t, "SELECT DISTINCT customer FROM orders WHERE customer < 15")
+			require.NoError(t, err)
+			require.Equal(t, 15, n)
+		}
+	})
+
+	t.Run("queries without a budget are not limited", func(t *testing.T) {
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		err = engine.SetCurrentDatabase(context.Background(), "db1")
+		require.NoError(t, err)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT customer, MAX(note), MIN(note) FROM orders GROUP BY customer ORDER BY customer", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		for i := 0; i < 100; i++ {
+			_, err := r.Read(context.Background())
+			require.NoError(t, err)
+		}
+	})
+}
+
+func TestCrossDatabaseJoin(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE DATABASE db1;
+		CREATE DATABASE db2;
+	`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		USE DATABASE db2;
+		CREATE TABLE customers(id INTEGER AUTO_INCREMENT, name VARCHAR, PRIMARY KEY id);
+		INSERT INTO customers(name) VALUES ('customer1'), ('customer2');
+	`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		USE DATABASE db1;
+		CREATE TABLE orders(id INTEGER AUTO_INCREMENT, customer_id INTEGER, amount INTEGER, PRIMARY KEY id);
+		INSERT INTO orders(customer_id, amount) VALUES (2, 10), (1, 20), (2, 30);
+	`, nil)
+	require.NoError(t, err)
+
+	t.Run("tables of other databases should be joined", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, `
+			SELECT o.id, c.name, o.amount
+			FROM db1.orders AS o
+			INNER JOIN db2.customers AS c ON c.id = o.customer_id
+			WHERE o.amount > 10
+		`, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		expected := []struct {
+			id     int64
+			name   string
+			amount int64
+		}{
+			{id: 2, name: "customer1", amount: 20},
+			{id: 3, name: "customer2", amount: 30},
+		}
+
+		for _, e := range expected {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, e.id, row.ValuesByPosition[0].Value())
+			require.Equal(t, e.name, row.ValuesByPosition[1].Value())
+			require.Equal(t, e.amount, row.ValuesByPosition[2].Value())
+		}
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+
+	t.Run("tables of other databases should be queried", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT COUNT(*) FROM db2.customers WHERE name = 'customer2'", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(1), row.ValuesByPosition[0].Value())
+	})
+
+	t.Run("tables of other databases should not be modified", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "INSERT INTO db2.customers(name) VALUES ('customer3')", nil)
+		require.ErrorIs(t, err, ErrNoSupported)
+
+		_, _, err = engine.Exec(context.Background(), nil, "UPDATE db2.customers SET name = 'customer3' WHERE id = 1", nil)
+		require.ErrorIs(t, err, ErrNoSupported)
+
+		_, _, err = engine.Exec(context.Background(), nil, "DELETE FROM db2.customers WHERE id = 1", nil)
+		require.ErrorIs(t, err, ErrNoSupported)
+	})
+
+	t.Run("referencing a missing database should fail", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT * FROM db3.customers", nil)
+		require.ErrorIs(t, err, ErrDatabaseDoesNotExist)
+	})
+}
+
+func TestLateralJoin(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE DATABASE db1; USE DATABASE db1;", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE TABLE customers(id INTEGER AUTO_INCREMENT, name VARCHAR, PRIMARY KEY id);
+		CREATE TABLE orders(id INTEGER AUTO_INCREMENT, customer_id INTEGER, order_date INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON orders(customer_id, order_date);
+	`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO customers(name) VALUES ('customer1'), ('customer2'), ('customer3')", nil)
+	require.NoError(t, err)
+
+	// customer1 has 5 orders, customer2 has 2 orders and customer3 has none
+	for _, o := range []struct{ customerID, orderDate int64 }{
+		{1, 10}, {2, 15}, {1, 30}, {1, 20}, {1, 50}, {2, 5}, {1, 40},
+	} {
+		_, _, err = engine.Exec(
+			context.Background(),
+			nil,
+			"INSERT INTO orders(customer_id, order_date) VALUES (@customer_id, @order_date)",
+			map[string]interface{}{"customer_id": o.customerID, "order_date": o.orderDate},
+		)
+		require.NoError(t, err)
+	}
+
+	t.Run("lateral subqueries should return the top rows of each group", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, `
+			SELECT c.name, o.order_date
+			FROM customers AS c
+			INNER JOIN LATERAL (
+				SELECT order_date FROM orders
+				WHERE customer_id = c.id
+				ORDER BY order_date DESC
+				LIMIT 3
+			) AS o ON TRUE
+		`, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		expected := []struct {
+			name      string
+			orderDate int64
+		}{
+			{"customer1", 50},
+			{"customer1", 40},
+			{"customer1", 30},
+			{"customer2", 15},
+			{"customer2", 5},
+		}
+
+		for _, e := range expected {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, e.name, row.ValuesByPosition[0].Value())
+			require.Equal(t, e.orderDate, row.ValuesByPosition[1].Value())
+		}
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+
+	t.Run("lateral subqueries should stop scanning each group after the limit", func(t *testing.T) {
+		tx, err := engine.NewTx(context.Background(), DefaultTxOptions())
+		require.NoError(t, err)
+		defer tx.Cancel()
+
+		stmts, err := Parse(strings.NewReader(`
+			SELECT c.id, o.order_date
+			FROM customers AS c
+			INNER JOIN LATERAL (
+				SELECT order_date FROM orders WHERE customer_id = c.id ORDER BY order_date DESC LIMIT 3
+			) AS o ON TRUE
+		`))
+		require.NoError(t, err)
+
+		jspec := stmts[0].(*SelectStmt).joins[0]
+		require.True(t, jspec.lateral)
+
+		row := &Row{
+			ValuesByPosition: []TypedValue{&Number{val: 1}},
+			ValuesBySelector: map[string]TypedValue{EncodeSelector("", "db1", "c", "id"): &Number{val: 1}},
+		}
+
+		subquery := correlatedStmt(jspec.ds.(*SelectStmt), row, "db1")
+
+		scanSpecs, err := subquery.genScanSpecs(tx, nil)
+		require.NoError(t, err)
+		require.Equal(t, "orders[customer_id,order_date]", indexName("orders", scanSpecs.Index.cols))
+		require.True(t, scanSpecs.DescOrder)
+
+		customerIDCol, err := scanSpecs.Index.table.GetColumnByName("customer_id")
+		require.NoError(t, err)
+		require.Contains(t, scanSpecs.rangesByColID, customerIDCol.id)
+
+		reader, err := subquery.Resolve(context.Background(), tx, nil, nil)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		require.IsType(t, &limitRowReader{}, reader)
+	})
+
+	t.Run("parameters of lateral subqueries should be inferred", func(t *testing.T) {
+		params, err := engine.InferParameters(context.Background(), nil, `
+			SELECT c.name, o.order_date
+			FROM customers AS c
+			INNER JOIN LATERAL (
+				SELECT order_date FROM orders WHERE customer_id = c.id AND order_date > @since ORDER BY order_date DESC LIMIT 3
+			) AS o ON TRUE
+		`)
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"since": IntegerType}, params)
+	})
+
+	t.Run("lateral joins should only accept select statements", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, `
+			SELECT c.id
+			FROM customers AS c
+			INNER JOIN LATERAL (
+				SELECT id FROM orders WHERE customer_id = c.id
+				UNION
+				SELECT id FROM orders WHERE customer_id = c.id
+			) AS o ON TRUE
+		`, nil)
+		require.ErrorIs(t, err, ErrNoSupported)
+	})
+}
+
+func TestQueryWithStats(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE items (id INTEGER AUTO_INCREMENT, category INTEGER, title VARCHAR[50], PRIMARY KEY id);
+		CREATE INDEX ON items(category);
+	`, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO items(category, title) VALUES (@category, @title)",
+			map[string]interface{}{"category": i % 4, "title": fmt.Sprintf("title%d", 20-i)})
+		require.NoError(t, err)
+	}
+
+	queryWithStats := func(t *testing.T, q string) (int, *QueryStats) {
+		r, stats, err := engine.QueryWithStats(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+
+		rows := 0
+
+		for {
+			_, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			rows++
+		}
+
+		require.NoError(t, r.Close())
+
+		return rows, stats
+	}
+
+	t.Run("stats should be populated", func(t *testing.T) {
+		rows, stats := queryWithStats(t, "SELECT COUNT(*), MAX(title) FROM items")
+		require.Equal(t, 1, rows)
+
+		require.Equal(t, uint64(1), stats.RowsReturned)
+		require.Equal(t, uint64(20), stats.RowsScanned)
+		require.NotZero(t, stats.BytesRead)
+		require.NotZero(t, stats.ExecutionTime)
+		require.NotZero(t, stats.AggregationTime)
+		require.Len(t, stats.Sources, 1)
+		require.Equal(t, "items", stats.Sources[0].Table)
+		require.Equal(t, uint64(1), stats.Sources[0].Scans)
+		require.Equal(t, uint64(20), stats.Sources[0].RowsScanned)
+		require.Equal(t, stats.BytesRead, stats.Sources[0].BytesRead)
+	})
+
+	t.Run("in-memory sorting should be measured", func(t *testing.T) {
+		rows, stats := queryWithStats(t, "SELECT title FROM items ORDER BY title COLLATE NOCASE")
+		require.Equal(t, 20, rows)
+		require.NotZero(t, stats.SortTime)
+		require.Zero(t, stats.AggregationTime)
+	})
+
+	t.Run("indexed and full scans should be told apart", func(t *testing.T) {
+		indexedRows, indexedStats := queryWithStats(t, "SELECT id FROM items USE INDEX ON (category) WHERE category = 1")
+		fullRows, fullStats := queryWithStats(t, "SELECT id FROM items USE INDEX ON (id) WHERE category = 1")
+
+		require.Equal(t, 5, indexedRows)
+		require.Equal(t, indexedRows, fullRows)
+
+		require.Len(t, indexedStats.Sources, 1)
+		require.False(t, indexedStats.Sources[0].FullScan)
+		require.Equal(t, uint64(5), indexedStats.RowsScanned)
+
+		require.Len(t, fullStats.Sources, 1)
+		require.True(t, fullStats.Sources[0].FullScan)
+		require.Equal(t, uint64(20), fullStats.RowsScanned)
+
+		require.NotEqual(t, indexedStats.Sources[0].Index, fullStats.Sources[0].Index)
+	})
+
+	t.Run("joined tables should be reported once per index", func(t *testing.T) {
+		_, stats := queryWithStats(t, "SELECT i1.id FROM items AS i1 INNER JOIN items AS i2 ON i1.id = i2.id")
+		require.Len(t, stats.Sources, 2)
+		require.Equal(t, uint64(1), stats.Sources[0].Scans)
+		require.Equal(t, uint64(20), stats.Sources[1].Scans)
+		require.Equal(t, stats.RowsScanned, stats.Sources[0].RowsScanned+stats.Sources[1].RowsScanned)
+	})
+}
+
+func TestTupleInList(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE orders (id INTEGER AUTO_INCREMENT, customer INTEGER, product VARCHAR[20], amount INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON orders(product, customer);
+	`, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 30; i++ {
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO orders(customer, product, amount) VALUES (@customer, @product, @amount)",
+			map[string]interface{}{"customer": i % 5, "product": fmt.Sprintf("p%d", i%3), "amount": i})
+		require.NoError(t, err)
+	}
+
+	queryIDs := func(t *testing.T, q string, params map[string]interface{}) ([]int64, *QueryStats) {
+		r, stats, err := engine.QueryWithStats(context.Background(), nil, q, params)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var ids []int64
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.ValuesByPosition[0].Value().(int64))
+		}
+
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		return ids, stats
+	}
+
+	t.Run("rows should match any of the tuples", func(t *testing.T) {
+		// rows of customer 1 and product p1 have ids 2 and 17, the ones of customer 3 and product p0 have ids 4 and 19
+		// and the ones of customer 3 and product p1 have ids 14 and 29
+		ids, _ := queryIDs(t, "SELECT id FROM orders WHERE (customer, product) IN ((1, 'p1'), (3, 'p0'), (3, 'p1'))", nil)
+		require.Equal(t, []int64{2, 4, 14, 17, 19, 29}, ids)
+
+		ids, _ = queryIDs(t, "SELECT id FROM orders WHERE (customer, product) IN ((@c, @p)) AND amount > 5", map[string]interface{}{"c": 1, "p": "p1"})
+		require.Equal(t, []int64{17}, ids)
+
+		ids, _ = queryIDs(t, "SELECT id FROM orders WHERE (customer, amount) NOT IN ((0, 0), (1, 1), (2, 2)) AND id <= 5", nil)
+		require.Equal(t, []int64{4, 5}, ids)
+	})
+
+	t.Run("tuples of a different size should be rejected", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT id FROM orders WHERE (customer, product) IN ((1, 'p1'), (3))", nil)
+		require.Error(t, err)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT id FROM orders WHERE (customer, product) IN ((1, 'p1'), (3, 'p0', 1))", nil)
+		require.ErrorIs(t, err, ErrInvalidNumberOfValues)
+
+		_, err = engine.InferParameters(context.Background(), nil, "SELECT id FROM orders WHERE (customer, product) IN ((1, @p, 2))")
+		require.ErrorIs(t, err, ErrInvalidNumberOfValues)
+	})
+
+	t.Run("values should be of the type of the matched columns", func(t *testing.T) {
+		params, err := engine.InferParameters(context.Background(), nil, "SELECT id FROM orders WHERE (customer, product) IN ((@c, @p))")
+		require.NoError(t, err)
+		require.Equal(t, IntegerType, params["c"])
+		require.Equal(t, VarcharType, params["p"])
+
+		_, err = engine.InferParameters(context.Background(), nil, "SELECT id FROM orders WHERE (customer, product) IN ((1, 2))")
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+
+	t.Run("tuples covered by an index should be looked up", func(t *testing.T) {
+		ids, stats := queryIDs(t, "SELECT id FROM orders WHERE (customer, product) IN ((1, 'p1'), (3, 'p0'), (1, 'p1'))", nil)
+		require.Equal(t, []int64{2, 4, 17, 19}, ids)
+
+		require.Len(t, stats.Sources, 1)
+		require.Equal(t, "orders[product,customer]", stats.Sources[0].Index)
+		require.False(t, stats.Sources[0].FullScan)
+		// duplicated tuples are looked up once
+		require.Equal(t, uint64(2), stats.Sources[0].Scans)
+		require.Equal(t, uint64(4), stats.RowsScanned)
+
+		// tuples not covered by an index are matched while scanning the table
+		ids, stats = queryIDs(t, "SELECT id FROM orders WHERE (customer, amount) IN ((1, 1), (2, 7))", nil)
+		require.Equal(t, []int64{2, 8}, ids)
+		require.True(t, stats.Sources[0].FullScan)
+		require.Equal(t, uint64(30), stats.RowsScanned)
+	})
+}
+
+func TestCommonTableExpressions(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE customers (id INTEGER AUTO_INCREMENT, name VARCHAR[20], referrer INTEGER, PRIMARY KEY id);
+		CREATE TABLE orders (id INTEGER AUTO_INCREMENT, customer INTEGER, amount INTEGER, PRIMARY KEY id);
+
+		INSERT INTO customers(name, referrer) VALUES ('alice', 0), ('bob', 1), ('carol', 1), ('dave', 3);
+		INSERT INTO orders(customer, amount) VALUES (1, 10), (2, 200), (3, 150), (2, 50), (4, 300);
+	`, nil)
+	require.NoError(t, err)
+
+	queryRows := func(t *testing.T, q string) [][]interface{} {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var rows [][]interface{}
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			values := make([]interface{}, len(row.ValuesByPosition))
+			for i, v := range row.ValuesByPosition {
+				values[i] = v.Value()
+			}
+
+			rows = append(rows, values)
+		}
+
+		return rows
+	}
+
+	t.Run("a single CTE should be queried as a table", func(t *testing.T) {
+		rows := queryRows(t, `
+			WITH big_orders AS (SELECT id, customer, amount FROM orders WHERE amount >= 100)
+			SELECT big_orders.id, customers.name FROM big_orders INNER JOIN customers ON customers.id = big_orders.customer
+		`)
+		require.Equal(t, [][]interface{}{{int64(2), "bob"}, {int64(3), "carol"}, {int64(5), "dave"}}, rows)
+	})
+
+	t.Run("chained CTEs should reference the previous ones", func(t *testing.T) {
+		rows := queryRows(t, `
+			WITH
+				big_orders AS (SELECT id, customer FROM orders WHERE amount >= 100),
+				big_customers AS (SELECT DISTINCT customer FROM big_orders WHERE customer > 2)
+			SELECT customer FROM big_customers
+		`)
+		require.Equal(t, [][]interface{}{{int64(3)}, {int64(4)}}, rows)
+	})
+
+	t.Run("a CTE referenced twice should be evaluated for each reference", func(t *testing.T) {
+		rows := queryRows(t, `
+			WITH referred AS (SELECT id, name, referrer FROM customers WHERE referrer > 0)
+			SELECT r1.name, r2.name FROM referred AS r1 INNER JOIN referred AS r2 ON r2.referrer = r1.id
+		`)
+		require.Equal(t, [][]interface{}{{"carol", "dave"}}, rows)
+	})
+
+	t.Run("a CTE should shadow a table of the same name", func(t *testing.T) {
+		rows := queryRows(t, `
+			WITH orders AS (SELECT id FROM orders WHERE customer = 2)
+			SELECT id FROM orders
+		`)
+		require.Equal(t, [][]interface{}{{int64(2)}, {int64(4)}}, rows)
+	})
+
+	t.Run("a CTE should be referenced within subqueries", func(t *testing.T) {
+		rows := queryRows(t, `
+			WITH small_orders AS (SELECT id, amount FROM orders WHERE amount < 100)
+			SELECT COUNT(*) FROM (SELECT id FROM small_orders WHERE amount > 20)
+		`)
+		require.Equal(t, [][]interface{}{{int64(1)}}, rows)
+	})
+
+	t.Run("undefined CTEs should be reported as missing tables", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, `
+			WITH first AS (SELECT id FROM second), second AS (SELECT id FROM orders)
+			SELECT id FROM first
+		`, nil)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+	})
+}
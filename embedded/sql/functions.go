@@ -0,0 +1,943 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+var ErrFunctionAlreadyRegistered = errors.New("function already registered")
+var ErrFunctionInUse = errors.New("function in use")
+
+// Function is a scalar function which can be called from SQL statements
+type Function struct {
+	// ParamTypes holds the type of each argument, AnyType accepts arguments of any type
+	ParamTypes []SQLValueType
+	// Variadic makes the last argument repeatable, the function accepts one or more arguments of such type
+	Variadic bool
+	// ResultType is the type of the values returned by the function
+	ResultType SQLValueType
+	// Eval computes the result of the function. Arguments are already checked against ParamTypes,
+	// but any of them may be NULL
+	Eval func(tx *SQLTx, params []TypedValue) (TypedValue, error)
+	// Volatile functions may return different results for the same arguments, e.g. NOW.
+	// Only functions which are not volatile can be used in index expressions
+	Volatile bool
+
+	// sameTypeParams requires all the arguments to be of the same type, which is also the type of the result
+	sameTypeParams bool
+	// maxParams limits the number of arguments of variadic functions, there is no limit when zero
+	maxParams int
+}
+
+var builtinFunctions = map[string]*Function{
+	NowFnCall: {
+		ResultType: TimestampType,
+		Volatile:   true,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			return &Timestamp{val: tx.Timestamp().Truncate(time.Microsecond).UTC()}, nil
+		},
+	},
+	// CURRENT_DATE, CURRENT_TIME and CURRENT_TIMESTAMP are evaluated in UTC at the timestamp of the
+	// transaction, as NOW is, thus all the references within a statement agree. There is no type for
+	// the time of the day, CURRENT_TIME returns it as a VARCHAR formatted as HH:MM:SS.ffffff
+	CurrentDateFnCall: {
+		ResultType: TimestampType,
+		Volatile:   true,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			t := tx.Timestamp().UTC()
+			return &Timestamp{val: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}, nil
+		},
+	},
+	CurrentTimeFnCall: {
+		ResultType: VarcharType,
+		Volatile:   true,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			return &Varchar{val: tx.Timestamp().UTC().Format("15:04:05.000000")}, nil
+		},
+	},
+	CurrentTimestampFnCall: {
+		ResultType: TimestampType,
+		Volatile:   true,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			return &Timestamp{val: tx.Timestamp().Truncate(time.Microsecond).UTC()}, nil
+		},
+	},
+	// GREATEST and LEAST ignore NULL arguments, NULL is returned only when all the arguments are NULL
+	GreatestFnCall: {
+		ParamTypes:     []SQLValueType{AnyType},
+		Variadic:       true,
+		ResultType:     AnyType,
+		Eval:           extremeValue(1),
+		sameTypeParams: true,
+	},
+	LeastFnCall: {
+		ParamTypes:     []SQLValueType{AnyType},
+		Variadic:       true,
+		ResultType:     AnyType,
+		Eval:           extremeValue(-1),
+		sameTypeParams: true,
+	},
+	// CONCAT skips NULL arguments, an empty string is returned when all the arguments are NULL.
+	// Arguments of other types are converted to VARCHAR as done by the || operator
+	ConcatFnCall: {
+		ParamTypes: []SQLValueType{AnyType},
+		Variadic:   true,
+		ResultType: VarcharType,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			var b strings.Builder
+
+			for _, p := range params {
+				if p.IsNull() {
+					continue
+				}
+
+				s, err := stringify(p)
+				if err != nil {
+					return nil, err
+				}
+
+				b.WriteString(s)
+			}
+
+			return &Varchar{val: b.String()}, nil
+		},
+	},
+	// MASK replaces every character of a string but the last given ones with '*',
+	// e.g. MASK(card_number, 4) only reveals the last four digits
+	MaskFnCall: {
+		ParamTypes: []SQLValueType{VarcharType, IntegerType},
+		ResultType: VarcharType,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			if params[0].IsNull() || params[1].IsNull() {
+				return &NullValue{t: VarcharType}, nil
+			}
+
+			runes := []rune(params[0].Value().(string))
+
+			visible := params[1].Value().(int64)
+			if visible < 0 {
+				return nil, fmt.Errorf("%w: the number of visible characters must not be negative", ErrIllegalArguments)
+			}
+
+			for i := 0; i < len(runes)-int(visible); i++ {
+				runes[i] = '*'
+			}
+
+			return &Varchar{val: string(runes)}, nil
+		},
+	},
+	// SHA256 returns the hex encoded SHA-256 digest of a string, equal values yield equal digests
+	// so masked values can still be compared or grouped
+	SHA256FnCall: {
+		ParamTypes: []SQLValueType{VarcharType},
+		ResultType: VarcharType,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			if params[0].IsNull() {
+				return &NullValue{t: VarcharType}, nil
+			}
+
+			digest := sha256.Sum256([]byte(params[0].Value().(string)))
+
+			return &Varchar{val: hex.EncodeToString(digest[:])}, nil
+		},
+	},
+	// ABS returns the absolute value of an integer, the smallest integer has no positive counterpart
+	// thus it results in an overflow error
+	AbsFnCall: {
+		ParamTypes: []SQLValueType{IntegerType},
+		ResultType: IntegerType,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			if params[0].IsNull() {
+				return &NullValue{t: IntegerType}, nil
+			}
+
+			n := params[0].Value().(int64)
+
+			if n == math.MinInt64 {
+				return nil, fmt.Errorf("%w: integer overflow", ErrIllegalArguments)
+			}
+
+			if n < 0 {
+				n = -n
+			}
+
+			return &Number{val: n}, nil
+		},
+	},
+	// CEIL and FLOOR return the nearest integer not less and not greater than the argument,
+	// being INTEGER the only numeric type, the argument is returned as is
+	CeilFnCall: {
+		ParamTypes: []SQLValueType{IntegerType},
+		ResultType: IntegerType,
+		Eval:       identity,
+	},
+	FloorFnCall: {
+		ParamTypes: []SQLValueType{IntegerType},
+		ResultType: IntegerType,
+		Eval:       identity,
+	},
+	// ROUND(x [, digits]) rounds x to the given number of decimal digits, zero by default.
+	// Negative digits round to the left of the decimal point, e.g. ROUND(1250, -2) = 1300.
+	// Halfway values are rounded away from zero, e.g. ROUND(-1250, -2) = -1300.
+	// Integers have no fractional digits, thus non-negative digits return x as is
+	RoundFnCall: {
+		ParamTypes: []SQLValueType{IntegerType},
+		Variadic:   true,
+		ResultType: IntegerType,
+		maxParams:  2,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			for _, p := range params {
+				if p.IsNull() {
+					return &NullValue{t: IntegerType}, nil
+				}
+			}
+
+			n := params[0].Value().(int64)
+
+			if len(params) == 1 || params[1].Value().(int64) >= 0 {
+				return &Number{val: n}, nil
+			}
+
+			rounded, err := roundInteger(n, -params[1].Value().(int64))
+			if err != nil {
+				return nil, err
+			}
+
+			return &Number{val: rounded}, nil
+		},
+	},
+	// EXTRACT(field FROM ts) returns a field of a timestamp as an INTEGER, timestamps are always in UTC.
+	// Supported fields are YEAR, MONTH (1 to 12), DAY (1 to 31), HOUR, MINUTE, SECOND,
+	// DOW (day of the week, 0 for Sunday to 6 for Saturday) and EPOCH (seconds since 1970-01-01 00:00:00 UTC).
+	// Being INTEGER the only numeric type, fractions of a second are discarded by SECOND and EPOCH
+	ExtractFnCall: {
+		ParamTypes: []SQLValueType{VarcharType, TimestampType},
+		ResultType: IntegerType,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			if params[0].IsNull() {
+				return nil, fmt.Errorf("%w: the field to extract must not be NULL", ErrIllegalArguments)
+			}
+
+			field := strings.ToUpper(params[0].Value().(string))
+
+			extract, ok := timestampFields[field]
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown field '%s' to extract from timestamp", ErrIllegalArguments, field)
+			}
+
+			if params[1].IsNull() {
+				return &NullValue{t: IntegerType}, nil
+			}
+
+			return &Number{val: extract(params[1].Value().(time.Time).UTC())}, nil
+		},
+	},
+	// LOWER and UPPER convert a string to lower and upper case, following Unicode case mapping
+	LowerFnCall: {
+		ParamTypes: []SQLValueType{VarcharType},
+		ResultType: VarcharType,
+		Eval:       mapString(strings.ToLower),
+	},
+	UpperFnCall: {
+		ParamTypes: []SQLValueType{VarcharType},
+		ResultType: VarcharType,
+		Eval:       mapString(strings.ToUpper),
+	},
+	// TRIM, LTRIM and RTRIM remove the given characters from both ends, the start and the end of a string.
+	// The optional second argument is the set of characters to be removed, whitespace by default.
+	// Strings are trimmed by character, not by byte, thus UTF-8 characters in the set are removed as a whole,
+	// e.g. LTRIM('0042', '0') = '42' and TRIM('¡¿hola?!', '¡¿?!') = 'hola'
+	TrimFnCall: {
+		ParamTypes: []SQLValueType{VarcharType},
+		Variadic:   true,
+		ResultType: VarcharType,
+		Eval:       trimString(strings.Trim, strings.TrimSpace),
+		maxParams:  2,
+	},
+	LTrimFnCall: {
+		ParamTypes: []SQLValueType{VarcharType},
+		Variadic:   true,
+		ResultType: VarcharType,
+		Eval: trimString(strings.TrimLeft, func(s string) string {
+			return strings.TrimLeftFunc(s, unicode.IsSpace)
+		}),
+		maxParams: 2,
+	},
+	RTrimFnCall: {
+		ParamTypes: []SQLValueType{VarcharType},
+		Variadic:   true,
+		ResultType: VarcharType,
+		Eval: trimString(strings.TrimRight, func(s string) string {
+			return strings.TrimRightFunc(s, unicode.IsSpace)
+		}),
+		maxParams: 2,
+	},
+	// INSTR(str, sub), also written as POSITION(sub IN str), returns the position of the first occurrence of sub in str,
+	// counted in characters from 1, or 0 when not found. The empty string is found at the start of any string,
+	// e.g. INSTR('añejo', 'e') = 3 and INSTR('abc', '') = 1
+	InstrFnCall: {
+		ParamTypes: []SQLValueType{VarcharType, VarcharType},
+		ResultType: IntegerType,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			if params[0].IsNull() || params[1].IsNull() {
+				return &NullValue{t: IntegerType}, nil
+			}
+
+			s := params[0].Value().(string)
+
+			i := strings.Index(s, params[1].Value().(string))
+			if i < 0 {
+				return &Number{val: 0}, nil
+			}
+
+			return &Number{val: int64(utf8.RuneCountInString(s[:i])) + 1}, nil
+		},
+	},
+	// SUBSTR(str, start [, length]) returns the characters of str from position start, counted from 1,
+	// up to the end of the string or up to length characters. Positions out of the string are ignored,
+	// e.g. SUBSTR('añejo', 2, 3) = 'ñej', SUBSTR('abc', 0, 2) = 'a' and SUBSTR('abc', 5) = ''
+	SubstrFnCall: {
+		ParamTypes: []SQLValueType{VarcharType, IntegerType},
+		Variadic:   true,
+		ResultType: VarcharType,
+		maxParams:  3,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			for _, p := range params {
+				if p.IsNull() {
+					return &NullValue{t: VarcharType}, nil
+				}
+			}
+
+			rs := []rune(params[0].Value().(string))
+
+			from := params[1].Value().(int64)
+			to := int64(len(rs)) + 1
+
+			if len(params) == 3 {
+				length := params[2].Value().(int64)
+				if length < 0 {
+					return nil, fmt.Errorf("%w: negative length in function '%s'", ErrIllegalArguments, SubstrFnCall)
+				}
+
+				if length < to-from {
+					to = from + length
+				}
+			}
+
+			if from < 1 {
+				from = 1
+			}
+
+			if to <= from {
+				return &Varchar{val: ""}, nil
+			}
+
+			return &Varchar{val: string(rs[from-1 : to-1])}, nil
+		},
+	},
+	// REPLACE(str, from, to) replaces every occurrence of from in str with to, occurrences are searched from the
+	// start of the string and don't overlap, e.g. REPLACE('aaa', 'aa', 'b') = 'ba'. Nothing is replaced when from is empty
+	ReplaceFnCall: {
+		ParamTypes: []SQLValueType{VarcharType, VarcharType, VarcharType},
+		ResultType: VarcharType,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			for _, p := range params {
+				if p.IsNull() {
+					return &NullValue{t: VarcharType}, nil
+				}
+			}
+
+			s := params[0].Value().(string)
+			from := params[1].Value().(string)
+
+			if from == "" {
+				return &Varchar{val: s}, nil
+			}
+
+			return &Varchar{val: strings.ReplaceAll(s, from, params[2].Value().(string))}, nil
+		},
+	},
+	// REVERSE returns the characters of a string in reverse order, e.g. REVERSE('añejo') = 'ojeña'
+	ReverseFnCall: {
+		ParamTypes: []SQLValueType{VarcharType},
+		ResultType: VarcharType,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			if params[0].IsNull() {
+				return &NullValue{t: VarcharType}, nil
+			}
+
+			rs := []rune(params[0].Value().(string))
+
+			for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+				rs[i], rs[j] = rs[j], rs[i]
+			}
+
+			return &Varchar{val: string(rs)}, nil
+		},
+	},
+	// DATE truncates a timestamp to the start of its day, timestamps are always in UTC
+	DateFnCall: {
+		ParamTypes: []SQLValueType{TimestampType},
+		ResultType: TimestampType,
+		Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+			if params[0].IsNull() {
+				return &NullValue{t: TimestampType}, nil
+			}
+
+			t := params[0].Value().(time.Time).UTC()
+
+			return &Timestamp{val: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}, nil
+		},
+	},
+	// HEX and TO_BASE64 encode a BLOB as a string, UNHEX and FROM_BASE64 decode it back.
+	// Base64 strings use the standard alphabet with padding, as defined in RFC 4648
+	HexFnCall: {
+		ParamTypes: []SQLValueType{BLOBType},
+		ResultType: VarcharType,
+		Eval:       encodeBlob(hex.EncodeToString),
+	},
+	UnhexFnCall: {
+		ParamTypes: []SQLValueType{VarcharType},
+		ResultType: BLOBType,
+		Eval:       decodeBlob("hex", hex.DecodeString),
+	},
+	ToBase64FnCall: {
+		ParamTypes: []SQLValueType{BLOBType},
+		ResultType: VarcharType,
+		Eval:       encodeBlob(base64.StdEncoding.EncodeToString),
+	},
+	FromBase64FnCall: {
+		ParamTypes: []SQLValueType{VarcharType},
+		ResultType: BLOBType,
+		Eval:       decodeBlob("base64", base64.StdEncoding.DecodeString),
+	},
+}
+
+var timestampFields = map[string]func(t time.Time) int64{
+	"YEAR":   func(t time.Time) int64 { return int64(t.Year()) },
+	"MONTH":  func(t time.Time) int64 { return int64(t.Month()) },
+	"DAY":    func(t time.Time) int64 { return int64(t.Day()) },
+	"HOUR":   func(t time.Time) int64 { return int64(t.Hour()) },
+	"MINUTE": func(t time.Time) int64 { return int64(t.Minute()) },
+	"SECOND": func(t time.Time) int64 { return int64(t.Second()) },
+	"DOW":    func(t time.Time) int64 { return int64(t.Weekday()) },
+	"EPOCH":  func(t time.Time) int64 { return t.Unix() },
+}
+
+func identity(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+	return params[0], nil
+}
+
+func mapString(fn func(string) string) func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+	return func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+		if params[0].IsNull() {
+			return &NullValue{t: VarcharType}, nil
+		}
+
+		return &Varchar{val: fn(params[0].Value().(string))}, nil
+	}
+}
+
+// trimString returns the function trimming a string either by the set of characters given as second argument
+// or, when not given, by whitespace
+func trimString(trim func(s, cutset string) string, trimSpace func(s string) string) func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+	return func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+		for _, p := range params {
+			if p.IsNull() {
+				return &NullValue{t: VarcharType}, nil
+			}
+		}
+
+		s := params[0].Value().(string)
+
+		if len(params) == 1 {
+			return &Varchar{val: trimSpace(s)}, nil
+		}
+
+		return &Varchar{val: trim(s, params[1].Value().(string))}, nil
+	}
+}
+
+func encodeBlob(encode func([]byte) string) func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+	return func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+		if params[0].IsNull() {
+			return &NullValue{t: VarcharType}, nil
+		}
+
+		return &Varchar{val: encode(params[0].Value().([]byte))}, nil
+	}
+}
+
+func decodeBlob(encoding string, decode func(string) ([]byte, error)) func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+	return func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+		if params[0].IsNull() {
+			return &NullValue{t: BLOBType}, nil
+		}
+
+		b, err := decode(params[0].Value().(string))
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed %s string: %v", ErrIllegalArguments, encoding, err)
+		}
+
+		return &Blob{val: b}, nil
+	}
+}
+
+// roundInteger rounds n to a multiple of 10^digits, halfway values are rounded away from zero
+func roundInteger(n int64, digits int64) (int64, error) {
+	// 10^19 exceeds the largest integer, every integer rounds either to zero or to an overflowing value
+	if digits > 18 {
+		if digits == 19 && (n >= 5e18 || n <= -5e18) {
+			return 0, fmt.Errorf("%w: integer overflow", ErrIllegalArguments)
+		}
+
+		return 0, nil
+	}
+
+	factor := int64(1)
+	for i := int64(0); i < digits; i++ {
+		factor *= 10
+	}
+
+	q, r := n/factor, n%factor
+
+	if r >= factor-r {
+		q++
+	} else if -r >= factor+r {
+		q--
+	}
+
+	if q > math.MaxInt64/factor || q < math.MinInt64/factor {
+		return 0, fmt.Errorf("%w: integer overflow", ErrIllegalArguments)
+	}
+
+	return q * factor, nil
+}
+
+// extremeValue returns the function selecting the non-NULL argument which compares as cmp to all the others
+func extremeValue(cmp int) func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+	return func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+		res := params[0]
+
+		for _, p := range params[1:] {
+			if p.IsNull() {
+				continue
+			}
+
+			if res.IsNull() {
+				res = p
+				continue
+			}
+
+			c, err := p.Compare(res)
+			if err != nil {
+				return nil, err
+			}
+
+			if c == cmp {
+				res = p
+			}
+		}
+
+		return res, nil
+	}
+}
+
+// validateFunction checks the signature of a function before registering it
+func validateFunction(name string, fn *Function) error {
+	if name == "" || fn == nil || fn.Eval == nil {
+		return ErrIllegalArguments
+	}
+
+	if fn.Variadic && len(fn.ParamTypes) == 0 {
+		return fmt.Errorf("%w: variadic functions require at least one argument type", ErrIllegalArguments)
+	}
+
+	for _, t := range fn.ParamTypes {
+		if t != AnyType && !validType(t) {
+			return fmt.Errorf("%w: unsupported argument type %v", ErrIllegalArguments, t)
+		}
+	}
+
+	if !validType(fn.ResultType) && !fn.sameTypeParams {
+		return fmt.Errorf("%w: unsupported result type %v", ErrIllegalArguments, fn.ResultType)
+	}
+
+	_, builtin := builtinFunctions[strings.ToUpper(name)]
+	if builtin {
+		return fmt.Errorf("%w: %s", ErrFunctionAlreadyRegistered, strings.ToUpper(name))
+	}
+
+	return nil
+}
+
+// RegisterFunction makes a scalar function callable by name from the statements run by the engine.
+// Function names are case insensitive
+func (e *Engine) RegisterFunction(name string, fn *Function) error {
+	err := validateFunction(name, fn)
+	if err != nil {
+		return err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	fnName := strings.ToUpper(name)
+
+	_, exists := e.functions[fnName]
+	if exists {
+		return fmt.Errorf("%w: %s", ErrFunctionAlreadyRegistered, fnName)
+	}
+
+	// the registered functions are never modified in place, as transactions keep a reference to them
+	functions := make(map[string]*Function, len(e.functions)+1)
+
+	for n, f := range e.functions {
+		functions[n] = f
+	}
+
+	functions[fnName] = fn
+
+	e.functions = functions
+
+	return nil
+}
+
+// UnregisterFunction removes a previously registered function.
+// Builtin functions and functions called by expression indexes can not be unregistered
+func (e *Engine) UnregisterFunction(ctx context.Context, name string) error {
+	fnName := strings.ToUpper(name)
+
+	_, builtin := builtinFunctions[fnName]
+	if builtin {
+		return fmt.Errorf("%w: builtin function %s can not be unregistered", ErrIllegalArguments, fnName)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	_, exists := e.functions[fnName]
+	if !exists {
+		return nil
+	}
+
+	tx, err := e.store.NewTx(ctx, store.DefaultTxOptions().WithMode(store.ReadOnlyTx))
+	if err != nil {
+		return err
+	}
+	defer tx.Cancel()
+
+	catalog := newCatalog(e.functions)
+
+	err = catalog.load(e.prefix, tx)
+	if err != nil {
+		return err
+	}
+
+	if catalog.indexedFunction(fnName) {
+		return fmt.Errorf("%w: function %s is called by an expression index", ErrFunctionInUse, fnName)
+	}
+
+	functions := make(map[string]*Function, len(e.functions))
+
+	for n, f := range e.functions {
+		if n != fnName {
+			functions[n] = f
+		}
+	}
+
+	e.functions = functions
+
+	return nil
+}
+
+// indexedFunction returns true if the function is called by an expression index of any table
+func (c *Catalog) indexedFunction(fnName string) bool {
+	for _, db := range c.dbsByID {
+		for _, table := range db.tables {
+			for _, col := range table.exprCols {
+				if callsFunction(col.expr, fnName) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func callsFunction(exp ValueExp, fnName string) bool {
+	fnCall, isFnCall := exp.(*FnCall)
+	if isFnCall && strings.ToUpper(fnCall.fn) == fnName {
+		return true
+	}
+
+	for _, e := range subExps(exp) {
+		if callsFunction(e, fnName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lookupFunction returns a builtin function or one registered in the engine when the catalog was loaded
+func (c *Catalog) lookupFunction(name string) (*Function, error) {
+	fnName := strings.ToUpper(name)
+
+	fn, ok := builtinFunctions[fnName]
+	if ok {
+		return fn, nil
+	}
+
+	fn, ok = c.functions[fnName]
+	if !ok {
+		return nil, fmt.Errorf("%w: unkown function %s", ErrIllegalArguments, name)
+	}
+
+	return fn, nil
+}
+
+// lookupFunction returns the function bound to the call when the statement was planned,
+// calls which were not bound may only refer to builtin functions
+func (v *FnCall) lookupFunction() (*Function, error) {
+	if v.function != nil {
+		return v.function, nil
+	}
+
+	fn, ok := builtinFunctions[strings.ToUpper(v.fn)]
+	if !ok {
+		return nil, fmt.Errorf("%w: unkown function %s", ErrIllegalArguments, v.fn)
+	}
+
+	return fn, nil
+}
+
+// bindFunctions resolves the functions called from the statement, so each function is looked up
+// once when the statement is planned instead of each time a call is evaluated.
+// Unknown functions are left unbound and reported when the call is checked or evaluated
+func (c *Catalog) bindFunctions(stmt SQLStmt) {
+	switch s := stmt.(type) {
+	case *CreateTableStmt:
+		for _, cs := range s.colsSpec {
+			c.bindExpFunctions(cs.defaultValue)
+		}
+	case *AddColumnStmt:
+		if s.colSpec != nil {
+			c.bindExpFunctions(s.colSpec.defaultValue)
+		}
+	case *CreateIndexStmt:
+		for _, e := range s.exprs {
+			c.bindExpFunctions(e)
+		}
+	case *UpsertIntoStmt:
+		c.bindFunctions(s.tableRef)
+
+		for _, row := range s.rows {
+			for _, v := range row.Values {
+				c.bindExpFunctions(v)
+			}
+		}
+	case *DeleteFromStmt:
+		c.bindFunctions(s.tableRef)
+		c.bindExpFunctions(s.where, s.limit, s.offset)
+	case *UpdateStmt:
+		c.bindFunctions(s.tableRef)
+		c.bindFunctions(s.from)
+		c.bindExpFunctions(s.where, s.limit, s.offset)
+
+		for _, u := range s.updates {
+			c.bindExpFunctions(u.val)
+		}
+	case *SelectStmt:
+		if s == nil {
+			return
+		}
+
+		for _, cte := range s.ctes {
+			c.bindFunctions(cte.ds)
+		}
+
+		for _, sel := range s.selectors {
+			c.bindExpFunctions(sel)
+		}
+
+		c.bindFunctions(s.ds)
+
+		for _, j := range s.joins {
+			c.bindFunctions(j.ds)
+			c.bindExpFunctions(j.cond)
+		}
+
+		c.bindExpFunctions(s.where, s.having, s.limit, s.offset)
+	case *UnionStmt:
+		c.bindFunctions(s.left)
+		c.bindFunctions(s.right)
+	case *SetOpStmt:
+		c.bindFunctions(s.left)
+		c.bindFunctions(s.right)
+	case *tableRef:
+		if s == nil {
+			return
+		}
+
+		for _, p := range []*openPeriod{s.period.start, s.period.end} {
+			if p != nil {
+				c.bindExpFunctions(p.instant.exp)
+			}
+		}
+
+		if s.sample != nil {
+			c.bindExpFunctions(s.sample.percentage, s.sample.seed)
+		}
+	case *viewRef:
+		c.bindFunctions(s.query)
+	case *FnDataSourceStmt:
+		if s.fnCall != nil {
+			c.bindExpFunctions(s.fnCall.params...)
+		}
+	}
+}
+
+func (c *Catalog) bindExpFunctions(exps ...ValueExp) {
+	for _, exp := range exps {
+		switch e := exp.(type) {
+		case *FnCall:
+			e.function, _ = c.lookupFunction(e.fn)
+		case *ExistsBoolExp:
+			c.bindFunctions(e.q)
+		case *InSubQueryExp:
+			c.bindExpFunctions(e.val)
+			c.bindFunctions(e.q)
+		}
+
+		if exp != nil {
+			c.bindExpFunctions(subExps(exp)...)
+		}
+	}
+}
+
+func (fn *Function) checkArity(name string, argCount int) error {
+	if fn.Variadic && argCount < len(fn.ParamTypes) {
+		return fmt.Errorf("%w: function '%s' expects at least %d arguments but %d were provided", ErrIllegalArguments, name, len(fn.ParamTypes), argCount)
+	}
+
+	if fn.Variadic && fn.maxParams > 0 && argCount > fn.maxParams {
+		return fmt.Errorf("%w: function '%s' expects at most %d arguments but %d were provided", ErrIllegalArguments, name, fn.maxParams, argCount)
+	}
+
+	if !fn.Variadic && argCount != len(fn.ParamTypes) {
+		return fmt.Errorf("%w: function '%s' expects %d arguments but %d were provided", ErrIllegalArguments, name, len(fn.ParamTypes), argCount)
+	}
+
+	return nil
+}
+
+func (fn *Function) paramType(i int) SQLValueType {
+	if i >= len(fn.ParamTypes) {
+		// only variadic functions accept additional arguments
+		return fn.ParamTypes[len(fn.ParamTypes)-1]
+	}
+
+	return fn.ParamTypes[i]
+}
+
+// checkFnCalls validates function calls in the expression against the signature of the called functions,
+// so invalid calls are detected before reading any row
+func checkFnCalls(ctx context.Context, rowReader RowReader, exp ValueExp) error {
+	var fnCalls []*FnCall
+
+	collectFnCalls(exp, &fnCalls)
+
+	if len(fnCalls) == 0 {
+		return nil
+	}
+
+	cols, err := rowReader.colsBySelector(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, fnCall := range fnCalls {
+		_, err := fnCall.inferType(cols, make(map[string]SQLValueType), rowReader.Database(), rowReader.TableAlias())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func collectFnCalls(exp ValueExp, fnCalls *[]*FnCall) {
+	fnCall, isFnCall := exp.(*FnCall)
+	if isFnCall {
+		*fnCalls = append(*fnCalls, fnCall)
+		return
+	}
+
+	for _, e := range subExps(exp) {
+		collectFnCalls(e, fnCalls)
+	}
+}
+
+func validType(t SQLValueType) bool {
+	_, err := asType(t)
+	return err == nil
+}
+
+// NewInteger returns an INTEGER value
+func NewInteger(val int64) TypedValue {
+	return &Number{val: val}
+}
+
+// NewVarchar returns a VARCHAR value
+func NewVarchar(val string) TypedValue {
+	return &Varchar{val: val}
+}
+
+// NewBool returns a BOOLEAN value
+func NewBool(val bool) TypedValue {
+	return &Bool{val: val}
+}
+
+// NewBlob returns a BLOB value
+func NewBlob(val []byte) TypedValue {
+	return &Blob{val: val}
+}
+
+// NewTimestamp returns a TIMESTAMP value
+func NewTimestamp(val time.Time) TypedValue {
+	return &Timestamp{val: val.Truncate(time.Microsecond).UTC()}
+}
+
+// NewNull returns a NULL value of the given type
+func NewNull(t SQLValueType) TypedValue {
+	return &NullValue{t: t}
+}
@@ -0,0 +1,1196 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterFunction(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	err := engine.RegisterFunction("", &Function{ResultType: IntegerType})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = engine.RegisterFunction("fn", nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = engine.RegisterFunction("fn", &Function{ResultType: IntegerType})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	eval := func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+		return NewNull(IntegerType), nil
+	}
+
+	err = engine.RegisterFunction("fn", &Function{ParamTypes: []SQLValueType{"FLOAT"}, ResultType: IntegerType, Eval: eval})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = engine.RegisterFunction("fn", &Function{ResultType: AnyType, Eval: eval})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = engine.RegisterFunction("fn", &Function{Variadic: true, ResultType: IntegerType, Eval: eval})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = engine.RegisterFunction("now", &Function{ResultType: TimestampType, Eval: eval})
+	require.ErrorIs(t, err, ErrFunctionAlreadyRegistered)
+
+	err = engine.RegisterFunction("fn", &Function{ResultType: IntegerType, Eval: eval})
+	require.NoError(t, err)
+
+	err = engine.RegisterFunction("FN", &Function{ResultType: IntegerType, Eval: eval})
+	require.ErrorIs(t, err, ErrFunctionAlreadyRegistered)
+
+	catalog, err := engine.Catalog(context.Background(), nil)
+	require.NoError(t, err)
+
+	_, err = catalog.lookupFunction("Fn")
+	require.NoError(t, err)
+
+	err = engine.UnregisterFunction(context.Background(), "FN")
+	require.NoError(t, err)
+
+	catalog, err = engine.Catalog(context.Background(), nil)
+	require.NoError(t, err)
+
+	_, err = catalog.lookupFunction("fn")
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	for _, name := range []string{"now", "Lower", "TRIM"} {
+		err = engine.UnregisterFunction(context.Background(), name)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = catalog.lookupFunction(name)
+		require.NoError(t, err)
+	}
+
+	t.Run("functions should be validated when creating the engine", func(t *testing.T) {
+		st, err := store.Open(t.TempDir(), store.DefaultOptions())
+		require.NoError(t, err)
+		defer closeStore(t, st)
+
+		_, err = NewEngine(st, DefaultOptions().WithFunctions(map[string]*Function{"fn": {ResultType: IntegerType}}))
+		require.ErrorIs(t, err, store.ErrInvalidOptions)
+
+		_, err = NewEngine(st, DefaultOptions().WithFunctions(map[string]*Function{"upper": {ResultType: IntegerType, Eval: eval}}))
+		require.ErrorIs(t, err, store.ErrInvalidOptions)
+	})
+}
+
+func TestCustomFunctions(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	functions := map[string]*Function{
+		"double": {
+			ParamTypes: []SQLValueType{IntegerType},
+			ResultType: IntegerType,
+			Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+				if params[0].IsNull() {
+					return NewNull(IntegerType), nil
+				}
+				return NewInteger(2 * params[0].Value().(int64)), nil
+			},
+		},
+		"label": {
+			ParamTypes: []SQLValueType{VarcharType, AnyType},
+			ResultType: VarcharType,
+			Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+				return NewVarchar(fmt.Sprintf("%s:%v", params[0].Value(), params[1].Value())), nil
+			},
+		},
+		"broken": {
+			ResultType: IntegerType,
+			Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+				return NewVarchar("not an integer"), nil
+			},
+		},
+	}
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix).WithFunctions(functions))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE DATABASE db1; USE DATABASE db1;", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table1(id INTEGER AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1(title) VALUES (@title)", map[string]interface{}{"title": fmt.Sprintf("title%d", i)})
+		require.NoError(t, err)
+	}
+
+	t.Run("custom functions should be callable in selectors and conditions", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id, DOUBLE(id) AS d, label(title, id) FROM table1 WHERE double(id) > @min", map[string]interface{}{"min": 6})
+		require.NoError(t, err)
+
+		cols, err := r.Columns(context.Background())
+		require.NoError(t, err)
+		require.Len(t, cols, 3)
+		require.Equal(t, "(db1.table1.d)", cols[1].Selector())
+		require.Equal(t, IntegerType, cols[1].Type)
+		require.Equal(t, "(db1.table1.col2)", cols[2].Selector())
+		require.Equal(t, VarcharType, cols[2].Type)
+
+		for id := 4; id <= 5; id++ {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, int64(id), row.ValuesBySelector["(db1.table1.id)"].Value())
+			require.Equal(t, int64(2*id), row.ValuesBySelector["(db1.table1.d)"].Value())
+			require.Equal(t, fmt.Sprintf("title%d:%d", id-1, id), row.ValuesBySelector["(db1.table1.col2)"].Value())
+		}
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("custom functions called by expression indexes should not be unregistered", func(t *testing.T) {
+		err := engine.RegisterFunction("halve", &Function{
+			ParamTypes: []SQLValueType{IntegerType},
+			ResultType: IntegerType,
+			Eval: func(tx *SQLTx, params []TypedValue) (TypedValue, error) {
+				if params[0].IsNull() {
+					return NewNull(IntegerType), nil
+				}
+				return NewInteger(params[0].Value().(int64) / 2), nil
+			},
+		})
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, `
+			CREATE TABLE table2(id INTEGER AUTO_INCREMENT, amount INTEGER, PRIMARY KEY id);
+			CREATE INDEX ON table2(LOWER(label('a', HALVE(amount))));
+		`, nil)
+		require.NoError(t, err)
+
+		for _, name := range []string{"halve", "LABEL"} {
+			err = engine.UnregisterFunction(context.Background(), name)
+			require.ErrorIs(t, err, ErrFunctionInUse)
+		}
+
+		err = engine.UnregisterFunction(context.Background(), "broken")
+		require.NoError(t, err)
+
+		err = engine.RegisterFunction("broken", functions["broken"])
+		require.NoError(t, err)
+	})
+
+	t.Run("parameters of custom functions should be inferred", func(t *testing.T) {
+		params, err := engine.InferParameters(context.Background(), nil, "SELECT double(@p1) FROM table1 WHERE label(@p2, @p3) = title")
+		require.NoError(t, err)
+		require.Equal(t, IntegerType, params["p1"])
+		require.Equal(t, VarcharType, params["p2"])
+		require.Equal(t, AnyType, params["p3"])
+	})
+
+	t.Run("signature mismatches should be detected before reading rows", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT id FROM table1 WHERE double(title) > 0", nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT double(id, id) FROM table1", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT id FROM table1 WHERE unknown(id) > 0", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		// comparisons are still evaluated when reading rows
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM table1 WHERE double(id) = 'a'", nil)
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNotComparableValues)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		_, err = engine.InferParameters(context.Background(), nil, "SELECT id FROM table1 WHERE double(id) = title")
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+
+	t.Run("invalid values should be detected when evaluating functions", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT double(@p) FROM table1", map[string]interface{}{"p": "a"})
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		r, err = engine.Query(context.Background(), nil, "SELECT broken() FROM table1", nil)
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("prepared statements should be evaluated with registered functions", func(t *testing.T) {
+		stmts, err := Parse(strings.NewReader("SELECT double(id) FROM table1 WHERE id = 1"))
+		require.NoError(t, err)
+
+		r, err := engine.QueryPreparedStmt(context.Background(), nil, stmts[0].(DataSource), nil)
+		require.NoError(t, err)
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(2), row.ValuesByPosition[0].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		// the statement is bound again to the functions registered when it's executed
+		err = engine.UnregisterFunction(context.Background(), "double")
+		require.NoError(t, err)
+
+		_, err = engine.QueryPreparedStmt(context.Background(), nil, stmts[0].(DataSource), nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = engine.RegisterFunction("double", functions["double"])
+		require.NoError(t, err)
+	})
+
+	t.Run("functions should only be callable from the engine they are registered in", func(t *testing.T) {
+		otherEngine := setupCommonTest(t)
+
+		_, _, err := otherEngine.Exec(context.Background(), nil, "CREATE TABLE table1(id INTEGER AUTO_INCREMENT, PRIMARY KEY id)", nil)
+		require.NoError(t, err)
+
+		_, err = otherEngine.Query(context.Background(), nil, "SELECT double(id) FROM table1", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
+
+func TestGreatestAndLeast(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE table1 (
+			id INTEGER,
+			a INTEGER,
+			b INTEGER,
+			c INTEGER,
+			created_at TIMESTAMP,
+			updated_at TIMESTAMP,
+			PRIMARY KEY id
+		)`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		INSERT INTO table1(id, a, b, c, created_at, updated_at) VALUES
+			(1, 10, 20, 5, CAST('2022-01-01' AS TIMESTAMP), CAST('2022-03-01' AS TIMESTAMP)),
+			(2, 7, NULL, 3, CAST('2022-02-01' AS TIMESTAMP), NULL),
+			(3, NULL, NULL, NULL, NULL, NULL)
+	`, nil)
+	require.NoError(t, err)
+
+	queryRows := func(t *testing.T, q string, params map[string]interface{}) [][]TypedValue {
+		r, err := engine.Query(context.Background(), nil, q, params)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var rows [][]TypedValue
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			rows = append(rows, row.ValuesByPosition)
+		}
+
+		return rows
+	}
+
+	t.Run("numeric arguments", func(t *testing.T) {
+		rows := queryRows(t, "SELECT GREATEST(a, b, c), LEAST(a, b, c), greatest(a, 15) FROM table1", nil)
+		require.Len(t, rows, 3)
+
+		require.Equal(t, int64(20), rows[0][0].Value())
+		require.Equal(t, int64(5), rows[0][1].Value())
+		require.Equal(t, int64(15), rows[0][2].Value())
+
+		// NULL arguments are ignored
+		require.Equal(t, int64(7), rows[1][0].Value())
+		require.Equal(t, int64(3), rows[1][1].Value())
+		require.Equal(t, int64(15), rows[1][2].Value())
+
+		// NULL is returned only if all the arguments are NULL
+		require.True(t, rows[2][0].IsNull())
+		require.True(t, rows[2][1].IsNull())
+		require.Equal(t, int64(15), rows[2][2].Value())
+	})
+
+	t.Run("timestamp arguments", func(t *testing.T) {
+		rows := queryRows(t, "SELECT GREATEST(created_at, updated_at), LEAST(created_at, updated_at) FROM table1", nil)
+		require.Len(t, rows, 3)
+
+		require.Equal(t, time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC), rows[0][0].Value())
+		require.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), rows[0][1].Value())
+		require.Equal(t, time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC), rows[1][0].Value())
+		require.Equal(t, time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC), rows[1][1].Value())
+		require.True(t, rows[2][0].IsNull())
+	})
+
+	t.Run("conditions", func(t *testing.T) {
+		rows := queryRows(t, "SELECT id FROM table1 WHERE GREATEST(a, b, c) > @min", map[string]interface{}{"min": 10})
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(1), rows[0][0].Value())
+
+		rows = queryRows(t, "SELECT id FROM table1 WHERE LEAST(a, c, @max) = 3", map[string]interface{}{"max": 100})
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(2), rows[0][0].Value())
+
+		rows = queryRows(t, "SELECT id FROM table1 WHERE GREATEST(created_at, updated_at) >= CAST('2022-02-01' AS TIMESTAMP)", nil)
+		require.Len(t, rows, 2)
+	})
+
+	t.Run("argument types should be unified", func(t *testing.T) {
+		params, err := engine.InferParameters(context.Background(), nil, "SELECT GREATEST(a, @p1) FROM table1 WHERE LEAST(@p2, @p3) > created_at")
+		require.NoError(t, err)
+		require.Equal(t, IntegerType, params["p1"])
+		require.Equal(t, TimestampType, params["p2"])
+		require.Equal(t, TimestampType, params["p3"])
+
+		_, err = engine.Query(context.Background(), nil, "SELECT GREATEST(a, created_at) FROM table1", nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = engine.InferParameters(context.Background(), nil, "SELECT id FROM table1 WHERE LEAST(a, b) = created_at")
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT GREATEST() FROM table1", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT GREATEST(a, @p) FROM table1", map[string]interface{}{"p": "a"})
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+}
+
+func TestConcat(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE table1 (
+			id INTEGER,
+			name VARCHAR,
+			active BOOLEAN,
+			payload BLOB,
+			created_at TIMESTAMP,
+			PRIMARY KEY id
+		)`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		INSERT INTO table1(id, name, active, payload, created_at) VALUES
+			(1, 'name1', true, x'0aff', CAST('2022-01-02 03:04:05.5' AS TIMESTAMP)),
+			(2, NULL, false, NULL, NULL)
+	`, nil)
+	require.NoError(t, err)
+
+	queryRow := func(t *testing.T, q string, params map[string]interface{}) []TypedValue {
+		r, err := engine.Query(context.Background(), nil, q, params)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		return row.ValuesByPosition
+	}
+
+	t.Run("concatenation operator", func(t *testing.T) {
+		row := queryRow(t, "SELECT name || '-' || id, 'active: ' || active, payload || '', created_at || '' FROM table1 WHERE id = 1", nil)
+		require.Equal(t, "name1-1", row[0].Value())
+		require.Equal(t, "active: true", row[1].Value())
+		require.Equal(t, "0aff", row[2].Value())
+		require.Equal(t, "2022-01-02 03:04:05.5", row[3].Value())
+	})
+
+	t.Run("concatenation operator should return NULL if any operand is NULL", func(t *testing.T) {
+		row := queryRow(t, "SELECT name || '-' || id, 'active: ' || active FROM table1 WHERE id = 2", nil)
+		require.True(t, row[0].IsNull())
+		require.Equal(t, VarcharType, row[0].Type())
+		require.Equal(t, "active: false", row[1].Value())
+	})
+
+	t.Run("concat function should skip NULL arguments", func(t *testing.T) {
+		row := queryRow(t, "SELECT CONCAT(name, '-', id, '-', active), concat(name), CONCAT(name, payload) FROM table1 WHERE id = 2", nil)
+		require.Equal(t, "-2-false", row[0].Value())
+		require.Equal(t, "", row[1].Value())
+		require.Equal(t, "", row[2].Value())
+
+		row = queryRow(t, "SELECT CONCAT(name, '-', id, '-', active, '-', payload) FROM table1 WHERE id = 1", nil)
+		require.Equal(t, "name1-1-true-0aff", row[0].Value())
+	})
+
+	t.Run("concatenation should be usable in conditions", func(t *testing.T) {
+		row := queryRow(t, "SELECT id FROM table1 WHERE name || id = @name AND CONCAT(name, @suffix) = 'name1!'", map[string]interface{}{
+			"name":   "name11",
+			"suffix": "!",
+		})
+		require.Equal(t, int64(1), row[0].Value())
+	})
+
+	t.Run("concatenation should infer string parameters", func(t *testing.T) {
+		params, err := engine.InferParameters(context.Background(), nil, "SELECT id FROM table1 WHERE @prefix || name = 'x'")
+		require.NoError(t, err)
+		require.Equal(t, VarcharType, params["prefix"])
+
+		_, err = engine.InferParameters(context.Background(), nil, "SELECT id FROM table1 WHERE name || id = 1")
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+
+	t.Run("concat function should require at least one argument", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT CONCAT() FROM table1", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
+
+func TestMaskingFunctions(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE customers (
+			id INTEGER AUTO_INCREMENT,
+			email VARCHAR,
+			card_number VARCHAR,
+			PRIMARY KEY id
+		)`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		INSERT INTO customers(email, card_number) VALUES
+			('jane@example.com', '4111111111111111'),
+			('john@example.com', '55'),
+			(NULL, NULL)
+	`, nil)
+	require.NoError(t, err)
+
+	queryRow := func(t *testing.T, q string, params map[string]interface{}) []TypedValue {
+		r, err := engine.Query(context.Background(), nil, q, params)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		return row.ValuesByPosition
+	}
+
+	t.Run("mask should only reveal the last characters", func(t *testing.T) {
+		row := queryRow(t, "SELECT MASK(card_number, 4), MASK(card_number, 0), mask(email, 100) FROM customers WHERE id = 1", nil)
+		require.Equal(t, "************1111", row[0].Value())
+		require.Equal(t, "****************", row[1].Value())
+		require.Equal(t, "jane@example.com", row[2].Value())
+
+		row = queryRow(t, "SELECT MASK(card_number, 4) FROM customers WHERE id = 2", nil)
+		require.Equal(t, "55", row[0].Value())
+	})
+
+	t.Run("sha256 should hash values", func(t *testing.T) {
+		row := queryRow(t, "SELECT SHA256(email) FROM customers WHERE id = 1", nil)
+		require.Equal(t, "8c87b489ce35cf2e2f39f80e282cb2e804932a56a213983eeeb428407d43b52d", row[0].Value())
+
+		row = queryRow(t, "SELECT id FROM customers WHERE SHA256(email) = SHA256(@email)", map[string]interface{}{"email": "john@example.com"})
+		require.Equal(t, int64(2), row[0].Value())
+	})
+
+	t.Run("masking functions should return NULL on NULL values", func(t *testing.T) {
+		row := queryRow(t, "SELECT MASK(card_number, 4), SHA256(email) FROM customers WHERE id = 3", nil)
+		require.True(t, row[0].IsNull())
+		require.True(t, row[1].IsNull())
+	})
+
+	t.Run("mask should reject a negative number of visible characters", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT MASK(card_number, -1) FROM customers", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("masking should not affect the stored values", func(t *testing.T) {
+		row := queryRow(t, "SELECT card_number FROM customers WHERE MASK(card_number, 4) = '************1111'", nil)
+		require.Equal(t, "4111111111111111", row[0].Value())
+	})
+}
+
+func TestMathFunctions(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE amounts (
+			id INTEGER AUTO_INCREMENT,
+			amount INTEGER,
+			PRIMARY KEY id
+		)`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		INSERT INTO amounts(amount) VALUES (1250), (-1250), (1249), (-1251), (NULL)
+	`, nil)
+	require.NoError(t, err)
+
+	queryRows := func(t *testing.T, q string) [][]TypedValue {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var rows [][]TypedValue
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				return rows
+			}
+			require.NoError(t, err)
+
+			rows = append(rows, row.ValuesByPosition)
+		}
+	}
+
+	t.Run("math functions should be usable in selectors", func(t *testing.T) {
+		rows := queryRows(t, "SELECT ABS(amount), CEIL(amount), FLOOR(amount), ROUND(amount), ROUND(amount, 0), ROUND(amount, 2), ROUND(amount, -2) FROM amounts")
+		require.Len(t, rows, 5)
+
+		expected := [][]int64{
+			{1250, 1250, 1250, 1250, 1250, 1250, 1300},
+			{1250, -1250, -1250, -1250, -1250, -1250, -1300},
+			{1249, 1249, 1249, 1249, 1249, 1249, 1200},
+			{1251, -1251, -1251, -1251, -1251, -1251, -1300},
+		}
+
+		for i, values := range expected {
+			for j, v := range values {
+				require.Equal(t, v, rows[i][j].Value(), "row %d, column %d", i, j)
+			}
+		}
+
+		for _, v := range rows[4] {
+			require.True(t, v.IsNull())
+			require.Equal(t, IntegerType, v.Type())
+		}
+	})
+
+	t.Run("math functions should be usable in conditions", func(t *testing.T) {
+		rows := queryRows(t, "SELECT id FROM amounts WHERE ABS(amount) = 1250 AND ROUND(amount, -3) <> 0")
+		require.Len(t, rows, 2)
+		require.Equal(t, int64(1), rows[0][0].Value())
+		require.Equal(t, int64(2), rows[1][0].Value())
+	})
+
+	t.Run("rounding edge cases", func(t *testing.T) {
+		for _, c := range []struct {
+			n, digits int64
+			expected  int64
+		}{
+			{5, -1, 10},
+			{-5, -1, -10},
+			{4, -1, 0},
+			{-4, -1, 0},
+			{-15, -1, -20},
+			{-14, -1, -10},
+			{999, -3, 1000},
+			{499, -3, 0},
+			{math.MaxInt64, -20, 0},
+			{math.MinInt64, -20, 0},
+			{4e18, -19, 0},
+		} {
+			rounded, err := roundInteger(c.n, -c.digits)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, rounded, "ROUND(%d, %d)", c.n, c.digits)
+		}
+
+		_, err := roundInteger(math.MaxInt64, 1)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = roundInteger(6e18, 19)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT ABS(amount, 1) FROM amounts", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT ROUND() FROM amounts", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT CEIL('1') FROM amounts", nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT ROUND(amount, 1, 2) FROM amounts", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT ABS(@n) FROM amounts", map[string]interface{}{"n": int64(math.MinInt64)})
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
+
+func TestExtract(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE events (
+			id INTEGER AUTO_INCREMENT,
+			ts TIMESTAMP,
+			PRIMARY KEY id
+		)`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		INSERT INTO events(ts) VALUES (@ts1), (@ts2), (NULL)
+	`, map[string]interface{}{
+		"ts1": time.Date(2023, 3, 5, 14, 7, 9, 500000000, time.UTC),
+		"ts2": time.Date(1969, 12, 31, 23, 59, 58, 0, time.FixedZone("CET", 3600)),
+	})
+	require.NoError(t, err)
+
+	queryRows := func(t *testing.T, q string) [][]TypedValue {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var rows [][]TypedValue
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				return rows
+			}
+			require.NoError(t, err)
+
+			rows = append(rows, row.ValuesByPosition)
+		}
+	}
+
+	t.Run("every field should be extracted in UTC", func(t *testing.T) {
+		rows := queryRows(t, `
+			SELECT
+				EXTRACT(YEAR FROM ts), EXTRACT(MONTH FROM ts), EXTRACT(DAY FROM ts),
+				EXTRACT(HOUR FROM ts), EXTRACT(MINUTE FROM ts), EXTRACT(SECOND FROM ts),
+				EXTRACT(DOW FROM ts), EXTRACT(EPOCH FROM ts)
+			FROM events`)
+		require.Len(t, rows, 3)
+
+		expected := [][]int64{
+			{2023, 3, 5, 14, 7, 9, 0, 1678025229},
+			{1969, 12, 31, 22, 59, 58, 3, -3602},
+		}
+
+		for i, values := range expected {
+			for j, v := range values {
+				require.Equal(t, IntegerType, rows[i][j].Type())
+				require.Equal(t, v, rows[i][j].Value(), "row %d, column %d", i, j)
+			}
+		}
+
+		for _, v := range rows[2] {
+			require.True(t, v.IsNull())
+			require.Equal(t, IntegerType, v.Type())
+		}
+	})
+
+	t.Run("fields should be case insensitive", func(t *testing.T) {
+		rows := queryRows(t, "SELECT EXTRACT(year FROM ts) AS y, EXTRACT(Dow FROM NOW()) >= 0 FROM events WHERE id = 1")
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(2023), rows[0][0].Value())
+		require.Equal(t, true, rows[0][1].Value())
+	})
+
+	t.Run("extracted fields should be usable in conditions", func(t *testing.T) {
+		rows := queryRows(t, "SELECT id FROM events WHERE EXTRACT(YEAR FROM ts) = 1969 OR EXTRACT(DOW FROM ts) = 0")
+		require.Len(t, rows, 2)
+		require.Equal(t, int64(1), rows[0][0].Value())
+		require.Equal(t, int64(2), rows[1][0].Value())
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT EXTRACT(YEAR FROM id) FROM events", nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT EXTRACT(WEEK FROM ts) FROM events", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
+
+func TestTrimFunctions(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE paths (
+			id INTEGER AUTO_INCREMENT,
+			path VARCHAR,
+			PRIMARY KEY id
+		)`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO paths(path) VALUES ('/var/log//'), (NULL)", nil)
+	require.NoError(t, err)
+
+	queryValue := func(t *testing.T, q string) TypedValue {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		return row.ValuesByPosition[0]
+	}
+
+	testCases := []struct {
+		exp      string
+		expected string
+	}{
+		{"TRIM(' \t abc \n ')", "abc"},
+		{"LTRIM('  abc  ')", "abc  "},
+		{"RTRIM('  abc  ')", "  abc"},
+		{"TRIM('xxabcxx', 'x')", "abc"},
+		{"LTRIM('0042', '0')", "42"},
+		{"RTRIM('/var/log//', '/')", "/var/log"},
+		{"LTRIM('abc', '')", "abc"},
+		{"TRIM('0' FROM '00420')", "42"},
+		{"TRIM(BOTH '0' FROM '00420')", "42"},
+		{"TRIM(LEADING '0' FROM '00420')", "420"},
+		{"TRIM(TRAILING '0' FROM '00420')", "0042"},
+		{"TRIM(LEADING FROM '  abc  ')", "abc  "},
+		{"TRIM(TRAILING FROM '  abc  ')", "  abc"},
+		{"TRIM(BOTH FROM '  abc  ')", "abc"},
+		{"TRIM('-_' FROM '_-_abc-_-')", "abc"},
+		{"TRIM('¡¿hola?!', '¡¿?!')", "hola"},
+		{"TRIM(LEADING 'ñé' FROM 'éñéxñ')", "xñ"},
+		{"RTRIM('日本語日', '日')", "日本語"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.exp, func(t *testing.T) {
+			v := queryValue(t, "SELECT "+tc.exp+" FROM paths WHERE id = 1")
+			require.Equal(t, VarcharType, v.Type())
+			require.Equal(t, tc.expected, v.Value())
+		})
+	}
+
+	t.Run("trimming should not split multi-byte characters", func(t *testing.T) {
+		// 'é' and 'è' share their leading byte but are different characters
+		v := queryValue(t, "SELECT TRIM('éabcé', 'è') FROM paths WHERE id = 1")
+		require.Equal(t, "éabcé", v.Value())
+	})
+
+	t.Run("trimmed columns should be usable in conditions", func(t *testing.T) {
+		v := queryValue(t, "SELECT id FROM paths WHERE TRIM(TRAILING '/' FROM path) = '/var/log'")
+		require.Equal(t, int64(1), v.Value())
+	})
+
+	t.Run("NULL arguments should result in NULL", func(t *testing.T) {
+		for _, exp := range []string{"TRIM(path)", "LTRIM('abc', NULL)", "TRIM(LEADING NULL FROM 'abc')"} {
+			v := queryValue(t, "SELECT "+exp+" FROM paths WHERE id = 2")
+			require.True(t, v.IsNull())
+			require.Equal(t, VarcharType, v.Type())
+		}
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT TRIM(id) FROM paths", nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT LTRIM(path, 'a', 'b') FROM paths", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT id FROM paths WHERE TRIM(path, 'a', 'b') = ''", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE empty_paths (id INTEGER, path VARCHAR, PRIMARY KEY id)", nil)
+		require.NoError(t, err)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT RTRIM(path, 'a', 'b') FROM empty_paths", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
+
+func TestSubstringFunctions(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE emails (
+			id INTEGER AUTO_INCREMENT,
+			email VARCHAR,
+			PRIMARY KEY id
+		)`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO emails(email) VALUES ('john@immudb.io'), (NULL), ('señor@añejo.es')", nil)
+	require.NoError(t, err)
+
+	queryValue := func(t *testing.T, q string) TypedValue {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		return row.ValuesByPosition[0]
+	}
+
+	testCases := []struct {
+		exp      string
+		expected interface{}
+	}{
+		{"INSTR('immudb', 'db')", int64(5)},
+		{"POSITION('db' IN 'immudb')", int64(5)},
+		{"INSTR('immudb', 'sql')", int64(0)},
+		{"POSITION('sql' IN 'immudb')", int64(0)},
+		{"INSTR('immudb', 'm')", int64(2)},
+		{"INSTR('añejo', 'e')", int64(3)},
+		{"POSITION('語' IN '日本語')", int64(3)},
+		{"INSTR('immudb', '')", int64(1)},
+		{"INSTR('', '')", int64(1)},
+		{"INSTR('', 'a')", int64(0)},
+		{"SUBSTR('immudb', 5)", "db"},
+		{"SUBSTR('immudb', 1, 4)", "immu"},
+		{"SUBSTR('añejo', 2, 3)", "ñej"},
+		{"SUBSTR('abc', 0, 2)", "a"},
+		{"SUBSTR('abc', 5)", ""},
+		{"SUBSTR('abc', 2, 0)", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.exp, func(t *testing.T) {
+			v := queryValue(t, "SELECT "+tc.exp+" FROM emails WHERE id = 1")
+			require.Equal(t, tc.expected, v.Value())
+		})
+	}
+
+	t.Run("position should compose with substr", func(t *testing.T) {
+		v := queryValue(t, "SELECT SUBSTR(email, 1, POSITION('@' IN email) - 1) FROM emails WHERE id = 1")
+		require.Equal(t, "john", v.Value())
+
+		v = queryValue(t, "SELECT SUBSTR(email, INSTR(email, '@') + 1) FROM emails WHERE id = 3")
+		require.Equal(t, "añejo.es", v.Value())
+	})
+
+	t.Run("position should be usable in conditions", func(t *testing.T) {
+		v := queryValue(t, "SELECT id FROM emails WHERE POSITION('@' IN email) = 6")
+		require.Equal(t, int64(3), v.Value())
+	})
+
+	t.Run("NULL arguments should result in NULL", func(t *testing.T) {
+		for _, exp := range []string{"INSTR(email, '@')", "POSITION(NULL IN 'abc')", "SUBSTR(email, 1)", "SUBSTR('abc', 1, NULL)"} {
+			v := queryValue(t, "SELECT "+exp+" FROM emails WHERE id = 2")
+			require.True(t, v.IsNull())
+		}
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT INSTR(id, '1') FROM emails", nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT SUBSTR(email) FROM emails", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT SUBSTR(email, 1, 2, 3) FROM emails", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT SUBSTR(email, 1, -1) FROM emails WHERE id = 1", nil)
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		require.NoError(t, r.Close())
+	})
+}
+
+func TestReplaceAndReverseFunctions(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE names (
+			id INTEGER AUTO_INCREMENT,
+			name VARCHAR,
+			PRIMARY KEY id
+		)`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO names(name) VALUES ('jo-hn  doe'), (NULL), ('añejo')", nil)
+	require.NoError(t, err)
+
+	queryValue := func(t *testing.T, q string) TypedValue {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		return row.ValuesByPosition[0]
+	}
+
+	testCases := []struct {
+		exp      string
+		expected interface{}
+	}{
+		{"REPLACE('immudb', 'db', 'DB')", "immuDB"},
+		{"REPLACE('a.b.c', '.', '')", "abc"},
+		{"REPLACE('aaa', 'aa', 'b')", "ba"},
+		{"REPLACE('aaaa', 'aa', 'a')", "aa"},
+		{"REPLACE('abab', 'ab', 'abab')", "abababab"},
+		{"REPLACE('immudb', '', 'x')", "immudb"},
+		{"REPLACE('immudb', 'sql', 'x')", "immudb"},
+		{"REPLACE('añejo', 'ñ', 'n')", "anejo"},
+		{"REVERSE('immudb')", "bdummi"},
+		{"REVERSE('añejo')", "ojeña"},
+		{"REVERSE('日本語')", "語本日"},
+		{"REVERSE('')", ""},
+		{"REVERSE(REVERSE('señor'))", "señor"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.exp, func(t *testing.T) {
+			v := queryValue(t, "SELECT "+tc.exp+" FROM names WHERE id = 1")
+			require.Equal(t, tc.expected, v.Value())
+		})
+	}
+
+	t.Run("replace and reverse should compose with other expressions", func(t *testing.T) {
+		v := queryValue(t, "SELECT UPPER(REPLACE(REPLACE(name, '-', ''), '  ', ' ')) FROM names WHERE id = 1")
+		require.Equal(t, "JOHN DOE", v.Value())
+
+		v = queryValue(t, "SELECT REVERSE(name) || '!' FROM names WHERE id = 3")
+		require.Equal(t, "ojeña!", v.Value())
+	})
+
+	t.Run("replace and reverse should be usable in conditions", func(t *testing.T) {
+		v := queryValue(t, "SELECT id FROM names WHERE REVERSE(name) = 'ojeña'")
+		require.Equal(t, int64(3), v.Value())
+
+		v = queryValue(t, "SELECT id FROM names WHERE REPLACE(name, '-', '') = 'john  doe'")
+		require.Equal(t, int64(1), v.Value())
+	})
+
+	t.Run("NULL arguments should result in NULL", func(t *testing.T) {
+		for _, exp := range []string{"REPLACE(name, 'a', 'b')", "REPLACE('abc', NULL, 'b')", "REPLACE('abc', 'a', NULL)", "REVERSE(name)"} {
+			v := queryValue(t, "SELECT "+exp+" FROM names WHERE id = 2")
+			require.True(t, v.IsNull())
+		}
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT REVERSE(id) FROM names", nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT REPLACE(name, 'a') FROM names", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
+
+func TestCurrentTimeKeywords(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE events (
+			id INTEGER AUTO_INCREMENT,
+			ts TIMESTAMP,
+			PRIMARY KEY id
+		)`, nil)
+	require.NoError(t, err)
+
+	t.Run("all the rows inserted by a statement should get the same time", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "INSERT INTO events(ts) VALUES (CURRENT_TIMESTAMP), (CURRENT_TIMESTAMP)", nil)
+		require.NoError(t, err)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT ts FROM events", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row1, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		row2, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, row1.ValuesByPosition[0].Value(), row2.ValuesByPosition[0].Value())
+	})
+
+	t.Run("all the references within a statement should agree", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil,
+			"SELECT CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, NOW(), CURRENT_DATE, DATE(CURRENT_TIMESTAMP), CURRENT_TIME FROM events WHERE id = 1", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		ts := row.ValuesByPosition[0].Value().(time.Time)
+		require.Equal(t, time.UTC, ts.Location())
+		require.Equal(t, ts, row.ValuesByPosition[1].Value())
+		require.Equal(t, ts, row.ValuesByPosition[2].Value())
+		require.Equal(t, row.ValuesByPosition[4].Value(), row.ValuesByPosition[3].Value())
+		require.Equal(t, ts.Format("15:04:05.000000"), row.ValuesByPosition[5].Value())
+	})
+
+	t.Run("keywords should be usable in expressions", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT COUNT(*) FROM events WHERE ts <= CURRENT_TIMESTAMP AND ts >= CURRENT_DATE", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(2), row.ValuesByPosition[0].Value())
+	})
+
+	t.Run("keywords are not function names", func(t *testing.T) {
+		_, err := ParseString("SELECT CURRENT_TIMESTAMP() FROM events")
+		require.Error(t, err)
+	})
+}
+
+func TestBlobEncodingFunctions(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE files (
+			id INTEGER AUTO_INCREMENT,
+			content BLOB,
+			PRIMARY KEY id
+		)`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		INSERT INTO files(content) VALUES (x'00ff10ab'), (@content), (NULL), (UNHEX('cafe')), (FROM_BASE64('aGk='))
+	`, map[string]interface{}{"content": []byte("immudb")})
+	require.NoError(t, err)
+
+	queryRow := func(t *testing.T, q string) []TypedValue {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+
+		return row.ValuesByPosition
+	}
+
+	t.Run("blobs should be encoded as hex and base64 strings", func(t *testing.T) {
+		row := queryRow(t, "SELECT HEX(content), TO_BASE64(content) FROM files WHERE id = 1")
+		require.Equal(t, "00ff10ab", row[0].Value())
+		require.Equal(t, "AP8Qqw==", row[1].Value())
+
+		row = queryRow(t, "SELECT HEX(content), TO_BASE64(content) FROM files WHERE id = 2")
+		require.Equal(t, "696d6d756462", row[0].Value())
+		require.Equal(t, "aW1tdWRi", row[1].Value())
+	})
+
+	t.Run("blobs should be inserted from hex and base64 strings", func(t *testing.T) {
+		row := queryRow(t, "SELECT content FROM files WHERE id = 4")
+		require.Equal(t, []byte{0xca, 0xfe}, row[0].Value())
+
+		row = queryRow(t, "SELECT content FROM files WHERE id = 5")
+		require.Equal(t, []byte("hi"), row[0].Value())
+
+		row = queryRow(t, "SELECT id FROM files WHERE content = UNHEX('CAFE')")
+		require.Equal(t, int64(4), row[0].Value())
+	})
+
+	t.Run("blobs should round-trip through hex and base64 strings", func(t *testing.T) {
+		for _, id := range []int{1, 2, 4, 5} {
+			row := queryRow(t, fmt.Sprintf(`
+				SELECT
+					UNHEX(HEX(content)) = content,
+					FROM_BASE64(TO_BASE64(content)) = content
+				FROM files WHERE id = %d`, id))
+
+			for _, v := range row {
+				require.Equal(t, true, v.Value())
+			}
+		}
+	})
+
+	t.Run("NULL values should be encoded and decoded as NULL", func(t *testing.T) {
+		row := queryRow(t, "SELECT HEX(content), TO_BASE64(content), UNHEX(HEX(content)), FROM_BASE64(TO_BASE64(content)) FROM files WHERE id = 3")
+		require.True(t, row[0].IsNull())
+		require.Equal(t, VarcharType, row[0].Type())
+		require.True(t, row[1].IsNull())
+		require.Equal(t, VarcharType, row[1].Type())
+		require.True(t, row[2].IsNull())
+		require.Equal(t, BLOBType, row[2].Type())
+		require.True(t, row[3].IsNull())
+		require.Equal(t, BLOBType, row[3].Type())
+	})
+
+	t.Run("malformed strings should not be decoded", func(t *testing.T) {
+		for _, q := range []string{
+			"SELECT UNHEX('abc') FROM files",
+			"SELECT UNHEX('zz') FROM files",
+			"SELECT FROM_BASE64('aGk') FROM files",
+			"SELECT FROM_BASE64('a*k=') FROM files",
+		} {
+			r, err := engine.Query(context.Background(), nil, q, nil)
+			require.NoError(t, err)
+
+			_, err = r.Read(context.Background())
+			require.ErrorIs(t, err, ErrIllegalArguments, q)
+			require.Contains(t, err.Error(), "malformed")
+
+			r.Close()
+		}
+
+		_, _, err := engine.Exec(context.Background(), nil, "INSERT INTO files(content) VALUES (UNHEX('xyz'))", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("arguments of invalid types should be rejected", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT HEX(id) FROM files", nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT FROM_BASE64(content) FROM files", nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+}
@@ -19,6 +19,7 @@ package sql
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/codenotary/immudb/embedded/store"
 )
@@ -32,6 +33,15 @@ type groupedRowReader struct {
 
 	currRow  *Row
 	nonEmpty bool
+
+	// mem is the memory account of the query, memUsed is the memory reserved for the group being built
+	mem     *memoryAccount
+	memUsed int64
+
+	// stats is set when the query is executed collecting statistics,
+	// sourceReadTime is then the time spent reading source rows by the ongoing Read
+	stats          *QueryStats
+	sourceReadTime time.Duration
 }
 
 func newGroupedRowReader(rowReader RowReader, selectors []Selector, groupBy []*ColSelector) (*groupedRowReader, error) {
@@ -45,6 +55,21 @@ func newGroupedRowReader(rowReader RowReader, selectors []Selector, groupBy []*C
 		return nil, ErrLimitedGroupBy
 	}
 
+	for _, sel := range selectors {
+		agg, isAggregation := sel.(*AggColSelector)
+		if !isAggregation || agg.exp == nil {
+			continue
+		}
+
+		var nested []*AggColSelector
+
+		collectAggregations(agg.exp, &nested)
+
+		if len(nested) > 0 {
+			return nil, fmt.Errorf("%w: nested aggregations", ErrNoSupported)
+		}
+	}
+
 	return &groupedRowReader{
 		rowReader: rowReader,
 		selectors: selectors,
@@ -105,6 +130,22 @@ func (gr *groupedRowReader) colsBySelector(ctx context.Context) (map[string]ColD
 			continue
 		}
 
+		agg := sel.(*AggColSelector)
+
+		if agg.exp != nil {
+			t, err := agg.inferType(colDescriptors, make(map[string]SQLValueType), gr.rowReader.Database(), gr.rowReader.TableAlias())
+			if err != nil {
+				return nil, err
+			}
+
+			colDescriptors[EncodeSelector("", db, table, col)] = ColDescriptor{
+				Database: db,
+				Table:    table,
+				Column:   col,
+				Type:     t,
+			}
+		}
+
 		des := ColDescriptor{
 			AggFn:    aggFn,
 			Database: db,
@@ -127,6 +168,9 @@ func (gr *groupedRowReader) colsBySelector(ctx context.Context) (map[string]ColD
 
 		if aggFn == MAX || aggFn == MIN {
 			colDescriptors[encSel] = colDesc
+		} else if aggFn == BOOL_AND || aggFn == BOOL_OR {
+			des.Type = BooleanType
+			colDescriptors[encSel] = des
 		} else {
 			// SUM, AVG
 			colDescriptors[encSel] = des
@@ -169,6 +213,11 @@ func zeroForType(t SQLValueType) TypedValue {
 			return &Timestamp{}
 		}
 	}
+
+	if elemType, isArray := arrayElemType(t); isArray {
+		return &Array{elemType: elemType}
+	}
+
 	return nil
 }
 
@@ -185,8 +234,34 @@ func (gr *groupedRowReader) SetParameters(params map[string]interface{}) error {
 }
 
 func (gr *groupedRowReader) Read(ctx context.Context) (*Row, error) {
+	if gr.stats == nil {
+		return gr.read(ctx)
+	}
+
+	start := time.Now()
+	gr.sourceReadTime = 0
+
+	row, err := gr.read(ctx)
+
+	gr.stats.aggregated(time.Since(start) - gr.sourceReadTime)
+
+	return row, err
+}
+
+func (gr *groupedRowReader) readSourceRow(ctx context.Context) (*Row, error) {
+	if gr.stats == nil {
+		return gr.rowReader.Read(ctx)
+	}
+
+	start := time.Now()
+	defer func() { gr.sourceReadTime += time.Since(start) }()
+
+	return gr.rowReader.Read(ctx)
+}
+
+func (gr *groupedRowReader) read(ctx context.Context) (*Row, error) {
 	for {
-		row, err := gr.rowReader.Read(ctx)
+		row, err := gr.readSourceRow(ctx)
 		if err == store.ErrNoMoreEntries {
 			if !gr.nonEmpty && allAgregations(gr.selectors) {
 				// special case when all selectors are aggregations
@@ -207,6 +282,8 @@ func (gr *groupedRowReader) Read(ctx context.Context) (*Row, error) {
 					var zero TypedValue
 					if aggFn == COUNT || aggFn == SUM || aggFn == AVG {
 						zero = zeroForType(IntegerType)
+					} else if aggFn == BOOL_AND || aggFn == BOOL_OR {
+						zero = &NullValue{t: BooleanType}
 					} else {
 						zero = zeroForType(colsBySelector[encSel].Type)
 					}
@@ -227,6 +304,9 @@ func (gr *groupedRowReader) Read(ctx context.Context) (*Row, error) {
 			r := gr.currRow
 			gr.currRow = nil
 
+			gr.mem.release(gr.memUsed)
+			gr.memUsed = 0
+
 			return r, nil
 		}
 		if err != nil {
@@ -235,12 +315,23 @@ func (gr *groupedRowReader) Read(ctx context.Context) (*Row, error) {
 
 		gr.nonEmpty = true
 
+		err = gr.evalAggregatedExps(row)
+		if err != nil {
+			return nil, err
+		}
+
 		if gr.currRow == nil {
 			gr.currRow = row
 			err = gr.initAggregations()
 			if err != nil {
 				return nil, err
 			}
+
+			err = gr.accountGroup()
+			if err != nil {
+				return nil, err
+			}
+
 			continue
 		}
 
@@ -258,6 +349,11 @@ func (gr *groupedRowReader) Read(ctx context.Context) (*Row, error) {
 				return nil, err
 			}
 
+			err = gr.accountGroup()
+			if err != nil {
+				return nil, err
+			}
+
 			return r, nil
 		}
 
@@ -286,7 +382,68 @@ func (gr *groupedRowReader) Read(ctx context.Context) (*Row, error) {
 				}
 			}
 		}
+
+		// aggregated values such as MIN and MAX over strings may grow
+		err = gr.accountGroup()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// accountGroup updates the memory reserved for the group being built.
+// Rows are read in the order of the grouping column, thus only one group is kept in memory at a time
+func (gr *groupedRowReader) accountGroup() error {
+	if gr.mem == nil {
+		return nil
+	}
+
+	var size int64
+	if gr.currRow != nil {
+		size = rowMemSize(gr.currRow)
+	}
+
+	if size <= gr.memUsed {
+		gr.mem.release(gr.memUsed - size)
+		gr.memUsed = size
+		return nil
+	}
+
+	err := gr.mem.reserve(size - gr.memUsed)
+	if err != nil {
+		return err
+	}
+
+	gr.memUsed = size
+
+	return nil
+}
+
+// evalAggregatedExps evaluates the aggregated expressions over the row,
+// their values are then aggregated as the ones of any other column
+func (gr *groupedRowReader) evalAggregatedExps(row *Row) error {
+	for _, sel := range gr.selectors {
+		agg, isAggregation := sel.(*AggColSelector)
+		if !isAggregation || agg.exp == nil {
+			continue
+		}
+
+		exp, err := agg.exp.substitute(gr.Parameters())
+		if err != nil {
+			return err
+		}
+
+		val, err := exp.reduce(gr.Tx(), row, gr.rowReader.Database(), gr.rowReader.TableAlias())
+		if err != nil {
+			return err
+		}
+
+		_, db, table, col := agg.resolve(gr.rowReader.Database(), gr.rowReader.TableAlias())
+
+		row.ValuesBySelector[EncodeSelector("", db, table, col)] = val
 	}
+
+	return nil
 }
 
 func (gr *groupedRowReader) initAggregations() error {
@@ -323,6 +480,10 @@ func (gr *groupedRowReader) initAggregations() error {
 			{
 				v = &AVGValue{sel: EncodeSelector("", db, table, col)}
 			}
+		case BOOL_AND, BOOL_OR:
+			{
+				v = &BoolAggValue{and: aggFn == BOOL_AND, sel: EncodeSelector("", db, table, col)}
+			}
 		default:
 			{
 				continue
@@ -362,5 +523,8 @@ func (gr *groupedRowReader) initAggregations() error {
 }
 
 func (gr *groupedRowReader) Close() error {
+	gr.mem.release(gr.memUsed)
+	gr.memUsed = 0
+
 	return gr.rowReader.Close()
 }
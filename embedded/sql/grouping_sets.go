@@ -0,0 +1,548 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// maxCubeColumns bounds the number of columns of a CUBE, which produces a grouping set per combination of them
+const maxCubeColumns = 12
+
+const groupingFnName = "GROUPING"
+
+type groupByKind = int
+
+const (
+	plainGroupBy groupByKind = iota
+	rollupGroupBy
+	cubeGroupBy
+	groupingSetsGroupBy
+)
+
+// groupByClause is the GROUP BY clause as parsed. ROLLUP and CUBE are expanded into their grouping sets:
+// ROLLUP(a, b) groups by (a, b), (a) and (), CUBE(a, b) by (a, b), (a), (b) and ()
+type groupByClause struct {
+	kind groupByKind
+	cols []*ColSelector
+	sets [][]*ColSelector
+}
+
+// setGroupBy sets the grouping of the statement, the grouping columns of ROLLUP, CUBE and GROUPING SETS
+// are all the ones referenced by any of their sets
+func (stmt *SelectStmt) setGroupBy(clause *groupByClause) {
+	if clause == nil {
+		return
+	}
+
+	if clause.kind == plainGroupBy {
+		stmt.groupBy = clause.cols
+		return
+	}
+
+	stmt.grouping = clause
+	stmt.groupBy = clause.columns()
+}
+
+func (clause *groupByClause) resolveCols(resolve func(col *ColSelector) *ColSelector) {
+	for i, col := range clause.cols {
+		clause.cols[i] = resolve(col)
+	}
+
+	for _, set := range clause.sets {
+		for i, col := range set {
+			set[i] = resolve(col)
+		}
+	}
+}
+
+// columns returns the distinct columns referenced by the grouping sets
+func (clause *groupByClause) columns() []*ColSelector {
+	cols := make([]*ColSelector, 0, len(clause.cols))
+
+	for _, set := range append([][]*ColSelector{clause.cols}, clause.sets...) {
+		for _, col := range set {
+			if indexOfGroupingCol(cols, col) < 0 {
+				cols = append(cols, col)
+			}
+		}
+	}
+
+	return cols
+}
+
+// groupingSets returns the sets of columns the rows are grouped by
+func (clause *groupByClause) groupingSets() [][]*ColSelector {
+	switch clause.kind {
+	case rollupGroupBy:
+		{
+			sets := make([][]*ColSelector, 0, len(clause.cols)+1)
+
+			for i := len(clause.cols); i >= 0; i-- {
+				sets = append(sets, clause.cols[:i])
+			}
+
+			return sets
+		}
+	case cubeGroupBy:
+		{
+			n := len(clause.cols)
+			sets := make([][]*ColSelector, 0, 1<<n)
+
+			// the first column is the most significant one, so sets go from all the columns down to none
+			for mask := 1<<n - 1; mask >= 0; mask-- {
+				var set []*ColSelector
+
+				for i, col := range clause.cols {
+					if mask&(1<<(n-1-i)) != 0 {
+						set = append(set, col)
+					}
+				}
+
+				sets = append(sets, set)
+			}
+
+			return sets
+		}
+	case groupingSetsGroupBy:
+		{
+			return clause.sets
+		}
+	}
+
+	return [][]*ColSelector{clause.cols}
+}
+
+func indexOfGroupingCol(cols []*ColSelector, col *ColSelector) int {
+	for i, c := range cols {
+		if c.db == col.db && c.table == col.table && c.col == col.col && c.ordinal == col.ordinal {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// validateGrouping checks the grouping sets can be computed and the GROUPING function
+// is only applied to grouping columns
+func (stmt *SelectStmt) validateGrouping() error {
+	if stmt.grouping != nil {
+		if stmt.grouping.kind == cubeGroupBy && len(stmt.grouping.cols) > maxCubeColumns {
+			return fmt.Errorf("%w: CUBE is limited to %d columns", ErrIllegalArguments, maxCubeColumns)
+		}
+
+		// groups are built in memory, in the order of the grouping columns
+		if len(stmt.orderBy) > 0 {
+			return fmt.Errorf("%w: ORDER BY is not supported with grouping sets", ErrLimitedOrderBy)
+		}
+	}
+
+	exps := []ValueExp{stmt.having}
+	for _, sel := range stmt.selectors {
+		exps = append(exps, sel)
+	}
+
+	for _, exp := range exps {
+		var fns []*GroupingExp
+
+		collectGroupingFns(exp, &fns)
+
+		for _, fn := range fns {
+			if !stmt.groupsBy(fn.col) {
+				return fmt.Errorf("%w: GROUPING argument %s is not a grouping column", ErrIllegalArguments, fn.col.col)
+			}
+		}
+	}
+
+	return nil
+}
+
+// groupsBy tells if the statement groups rows by the column
+func (stmt *SelectStmt) groupsBy(col *ColSelector) bool {
+	for _, c := range stmt.groupBy {
+		if c.col == col.col &&
+			(c.table == "" || col.table == "" || c.table == col.table) &&
+			(c.db == "" || col.db == "" || c.db == col.db) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func collectGroupingFns(exp ValueExp, fns *[]*GroupingExp) {
+	if exp == nil {
+		return
+	}
+
+	fn, isGrouping := exp.(*GroupingExp)
+	if isGrouping {
+		*fns = append(*fns, fn)
+	}
+
+	for _, e := range subExps(exp) {
+		collectGroupingFns(e, fns)
+	}
+}
+
+func groupingSelector(db, table, col string) string {
+	return EncodeSelector(groupingFnName, db, table, col)
+}
+
+// GroupingExp is the GROUPING(col) function, it's 1 for the rows of the grouping sets not including the column,
+// telling apart its NULL value standing for all the values from an actual NULL value, and 0 otherwise
+type GroupingExp struct {
+	col *ColSelector
+}
+
+func (e *GroupingExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return IntegerType, nil
+}
+
+func (e *GroupingExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != IntegerType {
+		return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, IntegerType, t)
+	}
+
+	return nil
+}
+
+func (e *GroupingExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	return e, nil
+}
+
+func (e *GroupingExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	_, db, table, col := e.col.resolve(implicitDB, implicitTable)
+
+	v, ok := row.ValuesBySelector[groupingSelector(db, table, col)]
+	if !ok {
+		// rows grouped by a plain GROUP BY are grouped by all of its columns
+		return &Number{val: 0}, nil
+	}
+
+	return v, nil
+}
+
+func (e *GroupingExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	_, db, table, col := e.col.resolve(implicitDB, implicitTable)
+
+	v, ok := row.ValuesBySelector[groupingSelector(db, table, col)]
+	if !ok {
+		return e
+	}
+
+	return v
+}
+
+func (e *GroupingExp) isConstant() bool {
+	return false
+}
+
+func (e *GroupingExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// groupingSetsRowReader aggregates the rows of the underlying reader once per grouping set. The grouping columns
+// not included in a set are NULL in its rows, and GROUPING tells them apart from actual NULL values.
+// Rows are fully read and kept in memory on first read, thus they are bounded by the distinct limit and by the
+// memory limit of the query. Groups are returned set after set, sorted by the columns of the set
+type groupingSetsRowReader struct {
+	rowReader RowReader
+
+	selectors []Selector
+	cols      []*ColSelector
+	sets      [][]*ColSelector
+
+	colSels []string
+	colDes  []ColDescriptor
+
+	// desc describes the aggregated rows, which are the same for all the sets
+	desc *groupedRowReader
+
+	rows   []*Row
+	loaded bool
+
+	// set is the index of the set being aggregated by grouped
+	set     int
+	grouped *groupedRowReader
+
+	mem     *memoryAccount
+	memUsed int64
+
+	stats *QueryStats
+}
+
+func newGroupingSetsRowReader(ctx context.Context, rowReader RowReader, selectors []Selector, cols []*ColSelector, sets [][]*ColSelector) (*groupingSetsRowReader, error) {
+	desc, err := newGroupedRowReader(rowReader, selectors, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	colsBySel, err := rowReader.colsBySelector(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	colSels := make([]string, len(cols))
+	colDes := make([]ColDescriptor, len(cols))
+
+	for i, col := range cols {
+		colSels[i] = EncodeSelector(col.resolve(rowReader.Database(), rowReader.TableAlias()))
+
+		des, ok := colsBySel[colSels[i]]
+		if !ok {
+			return nil, fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, col.col)
+		}
+
+		colDes[i] = des
+	}
+
+	return &groupingSetsRowReader{
+		rowReader: rowReader,
+		selectors: selectors,
+		cols:      cols,
+		sets:      sets,
+		colSels:   colSels,
+		colDes:    colDes,
+		desc:      desc,
+		mem:       memoryAccountFrom(ctx),
+		stats:     queryStatsFrom(ctx),
+	}, nil
+}
+
+func (gr *groupingSetsRowReader) onClose(callback func()) {
+	gr.rowReader.onClose(callback)
+}
+
+func (gr *groupingSetsRowReader) Tx() *SQLTx {
+	return gr.rowReader.Tx()
+}
+
+func (gr *groupingSetsRowReader) Database() string {
+	return gr.rowReader.Database()
+}
+
+func (gr *groupingSetsRowReader) TableAlias() string {
+	return gr.rowReader.TableAlias()
+}
+
+func (gr *groupingSetsRowReader) Parameters() map[string]interface{} {
+	return gr.rowReader.Parameters()
+}
+
+func (gr *groupingSetsRowReader) SetParameters(params map[string]interface{}) error {
+	return gr.rowReader.SetParameters(params)
+}
+
+func (gr *groupingSetsRowReader) OrderBy() []ColDescriptor {
+	return gr.rowReader.OrderBy()
+}
+
+func (gr *groupingSetsRowReader) ScanSpecs() *ScanSpecs {
+	return gr.rowReader.ScanSpecs()
+}
+
+func (gr *groupingSetsRowReader) Columns(ctx context.Context) ([]ColDescriptor, error) {
+	return gr.desc.Columns(ctx)
+}
+
+func (gr *groupingSetsRowReader) colsBySelector(ctx context.Context) (map[string]ColDescriptor, error) {
+	return gr.desc.colsBySelector(ctx)
+}
+
+func (gr *groupingSetsRowReader) InferParameters(ctx context.Context, params map[string]SQLValueType) error {
+	return gr.rowReader.InferParameters(ctx, params)
+}
+
+func (gr *groupingSetsRowReader) loadRows(ctx context.Context) error {
+	for {
+		row, err := gr.rowReader.Read(ctx)
+		if errors.Is(err, ErrNoMoreRows) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(gr.rows) == gr.Tx().distinctLimit() {
+			return ErrTooManyRows
+		}
+
+		size := rowMemSize(row)
+
+		err = gr.mem.reserve(size)
+		if err != nil {
+			return err
+		}
+
+		gr.memUsed += size
+		gr.rows = append(gr.rows, row)
+	}
+
+	gr.loaded = true
+
+	return nil
+}
+
+// groupSet returns the reader aggregating the rows by the columns of the set
+func (gr *groupingSetsRowReader) groupSet(set []*ColSelector) (*groupedRowReader, error) {
+	var colSels []string
+	for _, col := range set {
+		colSels = append(colSels, gr.colSels[indexOfGroupingCol(gr.cols, col)])
+	}
+
+	rows := make([]*Row, len(gr.rows))
+	copy(rows, gr.rows)
+
+	var cmpErr error
+
+	// rows with equal values must be contiguous to be grouped together, NULL values are placed first
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, sel := range colSels {
+			v1, ok1 := rows[i].ValuesBySelector[sel]
+			v2, ok2 := rows[j].ValuesBySelector[sel]
+
+			if !ok1 || !ok2 {
+				cmpErr = fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, sel)
+				return false
+			}
+
+			if v1.IsNull() || v2.IsNull() {
+				if v1.IsNull() == v2.IsNull() {
+					continue
+				}
+
+				return v1.IsNull()
+			}
+
+			cmp, err := v1.Compare(v2)
+			if err != nil {
+				cmpErr = err
+				return false
+			}
+
+			if cmp != 0 {
+				return cmp < 0
+			}
+		}
+
+		return false
+	})
+	if cmpErr != nil {
+		return nil, cmpErr
+	}
+
+	return &groupedRowReader{
+		rowReader: &bufferedRowReader{RowReader: gr.rowReader, rows: rows},
+		selectors: gr.selectors,
+		groupBy:   set,
+		stats:     gr.stats,
+	}, nil
+}
+
+func (gr *groupingSetsRowReader) Read(ctx context.Context) (*Row, error) {
+	if !gr.loaded {
+		err := gr.loadRows(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for gr.set < len(gr.sets) {
+		if gr.grouped == nil {
+			grouped, err := gr.groupSet(gr.sets[gr.set])
+			if err != nil {
+				return nil, err
+			}
+
+			gr.grouped = grouped
+		}
+
+		row, err := gr.grouped.Read(ctx)
+		if errors.Is(err, ErrNoMoreRows) {
+			gr.grouped = nil
+			gr.set++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		gr.markGrouping(row, gr.sets[gr.set])
+
+		return row, nil
+	}
+
+	return nil, ErrNoMoreRows
+}
+
+// markGrouping sets the grouping columns not included in the set to NULL, recording it for GROUPING
+func (gr *groupingSetsRowReader) markGrouping(row *Row, set []*ColSelector) {
+	for i, col := range gr.cols {
+		_, db, table, colName := col.resolve(gr.rowReader.Database(), gr.rowReader.TableAlias())
+
+		if indexOfGroupingCol(set, col) >= 0 {
+			row.ValuesBySelector[groupingSelector(db, table, colName)] = &Number{val: 0}
+			continue
+		}
+
+		row.ValuesBySelector[gr.colSels[i]] = &NullValue{t: gr.colDes[i].Type}
+		row.ValuesBySelector[groupingSelector(db, table, colName)] = &Number{val: 1}
+	}
+}
+
+func (gr *groupingSetsRowReader) Close() error {
+	gr.mem.release(gr.memUsed)
+	gr.memUsed = 0
+	gr.rows = nil
+
+	return gr.rowReader.Close()
+}
+
+// bufferedRowReader returns copies of rows kept in memory, so they can be read once per grouping set
+// regardless of the values the aggregation adds to them. Closing it does not close the underlying reader
+type bufferedRowReader struct {
+	RowReader
+
+	rows []*Row
+}
+
+func (br *bufferedRowReader) Read(ctx context.Context) (*Row, error) {
+	if len(br.rows) == 0 {
+		return nil, ErrNoMoreRows
+	}
+
+	row := br.rows[0]
+	br.rows = br.rows[1:]
+
+	valuesBySelector := make(map[string]TypedValue, len(row.ValuesBySelector))
+	for sel, v := range row.ValuesBySelector {
+		valuesBySelector[sel] = v
+	}
+
+	return &Row{
+		ValuesByPosition: append([]TypedValue{}, row.ValuesByPosition...),
+		ValuesBySelector: valuesBySelector,
+	}, nil
+}
+
+func (br *bufferedRowReader) Close() error {
+	return nil
+}
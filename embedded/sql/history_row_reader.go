@@ -0,0 +1,156 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// HistoryTxIDCol and HistoryTxTimestampCol are the columns added to the rows read from the history of a table,
+// they hold the id and timestamp of the transaction which committed each version of a row
+const (
+	HistoryTxIDCol        = "_tx_id"
+	HistoryTxTimestampCol = "_tx_ts"
+)
+
+// historyBatchSize is the number of versions of a row fetched at once from the history of its key
+const historyBatchSize = 100
+
+// historyRowReader reads every version of the rows of a table (HISTORY).
+//
+// Rows are scanned by primary key and each one is expanded into all its versions, walking the history
+// of its key in the primary index. Versions of a row are read in the order they were committed, or in
+// reverse order when rows are scanned in descending order. Deleted rows keep their history, but the
+// deletion itself is not read as a version.
+//
+// When a period is specified, only versions committed within it are read.
+type historyRowReader struct {
+	*rawRowReader
+
+	// key of the current row and the transactions of its versions not read yet
+	key      []byte
+	versions []uint64
+}
+
+func newHistoryRowReader(tx *SQLTx, params map[string]interface{}, table *Table, period period, tableAlias string, scanSpecs *ScanSpecs) (*historyRowReader, error) {
+	if table == nil || scanSpecs == nil || scanSpecs.Index == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	for _, colName := range []string{HistoryTxIDCol, HistoryTxTimestampCol} {
+		_, err := table.GetColumnByName(colName)
+		if err == nil {
+			return nil, fmt.Errorf("%w: column '%s' of table '%s' conflicts with the history columns", ErrDuplicatedColumn, colName, table.name)
+		}
+	}
+
+	// history is kept by the primary index, ranges over its columns still narrow the scan
+	pkScanSpecs := &ScanSpecs{
+		Index:         table.primaryIndex,
+		rangesByColID: scanSpecs.rangesByColID,
+		DescOrder:     scanSpecs.Index.IsPrimary() && scanSpecs.DescOrder,
+	}
+
+	// deleted rows are scanned as well, their previous versions are still part of the history
+	r, err := newRawRowReaderWithFilters(tx, params, table, period, tableAlias, pkScanSpecs, store.IgnoreExpired)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range []ColDescriptor{
+		{Database: r.db, Table: r.tableAlias, Column: HistoryTxIDCol, Type: IntegerType},
+		{Database: r.db, Table: r.tableAlias, Column: HistoryTxTimestampCol, Type: TimestampType},
+	} {
+		r.colsByPos = append(r.colsByPos, c)
+		r.colsBySel[c.Selector()] = c
+	}
+
+	return &historyRowReader{rawRowReader: r}, nil
+}
+
+func (r *historyRowReader) Read(ctx context.Context) (*Row, error) {
+	err := r.reduceTxRange()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		for len(r.versions) == 0 {
+			key, _, err := r.reader.Read()
+			if err != nil {
+				return nil, err
+			}
+
+			versions, err := r.tx.history(key)
+			if err != nil {
+				return nil, err
+			}
+
+			if r.scanSpecs.DescOrder {
+				for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+					versions[i], versions[j] = versions[j], versions[i]
+				}
+			}
+
+			r.key = key
+			r.versions = versions
+		}
+
+		txID := r.versions[0]
+		r.versions = r.versions[1:]
+
+		if r.txRange != nil && (txID < r.txRange.initialTxID || txID > r.txRange.finalTxID) {
+			continue
+		}
+
+		entry, hdr, err := r.tx.engine.store.ReadTxEntry(txID, r.key)
+		if err != nil {
+			return nil, err
+		}
+
+		if entry.Metadata() != nil && entry.Metadata().Deleted() {
+			continue
+		}
+
+		v, err := r.tx.engine.store.ReadValue(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		row, err := r.decodeRow(v)
+		if err != nil {
+			return nil, err
+		}
+
+		txIDVal := &Number{val: int64(txID)}
+		tsVal := &Timestamp{val: time.Unix(hdr.Ts, 0).UTC()}
+
+		row.ValuesByPosition = append(row.ValuesByPosition, txIDVal, tsVal)
+		row.ValuesBySelector[EncodeSelector("", r.db, r.tableAlias, HistoryTxIDCol)] = txIDVal
+		row.ValuesBySelector[EncodeSelector("", r.db, r.tableAlias, HistoryTxTimestampCol)] = tsVal
+
+		return row, nil
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowHistory(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE accounts (
+			id INTEGER,
+			balance INTEGER,
+			PRIMARY KEY id
+		);
+
+		CREATE INDEX ON accounts(balance);
+	`, nil)
+	require.NoError(t, err)
+
+	var txIDs []int64
+
+	for _, q := range []string{
+		"UPSERT INTO accounts(id, balance) VALUES (1, 100), (2, 200), (3, 300)",
+		"UPSERT INTO accounts(id, balance) VALUES (2, 210)",
+		"UPSERT INTO accounts(id, balance) VALUES (2, 220)",
+		"DELETE FROM accounts WHERE id = 3",
+	} {
+		_, txs, err := engine.Exec(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		require.Len(t, txs, 1)
+
+		txIDs = append(txIDs, int64(txs[0].TxHeader().ID))
+	}
+
+	queryRows := func(t *testing.T, q string, params map[string]interface{}) [][]TypedValue {
+		r, err := engine.Query(context.Background(), nil, q, params)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var rows [][]TypedValue
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				return rows
+			}
+			require.NoError(t, err)
+
+			rows = append(rows, row.ValuesByPosition)
+		}
+	}
+
+	requireVersions := func(t *testing.T, rows [][]TypedValue, expected [][]int64) {
+		require.Len(t, rows, len(expected))
+
+		for i, values := range expected {
+			for j, v := range values {
+				require.Equal(t, v, rows[i][j].Value(), "row %d, column %d", i, j)
+			}
+		}
+	}
+
+	t.Run("every version of a row should be read in tx order", func(t *testing.T) {
+		rows := queryRows(t, "SELECT * FROM accounts HISTORY WHERE id = 2", nil)
+
+		requireVersions(t, rows, [][]int64{
+			{2, 200, txIDs[0]},
+			{2, 210, txIDs[1]},
+			{2, 220, txIDs[2]},
+		})
+
+		for _, row := range rows {
+			require.Len(t, row, 4)
+			require.Equal(t, TimestampType, row[3].Type())
+			require.False(t, row[3].IsNull())
+		}
+
+		r, err := engine.Query(context.Background(), nil, "SELECT * FROM accounts HISTORY AS h", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns(context.Background())
+		require.NoError(t, err)
+		require.Len(t, cols, 4)
+		require.Equal(t, HistoryTxIDCol, cols[2].Column)
+		require.Equal(t, IntegerType, cols[2].Type)
+		require.Equal(t, HistoryTxTimestampCol, cols[3].Column)
+		require.Equal(t, TimestampType, cols[3].Type)
+	})
+
+	t.Run("deleted rows should keep their history", func(t *testing.T) {
+		rows := queryRows(t, "SELECT id, balance, _tx_id FROM accounts HISTORY", nil)
+
+		requireVersions(t, rows, [][]int64{
+			{1, 100, txIDs[0]},
+			{2, 200, txIDs[0]},
+			{2, 210, txIDs[1]},
+			{2, 220, txIDs[2]},
+			{3, 300, txIDs[0]},
+		})
+
+		rows = queryRows(t, "SELECT id FROM accounts", nil)
+		require.Len(t, rows, 2)
+	})
+
+	t.Run("versions should be filtered by period and conditions", func(t *testing.T) {
+		rows := queryRows(t, "SELECT id, balance, _tx_id FROM accounts SINCE TX @tx HISTORY WHERE balance > 200", map[string]interface{}{"tx": txIDs[1]})
+
+		requireVersions(t, rows, [][]int64{
+			{2, 210, txIDs[1]},
+			{2, 220, txIDs[2]},
+		})
+	})
+
+	t.Run("versions should be read in reverse order when rows are", func(t *testing.T) {
+		rows := queryRows(t, "SELECT id, balance FROM accounts HISTORY WHERE id <= 2 ORDER BY id DESC", nil)
+
+		requireVersions(t, rows, [][]int64{
+			{2, 220},
+			{2, 210},
+			{2, 200},
+			{1, 100},
+		})
+	})
+
+	t.Run("history should only be sorted by primary key", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT id FROM accounts HISTORY ORDER BY balance", nil)
+		require.ErrorIs(t, err, ErrLimitedOrderBy)
+	})
+
+	t.Run("history of rows changed by an ongoing transaction should not be available", func(t *testing.T) {
+		tx, _, err := engine.Exec(context.Background(), nil, "BEGIN TRANSACTION; UPSERT INTO accounts(id, balance) VALUES (2, 230);", nil)
+		require.NoError(t, err)
+		defer tx.Cancel()
+
+		r, err := engine.Query(context.Background(), tx, "SELECT id FROM accounts HISTORY WHERE id = 1", nil)
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.NoError(t, err)
+		r.Close()
+
+		r, err = engine.Query(context.Background(), tx, "SELECT id FROM accounts HISTORY WHERE id = 2", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, store.ErrIllegalState)
+	})
+
+	t.Run("history columns should not conflict with table columns", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE versions (id INTEGER, _tx_id INTEGER, PRIMARY KEY id)", nil)
+		require.NoError(t, err)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT * FROM versions HISTORY", nil)
+		require.ErrorIs(t, err, ErrDuplicatedColumn)
+	})
+}
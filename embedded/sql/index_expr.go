@@ -0,0 +1,257 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// indexExprText returns the canonical text of an expression over the columns of a table.
+// The text identifies the values of the expression in indexes, so the same expression
+// used in a query is matched against the ones of indexed expressions
+func indexExprText(exp ValueExp, table *Table, asTable string) (string, error) {
+	var b strings.Builder
+
+	refs, err := writeIndexExpr(&b, exp, table, asTable)
+	if err != nil {
+		return "", err
+	}
+
+	if refs == 0 {
+		return "", fmt.Errorf("%w: no column is referenced", ErrLimitedIndexExpression)
+	}
+
+	return b.String(), nil
+}
+
+func writeIndexExpr(b *strings.Builder, exp ValueExp, table *Table, asTable string) (refs int, err error) {
+	switch e := exp.(type) {
+	case *ColSelector:
+		{
+			aggFn, db, t, col := e.resolve(table.db.name, asTable)
+			if aggFn != "" || db != table.db.name || t != asTable {
+				return 0, fmt.Errorf("%w: column %s is not in table %s", ErrLimitedIndexExpression, col, table.name)
+			}
+
+			_, err := table.GetColumnByName(col)
+			if err != nil {
+				return 0, err
+			}
+
+			b.WriteString(col)
+
+			return 1, nil
+		}
+	case *FnCall:
+		{
+			fn, err := e.lookupFunction()
+			if err != nil {
+				return 0, err
+			}
+
+			name := strings.ToUpper(e.fn)
+
+			if fn.Volatile {
+				return 0, fmt.Errorf("%w: function %s is not deterministic", ErrLimitedIndexExpression, name)
+			}
+
+			b.WriteString(name)
+			b.WriteString("(")
+
+			params := e.params
+
+			if name == ExtractFnCall {
+				field, ok := params[0].(*Varchar)
+				if !ok || len(params) != 2 {
+					return 0, fmt.Errorf("%w: invalid arguments for function %s", ErrIllegalArguments, name)
+				}
+
+				b.WriteString(strings.ToUpper(field.val))
+				b.WriteString(" FROM ")
+
+				params = params[1:]
+			}
+
+			for i, p := range params {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+
+				n, err := writeIndexExpr(b, p, table, asTable)
+				if err != nil {
+					return 0, err
+				}
+
+				refs += n
+			}
+
+			b.WriteString(")")
+
+			return refs, nil
+		}
+	case *Cast:
+		{
+			b.WriteString("CAST(")
+
+			refs, err := writeIndexExpr(b, e.val, table, asTable)
+			if err != nil {
+				return 0, err
+			}
+
+			b.WriteString(" AS ")
+			b.WriteString(string(e.t))
+			b.WriteString(")")
+
+			return refs, nil
+		}
+	case *NumExp:
+		{
+			ops := map[NumOperator]string{
+				ADDOP:    " + ",
+				SUBSOP:   " - ",
+				DIVOP:    " / ",
+				MULTOP:   " * ",
+				BITANDOP: " & ",
+				BITOROP:  " | ",
+				BITXOROP: " ^ ",
+				SHLOP:    " << ",
+				SHROP:    " >> ",
+			}
+
+			b.WriteString("(")
+
+			lrefs, err := writeIndexExpr(b, e.left, table, asTable)
+			if err != nil {
+				return 0, err
+			}
+
+			b.WriteString(ops[e.op])
+
+			rrefs, err := writeIndexExpr(b, e.right, table, asTable)
+			if err != nil {
+				return 0, err
+			}
+
+			b.WriteString(")")
+
+			return lrefs + rrefs, nil
+		}
+	case *Number:
+		{
+			b.WriteString(strconv.FormatInt(e.val, 10))
+			return 0, nil
+		}
+	case *Varchar:
+		{
+			b.WriteString("'")
+			b.WriteString(strings.ReplaceAll(e.val, "'", "''"))
+			b.WriteString("'")
+			return 0, nil
+		}
+	case *Bool:
+		{
+			if e.val {
+				b.WriteString("TRUE")
+			} else {
+				b.WriteString("FALSE")
+			}
+			return 0, nil
+		}
+	case *NullValue:
+		{
+			b.WriteString("NULL")
+			return 0, nil
+		}
+	}
+
+	return 0, ErrLimitedIndexExpression
+}
+
+func exprReferencesColumn(exp ValueExp, colName string) bool {
+	switch e := exp.(type) {
+	case *ColSelector:
+		return e.col == colName
+	case *FnCall:
+		for _, p := range e.params {
+			if exprReferencesColumn(p, colName) {
+				return true
+			}
+		}
+	case *Cast:
+		return exprReferencesColumn(e.val, colName)
+	case *NumExp:
+		return exprReferencesColumn(e.left, colName) || exprReferencesColumn(e.right, colName)
+	}
+
+	return false
+}
+
+// parseIndexExpr parses back the canonical text of an indexed expression
+func parseIndexExpr(text string) (ValueExp, error) {
+	stmts, err := ParseString(fmt.Sprintf("CREATE INDEX ON t(%s)", text))
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, ok := stmts[0].(*CreateIndexStmt)
+	if !ok || len(stmts) != 1 || len(stmt.exprs) != 1 || stmt.exprs[0] == nil {
+		return nil, fmt.Errorf("%w: invalid index expression %s", ErrIllegalArguments, text)
+	}
+
+	return stmt.exprs[0], nil
+}
+
+// withIndexedExprs returns the values of the row extended with the ones of the indexed expressions
+func (tx *SQLTx) withIndexedExprs(table *Table, valuesByColID map[uint32]TypedValue) (map[uint32]TypedValue, error) {
+	if len(table.exprCols) == 0 {
+		return valuesByColID, nil
+	}
+
+	row := &Row{ValuesBySelector: make(map[string]TypedValue, len(table.cols))}
+
+	for _, col := range table.cols {
+		val, specified := valuesByColID[col.id]
+		if !specified || val == nil {
+			val = &NullValue{t: col.colType}
+		}
+
+		row.ValuesBySelector[EncodeSelector("", table.db.name, table.name, col.colName)] = val
+	}
+
+	values := make(map[uint32]TypedValue, len(valuesByColID)+len(table.exprCols))
+
+	for id, val := range valuesByColID {
+		values[id] = val
+	}
+
+	for _, col := range table.exprCols {
+		val, err := col.expr.reduce(tx, row, table.db.name, table.name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, col.colName)
+		}
+
+		if val.Type() != col.colType && !val.IsNull() {
+			return nil, fmt.Errorf("%w: expected type %v but found %v (%s)", ErrInvalidTypes, col.colType, val.Type(), col.colName)
+		}
+
+		values[col.id] = val
+	}
+
+	return values, nil
+}
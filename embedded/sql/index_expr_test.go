@@ -0,0 +1,178 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpressionIndexes(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	t.Cleanup(func() { closeStore(t, st) })
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE DATABASE db1;
+		USE DATABASE db1;
+
+		CREATE TABLE users (
+			id INTEGER AUTO_INCREMENT,
+			email VARCHAR[64],
+			created_at TIMESTAMP,
+			PRIMARY KEY id
+		);
+	`, nil)
+	require.NoError(t, err)
+
+	t.Run("invalid expressions are rejected", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "CREATE INDEX ON users(DATE(NOW()))", nil)
+		require.ErrorIs(t, err, ErrLimitedIndexExpression)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON users(LOWER(@email))", nil)
+		require.ErrorIs(t, err, ErrLimitedIndexExpression)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON users(LOWER('a'))", nil)
+		require.ErrorIs(t, err, ErrLimitedIndexExpression)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON users(LOWER(name))", nil)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON users(LOWER(accounts.email))", nil)
+		require.ErrorIs(t, err, ErrLimitedIndexExpression)
+	})
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE UNIQUE INDEX ON users(LOWER(email));
+		CREATE INDEX ON users(DATE(created_at), id);
+		CREATE INDEX IF NOT EXISTS ON users(lower(users.email));
+	`, nil)
+	require.NoError(t, err)
+
+	ts := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	for i, email := range []string{"Alice@Example.com", "bob@example.com", "CAROL@example.com"} {
+		_, _, err = engine.Exec(context.Background(), nil,
+			"INSERT INTO users(email, created_at) VALUES (@email, @created_at)",
+			map[string]interface{}{"email": email, "created_at": ts.Add(time.Duration(i) * 12 * time.Hour)},
+		)
+		require.NoError(t, err)
+	}
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO users(email) VALUES ('ALICE@example.com')", nil)
+	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+	queryIDs := func(t *testing.T, query string, params map[string]interface{}, expectedIndex string) []int64 {
+		r, err := engine.Query(context.Background(), nil, query, params)
+		require.NoError(t, err)
+		defer r.Close()
+
+		require.Equal(t, expectedIndex, r.ScanSpecs().Index.Name())
+
+		var ids []int64
+
+		for {
+			row, err := r.Read(context.Background())
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.ValuesByPosition[0].Value().(int64))
+		}
+
+		return ids
+	}
+
+	t.Run("queries on indexed expressions use the index", func(t *testing.T) {
+		ids := queryIDs(t, "SELECT id FROM users WHERE LOWER(email) = 'alice@example.com'", nil, "users[LOWER(email)]")
+		require.Equal(t, []int64{1}, ids)
+
+		ids = queryIDs(t, "SELECT id FROM users u WHERE lower(u.email) = @email", map[string]interface{}{"email": "carol@example.com"}, "users[LOWER(email)]")
+		require.Equal(t, []int64{3}, ids)
+
+		ids = queryIDs(t, "SELECT id FROM users WHERE DATE(created_at) = @day", map[string]interface{}{"day": ts.Truncate(24 * time.Hour)}, "users[DATE(created_at),id]")
+		require.Equal(t, []int64{1, 2}, ids)
+
+		ids = queryIDs(t, "SELECT id FROM users WHERE UPPER(email) = 'BOB@EXAMPLE.COM'", nil, "users[id]")
+		require.Equal(t, []int64{2}, ids)
+	})
+
+	t.Run("index entries are kept up to date", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "UPDATE users SET email = 'Robert@Example.com' WHERE id = 2", nil)
+		require.NoError(t, err)
+
+		ids := queryIDs(t, "SELECT id FROM users WHERE LOWER(email) = 'bob@example.com'", nil, "users[LOWER(email)]")
+		require.Empty(t, ids)
+
+		ids = queryIDs(t, "SELECT id FROM users WHERE LOWER(email) = 'robert@example.com'", nil, "users[LOWER(email)]")
+		require.Equal(t, []int64{2}, ids)
+
+		_, _, err = engine.Exec(context.Background(), nil, "DELETE FROM users WHERE id = 1", nil)
+		require.NoError(t, err)
+
+		ids = queryIDs(t, "SELECT id FROM users WHERE LOWER(email) = 'alice@example.com'", nil, "users[LOWER(email)]")
+		require.Empty(t, ids)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO users(email) VALUES ('ALICE@example.com')", nil)
+		require.NoError(t, err)
+
+		ids = queryIDs(t, "SELECT id FROM users WHERE LOWER(email) = 'alice@example.com'", nil, "users[LOWER(email)]")
+		require.Equal(t, []int64{4}, ids)
+	})
+
+	t.Run("indexed columns can not be renamed", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "ALTER TABLE users RENAME COLUMN email TO mail", nil)
+		require.ErrorIs(t, err, ErrLimitedIndexExpression)
+	})
+
+	t.Run("indexed expressions are loaded with the catalog", func(t *testing.T) {
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		err = engine.SetCurrentDatabase(context.Background(), "db1")
+		require.NoError(t, err)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM users WHERE LOWER(email) = 'carol@example.com'", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		require.Equal(t, "users[LOWER(email)]", r.ScanSpecs().Index.Name())
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(3), row.ValuesByPosition[0].Value())
+
+		export, err := engine.ExportCatalog(context.Background(), nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"LOWER(email)"}, export.Tables[0].Indexes[0].Columns)
+		require.Equal(t, []string{"DATE(created_at)", "id"}, export.Tables[0].Indexes[1].Columns)
+
+		stmts, err := export.stmts()
+		require.NoError(t, err)
+		require.Equal(t, newCreateIndexStmt(true, false, "users", []ValueExp{
+			&FnCall{fn: "lower", params: []ValueExp{&ColSelector{col: "email"}}},
+		}), stmts[1])
+	})
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"fmt"
+	"math"
+)
+
+// defaultRowsEstimate is the number of rows assumed for data sources whose size is unknown,
+// i.e. tables without an auto-increment primary key and function data sources
+const defaultRowsEstimate = 1000
+
+// checkJoinComplexity rejects a query before it is executed when it joins more data sources
+// than allowed, or when the estimated number of intermediate rows produced by its joins
+// exceeds the configured limit.
+//
+// The number of intermediate rows is estimated as the product of the rows of the joined sources,
+// where the rows of a table are estimated by its largest auto-increment primary key.
+// A join whose condition matches the leading column of an index of the joined table by equality
+// is resolved by index lookups, thus it's assumed not to multiply the number of rows.
+func (stmt *SelectStmt) checkJoinComplexity(tx *SQLTx) error {
+	maxJoinedSources := tx.engine.maxJoinedSources
+	maxJoinRows := tx.engine.maxJoinRows
+
+	if maxJoinedSources > 0 {
+		sources := joinedSources(stmt)
+
+		if sources > maxJoinedSources {
+			return fmt.Errorf("%w: %d data sources are joined, the maximum is %d", ErrQueryTooComplex, sources, maxJoinedSources)
+		}
+	}
+
+	if maxJoinRows > 0 {
+		rows := estimateRows(tx, stmt)
+
+		if rows > uint64(maxJoinRows) {
+			return fmt.Errorf("%w: joins are estimated to produce %d intermediate rows, the maximum is %d", ErrQueryTooComplex, rows, maxJoinRows)
+		}
+	}
+
+	return nil
+}
+
+// joinedSources returns the number of data sources read by ds, including the ones of its subqueries
+func joinedSources(ds DataSource) int {
+	switch ds := ds.(type) {
+	case *SelectStmt:
+		{
+			sources := joinedSources(ds.ds)
+
+			for _, join := range ds.joins {
+				sources += joinedSources(join.ds)
+			}
+
+			return sources
+		}
+	case *UnionStmt:
+		{
+			return joinedSources(ds.left) + joinedSources(ds.right)
+		}
+	case *SetOpStmt:
+		{
+			return joinedSources(ds.left) + joinedSources(ds.right)
+		}
+	}
+
+	return 1
+}
+
+func estimateRows(tx *SQLTx, ds DataSource) uint64 {
+	switch ds := ds.(type) {
+	case *tableRef:
+		{
+			table, err := ds.referencedTable(tx)
+			if err != nil || !table.autoIncrementPK {
+				// unresolvable tables are reported when the query is resolved
+				return defaultRowsEstimate
+			}
+
+			return uint64(table.maxPK)
+		}
+	case *SelectStmt:
+		{
+			rows := estimateRows(tx, ds.ds)
+
+			for _, join := range ds.joins {
+				if join.isIndexLookup(tx) {
+					continue
+				}
+
+				rows = saturatingMul(rows, estimateRows(tx, join.ds))
+			}
+
+			return rows
+		}
+	case *UnionStmt:
+		{
+			return saturatingAdd(estimateRows(tx, ds.left), estimateRows(tx, ds.right))
+		}
+	case *SetOpStmt:
+		{
+			return saturatingAdd(estimateRows(tx, ds.left), estimateRows(tx, ds.right))
+		}
+	}
+
+	return defaultRowsEstimate
+}
+
+// isIndexLookup returns true if the rows of the joined table can be looked up by the join condition,
+// as it constrains the leading column of one of its indexes by equality
+func (jspec *JoinSpec) isIndexLookup(tx *SQLTx) bool {
+	tableRef, isTableRef := jspec.ds.(*tableRef)
+	if !isTableRef {
+		return false
+	}
+
+	table, err := tableRef.referencedTable(tx)
+	if err != nil {
+		return false
+	}
+
+	return lookupCols(jspec.cond, table, tableRef.Alias(), func(col *Column) bool {
+		for _, index := range table.indexesByColID[col.id] {
+			if index.cols[0].id == col.id {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// lookupCols returns true if any column of the table compared by equality in the conjunctive
+// condition exp satisfies fn
+func lookupCols(exp ValueExp, table *Table, alias string, fn func(col *Column) bool) bool {
+	switch exp := exp.(type) {
+	case *BinBoolExp:
+		{
+			if exp.op != AND {
+				return false
+			}
+
+			return lookupCols(exp.left, table, alias, fn) || lookupCols(exp.right, table, alias, fn)
+		}
+	case *CmpBoolExp:
+		{
+			if exp.op != EQ {
+				return false
+			}
+
+			col, ok := joinedCol(exp.left, table, alias)
+			if ok && !refersTo(exp.right, table, alias) && fn(col) {
+				return true
+			}
+
+			col, ok = joinedCol(exp.right, table, alias)
+
+			return ok && !refersTo(exp.left, table, alias) && fn(col)
+		}
+	case *DistinctFromBoolExp:
+		{
+			if !exp.negate {
+				return false
+			}
+
+			return lookupCols(&CmpBoolExp{op: EQ, left: exp.left, right: exp.right}, table, alias, fn)
+		}
+	}
+
+	return false
+}
+
+// joinedCol returns the column of the joined table referenced by exp, if any.
+// Unqualified selectors are assumed to reference the joined table when it has such a column
+func joinedCol(exp ValueExp, table *Table, alias string) (*Column, bool) {
+	sel, isSel := exp.(*ColSelector)
+	if !isSel || (sel.table != "" && sel.table != alias) {
+		return nil, false
+	}
+
+	col, err := table.GetColumnByName(sel.col)
+	if err != nil {
+		return nil, false
+	}
+
+	return col, true
+}
+
+func refersTo(exp ValueExp, table *Table, alias string) bool {
+	_, ok := joinedCol(exp, table, alias)
+	return ok
+}
+
+func saturatingMul(a, b uint64) uint64 {
+	if a != 0 && b > math.MaxUint64/a {
+		return math.MaxUint64
+	}
+
+	return a * b
+}
+
+func saturatingAdd(a, b uint64) uint64 {
+	if a > math.MaxUint64-b {
+		return math.MaxUint64
+	}
+
+	return a + b
+}
@@ -31,9 +31,13 @@ type jointRowReader struct {
 	rowReaders                 []RowReader
 	rowReadersValuesByPosition [][]TypedValue
 	rowReadersValuesBySelector []map[string]TypedValue
+
+	// joined data sources are resolved while reading, sharing the memory account and statistics of the query
+	mem   *memoryAccount
+	stats *QueryStats
 }
 
-func newJointRowReader(rowReader RowReader, joins []*JoinSpec) (*jointRowReader, error) {
+func newJointRowReader(ctx context.Context, rowReader RowReader, joins []*JoinSpec) (*jointRowReader, error) {
 	if rowReader == nil || len(joins) == 0 {
 		return nil, ErrIllegalArguments
 	}
@@ -42,6 +46,11 @@ func newJointRowReader(rowReader RowReader, joins []*JoinSpec) (*jointRowReader,
 		if jspec.joinType != InnerJoin {
 			return nil, ErrUnsupportedJoinType
 		}
+
+		_, isSelectStmt := jspec.ds.(*SelectStmt)
+		if jspec.lateral && !isSelectStmt {
+			return nil, fmt.Errorf("%w: lateral joins are only supported with select statements", ErrNoSupported)
+		}
 	}
 
 	return &jointRowReader{
@@ -50,6 +59,8 @@ func newJointRowReader(rowReader RowReader, joins []*JoinSpec) (*jointRowReader,
 		rowReaders:                 []RowReader{rowReader},
 		rowReadersValuesByPosition: make([][]TypedValue, 1+len(joins)),
 		rowReadersValuesBySelector: make([]map[string]TypedValue, 1+len(joins)),
+		mem:                        memoryAccountFrom(ctx),
+		stats:                      queryStatsFrom(ctx),
 	}, nil
 }
 
@@ -93,7 +104,7 @@ func (jointr *jointRowReader) colsBySelector(ctx context.Context) (map[string]Co
 		//            on jointRowReader creation,
 		// Note: We're using a dummy ScanSpec object that is only used during read, we're only interested
 		//       in column list though
-		rr, err := jspec.ds.Resolve(ctx, jointr.Tx(), nil, &ScanSpecs{Index: &Index{}})
+		rr, err := jointr.resolveUncorrelated(ctx, jspec, colDescriptors)
 		if err != nil {
 			return nil, err
 		}
@@ -133,7 +144,12 @@ func (jointr *jointRowReader) colsByPos(ctx context.Context) ([]ColDescriptor, e
 		//            on jointRowReader creation,
 		// Note: We're using a dummy ScanSpec object that is only used during read, we're only interested
 		//       in column list though
-		rr, err := jspec.ds.Resolve(ctx, jointr.Tx(), nil, &ScanSpecs{Index: &Index{}})
+		precedingCols := make(map[string]ColDescriptor, len(colDescriptors))
+		for _, col := range colDescriptors {
+			precedingCols[col.Selector()] = col
+		}
+
+		rr, err := jointr.resolveUncorrelated(ctx, jspec, precedingCols)
 		if err != nil {
 			return nil, err
 		}
@@ -162,7 +178,13 @@ func (jointr *jointRowReader) InferParameters(ctx context.Context, params map[st
 	}
 
 	for _, join := range jointr.joins {
-		err = join.ds.inferParameters(ctx, jointr.Tx(), params)
+		ds := join.ds
+
+		if join.lateral {
+			ds = correlatedStmt(ds.(*SelectStmt), nullRow(cols), jointr.Database())
+		}
+
+		err = ds.inferParameters(ctx, jointr.Tx(), params)
 		if err != nil {
 			return err
 		}
@@ -235,13 +257,19 @@ func (jointr *jointRowReader) Read(ctx context.Context) (row *Row, err error) {
 		for i := len(jointr.rowReaders) - 1; i < len(jointr.joins); i++ {
 			jspec := jointr.joins[i]
 
+			ds := jspec.ds
+
+			if jspec.lateral {
+				ds = correlatedStmt(ds.(*SelectStmt), row, jointr.Database())
+			}
+
 			jointq := &SelectStmt{
-				ds:      jspec.ds,
+				ds:      ds,
 				where:   jspec.cond.reduceSelectors(row, jointr.Database(), jointr.TableAlias()),
 				indexOn: jspec.indexOn,
 			}
 
-			reader, err := jointq.Resolve(ctx, jointr.Tx(), jointr.Parameters(), nil)
+			reader, err := jointq.Resolve(jointr.resolveCtx(ctx), jointr.Tx(), jointr.Parameters(), nil)
 			if err != nil {
 				return nil, err
 			}
@@ -283,6 +311,45 @@ func (jointr *jointRowReader) Read(ctx context.Context) (row *Row, err error) {
 	}
 }
 
+// resolveUncorrelated resolves the joined data source without an actual row to be joined,
+// lateral subqueries are resolved using NULL values for the columns of the preceding data sources
+func (jointr *jointRowReader) resolveUncorrelated(ctx context.Context, jspec *JoinSpec, precedingCols map[string]ColDescriptor) (RowReader, error) {
+	ds := jspec.ds
+
+	if jspec.lateral {
+		ds = correlatedStmt(ds.(*SelectStmt), nullRow(precedingCols), jointr.Database())
+	}
+
+	// data sources are resolved only to get their columns, thus they are not accounted as scanned
+	return ds.Resolve(withQueryStats(withMemoryAccount(ctx, jointr.mem), nil), jointr.Tx(), nil, &ScanSpecs{Index: &Index{}})
+}
+
+func nullRow(cols map[string]ColDescriptor) *Row {
+	row := &Row{
+		ValuesBySelector: make(map[string]TypedValue, len(cols)),
+	}
+
+	for sel, col := range cols {
+		row.ValuesBySelector[sel] = &NullValue{t: col.Type}
+	}
+
+	return row
+}
+
+// correlatedStmt returns a copy of the lateral subquery where the selectors of its where clause
+// referencing columns of the row being joined are replaced by their values.
+// Unqualified selectors refer to the data source of the subquery.
+func correlatedStmt(stmt *SelectStmt, row *Row, implicitDB string) *SelectStmt {
+	if stmt.where == nil {
+		return stmt
+	}
+
+	correlated := *stmt
+	correlated.where = stmt.where.reduceSelectors(row, implicitDB, stmt.ds.Alias())
+
+	return &correlated
+}
+
 func (jointr *jointRowReader) Close() error {
 	merr := multierr.NewMultiErr()
 
@@ -295,3 +362,9 @@ func (jointr *jointRowReader) Close() error {
 
 	return merr.Reduce()
 }
+
+// resolveCtx returns the context in which joined data sources are resolved while reading rows,
+// which accounts them as the rest of the query
+func (jointr *jointRowReader) resolveCtx(ctx context.Context) context.Context {
+	return withQueryStats(withMemoryAccount(ctx, jointr.mem), jointr.stats)
+}
@@ -32,7 +32,7 @@ func TestJointRowReader(t *testing.T) {
 	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
 
-	_, err = newJointRowReader(nil, nil)
+	_, err = newJointRowReader(context.Background(), nil, nil)
 	require.Equal(t, ErrIllegalArguments, err)
 
 	tx, err := engine.NewTx(context.Background(), DefaultTxOptions())
@@ -55,13 +55,13 @@ func TestJointRowReader(t *testing.T) {
 	r, err := newRawRowReader(tx, nil, table, period{}, "", &ScanSpecs{Index: table.primaryIndex})
 	require.NoError(t, err)
 
-	_, err = newJointRowReader(r, []*JoinSpec{{joinType: LeftJoin}})
+	_, err = newJointRowReader(context.Background(), r, []*JoinSpec{{joinType: LeftJoin}})
 	require.Equal(t, ErrUnsupportedJoinType, err)
 
-	_, err = newJointRowReader(r, []*JoinSpec{{joinType: InnerJoin, ds: &SelectStmt{}}})
+	_, err = newJointRowReader(context.Background(), r, []*JoinSpec{{joinType: InnerJoin, ds: &SelectStmt{}}})
 	require.NoError(t, err)
 
-	jr, err := newJointRowReader(r, []*JoinSpec{{joinType: InnerJoin, ds: &tableRef{table: "table1", as: "table2"}}})
+	jr, err := newJointRowReader(context.Background(), r, []*JoinSpec{{joinType: InnerJoin, ds: &tableRef{table: "table1", as: "table2"}}})
 	require.NoError(t, err)
 
 	orderBy := jr.OrderBy()
@@ -90,7 +90,7 @@ func TestJointRowReader(t *testing.T) {
 	t.Run("corner cases", func(t *testing.T) {
 
 		t.Run("detect ambiguous selectors", func(t *testing.T) {
-			jr, err = newJointRowReader(r, []*JoinSpec{{joinType: InnerJoin, ds: &tableRef{table: "table1"}}})
+			jr, err = newJointRowReader(context.Background(), r, []*JoinSpec{{joinType: InnerJoin, ds: &tableRef{table: "table1"}}})
 			require.NoError(t, err)
 
 			_, err = jr.colsBySelector(context.Background())
@@ -138,7 +138,7 @@ func TestJointRowReader(t *testing.T) {
 		t.Run("must propagate error from joined reader on colsBySelector", func(t *testing.T) {
 			injectedErr := errors.New("err")
 
-			jr, err := newJointRowReader(r,
+			jr, err := newJointRowReader(context.Background(), r,
 				[]*JoinSpec{{joinType: InnerJoin, ds: &dummyDataSource{
 					ResolveFunc: func(ctx context.Context, tx *SQLTx, params map[string]interface{}, ScanSpecs *ScanSpecs) (RowReader, error) {
 						return nil, injectedErr
@@ -153,7 +153,7 @@ func TestJointRowReader(t *testing.T) {
 
 		t.Run("must propagate error from joined reader on colsBySelector from Resolve", func(t *testing.T) {
 
-			jr, err := newJointRowReader(r,
+			jr, err := newJointRowReader(context.Background(), r,
 				[]*JoinSpec{{joinType: InnerJoin, ds: &dummyDataSource{
 					ResolveFunc: func(ctx context.Context, tx *SQLTx, params map[string]interface{}, ScanSpecs *ScanSpecs) (RowReader, error) {
 						return &dummyRowReader{}, nil
@@ -20,15 +20,19 @@ import "context"
 
 type limitRowReader struct {
 	rowReader RowReader
+	limitExp  ValueExp
 
-	limit int
-	read  int
+	// limit is resolved on first read, as parameters may be provided after initialization.
+	// Zero means no limit
+	limit    int
+	resolved bool
+	read     int
 }
 
-func newLimitRowReader(rowReader RowReader, limit int) *limitRowReader {
+func newLimitRowReader(rowReader RowReader, limit ValueExp) *limitRowReader {
 	return &limitRowReader{
 		rowReader: rowReader,
-		limit:     limit,
+		limitExp:  limit,
 	}
 }
 
@@ -73,11 +77,26 @@ func (lr *limitRowReader) colsBySelector(ctx context.Context) (map[string]ColDes
 }
 
 func (lr *limitRowReader) InferParameters(ctx context.Context, params map[string]SQLValueType) error {
-	return lr.rowReader.InferParameters(ctx, params)
+	err := lr.rowReader.InferParameters(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	return lr.limitExp.requiresType(IntegerType, make(map[string]ColDescriptor), params, lr.Database(), lr.TableAlias())
 }
 
 func (lr *limitRowReader) Read(ctx context.Context) (*Row, error) {
-	if lr.read >= lr.limit {
+	if !lr.resolved {
+		limit, err := resolveBound(lr.Tx(), lr.Parameters(), lr.limitExp, lr.Database(), "LIMIT")
+		if err != nil {
+			return nil, err
+		}
+
+		lr.limit = limit
+		lr.resolved = true
+	}
+
+	if lr.limit > 0 && lr.read >= lr.limit {
 		return nil, ErrNoMoreRows
 	}
 
@@ -26,7 +26,7 @@ import (
 func TestLimitRowReader(t *testing.T) {
 	dummyr := &dummyRowReader{failReturningColumns: false}
 
-	rowReader := newLimitRowReader(dummyr, 1)
+	rowReader := newLimitRowReader(dummyr, &Number{val: 1})
 
 	require.Equal(t, dummyr.Database(), rowReader.Database())
 	require.Equal(t, dummyr.TableAlias(), rowReader.TableAlias())
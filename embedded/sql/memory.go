@@ -0,0 +1,113 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+)
+
+// rowDigestMemSize is the memory estimated to be held by each row remembered by its digest,
+// as done to remove duplicated rows and to compute set operations
+const rowDigestMemSize = sha256.Size + 16
+
+type memoryAccountKey struct{}
+
+// memoryAccount keeps track of the memory held by the operators of a query,
+// the query is aborted with ErrMemoryLimitExceeded as soon as its budget is exceeded.
+// A nil account does not impose any limit
+type memoryAccount struct {
+	limit int64
+	used  int64
+
+	mutex sync.Mutex
+}
+
+func newMemoryAccount(limit int64) *memoryAccount {
+	if limit <= 0 {
+		return nil
+	}
+
+	return &memoryAccount{limit: limit}
+}
+
+func (m *memoryAccount) reserve(n int64) error {
+	if m == nil {
+		return nil
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.used+n > m.limit {
+		return fmt.Errorf("%w: %d bytes are required but only %d bytes out of %d are available", ErrMemoryLimitExceeded, n, m.limit-m.used, m.limit)
+	}
+
+	m.used += n
+
+	return nil
+}
+
+func (m *memoryAccount) release(n int64) {
+	if m == nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.used -= n
+}
+
+// withMemoryAccount returns a context carrying the memory account of the query being resolved,
+// the account is shared with its subqueries
+func withMemoryAccount(ctx context.Context, m *memoryAccount) context.Context {
+	if m == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, memoryAccountKey{}, m)
+}
+
+func memoryAccountFrom(ctx context.Context) *memoryAccount {
+	m, _ := ctx.Value(memoryAccountKey{}).(*memoryAccount)
+	return m
+}
+
+// rowMemSize estimates the memory held by a row kept in memory
+func rowMemSize(row *Row) int64 {
+	size := int64(8 * len(row.ValuesByPosition))
+
+	for sel, v := range row.ValuesBySelector {
+		size += int64(len(sel)) + valueMemSize(v)
+	}
+
+	return size
+}
+
+func valueMemSize(v TypedValue) int64 {
+	switch val := v.Value().(type) {
+	case string:
+		return 32 + int64(len(val))
+	case []byte:
+		return 40 + int64(len(val))
+	}
+
+	return 32
+}
@@ -20,15 +20,18 @@ import "context"
 
 type offsetRowReader struct {
 	rowReader RowReader
+	offsetExp ValueExp
 
-	offset  int
-	skipped int
+	// offset is resolved on first read, as parameters may be provided after initialization
+	offset   int
+	resolved bool
+	skipped  int
 }
 
-func newOffsetRowReader(rowReader RowReader, offset int) *offsetRowReader {
+func newOffsetRowReader(rowReader RowReader, offset ValueExp) *offsetRowReader {
 	return &offsetRowReader{
 		rowReader: rowReader,
-		offset:    offset,
+		offsetExp: offset,
 	}
 }
 
@@ -73,10 +76,25 @@ func (r *offsetRowReader) colsBySelector(ctx context.Context) (map[string]ColDes
 }
 
 func (r *offsetRowReader) InferParameters(ctx context.Context, params map[string]SQLValueType) error {
-	return r.rowReader.InferParameters(ctx, params)
+	err := r.rowReader.InferParameters(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	return r.offsetExp.requiresType(IntegerType, make(map[string]ColDescriptor), params, r.Database(), r.TableAlias())
 }
 
 func (r *offsetRowReader) Read(ctx context.Context) (*Row, error) {
+	if !r.resolved {
+		offset, err := resolveBound(r.Tx(), r.Parameters(), r.offsetExp, r.Database(), "OFFSET")
+		if err != nil {
+			return nil, err
+		}
+
+		r.offset = offset
+		r.resolved = true
+	}
+
 	for {
 		row, err := r.rowReader.Read(ctx)
 		if err != nil {
@@ -26,7 +26,7 @@ import (
 func TestOffsetRowReader(t *testing.T) {
 	dummyr := &dummyRowReader{failReturningColumns: false}
 
-	rowReader := newOffsetRowReader(dummyr, 1)
+	rowReader := newOffsetRowReader(dummyr, &Number{val: 1})
 
 	require.Equal(t, dummyr.Database(), rowReader.Database())
 	require.Equal(t, dummyr.TableAlias(), rowReader.TableAlias())
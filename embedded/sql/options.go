@@ -28,6 +28,17 @@ type Options struct {
 	prefix        []byte
 	distinctLimit int
 	autocommit    bool
+
+	// guards against overly complex queries, zero means no limit
+	maxJoinedSources int
+	maxJoinRows      int64
+
+	queryMemoryLimit int64
+
+	rejectRedundantIndexes bool
+
+	// functions are registered in the engine in addition to the builtin ones, see Engine.RegisterFunction
+	functions map[string]*Function
 }
 
 func DefaultOptions() *Options {
@@ -45,6 +56,25 @@ func (opts *Options) Validate() error {
 		return fmt.Errorf("%w: invalid DistinctLimit value", store.ErrInvalidOptions)
 	}
 
+	if opts.maxJoinedSources < 0 {
+		return fmt.Errorf("%w: invalid MaxJoinedSources value", store.ErrInvalidOptions)
+	}
+
+	if opts.maxJoinRows < 0 {
+		return fmt.Errorf("%w: invalid MaxJoinRows value", store.ErrInvalidOptions)
+	}
+
+	if opts.queryMemoryLimit < 0 {
+		return fmt.Errorf("%w: invalid QueryMemoryLimit value", store.ErrInvalidOptions)
+	}
+
+	for name, fn := range opts.functions {
+		err := validateFunction(name, fn)
+		if err != nil {
+			return fmt.Errorf("%w: invalid function %s: %v", store.ErrInvalidOptions, name, err)
+		}
+	}
+
 	return nil
 }
 
@@ -62,3 +92,38 @@ func (opts *Options) WithAutocommit(autocommit bool) *Options {
 	opts.autocommit = autocommit
 	return opts
 }
+
+// WithMaxJoinedSources sets the maximum number of data sources a query may read, including
+// the ones joined in its subqueries. Zero means no limit
+func (opts *Options) WithMaxJoinedSources(maxJoinedSources int) *Options {
+	opts.maxJoinedSources = maxJoinedSources
+	return opts
+}
+
+// WithMaxJoinRows sets the maximum number of intermediate rows the joins of a query are
+// estimated to produce. Zero means no limit
+func (opts *Options) WithMaxJoinRows(maxJoinRows int64) *Options {
+	opts.maxJoinRows = maxJoinRows
+	return opts
+}
+
+// WithQueryMemoryLimit sets the maximum number of bytes a query may hold in memory to remove duplicated rows
+// and to compute set operations, the query is aborted with ErrMemoryLimitExceeded when exceeded. Zero means no limit
+func (opts *Options) WithQueryMemoryLimit(queryMemoryLimit int64) *Options {
+	opts.queryMemoryLimit = queryMemoryLimit
+	return opts
+}
+
+// WithRejectRedundantIndexes sets whether the creation of an index already covered by another index of the table
+// fails with ErrRedundantIndex. When disabled, the index is created and the transaction reports it in its warnings
+func (opts *Options) WithRejectRedundantIndexes(rejectRedundantIndexes bool) *Options {
+	opts.rejectRedundantIndexes = rejectRedundantIndexes
+	return opts
+}
+
+// WithFunctions sets the functions callable by name from the statements run by the engine,
+// in addition to the builtin ones. Function names are case insensitive
+func (opts *Options) WithFunctions(functions map[string]*Function) *Options {
+	opts.functions = functions
+	return opts
+}
@@ -42,5 +42,26 @@ func TestOptions(t *testing.T) {
 	opts.WithAutocommit(true)
 	require.True(t, opts.autocommit)
 
+	opts.WithMaxJoinedSources(-1)
+	require.Error(t, opts.Validate())
+
+	opts.WithMaxJoinedSources(4)
+	require.Equal(t, 4, opts.maxJoinedSources)
+
+	opts.WithMaxJoinRows(-1)
+	require.Error(t, opts.Validate())
+
+	opts.WithMaxJoinRows(1000)
+	require.Equal(t, int64(1000), opts.maxJoinRows)
+
+	opts.WithQueryMemoryLimit(-1)
+	require.Error(t, opts.Validate())
+
+	opts.WithQueryMemoryLimit(1 << 20)
+	require.Equal(t, int64(1<<20), opts.queryMemoryLimit)
+
+	opts.WithRejectRedundantIndexes(true)
+	require.True(t, opts.rejectRedundantIndexes)
+
 	require.NoError(t, opts.Validate())
 }
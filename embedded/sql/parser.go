@@ -29,65 +29,106 @@ import (
 //go:generate go run golang.org/x/tools/cmd/goyacc -l -o sql_parser.go sql_grammar.y
 
 var reservedWords = map[string]int{
-	"CREATE":         CREATE,
-	"USE":            USE,
-	"DATABASE":       DATABASE,
-	"SNAPSHOT":       SNAPSHOT,
-	"SINCE":          SINCE,
-	"AFTER":          AFTER,
-	"BEFORE":         BEFORE,
-	"UNTIL":          UNTIL,
-	"TABLE":          TABLE,
-	"PRIMARY":        PRIMARY,
-	"KEY":            KEY,
-	"UNIQUE":         UNIQUE,
-	"INDEX":          INDEX,
-	"ON":             ON,
-	"ALTER":          ALTER,
-	"ADD":            ADD,
-	"RENAME":         RENAME,
-	"TO":             TO,
-	"COLUMN":         COLUMN,
-	"INSERT":         INSERT,
-	"CONFLICT":       CONFLICT,
-	"DO":             DO,
-	"NOTHING":        NOTHING,
-	"UPSERT":         UPSERT,
-	"INTO":           INTO,
-	"VALUES":         VALUES,
-	"UPDATE":         UPDATE,
-	"SET":            SET,
-	"DELETE":         DELETE,
-	"BEGIN":          BEGIN,
-	"TRANSACTION":    TRANSACTION,
-	"COMMIT":         COMMIT,
-	"ROLLBACK":       ROLLBACK,
-	"SELECT":         SELECT,
-	"DISTINCT":       DISTINCT,
-	"FROM":           FROM,
-	"UNION":          UNION,
-	"ALL":            ALL,
-	"TX":             TX,
-	"JOIN":           JOIN,
-	"HAVING":         HAVING,
-	"WHERE":          WHERE,
-	"GROUP":          GROUP,
-	"BY":             BY,
-	"LIMIT":          LIMIT,
-	"OFFSET":         OFFSET,
-	"ORDER":          ORDER,
-	"AS":             AS,
-	"ASC":            ASC,
-	"DESC":           DESC,
-	"NOT":            NOT,
-	"LIKE":           LIKE,
-	"EXISTS":         EXISTS,
-	"IN":             IN,
-	"AUTO_INCREMENT": AUTO_INCREMENT,
-	"NULL":           NULL,
-	"IF":             IF,
-	"IS":             IS,
-	"CAST":           CAST,
+	"CREATE":            CREATE,
+	"USE":               USE,
+	"DATABASE":          DATABASE,
+	"SNAPSHOT":          SNAPSHOT,
+	"SINCE":             SINCE,
+	"AFTER":             AFTER,
+	"BEFORE":            BEFORE,
+	"UNTIL":             UNTIL,
+	"TABLE":             TABLE,
+	"VIEW":              VIEW,
+	"PRIMARY":           PRIMARY,
+	"KEY":               KEY,
+	"UNIQUE":            UNIQUE,
+	"DEFERRABLE":        DEFERRABLE,
+	"INITIALLY":         INITIALLY,
+	"DEFERRED":          DEFERRED,
+	"IMMEDIATE":         IMMEDIATE,
+	"INDEX":             INDEX,
+	"ON":                ON,
+	"ALTER":             ALTER,
+	"ADD":               ADD,
+	"RENAME":            RENAME,
+	"TO":                TO,
+	"COLUMN":            COLUMN,
+	"INSERT":            INSERT,
+	"CONFLICT":          CONFLICT,
+	"DO":                DO,
+	"NOTHING":           NOTHING,
+	"UPSERT":            UPSERT,
+	"INTO":              INTO,
+	"VALUES":            VALUES,
+	"UPDATE":            UPDATE,
+	"SET":               SET,
+	"DELETE":            DELETE,
+	"BEGIN":             BEGIN,
+	"TRANSACTION":       TRANSACTION,
+	"COMMIT":            COMMIT,
+	"ROLLBACK":          ROLLBACK,
+	"SELECT":            SELECT,
+	"DISTINCT":          DISTINCT,
+	"FROM":              FROM,
+	"UNION":             UNION,
+	"WITH":              WITH,
+	"EXCEPT":            EXCEPT,
+	"INTERSECT":         INTERSECT,
+	"ALL":               ALL,
+	"TABLESAMPLE":       TABLESAMPLE,
+	"BERNOULLI":         BERNOULLI,
+	"PERCENT":           PERCENT,
+	"REPEATABLE":        REPEATABLE,
+	"SORT":              SORT,
+	"HISTORY":           HISTORY,
+	"TX":                TX,
+	"JOIN":              JOIN,
+	"LATERAL":           LATERAL,
+	"HAVING":            HAVING,
+	"WHERE":             WHERE,
+	"GROUP":             GROUP,
+	"BY":                BY,
+	"LIMIT":             LIMIT,
+	"OFFSET":            OFFSET,
+	"ORDER":             ORDER,
+	"AS":                AS,
+	"ASC":               ASC,
+	"DESC":              DESC,
+	"NOT":               NOT,
+	"LIKE":              LIKE,
+	"EXISTS":            EXISTS,
+	"IN":                IN,
+	"AUTO_INCREMENT":    AUTO_INCREMENT,
+	"NULL":              NULL,
+	"IF":                IF,
+	"IS":                IS,
+	"CAST":              CAST,
+	"DEFAULT":           DEFAULT,
+	"CURRENT_DATE":      CURRENT_DATE,
+	"CURRENT_TIME":      CURRENT_TIME,
+	"CURRENT_TIMESTAMP": CURRENT_TIMESTAMP,
+	"ROLLUP":            ROLLUP,
+	"CUBE":              CUBE,
+	"GROUPING":          GROUPING,
+	"SETS":              SETS,
+	"EXTRACT":           EXTRACT,
+	"TRIM":              TRIM,
+	"POSITION":          POSITION,
+	"LEADING":           LEADING,
+	"TRAILING":          TRAILING,
+	"BOTH":              BOTH,
+	"CASE":              CASE,
+	"COLLATE":           COLLATE,
+	"NULLS":             NULLS,
+	"REGEXP":            REGEXP,
+	"IREGEXP":           IREGEXP,
+	"MATCH":             MATCH,
+	"WHEN":              WHEN,
+	"THEN":              THEN,
+	"ELSE":              ELSE,
+	"END":               END,
+	"ARRAY":             ARRAY,
+	"ANY":               ANY,
 }
 
 var joinTypes = map[string]JoinType{
@@ -110,6 +151,9 @@ var aggregateFns = map[string]AggregateFn{
 	"MAX":   MAX,
 	"MIN":   MIN,
 	"AVG":   AVG,
+
+	"BOOL_AND": BOOL_AND,
+	"BOOL_OR":  BOOL_OR,
 }
 
 var boolValues = map[string]bool{
@@ -150,6 +194,9 @@ type lexer struct {
 	namedParamsType positionalParamType
 	paramsCount     int
 	result          []SQLStmt
+
+	// separators holds the position of each statement separator
+	separators []int
 }
 
 type aheadByteReader struct {
@@ -157,6 +204,9 @@ type aheadByteReader struct {
 	nextErr   error
 	r         io.ByteReader
 	readCount int
+
+	// text holds the bytes read so far, so the text of a statement can be kept as written
+	text []byte
 }
 
 func newAheadByteReader(r io.ByteReader) *aheadByteReader {
@@ -174,6 +224,10 @@ func (ar *aheadByteReader) ReadByte() (byte, error) {
 
 	ar.readCount++
 
+	if ar.nextErr == nil {
+		ar.text = append(ar.text, ar.nextChar)
+	}
+
 	return ar.nextChar, ar.nextErr
 }
 
@@ -194,9 +248,34 @@ func Parse(r io.ByteReader) ([]SQLStmt, error) {
 
 	yyParse(lexer)
 
+	if lexer.err == nil {
+		lexer.setViewTexts()
+	}
+
 	return lexer.result, lexer.err
 }
 
+// setViewTexts keeps the text of the query of each created view, as written from the start of the query up to the end of the statement
+func (l *lexer) setViewTexts() {
+	for _, stmt := range l.result {
+		view, ok := stmt.(*CreateViewStmt)
+		if !ok {
+			continue
+		}
+
+		end := len(l.r.text)
+
+		for _, sep := range l.separators {
+			if sep > view.textStart {
+				end = sep
+				break
+			}
+		}
+
+		view.text = strings.TrimSpace(string(l.r.text[view.textStart:end]))
+	}
+}
+
 func newLexer(r io.ByteReader) *lexer {
 	return &lexer{
 		r:   newAheadByteReader(r),
@@ -256,7 +335,10 @@ func (l *lexer) Lex(lval *yySymType) int {
 		}
 	}
 
+	lval.pos = l.r.ReadCount() - 1
+
 	if isSeparator(ch) {
+		l.separators = append(l.separators, lval.pos)
 		return STMT_SEPARATOR
 	}
 
@@ -364,6 +446,21 @@ func (l *lexer) Lex(lval *yySymType) int {
 		return NUMBER
 	}
 
+	if ch == '|' && l.r.nextChar == '|' {
+		l.r.ReadByte() // consume second pipe
+		return CONCAT
+	}
+
+	if ch == '<' && l.r.nextChar == '<' {
+		l.r.ReadByte() // consume second '<'
+		return SHL
+	}
+
+	if ch == '>' && l.r.nextChar == '>' {
+		l.r.ReadByte() // consume second '>'
+		return SHR
+	}
+
 	if isComparison(ch) {
 		tail, err := l.readComparison()
 		if err != nil {
@@ -394,6 +491,11 @@ func (l *lexer) Lex(lval *yySymType) int {
 		return VARCHAR
 	}
 
+	if ch == '@' && l.r.nextChar == '>' {
+		l.r.ReadByte() // consume '>'
+		return CONTAINS
+	}
+
 	if ch == '@' {
 		if l.namedParamsType == UnnamedParamType {
 			lval.err = ErrEitherNamedOrUnnamedParams
@@ -48,7 +48,7 @@ func TestCreateDatabaseStmt(t *testing.T) {
 		{
 			input:          "CREATE db1",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected IDENTIFIER, expecting DATABASE or TABLE or UNIQUE or INDEX at position 10"),
+			expectedError:  errors.New("syntax error: unexpected IDENTIFIER at position 10"),
 		},
 	}
 
@@ -226,6 +226,20 @@ func TestCreateTableStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "CREATE TABLE table1 (id INTEGER, ts TIMESTAMP, PRIMARY KEY id) SORT BY ts DESC",
+			expectedOutput: []SQLStmt{
+				&CreateTableStmt{
+					table: "table1",
+					colsSpec: []*ColSpec{
+						{colName: "id", colType: IntegerType},
+						{colName: "ts", colType: TimestampType},
+					},
+					pkColNames: []string{"id"},
+					sortKey:    &OrdCol{sel: &ColSelector{col: "ts"}, descOrder: true},
+				}},
+			expectedError: nil,
+		},
 		{
 			input: "CREATE TABLE xtable1 (xid INTEGER, PRIMARY KEY xid)",
 			expectedOutput: []SQLStmt{
@@ -265,10 +279,24 @@ func TestCreateTableStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "CREATE TABLE posts (id INTEGER, tags VARCHAR[] NOT NULL, scores INTEGER[], PRIMARY KEY id)",
+			expectedOutput: []SQLStmt{
+				&CreateTableStmt{
+					table: "posts",
+					colsSpec: []*ColSpec{
+						{colName: "id", colType: IntegerType},
+						{colName: "tags", colType: ArrayTypeOf(VarcharType), notNull: true},
+						{colName: "scores", colType: ArrayTypeOf(IntegerType)},
+					},
+					pkColNames: []string{"id"},
+				}},
+			expectedError: nil,
+		},
 		{
 			input:          "CREATE table1",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected IDENTIFIER, expecting DATABASE or TABLE or UNIQUE or INDEX at position 13"),
+			expectedError:  errors.New("syntax error: unexpected IDENTIFIER at position 13"),
 		},
 		{
 			input:          "CREATE TABLE table1",
@@ -328,6 +356,15 @@ func TestCreateIndexStmt(t *testing.T) {
 			expectedOutput: []SQLStmt{&CreateIndexStmt{unique: true, table: "table1", cols: []string{"id", "title"}}},
 			expectedError:  nil,
 		},
+		{
+			input: "CREATE INDEX ON table1(LOWER(title), id)",
+			expectedOutput: []SQLStmt{&CreateIndexStmt{
+				table: "table1",
+				cols:  []string{"", "id"},
+				exprs: []ValueExp{&FnCall{fn: "lower", params: []ValueExp{&ColSelector{col: "title"}}}, nil},
+			}},
+			expectedError: nil,
+		},
 	}
 
 	for i, tc := range testCases {
@@ -471,6 +508,21 @@ func TestInsertIntoStmt(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			input: "INSERT INTO table1(id, title) VALUES (DEFAULT, 'untitled row'), (DEFAULT, DEFAULT)",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					isInsert: true,
+					tableRef: &tableRef{table: "table1"},
+					cols:     []string{"id", "title"},
+					rows: []*RowSpec{
+						{Values: []ValueExp{&DefaultValue{}, &Varchar{val: "untitled row"}}},
+						{Values: []ValueExp{&DefaultValue{}, &DefaultValue{}}},
+					},
+				},
+			},
+			expectedError: nil,
+		},
 		{
 			input: "UPSERT INTO table1(id, time, title, active, compressed, payload, note) VALUES (2, now(), 'untitled row', TRUE, ?, x'AED0393F', ?)",
 			expectedOutput: []SQLStmt{
@@ -749,6 +801,24 @@ func TestTxStmt(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			input: "UPDATE table1 t SET t.label = s.label FROM source s WHERE t.id = s.id",
+			expectedOutput: []SQLStmt{
+				&UpdateStmt{
+					tableRef: &tableRef{table: "table1", as: "t"},
+					updates: []*colUpdate{
+						{table: "t", col: "label", op: EQ, val: &ColSelector{table: "s", col: "label"}},
+					},
+					from: &tableRef{table: "source", as: "s"},
+					where: &CmpBoolExp{
+						op:    EQ,
+						left:  &ColSelector{table: "t", col: "id"},
+						right: &ColSelector{table: "s", col: "id"},
+					},
+				},
+			},
+			expectedError: nil,
+		},
 		{
 			input: "BEGIN TRANSACTION; CREATE TABLE table1 (id INTEGER, label VARCHAR NOT NULL, PRIMARY KEY id); UPSERT INTO table1 (id, label) VALUES (100, 'label1'); COMMIT;",
 			expectedOutput: []SQLStmt{
@@ -950,6 +1020,38 @@ func TestSelectStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT id, title FROM table1 ORDER BY title COLLATE nocase DESC",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+						&ColSelector{col: "title"},
+					},
+					ds: &tableRef{table: "table1"},
+					orderBy: []*OrdCol{
+						{sel: &ColSelector{col: "title"}, collation: NoCaseCollation, descOrder: true},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id, title FROM table1 ORDER BY title DESC NULLS first",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+						&ColSelector{col: "title"},
+					},
+					ds: &tableRef{table: "table1"},
+					orderBy: []*OrdCol{
+						{sel: &ColSelector{col: "title"}, descOrder: true, nulls: NullsFirst},
+					},
+				}},
+			expectedError: nil,
+		},
 		{
 			input: "SELECT id, name, table2.status FROM table1 INNER JOIN table2 ON table1.id = table2.id WHERE name = 'John' ORDER BY name DESC",
 			expectedOutput: []SQLStmt{
@@ -989,6 +1091,39 @@ func TestSelectStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT t1.id, t2.id FROM table1 AS t1 INNER JOIN LATERAL (SELECT id FROM table2 WHERE fkid = t1.id LIMIT 1) AS t2 ON TRUE",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{table: "t1", col: "id"},
+						&ColSelector{table: "t2", col: "id"},
+					},
+					ds: &tableRef{table: "table1", as: "t1"},
+					joins: []*JoinSpec{
+						{
+							joinType: InnerJoin,
+							ds: &SelectStmt{
+								selectors: []Selector{
+									&ColSelector{col: "id"},
+								},
+								ds: &tableRef{table: "table2"},
+								where: &CmpBoolExp{
+									op:    EQ,
+									left:  &ColSelector{col: "fkid"},
+									right: &ColSelector{table: "t1", col: "id"},
+								},
+								limit: &Number{val: 1},
+								as:    "t2",
+							},
+							lateral: true,
+							cond:    &Bool{val: true},
+						},
+					},
+				}},
+			expectedError: nil,
+		},
 		{
 			input: "SELECT id, name, table2.status FROM table1 JOIN table2 ON table1.id = table2.id WHERE name = 'John' ORDER BY name DESC",
 			expectedOutput: []SQLStmt{
@@ -1083,10 +1218,36 @@ func TestSelectStmt(t *testing.T) {
 							&ColSelector{col: "col2", as: "title"},
 						},
 						ds:     &tableRef{table: "table2"},
-						limit:  100,
-						offset: 1,
+						limit:  &Number{val: 100},
+						offset: &Number{val: 1},
+					},
+					limit: &Number{val: 10},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 LIMIT $1 OFFSET $2",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds:     &tableRef{table: "table1"},
+					limit:  &Param{id: "param1", pos: 1},
+					offset: &Param{id: "param2", pos: 2},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM db1.table1 AS t",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
 					},
-					limit: 10,
+					ds: &tableRef{db: "db1", table: "table1", as: "t"},
 				}},
 			expectedError: nil,
 		},
@@ -1175,6 +1336,245 @@ func TestSelectUnionStmt(t *testing.T) {
 	}
 }
 
+func TestSelectSetOpStmt(t *testing.T) {
+	selectID := func(table string) *SelectStmt {
+		return &SelectStmt{
+			selectors: []Selector{&ColSelector{col: "id"}},
+			ds:        &tableRef{table: table},
+		}
+	}
+
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: "SELECT id FROM table1 EXCEPT SELECT id FROM table2",
+			expectedOutput: []SQLStmt{
+				&SetOpStmt{
+					op:       ExceptSetOp,
+					distinct: true,
+					left:     selectID("table1"),
+					right:    selectID("table2"),
+				},
+			},
+		},
+		{
+			input: "SELECT id FROM table1 INTERSECT ALL SELECT id FROM table2",
+			expectedOutput: []SQLStmt{
+				&SetOpStmt{
+					op:    IntersectSetOp,
+					left:  selectID("table1"),
+					right: selectID("table2"),
+				},
+			},
+		},
+		{
+			input: "SELECT id FROM table1 EXCEPT SELECT id FROM table2 EXCEPT SELECT id FROM table3",
+			expectedOutput: []SQLStmt{
+				&SetOpStmt{
+					op:       ExceptSetOp,
+					distinct: true,
+					left: &SetOpStmt{
+						op:       ExceptSetOp,
+						distinct: true,
+						left:     selectID("table1"),
+						right:    selectID("table2"),
+					},
+					right: selectID("table3"),
+				},
+			},
+		},
+		{
+			input: "SELECT id FROM table1 UNION SELECT id FROM table2 INTERSECT SELECT id FROM table3",
+			expectedOutput: []SQLStmt{
+				&UnionStmt{
+					distinct: true,
+					left:     selectID("table1"),
+					right: &SetOpStmt{
+						op:       IntersectSetOp,
+						distinct: true,
+						left:     selectID("table2"),
+						right:    selectID("table3"),
+					},
+				},
+			},
+		},
+		{
+			input: "SELECT id FROM (SELECT id FROM table1 EXCEPT ALL SELECT id FROM table2) AS t",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds: &SetOpStmt{
+						op:    ExceptSetOp,
+						left:  selectID("table1"),
+						right: selectID("table2"),
+						as:    "t",
+					},
+				},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestSelectTableSampleStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: "SELECT id FROM table1 TABLESAMPLE (5 PERCENT)",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds: &tableRef{
+						table:  "table1",
+						sample: &tableSample{percentage: &Number{val: 5}},
+					},
+				},
+			},
+		},
+		{
+			input: "SELECT id FROM table1 AS t TABLESAMPLE BERNOULLI (@p) REPEATABLE (42)",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds: &tableRef{
+						table: "table1",
+						as:    "t",
+						sample: &tableSample{
+							percentage: &Param{id: "p"},
+							seed:       &Number{val: 42},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "SELECT id FROM table1 HISTORY AS h TABLESAMPLE (5)",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds: &tableRef{
+						table:   "table1",
+						history: true,
+						as:      "h",
+						sample:  &tableSample{percentage: &Number{val: 5}},
+					},
+				},
+			},
+		},
+		{
+			input:         "SELECT id FROM table1 TABLESAMPLE 5 PERCENT",
+			expectedError: errors.New("syntax error: unexpected NUMBER, expecting '(' at position 35"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestSelectWithCTEStmt(t *testing.T) {
+	recent := &SelectStmt{
+		selectors: []Selector{&ColSelector{col: "id"}},
+		ds:        &tableRef{table: "table1"},
+		where:     &CmpBoolExp{op: GT, left: &ColSelector{col: "id"}, right: &Number{val: 10}},
+	}
+
+	aliased := func(stmt *SelectStmt, as string) *SelectStmt {
+		c := *stmt
+		c.as = as
+		return &c
+	}
+
+	top := &SelectStmt{
+		selectors: []Selector{&ColSelector{col: "id"}},
+		ds:        aliased(recent, "recent"),
+		limit:     &Number{val: 1},
+	}
+
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: "WITH recent AS (SELECT id FROM table1 WHERE id > 10) SELECT id FROM recent",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					ctes:      []*CTE{{name: "recent", ds: recent}},
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds:        aliased(recent, "recent"),
+				},
+			},
+		},
+		{
+			input: "WITH recent AS (SELECT id FROM table1 WHERE id > 10), top AS (SELECT id FROM recent LIMIT 1) SELECT r.id FROM recent AS r INNER JOIN top ON r.id = top.id",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					ctes: []*CTE{
+						{name: "recent", ds: recent},
+						{name: "top", ds: top},
+					},
+					selectors: []Selector{&ColSelector{table: "r", col: "id"}},
+					ds:        aliased(recent, "r"),
+					joins: []*JoinSpec{
+						{
+							joinType: InnerJoin,
+							ds:       aliased(top, "top"),
+							cond: &CmpBoolExp{
+								op:    EQ,
+								left:  &ColSelector{table: "r", col: "id"},
+								right: &ColSelector{table: "top", col: "id"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "WITH table1 AS (SELECT id FROM table1) SELECT id FROM db1.table1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					ctes:      []*CTE{{name: "table1", ds: &SelectStmt{selectors: []Selector{&ColSelector{col: "id"}}, ds: &tableRef{table: "table1"}}}},
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds:        &tableRef{db: "db1", table: "table1"},
+				},
+			},
+		},
+		{
+			input:         "WITH recent (SELECT id FROM table1) SELECT id FROM recent",
+			expectedError: errors.New("syntax error: unexpected '(', expecting AS at position 13"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
 func TestAggFnStmt(t *testing.T) {
 	testCases := []struct {
 		input          string
@@ -1214,6 +1614,134 @@ func TestAggFnStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT t.country AS c, SUM(amount) FROM table1 t GROUP BY 1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{table: "t", col: "country", as: "c"},
+						&AggColSelector{aggFn: SUM, col: "amount"},
+					},
+					ds: &tableRef{table: "table1", as: "t"},
+					groupBy: []*ColSelector{
+						{table: "t", col: "country"},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT country, SUM(amount) FROM table1 GROUP BY 2, 3",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "country"},
+						&AggColSelector{aggFn: SUM, col: "amount"},
+					},
+					ds: &tableRef{table: "table1"},
+					groupBy: []*ColSelector{
+						{ordinal: 2},
+						{ordinal: 3},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT country, city, GROUPING(city), SUM(amount) FROM table1 GROUP BY CUBE(country, 2)",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "country"},
+						&ColSelector{col: "city"},
+						&ExpSelector{exp: &GroupingExp{col: &ColSelector{col: "city"}}},
+						&AggColSelector{aggFn: SUM, col: "amount"},
+					},
+					ds: &tableRef{table: "table1"},
+					groupBy: []*ColSelector{
+						{col: "country"},
+						{col: "city"},
+					},
+					grouping: &groupByClause{
+						kind: cubeGroupBy,
+						cols: []*ColSelector{
+							{col: "country"},
+							{col: "city"},
+						},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT country, SUM(amount) FROM table1 GROUP BY GROUPING SETS ((country, city), country, ())",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "country"},
+						&AggColSelector{aggFn: SUM, col: "amount"},
+					},
+					ds: &tableRef{table: "table1"},
+					groupBy: []*ColSelector{
+						{col: "country"},
+						{col: "city"},
+					},
+					grouping: &groupByClause{
+						kind: groupingSetsGroupBy,
+						sets: [][]*ColSelector{
+							{{col: "country"}, {col: "city"}},
+							{{col: "country"}},
+							{},
+						},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT SUM(CASE WHEN status = 'paid' THEN amount ELSE 0 END), MAX(a + b) FROM table1 HAVING MAX(a+b) > 0",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&AggColSelector{
+							aggFn: SUM,
+							col:   "(exp0)",
+							exp: &CaseExp{
+								whens: []*whenThen{
+									{
+										cond:   &CmpBoolExp{op: EQ, left: &ColSelector{col: "status"}, right: &Varchar{val: "paid"}},
+										result: &ColSelector{col: "amount"},
+									},
+								},
+								elseExp: &Number{val: 0},
+							},
+						},
+						&AggColSelector{
+							aggFn: MAX,
+							col:   "(exp1)",
+							exp:   &NumExp{op: ADDOP, left: &ColSelector{col: "a"}, right: &ColSelector{col: "b"}},
+						},
+					},
+					ds: &tableRef{table: "table1"},
+					having: &CmpBoolExp{
+						op: GT,
+						left: &AggColSelector{
+							aggFn: MAX,
+							col:   "(exp1)",
+							exp:   &NumExp{op: ADDOP, left: &ColSelector{col: "a"}, right: &ColSelector{col: "b"}},
+						},
+						right: &Number{val: 0},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT AVG((price)) FROM table1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&AggColSelector{aggFn: AVG, col: "price"},
+					},
+					ds: &tableRef{table: "table1"},
+				}},
+			expectedError: nil,
+		},
 	}
 
 	for i, tc := range testCases {
@@ -1232,6 +1760,80 @@ func TestExpressions(t *testing.T) {
 		expectedOutput []SQLStmt
 		expectedError  error
 	}{
+		{
+			input: "SELECT id FROM table1 WHERE title || '-' || id = @title",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "table1"},
+					where: &CmpBoolExp{
+						op: EQ,
+						left: &ConcatExp{
+							left: &ConcatExp{
+								left:  &ColSelector{col: "title"},
+								right: &Varchar{val: "-"},
+							},
+							right: &ColSelector{col: "id"},
+						},
+						right: &Param{id: "title"},
+					},
+				}},
+		},
+		{
+			input: "SELECT id FROM table1 WHERE flags & 4 != 0",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "table1"},
+					where: &CmpBoolExp{
+						op: NE,
+						left: &NumExp{
+							op:    BITANDOP,
+							left:  &ColSelector{col: "flags"},
+							right: &Number{val: 4},
+						},
+						right: &Number{val: 0},
+					},
+				}},
+		},
+		{
+			input: "SELECT ~flags | 1 << 2 ^ flags >> 1 FROM table1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ExpSelector{exp: &NumExp{
+							op: BITOROP,
+							left: &NumExp{
+								op:   BITXOROP,
+								left: &ColSelector{col: "flags"},
+								right: &NumExp{
+									op:    SUBSOP,
+									left:  &Number{val: 0},
+									right: &Number{val: 1},
+								},
+							},
+							right: &NumExp{
+								op: BITXOROP,
+								left: &NumExp{
+									op:    SHLOP,
+									left:  &Number{val: 1},
+									right: &Number{val: 2},
+								},
+								right: &NumExp{
+									op:    SHROP,
+									left:  &ColSelector{col: "flags"},
+									right: &Number{val: 1},
+								},
+							},
+						}},
+					},
+					ds: &tableRef{table: "table1"},
+				}},
+		},
 		{
 			input: "SELECT id FROM table1 WHERE id > 0",
 			expectedOutput: []SQLStmt{
@@ -1402,6 +2004,56 @@ func TestExpressions(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT id FROM table1 WHERE title REGEXP '^[A-Z].*[0-9]$' AND name NOT MATCH @param1 AND code IREGEXP 'x+'",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "table1"},
+					where: &BinBoolExp{
+						op: AND,
+						left: &BinBoolExp{
+							op:    AND,
+							left:  newRegexpBoolExp(&ColSelector{col: "title"}, false, &Varchar{val: "^[A-Z].*[0-9]$"}, false),
+							right: newRegexpBoolExp(&ColSelector{col: "name"}, true, &Param{id: "param1"}, false),
+						},
+						right: newRegexpBoolExp(&ColSelector{col: "code"}, false, &Varchar{val: "x+"}, true),
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 WHERE (a, b) IN ((1, 'x'), (@p, 'y')) AND (a, b, c) NOT IN ((1, 2, 3))",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "table1"},
+					where: &BinBoolExp{
+						op: AND,
+						left: &TupleInListExp{
+							vals: []ValueExp{&ColSelector{col: "a"}, &ColSelector{col: "b"}},
+							tuples: [][]ValueExp{
+								{&Number{val: 1}, &Varchar{val: "x"}},
+								{&Param{id: "p"}, &Varchar{val: "y"}},
+							},
+						},
+						right: &TupleInListExp{
+							vals:  []ValueExp{&ColSelector{col: "a"}, &ColSelector{col: "b"}, &ColSelector{col: "c"}},
+							notIn: true,
+							tuples: [][]ValueExp{
+								{&Number{val: 1}, &Number{val: 2}, &Number{val: 3}},
+							},
+						},
+					},
+				}},
+			expectedError: nil,
+		},
 		{
 			input: "SELECT id FROM table1 WHERE (id > 0 AND NOT table1.id >= 10) OR table1.title LIKE 'J%O'",
 			expectedOutput: []SQLStmt{
@@ -1514,6 +2166,76 @@ func TestExpressions(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT id FROM clients WHERE deleted_at IS DISTINCT FROM created_at AND name IS NOT DISTINCT FROM @name",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "clients"},
+					where: &BinBoolExp{
+						op: AND,
+						left: &DistinctFromBoolExp{
+							left:  &ColSelector{col: "deleted_at"},
+							right: &ColSelector{col: "created_at"},
+						},
+						right: &DistinctFromBoolExp{
+							left:   &ColSelector{col: "name"},
+							right:  &Param{id: "name"},
+							negate: true,
+						},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM posts WHERE tags @> ARRAY['go', @tag] OR 'db' = ANY(tags)",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "posts"},
+					where: &BinBoolExp{
+						op: OR,
+						left: &ArrayContainsExp{
+							left:  &ColSelector{col: "tags"},
+							right: &ArrayExp{elems: []ValueExp{&Varchar{val: "go"}, &Param{id: "tag"}}},
+						},
+						right: &CmpAnyBoolExp{
+							op:  EQ,
+							val: &Varchar{val: "db"},
+							arr: &ColSelector{col: "tags"},
+						},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT CASE WHEN qty > 10 THEN 'bulk' WHEN qty > 0 THEN 'retail' END FROM table1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ExpSelector{
+							exp: &CaseExp{
+								whens: []*whenThen{
+									{
+										cond:   &CmpBoolExp{op: GT, left: &ColSelector{col: "qty"}, right: &Number{val: 10}},
+										result: &Varchar{val: "bulk"},
+									},
+									{
+										cond:   &CmpBoolExp{op: GT, left: &ColSelector{col: "qty"}, right: &Number{val: 0}},
+										result: &Varchar{val: "retail"},
+									},
+								},
+							},
+						},
+					},
+					ds: &tableRef{table: "table1"},
+				}},
+			expectedError: nil,
+		},
 	}
 
 	for i, tc := range testCases {
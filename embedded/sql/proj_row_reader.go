@@ -102,7 +102,7 @@ func (pr *projectedRowReader) Columns(ctx context.Context) ([]ColDescriptor, err
 			col = sel.alias()
 		}
 
-		if aggFn != "" {
+		if aggFn != "" || isExpSelector(sel) {
 			aggFn = ""
 			col = sel.alias()
 			if col == "" {
@@ -139,7 +139,15 @@ func (pr *projectedRowReader) colsBySelector(ctx context.Context) (map[string]Co
 		encSel := EncodeSelector(aggFn, db, table, col)
 
 		colDesc, ok := dsColDescriptors[encSel]
-		if !ok {
+
+		if isExpSelector(sel) {
+			t, err := sel.inferType(dsColDescriptors, make(map[string]SQLValueType), pr.rowReader.Database(), pr.rowReader.TableAlias())
+			if err != nil {
+				return nil, err
+			}
+
+			colDesc = ColDescriptor{Type: t}
+		} else if !ok {
 			return nil, fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, col)
 		}
 
@@ -152,7 +160,7 @@ func (pr *projectedRowReader) colsBySelector(ctx context.Context) (map[string]Co
 			col = sel.alias()
 		}
 
-		if aggFn != "" {
+		if aggFn != "" || isExpSelector(sel) {
 			aggFn = ""
 			col = sel.alias()
 			if col == "" {
@@ -175,7 +183,33 @@ func (pr *projectedRowReader) colsBySelector(ctx context.Context) (map[string]Co
 }
 
 func (pr *projectedRowReader) InferParameters(ctx context.Context, params map[string]SQLValueType) error {
-	return pr.rowReader.InferParameters(ctx, params)
+	err := pr.rowReader.InferParameters(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	cols, err := pr.rowReader.colsBySelector(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, sel := range pr.selectors {
+		if !isExpSelector(sel) {
+			continue
+		}
+
+		_, err = sel.inferType(cols, params, pr.rowReader.Database(), pr.rowReader.TableAlias())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isExpSelector(sel Selector) bool {
+	_, isExpSelector := sel.(*ExpSelector)
+	return isExpSelector
 }
 
 func (pr *projectedRowReader) Parameters() map[string]interface{} {
@@ -203,7 +237,18 @@ func (pr *projectedRowReader) Read(ctx context.Context) (*Row, error) {
 		encSel := EncodeSelector(aggFn, db, table, col)
 
 		val, ok := row.ValuesBySelector[encSel]
-		if !ok {
+
+		if isExpSelector(sel) {
+			exp, err := sel.substitute(pr.Parameters())
+			if err != nil {
+				return nil, err
+			}
+
+			val, err = exp.reduce(pr.Tx(), row, pr.rowReader.Database(), pr.rowReader.TableAlias())
+			if err != nil {
+				return nil, err
+			}
+		} else if !ok {
 			return nil, fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, col)
 		}
 
@@ -216,7 +261,7 @@ func (pr *projectedRowReader) Read(ctx context.Context) (*Row, error) {
 			col = sel.alias()
 		}
 
-		if aggFn != "" {
+		if aggFn != "" || isExpSelector(sel) {
 			aggFn = ""
 			col = sel.alias()
 			if col == "" {
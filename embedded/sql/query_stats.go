@@ -0,0 +1,161 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"time"
+)
+
+// QueryStats holds the statistics measured while executing a query, see Engine.QueryWithStats.
+// Statistics are updated as rows are read, they are complete once the row reader is closed
+type QueryStats struct {
+	// RowsScanned is the number of rows read from the indexes of all the data sources
+	RowsScanned uint64
+	// RowsReturned is the number of rows returned by the query
+	RowsReturned uint64
+	// BytesRead is the size of the index entries and row values read
+	BytesRead uint64
+
+	// SortTime is the time spent sorting rows in memory
+	SortTime time.Duration
+	// AggregationTime is the time spent grouping and aggregating rows, reading them excluded
+	AggregationTime time.Duration
+	// ExecutionTime is the time elapsed since the query was resolved until its row reader was closed
+	ExecutionTime time.Duration
+
+	// Sources holds the statistics of every table scanned by the query
+	Sources []*SourceStats
+
+	startedAt time.Time
+}
+
+// SourceStats holds the statistics of the scans of a table through a given index.
+// Tables scanned several times, e.g. when joined, are reported once per index
+type SourceStats struct {
+	Table string
+	Alias string
+	Index string
+	// FullScan is set when the index was scanned without narrowing its range, i.e. no condition
+	// constrains its leading column
+	FullScan bool
+	// Scans is the number of times the table was scanned, e.g. once per row of the preceding data source when joined
+	Scans       uint64
+	RowsScanned uint64
+	BytesRead   uint64
+}
+
+type queryStatsKey struct{}
+
+// withQueryStats returns a context in which data sources are resolved accounting their scans into stats,
+// no statistics are collected when stats is nil
+func withQueryStats(ctx context.Context, stats *QueryStats) context.Context {
+	return context.WithValue(ctx, queryStatsKey{}, stats)
+}
+
+func queryStatsFrom(ctx context.Context) *QueryStats {
+	stats, _ := ctx.Value(queryStatsKey{}).(*QueryStats)
+	return stats
+}
+
+// sourceScanned returns the statistics of the scan of the table through the index of the scan specs
+func (s *QueryStats) sourceScanned(table *Table, alias string, scanSpecs *ScanSpecs) *SourceStats {
+	if s == nil {
+		return nil
+	}
+
+	index := scanSpecs.Index.Name()
+
+	for _, src := range s.Sources {
+		if src.Table == table.name && src.Alias == alias && src.Index == index {
+			src.Scans++
+			return src
+		}
+	}
+
+	src := &SourceStats{
+		Table:    table.name,
+		Alias:    alias,
+		Index:    index,
+		FullScan: isFullScan(scanSpecs),
+		Scans:    1,
+	}
+
+	s.Sources = append(s.Sources, src)
+
+	return src
+}
+
+// isFullScan returns true when the scan is not narrowed by a range on the leading column of its index
+func isFullScan(scanSpecs *ScanSpecs) bool {
+	colRange, ok := scanSpecs.rangesByColID[scanSpecs.Index.cols[0].id]
+
+	return !ok || (colRange.lRange == nil && colRange.hRange == nil)
+}
+
+func (s *QueryStats) rowScanned(src *SourceStats, bytes int) {
+	if s == nil {
+		return
+	}
+
+	s.RowsScanned++
+	s.BytesRead += uint64(bytes)
+
+	src.RowsScanned++
+	src.BytesRead += uint64(bytes)
+}
+
+func (s *QueryStats) sorted(since time.Time) {
+	if s == nil {
+		return
+	}
+
+	s.SortTime += time.Since(since)
+}
+
+func (s *QueryStats) aggregated(elapsed time.Duration) {
+	if s == nil {
+		return
+	}
+
+	s.AggregationTime += elapsed
+}
+
+// statsRowReader counts the rows returned by a query and completes its statistics once closed
+type statsRowReader struct {
+	RowReader
+	stats *QueryStats
+}
+
+func (sr *statsRowReader) Read(ctx context.Context) (*Row, error) {
+	row, err := sr.RowReader.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sr.stats.RowsReturned++
+
+	return row, nil
+}
+
+func (sr *statsRowReader) Close() error {
+	if sr.stats.ExecutionTime == 0 {
+		sr.stats.ExecutionTime = time.Since(sr.stats.startedAt)
+	}
+
+	return sr.RowReader.Close()
+}
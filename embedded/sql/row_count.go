@@ -0,0 +1,240 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RowCount is the number of rows returned by a query when its LIMIT and OFFSET clauses are not applied
+type RowCount struct {
+	Rows uint64
+	// Exact is false when the number of rows was estimated
+	Exact bool
+}
+
+// QueryWithRowCount returns the rows of the query together with the number of rows the query returns
+// regardless of its LIMIT and OFFSET clauses, as needed to paginate results.
+// See QueryPreparedStmtWithRowCount for how rows are counted
+func (e *Engine) QueryWithRowCount(ctx context.Context, tx *SQLTx, sql string, params map[string]interface{}, countLimit int) (RowReader, *RowCount, error) {
+	stmts, err := Parse(strings.NewReader(sql))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrParsingError, err)
+	}
+	if len(stmts) != 1 {
+		return nil, nil, ErrExpectingDQLStmt
+	}
+
+	stmt, ok := stmts[0].(DataSource)
+	if !ok {
+		return nil, nil, ErrExpectingDQLStmt
+	}
+
+	return e.QueryPreparedStmtWithRowCount(ctx, tx, stmt, params, countLimit)
+}
+
+// QueryPreparedStmtWithRowCount returns the rows of the query together with the number of rows the query returns
+// regardless of its LIMIT and OFFSET clauses. Both are computed within the same transaction.
+//
+// Rows are counted until more than countLimit rows are read, zero means no limit. Once the limit is exceeded, the count is estimated:
+//   - for queries over a single table with an auto-increment primary key, which are neither joined, grouped nor distinct,
+//     and whose scan is not bounded by the WHERE clause, the ratio of matching rows among the rows read so far is
+//     applied to the number of rows of the table, which is in turn estimated by the largest primary key assigned
+//   - otherwise the number of rows counted so far is returned, which is a lower bound
+func (e *Engine) QueryPreparedStmtWithRowCount(ctx context.Context, tx *SQLTx, stmt DataSource, params map[string]interface{}, countLimit int) (rowReader RowReader, rowCount *RowCount, err error) {
+	if stmt == nil || countLimit < 0 {
+		return nil, nil, ErrIllegalArguments
+	}
+
+	qtx := tx
+
+	if qtx == nil {
+		qtx, err = e.NewTx(ctx, DefaultTxOptions().WithReadOnly(true))
+		if err != nil {
+			return nil, nil, err
+		}
+		defer func() {
+			if err != nil {
+				qtx.Cancel()
+			}
+		}()
+	}
+
+	rowCount, err = e.countRows(ctx, qtx, stmt, params, countLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rowReader, err = e.QueryPreparedStmt(ctx, qtx, stmt, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if tx == nil {
+		rowReader.onClose(func() {
+			qtx.Cancel()
+		})
+	}
+
+	return rowReader, rowCount, nil
+}
+
+func (e *Engine) countRows(ctx context.Context, tx *SQLTx, stmt DataSource, params map[string]interface{}, countLimit int) (*RowCount, error) {
+	selectStmt, isSelect := stmt.(*SelectStmt)
+	if isSelect {
+		// rows are counted without paginating them
+		unpaged := *selectStmt
+		unpaged.limit = nil
+		unpaged.offset = nil
+
+		if unpaged.estimable() {
+			return e.countTableRows(ctx, tx, &unpaged, params, countLimit)
+		}
+
+		stmt = &unpaged
+	}
+
+	r, err := e.QueryPreparedStmt(ctx, tx, stmt, params)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	rowCount := &RowCount{}
+
+	for {
+		_, err := r.Read(ctx)
+		if errors.Is(err, ErrNoMoreRows) {
+			rowCount.Exact = true
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if countLimit > 0 && rowCount.Rows == uint64(countLimit) {
+			break
+		}
+
+		rowCount.Rows++
+	}
+
+	return rowCount, nil
+}
+
+// estimable returns true if the number of rows of the query only depends on its WHERE clause,
+// so it can be estimated from the rows of the table
+func (stmt *SelectStmt) estimable() bool {
+	tableRef, isTableRef := stmt.ds.(*tableRef)
+
+	return isTableRef &&
+		tableRef.sample == nil &&
+		len(stmt.joins) == 0 &&
+		len(stmt.groupBy) == 0 &&
+		stmt.grouping == nil &&
+		!stmt.distinct &&
+		!stmt.containsAggregations()
+}
+
+func (e *Engine) countTableRows(ctx context.Context, tx *SQLTx, stmt *SelectStmt, params map[string]interface{}, countLimit int) (*RowCount, error) {
+	nparams, err := normalizeParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = stmt.execAt(ctx, tx, nparams)
+	if err != nil {
+		return nil, err
+	}
+
+	tableRef := stmt.ds.(*tableRef)
+
+	table, err := tableRef.referencedTable(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	scanSpecs, err := stmt.genScanSpecs(tx, nparams)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := tableRef.Resolve(ctx, tx, nparams, scanSpecs)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var cond ValueExp
+
+	if stmt.where != nil {
+		cond, err = stmt.where.substitute(nparams)
+		if err != nil {
+			return nil, fmt.Errorf("%w: when evaluating WHERE clause", err)
+		}
+	}
+
+	rowCount := &RowCount{}
+	var readRows uint64
+
+	for {
+		row, err := r.Read(ctx)
+		if errors.Is(err, ErrNoMoreRows) {
+			rowCount.Exact = true
+			return rowCount, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if countLimit > 0 && readRows == uint64(countLimit) {
+			break
+		}
+
+		readRows++
+
+		if cond != nil {
+			v, err := cond.reduce(tx, row, r.Database(), r.TableAlias())
+			if err != nil {
+				return nil, fmt.Errorf("%w: when evaluating WHERE clause", err)
+			}
+
+			satisfies, isBool := v.(*Bool)
+			if !isBool && !v.IsNull() {
+				return nil, fmt.Errorf("%w: expected '%s' in WHERE clause, but '%s' was provided", ErrInvalidCondition, BooleanType, v.Type())
+			}
+
+			if !isBool || !satisfies.val {
+				continue
+			}
+		}
+
+		rowCount.Rows++
+	}
+
+	// the rows read so far are a sample of the whole table only if the scan is not bounded
+	_, bounded := scanSpecs.rangesByColID[scanSpecs.Index.cols[0].id]
+
+	if table.autoIncrementPK && !bounded && uint64(table.maxPK) > readRows {
+		rowCount.Rows = rowCount.Rows * uint64(table.maxPK) / readRows
+	}
+
+	return rowCount, nil
+}
@@ -109,6 +109,7 @@ func (row *Row) digest(cols []ColDescriptor) (d [sha256.Size]byte, err error) {
 type rawRowReader struct {
 	tx         *SQLTx
 	table      *Table
+	db         string
 	tableAlias string
 	colsByPos  []ColDescriptor
 	colsBySel  map[string]ColDescriptor
@@ -127,6 +128,10 @@ type rawRowReader struct {
 
 	reader          store.KeyReader
 	onCloseCallback func()
+
+	// stats and sourceStats are set when the query is executed collecting statistics
+	stats       *QueryStats
+	sourceStats *SourceStats
 }
 
 type txRange struct {
@@ -147,6 +152,11 @@ func (d *ColDescriptor) Selector() string {
 }
 
 func newRawRowReader(tx *SQLTx, params map[string]interface{}, table *Table, period period, tableAlias string, scanSpecs *ScanSpecs) (*rawRowReader, error) {
+	return newRawRowReaderWithFilters(tx, params, table, period, tableAlias, scanSpecs, store.IgnoreExpired, store.IgnoreDeleted)
+}
+
+// newRawRowReaderWithFilters returns a reader of the entries of the index which pass all the given filters
+func newRawRowReaderWithFilters(tx *SQLTx, params map[string]interface{}, table *Table, period period, tableAlias string, scanSpecs *ScanSpecs, filters ...store.FilterFn) (*rawRowReader, error) {
 	if table == nil || scanSpecs == nil || scanSpecs.Index == nil {
 		return nil, ErrIllegalArguments
 	}
@@ -156,6 +166,8 @@ func newRawRowReader(tx *SQLTx, params map[string]interface{}, table *Table, per
 		return nil, err
 	}
 
+	rSpec.Filters = filters
+
 	r, err := tx.newKeyReader(*rSpec)
 	if err != nil {
 		return nil, err
@@ -165,12 +177,19 @@ func newRawRowReader(tx *SQLTx, params map[string]interface{}, table *Table, per
 		tableAlias = table.name
 	}
 
+	// columns are referenced under the current selected database, thus tables
+	// of other databases can be queried (and joined) as any other table
+	db := table.db.name
+	if tx.currentDB != nil {
+		db = tx.currentDB.name
+	}
+
 	colsByPos := make([]ColDescriptor, len(table.Cols()))
 	colsBySel := make(map[string]ColDescriptor, len(table.Cols()))
 
 	for i, c := range table.Cols() {
 		colDescriptor := ColDescriptor{
-			Database: table.db.name,
+			Database: db,
 			Table:    tableAlias,
 			Column:   c.colName,
 			Type:     c.colType,
@@ -183,6 +202,7 @@ func newRawRowReader(tx *SQLTx, params map[string]interface{}, table *Table, per
 	return &rawRowReader{
 		tx:         tx,
 		table:      table,
+		db:         db,
 		period:     period,
 		tableAlias: tableAlias,
 		colsByPos:  colsByPos,
@@ -272,7 +292,7 @@ func (r *rawRowReader) Tx() *SQLTx {
 }
 
 func (r *rawRowReader) Database() string {
-	return r.table.db.name
+	return r.db
 }
 
 func (r *rawRowReader) TableAlias() string {
@@ -284,7 +304,7 @@ func (r *rawRowReader) OrderBy() []ColDescriptor {
 
 	for i, col := range r.scanSpecs.Index.cols {
 		cols[i] = ColDescriptor{
-			Database: r.table.db.name,
+			Database: r.db,
 			Table:    r.tableAlias,
 			Column:   col.colName,
 			Type:     col.colType,
@@ -406,6 +426,8 @@ func (r *rawRowReader) Read(ctx context.Context) (row *Row, err error) {
 
 	var v []byte
 
+	bytesRead := len(mkey)
+
 	//decompose key, determine if it's pk, when it's pk, the value holds the actual row data
 	if r.scanSpecs.Index.IsPrimary() {
 		v, err = vref.Resolve()
@@ -420,7 +442,9 @@ func (r *rawRowReader) Read(ctx context.Context) (row *Row, err error) {
 			return nil, err
 		}
 
-		if r.scanSpecs.Index.IsUnique() {
+		bytesRead += len(v)
+
+		if r.scanSpecs.Index.uniqueKeys() {
 			encPKVals = v
 		} else {
 			encPKVals, err = unmapIndexEntry(r.scanSpecs.Index, r.tx.engine.prefix, mkey)
@@ -440,6 +464,19 @@ func (r *rawRowReader) Read(ctx context.Context) (row *Row, err error) {
 		}
 	}
 
+	r.stats.rowScanned(r.sourceStats, bytesRead+len(v))
+
+	return r.decodeRow(v)
+}
+
+// collectStats makes the reader account its scan into the statistics of the query, if collected
+func (r *rawRowReader) collectStats(stats *QueryStats) {
+	r.stats = stats
+	r.sourceStats = stats.sourceScanned(r.table, r.tableAlias, r.scanSpecs)
+}
+
+// decodeRow decodes the row stored as value of the primary index
+func (r *rawRowReader) decodeRow(v []byte) (*Row, error) {
 	valuesByPosition := make([]TypedValue, len(r.table.Cols()))
 	valuesBySelector := make(map[string]TypedValue, len(r.table.Cols()))
 
@@ -447,7 +484,7 @@ func (r *rawRowReader) Read(ctx context.Context) (row *Row, err error) {
 		v := &NullValue{t: col.colType}
 
 		valuesByPosition[i] = v
-		valuesBySelector[EncodeSelector("", r.table.db.name, r.tableAlias, col.colName)] = v
+		valuesBySelector[EncodeSelector("", r.db, r.tableAlias, col.colName)] = v
 	}
 
 	if len(v) < EncLenLen {
@@ -480,7 +517,7 @@ func (r *rawRowReader) Read(ctx context.Context) (row *Row, err error) {
 		voff += n
 
 		valuesByPosition[i] = val
-		valuesBySelector[EncodeSelector("", r.table.db.name, r.tableAlias, col.colName)] = val
+		valuesBySelector[EncodeSelector("", r.db, r.tableAlias, col.colName)] = val
 	}
 
 	if len(v)-voff > 0 {
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"math/rand"
+)
+
+// sampleRowReader implements a Bernoulli sampling of the rows of a table (TABLESAMPLE).
+//
+// Every row is included independently with the sampling probability p, thus the number of
+// returned rows out of n follows a binomial distribution with mean n*p and standard deviation
+// sqrt(n*p*(1-p)). Rows are drawn from a pseudo-random generator consuming one draw per row,
+// so the same seed (REPEATABLE) yields the same sample as long as the scanned rows are the same.
+//
+// Sampling does not reduce the number of rows scanned, it only reduces the rows processed
+// by the rest of the query.
+type sampleRowReader struct {
+	rowReader RowReader
+	sample    *tableSample
+
+	// percentage and rand are resolved on first read, as parameters may be provided after initialization
+	percentage int64
+	rand       *rand.Rand
+}
+
+func newSampleRowReader(rowReader RowReader, sample *tableSample) *sampleRowReader {
+	return &sampleRowReader{
+		rowReader: rowReader,
+		sample:    sample,
+	}
+}
+
+func (sr *sampleRowReader) onClose(callback func()) {
+	sr.rowReader.onClose(callback)
+}
+
+func (sr *sampleRowReader) Tx() *SQLTx {
+	return sr.rowReader.Tx()
+}
+
+func (sr *sampleRowReader) Database() string {
+	return sr.rowReader.Database()
+}
+
+func (sr *sampleRowReader) TableAlias() string {
+	return sr.rowReader.TableAlias()
+}
+
+func (sr *sampleRowReader) Parameters() map[string]interface{} {
+	return sr.rowReader.Parameters()
+}
+
+func (sr *sampleRowReader) SetParameters(params map[string]interface{}) error {
+	return sr.rowReader.SetParameters(params)
+}
+
+func (sr *sampleRowReader) OrderBy() []ColDescriptor {
+	return sr.rowReader.OrderBy()
+}
+
+func (sr *sampleRowReader) ScanSpecs() *ScanSpecs {
+	return sr.rowReader.ScanSpecs()
+}
+
+func (sr *sampleRowReader) Columns(ctx context.Context) ([]ColDescriptor, error) {
+	return sr.rowReader.Columns(ctx)
+}
+
+func (sr *sampleRowReader) colsBySelector(ctx context.Context) (map[string]ColDescriptor, error) {
+	return sr.rowReader.colsBySelector(ctx)
+}
+
+func (sr *sampleRowReader) InferParameters(ctx context.Context, params map[string]SQLValueType) error {
+	err := sr.rowReader.InferParameters(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	cols, err := sr.colsBySelector(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = sr.sample.percentage.requiresType(IntegerType, cols, params, sr.Database(), sr.TableAlias())
+	if err != nil {
+		return err
+	}
+
+	if sr.sample.seed != nil {
+		return sr.sample.seed.requiresType(IntegerType, cols, params, sr.Database(), sr.TableAlias())
+	}
+
+	return nil
+}
+
+func (sr *sampleRowReader) Read(ctx context.Context) (*Row, error) {
+	if sr.rand == nil {
+		percentage, seed, err := sr.sample.resolve(sr.Tx(), sr.Parameters())
+		if err != nil {
+			return nil, err
+		}
+
+		sr.percentage = percentage
+		sr.rand = rand.New(rand.NewSource(seed))
+	}
+
+	for {
+		row, err := sr.rowReader.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if sr.rand.Int63n(100) < sr.percentage {
+			return row, nil
+		}
+	}
+}
+
+func (sr *sampleRowReader) Close() error {
+	return sr.rowReader.Close()
+}
@@ -0,0 +1,314 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// RowScanner reads the rows of a query into structs, see ScanRow for details on how values are mapped
+type RowScanner struct {
+	r    RowReader
+	cols []ColDescriptor
+}
+
+func NewRowScanner(ctx context.Context, r RowReader) (*RowScanner, error) {
+	if r == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	cols, err := r.Columns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RowScanner{r: r, cols: cols}, nil
+}
+
+// Next reads the next row into the struct pointed by dest.
+// ErrNoMoreRows is returned once all the rows have been read
+func (s *RowScanner) Next(ctx context.Context, dest interface{}) error {
+	row, err := s.r.Read(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ScanRow(s.cols, row, dest)
+}
+
+// ScanRow maps the values of a row into the fields of the struct pointed by dest.
+//
+// Columns are matched with the exported fields of the struct by the name set in their `sql` tag,
+// or by the name of the field when there is no tag, ignoring case in both cases.
+// Fields tagged as `sql:"-"` are never set and columns with no matching field are ignored.
+//
+// Values must be assignable to the type of the field:
+//   - INTEGER to any integer type, as long as the value is within its range, or sql.NullInt64
+//   - VARCHAR to string or sql.NullString
+//   - BOOLEAN to bool or sql.NullBool
+//   - BLOB to []byte
+//   - TIMESTAMP to time.Time or sql.NullTime
+//   - any type to interface{} or a type implementing sql.Scanner
+//
+// Pointers to any of the above types are also supported. NULL values set pointers, []byte and
+// interface{} fields to nil and sql.Null* fields as not valid, other types can not hold NULL values.
+func ScanRow(cols []ColDescriptor, row *Row, dest interface{}) error {
+	if row == nil || len(cols) != len(row.ValuesByPosition) {
+		return ErrIllegalArguments
+	}
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: a pointer to a struct is required", ErrIllegalArguments)
+	}
+
+	v = v.Elem()
+
+	fieldsByCol := fieldsByColumn(v.Type())
+
+	for i, col := range cols {
+		field, ok := fieldsByCol[strings.ToLower(col.Column)]
+		if !ok {
+			continue
+		}
+
+		err := scanValue(row.ValuesByPosition[i], v.Field(field))
+		if err != nil {
+			return fmt.Errorf("error scanning column '%s': %w", col.Column, err)
+		}
+	}
+
+	return nil
+}
+
+func fieldsByColumn(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.PkgPath != "" {
+			// unexported field
+			continue
+		}
+
+		name := f.Tag.Get("sql")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fields[strings.ToLower(name)] = i
+	}
+
+	return fields
+}
+
+func scanValue(val TypedValue, f reflect.Value) error {
+	if val.IsNull() {
+		return scanNull(f)
+	}
+
+	if f.Kind() == reflect.Ptr {
+		ptr := reflect.New(f.Type().Elem())
+
+		err := scanValue(val, ptr.Elem())
+		if err != nil {
+			return err
+		}
+
+		f.Set(ptr)
+
+		return nil
+	}
+
+	if f.Kind() == reflect.Interface && f.NumMethod() == 0 {
+		f.Set(reflect.ValueOf(val.Value()))
+		return nil
+	}
+
+	rval := val.Value()
+
+	mismatch := fmt.Errorf("%w: %s value can not be scanned into %s", ErrInvalidTypes, val.Type(), f.Type())
+
+	switch d := f.Addr().Interface().(type) {
+	case *sql.NullInt64:
+		{
+			n, ok := rval.(int64)
+			if !ok {
+				return mismatch
+			}
+
+			*d = sql.NullInt64{Int64: n, Valid: true}
+
+			return nil
+		}
+	case *sql.NullString:
+		{
+			s, ok := rval.(string)
+			if !ok {
+				return mismatch
+			}
+
+			*d = sql.NullString{String: s, Valid: true}
+
+			return nil
+		}
+	case *sql.NullBool:
+		{
+			b, ok := rval.(bool)
+			if !ok {
+				return mismatch
+			}
+
+			*d = sql.NullBool{Bool: b, Valid: true}
+
+			return nil
+		}
+	case *sql.NullTime:
+		{
+			t, ok := rval.(time.Time)
+			if !ok {
+				return mismatch
+			}
+
+			*d = sql.NullTime{Time: t, Valid: true}
+
+			return nil
+		}
+	case sql.Scanner:
+		{
+			return d.Scan(rval)
+		}
+	}
+
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		{
+			n, ok := rval.(int64)
+			if !ok {
+				return mismatch
+			}
+
+			if f.OverflowInt(n) {
+				return fmt.Errorf("%w: %d overflows %s", ErrInvalidValue, n, f.Type())
+			}
+
+			f.SetInt(n)
+
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		{
+			n, ok := rval.(int64)
+			if !ok {
+				return mismatch
+			}
+
+			if n < 0 || f.OverflowUint(uint64(n)) {
+				return fmt.Errorf("%w: %d overflows %s", ErrInvalidValue, n, f.Type())
+			}
+
+			f.SetUint(uint64(n))
+
+			return nil
+		}
+	case reflect.String:
+		{
+			s, ok := rval.(string)
+			if !ok {
+				return mismatch
+			}
+
+			f.SetString(s)
+
+			return nil
+		}
+	case reflect.Bool:
+		{
+			b, ok := rval.(bool)
+			if !ok {
+				return mismatch
+			}
+
+			f.SetBool(b)
+
+			return nil
+		}
+	case reflect.Slice:
+		{
+			b, ok := rval.([]byte)
+			if !ok || f.Type().Elem().Kind() != reflect.Uint8 {
+				return mismatch
+			}
+
+			cb := make([]byte, len(b))
+			copy(cb, b)
+
+			f.SetBytes(cb)
+
+			return nil
+		}
+	case reflect.Struct:
+		{
+			t, ok := rval.(time.Time)
+			if !ok || f.Type() != timeType {
+				return mismatch
+			}
+
+			f.Set(reflect.ValueOf(t))
+
+			return nil
+		}
+	}
+
+	return mismatch
+}
+
+func scanNull(f reflect.Value) error {
+	switch f.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice:
+		{
+			f.Set(reflect.Zero(f.Type()))
+			return nil
+		}
+	}
+
+	switch d := f.Addr().Interface().(type) {
+	case *sql.NullInt64, *sql.NullString, *sql.NullBool, *sql.NullTime:
+		{
+			f.Set(reflect.Zero(f.Type()))
+			return nil
+		}
+	case sql.Scanner:
+		{
+			return d.Scan(nil)
+		}
+	}
+
+	return fmt.Errorf("%w: NULL can not be scanned into %s", ErrInvalidValue, f.Type())
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowScanner(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE DATABASE db1;
+		USE DATABASE db1;
+		CREATE TABLE table1(
+			id INTEGER AUTO_INCREMENT,
+			title VARCHAR,
+			active BOOLEAN,
+			payload BLOB,
+			ts TIMESTAMP,
+			PRIMARY KEY id
+		);
+	`, nil)
+	require.NoError(t, err)
+
+	ts := time.Date(2022, 6, 1, 10, 30, 0, 0, time.UTC)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		INSERT INTO table1(title, active, payload, ts) VALUES ('title1', true, x'0102', @ts);
+		INSERT INTO table1(title) VALUES (NULL);
+	`, map[string]interface{}{"ts": ts})
+	require.NoError(t, err)
+
+	type plainRow struct {
+		ID      int64
+		Title   string `sql:"title"`
+		Active  bool
+		Payload []byte
+		TS      time.Time `sql:"ts"`
+		Ignored string    `sql:"-"`
+	}
+
+	type nullableRow struct {
+		ID      uint32
+		Title   *string
+		Active  sql.NullBool
+		Payload interface{}
+		TS      *time.Time
+	}
+
+	type nullRow struct {
+		ID      int8
+		Title   sql.NullString
+		Active  *bool
+		Payload interface{}
+		TS      sql.NullTime
+	}
+
+	newScanner := func(t *testing.T, q string) *RowScanner {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { r.Close() })
+
+		scanner, err := NewRowScanner(context.Background(), r)
+		require.NoError(t, err)
+
+		return scanner
+	}
+
+	t.Run("values should be scanned into fields of the same type", func(t *testing.T) {
+		scanner := newScanner(t, "SELECT * FROM table1 WHERE id = 1")
+
+		row := plainRow{Ignored: "untouched"}
+
+		err := scanner.Next(context.Background(), &row)
+		require.NoError(t, err)
+		require.Equal(t, plainRow{
+			ID:      1,
+			Title:   "title1",
+			Active:  true,
+			Payload: []byte{1, 2},
+			TS:      ts,
+			Ignored: "untouched",
+		}, row)
+
+		err = scanner.Next(context.Background(), &row)
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+
+	t.Run("values should be scanned into pointers and nullable types", func(t *testing.T) {
+		scanner := newScanner(t, "SELECT * FROM table1 WHERE id = 1")
+
+		var row nullableRow
+
+		err := scanner.Next(context.Background(), &row)
+		require.NoError(t, err)
+		require.Equal(t, uint32(1), row.ID)
+		require.Equal(t, "title1", *row.Title)
+		require.Equal(t, sql.NullBool{Bool: true, Valid: true}, row.Active)
+		require.Equal(t, []byte{1, 2}, row.Payload)
+		require.Equal(t, ts, *row.TS)
+	})
+
+	t.Run("NULL values should be scanned into pointers and nullable types", func(t *testing.T) {
+		scanner := newScanner(t, "SELECT * FROM table1 WHERE id = 2")
+
+		row := nullRow{Active: new(bool), Payload: "not null"}
+
+		err := scanner.Next(context.Background(), &row)
+		require.NoError(t, err)
+		require.Equal(t, nullRow{ID: 2}, row)
+	})
+
+	t.Run("NULL values should not be scanned into non-nullable types", func(t *testing.T) {
+		scanner := newScanner(t, "SELECT * FROM table1 WHERE id = 2")
+
+		var row plainRow
+
+		err := scanner.Next(context.Background(), &row)
+		require.ErrorIs(t, err, ErrInvalidValue)
+		require.Contains(t, err.Error(), "'title'")
+	})
+
+	t.Run("values should only be scanned into compatible types", func(t *testing.T) {
+		scanner := newScanner(t, "SELECT id AS title FROM table1 WHERE id = 1")
+
+		var row plainRow
+
+		err := scanner.Next(context.Background(), &row)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+
+	t.Run("integers should be scanned within the range of the field type", func(t *testing.T) {
+		cols := []ColDescriptor{{Column: "id", Type: IntegerType}}
+
+		var row struct{ ID int8 }
+
+		err := ScanRow(cols, &Row{ValuesByPosition: []TypedValue{&Number{val: 128}}}, &row)
+		require.ErrorIs(t, err, ErrInvalidValue)
+
+		var urow struct{ ID uint64 }
+
+		err = ScanRow(cols, &Row{ValuesByPosition: []TypedValue{&Number{val: -1}}}, &urow)
+		require.ErrorIs(t, err, ErrInvalidValue)
+	})
+
+	t.Run("a pointer to a struct should be required", func(t *testing.T) {
+		cols := []ColDescriptor{{Column: "id", Type: IntegerType}}
+		row := &Row{ValuesByPosition: []TypedValue{&Number{val: 1}}}
+
+		var id int64
+
+		err := ScanRow(cols, row, &id)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = ScanRow(cols, row, plainRow{})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = ScanRow(nil, row, &plainRow{})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = NewRowScanner(context.Background(), nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"crypto/sha256"
+
+	"github.com/codenotary/immudb/embedded/multierr"
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// setOpRowReader returns the rows of the left subquery which are either excluded (EXCEPT)
+// or also found (INTERSECT) in the rows of the right subquery.
+// Rows of the right subquery are fully read and kept in memory, thus they are bounded by the distinct limit and by the memory limit of the query.
+type setOpRowReader struct {
+	op       SetOperator
+	distinct bool
+
+	left, right RowReader
+	cols        []ColDescriptor
+
+	// rowCount holds the number of rows of the right subquery by digest,
+	// it's loaded from the right subquery on first read
+	rowCount map[[sha256.Size]byte]int
+
+	mem *memoryAccount
+}
+
+func newSetOpRowReader(ctx context.Context, op SetOperator, distinct bool, left, right RowReader) (*setOpRowReader, error) {
+	if op != ExceptSetOp && op != IntersectSetOp {
+		return nil, ErrIllegalArguments
+	}
+
+	cols, err := left.Columns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := right.Columns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkMatchingColumns(cols, cs, ErrColumnMismatchInSetOpStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &setOpRowReader{
+		op:       op,
+		distinct: distinct,
+		left:     left,
+		right:    right,
+		cols:     cols,
+		mem:      memoryAccountFrom(ctx),
+	}, nil
+}
+
+func (sr *setOpRowReader) onClose(callback func()) {
+	sr.left.onClose(callback)
+}
+
+func (sr *setOpRowReader) Tx() *SQLTx {
+	return sr.left.Tx()
+}
+
+func (sr *setOpRowReader) Database() string {
+	return sr.left.Database()
+}
+
+func (sr *setOpRowReader) TableAlias() string {
+	return ""
+}
+
+func (sr *setOpRowReader) SetParameters(params map[string]interface{}) error {
+	err := sr.left.SetParameters(params)
+	if err != nil {
+		return err
+	}
+
+	return sr.right.SetParameters(params)
+}
+
+func (sr *setOpRowReader) Parameters() map[string]interface{} {
+	return sr.left.Parameters()
+}
+
+func (sr *setOpRowReader) OrderBy() []ColDescriptor {
+	return nil
+}
+
+func (sr *setOpRowReader) ScanSpecs() *ScanSpecs {
+	return nil
+}
+
+func (sr *setOpRowReader) Columns(ctx context.Context) ([]ColDescriptor, error) {
+	return sr.left.Columns(ctx)
+}
+
+func (sr *setOpRowReader) colsBySelector(ctx context.Context) (map[string]ColDescriptor, error) {
+	return sr.left.colsBySelector(ctx)
+}
+
+func (sr *setOpRowReader) InferParameters(ctx context.Context, params map[string]SQLValueType) error {
+	err := sr.left.InferParameters(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	return sr.right.InferParameters(ctx, params)
+}
+
+func (sr *setOpRowReader) loadRightRows(ctx context.Context) error {
+	sr.rowCount = make(map[[sha256.Size]byte]int)
+
+	for {
+		row, err := sr.right.Read(ctx)
+		if err == store.ErrNoMoreEntries {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		digest, err := row.digest(sr.cols)
+		if err != nil {
+			return err
+		}
+
+		_, ok := sr.rowCount[digest]
+		if !ok && len(sr.rowCount) == sr.Tx().distinctLimit() {
+			return ErrTooManyRows
+		}
+
+		if !ok {
+			err = sr.mem.reserve(rowDigestMemSize)
+			if err != nil {
+				return err
+			}
+		}
+
+		sr.rowCount[digest]++
+	}
+}
+
+func (sr *setOpRowReader) Read(ctx context.Context) (*Row, error) {
+	if sr.rowCount == nil {
+		err := sr.loadRightRows(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		row, err := sr.left.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		digest, err := row.digest(sr.cols)
+		if err != nil {
+			return nil, err
+		}
+
+		count, ok := sr.rowCount[digest]
+
+		if sr.op == ExceptSetOp {
+			if count > 0 {
+				if !sr.distinct {
+					sr.rowCount[digest]--
+				}
+				continue
+			}
+
+			if sr.distinct {
+				if !ok && len(sr.rowCount) == sr.Tx().distinctLimit() {
+					return nil, ErrTooManyRows
+				}
+
+				if !ok {
+					err = sr.mem.reserve(rowDigestMemSize)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				// further occurrences of the same row are skipped
+				sr.rowCount[digest] = 1
+			}
+
+			return row, nil
+		}
+
+		if count == 0 {
+			continue
+		}
+
+		if sr.distinct {
+			sr.rowCount[digest] = 0
+		} else {
+			sr.rowCount[digest]--
+		}
+
+		return row, nil
+	}
+}
+
+func (sr *setOpRowReader) Close() error {
+	sr.mem.release(int64(len(sr.rowCount)) * rowDigestMemSize)
+	sr.rowCount = nil
+
+	merr := multierr.NewMultiErr()
+
+	// Closing in reverse order to ensure the onClose callback
+	// is called after the last reader is closed
+	merr.Append(sr.right.Close())
+	merr.Append(sr.left.Close())
+
+	return merr.Reduce()
+}
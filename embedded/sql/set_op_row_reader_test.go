@@ -0,0 +1,79 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetOpRowReader(t *testing.T) {
+	left := &dummyRowReader{
+		database:             "db1",
+		failReturningColumns: true,
+	}
+
+	right := &dummyRowReader{
+		database: "db1",
+	}
+
+	_, err := newSetOpRowReader(context.Background(), 2, true, left, right)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = newSetOpRowReader(context.Background(), ExceptSetOp, true, left, right)
+	require.ErrorIs(t, err, errDummy)
+
+	left.failReturningColumns = false
+	right.failReturningColumns = true
+
+	_, err = newSetOpRowReader(context.Background(), ExceptSetOp, true, left, right)
+	require.ErrorIs(t, err, errDummy)
+
+	right.failReturningColumns = false
+
+	rowReader, err := newSetOpRowReader(context.Background(), IntersectSetOp, false, left, right)
+	require.NoError(t, err)
+	require.NotNil(t, rowReader)
+
+	require.Equal(t, "db1", rowReader.Database())
+
+	require.Equal(t, "", rowReader.TableAlias())
+
+	require.Nil(t, rowReader.OrderBy())
+
+	require.Nil(t, rowReader.ScanSpecs())
+
+	params := map[string]interface{}{
+		"param1": 1,
+	}
+
+	err = rowReader.SetParameters(params)
+	require.NoError(t, err)
+
+	require.Equal(t, params, rowReader.Parameters())
+	require.Equal(t, params, right.Parameters())
+
+	paramTypes := make(map[string]string)
+	err = rowReader.InferParameters(context.Background(), paramTypes)
+	require.NoError(t, err)
+
+	right.failInferringParams = true
+	err = rowReader.InferParameters(context.Background(), paramTypes)
+	require.ErrorIs(t, err, errDummy)
+}
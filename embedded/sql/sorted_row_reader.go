@@ -0,0 +1,209 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// sortedRowReader returns the rows of the underlying reader sorted by a column according to a collation
+// and to the placement of NULL values.
+// Rows are fully read and kept in memory on first read, thus they are bounded by the distinct limit
+// and by the memory limit of the query.
+type sortedRowReader struct {
+	rowReader RowReader
+
+	ordCol *OrdCol
+	colSel string
+	colDes ColDescriptor
+
+	rows   []*Row
+	loaded bool
+
+	mem     *memoryAccount
+	memUsed int64
+
+	stats *QueryStats
+}
+
+func newSortedRowReader(ctx context.Context, rowReader RowReader, ordCol *OrdCol) (*sortedRowReader, error) {
+	cols, err := rowReader.colsBySelector(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	colSel := EncodeSelector(ordCol.sel.resolve(rowReader.Database(), rowReader.TableAlias()))
+
+	colDes, ok := cols[colSel]
+	if !ok {
+		return nil, fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, ordCol.sel.col)
+	}
+
+	if ordCol.collation != "" {
+		err = ordCol.collation.validFor(colDes.Type)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &sortedRowReader{
+		rowReader: rowReader,
+		ordCol:    ordCol,
+		colSel:    colSel,
+		colDes:    colDes,
+		mem:       memoryAccountFrom(ctx),
+		stats:     queryStatsFrom(ctx),
+	}, nil
+}
+
+func (sr *sortedRowReader) onClose(callback func()) {
+	sr.rowReader.onClose(callback)
+}
+
+func (sr *sortedRowReader) Tx() *SQLTx {
+	return sr.rowReader.Tx()
+}
+
+func (sr *sortedRowReader) Database() string {
+	return sr.rowReader.Database()
+}
+
+func (sr *sortedRowReader) TableAlias() string {
+	return sr.rowReader.TableAlias()
+}
+
+func (sr *sortedRowReader) Parameters() map[string]interface{} {
+	return sr.rowReader.Parameters()
+}
+
+func (sr *sortedRowReader) SetParameters(params map[string]interface{}) error {
+	return sr.rowReader.SetParameters(params)
+}
+
+func (sr *sortedRowReader) OrderBy() []ColDescriptor {
+	return []ColDescriptor{sr.colDes}
+}
+
+func (sr *sortedRowReader) ScanSpecs() *ScanSpecs {
+	return sr.rowReader.ScanSpecs()
+}
+
+func (sr *sortedRowReader) Columns(ctx context.Context) ([]ColDescriptor, error) {
+	return sr.rowReader.Columns(ctx)
+}
+
+func (sr *sortedRowReader) colsBySelector(ctx context.Context) (map[string]ColDescriptor, error) {
+	return sr.rowReader.colsBySelector(ctx)
+}
+
+func (sr *sortedRowReader) InferParameters(ctx context.Context, params map[string]SQLValueType) error {
+	return sr.rowReader.InferParameters(ctx, params)
+}
+
+func (sr *sortedRowReader) loadRows(ctx context.Context) error {
+	for {
+		row, err := sr.rowReader.Read(ctx)
+		if err == ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(sr.rows) == sr.Tx().distinctLimit() {
+			return ErrTooManyRows
+		}
+
+		size := rowMemSize(row)
+
+		err = sr.mem.reserve(size)
+		if err != nil {
+			return err
+		}
+
+		sr.memUsed += size
+		sr.rows = append(sr.rows, row)
+	}
+
+	var cmpErr error
+
+	nullsFirst := sr.ordCol.nullsFirst()
+
+	defer sr.stats.sorted(time.Now())
+
+	// the relative order of rows with equivalent values is kept
+	sort.SliceStable(sr.rows, func(i, j int) bool {
+		v1, ok1 := sr.rows[i].ValuesBySelector[sr.colSel]
+		v2, ok2 := sr.rows[j].ValuesBySelector[sr.colSel]
+
+		if !ok1 || !ok2 {
+			cmpErr = fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, sr.ordCol.sel.col)
+			return false
+		}
+
+		// NULL values are placed regardless of the direction of the order
+		if v1.IsNull() || v2.IsNull() {
+			return (v1.IsNull() && !v2.IsNull() && nullsFirst) || (v2.IsNull() && !v1.IsNull() && !nullsFirst)
+		}
+
+		cmp, err := sr.ordCol.collation.compare(v1, v2)
+		if err != nil {
+			cmpErr = err
+			return false
+		}
+
+		if sr.ordCol.descOrder {
+			return cmp > 0
+		}
+
+		return cmp < 0
+	})
+
+	sr.loaded = true
+
+	return cmpErr
+}
+
+func (sr *sortedRowReader) Read(ctx context.Context) (*Row, error) {
+	if !sr.loaded {
+		err := sr.loadRows(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(sr.rows) == 0 {
+		return nil, ErrNoMoreRows
+	}
+
+	row := sr.rows[0]
+	sr.rows[0] = nil
+	sr.rows = sr.rows[1:]
+
+	return row, nil
+}
+
+func (sr *sortedRowReader) Close() error {
+	sr.mem.release(sr.memUsed)
+	sr.memUsed = 0
+	sr.rows = nil
+
+	return sr.rowReader.Close()
+}
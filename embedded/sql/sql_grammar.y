@@ -34,6 +34,7 @@ func setResult(l yyLexer, stmts []SQLStmt) {
     rows []*RowSpec
     row *RowSpec
     values []ValueExp
+    tuples [][]ValueExp
     value ValueExp
     id string
     number uint64
@@ -52,6 +53,7 @@ func setResult(l yyLexer, stmts []SQLStmt) {
     period period
     openPeriod *openPeriod
     periodInstant periodInstant
+    tableSample *tableSample
     joins []*JoinSpec
     join *JoinSpec
     joinType JoinType
@@ -59,6 +61,9 @@ func setResult(l yyLexer, stmts []SQLStmt) {
     binExp ValueExp
     err error
     ordcols []*OrdCol
+    ordcol *OrdCol
+    ctes []*CTE
+    cte *CTE
     opt_ord bool
     logicOp LogicOperator
     cmpOp CmpOperator
@@ -66,14 +71,32 @@ func setResult(l yyLexer, stmts []SQLStmt) {
     update *colUpdate
     updates []*colUpdate
     onConflict *OnConflictDo
+    whens []*whenThen
+    groupBy *groupByClause
+    groupingSets [][]*ColSelector
+    pos int
 }
 
 %token CREATE USE DATABASE SNAPSHOT SINCE AFTER BEFORE UNTIL TX OF TIMESTAMP TABLE UNIQUE INDEX ON ALTER ADD RENAME TO COLUMN PRIMARY KEY
 %token BEGIN TRANSACTION COMMIT ROLLBACK
 %token INSERT UPSERT INTO VALUES DELETE UPDATE SET CONFLICT DO NOTHING
-%token SELECT DISTINCT FROM JOIN HAVING WHERE GROUP BY LIMIT OFFSET ORDER ASC DESC AS UNION ALL
-%token NOT LIKE IF EXISTS IN IS
-%token AUTO_INCREMENT NULL CAST
+%token SELECT DISTINCT FROM JOIN LATERAL HAVING WHERE GROUP BY LIMIT OFFSET ORDER ASC DESC AS UNION EXCEPT INTERSECT ALL
+%token NOT LIKE IF EXISTS IN IS CONCAT
+%token TABLESAMPLE BERNOULLI PERCENT REPEATABLE SORT HISTORY
+%token AUTO_INCREMENT NULL CAST EXTRACT DEFAULT
+%token CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP
+%token TRIM LEADING TRAILING BOTH
+%token POSITION
+%token CASE WHEN THEN ELSE END
+%token COLLATE
+%token NULLS
+%token ARRAY ANY CONTAINS
+%token WITH
+%token REGEXP IREGEXP MATCH
+%token ROLLUP CUBE GROUPING SETS
+%token SHL SHR
+%token DEFERRABLE INITIALLY DEFERRED IMMEDIATE
+%token VIEW
 %token <id> NPARAM
 %token <pparam> PPARAM
 %token <joinType> JOINTYPE
@@ -88,14 +111,22 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 %token <aggFn> AGGREGATE_FUNC
 %token <err> ERROR
 
+%left UNION EXCEPT
+%left INTERSECT
 %left  ','
 %right AS
 %left  LOP
-%right LIKE
+%right LIKE REGEXP IREGEXP MATCH
 %right NOT
-%left  CMPOP
+%left  CMPOP CONTAINS
+%left  CONCAT
+%left '|'
+%left '^'
+%left '&'
+%left SHL SHR
 %left '+' '-'
 %left '*' '/'
+%right '~'
 %left  '.'
 %right STMT_SEPARATOR
 %left IS
@@ -105,33 +136,42 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 %type <colsSpec> colsSpec
 %type <colSpec> colSpec
 %type <ids> ids one_or_more_ids opt_ids
-%type <cols> cols
+%type <cols> groupcols grouping_set
+%type <groupingSets> grouping_sets
 %type <rows> rows
 %type <row> row
-%type <values> values opt_values
+%type <values> values opt_values tuple
+%type <tuples> tuples
 %type <value> val fnCall
 %type <sel> selector
 %type <sels> opt_selectors selectors
-%type <col> col
+%type <col> col groupcol
 %type <distinct> opt_distinct opt_all
-%type <ds> ds
+%type <ds> ds opt_update_from
 %type <tableRef> tableRef
 %type <period> opt_period
 %type <openPeriod> opt_period_start
 %type <openPeriod> opt_period_end
 %type <periodInstant> period_instant
+%type <tableSample> opt_tablesample
+%type <exp> opt_repeatable
 %type <joins> opt_joins joins
 %type <join> join
 %type <joinType> opt_join_type
-%type <exp> exp opt_where opt_having boundexp
+%type <exp> exp opt_where opt_having boundexp opt_limit opt_offset opt_else
+%type <value> opt_default
+%type <whens> whens
 %type <binExp> binExp
-%type <cols> opt_groupby
-%type <number> opt_limit opt_offset opt_max_len
-%type <id> opt_as
+%type <groupBy> opt_groupby
+%type <number> opt_max_len
+%type <id> opt_as opt_collate opt_nulls trim_side
 %type <ordcols> ordcols opt_orderby
+%type <ctes> ctes
+%type <cte> cte
+%type <ordcol> opt_sort_key
 %type <opt_ord> opt_ord
 %type <ids> opt_indexon
-%type <boolean> opt_if_not_exists opt_auto_increment opt_not_null opt_not
+%type <boolean> opt_if_not_exists opt_auto_increment opt_not_null opt_not opt_history opt_deferrable
 %type <update> update
 %type <updates> updates
 %type <onConflict> opt_on_conflict
@@ -202,19 +242,27 @@ ddlstmt:
         $$ = &UseSnapshotStmt{period: $3}
     }
 |
-    CREATE TABLE opt_if_not_exists IDENTIFIER '(' colsSpec ',' PRIMARY KEY one_or_more_ids ')'
+    CREATE TABLE opt_if_not_exists IDENTIFIER '(' colsSpec ',' PRIMARY KEY one_or_more_ids ')' opt_sort_key
     {
-        $$ = &CreateTableStmt{ifNotExists: $3, table: $4, colsSpec: $6, pkColNames: $10}
+        $$ = &CreateTableStmt{ifNotExists: $3, table: $4, colsSpec: $6, pkColNames: $10, sortKey: $12}
     }
 |
-    CREATE INDEX opt_if_not_exists ON IDENTIFIER '(' ids ')'
+    CREATE INDEX opt_if_not_exists ON IDENTIFIER '(' values ')'
     {
-        $$ = &CreateIndexStmt{ifNotExists: $3, table: $5, cols: $7}
+        $$ = newCreateIndexStmt(false, $3, $5, $7)
     }
 |
-    CREATE UNIQUE INDEX opt_if_not_exists ON IDENTIFIER '(' ids ')'
+    CREATE UNIQUE INDEX opt_if_not_exists ON IDENTIFIER '(' values ')' opt_deferrable
     {
-        $$ = &CreateIndexStmt{unique: true, ifNotExists: $4, table: $6, cols: $8}
+        stmt := newCreateIndexStmt(true, $4, $6, $8)
+        stmt.deferred = $10
+        $$ = stmt
+    }
+|
+    CREATE VIEW opt_if_not_exists IDENTIFIER AS dqlstmt
+    {
+        // the text of the query is set once the statement is parsed, see setViewTexts
+        $$ = &CreateViewStmt{ifNotExists: $3, name: $4, textStart: $<pos>5 + len("AS")}
     }
 |
     ALTER TABLE IDENTIFIER ADD COLUMN colSpec
@@ -237,6 +285,21 @@ opt_if_not_exists:
         $$ = true
     }
 
+opt_deferrable:
+    {
+        $$ = false
+    }
+|
+    DEFERRABLE INITIALLY IMMEDIATE
+    {
+        $$ = false
+    }
+|
+    DEFERRABLE INITIALLY DEFERRED
+    {
+        $$ = true
+    }
+
 one_or_more_ids:
     IDENTIFIER
     {
@@ -261,12 +324,13 @@ dmlstmt:
 |
     DELETE FROM tableRef opt_where opt_indexon opt_limit opt_offset
     {
-        $$ = &DeleteFromStmt{tableRef: $3, where: $4, indexOn: $5, limit: int($6), offset: int($7)}
+        $$ = &DeleteFromStmt{tableRef: $3, where: $4, indexOn: $5, limit: $6, offset: $7}
     }
 |
-    UPDATE tableRef SET updates opt_where opt_indexon opt_limit opt_offset
+    UPDATE tableRef opt_as SET updates opt_update_from opt_where opt_indexon opt_limit opt_offset
     {
-        $$ = &UpdateStmt{tableRef: $2, updates: $4, where: $5, indexOn: $6, limit: int($7), offset: int($8)}
+        $2.as = $3
+        $$ = &UpdateStmt{tableRef: $2, updates: $5, from: $6, where: $7, indexOn: $8, limit: $9, offset: $10}
     }
 
 opt_on_conflict:
@@ -295,6 +359,21 @@ update:
     {
         $$ = &colUpdate{col: $1, op: $2, val: $3}
     }
+|
+    IDENTIFIER '.' IDENTIFIER CMPOP exp
+    {
+        $$ = &colUpdate{table: $1, col: $3, op: $4, val: $5}
+    }
+
+opt_update_from:
+    {
+        $$ = nil
+    }
+|
+    FROM ds
+    {
+        $$ = $2
+    }
 
 opt_ids:
     {
@@ -334,17 +413,6 @@ ids:
         $$ = append($1, $3)
     }
 
-cols:
-    col
-    {
-        $$ = []*ColSelector{$1}
-    }
-|
-    cols ',' col
-    {
-        $$ = append($1, $3)
-    }
-
 opt_values:
     {
         $$ = nil
@@ -366,6 +434,23 @@ values:
         $$ = append($1, $3)
     }
 
+tuples:
+    tuple
+    {
+        $$ = [][]ValueExp{$1}
+    }
+|
+    tuples ',' tuple
+    {
+        $$ = append($1, $3)
+    }
+
+tuple:
+    '(' exp ',' values ')'
+    {
+        $$ = append([]ValueExp{$2}, $4...)
+    }
+
 val: 
     NUMBER
     {
@@ -391,6 +476,11 @@ val:
     {
         $$ = &Cast{val: $3, t: $5}
     }
+|
+    ARRAY '[' opt_values ']'
+    {
+        $$ = &ArrayExp{elems: $3}
+    }
 |
     fnCall
     {
@@ -411,12 +501,78 @@ val:
     {
         $$ = &NullValue{t: AnyType}
     }
+|
+    DEFAULT
+    {
+        $$ = &DefaultValue{}
+    }
+|
+    CURRENT_DATE
+    {
+        $$ = &FnCall{fn: CurrentDateFnCall}
+    }
+|
+    CURRENT_TIME
+    {
+        $$ = &FnCall{fn: CurrentTimeFnCall}
+    }
+|
+    CURRENT_TIMESTAMP
+    {
+        $$ = &FnCall{fn: CurrentTimestampFnCall}
+    }
 
 fnCall:
     IDENTIFIER '(' opt_values ')'
     {
         $$ = &FnCall{fn: $1, params: $3}
     }
+|
+    EXTRACT '(' IDENTIFIER FROM exp ')'
+    {
+        $$ = &FnCall{fn: ExtractFnCall, params: []ValueExp{&Varchar{val: $3}, $5}}
+    }
+|
+    TRIM '(' values ')'
+    {
+        $$ = &FnCall{fn: TrimFnCall, params: $3}
+    }
+|
+    TRIM '(' exp FROM exp ')'
+    {
+        $$ = &FnCall{fn: TrimFnCall, params: []ValueExp{$5, $3}}
+    }
+|
+    TRIM '(' trim_side FROM exp ')'
+    {
+        $$ = &FnCall{fn: $3, params: []ValueExp{$5}}
+    }
+|
+    TRIM '(' trim_side exp FROM exp ')'
+    {
+        $$ = &FnCall{fn: $3, params: []ValueExp{$6, $4}}
+    }
+|
+    POSITION '(' boundexp IN exp ')'
+    {
+        $$ = &FnCall{fn: InstrFnCall, params: []ValueExp{$5, $3}}
+    }
+
+trim_side:
+    LEADING
+    {
+        $$ = LTrimFnCall
+    }
+|
+    TRAILING
+    {
+        $$ = RTrimFnCall
+    }
+|
+    BOTH
+    {
+        $$ = TrimFnCall
+    }
 
 colsSpec:
     colSpec
@@ -430,9 +586,24 @@ colsSpec:
     }
 
 colSpec:
-    IDENTIFIER TYPE opt_max_len opt_not_null opt_auto_increment
+    IDENTIFIER TYPE opt_max_len opt_not_null opt_auto_increment opt_default
+    {
+        $$ = &ColSpec{colName: $1, colType: $2, maxLen: int($3), notNull: $4, autoIncrement: $5, defaultValue: $6}
+    }
+|
+    IDENTIFIER TYPE '[' ']' opt_not_null opt_auto_increment opt_default
+    {
+        $$ = &ColSpec{colName: $1, colType: ArrayTypeOf($2), notNull: $5, autoIncrement: $6, defaultValue: $7}
+    }
+
+opt_default:
     {
-        $$ = &ColSpec{colName: $1, colType: $2, maxLen: int($3), notNull: $4, autoIncrement: $5}
+        $$ = nil
+    }
+|
+    DEFAULT val
+    {
+        $$ = $2
     }
 
 opt_max_len:
@@ -476,7 +647,7 @@ dqlstmt:
         $$ = $1
     }
 |
-    select_stmt UNION opt_all dqlstmt
+    dqlstmt UNION opt_all dqlstmt
     {
         $$ = &UnionStmt{
             distinct: $3,
@@ -484,22 +655,88 @@ dqlstmt:
             right: $4.(DataSource),
         }
     }
+|
+    dqlstmt EXCEPT opt_all dqlstmt
+    {
+        $$ = &SetOpStmt{
+            op: ExceptSetOp,
+            distinct: $3,
+            left: $1.(DataSource),
+            right: $4.(DataSource),
+        }
+    }
+|
+    dqlstmt INTERSECT opt_all dqlstmt
+    {
+        $$ = &SetOpStmt{
+            op: IntersectSetOp,
+            distinct: $3,
+            left: $1.(DataSource),
+            right: $4.(DataSource),
+        }
+    }
 
 select_stmt: SELECT opt_distinct opt_selectors FROM ds opt_indexon opt_joins opt_where opt_groupby opt_having opt_orderby opt_limit opt_offset
     {
-        $$ = &SelectStmt{
+        stmt := &SelectStmt{
                 distinct: $2,
                 selectors: $3,
                 ds: $5,
                 indexOn: $6,
                 joins: $7,
                 where: $8,
-                groupBy: $9,
                 having: $10,
                 orderBy: $11,
-                limit: int($12),
-                offset: int($13),
+                limit: $12,
+                offset: $13,
+            }
+
+        stmt.setGroupBy($9)
+        stmt.nameAggregatedExps()
+        stmt.resolveGroupByOrdinals()
+
+        $$ = stmt
+    }
+|
+    WITH ctes SELECT opt_distinct opt_selectors FROM ds opt_indexon opt_joins opt_where opt_groupby opt_having opt_orderby opt_limit opt_offset
+    {
+        stmt := &SelectStmt{
+                ctes: $2,
+                distinct: $4,
+                selectors: $5,
+                ds: $7,
+                indexOn: $8,
+                joins: $9,
+                where: $10,
+                having: $12,
+                orderBy: $13,
+                limit: $14,
+                offset: $15,
             }
+
+        stmt.setGroupBy($11)
+        stmt.nameAggregatedExps()
+        stmt.resolveGroupByOrdinals()
+        stmt.inlineCTEs()
+
+        $$ = stmt
+    }
+
+ctes:
+    cte
+    {
+        $$ = []*CTE{$1}
+    }
+|
+    ctes ',' cte
+    {
+        $$ = append($1, $3)
+    }
+
+cte:
+    IDENTIFIER AS '(' dqlstmt ')'
+    {
+        $$ = &CTE{name: $1, ds: $4.(DataSource)}
     }
 
 opt_all:
@@ -534,16 +771,14 @@ opt_selectors:
     }
 
 selectors:
-    selector opt_as
+    exp opt_as
     {
-        $1.setAlias($2)
-        $$ = []Selector{$1}
+        $$ = []Selector{newSelector($1, $2)}
     }
 |
-    selectors ',' selector opt_as
+    selectors ',' exp opt_as
     {
-        $3.setAlias($4)
-        $$ = append($1, $3)
+        $$ = append($1, newSelector($3, $4))
     }
 
 selector:
@@ -557,9 +792,9 @@ selector:
         $$ = &AggColSelector{aggFn: $1, col: "*"}
     }
 |
-    AGGREGATE_FUNC '(' col ')'
+    AGGREGATE_FUNC '(' exp ')'
     {
-        $$ = &AggColSelector{aggFn: $1, db: $3.db, table: $3.table, col: $3.col}
+        $$ = newAggColSelector($1, $3)
     }
 
 col:
@@ -574,16 +809,25 @@ col:
     }
 
 ds:
-    tableRef opt_period opt_as
+    tableRef opt_period opt_history opt_as opt_tablesample
     {
         $1.period = $2
-        $1.as = $3
+        $1.history = $3
+        $1.as = $4
+        $1.sample = $5
         $$ = $1
     }
 |
     '(' dqlstmt ')' opt_as
     {
-        $2.(*SelectStmt).as = $4
+        switch ds := $2.(type) {
+        case *SelectStmt:
+            ds.as = $4
+        case *UnionStmt:
+            ds.as = $4
+        case *SetOpStmt:
+            ds.as = $4
+        }
         $$ = $2.(DataSource)
     }
 |
@@ -597,6 +841,57 @@ tableRef:
     {
         $$ = &tableRef{table: $1}
     }
+|
+    IDENTIFIER '.' IDENTIFIER
+    {
+        $$ = &tableRef{db: $1, table: $3}
+    }
+
+opt_history:
+    {
+        $$ = false
+    }
+|
+    HISTORY
+    {
+        $$ = true
+    }
+
+opt_tablesample:
+    {
+        $$ = nil
+    }
+|
+    TABLESAMPLE opt_bernoulli '(' exp opt_percent ')' opt_repeatable
+    {
+        $$ = &tableSample{percentage: $4, seed: $7}
+    }
+
+opt_bernoulli:
+    {
+    }
+|
+    BERNOULLI
+    {
+    }
+
+opt_percent:
+    {
+    }
+|
+    PERCENT
+    {
+    }
+
+opt_repeatable:
+    {
+        $$ = nil
+    }
+|
+    REPEATABLE '(' exp ')'
+    {
+        $$ = $3
+    }
 
 opt_period:
     opt_period_start opt_period_end
@@ -671,6 +966,19 @@ join:
     {
         $$ = &JoinSpec{joinType: $1, ds: $3, indexOn: $4, cond: $6}
     }
+|
+    opt_join_type JOIN LATERAL '(' dqlstmt ')' opt_as ON exp
+    {
+        switch ds := $5.(type) {
+        case *SelectStmt:
+            ds.as = $7
+        case *UnionStmt:
+            ds.as = $7
+        case *SetOpStmt:
+            ds.as = $7
+        }
+        $$ = &JoinSpec{joinType: $1, ds: $5.(DataSource), lateral: true, cond: $9}
+    }
 
 opt_join_type:
     {
@@ -697,9 +1005,73 @@ opt_groupby:
         $$ = nil
     }
 |
-    GROUP BY cols
+    GROUP BY groupcols
     {
-        $$ = $3
+        $$ = &groupByClause{cols: $3}
+    }
+|
+    GROUP BY ROLLUP '(' groupcols ')'
+    {
+        $$ = &groupByClause{kind: rollupGroupBy, cols: $5}
+    }
+|
+    GROUP BY CUBE '(' groupcols ')'
+    {
+        $$ = &groupByClause{kind: cubeGroupBy, cols: $5}
+    }
+|
+    GROUP BY GROUPING SETS '(' grouping_sets ')'
+    {
+        $$ = &groupByClause{kind: groupingSetsGroupBy, sets: $6}
+    }
+
+grouping_sets:
+    grouping_set
+    {
+        $$ = [][]*ColSelector{$1}
+    }
+|
+    grouping_sets ',' grouping_set
+    {
+        $$ = append($1, $3)
+    }
+
+grouping_set:
+    groupcol
+    {
+        $$ = []*ColSelector{$1}
+    }
+|
+    '(' ')'
+    {
+        $$ = []*ColSelector{}
+    }
+|
+    '(' groupcols ')'
+    {
+        $$ = $2
+    }
+
+groupcols:
+    groupcol
+    {
+        $$ = []*ColSelector{$1}
+    }
+|
+    groupcols ',' groupcol
+    {
+        $$ = append($1, $3)
+    }
+
+groupcol:
+    col
+    {
+        $$ = $1
+    }
+|
+    NUMBER
+    {
+        $$ = &ColSelector{ordinal: int($1)}
     }
 
 opt_having:
@@ -714,20 +1086,20 @@ opt_having:
 
 opt_limit:
     {
-        $$ = 0
+        $$ = nil
     }
 |
-    LIMIT NUMBER
+    LIMIT exp
     {
         $$ = $2
     }
 
 opt_offset:
     {
-        $$ = 0
+        $$ = nil
     }
 |
-    OFFSET NUMBER
+    OFFSET exp
     {
         $$ = $2
     }
@@ -753,14 +1125,44 @@ opt_indexon:
     }
 
 ordcols:
-    col opt_ord
+    col opt_collate opt_ord opt_nulls
+    {
+        $$ = []*OrdCol{{sel: $1, collation: collationByName($2), descOrder: $3, nulls: nullsOrderByName($4)}}
+    }
+|
+    ordcols ',' col opt_collate opt_ord opt_nulls
+    {
+        $$ = append($1, &OrdCol{sel: $3, collation: collationByName($4), descOrder: $5, nulls: nullsOrderByName($6)})
+    }
+
+opt_collate:
+    {
+        $$ = ""
+    }
+|
+    COLLATE IDENTIFIER
     {
-        $$ = []*OrdCol{{sel: $1, descOrder: $2}}
+        $$ = $2
+    }
+
+opt_nulls:
+    {
+        $$ = ""
+    }
+|
+    NULLS IDENTIFIER
+    {
+        $$ = $2
+    }
+
+opt_sort_key:
+    {
+        $$ = nil
     }
 |
-    ordcols ',' col opt_ord
+    SORT BY IDENTIFIER opt_ord
     {
-        $$ = append($1, &OrdCol{sel: $3, descOrder: $4})
+        $$ = &OrdCol{sel: &ColSelector{col: $3}, descOrder: $4}
     }
 
 opt_ord:
@@ -813,11 +1215,32 @@ exp:
     {
         $$ = &NumExp{left: &Number{val: 0}, op: SUBSOP, right: $2}
     }
+|
+    '~' exp
+    {
+        // bitwise NOT is computed as a XOR with all bits set, i.e. with -1
+        $$ = &NumExp{left: $2, op: BITXOROP, right: &NumExp{left: &Number{val: 0}, op: SUBSOP, right: &Number{val: 1}}}
+    }
 |
     boundexp opt_not LIKE exp
     {
         $$ = &LikeBoolExp{val: $1, notLike: $2, pattern: $4}
     }
+|
+    boundexp opt_not REGEXP exp
+    {
+        $$ = newRegexpBoolExp($1, $2, $4, false)
+    }
+|
+    boundexp opt_not MATCH exp
+    {
+        $$ = newRegexpBoolExp($1, $2, $4, false)
+    }
+|
+    boundexp opt_not IREGEXP exp
+    {
+        $$ = newRegexpBoolExp($1, $2, $4, true)
+    }
 |
     EXISTS '(' dqlstmt ')'
     {
@@ -833,6 +1256,11 @@ exp:
     {
         $$ = &InListExp{val: $1, notIn: $2, values: $5}
     }
+|
+    '(' exp ',' values ')' opt_not IN '(' tuples ')'
+    {
+        $$ = &TupleInListExp{vals: append([]ValueExp{$2}, $4...), notIn: $6, tuples: $9}
+    }
 
 boundexp:
     selector
@@ -849,6 +1277,37 @@ boundexp:
     {
         $$ = $2
     }
+|
+    CASE whens opt_else END
+    {
+        $$ = &CaseExp{whens: $2, elseExp: $3}
+    }
+|
+    GROUPING '(' col ')'
+    {
+        $$ = &GroupingExp{col: $3}
+    }
+
+whens:
+    WHEN exp THEN exp
+    {
+        $$ = []*whenThen{{cond: $2, result: $4}}
+    }
+|
+    whens WHEN exp THEN exp
+    {
+        $$ = append($1, &whenThen{cond: $3, result: $5})
+    }
+
+opt_else:
+    {
+        $$ = nil
+    }
+|
+    ELSE exp
+    {
+        $$ = $2
+    }
 
 opt_not:
     {
@@ -865,6 +1324,11 @@ binExp:
     {
         $$ = &NumExp{left: $1, op: ADDOP, right: $3}
     }
+|
+    exp CONCAT exp
+    {
+        $$ = &ConcatExp{left: $1, right: $3}
+    }
 |
     exp '-' exp
     {
@@ -880,6 +1344,31 @@ binExp:
     {
         $$ = &NumExp{left: $1, op: MULTOP, right: $3}
     }
+|
+    exp '&' exp
+    {
+        $$ = &NumExp{left: $1, op: BITANDOP, right: $3}
+    }
+|
+    exp '|' exp
+    {
+        $$ = &NumExp{left: $1, op: BITOROP, right: $3}
+    }
+|
+    exp '^' exp
+    {
+        $$ = &NumExp{left: $1, op: BITXOROP, right: $3}
+    }
+|
+    exp SHL exp
+    {
+        $$ = &NumExp{left: $1, op: SHLOP, right: $3}
+    }
+|
+    exp SHR exp
+    {
+        $$ = &NumExp{left: $1, op: SHROP, right: $3}
+    }
 |
     exp LOP exp
     {
@@ -890,6 +1379,16 @@ binExp:
     {
         $$ = &CmpBoolExp{left: $1, op: $2, right: $3}
     }
+|
+    exp CMPOP ANY '(' exp ')'
+    {
+        $$ = &CmpAnyBoolExp{op: $2, val: $1, arr: $5}
+    }
+|
+    exp CONTAINS exp
+    {
+        $$ = &ArrayContainsExp{left: $1, right: $3}
+    }
 |
     exp IS NULL
     {
@@ -900,3 +1399,13 @@ binExp:
     {
         $$ = &CmpBoolExp{left: $1, op: NE, right: &NullValue{t: AnyType}}
     }
+|
+    exp IS DISTINCT FROM exp %prec CMPOP
+    {
+        $$ = &DistinctFromBoolExp{left: $1, right: $5}
+    }
+|
+    exp IS NOT DISTINCT FROM exp %prec CMPOP
+    {
+        $$ = &DistinctFromBoolExp{left: $1, right: $6, negate: true}
+    }
@@ -20,6 +20,7 @@ type yySymType struct {
 	rows          []*RowSpec
 	row           *RowSpec
 	values        []ValueExp
+	tuples        [][]ValueExp
 	value         ValueExp
 	id            string
 	number        uint64
@@ -38,6 +39,7 @@ type yySymType struct {
 	period        period
 	openPeriod    *openPeriod
 	periodInstant periodInstant
+	tableSample   *tableSample
 	joins         []*JoinSpec
 	join          *JoinSpec
 	joinType      JoinType
@@ -45,6 +47,9 @@ type yySymType struct {
 	binExp        ValueExp
 	err           error
 	ordcols       []*OrdCol
+	ordcol        *OrdCol
+	ctes          []*CTE
+	cte           *CTE
 	opt_ord       bool
 	logicOp       LogicOperator
 	cmpOp         CmpOperator
@@ -52,6 +57,10 @@ type yySymType struct {
 	update        *colUpdate
 	updates       []*colUpdate
 	onConflict    *OnConflictDo
+	whens         []*whenThen
+	groupBy       *groupByClause
+	groupingSets  [][]*ColSelector
+	pos           int
 }
 
 const CREATE = 57346
@@ -94,41 +103,86 @@ const SELECT = 57382
 const DISTINCT = 57383
 const FROM = 57384
 const JOIN = 57385
-const HAVING = 57386
-const WHERE = 57387
-const GROUP = 57388
-const BY = 57389
-const LIMIT = 57390
-const OFFSET = 57391
-const ORDER = 57392
-const ASC = 57393
-const DESC = 57394
-const AS = 57395
-const UNION = 57396
-const ALL = 57397
-const NOT = 57398
-const LIKE = 57399
-const IF = 57400
-const EXISTS = 57401
-const IN = 57402
-const IS = 57403
-const AUTO_INCREMENT = 57404
-const NULL = 57405
-const CAST = 57406
-const NPARAM = 57407
-const PPARAM = 57408
-const JOINTYPE = 57409
-const LOP = 57410
-const CMPOP = 57411
-const IDENTIFIER = 57412
-const TYPE = 57413
-const NUMBER = 57414
-const VARCHAR = 57415
-const BOOLEAN = 57416
-const BLOB = 57417
-const AGGREGATE_FUNC = 57418
-const ERROR = 57419
-const STMT_SEPARATOR = 57420
+const LATERAL = 57386
+const HAVING = 57387
+const WHERE = 57388
+const GROUP = 57389
+const BY = 57390
+const LIMIT = 57391
+const OFFSET = 57392
+const ORDER = 57393
+const ASC = 57394
+const DESC = 57395
+const AS = 57396
+const UNION = 57397
+const EXCEPT = 57398
+const INTERSECT = 57399
+const ALL = 57400
+const NOT = 57401
+const LIKE = 57402
+const IF = 57403
+const EXISTS = 57404
+const IN = 57405
+const IS = 57406
+const CONCAT = 57407
+const TABLESAMPLE = 57408
+const BERNOULLI = 57409
+const PERCENT = 57410
+const REPEATABLE = 57411
+const SORT = 57412
+const HISTORY = 57413
+const AUTO_INCREMENT = 57414
+const NULL = 57415
+const CAST = 57416
+const EXTRACT = 57417
+const DEFAULT = 57418
+const CURRENT_DATE = 57419
+const CURRENT_TIME = 57420
+const CURRENT_TIMESTAMP = 57421
+const TRIM = 57422
+const LEADING = 57423
+const TRAILING = 57424
+const BOTH = 57425
+const POSITION = 57426
+const CASE = 57427
+const WHEN = 57428
+const THEN = 57429
+const ELSE = 57430
+const END = 57431
+const COLLATE = 57432
+const NULLS = 57433
+const ARRAY = 57434
+const ANY = 57435
+const CONTAINS = 57436
+const WITH = 57437
+const REGEXP = 57438
+const IREGEXP = 57439
+const MATCH = 57440
+const ROLLUP = 57441
+const CUBE = 57442
+const GROUPING = 57443
+const SETS = 57444
+const SHL = 57445
+const SHR = 57446
+const DEFERRABLE = 57447
+const INITIALLY = 57448
+const DEFERRED = 57449
+const IMMEDIATE = 57450
+const VIEW = 57451
+const NPARAM = 57452
+const PPARAM = 57453
+const JOINTYPE = 57454
+const LOP = 57455
+const CMPOP = 57456
+const IDENTIFIER = 57457
+const TYPE = 57458
+const NUMBER = 57459
+const VARCHAR = 57460
+const BOOLEAN = 57461
+const BLOB = 57462
+const AGGREGATE_FUNC = 57463
+const ERROR = 57464
+const STMT_SEPARATOR = 57465
 
 var yyToknames = [...]string{
 	"$end",
@@ -174,6 +228,7 @@ var yyToknames = [...]string{
 	"DISTINCT",
 	"FROM",
 	"JOIN",
+	"LATERAL",
 	"HAVING",
 	"WHERE",
 	"GROUP",
@@ -185,6 +240,8 @@ var yyToknames = [...]string{
 	"DESC",
 	"AS",
 	"UNION",
+	"EXCEPT",
+	"INTERSECT",
 	"ALL",
 	"NOT",
 	"LIKE",
@@ -192,9 +249,51 @@ var yyToknames = [...]string{
 	"EXISTS",
 	"IN",
 	"IS",
+	"CONCAT",
+	"TABLESAMPLE",
+	"BERNOULLI",
+	"PERCENT",
+	"REPEATABLE",
+	"SORT",
+	"HISTORY",
 	"AUTO_INCREMENT",
 	"NULL",
 	"CAST",
+	"EXTRACT",
+	"DEFAULT",
+	"CURRENT_DATE",
+	"CURRENT_TIME",
+	"CURRENT_TIMESTAMP",
+	"TRIM",
+	"LEADING",
+	"TRAILING",
+	"BOTH",
+	"POSITION",
+	"CASE",
+	"WHEN",
+	"THEN",
+	"ELSE",
+	"END",
+	"COLLATE",
+	"NULLS",
+	"ARRAY",
+	"ANY",
+	"CONTAINS",
+	"WITH",
+	"REGEXP",
+	"IREGEXP",
+	"MATCH",
+	"ROLLUP",
+	"CUBE",
+	"GROUPING",
+	"SETS",
+	"SHL",
+	"SHR",
+	"DEFERRABLE",
+	"INITIALLY",
+	"DEFERRED",
+	"IMMEDIATE",
+	"VIEW",
 	"NPARAM",
 	"PPARAM",
 	"JOINTYPE",
@@ -209,10 +308,14 @@ var yyToknames = [...]string{
 	"AGGREGATE_FUNC",
 	"ERROR",
 	"','",
+	"'|'",
+	"'^'",
+	"'&'",
 	"'+'",
 	"'-'",
 	"'*'",
 	"'/'",
+	"'~'",
 	"'.'",
 	"STMT_SEPARATOR",
 	"'('",
@@ -231,218 +334,437 @@ var yyExca = [...]int{
 	-1, 1,
 	1, -1,
 	-2, 0,
-	-1, 74,
-	57, 137,
-	60, 137,
-	-2, 126,
-	-1, 186,
-	43, 102,
-	-2, 97,
-	-1, 215,
-	43, 102,
-	-2, 99,
+	-1, 73,
+	60, 209,
+	63, 209,
+	96, 209,
+	97, 209,
+	98, 209,
+	-2, 187,
+	-1, 265,
+	43, 145,
+	-2, 139,
+	-1, 320,
+	43, 145,
+	-2, 141,
+	-1, 376,
+	43, 145,
+	-2, 139,
 }
 
 const yyPrivate = 57344
 
-const yyLast = 373
+const yyLast = 1635
 
 var yyAct = [...]int{
-	73, 292, 60, 180, 138, 208, 232, 236, 87, 135,
-	144, 172, 214, 105, 231, 97, 173, 155, 45, 6,
-	79, 265, 100, 178, 223, 203, 18, 178, 178, 178,
-	274, 269, 72, 251, 268, 249, 224, 179, 237, 148,
-	252, 250, 76, 219, 202, 78, 200, 192, 191, 90,
-	86, 88, 89, 238, 146, 233, 91, 59, 82, 83,
-	84, 85, 61, 177, 199, 109, 77, 131, 196, 131,
-	157, 81, 130, 116, 102, 128, 76, 126, 127, 78,
-	111, 108, 129, 90, 86, 88, 89, 96, 95, 20,
-	91, 109, 82, 83, 84, 85, 61, 62, 248, 140,
-	77, 291, 62, 61, 98, 81, 137, 285, 57, 123,
-	235, 152, 147, 151, 254, 141, 121, 122, 159, 160,
-	161, 162, 163, 164, 203, 149, 255, 117, 118, 120,
-	119, 171, 174, 193, 170, 123, 178, 142, 104, 62,
-	210, 228, 194, 201, 185, 61, 183, 62, 169, 186,
-	175, 123, 136, 117, 118, 120, 119, 230, 121, 122,
-	143, 189, 107, 190, 187, 184, 188, 195, 198, 117,
-	118, 120, 119, 206, 76, 27, 28, 78, 217, 106,
-	101, 90, 86, 88, 89, 220, 254, 212, 91, 123,
-	82, 83, 84, 85, 61, 176, 121, 122, 77, 156,
-	174, 218, 158, 81, 229, 123, 225, 117, 118, 120,
-	119, 221, 153, 122, 71, 123, 227, 150, 239, 226,
-	112, 65, 234, 117, 118, 120, 119, 63, 241, 240,
-	34, 156, 49, 243, 174, 120, 119, 44, 264, 26,
-	247, 197, 166, 263, 123, 256, 110, 246, 257, 165,
-	40, 147, 261, 260, 167, 125, 64, 168, 55, 35,
-	277, 266, 209, 92, 181, 273, 293, 294, 284, 272,
-	259, 39, 278, 98, 271, 280, 10, 11, 242, 103,
-	283, 32, 286, 37, 114, 115, 18, 289, 290, 287,
-	282, 12, 275, 267, 295, 41, 42, 296, 7, 53,
-	8, 9, 13, 14, 145, 31, 15, 16, 207, 205,
-	30, 21, 18, 244, 133, 67, 132, 204, 93, 94,
-	2, 33, 22, 281, 211, 113, 66, 182, 43, 29,
-	139, 23, 25, 24, 19, 50, 51, 52, 70, 69,
-	253, 38, 47, 48, 99, 124, 245, 262, 276, 288,
-	222, 258, 75, 74, 270, 216, 215, 213, 68, 46,
-	54, 36, 58, 56, 80, 279, 134, 154, 17, 5,
-	4, 3, 1,
+	232, 510, 494, 487, 84, 482, 428, 435, 431, 257,
+	309, 231, 65, 442, 423, 391, 401, 81, 6, 350,
+	378, 354, 413, 127, 318, 190, 92, 181, 230, 149,
+	184, 319, 349, 247, 57, 69, 405, 188, 73, 382,
+	289, 72, 345, 394, 95, 90, 101, 96, 97, 98,
+	99, 102, 22, 23, 24, 103, 82, 161, 491, 381,
+	121, 121, 148, 91, 226, 107, 437, 108, 109, 22,
+	23, 24, 83, 422, 101, 455, 151, 152, 153, 102,
+	155, 93, 94, 103, 502, 134, 100, 513, 86, 87,
+	88, 89, 85, 410, 22, 23, 24, 290, 22, 23,
+	24, 143, 144, 501, 370, 243, 22, 23, 24, 507,
+	291, 101, 286, 285, 194, 500, 102, 148, 121, 121,
+	103, 177, 141, 142, 140, 135, 137, 139, 138, 186,
+	254, 481, 460, 192, 195, 514, 196, 197, 198, 199,
+	200, 201, 202, 203, 204, 205, 206, 207, 209, 366,
+	226, 194, 437, 455, 183, 455, 143, 144, 224, 193,
+	228, 229, 443, 225, 462, 485, 237, 484, 72, 489,
+	192, 473, 255, 218, 325, 290, 463, 236, 300, 140,
+	135, 137, 139, 138, 411, 290, 279, 383, 245, 148,
+	136, 233, 290, 251, 162, 290, 21, 367, 290, 355,
+	68, 255, 162, 244, 346, 242, 268, 331, 269, 163,
+	293, 267, 252, 256, 274, 275, 276, 277, 356, 457,
+	163, 265, 162, 282, 283, 456, 266, 440, 143, 144,
+	420, 280, 417, 351, 304, 278, 270, 249, 171, 296,
+	169, 166, 148, 136, 298, 165, 164, 160, 159, 141,
+	142, 140, 135, 137, 139, 138, 148, 158, 154, 311,
+	126, 303, 125, 68, 316, 263, 148, 262, 386, 455,
+	104, 326, 147, 385, 328, 301, 290, 255, 133, 342,
+	334, 143, 144, 264, 313, 333, 302, 516, 315, 193,
+	330, 335, 146, 336, 67, 337, 338, 329, 340, 314,
+	499, 226, 141, 142, 140, 135, 137, 139, 138, 480,
+	226, 353, 437, 32, 33, 182, 347, 322, 348, 135,
+	137, 139, 138, 317, 189, 341, 193, 307, 365, 119,
+	253, 139, 138, 369, 248, 343, 362, 250, 363, 235,
+	374, 352, 359, 234, 357, 358, 148, 136, 261, 172,
+	44, 131, 360, 105, 130, 66, 115, 112, 110, 390,
+	39, 368, 61, 56, 148, 451, 450, 389, 26, 426,
+	248, 43, 376, 385, 407, 40, 147, 27, 29, 28,
+	387, 458, 511, 483, 388, 143, 144, 393, 193, 157,
+	122, 18, 148, 281, 424, 145, 146, 403, 402, 400,
+	412, 399, 404, 143, 144, 408, 141, 142, 140, 135,
+	137, 139, 138, 324, 446, 430, 213, 519, 506, 217,
+	436, 425, 31, 421, 416, 142, 140, 135, 137, 139,
+	138, 143, 144, 432, 433, 434, 439, 444, 453, 459,
+	449, 461, 447, 222, 464, 223, 19, 175, 176, 226,
+	212, 437, 214, 216, 215, 135, 137, 139, 138, 469,
+	436, 436, 436, 470, 467, 471, 472, 465, 211, 380,
+	272, 30, 479, 474, 419, 148, 477, 168, 436, 396,
+	167, 488, 210, 379, 397, 498, 497, 297, 170, 493,
+	492, 504, 51, 150, 436, 22, 23, 24, 503, 24,
+	111, 47, 271, 509, 508, 512, 436, 75, 174, 488,
+	78, 106, 515, 495, 496, 517, 50, 518, 429, 310,
+	258, 95, 90, 101, 96, 97, 98, 99, 102, 239,
+	240, 241, 103, 82, 466, 454, 415, 392, 128, 414,
+	91, 361, 327, 299, 52, 53, 292, 55, 273, 83,
+	132, 18, 37, 41, 452, 427, 409, 129, 93, 94,
+	308, 191, 306, 100, 36, 86, 87, 88, 89, 85,
+	75, 114, 35, 78, 25, 377, 76, 46, 38, 77,
+	179, 178, 79, 305, 95, 90, 101, 96, 97, 98,
+	99, 102, 123, 124, 490, 103, 82, 62, 63, 64,
+	438, 48, 49, 91, 312, 2, 19, 173, 113, 259,
+	54, 34, 83, 118, 117, 185, 295, 59, 60, 475,
+	418, 93, 94, 20, 384, 187, 100, 45, 86, 87,
+	88, 89, 85, 75, 406, 323, 78, 445, 42, 76,
+	468, 238, 77, 344, 74, 79, 156, 95, 90, 101,
+	96, 97, 98, 99, 102, 221, 321, 320, 103, 82,
+	505, 395, 116, 58, 260, 71, 91, 80, 441, 486,
+	180, 246, 17, 5, 4, 83, 3, 1, 0, 0,
+	0, 0, 0, 0, 93, 94, 0, 0, 0, 100,
+	0, 86, 87, 88, 89, 85, 75, 0, 0, 78,
+	0, 0, 76, 0, 0, 77, 0, 0, 79, 0,
+	95, 90, 101, 96, 97, 98, 99, 102, 0, 0,
+	0, 103, 82, 0, 0, 0, 0, 0, 0, 91,
+	0, 0, 0, 0, 0, 0, 0, 0, 83, 0,
+	0, 0, 0, 0, 0, 0, 0, 93, 94, 0,
+	0, 0, 100, 0, 86, 87, 88, 89, 85, 75,
+	0, 0, 78, 0, 0, 76, 70, 0, 77, 0,
+	0, 79, 0, 95, 90, 101, 96, 97, 98, 99,
+	102, 0, 0, 0, 103, 82, 0, 0, 0, 0,
+	0, 0, 91, 0, 0, 0, 0, 0, 0, 0,
+	0, 83, 0, 0, 0, 0, 0, 0, 0, 0,
+	93, 94, 0, 0, 0, 100, 0, 86, 87, 88,
+	89, 85, 75, 0, 0, 78, 0, 0, 76, 227,
+	0, 77, 0, 0, 79, 0, 95, 90, 101, 96,
+	97, 98, 99, 102, 0, 120, 0, 103, 82, 0,
+	0, 0, 0, 0, 0, 91, 208, 0, 0, 0,
+	0, 0, 0, 0, 83, 0, 0, 0, 0, 0,
+	0, 0, 0, 93, 94, 0, 0, 0, 100, 0,
+	86, 87, 88, 89, 85, 0, 0, 0, 0, 0,
+	0, 76, 75, 0, 77, 78, 0, 79, 0, 0,
+	0, 0, 0, 0, 0, 0, 95, 90, 101, 96,
+	97, 98, 99, 102, 0, 0, 0, 103, 82, 0,
+	0, 0, 0, 0, 0, 91, 0, 0, 0, 0,
+	0, 0, 0, 0, 83, 0, 0, 0, 0, 0,
+	0, 0, 0, 93, 94, 0, 0, 0, 100, 0,
+	86, 87, 88, 89, 85, 75, 0, 0, 78, 0,
+	0, 76, 0, 0, 77, 0, 0, 79, 0, 95,
+	90, 101, 96, 97, 98, 99, 102, 0, 0, 0,
+	103, 82, 148, 136, 0, 0, 0, 0, 91, 0,
+	0, 0, 0, 0, 0, 0, 0, 83, 0, 0,
+	0, 0, 0, 0, 0, 0, 93, 94, 0, 0,
+	0, 100, 147, 86, 87, 88, 89, 85, 0, 148,
+	136, 143, 144, 0, 76, 0, 0, 77, 0, 0,
+	79, 145, 146, 0, 0, 0, 0, 148, 136, 0,
+	0, 219, 141, 142, 140, 135, 137, 139, 138, 147,
+	0, 0, 0, 220, 0, 0, 0, 0, 143, 144,
+	0, 0, 0, 0, 0, 148, 136, 147, 145, 146,
+	0, 0, 0, 0, 0, 0, 143, 144, 0, 141,
+	142, 140, 135, 137, 139, 138, 145, 146, 0, 0,
+	398, 0, 0, 148, 136, 147, 0, 141, 142, 140,
+	135, 137, 139, 138, 143, 144, 0, 0, 375, 0,
+	0, 148, 136, 0, 145, 146, 0, 0, 0, 0,
+	0, 0, 0, 147, 0, 141, 142, 140, 135, 137,
+	139, 138, 143, 144, 0, 0, 373, 0, 0, 148,
+	136, 147, 145, 146, 0, 0, 0, 0, 0, 0,
+	143, 144, 0, 141, 142, 140, 135, 137, 139, 138,
+	145, 146, 0, 0, 372, 0, 0, 148, 136, 147,
+	0, 141, 142, 140, 135, 137, 139, 138, 143, 144,
+	0, 0, 371, 0, 0, 148, 136, 0, 145, 146,
+	0, 0, 0, 0, 0, 0, 0, 147, 0, 141,
+	142, 140, 135, 137, 139, 138, 143, 144, 0, 0,
+	364, 0, 67, 0, 0, 147, 145, 146, 0, 0,
+	0, 0, 148, 136, 143, 144, 0, 141, 142, 140,
+	135, 137, 139, 138, 145, 146, 0, 0, 220, 0,
+	148, 136, 0, 0, 0, 141, 142, 140, 135, 137,
+	139, 138, 147, 0, 0, 0, 287, 0, 0, 0,
+	0, 143, 144, 0, 0, 0, 0, 0, 0, 0,
+	147, 145, 146, 66, 0, 0, 0, 148, 136, 143,
+	144, 476, 141, 142, 140, 135, 137, 139, 138, 145,
+	146, 0, 339, 0, 0, 0, 0, 0, 0, 478,
+	141, 142, 140, 135, 137, 139, 138, 147, 0, 0,
+	0, 0, 0, 0, 148, 136, 143, 144, 0, 0,
+	0, 0, 148, 136, 0, 0, 145, 146, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 141, 142, 140,
+	135, 137, 139, 138, 147, 332, 294, 0, 0, 0,
+	0, 0, 147, 143, 144, 0, 0, 0, 0, 0,
+	0, 143, 144, 145, 146, 0, 0, 0, 148, 136,
+	0, 145, 146, 0, 141, 142, 140, 135, 137, 139,
+	138, 0, 141, 142, 140, 135, 137, 139, 138, 0,
+	0, 0, 0, 0, 0, 288, 0, 0, 147, 0,
+	0, 0, 0, 0, 0, 148, 136, 143, 144, 0,
+	0, 0, 0, 148, 136, 0, 0, 145, 146, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 141, 142,
+	140, 135, 137, 139, 138, 147, 284, 0, 0, 0,
+	0, 0, 0, 147, 143, 144, 0, 0, 0, 0,
+	148, 136, 143, 144, 145, 146, 0, 0, 0, 0,
+	0, 0, 145, 146, 0, 141, 142, 140, 135, 137,
+	139, 138, 0, 141, 142, 140, 135, 137, 139, 138,
+	147, 0, 0, 0, 0, 0, 0, 0, 0, 143,
+	144, 0, 0, 0, 0, 0, 0, 0, 0, 145,
+	146, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	141, 142, 140, 135, 137, 139, 138, 95, 90, 101,
+	96, 97, 98, 99, 102, 0, 0, 0, 103, 0,
+	0, 0, 0, 0, 0, 0, 91, 0, 0, 0,
+	0, 0, 0, 10, 11, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 93, 94, 0, 0, 12, 448,
+	0, 86, 87, 88, 89, 7, 0, 8, 9, 13,
+	14, 0, 0, 15, 16, 0, 0, 0, 0, 18,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 19,
 }
 
 var yyPact = [...]int{
-	272, -1000, -1000, 5, -1000, -1000, -1000, 284, -1000, -1000,
-	316, 169, 314, 278, 273, 239, 160, 205, 242, -1000,
-	272, -1000, 192, 192, 192, 311, -1000, 167, 334, 162,
-	160, 160, 160, 263, -1000, 203, 27, -1000, -1000, 157,
-	200, 151, 308, 192, -1000, -1000, 328, 20, 20, 298,
-	3, 2, 228, 110, 246, -1000, 237, -1000, 60, 109,
-	-1000, -4, 8, -1000, 187, -5, 150, 307, -1000, 20,
-	20, -1000, 118, 128, 199, -1000, 118, 118, -10, -1000,
-	-1000, 118, -1000, -1000, -1000, -1000, -13, -1000, -1000, -1000,
-	-1000, -18, -1000, 293, 291, 82, 82, 325, 118, 59,
-	-1000, 91, -1000, -31, 69, -1000, -1000, 147, 32, 142,
-	-1000, 129, -15, 132, -1000, -1000, 128, 118, 118, 118,
-	118, 118, 118, 186, 197, -1000, 144, 154, 246, 48,
-	118, 118, 129, 125, -23, 58, -1000, -49, 216, 310,
-	128, 325, 110, 118, 325, 334, 246, 109, -16, 109,
-	-1000, -38, -39, -1000, 55, -1000, 71, 82, -17, 154,
-	154, 183, 183, 144, 74, -1000, 178, 118, -21, -40,
-	-1000, 90, -42, 46, 128, -1000, 295, 276, 103, 275,
-	213, 68, 306, 216, -1000, 128, 111, 109, -43, -1000,
-	-1000, -1000, -1000, 161, -63, -50, 82, -1000, 144, -14,
-	-1000, 70, -1000, 118, 87, -30, -1000, -30, -1000, 38,
-	-1000, -32, 213, 228, -1000, 111, 235, -1000, -1000, 109,
-	288, -1000, 184, 26, -1000, -51, -45, -53, -46, 128,
-	-1000, 108, -1000, 118, 36, -1000, -1000, -1000, 82, -1000,
-	224, -1000, -31, -1000, -32, 181, -1000, 175, -67, -1000,
-	-1000, -1000, -1000, -1000, -30, 256, -52, -55, 230, 222,
-	325, -56, -1000, -1000, -1000, -1000, -1000, 254, -1000, -1000,
-	210, 118, 77, 305, -1000, 251, 216, 221, 128, 29,
-	-1000, 118, -1000, 213, 77, 77, 128, -1000, 23, 215,
-	-1000, 77, -1000, -1000, -1000, 215, -1000,
+	1539, -1000, -1000, 63, -1000, -1000, 440, 547, -1000, -1000,
+	362, 307, 596, 540, 532, 510, 245, -1000, 512, 235,
+	-1000, 1539, 443, 443, 443, -1000, 431, 431, 431, 593,
+	431, -1000, 248, 609, 247, 245, 245, 245, 240, 131,
+	637, -1000, 230, -1000, 457, -1000, 351, -1000, 351, 351,
+	243, 441, 242, 590, 431, 241, -1000, -1000, 603, 833,
+	833, 572, 128, 126, 492, 521, -1000, 239, 236, 508,
+	-1000, 155, 1158, 434, -1000, 896, 896, 896, 124, 896,
+	-1000, -1000, 303, 123, -1000, 114, -1000, -1000, -1000, -1000,
+	113, -79, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	88, 112, 111, 107, 512, 235, 106, 442, 442, -1000,
+	-1000, 426, 104, 234, 589, 454, -1000, 833, 833, -1000,
+	896, 1386, -1000, 558, 557, 200, 200, 610, 896, 209,
+	-1000, -1000, 36, 896, -1000, 896, 896, 896, 896, 896,
+	896, 896, 896, 896, 896, 896, 763, 896, 409, 356,
+	-1000, 178, 202, 411, 351, 918, 357, 896, 186, 700,
+	896, 896, 896, 228, 224, 448, -29, 637, -1000, 351,
+	-1000, 219, 103, 222, 351, -1000, -1000, 1386, 219, 215,
+	-5, 154, -1000, 78, 471, 592, 1386, 225, -1000, 151,
+	610, 609, 351, 240, 68, 1158, 202, -2, 202, 411,
+	411, 328, 300, 53, 192, 192, 178, 125, 102, 125,
+	-1000, 429, 506, 896, 896, 896, 896, 101, 51, 896,
+	-1000, 304, 896, 896, 1349, -22, 77, -23, 1121, 1341,
+	-97, 153, 1386, -25, -1000, 504, 75, 1304, 574, -1000,
+	-1000, -1000, 424, 896, 501, 43, 152, -1000, 170, 896,
+	100, 440, -1000, 561, 529, 212, 527, 469, 896, 586,
+	492, 209, 36, 896, 208, 205, 342, 39, -1000, -1000,
+	896, -1000, 500, 896, 178, 178, 178, 178, 511, -1000,
+	72, -1000, 1258, 1386, 896, -1000, -1000, -1000, 164, -1000,
+	896, -1000, 896, -1000, 896, 896, 1250, 896, 1103, 36,
+	-1000, 255, -94, 69, 896, 203, 99, -1000, 99, -1000,
+	896, 1386, 84, 610, -1000, -1000, 1386, 231, 492, -1000,
+	205, 498, -1000, 240, -1000, 240, 1075, 896, 125, 14,
+	62, 434, 896, 1386, -31, 1386, 1047, 1029, 1001, 896,
+	973, 610, 550, -1000, 410, -78, -1000, 52, -1000, 250,
+	-1000, 896, 150, 1386, -1000, -1000, 200, 471, 896, 490,
+	-1000, -1, 413, -1000, -1000, 125, -1000, -1000, 421, 1386,
+	-1000, -1000, -1000, -1000, 955, -1000, 205, 84, 326, -1000,
+	324, 410, -101, 269, -1000, 99, 519, -42, 49, 469,
+	1386, 494, 488, 610, 98, -1000, 407, 96, -1000, 492,
+	-62, 318, -1000, -1000, 326, -1000, -1000, 263, -1000, 517,
+	-1000, -1000, -1000, 467, 896, 334, 582, 351, 93, -1000,
+	28, 490, 344, -1000, 1444, 318, 258, 515, 471, 487,
+	1386, 146, 91, 85, 279, -1000, -1000, -1000, 896, -3,
+	896, 41, -1000, 896, 494, -1000, 486, -1000, 60, -1000,
+	-1000, -1000, -1000, 469, 186, 195, 195, 195, 37, 1386,
+	240, 1213, 28, -1000, 1176, 467, 194, -1000, 8, 293,
+	-1000, 32, 30, 35, 576, -77, -1000, -1000, 896, 471,
+	461, 186, 461, 185, -1000, -1000, -20, -1000, -1000, -51,
+	896, 349, -26, 469, -1000, -1000, -1000, 293, 291, -1000,
+	-1000, 35, -1000, -48, 1386, -1000, 1, -1000, -1000, 461,
+	-1000, 172, -1000, -1000, 896, 291, -1000, 282, -1000, -1000,
 }
 
 var yyPgo = [...]int{
-	0, 372, 320, 371, 370, 369, 19, 368, 367, 17,
-	9, 7, 366, 365, 14, 6, 16, 11, 364, 8,
-	20, 363, 362, 2, 361, 360, 10, 304, 18, 359,
-	358, 214, 357, 12, 356, 355, 0, 15, 354, 353,
-	352, 351, 3, 5, 350, 13, 349, 348, 1, 4,
-	271, 347, 346, 345, 22, 344, 340, 334,
+	0, 677, 605, 676, 674, 673, 18, 672, 671, 33,
+	27, 21, 670, 8, 3, 669, 32, 19, 11, 28,
+	13, 668, 17, 26, 667, 35, 665, 4, 7, 375,
+	577, 25, 664, 561, 34, 663, 662, 329, 661, 660,
+	24, 31, 657, 656, 0, 23, 22, 38, 9, 10,
+	655, 14, 646, 644, 15, 643, 12, 5, 1, 641,
+	640, 6, 638, 371, 637, 2, 30, 516, 16, 20,
+	29, 635, 634, 37, 625, 624, 623, 620, 619,
 }
 
 var yyR1 = [...]int{
-	0, 1, 2, 2, 57, 57, 3, 3, 3, 4,
+	0, 1, 2, 2, 76, 76, 3, 3, 3, 4,
 	4, 4, 4, 4, 4, 4, 4, 4, 4, 4,
-	4, 4, 50, 50, 11, 11, 5, 5, 5, 5,
-	56, 56, 55, 55, 54, 12, 12, 14, 14, 15,
-	10, 10, 13, 13, 17, 17, 16, 16, 18, 18,
-	18, 18, 18, 18, 18, 18, 18, 19, 8, 8,
-	9, 44, 44, 51, 51, 52, 52, 52, 6, 6,
-	7, 25, 25, 24, 24, 21, 21, 22, 22, 20,
-	20, 20, 23, 23, 26, 26, 26, 27, 28, 29,
-	29, 29, 30, 30, 30, 31, 31, 32, 32, 33,
-	33, 34, 35, 35, 37, 37, 41, 41, 38, 38,
-	42, 42, 43, 43, 47, 47, 49, 49, 46, 46,
-	48, 48, 48, 45, 45, 45, 36, 36, 36, 36,
-	36, 36, 36, 36, 39, 39, 39, 53, 53, 40,
-	40, 40, 40, 40, 40, 40, 40,
+	4, 4, 4, 67, 67, 72, 72, 72, 11, 11,
+	5, 5, 5, 5, 75, 75, 74, 74, 73, 73,
+	32, 32, 12, 12, 16, 16, 17, 10, 10, 19,
+	19, 18, 18, 21, 21, 20, 22, 22, 22, 22,
+	22, 22, 22, 22, 22, 22, 22, 22, 22, 22,
+	23, 23, 23, 23, 23, 23, 23, 59, 59, 59,
+	8, 8, 9, 9, 51, 51, 55, 55, 68, 68,
+	69, 69, 69, 6, 6, 6, 6, 7, 7, 62,
+	62, 63, 30, 30, 29, 29, 25, 25, 26, 26,
+	24, 24, 24, 27, 27, 31, 31, 31, 33, 33,
+	71, 71, 38, 38, 77, 77, 78, 78, 39, 39,
+	34, 35, 35, 35, 36, 36, 36, 37, 37, 40,
+	40, 41, 41, 42, 42, 43, 43, 45, 45, 54,
+	54, 54, 54, 54, 15, 15, 14, 14, 14, 13,
+	13, 28, 28, 46, 46, 48, 48, 49, 49, 61,
+	61, 66, 66, 60, 60, 57, 57, 58, 58, 64,
+	64, 65, 65, 65, 56, 56, 56, 44, 44, 44,
+	44, 44, 44, 44, 44, 44, 44, 44, 44, 44,
+	47, 47, 47, 47, 47, 52, 52, 50, 50, 70,
+	70, 53, 53, 53, 53, 53, 53, 53, 53, 53,
+	53, 53, 53, 53, 53, 53, 53, 53, 53,
 }
 
 var yyR2 = [...]int{
 	0, 1, 2, 3, 0, 1, 1, 1, 1, 2,
-	1, 1, 1, 4, 2, 3, 3, 11, 8, 9,
-	6, 8, 0, 3, 1, 3, 9, 8, 7, 8,
-	0, 4, 1, 3, 3, 0, 1, 1, 3, 3,
-	1, 3, 1, 3, 0, 1, 1, 3, 1, 1,
-	1, 1, 6, 1, 1, 1, 1, 4, 1, 3,
-	5, 0, 3, 0, 1, 0, 1, 2, 1, 4,
-	13, 0, 1, 0, 1, 1, 1, 2, 4, 1,
-	4, 4, 1, 3, 3, 4, 2, 1, 2, 0,
-	2, 2, 0, 2, 2, 2, 1, 0, 1, 1,
-	2, 6, 0, 1, 0, 2, 0, 3, 0, 2,
-	0, 2, 0, 2, 0, 3, 0, 4, 2, 4,
-	0, 1, 1, 0, 1, 2, 1, 1, 2, 2,
-	4, 4, 6, 6, 1, 1, 3, 0, 1, 3,
-	3, 3, 3, 3, 3, 3, 4,
+	1, 1, 1, 4, 2, 3, 3, 12, 8, 10,
+	6, 6, 8, 0, 3, 0, 3, 3, 1, 3,
+	9, 8, 7, 10, 0, 4, 1, 3, 3, 5,
+	0, 2, 0, 1, 1, 3, 3, 1, 3, 0,
+	1, 1, 3, 1, 3, 5, 1, 1, 1, 1,
+	6, 4, 1, 1, 1, 1, 1, 1, 1, 1,
+	4, 6, 4, 6, 6, 7, 6, 1, 1, 1,
+	1, 3, 6, 7, 0, 2, 0, 3, 0, 1,
+	0, 1, 2, 1, 4, 4, 4, 13, 15, 1,
+	3, 5, 0, 1, 0, 1, 1, 1, 2, 4,
+	1, 4, 4, 1, 3, 5, 4, 2, 1, 3,
+	0, 1, 0, 7, 0, 1, 0, 1, 0, 4,
+	2, 0, 2, 2, 0, 2, 2, 2, 1, 0,
+	1, 1, 2, 6, 9, 0, 1, 0, 2, 0,
+	3, 6, 6, 7, 1, 3, 1, 2, 3, 1,
+	3, 1, 1, 0, 2, 0, 2, 0, 2, 0,
+	3, 0, 4, 4, 6, 0, 2, 0, 2, 0,
+	4, 0, 1, 1, 0, 1, 2, 1, 1, 2,
+	2, 2, 4, 4, 4, 4, 4, 6, 6, 10,
+	1, 1, 3, 4, 4, 4, 5, 0, 2, 0,
+	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 6, 3, 3, 4, 5, 6,
 }
 
 var yyChk = [...]int{
 	-1000, -1, -2, -3, -4, -5, -6, 26, 28, 29,
-	4, 5, 19, 30, 31, 34, 35, -7, 40, -57,
-	84, 27, 6, 15, 17, 16, 70, 6, 7, 15,
-	32, 32, 42, -27, 70, 54, -24, 41, -2, -50,
-	58, -50, -50, 17, 70, -28, -29, 8, 9, 70,
-	-27, -27, -27, 36, -25, 55, -21, 81, -22, -20,
-	-23, 76, 70, 70, 56, 70, 18, -50, -30, 11,
-	10, -31, 12, -36, -39, -40, 56, 80, 59, -20,
-	-18, 85, 72, 73, 74, 75, 64, -19, 65, 66,
-	63, 70, -31, 20, 21, 85, 85, -37, 45, -55,
-	-54, 70, -6, 42, 78, -45, 70, 53, 85, 83,
-	59, 85, 70, 18, -31, -31, -36, 79, 80, 82,
-	81, 68, 69, 61, -53, 56, -36, -36, 85, -36,
-	85, 85, 23, 23, -12, -10, 70, -10, -49, 5,
-	-36, -37, 78, 69, -26, -27, 85, -19, 70, -20,
-	70, 81, -23, 70, -8, -9, 70, 85, 70, -36,
-	-36, -36, -36, -36, -36, 63, 56, 57, 60, -6,
-	86, -36, -17, -16, -36, -9, 70, 86, 78, 86,
-	-42, 48, 17, -49, -54, -36, -49, -28, -6, -45,
-	-45, 86, 86, 78, 71, -10, 85, 63, -36, 85,
-	86, 53, 86, 78, 22, 33, 70, 33, -43, 49,
-	72, 18, -42, -32, -33, -34, -35, 67, -45, 86,
-	24, -9, -44, 87, 86, -10, -6, -16, 71, -36,
-	70, -14, -15, 85, -14, 72, -11, 70, 85, -43,
-	-37, -33, 43, -45, 25, -52, 63, 56, 72, 86,
-	86, 86, 86, -56, 78, 18, -17, -10, -41, 46,
-	-26, -11, -51, 62, 63, 88, -15, 37, 86, 86,
-	-38, 44, 47, -49, 86, 38, -47, 50, -36, -13,
-	-23, 18, 39, -42, 47, 78, -36, -43, -46, -23,
-	-23, 78, -48, 51, 52, -23, -48,
+	4, 5, 19, 30, 31, 34, 35, -7, 40, 95,
+	-76, 133, 55, 56, 57, 27, 6, 15, 17, 16,
+	109, 115, 6, 7, 15, 32, 32, 42, -33, 115,
+	-29, 41, -62, -63, 115, -2, -30, 58, -30, -30,
+	-67, 61, -67, -67, 17, -67, 115, -34, -35, 8,
+	9, 115, -33, -33, -33, -56, 115, 54, 132, -25,
+	129, -26, -44, -47, -53, 59, 128, 131, 62, 134,
+	-24, -22, 85, 101, -27, 121, 117, 118, 119, 120,
+	74, 92, -23, 110, 111, 73, 76, 77, 78, 79,
+	115, 75, 80, 84, 40, 123, 54, -6, -6, -6,
+	115, 59, 115, 18, -67, 115, -36, 11, 10, -37,
+	12, -44, -37, 20, 21, 134, 134, -45, 46, 36,
+	115, 115, 42, 123, -56, 127, 65, 128, 130, 129,
+	126, 124, 125, 103, 104, 113, 114, 94, 64, -70,
+	59, -44, -44, -44, 134, -44, -52, 86, 134, 134,
+	134, 136, 134, 132, 134, 134, 134, -29, -63, 134,
+	62, 134, 115, 18, 54, -37, -37, -44, 23, 23,
+	-12, -10, 115, -10, -66, 5, -44, -74, -73, 115,
+	-31, -33, 134, -23, 115, -44, -44, -44, -44, -44,
+	-44, -44, -44, -44, -44, -44, -44, -44, 93, -44,
+	73, 59, 41, 60, 96, 98, 97, 63, -6, 123,
+	135, -50, 86, 88, -44, -27, 115, 129, -44, -44,
+	-19, -18, -44, -19, 115, 115, -18, -44, -59, 81,
+	82, 83, -47, 134, -25, -6, -8, -9, 115, 134,
+	115, -6, -9, 115, 135, 123, 135, -48, 49, 17,
+	-32, 123, 42, 114, 132, -66, -34, -6, -56, -56,
+	134, 73, 41, 42, -44, -44, -44, -44, 134, 135,
+	-18, 89, -44, -44, 87, 135, 135, 135, 54, 137,
+	123, 135, 42, 135, 42, 42, -44, 63, -44, 42,
+	135, 123, 116, -18, 134, 22, 33, 115, 33, -49,
+	50, -44, 18, -45, -73, -31, -44, 115, -40, -41,
+	-42, -43, 112, -71, 71, 135, -44, 42, -44, -6,
+	-18, 135, 87, -44, 116, -44, -44, -44, -44, 42,
+	-44, -31, 24, -9, -55, 136, 135, -18, 115, -16,
+	-17, 134, -16, -44, -11, 115, 134, -66, 114, -45,
+	-41, 43, -56, -56, 135, -44, 135, 135, -70, -44,
+	135, 135, 135, 135, -44, 135, -66, 25, -69, 73,
+	59, 137, 117, 135, -75, 123, 18, -19, -10, -48,
+	-44, -54, 47, -31, 44, -38, 66, 63, 135, -40,
+	-11, -68, 72, 73, -69, 137, -72, 105, -17, 37,
+	135, 135, -49, -46, 45, 48, -66, 134, -77, 67,
+	134, -45, 135, -51, 76, -68, 106, 38, -61, 51,
+	-44, -13, 99, 100, 101, -28, -27, 117, 18, -6,
+	134, -21, -20, 134, -54, -64, 70, -22, 115, -51,
+	108, 107, 39, -48, 48, 123, 134, 134, 102, -44,
+	135, -44, 123, 135, -44, -46, 48, -49, -60, -27,
+	-28, -13, -13, 134, -56, -78, 68, -20, 123, -61,
+	115, 123, -57, 90, 135, 135, -15, -14, -28, 134,
+	18, 135, -18, -48, -65, 52, 53, -27, -65, 115,
+	135, 123, 135, -13, -44, -39, 69, 135, -49, -57,
+	-58, 91, -14, 135, 134, -65, 115, -44, -58, 135,
 }
 
 var yyDef = [...]int{
 	0, -2, 1, 4, 6, 7, 8, 10, 11, 12,
-	0, 0, 0, 0, 0, 0, 0, 68, 73, 2,
-	5, 9, 22, 22, 22, 0, 14, 0, 89, 0,
-	0, 0, 0, 0, 87, 71, 0, 74, 3, 0,
-	0, 0, 0, 22, 15, 16, 92, 0, 0, 0,
-	0, 0, 104, 0, 0, 72, 0, 75, 76, 123,
-	79, 0, 82, 13, 0, 0, 0, 0, 88, 0,
-	0, 90, 0, 96, -2, 127, 0, 0, 0, 134,
-	135, 0, 48, 49, 50, 51, 0, 53, 54, 55,
-	56, 82, 91, 0, 0, 35, 0, 116, 0, 104,
-	32, 0, 69, 0, 0, 77, 124, 0, 0, 0,
-	23, 0, 0, 0, 93, 94, 95, 0, 0, 0,
-	0, 0, 0, 0, 0, 138, 128, 129, 0, 0,
-	0, 44, 0, 0, 0, 36, 40, 0, 110, 0,
-	105, 116, 0, 0, 116, 89, 0, 123, 87, 123,
-	125, 0, 0, 83, 0, 58, 0, 0, 0, 139,
-	140, 141, 142, 143, 144, 145, 0, 0, 0, 0,
-	136, 0, 0, 45, 46, 20, 0, 0, 0, 0,
-	112, 0, 0, 110, 33, 34, -2, 123, 0, 86,
-	78, 80, 81, 0, 61, 0, 0, 146, 130, 0,
-	131, 0, 57, 0, 0, 0, 41, 0, 28, 0,
-	111, 0, 112, 104, 98, -2, 0, 103, 84, 123,
-	0, 59, 65, 0, 18, 0, 0, 0, 0, 47,
-	21, 30, 37, 44, 27, 113, 117, 24, 0, 29,
-	106, 100, 0, 85, 0, 63, 66, 0, 0, 19,
-	132, 133, 52, 26, 0, 0, 0, 0, 108, 0,
-	116, 0, 60, 64, 67, 62, 38, 0, 39, 25,
-	114, 0, 0, 0, 17, 0, 110, 0, 109, 107,
-	42, 0, 31, 112, 0, 0, 101, 70, 115, 120,
-	43, 0, 118, 121, 122, 120, 119,
+	0, 0, 0, 0, 0, 0, 0, 93, 104, 0,
+	2, 5, 102, 102, 102, 9, 23, 23, 23, 0,
+	23, 14, 0, 131, 0, 0, 0, 0, 184, 118,
+	0, 105, 0, 99, 0, 3, 0, 103, 0, 0,
+	0, 0, 0, 0, 23, 0, 15, 16, 134, 0,
+	0, 0, 0, 0, 147, 0, 185, 0, 0, 0,
+	106, 107, 184, -2, 188, 0, 0, 0, 0, 0,
+	200, 201, 0, 0, 110, 0, 56, 57, 58, 59,
+	0, 0, 62, 63, 64, 65, 66, 67, 68, 69,
+	113, 0, 0, 0, 104, 0, 0, 94, 95, 96,
+	13, 0, 0, 0, 0, 0, 130, 0, 0, 132,
+	0, 138, 133, 0, 0, 42, 0, 171, 0, 0,
+	186, 119, 0, 0, 108, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	210, 189, 190, 191, 0, 0, 207, 0, 0, 0,
+	0, 49, 49, 0, 0, 0, 0, 0, 100, 0,
+	24, 0, 0, 0, 0, 135, 136, 137, 0, 0,
+	0, 43, 47, 0, 165, 0, 148, 40, 36, 0,
+	171, 131, 0, 184, 118, 184, 211, 212, 213, 214,
+	215, 216, 217, 218, 219, 220, 221, 222, 0, 224,
+	225, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	202, 0, 0, 0, 0, 0, 113, 0, 0, 0,
+	0, 50, 51, 0, 114, 0, 0, 51, 0, 77,
+	78, 79, 0, 0, 0, 0, 0, 80, 0, 0,
+	0, 20, 21, 0, 0, 0, 0, 167, 0, 0,
+	147, 0, 0, 0, 0, -2, 120, 0, 117, 109,
+	0, 226, 0, 0, 192, 193, 194, 195, 0, 196,
+	0, 203, 0, 208, 0, 204, 111, 112, 0, 61,
+	0, 70, 0, 72, 0, 0, 0, 0, 0, 0,
+	101, 0, 86, 0, 0, 0, 0, 48, 0, 32,
+	0, 166, 0, 171, 37, 41, 38, 0, 147, 140,
+	-2, 0, 146, 184, 121, 184, 0, 0, 227, 0,
+	0, 209, 0, 205, 0, 52, 0, 0, 0, 0,
+	0, 171, 0, 81, 90, 0, 18, 0, 22, 34,
+	44, 49, 31, 168, 172, 28, 0, 165, 0, 149,
+	142, 0, 122, 116, 223, 228, 197, 198, 0, 206,
+	60, 71, 73, 74, 0, 76, -2, 0, 88, 91,
+	0, 90, 0, 25, 30, 0, 0, 0, 0, 167,
+	39, 163, 0, 171, 0, 115, 124, 0, 75, 147,
+	0, 84, 89, 92, 88, 87, 19, 0, 45, 0,
+	46, 29, 33, 169, 0, 0, 0, 0, 0, 125,
+	0, 149, 179, 82, 0, 84, 0, 0, 165, 0,
+	164, 150, 0, 0, 0, 159, 161, 162, 0, 0,
+	0, 0, 53, 0, 163, 17, 0, 85, 0, 83,
+	26, 27, 35, 167, 0, 0, 0, 0, 0, 143,
+	184, 126, 0, 199, 0, 169, 0, 97, 170, 175,
+	160, 0, 0, 0, 0, 0, 127, 54, 0, 165,
+	181, 0, 181, 0, 151, 152, 0, 154, 156, 0,
+	0, 128, 0, 167, 180, 182, 183, 175, 177, 176,
+	153, 0, 157, 0, 144, 123, 0, 55, 98, 181,
+	173, 0, 155, 158, 0, 177, 178, 0, 174, 129,
 }
 
 var yyTok1 = [...]int{
 	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 126, 3,
+	134, 135, 129, 127, 123, 128, 132, 130, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	85, 86, 81, 79, 78, 80, 83, 82, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 136, 3, 137, 125, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 87, 3, 88,
+	3, 3, 3, 3, 124, 3, 131,
 }
 
 var yyTok2 = [...]int{
@@ -453,7 +775,12 @@ var yyTok2 = [...]int{
 	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
 	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
 	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
-	72, 73, 74, 75, 76, 77, 84,
+	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
+	82, 83, 84, 85, 86, 87, 88, 89, 90, 91,
+	92, 93, 94, 95, 96, 97, 98, 99, 100, 101,
+	102, 103, 104, 105, 106, 107, 108, 109, 110, 111,
+	112, 113, 114, 115, 116, 117, 118, 119, 120, 121,
+	122, 133,
 }
 
 var yyTok3 = [...]int{
@@ -856,676 +1183,1136 @@ yydefault:
 			yyVAL.stmt = &UseSnapshotStmt{period: yyDollar[3].period}
 		}
 	case 17:
-		yyDollar = yyS[yypt-11 : yypt+1]
+		yyDollar = yyS[yypt-12 : yypt+1]
 		{
-			yyVAL.stmt = &CreateTableStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[4].id, colsSpec: yyDollar[6].colsSpec, pkColNames: yyDollar[10].ids}
+			yyVAL.stmt = &CreateTableStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[4].id, colsSpec: yyDollar[6].colsSpec, pkColNames: yyDollar[10].ids, sortKey: yyDollar[12].ordcol}
 		}
 	case 18:
 		yyDollar = yyS[yypt-8 : yypt+1]
 		{
-			yyVAL.stmt = &CreateIndexStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[5].id, cols: yyDollar[7].ids}
+			yyVAL.stmt = newCreateIndexStmt(false, yyDollar[3].boolean, yyDollar[5].id, yyDollar[7].values)
 		}
 	case 19:
-		yyDollar = yyS[yypt-9 : yypt+1]
+		yyDollar = yyS[yypt-10 : yypt+1]
 		{
-			yyVAL.stmt = &CreateIndexStmt{unique: true, ifNotExists: yyDollar[4].boolean, table: yyDollar[6].id, cols: yyDollar[8].ids}
+			stmt := newCreateIndexStmt(true, yyDollar[4].boolean, yyDollar[6].id, yyDollar[8].values)
+			stmt.deferred = yyDollar[10].boolean
+			yyVAL.stmt = stmt
 		}
 	case 20:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
-			yyVAL.stmt = &AddColumnStmt{table: yyDollar[3].id, colSpec: yyDollar[6].colSpec}
+			// the text of the query is set once the statement is parsed, see setViewTexts
+			yyVAL.stmt = &CreateViewStmt{ifNotExists: yyDollar[3].boolean, name: yyDollar[4].id, textStart: yyDollar[5].pos + len("AS")}
 		}
 	case 21:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.stmt = &AddColumnStmt{table: yyDollar[3].id, colSpec: yyDollar[6].colSpec}
+		}
+	case 22:
 		yyDollar = yyS[yypt-8 : yypt+1]
 		{
 			yyVAL.stmt = &RenameColumnStmt{table: yyDollar[3].id, oldName: yyDollar[6].id, newName: yyDollar[8].id}
 		}
-	case 22:
+	case 23:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 23:
+	case 24:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 24:
-		yyDollar = yyS[yypt-1 : yypt+1]
-		{
-			yyVAL.ids = []string{yyDollar[1].id}
-		}
 	case 25:
-		yyDollar = yyS[yypt-3 : yypt+1]
+		yyDollar = yyS[yypt-0 : yypt+1]
 		{
-			yyVAL.ids = yyDollar[2].ids
+			yyVAL.boolean = false
 		}
 	case 26:
-		yyDollar = yyS[yypt-9 : yypt+1]
+		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.stmt = &UpsertIntoStmt{isInsert: true, tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows, onConflict: yyDollar[9].onConflict}
+			yyVAL.boolean = false
 		}
 	case 27:
-		yyDollar = yyS[yypt-8 : yypt+1]
+		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.stmt = &UpsertIntoStmt{tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows}
+			yyVAL.boolean = true
 		}
 	case 28:
-		yyDollar = yyS[yypt-7 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.stmt = &DeleteFromStmt{tableRef: yyDollar[3].tableRef, where: yyDollar[4].exp, indexOn: yyDollar[5].ids, limit: int(yyDollar[6].number), offset: int(yyDollar[7].number)}
+			yyVAL.ids = []string{yyDollar[1].id}
 		}
 	case 29:
-		yyDollar = yyS[yypt-8 : yypt+1]
+		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.stmt = &UpdateStmt{tableRef: yyDollar[2].tableRef, updates: yyDollar[4].updates, where: yyDollar[5].exp, indexOn: yyDollar[6].ids, limit: int(yyDollar[7].number), offset: int(yyDollar[8].number)}
+			yyVAL.ids = yyDollar[2].ids
 		}
 	case 30:
-		yyDollar = yyS[yypt-0 : yypt+1]
+		yyDollar = yyS[yypt-9 : yypt+1]
 		{
-			yyVAL.onConflict = nil
+			yyVAL.stmt = &UpsertIntoStmt{isInsert: true, tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows, onConflict: yyDollar[9].onConflict}
 		}
 	case 31:
-		yyDollar = yyS[yypt-4 : yypt+1]
+		yyDollar = yyS[yypt-8 : yypt+1]
 		{
-			yyVAL.onConflict = &OnConflictDo{}
+			yyVAL.stmt = &UpsertIntoStmt{tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows}
 		}
 	case 32:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-7 : yypt+1]
 		{
-			yyVAL.updates = []*colUpdate{yyDollar[1].update}
+			yyVAL.stmt = &DeleteFromStmt{tableRef: yyDollar[3].tableRef, where: yyDollar[4].exp, indexOn: yyDollar[5].ids, limit: yyDollar[6].exp, offset: yyDollar[7].exp}
 		}
 	case 33:
-		yyDollar = yyS[yypt-3 : yypt+1]
+		yyDollar = yyS[yypt-10 : yypt+1]
 		{
-			yyVAL.updates = append(yyDollar[1].updates, yyDollar[3].update)
+			yyDollar[2].tableRef.as = yyDollar[3].id
+			yyVAL.stmt = &UpdateStmt{tableRef: yyDollar[2].tableRef, updates: yyDollar[5].updates, from: yyDollar[6].ds, where: yyDollar[7].exp, indexOn: yyDollar[8].ids, limit: yyDollar[9].exp, offset: yyDollar[10].exp}
 		}
 	case 34:
-		yyDollar = yyS[yypt-3 : yypt+1]
+		yyDollar = yyS[yypt-0 : yypt+1]
 		{
-			yyVAL.update = &colUpdate{col: yyDollar[1].id, op: yyDollar[2].cmpOp, val: yyDollar[3].exp}
+			yyVAL.onConflict = nil
 		}
 	case 35:
-		yyDollar = yyS[yypt-0 : yypt+1]
+		yyDollar = yyS[yypt-4 : yypt+1]
 		{
-			yyVAL.ids = nil
+			yyVAL.onConflict = &OnConflictDo{}
 		}
 	case 36:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.ids = yyDollar[1].ids
+			yyVAL.updates = []*colUpdate{yyDollar[1].update}
 		}
 	case 37:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.rows = []*RowSpec{yyDollar[1].row}
+			yyVAL.updates = append(yyDollar[1].updates, yyDollar[3].update)
 		}
 	case 38:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.rows = append(yyDollar[1].rows, yyDollar[3].row)
+			yyVAL.update = &colUpdate{col: yyDollar[1].id, op: yyDollar[2].cmpOp, val: yyDollar[3].exp}
 		}
 	case 39:
-		yyDollar = yyS[yypt-3 : yypt+1]
+		yyDollar = yyS[yypt-5 : yypt+1]
 		{
-			yyVAL.row = &RowSpec{Values: yyDollar[2].values}
+			yyVAL.update = &colUpdate{table: yyDollar[1].id, col: yyDollar[3].id, op: yyDollar[4].cmpOp, val: yyDollar[5].exp}
 		}
 	case 40:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-0 : yypt+1]
 		{
-			yyVAL.ids = []string{yyDollar[1].id}
+			yyVAL.ds = nil
 		}
 	case 41:
-		yyDollar = yyS[yypt-3 : yypt+1]
+		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			yyVAL.ids = append(yyDollar[1].ids, yyDollar[3].id)
+			yyVAL.ds = yyDollar[2].ds
 		}
 	case 42:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-0 : yypt+1]
 		{
-			yyVAL.cols = []*ColSelector{yyDollar[1].col}
+			yyVAL.ids = nil
 		}
 	case 43:
-		yyDollar = yyS[yypt-3 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.cols = append(yyDollar[1].cols, yyDollar[3].col)
+			yyVAL.ids = yyDollar[1].ids
 		}
 	case 44:
-		yyDollar = yyS[yypt-0 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.values = nil
+			yyVAL.rows = []*RowSpec{yyDollar[1].row}
 		}
 	case 45:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.values = yyDollar[1].values
+			yyVAL.rows = append(yyDollar[1].rows, yyDollar[3].row)
 		}
 	case 46:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.values = []ValueExp{yyDollar[1].exp}
+			yyVAL.row = &RowSpec{Values: yyDollar[2].values}
 		}
 	case 47:
-		yyDollar = yyS[yypt-3 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.values = append(yyDollar[1].values, yyDollar[3].exp)
+			yyVAL.ids = []string{yyDollar[1].id}
 		}
 	case 48:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.value = &Number{val: int64(yyDollar[1].number)}
+			yyVAL.ids = append(yyDollar[1].ids, yyDollar[3].id)
 		}
 	case 49:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-0 : yypt+1]
 		{
-			yyVAL.value = &Varchar{val: yyDollar[1].str}
+			yyVAL.values = nil
 		}
 	case 50:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.value = &Bool{val: yyDollar[1].boolean}
+			yyVAL.values = yyDollar[1].values
 		}
 	case 51:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.value = &Blob{val: yyDollar[1].blob}
+			yyVAL.values = []ValueExp{yyDollar[1].exp}
 		}
 	case 52:
-		yyDollar = yyS[yypt-6 : yypt+1]
+		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.value = &Cast{val: yyDollar[3].exp, t: yyDollar[5].sqlType}
+			yyVAL.values = append(yyDollar[1].values, yyDollar[3].exp)
 		}
 	case 53:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.value = yyDollar[1].value
+			yyVAL.tuples = [][]ValueExp{yyDollar[1].values}
 		}
 	case 54:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.value = &Param{id: yyDollar[1].id}
+			yyVAL.tuples = append(yyDollar[1].tuples, yyDollar[3].values)
 		}
 	case 55:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-5 : yypt+1]
 		{
-			yyVAL.value = &Param{id: fmt.Sprintf("param%d", yyDollar[1].pparam), pos: yyDollar[1].pparam}
+			yyVAL.values = append([]ValueExp{yyDollar[2].exp}, yyDollar[4].values...)
 		}
 	case 56:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.value = &NullValue{t: AnyType}
+			yyVAL.value = &Number{val: int64(yyDollar[1].number)}
 		}
 	case 57:
-		yyDollar = yyS[yypt-4 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.value = &FnCall{fn: yyDollar[1].id, params: yyDollar[3].values}
+			yyVAL.value = &Varchar{val: yyDollar[1].str}
 		}
 	case 58:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.colsSpec = []*ColSpec{yyDollar[1].colSpec}
+			yyVAL.value = &Bool{val: yyDollar[1].boolean}
 		}
 	case 59:
-		yyDollar = yyS[yypt-3 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.colsSpec = append(yyDollar[1].colsSpec, yyDollar[3].colSpec)
+			yyVAL.value = &Blob{val: yyDollar[1].blob}
 		}
 	case 60:
-		yyDollar = yyS[yypt-5 : yypt+1]
+		yyDollar = yyS[yypt-6 : yypt+1]
 		{
-			yyVAL.colSpec = &ColSpec{colName: yyDollar[1].id, colType: yyDollar[2].sqlType, maxLen: int(yyDollar[3].number), notNull: yyDollar[4].boolean, autoIncrement: yyDollar[5].boolean}
+			yyVAL.value = &Cast{val: yyDollar[3].exp, t: yyDollar[5].sqlType}
 		}
 	case 61:
-		yyDollar = yyS[yypt-0 : yypt+1]
+		yyDollar = yyS[yypt-4 : yypt+1]
 		{
-			yyVAL.number = 0
+			yyVAL.value = &ArrayExp{elems: yyDollar[3].values}
 		}
 	case 62:
-		yyDollar = yyS[yypt-3 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.number = yyDollar[2].number
+			yyVAL.value = yyDollar[1].value
 		}
 	case 63:
-		yyDollar = yyS[yypt-0 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.boolean = false
+			yyVAL.value = &Param{id: yyDollar[1].id}
 		}
 	case 64:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.boolean = true
+			yyVAL.value = &Param{id: fmt.Sprintf("param%d", yyDollar[1].pparam), pos: yyDollar[1].pparam}
 		}
 	case 65:
-		yyDollar = yyS[yypt-0 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.boolean = false
+			yyVAL.value = &NullValue{t: AnyType}
 		}
 	case 66:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.boolean = false
+			yyVAL.value = &DefaultValue{}
 		}
 	case 67:
-		yyDollar = yyS[yypt-2 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.boolean = true
+			yyVAL.value = &FnCall{fn: CurrentDateFnCall}
 		}
 	case 68:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.stmt = yyDollar[1].stmt
+			yyVAL.value = &FnCall{fn: CurrentTimeFnCall}
 		}
 	case 69:
-		yyDollar = yyS[yypt-4 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.stmt = &UnionStmt{
-				distinct: yyDollar[3].distinct,
-				left:     yyDollar[1].stmt.(DataSource),
-				right:    yyDollar[4].stmt.(DataSource),
-			}
+			yyVAL.value = &FnCall{fn: CurrentTimestampFnCall}
 		}
 	case 70:
-		yyDollar = yyS[yypt-13 : yypt+1]
+		yyDollar = yyS[yypt-4 : yypt+1]
 		{
-			yyVAL.stmt = &SelectStmt{
-				distinct:  yyDollar[2].distinct,
-				selectors: yyDollar[3].sels,
-				ds:        yyDollar[5].ds,
-				indexOn:   yyDollar[6].ids,
-				joins:     yyDollar[7].joins,
-				where:     yyDollar[8].exp,
-				groupBy:   yyDollar[9].cols,
-				having:    yyDollar[10].exp,
-				orderBy:   yyDollar[11].ordcols,
-				limit:     int(yyDollar[12].number),
-				offset:    int(yyDollar[13].number),
-			}
+			yyVAL.value = &FnCall{fn: yyDollar[1].id, params: yyDollar[3].values}
 		}
 	case 71:
-		yyDollar = yyS[yypt-0 : yypt+1]
+		yyDollar = yyS[yypt-6 : yypt+1]
 		{
-			yyVAL.distinct = true
+			yyVAL.value = &FnCall{fn: ExtractFnCall, params: []ValueExp{&Varchar{val: yyDollar[3].id}, yyDollar[5].exp}}
 		}
 	case 72:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-4 : yypt+1]
 		{
-			yyVAL.distinct = false
+			yyVAL.value = &FnCall{fn: TrimFnCall, params: yyDollar[3].values}
 		}
 	case 73:
-		yyDollar = yyS[yypt-0 : yypt+1]
+		yyDollar = yyS[yypt-6 : yypt+1]
 		{
-			yyVAL.distinct = false
+			yyVAL.value = &FnCall{fn: TrimFnCall, params: []ValueExp{yyDollar[5].exp, yyDollar[3].exp}}
 		}
 	case 74:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-6 : yypt+1]
 		{
-			yyVAL.distinct = true
+			yyVAL.value = &FnCall{fn: yyDollar[3].id, params: []ValueExp{yyDollar[5].exp}}
 		}
 	case 75:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-7 : yypt+1]
 		{
-			yyVAL.sels = nil
+			yyVAL.value = &FnCall{fn: yyDollar[3].id, params: []ValueExp{yyDollar[6].exp, yyDollar[4].exp}}
 		}
 	case 76:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-6 : yypt+1]
 		{
-			yyVAL.sels = yyDollar[1].sels
+			yyVAL.value = &FnCall{fn: InstrFnCall, params: []ValueExp{yyDollar[5].exp, yyDollar[3].exp}}
 		}
 	case 77:
-		yyDollar = yyS[yypt-2 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyDollar[1].sel.setAlias(yyDollar[2].id)
-			yyVAL.sels = []Selector{yyDollar[1].sel}
+			yyVAL.id = LTrimFnCall
 		}
 	case 78:
-		yyDollar = yyS[yypt-4 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyDollar[3].sel.setAlias(yyDollar[4].id)
-			yyVAL.sels = append(yyDollar[1].sels, yyDollar[3].sel)
+			yyVAL.id = RTrimFnCall
 		}
 	case 79:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.sel = yyDollar[1].col
+			yyVAL.id = TrimFnCall
 		}
 	case 80:
-		yyDollar = yyS[yypt-4 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.sel = &AggColSelector{aggFn: yyDollar[1].aggFn, col: "*"}
+			yyVAL.colsSpec = []*ColSpec{yyDollar[1].colSpec}
 		}
 	case 81:
-		yyDollar = yyS[yypt-4 : yypt+1]
+		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.sel = &AggColSelector{aggFn: yyDollar[1].aggFn, db: yyDollar[3].col.db, table: yyDollar[3].col.table, col: yyDollar[3].col.col}
+			yyVAL.colsSpec = append(yyDollar[1].colsSpec, yyDollar[3].colSpec)
 		}
 	case 82:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-6 : yypt+1]
 		{
-			yyVAL.col = &ColSelector{col: yyDollar[1].id}
+			yyVAL.colSpec = &ColSpec{colName: yyDollar[1].id, colType: yyDollar[2].sqlType, maxLen: int(yyDollar[3].number), notNull: yyDollar[4].boolean, autoIncrement: yyDollar[5].boolean, defaultValue: yyDollar[6].value}
 		}
 	case 83:
-		yyDollar = yyS[yypt-3 : yypt+1]
+		yyDollar = yyS[yypt-7 : yypt+1]
 		{
-			yyVAL.col = &ColSelector{table: yyDollar[1].id, col: yyDollar[3].id}
+			yyVAL.colSpec = &ColSpec{colName: yyDollar[1].id, colType: ArrayTypeOf(yyDollar[2].sqlType), notNull: yyDollar[5].boolean, autoIncrement: yyDollar[6].boolean, defaultValue: yyDollar[7].value}
 		}
 	case 84:
-		yyDollar = yyS[yypt-3 : yypt+1]
+		yyDollar = yyS[yypt-0 : yypt+1]
 		{
-			yyDollar[1].tableRef.period = yyDollar[2].period
-			yyDollar[1].tableRef.as = yyDollar[3].id
-			yyVAL.ds = yyDollar[1].tableRef
+			yyVAL.value = nil
 		}
 	case 85:
-		yyDollar = yyS[yypt-4 : yypt+1]
+		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			yyDollar[2].stmt.(*SelectStmt).as = yyDollar[4].id
-			yyVAL.ds = yyDollar[2].stmt.(DataSource)
+			yyVAL.value = yyDollar[2].value
 		}
 	case 86:
-		yyDollar = yyS[yypt-2 : yypt+1]
+		yyDollar = yyS[yypt-0 : yypt+1]
 		{
-			yyVAL.ds = &FnDataSourceStmt{fnCall: yyDollar[1].value.(*FnCall), as: yyDollar[2].id}
+			yyVAL.number = 0
 		}
 	case 87:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.tableRef = &tableRef{table: yyDollar[1].id}
+			yyVAL.number = yyDollar[2].number
 		}
 	case 88:
-		yyDollar = yyS[yypt-2 : yypt+1]
+		yyDollar = yyS[yypt-0 : yypt+1]
 		{
-			yyVAL.period = period{start: yyDollar[1].openPeriod, end: yyDollar[2].openPeriod}
+			yyVAL.boolean = false
 		}
 	case 89:
-		yyDollar = yyS[yypt-0 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.openPeriod = nil
+			yyVAL.boolean = true
 		}
 	case 90:
-		yyDollar = yyS[yypt-2 : yypt+1]
+		yyDollar = yyS[yypt-0 : yypt+1]
 		{
-			yyVAL.openPeriod = &openPeriod{inclusive: true, instant: yyDollar[2].periodInstant}
+			yyVAL.boolean = false
 		}
 	case 91:
-		yyDollar = yyS[yypt-2 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.openPeriod = &openPeriod{instant: yyDollar[2].periodInstant}
+			yyVAL.boolean = false
 		}
 	case 92:
-		yyDollar = yyS[yypt-0 : yypt+1]
+		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			yyVAL.openPeriod = nil
+			yyVAL.boolean = true
 		}
 	case 93:
-		yyDollar = yyS[yypt-2 : yypt+1]
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.openPeriod = &openPeriod{inclusive: true, instant: yyDollar[2].periodInstant}
+			yyVAL.stmt = yyDollar[1].stmt
 		}
 	case 94:
-		yyDollar = yyS[yypt-2 : yypt+1]
+		yyDollar = yyS[yypt-4 : yypt+1]
 		{
-			yyVAL.openPeriod = &openPeriod{instant: yyDollar[2].periodInstant}
-		}
+			yyVAL.stmt = &UnionStmt{
+				distinct: yyDollar[3].distinct,
+				left:     yyDollar[1].stmt.(DataSource),
+				right:    yyDollar[4].stmt.(DataSource),
+			}
+		}
 	case 95:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.stmt = &SetOpStmt{
+				op:       ExceptSetOp,
+				distinct: yyDollar[3].distinct,
+				left:     yyDollar[1].stmt.(DataSource),
+				right:    yyDollar[4].stmt.(DataSource),
+			}
+		}
+	case 96:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.stmt = &SetOpStmt{
+				op:       IntersectSetOp,
+				distinct: yyDollar[3].distinct,
+				left:     yyDollar[1].stmt.(DataSource),
+				right:    yyDollar[4].stmt.(DataSource),
+			}
+		}
+	case 97:
+		yyDollar = yyS[yypt-13 : yypt+1]
+		{
+			stmt := &SelectStmt{
+				distinct:  yyDollar[2].distinct,
+				selectors: yyDollar[3].sels,
+				ds:        yyDollar[5].ds,
+				indexOn:   yyDollar[6].ids,
+				joins:     yyDollar[7].joins,
+				where:     yyDollar[8].exp,
+				having:    yyDollar[10].exp,
+				orderBy:   yyDollar[11].ordcols,
+				limit:     yyDollar[12].exp,
+				offset:    yyDollar[13].exp,
+			}
+
+			stmt.setGroupBy(yyDollar[9].groupBy)
+			stmt.nameAggregatedExps()
+			stmt.resolveGroupByOrdinals()
+
+			yyVAL.stmt = stmt
+		}
+	case 98:
+		yyDollar = yyS[yypt-15 : yypt+1]
+		{
+			stmt := &SelectStmt{
+				ctes:      yyDollar[2].ctes,
+				distinct:  yyDollar[4].distinct,
+				selectors: yyDollar[5].sels,
+				ds:        yyDollar[7].ds,
+				indexOn:   yyDollar[8].ids,
+				joins:     yyDollar[9].joins,
+				where:     yyDollar[10].exp,
+				having:    yyDollar[12].exp,
+				orderBy:   yyDollar[13].ordcols,
+				limit:     yyDollar[14].exp,
+				offset:    yyDollar[15].exp,
+			}
+
+			stmt.setGroupBy(yyDollar[11].groupBy)
+			stmt.nameAggregatedExps()
+			stmt.resolveGroupByOrdinals()
+			stmt.inlineCTEs()
+
+			yyVAL.stmt = stmt
+		}
+	case 99:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.ctes = []*CTE{yyDollar[1].cte}
+		}
+	case 100:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.ctes = append(yyDollar[1].ctes, yyDollar[3].cte)
+		}
+	case 101:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyVAL.cte = &CTE{name: yyDollar[1].id, ds: yyDollar[4].stmt.(DataSource)}
+		}
+	case 102:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.distinct = true
+		}
+	case 103:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.distinct = false
+		}
+	case 104:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.distinct = false
+		}
+	case 105:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.distinct = true
+		}
+	case 106:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.sels = nil
+		}
+	case 107:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.sels = yyDollar[1].sels
+		}
+	case 108:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.sels = []Selector{newSelector(yyDollar[1].exp, yyDollar[2].id)}
+		}
+	case 109:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.sels = append(yyDollar[1].sels, newSelector(yyDollar[3].exp, yyDollar[4].id))
+		}
+	case 110:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.sel = yyDollar[1].col
+		}
+	case 111:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.sel = &AggColSelector{aggFn: yyDollar[1].aggFn, col: "*"}
+		}
+	case 112:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.sel = newAggColSelector(yyDollar[1].aggFn, yyDollar[3].exp)
+		}
+	case 113:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.col = &ColSelector{col: yyDollar[1].id}
+		}
+	case 114:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.col = &ColSelector{table: yyDollar[1].id, col: yyDollar[3].id}
+		}
+	case 115:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyDollar[1].tableRef.period = yyDollar[2].period
+			yyDollar[1].tableRef.history = yyDollar[3].boolean
+			yyDollar[1].tableRef.as = yyDollar[4].id
+			yyDollar[1].tableRef.sample = yyDollar[5].tableSample
+			yyVAL.ds = yyDollar[1].tableRef
+		}
+	case 116:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			switch ds := yyDollar[2].stmt.(type) {
+			case *SelectStmt:
+				ds.as = yyDollar[4].id
+			case *UnionStmt:
+				ds.as = yyDollar[4].id
+			case *SetOpStmt:
+				ds.as = yyDollar[4].id
+			}
+			yyVAL.ds = yyDollar[2].stmt.(DataSource)
+		}
+	case 117:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.ds = &FnDataSourceStmt{fnCall: yyDollar[1].value.(*FnCall), as: yyDollar[2].id}
+		}
+	case 118:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.tableRef = &tableRef{table: yyDollar[1].id}
+		}
+	case 119:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.tableRef = &tableRef{db: yyDollar[1].id, table: yyDollar[3].id}
+		}
+	case 120:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.boolean = false
+		}
+	case 121:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.boolean = true
+		}
+	case 122:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.tableSample = nil
+		}
+	case 123:
+		yyDollar = yyS[yypt-7 : yypt+1]
+		{
+			yyVAL.tableSample = &tableSample{percentage: yyDollar[4].exp, seed: yyDollar[7].exp}
+		}
+	case 124:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+		}
+	case 125:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+		}
+	case 126:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+		}
+	case 127:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+		}
+	case 128:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.exp = nil
+		}
+	case 129:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.exp = yyDollar[3].exp
+		}
+	case 130:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.period = period{start: yyDollar[1].openPeriod, end: yyDollar[2].openPeriod}
+		}
+	case 131:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.openPeriod = nil
+		}
+	case 132:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.openPeriod = &openPeriod{inclusive: true, instant: yyDollar[2].periodInstant}
+		}
+	case 133:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.openPeriod = &openPeriod{instant: yyDollar[2].periodInstant}
+		}
+	case 134:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.openPeriod = nil
+		}
+	case 135:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.openPeriod = &openPeriod{inclusive: true, instant: yyDollar[2].periodInstant}
+		}
+	case 136:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.openPeriod = &openPeriod{instant: yyDollar[2].periodInstant}
+		}
+	case 137:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.periodInstant = periodInstant{instantType: txInstant, exp: yyDollar[2].exp}
 		}
-	case 96:
+	case 138:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.periodInstant = periodInstant{instantType: timeInstant, exp: yyDollar[1].exp}
 		}
-	case 97:
+	case 139:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.joins = nil
 		}
-	case 98:
+	case 140:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joins = yyDollar[1].joins
 		}
-	case 99:
+	case 141:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joins = []*JoinSpec{yyDollar[1].join}
 		}
-	case 100:
+	case 142:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.joins = append([]*JoinSpec{yyDollar[1].join}, yyDollar[2].joins...)
 		}
-	case 101:
+	case 143:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.join = &JoinSpec{joinType: yyDollar[1].joinType, ds: yyDollar[3].ds, indexOn: yyDollar[4].ids, cond: yyDollar[6].exp}
 		}
-	case 102:
+	case 144:
+		yyDollar = yyS[yypt-9 : yypt+1]
+		{
+			switch ds := yyDollar[5].stmt.(type) {
+			case *SelectStmt:
+				ds.as = yyDollar[7].id
+			case *UnionStmt:
+				ds.as = yyDollar[7].id
+			case *SetOpStmt:
+				ds.as = yyDollar[7].id
+			}
+			yyVAL.join = &JoinSpec{joinType: yyDollar[1].joinType, ds: yyDollar[5].stmt.(DataSource), lateral: true, cond: yyDollar[9].exp}
+		}
+	case 145:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.joinType = InnerJoin
 		}
-	case 103:
+	case 146:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joinType = yyDollar[1].joinType
 		}
-	case 104:
+	case 147:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.exp = nil
 		}
-	case 105:
+	case 148:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 106:
+	case 149:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
-			yyVAL.cols = nil
+			yyVAL.groupBy = nil
 		}
-	case 107:
+	case 150:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.cols = yyDollar[3].cols
+			yyVAL.groupBy = &groupByClause{cols: yyDollar[3].cols}
 		}
-	case 108:
+	case 151:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.groupBy = &groupByClause{kind: rollupGroupBy, cols: yyDollar[5].cols}
+		}
+	case 152:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.groupBy = &groupByClause{kind: cubeGroupBy, cols: yyDollar[5].cols}
+		}
+	case 153:
+		yyDollar = yyS[yypt-7 : yypt+1]
+		{
+			yyVAL.groupBy = &groupByClause{kind: groupingSetsGroupBy, sets: yyDollar[6].groupingSets}
+		}
+	case 154:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.groupingSets = [][]*ColSelector{yyDollar[1].cols}
+		}
+	case 155:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.groupingSets = append(yyDollar[1].groupingSets, yyDollar[3].cols)
+		}
+	case 156:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.cols = []*ColSelector{yyDollar[1].col}
+		}
+	case 157:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.cols = []*ColSelector{}
+		}
+	case 158:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.cols = yyDollar[2].cols
+		}
+	case 159:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.cols = []*ColSelector{yyDollar[1].col}
+		}
+	case 160:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.cols = append(yyDollar[1].cols, yyDollar[3].col)
+		}
+	case 161:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.col = yyDollar[1].col
+		}
+	case 162:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.col = &ColSelector{ordinal: int(yyDollar[1].number)}
+		}
+	case 163:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.exp = nil
 		}
-	case 109:
+	case 164:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 110:
+	case 165:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
-			yyVAL.number = 0
+			yyVAL.exp = nil
 		}
-	case 111:
+	case 166:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			yyVAL.number = yyDollar[2].number
+			yyVAL.exp = yyDollar[2].exp
 		}
-	case 112:
+	case 167:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
-			yyVAL.number = 0
+			yyVAL.exp = nil
 		}
-	case 113:
+	case 168:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			yyVAL.number = yyDollar[2].number
+			yyVAL.exp = yyDollar[2].exp
 		}
-	case 114:
+	case 169:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ordcols = nil
 		}
-	case 115:
+	case 170:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ordcols = yyDollar[3].ordcols
 		}
-	case 116:
+	case 171:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ids = nil
 		}
-	case 117:
+	case 172:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.ids = yyDollar[4].ids
 		}
-	case 118:
+	case 173:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.ordcols = []*OrdCol{{sel: yyDollar[1].col, collation: collationByName(yyDollar[2].id), descOrder: yyDollar[3].opt_ord, nulls: nullsOrderByName(yyDollar[4].id)}}
+		}
+	case 174:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.ordcols = append(yyDollar[1].ordcols, &OrdCol{sel: yyDollar[3].col, collation: collationByName(yyDollar[4].id), descOrder: yyDollar[5].opt_ord, nulls: nullsOrderByName(yyDollar[6].id)})
+		}
+	case 175:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.id = ""
+		}
+	case 176:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			yyVAL.ordcols = []*OrdCol{{sel: yyDollar[1].col, descOrder: yyDollar[2].opt_ord}}
+			yyVAL.id = yyDollar[2].id
 		}
-	case 119:
+	case 177:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.id = ""
+		}
+	case 178:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.id = yyDollar[2].id
+		}
+	case 179:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.ordcol = nil
+		}
+	case 180:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
-			yyVAL.ordcols = append(yyDollar[1].ordcols, &OrdCol{sel: yyDollar[3].col, descOrder: yyDollar[4].opt_ord})
+			yyVAL.ordcol = &OrdCol{sel: &ColSelector{col: yyDollar[3].id}, descOrder: yyDollar[4].opt_ord}
 		}
-	case 120:
+	case 181:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.opt_ord = false
 		}
-	case 121:
+	case 182:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.opt_ord = false
 		}
-	case 122:
+	case 183:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.opt_ord = true
 		}
-	case 123:
+	case 184:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.id = ""
 		}
-	case 124:
+	case 185:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.id = yyDollar[1].id
 		}
-	case 125:
+	case 186:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.id = yyDollar[2].id
 		}
-	case 126:
+	case 187:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].exp
 		}
-	case 127:
+	case 188:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].binExp
 		}
-	case 128:
+	case 189:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = &NotBoolExp{exp: yyDollar[2].exp}
 		}
-	case 129:
+	case 190:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = &NumExp{left: &Number{val: 0}, op: SUBSOP, right: yyDollar[2].exp}
 		}
-	case 130:
+	case 191:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			// bitwise NOT is computed as a XOR with all bits set, i.e. with -1
+			yyVAL.exp = &NumExp{left: yyDollar[2].exp, op: BITXOROP, right: &NumExp{left: &Number{val: 0}, op: SUBSOP, right: &Number{val: 1}}}
+		}
+	case 192:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.exp = &LikeBoolExp{val: yyDollar[1].exp, notLike: yyDollar[2].boolean, pattern: yyDollar[4].exp}
 		}
-	case 131:
+	case 193:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.exp = newRegexpBoolExp(yyDollar[1].exp, yyDollar[2].boolean, yyDollar[4].exp, false)
+		}
+	case 194:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.exp = newRegexpBoolExp(yyDollar[1].exp, yyDollar[2].boolean, yyDollar[4].exp, false)
+		}
+	case 195:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.exp = newRegexpBoolExp(yyDollar[1].exp, yyDollar[2].boolean, yyDollar[4].exp, true)
+		}
+	case 196:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.exp = &ExistsBoolExp{q: (yyDollar[3].stmt).(*SelectStmt)}
 		}
-	case 132:
+	case 197:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.exp = &InSubQueryExp{val: yyDollar[1].exp, notIn: yyDollar[2].boolean, q: yyDollar[5].stmt.(*SelectStmt)}
 		}
-	case 133:
+	case 198:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.exp = &InListExp{val: yyDollar[1].exp, notIn: yyDollar[2].boolean, values: yyDollar[5].values}
 		}
-	case 134:
+	case 199:
+		yyDollar = yyS[yypt-10 : yypt+1]
+		{
+			yyVAL.exp = &TupleInListExp{vals: append([]ValueExp{yyDollar[2].exp}, yyDollar[4].values...), notIn: yyDollar[6].boolean, tuples: yyDollar[9].tuples}
+		}
+	case 200:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].sel
 		}
-	case 135:
+	case 201:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].value
 		}
-	case 136:
+	case 202:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 137:
+	case 203:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.exp = &CaseExp{whens: yyDollar[2].whens, elseExp: yyDollar[3].exp}
+		}
+	case 204:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.exp = &GroupingExp{col: yyDollar[3].col}
+		}
+	case 205:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.whens = []*whenThen{{cond: yyDollar[2].exp, result: yyDollar[4].exp}}
+		}
+	case 206:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyVAL.whens = append(yyDollar[1].whens, &whenThen{cond: yyDollar[3].exp, result: yyDollar[5].exp})
+		}
+	case 207:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.exp = nil
+		}
+	case 208:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.exp = yyDollar[2].exp
+		}
+	case 209:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 138:
+	case 210:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 139:
+	case 211:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: ADDOP, right: yyDollar[3].exp}
 		}
-	case 140:
+	case 212:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.binExp = &ConcatExp{left: yyDollar[1].exp, right: yyDollar[3].exp}
+		}
+	case 213:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: SUBSOP, right: yyDollar[3].exp}
 		}
-	case 141:
+	case 214:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: DIVOP, right: yyDollar[3].exp}
 		}
-	case 142:
+	case 215:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: MULTOP, right: yyDollar[3].exp}
 		}
-	case 143:
+	case 216:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: BITANDOP, right: yyDollar[3].exp}
+		}
+	case 217:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: BITOROP, right: yyDollar[3].exp}
+		}
+	case 218:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: BITXOROP, right: yyDollar[3].exp}
+		}
+	case 219:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: SHLOP, right: yyDollar[3].exp}
+		}
+	case 220:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: SHROP, right: yyDollar[3].exp}
+		}
+	case 221:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &BinBoolExp{left: yyDollar[1].exp, op: yyDollar[2].logicOp, right: yyDollar[3].exp}
 		}
-	case 144:
+	case 222:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &CmpBoolExp{left: yyDollar[1].exp, op: yyDollar[2].cmpOp, right: yyDollar[3].exp}
 		}
-	case 145:
+	case 223:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.binExp = &CmpAnyBoolExp{op: yyDollar[2].cmpOp, val: yyDollar[1].exp, arr: yyDollar[5].exp}
+		}
+	case 224:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.binExp = &ArrayContainsExp{left: yyDollar[1].exp, right: yyDollar[3].exp}
+		}
+	case 225:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &CmpBoolExp{left: yyDollar[1].exp, op: EQ, right: &NullValue{t: AnyType}}
 		}
-	case 146:
+	case 226:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.binExp = &CmpBoolExp{left: yyDollar[1].exp, op: NE, right: &NullValue{t: AnyType}}
 		}
+	case 227:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyVAL.binExp = &DistinctFromBoolExp{left: yyDollar[1].exp, right: yyDollar[5].exp}
+		}
+	case 228:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.binExp = &DistinctFromBoolExp{left: yyDollar[1].exp, right: yyDollar[6].exp, negate: true}
+		}
 	}
 	goto yystack /* stack new state and value */
 }
@@ -19,6 +19,7 @@ package sql
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/codenotary/immudb/embedded/store"
@@ -41,12 +42,24 @@ type SQLTx struct {
 	lastInsertedPKs  map[string]int64 // last inserted PK by table name
 	firstInsertedPKs map[string]int64 // first inserted PK by table name
 
+	warnings []error
+
+	// deferredUniqueChecks holds the values written into deferred unique indexes, checked when committing
+	deferredUniqueChecks []*deferredUniqueCheck
+	deferredUniqueKeys   map[string]struct{}
+
 	txHeader *store.TxHeader // header is set once tx is committed
 
 	committed bool
 	closed    bool
 }
 
+type deferredUniqueCheck struct {
+	index *Index
+	// prefix of the index entries holding the same values, regardless of the primary key
+	prefix []byte
+}
+
 func (sqlTx *SQLTx) Catalog() *Catalog {
 	return sqlTx.catalog
 }
@@ -82,10 +95,26 @@ func (sqlTx *SQLTx) FirstInsertedPKs() map[string]int64 {
 	return sqlTx.firstInsertedPKs
 }
 
+// Warnings returns the issues found while executing the statements of the transaction which did not prevent their execution
+func (sqlTx *SQLTx) Warnings() []error {
+	return sqlTx.warnings
+}
+
 func (sqlTx *SQLTx) TxHeader() *store.TxHeader {
 	return sqlTx.txHeader
 }
 
+// TxID returns the id of the transaction the statements were committed in, zero until committed.
+// Rows as written by INSERT, UPDATE or DELETE statements can later be read as of such transaction,
+// e.g. `SELECT * FROM t UNTIL TX @txID`
+func (sqlTx *SQLTx) TxID() uint64 {
+	if sqlTx.txHeader == nil {
+		return 0
+	}
+
+	return sqlTx.txHeader.ID
+}
+
 func (sqlTx *SQLTx) sqlPrefix() []byte {
 	return sqlTx.engine.prefix
 }
@@ -102,6 +131,24 @@ func (sqlTx *SQLTx) get(key []byte) (store.ValueRef, error) {
 	return sqlTx.tx.Get(key)
 }
 
+// history returns the ids of all the transactions in which the key was set, in ascending order
+func (sqlTx *SQLTx) history(key []byte) ([]uint64, error) {
+	var txIDs []uint64
+
+	for {
+		txs, hCount, err := sqlTx.tx.History(key, uint64(len(txIDs)), false, historyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		txIDs = append(txIDs, txs...)
+
+		if uint64(len(txIDs)) >= hCount {
+			return txIDs, nil
+		}
+	}
+}
+
 func (sqlTx *SQLTx) set(key []byte, metadata *store.KVMetadata, value []byte) error {
 	return sqlTx.tx.Set(key, metadata, value)
 }
@@ -125,11 +172,74 @@ func (sqlTx *SQLTx) Cancel() error {
 	return sqlTx.tx.Cancel()
 }
 
+// deferUniqueCheck records the values written into a deferred unique index, so they are checked to be unique when committing
+func (sqlTx *SQLTx) deferUniqueCheck(index *Index, prefix []byte) {
+	if sqlTx.deferredUniqueKeys == nil {
+		sqlTx.deferredUniqueKeys = make(map[string]struct{})
+	}
+
+	_, checked := sqlTx.deferredUniqueKeys[string(prefix)]
+	if checked {
+		return
+	}
+
+	sqlTx.deferredUniqueKeys[string(prefix)] = struct{}{}
+	sqlTx.deferredUniqueChecks = append(sqlTx.deferredUniqueChecks, &deferredUniqueCheck{index: index, prefix: prefix})
+}
+
+// checkDeferredUniqueness fails if more than one live entry holds the values written into a deferred unique index.
+// Reading the entries adds them to the read set of the transaction, so a conflicting commit is detected as well
+func (sqlTx *SQLTx) checkDeferredUniqueness() error {
+	for _, check := range sqlTx.deferredUniqueChecks {
+		err := sqlTx.checkUniqueEntry(check)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sqlTx *SQLTx) checkUniqueEntry(check *deferredUniqueCheck) error {
+	reader, err := sqlTx.newKeyReader(store.KeyReaderSpec{
+		Prefix:  check.prefix,
+		Filters: []store.FilterFn{store.IgnoreExpired, store.IgnoreDeleted},
+	})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	entries := 0
+
+	for {
+		_, _, err := reader.Read()
+		if errors.Is(err, store.ErrNoMoreEntries) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		entries++
+
+		if entries > 1 {
+			return fmt.Errorf("%w: index %s", ErrUniqueConstraintViolation, check.index.Name())
+		}
+	}
+}
+
 func (sqlTx *SQLTx) commit(ctx context.Context) error {
 	if sqlTx.closed {
 		return ErrAlreadyClosed
 	}
 
+	err := sqlTx.checkDeferredUniqueness()
+	if err != nil {
+		sqlTx.Cancel()
+		return err
+	}
+
 	sqlTx.committed = true
 	sqlTx.closed = true
 
@@ -20,23 +20,31 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/codenotary/immudb/embedded/store"
 )
 
 const (
-	catalogDatabasePrefix = "CTL.DATABASE." // (key=CTL.DATABASE.{dbID}, value={dbNAME})
-	catalogTablePrefix    = "CTL.TABLE."    // (key=CTL.TABLE.{dbID}{tableID}, value={tableNAME})
-	catalogColumnPrefix   = "CTL.COLUMN."   // (key=CTL.COLUMN.{dbID}{tableID}{colID}{colTYPE}, value={(auto_incremental | nullable){maxLen}{colNAME}})
-	catalogIndexPrefix    = "CTL.INDEX."    // (key=CTL.INDEX.{dbID}{tableID}{indexID}, value={unique {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)})
-	PIndexPrefix          = "R."            // (key=R.{dbID}{tableID}{0}({null}({pkVal}{padding}{pkValLen})?)+, value={count (colID valLen val)+})
-	SIndexPrefix          = "E."            // (key=E.{dbID}{tableID}{indexID}({null}({val}{padding}{valLen})?)+({pkVal}{padding}{pkValLen})+, value={})
-	UIndexPrefix          = "N."            // (key=N.{dbID}{tableID}{indexID}({null}({val}{padding}{valLen})?)+, value={({pkVal}{padding}{pkValLen})+})
+	catalogDatabasePrefix      = "CTL.DATABASE."   // (key=CTL.DATABASE.{dbID}, value={dbNAME})
+	catalogTablePrefix         = "CTL.TABLE."      // (key=CTL.TABLE.{dbID}{tableID}, value={tableNAME})
+	catalogColumnPrefix        = "CTL.COLUMN."     // (key=CTL.COLUMN.{dbID}{tableID}{colID}{colTYPE}, value={(auto_incremental | nullable){maxLen}{colNAME}})
+	catalogIndexPrefix         = "CTL.INDEX."      // (key=CTL.INDEX.{dbID}{tableID}{indexID}, value={(unique | deferred) {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)})
+	catalogSortKeyPrefix       = "CTL.SORTKEY."    // (key=CTL.SORTKEY.{dbID}{tableID}, value={colID}(ASC|DESC))
+	catalogIndexExprPrefix     = "CTL.INDEX_EXPR." // (key=CTL.INDEX_EXPR.{dbID}{tableID}{colID}, value={expression})
+	catalogColumnDefaultPrefix = "CTL.DEFAULT."    // (key=CTL.DEFAULT.{dbID}{tableID}{colID}, value={default value})
+	catalogViewPrefix          = "CTL.VIEW."       // (key=CTL.VIEW.{dbID}{viewID}, value={tableID}{colCount}({colID})*{nameLen}{name}{query})
+	PIndexPrefix               = "R."              // (key=R.{dbID}{tableID}{0}({null}({pkVal}{padding}{pkValLen})?)+, value={count (colID valLen val)+})
+	SIndexPrefix               = "E."              // (key=E.{dbID}{tableID}{indexID}({null}({val}{padding}{valLen})?)+({pkVal}{padding}{pkValLen})+, value={})
+	UIndexPrefix               = "N."              // (key=N.{dbID}{tableID}{indexID}({null}({val}{padding}{valLen})?)+, value={({pkVal}{padding}{pkValLen})+})
 
 	// Old prefixes that must not be reused:
 	//  `CATALOG.DATABASE.`
@@ -58,6 +66,12 @@ const (
 	autoIncrementFlag byte = 1 << iota
 )
 
+// unique indexes checked at commit time are stored as secondary indexes, so their entries include the primary key
+const (
+	uniqueIndexFlag   byte = 1 << iota
+	deferredIndexFlag byte = 1 << iota
+)
+
 type SQLValueType = string
 
 const (
@@ -77,6 +91,10 @@ const (
 	MAX   AggregateFn = "MAX"
 	MIN   AggregateFn = "MIN"
 	AVG   AggregateFn = "AVG"
+	// BOOL_AND and BOOL_OR are true when all and any of the aggregated BOOLEAN values are true,
+	// NULL values are ignored and the result is NULL when there are no values to aggregate
+	BOOL_AND AggregateFn = "BOOL_AND"
+	BOOL_OR  AggregateFn = "BOOL_OR"
 )
 
 type CmpOperator = int
@@ -104,6 +122,14 @@ const (
 	SUBSOP
 	DIVOP
 	MULTOP
+	// bitwise operators work on the two's complement representation of the 64-bit integers,
+	// the sign bit included. Shifting to the right preserves the sign (arithmetic shift),
+	// shift amounts of 64 or more shift out every bit while negative ones are rejected
+	BITANDOP
+	BITOROP
+	BITXOROP
+	SHLOP
+	SHROP
 )
 
 type JoinType = int
@@ -114,12 +140,46 @@ const (
 	RightJoin
 )
 
+type SetOperator = int
+
+const (
+	ExceptSetOp SetOperator = iota
+	IntersectSetOp
+)
+
 const (
-	NowFnCall       string = "NOW"
-	DatabasesFnCall string = "DATABASES"
-	TablesFnCall    string = "TABLES"
-	ColumnsFnCall   string = "COLUMNS"
-	IndexesFnCall   string = "INDEXES"
+	NowFnCall              string = "NOW"
+	CurrentDateFnCall      string = "CURRENT_DATE"
+	CurrentTimeFnCall      string = "CURRENT_TIME"
+	CurrentTimestampFnCall string = "CURRENT_TIMESTAMP"
+	GreatestFnCall         string = "GREATEST"
+	LeastFnCall            string = "LEAST"
+	ConcatFnCall           string = "CONCAT"
+	MaskFnCall             string = "MASK"
+	SHA256FnCall           string = "SHA256"
+	AbsFnCall              string = "ABS"
+	CeilFnCall             string = "CEIL"
+	FloorFnCall            string = "FLOOR"
+	RoundFnCall            string = "ROUND"
+	ExtractFnCall          string = "EXTRACT"
+	LowerFnCall            string = "LOWER"
+	UpperFnCall            string = "UPPER"
+	TrimFnCall             string = "TRIM"
+	LTrimFnCall            string = "LTRIM"
+	RTrimFnCall            string = "RTRIM"
+	InstrFnCall            string = "INSTR"
+	SubstrFnCall           string = "SUBSTR"
+	ReplaceFnCall          string = "REPLACE"
+	ReverseFnCall          string = "REVERSE"
+	DateFnCall             string = "DATE"
+	HexFnCall              string = "HEX"
+	UnhexFnCall            string = "UNHEX"
+	ToBase64FnCall         string = "TO_BASE64"
+	FromBase64FnCall       string = "FROM_BASE64"
+	DatabasesFnCall        string = "DATABASES"
+	TablesFnCall           string = "TABLES"
+	ColumnsFnCall          string = "COLUMNS"
+	IndexesFnCall          string = "INDEXES"
 )
 
 type SQLStmt interface {
@@ -287,6 +347,13 @@ func persistColumn(col *Column, tx *SQLTx) error {
 
 	copy(v[5:], []byte(col.Name()))
 
+	if col.defaultValue != nil {
+		err := persistColumnDefault(col, tx)
+		if err != nil {
+			return err
+		}
+	}
+
 	mappedKey := mapKey(
 		tx.sqlPrefix(),
 		catalogColumnPrefix,
@@ -304,6 +371,7 @@ type CreateTableStmt struct {
 	ifNotExists bool
 	colsSpec    []*ColSpec
 	pkColNames  []string
+	sortKey     *OrdCol
 }
 
 func (stmt *CreateTableStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
@@ -343,6 +411,13 @@ func (stmt *CreateTableStmt) execAt(ctx context.Context, tx *SQLTx, params map[s
 		}
 	}
 
+	if stmt.sortKey != nil {
+		err = stmt.createSortKey(ctx, tx, table, params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	mappedKey := mapKey(tx.sqlPrefix(), catalogTablePrefix, EncodeID(tx.currentDB.id), EncodeID(table.id))
 
 	err = tx.set(mappedKey, nil, []byte(table.name))
@@ -353,19 +428,83 @@ func (stmt *CreateTableStmt) execAt(ctx context.Context, tx *SQLTx, params map[s
 	return tx, nil
 }
 
+// createSortKey sets the column rows are sorted by when no order is specified in a query.
+// An index on such column is created unless it's the first column of the primary key,
+// as secondary indexes can only be created while the table is empty
+func (stmt *CreateTableStmt) createSortKey(ctx context.Context, tx *SQLTx, table *Table, params map[string]interface{}) error {
+	col, err := table.GetColumnByName(stmt.sortKey.sel.col)
+	if err != nil {
+		return err
+	}
+
+	if col.id != table.primaryIndex.cols[0].id {
+		createIndexStmt := &CreateIndexStmt{table: table.name, cols: []string{col.colName}}
+		_, err = createIndexStmt.execAt(ctx, tx, params)
+		if err != nil {
+			return err
+		}
+	}
+
+	table.sortCol = col
+	table.sortDesc = stmt.sortKey.descOrder
+
+	// v={colID}(ASC|DESC)
+	v := make([]byte, EncIDLen+1)
+	copy(v, EncodeID(col.id))
+
+	if table.sortDesc {
+		v[EncIDLen] = 1
+	}
+
+	mappedKey := mapKey(tx.sqlPrefix(), catalogSortKeyPrefix, EncodeID(table.db.id), EncodeID(table.id))
+
+	return tx.set(mappedKey, nil, v)
+}
+
 type ColSpec struct {
 	colName       string
 	colType       SQLValueType
 	maxLen        int
 	autoIncrement bool
 	notNull       bool
+	defaultValue  ValueExp
 }
 
 type CreateIndexStmt struct {
-	unique      bool
+	unique bool
+	// deferred postpones checking the uniqueness of the indexed values until the transaction is committed
+	deferred    bool
 	ifNotExists bool
 	table       string
 	cols        []string
+
+	// exprs holds the expression indexed in place of a column, if any
+	exprs []ValueExp
+}
+
+func newCreateIndexStmt(unique, ifNotExists bool, table string, elems []ValueExp) *CreateIndexStmt {
+	stmt := &CreateIndexStmt{
+		unique:      unique,
+		ifNotExists: ifNotExists,
+		table:       table,
+		cols:        make([]string, len(elems)),
+	}
+
+	for i, e := range elems {
+		sel, isSel := e.(*ColSelector)
+		if isSel && sel.db == "" && sel.table == "" {
+			stmt.cols[i] = sel.col
+			continue
+		}
+
+		if stmt.exprs == nil {
+			stmt.exprs = make([]ValueExp, len(elems))
+		}
+
+		stmt.exprs[i] = e
+	}
+
+	return stmt
 }
 
 func (stmt *CreateIndexStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
@@ -392,7 +531,41 @@ func (stmt *CreateIndexStmt) execAt(ctx context.Context, tx *SQLTx, params map[s
 
 	colIDs := make([]uint32, len(stmt.cols))
 
+	var newExprCols []*Column
+
 	for i, colName := range stmt.cols {
+		if stmt.exprs != nil && stmt.exprs[i] != nil {
+			text, err := indexExprText(stmt.exprs[i], table, table.name)
+			if err != nil {
+				return nil, err
+			}
+
+			sel, isSel := stmt.exprs[i].(*ColSelector)
+			if !isSel {
+				_, indexed := table.exprColsByName[text]
+
+				// the expression is evaluated as parsed from its text, as done when the catalog is loaded
+				expr, err := parseIndexExpr(text)
+				if err != nil {
+					return nil, err
+				}
+
+				col, err := table.newExprColumn(text, expr)
+				if err != nil {
+					return nil, err
+				}
+
+				if !indexed {
+					newExprCols = append(newExprCols, col)
+				}
+
+				colIDs[i] = col.id
+				continue
+			}
+
+			colName = sel.col
+		}
+
 		col, err := table.GetColumnByName(colName)
 		if err != nil {
 			return nil, err
@@ -405,6 +578,10 @@ func (stmt *CreateIndexStmt) execAt(ctx context.Context, tx *SQLTx, params map[s
 		colIDs[i] = col.id
 	}
 
+	if stmt.deferred && !stmt.unique {
+		return nil, fmt.Errorf("%w: only unique indexes can be deferred", ErrIllegalArguments)
+	}
+
 	index, err := table.newIndex(stmt.unique, colIDs)
 	if err == ErrIndexAlreadyExists && stmt.ifNotExists {
 		return tx, nil
@@ -413,6 +590,19 @@ func (stmt *CreateIndexStmt) execAt(ctx context.Context, tx *SQLTx, params map[s
 		return nil, err
 	}
 
+	index.deferred = stmt.deferred
+
+	coveredBy := table.coveringIndex(index)
+	if coveredBy != nil {
+		err := fmt.Errorf("%w: index %s is covered by index %s", ErrRedundantIndex, index.Name(), coveredBy.Name())
+
+		if tx.engine.rejectRedundantIndexes {
+			return nil, err
+		}
+
+		tx.warnings = append(tx.warnings, err)
+	}
+
 	// check table is empty
 	{
 		pkPrefix := mapKey(tx.sqlPrefix(), PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(PKIndexID))
@@ -425,20 +615,33 @@ func (stmt *CreateIndexStmt) execAt(ctx context.Context, tx *SQLTx, params map[s
 		}
 	}
 
-	// v={unique {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)}
+	// v={flags {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)}
 	// TODO: currently only ASC order is supported
 	colSpecLen := EncIDLen + 1
 
 	encodedValues := make([]byte, 1+len(index.cols)*colSpecLen)
 
 	if index.IsUnique() {
-		encodedValues[0] = 1
+		encodedValues[0] |= uniqueIndexFlag
+	}
+
+	if index.IsDeferred() {
+		encodedValues[0] |= deferredIndexFlag
 	}
 
 	for i, col := range index.cols {
 		copy(encodedValues[1+i*colSpecLen:], EncodeID(col.id))
 	}
 
+	for _, col := range newExprCols {
+		mappedKey := mapKey(tx.sqlPrefix(), catalogIndexExprPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(col.id))
+
+		err = tx.set(mappedKey, nil, []byte(col.colName))
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	mappedKey := mapKey(tx.sqlPrefix(), catalogIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(index.id))
 
 	err = tx.set(mappedKey, nil, encodedValues)
@@ -585,6 +788,11 @@ func (stmt *UpsertIntoStmt) execAt(ctx context.Context, tx *SQLTx, params map[st
 		return nil, ErrNoDatabaseSelected
 	}
 
+	err := stmt.tableRef.checkWritable(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	table, err := stmt.tableRef.referencedTable(tx)
 	if err != nil {
 		return nil, err
@@ -606,8 +814,31 @@ func (stmt *UpsertIntoStmt) execAt(ctx context.Context, tx *SQLTx, params map[st
 
 		for colID, col := range table.colsByID {
 			colPos, specified := selPosByColID[colID]
+
+			if specified {
+				_, isDefault := row.Values[colPos].(*DefaultValue)
+
+				if isDefault && !col.autoIncrement && col.defaultValue == nil {
+					return nil, fmt.Errorf("%w (%s)", ErrNoDefaultValue, col.colName)
+				}
+
+				// the next value of an auto-incremental column is its default value
+				specified = !isDefault
+			}
+
+			if !specified && col.defaultValue != nil {
+				rval, err := col.defaultValue.reduce(tx, nil, tx.currentDB.name, table.name)
+				if err != nil {
+					return nil, err
+				}
+
+				if !rval.IsNull() {
+					valuesByColID[colID] = rval
+					continue
+				}
+			}
+
 			if !specified {
-				// TODO: Default values
 				if col.notNull && !col.autoIncrement {
 					return nil, fmt.Errorf("%w (%s)", ErrNotNullableColumnCannotBeNull, col.colName)
 				}
@@ -708,6 +939,12 @@ func (stmt *UpsertIntoStmt) execAt(ctx context.Context, tx *SQLTx, params map[st
 func (tx *SQLTx) doUpsert(ctx context.Context, pkEncVals []byte, valuesByColID map[uint32]TypedValue, table *Table, reuseIndex bool) error {
 	var reusableIndexEntries map[uint32]struct{}
 
+	// index entries also hold the values of indexed expressions
+	indexedValuesByColID, err := tx.withIndexedExprs(table, valuesByColID)
+	if err != nil {
+		return err
+	}
+
 	if reuseIndex && len(table.indexes) > 1 {
 		currPKRow, err := tx.fetchPKRow(ctx, table, valuesByColID)
 		if err != nil && err != ErrNoMoreRows {
@@ -722,7 +959,12 @@ func (tx *SQLTx) doUpsert(ctx context.Context, pkEncVals []byte, valuesByColID m
 				currValuesByColID[col.id] = currPKRow.ValuesBySelector[encSel]
 			}
 
-			reusableIndexEntries, err = tx.deprecateIndexEntries(pkEncVals, currValuesByColID, valuesByColID, table)
+			currValuesByColID, err = tx.withIndexedExprs(table, currValuesByColID)
+			if err != nil {
+				return err
+			}
+
+			reusableIndexEntries, err = tx.deprecateIndexEntries(pkEncVals, currValuesByColID, indexedValuesByColID, table)
 			if err != nil {
 				return err
 			}
@@ -745,7 +987,7 @@ func (tx *SQLTx) doUpsert(ctx context.Context, pkEncVals []byte, valuesByColID m
 	b := make([]byte, EncLenLen)
 	binary.BigEndian.PutUint32(b, uint32(encodedVals))
 
-	_, err := valbuf.Write(b)
+	_, err = valbuf.Write(b)
 	if err != nil {
 		return err
 	}
@@ -797,7 +1039,7 @@ func (tx *SQLTx) doUpsert(ctx context.Context, pkEncVals []byte, valuesByColID m
 		var encodedValues [][]byte
 		var val []byte
 
-		if index.IsUnique() {
+		if index.uniqueKeys() {
 			prefix = UIndexPrefix
 			encodedValues = make([][]byte, 3+len(index.cols))
 			val = pkEncVals
@@ -812,7 +1054,7 @@ func (tx *SQLTx) doUpsert(ctx context.Context, pkEncVals []byte, valuesByColID m
 		encodedValues[2] = EncodeID(index.id)
 
 		for i, col := range index.cols {
-			rval, specified := valuesByColID[col.id]
+			rval, specified := indexedValuesByColID[col.id]
 			if !specified {
 				rval = &NullValue{t: col.colType}
 			}
@@ -827,7 +1069,7 @@ func (tx *SQLTx) doUpsert(ctx context.Context, pkEncVals []byte, valuesByColID m
 
 		mkey := mapKey(tx.sqlPrefix(), prefix, encodedValues...)
 
-		if index.IsUnique() {
+		if index.uniqueKeys() {
 			// mkey must not exist
 			_, err := tx.get(mkey)
 			if err == nil {
@@ -842,6 +1084,10 @@ func (tx *SQLTx) doUpsert(ctx context.Context, pkEncVals []byte, valuesByColID m
 		if err != nil {
 			return err
 		}
+
+		if index.IsDeferred() {
+			tx.deferUniqueCheck(index, mapKey(tx.sqlPrefix(), prefix, encodedValues[:len(encodedValues)-1]...))
+		}
 	}
 
 	tx.updatedRows++
@@ -917,7 +1163,7 @@ func (tx *SQLTx) deprecateIndexEntries(
 		var prefix string
 		var encodedValues [][]byte
 
-		if index.IsUnique() {
+		if index.uniqueKeys() {
 			prefix = UIndexPrefix
 			encodedValues = make([][]byte, 3+len(index.cols))
 		} else {
@@ -976,17 +1222,45 @@ func (tx *SQLTx) deprecateIndexEntries(
 
 type UpdateStmt struct {
 	tableRef *tableRef
-	where    ValueExp
-	updates  []*colUpdate
-	indexOn  []string
-	limit    int
-	offset   int
+	// from is the optional data source whose columns may be referenced by the
+	// updates and the where clause, it's inner joined with the updated table
+	from    DataSource
+	where   ValueExp
+	updates []*colUpdate
+	indexOn []string
+	limit   ValueExp
+	offset  ValueExp
 }
 
 type colUpdate struct {
-	col string
-	op  CmpOperator
-	val ValueExp
+	table string
+	col   string
+	op    CmpOperator
+	val   ValueExp
+}
+
+// selectStmt returns the query resolving the rows to be updated. When a source is specified,
+// each row of the updated table is joined with the source rows satisfying the where clause,
+// a row to be updated matching more than one source row is rejected with ErrAmbiguousUpdate
+func (stmt *UpdateStmt) selectStmt() *SelectStmt {
+	selectStmt := &SelectStmt{
+		ds:      stmt.tableRef,
+		where:   stmt.where,
+		indexOn: stmt.indexOn,
+		limit:   stmt.limit,
+		offset:  stmt.offset,
+	}
+
+	if stmt.from != nil {
+		cond := stmt.where
+		if cond == nil {
+			cond = &Bool{val: true}
+		}
+
+		selectStmt.joins = []*JoinSpec{{joinType: InnerJoin, ds: stmt.from, cond: cond}}
+	}
+
+	return selectStmt
 }
 
 func (stmt *UpdateStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
@@ -996,6 +1270,7 @@ func (stmt *UpdateStmt) inferParameters(ctx context.Context, tx *SQLTx, params m
 
 	selectStmt := &SelectStmt{
 		ds:    stmt.tableRef,
+		joins: stmt.selectStmt().joins,
 		where: stmt.where,
 	}
 
@@ -1009,13 +1284,28 @@ func (stmt *UpdateStmt) inferParameters(ctx context.Context, tx *SQLTx, params m
 		return err
 	}
 
+	cols := make(map[string]ColDescriptor)
+
+	if stmt.from != nil {
+		rowReader, err := selectStmt.Resolve(ctx, tx, nil, nil)
+		if err != nil {
+			return err
+		}
+		defer rowReader.Close()
+
+		cols, err = rowReader.colsBySelector(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	for _, update := range stmt.updates {
 		col, err := table.GetColumnByName(update.col)
 		if err != nil {
 			return err
 		}
 
-		err = update.val.requiresType(col.colType, make(map[string]ColDescriptor), params, tx.currentDB.name, table.name)
+		err = update.val.requiresType(col.colType, cols, params, tx.currentDB.name, stmt.tableRef.Alias())
 		if err != nil {
 			return err
 		}
@@ -1032,6 +1322,10 @@ func (stmt *UpdateStmt) validate(table *Table) error {
 			return ErrIllegalArguments
 		}
 
+		if update.table != "" && update.table != stmt.tableRef.Alias() {
+			return fmt.Errorf("%w: %s.%s is not a column of the updated table", ErrInvalidColumn, update.table, update.col)
+		}
+
 		col, err := table.GetColumnByName(update.col)
 		if err != nil {
 			return err
@@ -1057,21 +1351,19 @@ func (stmt *UpdateStmt) execAt(ctx context.Context, tx *SQLTx, params map[string
 		return nil, ErrNoDatabaseSelected
 	}
 
-	selectStmt := &SelectStmt{
-		ds:      stmt.tableRef,
-		where:   stmt.where,
-		indexOn: stmt.indexOn,
-		limit:   stmt.limit,
-		offset:  stmt.offset,
+	err := stmt.tableRef.checkWritable(tx)
+	if err != nil {
+		return nil, err
 	}
 
-	rowReader, err := selectStmt.Resolve(ctx, tx, params, nil)
+	rowReader, err := stmt.selectStmt().Resolve(ctx, tx, params, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer rowReader.Close()
 
 	table := rowReader.ScanSpecs().Index.table
+	alias := stmt.tableRef.Alias()
 
 	err = stmt.validate(table)
 	if err != nil {
@@ -1083,19 +1375,45 @@ func (stmt *UpdateStmt) execAt(ctx context.Context, tx *SQLTx, params map[string
 		return nil, err
 	}
 
+	// rows updated from a source row are all matched before writing any of them, so rows
+	// are neither read again once updated nor matched against more than one source row
+	var updatedPKs map[string]struct{}
+	var pendingRows []map[uint32]TypedValue
+
+	if stmt.from != nil {
+		updatedPKs = make(map[string]struct{})
+	}
+
 	for {
 		row, err := rowReader.Read(ctx)
 		if err == ErrNoMoreRows {
 			break
 		}
+		if err != nil {
+			return nil, err
+		}
 
 		valuesByColID := make(map[uint32]TypedValue, len(row.ValuesBySelector))
 
 		for _, col := range table.cols {
-			encSel := EncodeSelector("", table.db.name, table.name, col.colName)
+			encSel := EncodeSelector("", table.db.name, alias, col.colName)
 			valuesByColID[col.id] = row.ValuesBySelector[encSel]
 		}
 
+		if updatedPKs != nil {
+			pkEncVals, err := encodedPK(table, valuesByColID)
+			if err != nil {
+				return nil, err
+			}
+
+			_, updated := updatedPKs[string(pkEncVals)]
+			if updated {
+				return nil, ErrAmbiguousUpdate
+			}
+
+			updatedPKs[string(pkEncVals)] = struct{}{}
+		}
+
 		for _, update := range stmt.updates {
 			col, err := table.GetColumnByName(update.col)
 			if err != nil {
@@ -1107,12 +1425,12 @@ func (stmt *UpdateStmt) execAt(ctx context.Context, tx *SQLTx, params map[string
 				return nil, err
 			}
 
-			rval, err := sval.reduce(tx, row, table.db.name, table.name)
+			rval, err := sval.reduce(tx, row, table.db.name, alias)
 			if err != nil {
 				return nil, err
 			}
 
-			err = rval.requiresType(col.colType, cols, nil, table.db.name, table.name)
+			err = rval.requiresType(col.colType, cols, nil, table.db.name, alias)
 			if err != nil {
 				return nil, err
 			}
@@ -1120,21 +1438,23 @@ func (stmt *UpdateStmt) execAt(ctx context.Context, tx *SQLTx, params map[string
 			valuesByColID[col.id] = rval
 		}
 
-		pkEncVals, err := encodedPK(table, valuesByColID)
-		if err != nil {
-			return nil, err
-		}
+		if updatedPKs != nil {
+			if len(pendingRows) == tx.distinctLimit() {
+				return nil, ErrTooManyRows
+			}
 
-		// primary index entry
-		mkey := mapKey(tx.sqlPrefix(), PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(table.primaryIndex.id), pkEncVals)
+			pendingRows = append(pendingRows, valuesByColID)
+			continue
+		}
 
-		// mkey must exist
-		_, err = tx.get(mkey)
+		err = stmt.updateRow(ctx, tx, table, valuesByColID)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		err = tx.doUpsert(ctx, pkEncVals, valuesByColID, table, true)
+	for _, valuesByColID := range pendingRows {
+		err = stmt.updateRow(ctx, tx, table, valuesByColID)
 		if err != nil {
 			return nil, err
 		}
@@ -1143,12 +1463,30 @@ func (stmt *UpdateStmt) execAt(ctx context.Context, tx *SQLTx, params map[string
 	return tx, nil
 }
 
+func (stmt *UpdateStmt) updateRow(ctx context.Context, tx *SQLTx, table *Table, valuesByColID map[uint32]TypedValue) error {
+	pkEncVals, err := encodedPK(table, valuesByColID)
+	if err != nil {
+		return err
+	}
+
+	// primary index entry
+	mkey := mapKey(tx.sqlPrefix(), PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(table.primaryIndex.id), pkEncVals)
+
+	// mkey must exist
+	_, err = tx.get(mkey)
+	if err != nil {
+		return err
+	}
+
+	return tx.doUpsert(ctx, pkEncVals, valuesByColID, table, true)
+}
+
 type DeleteFromStmt struct {
 	tableRef *tableRef
 	where    ValueExp
 	indexOn  []string
-	limit    int
-	offset   int
+	limit    ValueExp
+	offset   ValueExp
 }
 
 func (stmt *DeleteFromStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
@@ -1164,6 +1502,11 @@ func (stmt *DeleteFromStmt) execAt(ctx context.Context, tx *SQLTx, params map[st
 		return nil, ErrNoDatabaseSelected
 	}
 
+	err := stmt.tableRef.checkWritable(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	selectStmt := &SelectStmt{
 		ds:      stmt.tableRef,
 		where:   stmt.where,
@@ -1213,11 +1556,16 @@ func (stmt *DeleteFromStmt) execAt(ctx context.Context, tx *SQLTx, params map[st
 }
 
 func (tx *SQLTx) deleteIndexEntries(pkEncVals []byte, valuesByColID map[uint32]TypedValue, table *Table) error {
+	valuesByColID, err := tx.withIndexedExprs(table, valuesByColID)
+	if err != nil {
+		return err
+	}
+
 	for _, index := range table.indexes {
 		var prefix string
 		var encodedValues [][]byte
 
-		if index.IsUnique() {
+		if index.uniqueKeys() {
 			if index.IsPrimary() {
 				prefix = PIndexPrefix
 			} else {
@@ -1445,6 +1793,37 @@ func (v *NullValue) selectorRanges(table *Table, asTable string, params map[stri
 	return nil
 }
 
+// DefaultValue stands for the default value of a column in the values of an INSERT statement
+type DefaultValue struct{}
+
+func (v *DefaultValue) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return AnyType, nil
+}
+
+func (v *DefaultValue) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	return nil
+}
+
+func (v *DefaultValue) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *DefaultValue) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return nil, fmt.Errorf("%w: DEFAULT can only be used as an inserted value", ErrInvalidValue)
+}
+
+func (v *DefaultValue) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *DefaultValue) isConstant() bool {
+	return false
+}
+
+func (v *DefaultValue) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
 type Number struct {
 	val int64
 }
@@ -1782,57 +2161,192 @@ func (v *Blob) Compare(val TypedValue) (int, error) {
 type FnCall struct {
 	fn     string
 	params []ValueExp
+
+	// function is the called function, bound when the statement is planned, see bindFunctions
+	function *Function
 }
 
 func (v *FnCall) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	if strings.ToUpper(v.fn) == NowFnCall {
-		return TimestampType, nil
+	fn, err := v.lookupFunction()
+	if err != nil {
+		return AnyType, err
 	}
 
-	return AnyType, fmt.Errorf("%w: unkown function %s", ErrIllegalArguments, v.fn)
+	return v.checkParams(fn, cols, params, implicitDB, implicitTable)
 }
 
 func (v *FnCall) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if strings.ToUpper(v.fn) == NowFnCall {
-		if t != TimestampType {
-			return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, TimestampType, t)
+	fn, err := v.lookupFunction()
+	if err != nil {
+		return err
+	}
+
+	resultType, err := v.checkParams(fn, cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return err
+	}
+
+	if resultType == AnyType && fn.sameTypeParams {
+		// none of the arguments has a known type, so all of them must be of the required one
+		for i, p := range v.params {
+			err := p.requiresType(t, cols, params, implicitDB, implicitTable)
+			if err != nil {
+				return fmt.Errorf("invalid argument %d of function '%s': %w", i+1, v.fn, err)
+			}
 		}
 
 		return nil
 	}
 
-	return fmt.Errorf("%w: unkown function %s", ErrIllegalArguments, v.fn)
+	if t != resultType {
+		return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, resultType, t)
+	}
+
+	return nil
 }
 
-func (v *FnCall) substitute(params map[string]interface{}) (val ValueExp, err error) {
-	ps := make([]ValueExp, len(v.params))
+// checkParams validates the arguments of the call and returns the type of its result
+func (v *FnCall) checkParams(fn *Function, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	err := fn.checkArity(v.fn, len(v.params))
+	if err != nil {
+		return AnyType, err
+	}
+
+	if fn.sameTypeParams {
+		return v.unifyParamTypes(cols, params, implicitDB, implicitTable)
+	}
 
 	for i, p := range v.params {
-		ps[i], err = p.substitute(params)
+		if fn.paramType(i) == AnyType {
+			_, err := p.inferType(cols, params, implicitDB, implicitTable)
+			if err != nil {
+				return AnyType, err
+			}
+			continue
+		}
+
+		err := p.requiresType(fn.paramType(i), cols, params, implicitDB, implicitTable)
 		if err != nil {
-			return nil, err
+			return AnyType, fmt.Errorf("invalid argument %d of function '%s': %w", i+1, v.fn, err)
 		}
 	}
 
-	return &FnCall{
-		fn:     v.fn,
-		params: ps,
-	}, nil
+	return fn.ResultType, nil
 }
 
-func (v *FnCall) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	if strings.ToUpper(v.fn) == NowFnCall {
-		if len(v.params) > 0 {
-			return nil, fmt.Errorf("%w: '%s' function does not expect any argument but %d were provided", ErrIllegalArguments, NowFnCall, len(v.params))
+// unifyParamTypes returns the single type all the arguments must be of,
+// arguments without a known type e.g. NULL or parameters, are required to be of such type
+func (v *FnCall) unifyParamTypes(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	t := AnyType
+
+	for i, p := range v.params {
+		pt, err := p.inferType(cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, err
 		}
-		return &Timestamp{val: tx.Timestamp().Truncate(time.Microsecond).UTC()}, nil
-	}
 
-	return nil, fmt.Errorf("%w: unkown function %s", ErrIllegalArguments, v.fn)
-}
+		if pt == AnyType || pt == t {
+			continue
+		}
 
-func (v *FnCall) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return v
+		if t != AnyType {
+			return AnyType, fmt.Errorf("%w: argument %d of function '%s' is of type %v but %v was expected", ErrInvalidTypes, i+1, v.fn, pt, t)
+		}
+
+		t = pt
+	}
+
+	if t == AnyType {
+		return AnyType, nil
+	}
+
+	for i, p := range v.params {
+		err := p.requiresType(t, cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, fmt.Errorf("invalid argument %d of function '%s': %w", i+1, v.fn, err)
+		}
+	}
+
+	return t, nil
+}
+
+func (v *FnCall) substitute(params map[string]interface{}) (val ValueExp, err error) {
+	ps := make([]ValueExp, len(v.params))
+
+	for i, p := range v.params {
+		ps[i], err = p.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &FnCall{
+		fn:       v.fn,
+		params:   ps,
+		function: v.function,
+	}, nil
+}
+
+func (v *FnCall) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	fn, err := v.lookupFunction()
+	if err != nil {
+		return nil, err
+	}
+
+	err = fn.checkArity(v.fn, len(v.params))
+	if err != nil {
+		return nil, err
+	}
+
+	resultType := fn.ResultType
+
+	vals := make([]TypedValue, len(v.params))
+
+	for i, p := range v.params {
+		val, err := p.reduce(tx, row, implicitDB, implicitTable)
+		if err != nil {
+			return nil, err
+		}
+
+		if !val.IsNull() && fn.paramType(i) != AnyType && val.Type() != fn.paramType(i) {
+			return nil, fmt.Errorf("%w: argument %d of function '%s' must be of type %v", ErrInvalidTypes, i+1, v.fn, fn.paramType(i))
+		}
+
+		if !val.IsNull() && fn.sameTypeParams {
+			if resultType != AnyType && val.Type() != resultType {
+				return nil, fmt.Errorf("%w: argument %d of function '%s' must be of type %v", ErrInvalidTypes, i+1, v.fn, resultType)
+			}
+
+			resultType = val.Type()
+		}
+
+		vals[i] = val
+	}
+
+	res, err := fn.Eval(tx, vals)
+	if err != nil {
+		return nil, err
+	}
+
+	if res == nil || (!res.IsNull() && res.Type() != resultType) {
+		return nil, fmt.Errorf("%w: function '%s' must return a value of type %v", ErrInvalidTypes, v.fn, resultType)
+	}
+
+	return res, nil
+}
+
+func (v *FnCall) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	ps := make([]ValueExp, len(v.params))
+
+	for i, p := range v.params {
+		ps[i] = p.reduceSelectors(row, implicitDB, implicitTable)
+	}
+
+	return &FnCall{
+		fn:       v.fn,
+		params:   ps,
+		function: v.function,
+	}
 }
 
 func (v *FnCall) isConstant() bool {
@@ -1934,8 +2448,11 @@ func (c *Cast) substitute(params map[string]interface{}) (ValueExp, error) {
 	if err != nil {
 		return nil, err
 	}
-	c.val = val
-	return c, nil
+
+	return &Cast{
+		val: val,
+		t:   c.t,
+	}, nil
 }
 
 func (c *Cast) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
@@ -1999,6 +2516,10 @@ func (p *Param) substitute(params map[string]interface{}) (ValueExp, error) {
 		return nil, fmt.Errorf("%w(%s)", ErrMissingParameter, p.id)
 	}
 
+	return paramValue(val)
+}
+
+func paramValue(val interface{}) (TypedValue, error) {
 	if val == nil {
 		return &NullValue{t: AnyType}, nil
 	}
@@ -2074,6 +2595,7 @@ type DataSource interface {
 }
 
 type SelectStmt struct {
+	ctes      []*CTE
 	distinct  bool
 	selectors []Selector
 	ds        DataSource
@@ -2081,18 +2603,19 @@ type SelectStmt struct {
 	joins     []*JoinSpec
 	where     ValueExp
 	groupBy   []*ColSelector
+	grouping  *groupByClause
 	having    ValueExp
-	limit     int
-	offset    int
+	limit     ValueExp
+	offset    ValueExp
 	orderBy   []*OrdCol
 	as        string
 }
 
-func (stmt *SelectStmt) Limit() int {
+func (stmt *SelectStmt) Limit() ValueExp {
 	return stmt.limit
 }
 
-func (stmt *SelectStmt) Offset() int {
+func (stmt *SelectStmt) Offset() ValueExp {
 	return stmt.offset
 }
 
@@ -2121,15 +2644,52 @@ func (stmt *SelectStmt) execAt(ctx context.Context, tx *SQLTx, params map[string
 		return nil, ErrHavingClauseRequiresGroupClause
 	}
 
-	if len(stmt.groupBy) > 1 {
+	for _, col := range stmt.groupBy {
+		// positions are kept only when they could not be resolved
+		if col.col != "" {
+			continue
+		}
+
+		if col.ordinal < 1 || col.ordinal > len(stmt.selectors) {
+			return nil, fmt.Errorf("%w: GROUP BY position %d is not in select list", ErrIllegalArguments, col.ordinal)
+		}
+
+		return nil, fmt.Errorf("%w: GROUP BY position %d does not refer to a column", ErrIllegalArguments, col.ordinal)
+	}
+
+	if len(stmt.groupBy) > 1 && stmt.grouping == nil {
 		return nil, ErrLimitedGroupBy
 	}
 
+	err := stmt.validateGrouping()
+	if err != nil {
+		return nil, err
+	}
+
 	if len(stmt.orderBy) > 1 {
 		return nil, ErrLimitedOrderBy
 	}
 
+	if len(stmt.orderBy) > 0 && stmt.orderBy[0].collation != "" {
+		err := stmt.orderBy[0].collation.validFor(AnyType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if len(stmt.orderBy) > 0 {
+		err := stmt.orderBy[0].nulls.valid()
+		if err != nil {
+			return nil, err
+		}
+
+		// groups are built from rows with equal values, which are only contiguous when read in index order
+		if stmt.groupBy != nil && stmt.orderBy[0].sortedInMemory() {
+			return nil, ErrLimitedGroupBy
+		}
+	}
+
+	if len(stmt.orderBy) > 0 && !stmt.orderBy[0].sortedInMemory() {
 		tableRef, ok := stmt.ds.(*tableRef)
 		if !ok {
 			return nil, ErrLimitedOrderBy
@@ -2149,6 +2709,11 @@ func (stmt *SelectStmt) execAt(ctx context.Context, tx *SQLTx, params map[string
 		if !indexed {
 			return nil, ErrLimitedOrderBy
 		}
+
+		// the history of a table is only read in primary key order
+		if tableRef.history && table.primaryIndex.cols[0].id != col.id {
+			return nil, ErrLimitedOrderBy
+		}
 	}
 
 	return tx, nil
@@ -2160,7 +2725,35 @@ func (stmt *SelectStmt) Resolve(ctx context.Context, tx *SQLTx, params map[strin
 		return nil, err
 	}
 
-	rowReader, err := stmt.ds.Resolve(ctx, tx, params, scanSpecs)
+	containsAggregations := stmt.containsAggregations()
+
+	ds := stmt.ds
+
+	union, isUnion := stmt.ds.(*UnionStmt)
+	if isUnion && stmt.joins == nil {
+		// rows from the union are still filtered and limited below
+		limit := 0
+
+		// each branch can only be cut when the rows are not sorted or filtered after grouping
+		if !stmt.distinct && stmt.groupBy == nil && !containsAggregations && stmt.orderBy == nil && stmt.having == nil {
+			limit = stmt.constantBounds()
+		}
+
+		ds = union.pushdown(tx, stmt.where, limit)
+	}
+
+	lookups, err := stmt.tupleLookups(tx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var rowReader RowReader
+
+	if lookups != nil {
+		rowReader, err = stmt.resolveTupleLookups(ctx, tx, params, lookups)
+	} else {
+		rowReader, err = ds.Resolve(ctx, tx, params, scanSpecs)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -2171,26 +2764,57 @@ func (stmt *SelectStmt) Resolve(ctx context.Context, tx *SQLTx, params map[strin
 	}()
 
 	if stmt.joins != nil {
-		jointRowReader, err := newJointRowReader(rowReader, stmt.joins)
+		jointRowReader, err := newJointRowReader(ctx, rowReader, stmt.joins)
 		if err != nil {
 			return nil, err
 		}
 		rowReader = jointRowReader
+
+		err = stmt.checkJoinComplexity(tx)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if stmt.where != nil {
+		err = checkFnCalls(ctx, rowReader, stmt.where)
+		if err != nil {
+			return nil, err
+		}
+
+		// invalid patterns must be reported before reading any row
+		err = compileRegexps(stmt.where, params)
+		if err != nil {
+			return nil, err
+		}
+
+		err = checkTupleArities(stmt.where)
+		if err != nil {
+			return nil, err
+		}
+
 		rowReader = newConditionalRowReader(rowReader, stmt.where)
 	}
 
-	containsAggregations := false
-	for _, sel := range stmt.selectors {
-		_, containsAggregations = sel.(*AggColSelector)
-		if containsAggregations {
-			break
+	if len(stmt.orderBy) > 0 && stmt.orderBy[0].sortedInMemory() {
+		sortedRowReader, err := newSortedRowReader(ctx, rowReader, stmt.orderBy[0])
+		if err != nil {
+			return nil, err
 		}
+		rowReader = sortedRowReader
 	}
 
-	if containsAggregations {
+	if stmt.grouping != nil {
+		groupingSetsRowReader, err := newGroupingSetsRowReader(ctx, rowReader, stmt.selectors, stmt.groupBy, stmt.grouping.groupingSets())
+		if err != nil {
+			return nil, err
+		}
+		rowReader = groupingSetsRowReader
+
+		if stmt.having != nil {
+			rowReader = newConditionalRowReader(rowReader, stmt.having)
+		}
+	} else if containsAggregations {
 		var groupBy []*ColSelector
 		if stmt.groupBy != nil {
 			groupBy = stmt.groupBy
@@ -2200,6 +2824,8 @@ func (stmt *SelectStmt) Resolve(ctx context.Context, tx *SQLTx, params map[strin
 		if err != nil {
 			return nil, err
 		}
+		groupedRowReader.stats = queryStatsFrom(ctx)
+		groupedRowReader.mem = memoryAccountFrom(ctx)
 		rowReader = groupedRowReader
 
 		if stmt.having != nil {
@@ -2207,6 +2833,13 @@ func (stmt *SelectStmt) Resolve(ctx context.Context, tx *SQLTx, params map[strin
 		}
 	}
 
+	for _, sel := range stmt.selectors {
+		err = checkFnCalls(ctx, rowReader, sel)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	projectedRowReader, err := newProjectedRowReader(ctx, rowReader, stmt.as, stmt.selectors)
 	if err != nil {
 		return nil, err
@@ -2221,17 +2854,192 @@ func (stmt *SelectStmt) Resolve(ctx context.Context, tx *SQLTx, params map[strin
 		rowReader = distinctRowReader
 	}
 
-	if stmt.offset > 0 {
+	if stmt.offset != nil {
 		rowReader = newOffsetRowReader(rowReader, stmt.offset)
 	}
 
-	if stmt.limit > 0 {
+	if stmt.limit != nil {
 		rowReader = newLimitRowReader(rowReader, stmt.limit)
 	}
 
 	return rowReader, nil
 }
 
+// constantBounds returns the number of rows needed to apply the LIMIT and OFFSET clauses,
+// zero is returned if there is no limit or the bounds are not constant (e.g. parameters)
+func (stmt *SelectStmt) constantBounds() int {
+	limit, isNumber := stmt.limit.(*Number)
+	if !isNumber || limit.val <= 0 {
+		return 0
+	}
+
+	if stmt.offset == nil {
+		return int(limit.val)
+	}
+
+	offset, isNumber := stmt.offset.(*Number)
+	if !isNumber || offset.val < 0 {
+		return 0
+	}
+
+	return int(offset.val + limit.val)
+}
+
+func (stmt *SelectStmt) containsAggregations() bool {
+	for _, sel := range stmt.selectors {
+		_, isAggregation := sel.(*AggColSelector)
+		if isAggregation {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nameAggregatedExps names the values of the expressions aggregated by the statement, so they are
+// accumulated as the ones of any other column. Aggregations in the HAVING clause refer to the values
+// of the equivalent aggregations in the selectors
+func (stmt *SelectStmt) nameAggregatedExps() {
+	var named []*AggColSelector
+
+	nameAs := func(agg *AggColSelector) {
+		for _, n := range named {
+			if reflect.DeepEqual(n.exp, agg.exp) {
+				agg.col = n.col
+				return
+			}
+		}
+
+		agg.col = fmt.Sprintf("(exp%d)", len(named))
+		named = append(named, agg)
+	}
+
+	for _, sel := range stmt.selectors {
+		agg, isAggregation := sel.(*AggColSelector)
+		if isAggregation && agg.exp != nil {
+			nameAs(agg)
+		}
+	}
+
+	var aggs []*AggColSelector
+
+	collectAggregations(stmt.having, &aggs)
+
+	for _, agg := range aggs {
+		if agg.exp != nil {
+			nameAs(agg)
+		}
+	}
+}
+
+// resolveGroupByOrdinals replaces the positions of the selectors referenced in the GROUP BY clause
+// with the selected columns. Positions out of range or of selectors other than columns are kept,
+// so the statement is rejected when executed
+func (stmt *SelectStmt) resolveGroupByOrdinals() {
+	resolve := func(col *ColSelector) *ColSelector {
+		if col.ordinal < 1 || col.ordinal > len(stmt.selectors) {
+			return col
+		}
+
+		sel, isCol := stmt.selectors[col.ordinal-1].(*ColSelector)
+		if !isCol {
+			return col
+		}
+
+		return &ColSelector{db: sel.db, table: sel.table, col: sel.col}
+	}
+
+	if stmt.grouping != nil {
+		// the same column may be referenced by name and position in different sets
+		stmt.grouping.resolveCols(resolve)
+		stmt.groupBy = stmt.grouping.columns()
+		return
+	}
+
+	for i, col := range stmt.groupBy {
+		stmt.groupBy[i] = resolve(col)
+	}
+}
+
+func collectAggregations(exp ValueExp, aggs *[]*AggColSelector) {
+	agg, isAggregation := exp.(*AggColSelector)
+	if isAggregation {
+		*aggs = append(*aggs, agg)
+	}
+
+	for _, e := range subExps(exp) {
+		collectAggregations(e, aggs)
+	}
+}
+
+// projectedColSelectors returns the selectors of the columns returned by the statement,
+// it's only possible when all of them are plain column references
+func (stmt *SelectStmt) projectedColSelectors(tx *SQLTx) ([]*ColSelector, bool) {
+	if len(stmt.selectors) == 0 {
+		tableRef, isTableRef := stmt.ds.(*tableRef)
+		if !isTableRef || len(stmt.joins) > 0 {
+			return nil, false
+		}
+
+		table, err := tableRef.referencedTable(tx)
+		if err != nil {
+			return nil, false
+		}
+
+		cols := make([]*ColSelector, len(table.cols))
+		for i, col := range table.cols {
+			cols[i] = &ColSelector{col: col.colName}
+		}
+
+		return cols, true
+	}
+
+	cols := make([]*ColSelector, len(stmt.selectors))
+
+	for i, sel := range stmt.selectors {
+		colSel, isColSel := sel.(*ColSelector)
+		if !isColSel {
+			return nil, false
+		}
+
+		cols[i] = colSel
+	}
+
+	return cols, true
+}
+
+func (stmt *SelectStmt) pushdownInto(tx *SQLTx, cond func(cols []*ColSelector) (ValueExp, bool), limit int) *SelectStmt {
+	if stmt.limit != nil || stmt.offset != nil || stmt.groupBy != nil || stmt.containsAggregations() {
+		return stmt
+	}
+
+	cols, ok := stmt.projectedColSelectors(tx)
+	if !ok {
+		return stmt
+	}
+
+	exp, ok := cond(cols)
+	if !ok {
+		return stmt
+	}
+
+	pushed := *stmt
+
+	if exp != nil {
+		if stmt.where == nil {
+			pushed.where = exp
+		} else {
+			pushed.where = &BinBoolExp{op: AND, left: stmt.where, right: exp}
+		}
+	}
+
+	if limit > 0 {
+		pushed.limit = &Number{val: int64(limit)}
+	}
+
+	return &pushed
+}
+
 func (stmt *SelectStmt) Alias() string {
 	if stmt.as == "" {
 		return stmt.ds.Alias()
@@ -2284,15 +3092,23 @@ func (stmt *SelectStmt) genScanSpecs(tx *SQLTx, params map[string]interface{}) (
 	var sortingIndex *Index
 	var descOrder bool
 
-	if stmt.orderBy == nil {
+	indexOrdered := len(stmt.orderBy) > 0 && !stmt.orderBy[0].sortedInMemory()
+
+	if !indexOrdered {
 		if preferredIndex == nil {
-			sortingIndex = table.primaryIndex
+			// rows are sorted by the sort key of the table, if any
+			sortingIndex, descOrder = table.sortingIndex(rangesByColID)
+
+			// an index on a constrained expression is preferred, its values can only be looked up through it
+			if exprIndex := table.exprIndexFor(rangesByColID); exprIndex != nil {
+				sortingIndex, descOrder = exprIndex, false
+			}
 		} else {
 			sortingIndex = preferredIndex
 		}
 	}
 
-	if len(stmt.orderBy) > 0 {
+	if indexOrdered {
 		col, err := table.GetColumnByName(stmt.orderBy[0].sel.col)
 		if err != nil {
 			return nil, err
@@ -2324,6 +3140,7 @@ func (stmt *SelectStmt) genScanSpecs(tx *SQLTx, params map[string]interface{}) (
 type UnionStmt struct {
 	distinct    bool
 	left, right DataSource
+	as          string
 }
 
 func (stmt *UnionStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
@@ -2392,28 +3209,539 @@ func (stmt *UnionStmt) Resolve(ctx context.Context, tx *SQLTx, params map[string
 		rowReader = distinctReader
 	}
 
-	return rowReader, nil
+	// columns of the union are referenced through its alias when used as a data source
+	projectedRowReader, err := newProjectedRowReader(ctx, rowReader, stmt.Alias(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return projectedRowReader, nil
 }
 
 func (stmt *UnionStmt) Alias() string {
-	return ""
-}
+	if stmt.as == "" {
+		return stmt.left.Alias()
+	}
 
-type tableRef struct {
-	db     string
-	table  string
-	period period
-	as     string
+	return stmt.as
 }
 
-type period struct {
-	start *openPeriod
-	end   *openPeriod
-}
+// pushdown returns a copy of the union where the predicate, expressed over the columns of the union,
+// is included in the WHERE clause of each branch, so branches may narrow down their own scans.
+// When limit is positive, branches combined with UNION ALL are also limited to that number of rows.
+// Branches where it's not safe to do so are kept unchanged, thus the predicate and limit must
+// still be applied over the rows of the union.
+func (stmt *UnionStmt) pushdown(tx *SQLTx, where ValueExp, limit int) *UnionStmt {
+	if where == nil && limit <= 0 {
+		return stmt
+	}
 
-type openPeriod struct {
-	inclusive bool
-	instant   periodInstant
+	colNames, ok := stmt.columnNames(tx)
+	if !ok {
+		return stmt
+	}
+
+	colPosByName := make(map[string]int, len(colNames))
+
+	for i, name := range colNames {
+		_, duplicated := colPosByName[name]
+		if duplicated {
+			// ambiguous column references can not be mapped into the branches
+			return stmt
+		}
+
+		colPosByName[name] = i
+	}
+
+	cond := func(cols []*ColSelector) (ValueExp, bool) {
+		if where == nil {
+			return nil, true
+		}
+
+		return rewriteColSelectors(where, func(sel *ColSelector) (ValueExp, bool) {
+			if sel.db != "" || (sel.table != "" && sel.table != stmt.Alias()) {
+				return nil, false
+			}
+
+			pos, ok := colPosByName[sel.col]
+			if !ok || pos >= len(cols) {
+				return nil, false
+			}
+
+			return &ColSelector{db: cols[pos].db, table: cols[pos].table, col: cols[pos].col}, true
+		})
+	}
+
+	return stmt.pushdownInto(tx, cond, limit)
+}
+
+func (stmt *UnionStmt) pushdownInto(tx *SQLTx, cond func(cols []*ColSelector) (ValueExp, bool), limit int) *UnionStmt {
+	if stmt.distinct {
+		limit = 0
+	}
+
+	return &UnionStmt{
+		distinct: stmt.distinct,
+		left:     pushdownInto(tx, stmt.left, cond, limit),
+		right:    pushdownInto(tx, stmt.right, cond, limit),
+		as:       stmt.as,
+	}
+}
+
+func (stmt *UnionStmt) columnNames(tx *SQLTx) ([]string, bool) {
+	return columnNames(tx, stmt.left)
+}
+
+// columnNames returns the names of the columns of the rows combined by a union or set operation,
+// which are the ones of its leftmost subquery
+func columnNames(tx *SQLTx, ds DataSource) ([]string, bool) {
+	switch ds := ds.(type) {
+	case *SelectStmt:
+		{
+			cols, ok := ds.projectedColSelectors(tx)
+			if !ok {
+				return nil, false
+			}
+
+			names := make([]string, len(cols))
+			for i, col := range cols {
+				names[i] = col.alias()
+			}
+
+			return names, true
+		}
+	case *UnionStmt:
+		{
+			return columnNames(tx, ds.left)
+		}
+	case *SetOpStmt:
+		{
+			return columnNames(tx, ds.left)
+		}
+	}
+
+	return nil, false
+}
+
+// SetOpStmt combines the rows of two subqueries with EXCEPT or INTERSECT.
+// Duplicated rows are removed unless ALL is specified
+type SetOpStmt struct {
+	op          SetOperator
+	distinct    bool
+	left, right DataSource
+	as          string
+}
+
+func (stmt *SetOpStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
+	err := stmt.left.inferParameters(ctx, tx, params)
+	if err != nil {
+		return err
+	}
+
+	return stmt.right.inferParameters(ctx, tx, params)
+}
+
+func (stmt *SetOpStmt) execAt(ctx context.Context, tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	_, err := stmt.left.execAt(ctx, tx, params)
+	if err != nil {
+		return tx, err
+	}
+
+	return stmt.right.execAt(ctx, tx, params)
+}
+
+func (stmt *SetOpStmt) Resolve(ctx context.Context, tx *SQLTx, params map[string]interface{}, _ *ScanSpecs) (ret RowReader, err error) {
+	leftRowReader, err := stmt.left.Resolve(ctx, tx, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			leftRowReader.Close()
+		}
+	}()
+
+	rightRowReader, err := stmt.right.Resolve(ctx, tx, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			rightRowReader.Close()
+		}
+	}()
+
+	rowReader, err := newSetOpRowReader(ctx, stmt.op, stmt.distinct, leftRowReader, rightRowReader)
+	if err != nil {
+		return nil, err
+	}
+
+	// columns of the set operation are referenced through its alias when used as a data source
+	projectedRowReader, err := newProjectedRowReader(ctx, rowReader, stmt.Alias(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return projectedRowReader, nil
+}
+
+func (stmt *SetOpStmt) Alias() string {
+	if stmt.as == "" {
+		return stmt.left.Alias()
+	}
+
+	return stmt.as
+}
+
+// pushdownInto includes the predicate in both subqueries, a row is filtered out from the result
+// exactly when it's filtered out from each subquery. Limits can not be pushed down.
+func (stmt *SetOpStmt) pushdownInto(tx *SQLTx, cond func(cols []*ColSelector) (ValueExp, bool), _ int) *SetOpStmt {
+	return &SetOpStmt{
+		op:       stmt.op,
+		distinct: stmt.distinct,
+		left:     pushdownInto(tx, stmt.left, cond, 0),
+		right:    pushdownInto(tx, stmt.right, cond, 0),
+		as:       stmt.as,
+	}
+}
+
+func pushdownInto(tx *SQLTx, ds DataSource, cond func(cols []*ColSelector) (ValueExp, bool), limit int) DataSource {
+	switch ds := ds.(type) {
+	case *SelectStmt:
+		{
+			return ds.pushdownInto(tx, cond, limit)
+		}
+	case *UnionStmt:
+		{
+			return ds.pushdownInto(tx, cond, limit)
+		}
+	case *SetOpStmt:
+		{
+			return ds.pushdownInto(tx, cond, limit)
+		}
+	}
+
+	return ds
+}
+
+// rewriteColSelectors returns a copy of the expression where column selectors are replaced
+// by the expressions returned by fn. It fails if any part of the expression can not be rewritten.
+func rewriteColSelectors(exp ValueExp, fn func(sel *ColSelector) (ValueExp, bool)) (ValueExp, bool) {
+	rewriteAll := func(exps []ValueExp) ([]ValueExp, bool) {
+		rexps := make([]ValueExp, len(exps))
+
+		for i, e := range exps {
+			re, ok := rewriteColSelectors(e, fn)
+			if !ok {
+				return nil, false
+			}
+
+			rexps[i] = re
+		}
+
+		return rexps, true
+	}
+
+	switch e := exp.(type) {
+	case *ColSelector:
+		{
+			return fn(e)
+		}
+	case *NullValue, *Number, *Varchar, *Bool, *Blob, *Timestamp, *Array, *Param:
+		{
+			return e, true
+		}
+	case *Cast:
+		{
+			val, ok := rewriteColSelectors(e.val, fn)
+			return &Cast{val: val, t: e.t}, ok
+		}
+	case *FnCall:
+		{
+			params, ok := rewriteAll(e.params)
+			return &FnCall{fn: e.fn, params: params, function: e.function}, ok
+		}
+	case *NumExp:
+		{
+			exps, ok := rewriteAll([]ValueExp{e.left, e.right})
+			if !ok {
+				return nil, false
+			}
+			return &NumExp{op: e.op, left: exps[0], right: exps[1]}, true
+		}
+	case *CmpBoolExp:
+		{
+			exps, ok := rewriteAll([]ValueExp{e.left, e.right})
+			if !ok {
+				return nil, false
+			}
+			return &CmpBoolExp{op: e.op, left: exps[0], right: exps[1]}, true
+		}
+	case *BinBoolExp:
+		{
+			exps, ok := rewriteAll([]ValueExp{e.left, e.right})
+			if !ok {
+				return nil, false
+			}
+			return &BinBoolExp{op: e.op, left: exps[0], right: exps[1]}, true
+		}
+	case *DistinctFromBoolExp:
+		{
+			exps, ok := rewriteAll([]ValueExp{e.left, e.right})
+			if !ok {
+				return nil, false
+			}
+			return &DistinctFromBoolExp{left: exps[0], right: exps[1], negate: e.negate}, true
+		}
+	case *ConcatExp:
+		{
+			exps, ok := rewriteAll([]ValueExp{e.left, e.right})
+			if !ok {
+				return nil, false
+			}
+			return &ConcatExp{left: exps[0], right: exps[1]}, true
+		}
+	case *ArrayExp:
+		{
+			elems, ok := rewriteAll(e.elems)
+			return &ArrayExp{elems: elems}, ok
+		}
+	case *CmpAnyBoolExp:
+		{
+			exps, ok := rewriteAll([]ValueExp{e.val, e.arr})
+			if !ok {
+				return nil, false
+			}
+			return &CmpAnyBoolExp{op: e.op, val: exps[0], arr: exps[1]}, true
+		}
+	case *ArrayContainsExp:
+		{
+			exps, ok := rewriteAll([]ValueExp{e.left, e.right})
+			if !ok {
+				return nil, false
+			}
+			return &ArrayContainsExp{left: exps[0], right: exps[1]}, true
+		}
+	case *NotBoolExp:
+		{
+			rexp, ok := rewriteColSelectors(e.exp, fn)
+			return &NotBoolExp{exp: rexp}, ok
+		}
+	case *LikeBoolExp:
+		{
+			exps, ok := rewriteAll([]ValueExp{e.val, e.pattern})
+			if !ok {
+				return nil, false
+			}
+			return &LikeBoolExp{val: exps[0], notLike: e.notLike, pattern: exps[1]}, true
+		}
+	case *RegexpBoolExp:
+		{
+			exps, ok := rewriteAll([]ValueExp{e.val, e.pattern})
+			if !ok {
+				return nil, false
+			}
+			return &RegexpBoolExp{val: exps[0], notMatch: e.notMatch, pattern: exps[1], caseInsensitive: e.caseInsensitive, cache: e.cache}, true
+		}
+	case *InListExp:
+		{
+			val, ok := rewriteColSelectors(e.val, fn)
+			if !ok {
+				return nil, false
+			}
+
+			values, ok := rewriteAll(e.values)
+			return &InListExp{val: val, notIn: e.notIn, values: values}, ok
+		}
+	case *TupleInListExp:
+		{
+			vals, ok := rewriteAll(e.vals)
+			if !ok {
+				return nil, false
+			}
+
+			tuples := make([][]ValueExp, len(e.tuples))
+
+			for i, tuple := range e.tuples {
+				tuples[i], ok = rewriteAll(tuple)
+				if !ok {
+					return nil, false
+				}
+			}
+
+			return &TupleInListExp{vals: vals, notIn: e.notIn, tuples: tuples}, true
+		}
+	case *CaseExp:
+		{
+			whens := make([]*whenThen, len(e.whens))
+
+			for i, w := range e.whens {
+				exps, ok := rewriteAll([]ValueExp{w.cond, w.result})
+				if !ok {
+					return nil, false
+				}
+
+				whens[i] = &whenThen{cond: exps[0], result: exps[1]}
+			}
+
+			if e.elseExp == nil {
+				return &CaseExp{whens: whens}, true
+			}
+
+			elseExp, ok := rewriteColSelectors(e.elseExp, fn)
+			return &CaseExp{whens: whens, elseExp: elseExp}, ok
+		}
+	}
+
+	return nil, false
+}
+
+// subExps returns the expressions directly nested in exp, expressions in subqueries are not included
+func subExps(exp ValueExp) []ValueExp {
+	switch e := exp.(type) {
+	case *ExpSelector:
+		return []ValueExp{e.exp}
+	case *AggColSelector:
+		if e.exp != nil {
+			return []ValueExp{e.exp}
+		}
+	case *Cast:
+		return []ValueExp{e.val}
+	case *FnCall:
+		return e.params
+	case *NumExp:
+		return []ValueExp{e.left, e.right}
+	case *CmpBoolExp:
+		return []ValueExp{e.left, e.right}
+	case *BinBoolExp:
+		return []ValueExp{e.left, e.right}
+	case *DistinctFromBoolExp:
+		return []ValueExp{e.left, e.right}
+	case *ConcatExp:
+		return []ValueExp{e.left, e.right}
+	case *ArrayExp:
+		return e.elems
+	case *CmpAnyBoolExp:
+		return []ValueExp{e.val, e.arr}
+	case *ArrayContainsExp:
+		return []ValueExp{e.left, e.right}
+	case *NotBoolExp:
+		return []ValueExp{e.exp}
+	case *LikeBoolExp:
+		return []ValueExp{e.val, e.pattern}
+	case *RegexpBoolExp:
+		return []ValueExp{e.val, e.pattern}
+	case *InListExp:
+		return append([]ValueExp{e.val}, e.values...)
+	case *TupleInListExp:
+		{
+			exps := append([]ValueExp{}, e.vals...)
+
+			for _, tuple := range e.tuples {
+				exps = append(exps, tuple...)
+			}
+
+			return exps
+		}
+	case *CaseExp:
+		{
+			exps := make([]ValueExp, 0, 2*len(e.whens)+1)
+
+			for _, w := range e.whens {
+				exps = append(exps, w.cond, w.result)
+			}
+
+			if e.elseExp != nil {
+				exps = append(exps, e.elseExp)
+			}
+
+			return exps
+		}
+	}
+
+	return nil
+}
+
+type tableRef struct {
+	db     string
+	table  string
+	period period
+	// history makes every version of each row to be read (HISTORY), see newHistoryRowReader
+	history bool
+	as      string
+	sample  *tableSample
+}
+
+// tableSample holds the sampling clause of a table (TABLESAMPLE),
+// percentage is the probability of including each row and seed makes the sample repeatable
+type tableSample struct {
+	percentage ValueExp
+	seed       ValueExp
+}
+
+func (s *tableSample) resolve(tx *SQLTx, params map[string]interface{}) (percentage int64, seed int64, err error) {
+	percentage, err = resolveInteger(tx, params, s.percentage, tx.currentDB.name)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if percentage < 0 || percentage > 100 {
+		return 0, 0, fmt.Errorf("%w: invalid sample percentage, it must be between 0 and 100, %d given", ErrIllegalArguments, percentage)
+	}
+
+	if s.seed == nil {
+		return percentage, time.Now().UnixNano(), nil
+	}
+
+	seed, err = resolveInteger(tx, params, s.seed, tx.currentDB.name)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return percentage, seed, nil
+}
+
+// resolveBound resolves the bound of a LIMIT or OFFSET clause, which must be a non-negative integer
+func resolveBound(tx *SQLTx, params map[string]interface{}, exp ValueExp, implicitDB, clause string) (int, error) {
+	n, err := resolveInteger(tx, params, exp, implicitDB)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid %s", err, clause)
+	}
+
+	if n < 0 {
+		return 0, fmt.Errorf("%w: %s must not be negative, %d given", ErrIllegalArguments, clause, n)
+	}
+
+	return int(n), nil
+}
+
+func resolveInteger(tx *SQLTx, params map[string]interface{}, exp ValueExp, implicitDB string) (int64, error) {
+	exp, err := exp.substitute(params)
+	if err != nil {
+		return 0, err
+	}
+
+	val, err := exp.reduce(tx, nil, implicitDB, "")
+	if err != nil {
+		return 0, err
+	}
+
+	n, ok := val.Value().(int64)
+	if !ok {
+		return 0, fmt.Errorf("%w: integer value expected, %s given", ErrIllegalArguments, val.Type())
+	}
+
+	return n, nil
+}
+
+type period struct {
+	start *openPeriod
+	end   *openPeriod
+}
+
+type openPeriod struct {
+	inclusive bool
+	instant   periodInstant
 }
 
 type periodInstant struct {
@@ -2515,15 +3843,30 @@ func (stmt *tableRef) referencedTable(tx *SQLTx) (*Table, error) {
 		return nil, ErrNoDatabaseSelected
 	}
 
+	db := tx.currentDB
+
 	if stmt.db != "" && stmt.db != tx.currentDB.name {
-		return nil,
-			fmt.Errorf(
-				"%w: statements must only involve current selected database '%s' but '%s' was referenced",
-				ErrNoSupported, tx.currentDB.name, stmt.db,
-			)
+		if tx.engine.multidbHandler != nil {
+			// databases managed by the handler are backed by independent stores,
+			// thus there is no consistent snapshot to read them within a single transaction
+			return nil,
+				fmt.Errorf(
+					"%w: statements must only involve current selected database '%s' but '%s' was referenced",
+					ErrNoSupported, tx.currentDB.name, stmt.db,
+				)
+		}
+
+		// tables of other databases are read within the same transaction, as all the databases
+		// of the engine share the same underlying store
+		referencedDB, err := tx.catalog.GetDatabaseByName(stmt.db)
+		if err != nil {
+			return nil, err
+		}
+
+		db = referencedDB
 	}
 
-	table, err := tx.currentDB.GetTableByName(stmt.table)
+	table, err := db.GetTableByName(stmt.table)
 	if err != nil {
 		return nil, err
 	}
@@ -2531,7 +3874,20 @@ func (stmt *tableRef) referencedTable(tx *SQLTx) (*Table, error) {
 	return table, nil
 }
 
-func (stmt *tableRef) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
+// checkWritable returns an error if the referenced table does not belong to the current selected database,
+// as only reads may span multiple databases
+func (stmt *tableRef) checkWritable(tx *SQLTx) error {
+	if stmt.db != "" && stmt.db != tx.currentDB.name {
+		return fmt.Errorf(
+			"%w: statements must only modify current selected database '%s' but '%s' was referenced",
+			ErrNoSupported, tx.currentDB.name, stmt.db,
+		)
+	}
+
+	return nil
+}
+
+func (stmt *tableRef) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
 	return nil
 }
 
@@ -2549,7 +3905,31 @@ func (stmt *tableRef) Resolve(ctx context.Context, tx *SQLTx, params map[string]
 		return nil, err
 	}
 
-	return newRawRowReader(tx, params, table, stmt.period, stmt.as, scanSpecs)
+	var rowReader RowReader
+
+	if stmt.history {
+		hr, err := newHistoryRowReader(tx, params, table, stmt.period, stmt.as, scanSpecs)
+		if err != nil {
+			return nil, err
+		}
+
+		hr.collectStats(queryStatsFrom(ctx))
+		rowReader = hr
+	} else {
+		rr, err := newRawRowReader(tx, params, table, stmt.period, stmt.as, scanSpecs)
+		if err != nil {
+			return nil, err
+		}
+
+		rr.collectStats(queryStatsFrom(ctx))
+		rowReader = rr
+	}
+
+	if stmt.sample == nil {
+		return rowReader, nil
+	}
+
+	return newSampleRowReader(rowReader, stmt.sample), nil
 }
 
 func (stmt *tableRef) Alias() string {
@@ -2564,11 +3944,43 @@ type JoinSpec struct {
 	ds       DataSource
 	cond     ValueExp
 	indexOn  []string
+
+	// lateral subqueries are resolved for each row of the preceding data sources,
+	// thus their where clause may reference any of their columns
+	lateral bool
 }
 
 type OrdCol struct {
 	sel       *ColSelector
 	descOrder bool
+	// collation overrides the order of the values of the column, see sortedInMemory
+	collation Collation
+	// nulls overrides the placement of NULL values, see nullsFirst
+	nulls NullsOrder
+}
+
+// sortedInMemory tells if rows are sorted in memory instead of being read in the order of an index,
+// as done when the order is overridden by a collation other than the binary one of indexes
+// or when NULL values are placed differently than in a scan of an index in the same direction
+func (oc *OrdCol) sortedInMemory() bool {
+	if oc.collation != "" && oc.collation != BinaryCollation {
+		return true
+	}
+
+	return oc.nullsFirst() != nullsFirstInIndexOrder(oc.descOrder)
+}
+
+// nullsFirst tells if NULL values come before any other value,
+// as they do in index order unless explicitly placed with NULLS FIRST or NULLS LAST
+func (oc *OrdCol) nullsFirst() bool {
+	switch oc.nulls {
+	case NullsFirst:
+		return true
+	case NullsLast:
+		return false
+	}
+
+	return nullsFirstInIndexOrder(oc.descOrder)
 }
 
 type Selector interface {
@@ -2583,6 +3995,10 @@ type ColSelector struct {
 	table string
 	col   string
 	as    string
+
+	// ordinal is the position of the selector referenced in a GROUP BY clause, it's replaced by
+	// the selector when parsing the statement, see resolveGroupByOrdinals
+	ordinal int
 }
 
 func (sel *ColSelector) resolve(implicitDB, implicitTable string) (aggFn, db, table, col string) {
@@ -2682,7 +4098,19 @@ type AggColSelector struct {
 	db    string
 	table string
 	col   string
-	as    string
+	// exp is the aggregated expression when the argument is not a plain column,
+	// its values are then identified by col as assigned by nameAggregatedExps
+	exp ValueExp
+	as  string
+}
+
+func newAggColSelector(aggFn AggregateFn, exp ValueExp) *AggColSelector {
+	col, isCol := exp.(*ColSelector)
+	if isCol {
+		return &AggColSelector{aggFn: aggFn, db: col.db, table: col.table, col: col.col}
+	}
+
+	return &AggColSelector{aggFn: aggFn, exp: exp}
 }
 
 func EncodeSelector(aggFn, db, table, col string) string {
@@ -2716,10 +4144,10 @@ func (sel *AggColSelector) inferType(cols map[string]ColDescriptor, params map[s
 		return IntegerType, nil
 	}
 
-	colSelector := &ColSelector{db: sel.db, table: sel.table, col: sel.col}
+	arg := sel.argument()
 
 	if sel.aggFn == SUM || sel.aggFn == AVG {
-		err := colSelector.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+		err := arg.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
 		if err != nil {
 			return AnyType, err
 		}
@@ -2727,7 +4155,16 @@ func (sel *AggColSelector) inferType(cols map[string]ColDescriptor, params map[s
 		return IntegerType, nil
 	}
 
-	return colSelector.inferType(cols, params, implicitDB, implicitTable)
+	if sel.aggFn == BOOL_AND || sel.aggFn == BOOL_OR {
+		err := arg.requiresType(BooleanType, cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, err
+		}
+
+		return BooleanType, nil
+	}
+
+	return arg.inferType(cols, params, implicitDB, implicitTable)
 }
 
 func (sel *AggColSelector) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
@@ -2738,13 +4175,30 @@ func (sel *AggColSelector) requiresType(t SQLValueType, cols map[string]ColDescr
 		return nil
 	}
 
-	colSelector := &ColSelector{db: sel.db, table: sel.table, col: sel.col}
+	arg := sel.argument()
 
 	if sel.aggFn == SUM || sel.aggFn == AVG {
-		return colSelector.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+		return arg.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+	}
+
+	if sel.aggFn == BOOL_AND || sel.aggFn == BOOL_OR {
+		if t != BooleanType {
+			return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, BooleanType, t)
+		}
+
+		return arg.requiresType(BooleanType, cols, params, implicitDB, implicitTable)
 	}
 
-	return colSelector.requiresType(t, cols, params, implicitDB, implicitTable)
+	return arg.requiresType(t, cols, params, implicitDB, implicitTable)
+}
+
+// argument returns the aggregated expression, which is the column itself when aggregating a column
+func (sel *AggColSelector) argument() ValueExp {
+	if sel.exp != nil {
+		return sel.exp
+	}
+
+	return &ColSelector{db: sel.db, table: sel.table, col: sel.col}
 }
 
 func (sel *AggColSelector) substitute(params map[string]interface{}) (ValueExp, error) {
@@ -2760,138 +4214,503 @@ func (sel *AggColSelector) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable
 	if !ok {
 		return nil, fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, sel.col)
 	}
-	return v, nil
+	return v, nil
+}
+
+func (sel *AggColSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return sel
+}
+
+func (sel *AggColSelector) isConstant() bool {
+	return false
+}
+
+func (sel *AggColSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// ExpSelector is a selector computed by evaluating an expression over each row
+type ExpSelector struct {
+	exp ValueExp
+	as  string
+}
+
+func newSelector(exp ValueExp, as string) Selector {
+	sel, isSelector := exp.(Selector)
+	if !isSelector {
+		sel = &ExpSelector{exp: exp}
+	}
+
+	sel.setAlias(as)
+
+	return sel
+}
+
+func (sel *ExpSelector) resolve(implicitDB, implicitTable string) (aggFn, db, table, col string) {
+	return "", implicitDB, implicitTable, sel.as
+}
+
+func (sel *ExpSelector) alias() string {
+	return sel.as
+}
+
+func (sel *ExpSelector) setAlias(alias string) {
+	sel.as = alias
+}
+
+func (sel *ExpSelector) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return sel.exp.inferType(cols, params, implicitDB, implicitTable)
+}
+
+func (sel *ExpSelector) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	return sel.exp.requiresType(t, cols, params, implicitDB, implicitTable)
+}
+
+func (sel *ExpSelector) substitute(params map[string]interface{}) (ValueExp, error) {
+	exp, err := sel.exp.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExpSelector{exp: exp, as: sel.as}, nil
+}
+
+func (sel *ExpSelector) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return sel.exp.reduce(tx, row, implicitDB, implicitTable)
+}
+
+func (sel *ExpSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return sel.exp.reduceSelectors(row, implicitDB, implicitTable)
+}
+
+func (sel *ExpSelector) isConstant() bool {
+	return sel.exp.isConstant()
+}
+
+func (sel *ExpSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+type NumExp struct {
+	op          NumOperator
+	left, right ValueExp
+}
+
+func (bexp *NumExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	err := bexp.left.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
+	}
+
+	err = bexp.right.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
+	}
+
+	return IntegerType, nil
+}
+
+func (bexp *NumExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != IntegerType {
+		return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, IntegerType, t)
+	}
+
+	err := bexp.left.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return err
+	}
+
+	err = bexp.right.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (bexp *NumExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	rlexp, err := bexp.left.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	rrexp, err := bexp.right.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NumExp{
+		op:    bexp.op,
+		left:  rlexp,
+		right: rrexp,
+	}, nil
+}
+
+func (bexp *NumExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	vl, err := bexp.left.reduce(tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	vr, err := bexp.right.reduce(tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	// as in standard SQL, the result is NULL if any of the operands is NULL
+	if vl.IsNull() || vr.IsNull() {
+		return &NullValue{t: IntegerType}, nil
+	}
+
+	nl, isNumber := vl.Value().(int64)
+	if !isNumber {
+		return nil, fmt.Errorf("%w (expecting numeric value)", ErrInvalidValue)
+	}
+
+	nr, isNumber := vr.Value().(int64)
+	if !isNumber {
+		return nil, fmt.Errorf("%w (expecting numeric value)", ErrInvalidValue)
+	}
+
+	switch bexp.op {
+	case ADDOP:
+		{
+			return &Number{val: nl + nr}, nil
+		}
+	case SUBSOP:
+		{
+			return &Number{val: nl - nr}, nil
+		}
+	case DIVOP:
+		{
+			if nr == 0 {
+				return nil, ErrDivisionByZero
+			}
+
+			return &Number{val: nl / nr}, nil
+		}
+	case MULTOP:
+		{
+			return &Number{val: nl * nr}, nil
+		}
+	case BITANDOP:
+		{
+			return &Number{val: nl & nr}, nil
+		}
+	case BITOROP:
+		{
+			return &Number{val: nl | nr}, nil
+		}
+	case BITXOROP:
+		{
+			return &Number{val: nl ^ nr}, nil
+		}
+	case SHLOP, SHROP:
+		{
+			if nr < 0 {
+				return nil, fmt.Errorf("%w: negative shift amount %d", ErrIllegalArguments, nr)
+			}
+
+			if bexp.op == SHLOP {
+				return &Number{val: nl << nr}, nil
+			}
+
+			return &Number{val: nl >> nr}, nil
+		}
+	}
+
+	return nil, ErrUnexpected
+}
+
+func (bexp *NumExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return &NumExp{
+		op:    bexp.op,
+		left:  bexp.left.reduceSelectors(row, implicitDB, implicitTable),
+		right: bexp.right.reduceSelectors(row, implicitDB, implicitTable),
+	}
+}
+
+func (bexp *NumExp) isConstant() bool {
+	return bexp.left.isConstant() && bexp.right.isConstant()
+}
+
+func (bexp *NumExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// ConcatExp concatenates the string representation of two values with the || operator.
+// As in standard SQL, the result is NULL if any of the operands is NULL
+type ConcatExp struct {
+	left, right ValueExp
+}
+
+func (bexp *ConcatExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	for _, exp := range []ValueExp{bexp.left, bexp.right} {
+		t, err := exp.inferType(cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, err
+		}
+
+		if t == AnyType {
+			// operands of unknown type are expected to be strings
+			err = exp.requiresType(VarcharType, cols, params, implicitDB, implicitTable)
+			if err != nil {
+				return AnyType, err
+			}
+		}
+	}
+
+	return VarcharType, nil
+}
+
+func (bexp *ConcatExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != VarcharType {
+		return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, VarcharType, t)
+	}
+
+	_, err := bexp.inferType(cols, params, implicitDB, implicitTable)
+	return err
+}
+
+func (bexp *ConcatExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	rlexp, err := bexp.left.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	rrexp, err := bexp.right.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConcatExp{
+		left:  rlexp,
+		right: rrexp,
+	}, nil
+}
+
+func (bexp *ConcatExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	vl, err := bexp.left.reduce(tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	vr, err := bexp.right.reduce(tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	if vl.IsNull() || vr.IsNull() {
+		return &NullValue{t: VarcharType}, nil
+	}
+
+	sl, err := stringify(vl)
+	if err != nil {
+		return nil, err
+	}
+
+	sr, err := stringify(vr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Varchar{val: sl + sr}, nil
+}
+
+func (bexp *ConcatExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return &ConcatExp{
+		left:  bexp.left.reduceSelectors(row, implicitDB, implicitTable),
+		right: bexp.right.reduceSelectors(row, implicitDB, implicitTable),
+	}
 }
 
-func (sel *AggColSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return sel
+func (bexp *ConcatExp) isConstant() bool {
+	return bexp.left.isConstant() && bexp.right.isConstant()
 }
 
-func (sel *AggColSelector) isConstant() bool {
-	return false
+func (bexp *ConcatExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
 }
 
-func (sel *AggColSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	return nil
+// CaseExp is a searched CASE expression, it evaluates to the result of the first condition which holds true,
+// or to the ELSE result when none of them does. The result is NULL if there is no ELSE clause
+type CaseExp struct {
+	whens   []*whenThen
+	elseExp ValueExp
 }
 
-type NumExp struct {
-	op          NumOperator
-	left, right ValueExp
+type whenThen struct {
+	cond   ValueExp
+	result ValueExp
 }
 
-func (bexp *NumExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	err := bexp.left.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
-	if err != nil {
-		return AnyType, err
+func (ce *CaseExp) results() []ValueExp {
+	results := make([]ValueExp, 0, len(ce.whens)+1)
+
+	for _, w := range ce.whens {
+		results = append(results, w.result)
 	}
 
-	err = bexp.right.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
-	if err != nil {
-		return AnyType, err
+	if ce.elseExp != nil {
+		results = append(results, ce.elseExp)
 	}
 
-	return IntegerType, nil
+	return results
 }
 
-func (bexp *NumExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if t != IntegerType {
-		return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, IntegerType, t)
+func (ce *CaseExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	for _, w := range ce.whens {
+		err := w.cond.requiresType(BooleanType, cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, err
+		}
 	}
 
-	err := bexp.left.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
-	if err != nil {
-		return err
-	}
+	t := AnyType
 
-	err = bexp.right.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
-	if err != nil {
-		return err
+	for _, result := range ce.results() {
+		rt, err := result.inferType(cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, err
+		}
+
+		if rt == AnyType {
+			continue
+		}
+
+		if t != AnyType && t != rt {
+			return AnyType, fmt.Errorf("%w: results of CASE expression of types %v and %v", ErrInvalidTypes, t, rt)
+		}
+
+		t = rt
 	}
 
-	return nil
+	return t, nil
 }
 
-func (bexp *NumExp) substitute(params map[string]interface{}) (ValueExp, error) {
-	rlexp, err := bexp.left.substitute(params)
-	if err != nil {
-		return nil, err
+func (ce *CaseExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	for _, w := range ce.whens {
+		err := w.cond.requiresType(BooleanType, cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return err
+		}
 	}
 
-	rrexp, err := bexp.right.substitute(params)
-	if err != nil {
-		return nil, err
+	for _, result := range ce.results() {
+		err := result.requiresType(t, cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return err
+		}
 	}
 
-	bexp.left = rlexp
-	bexp.right = rrexp
-
-	return bexp, nil
+	return nil
 }
 
-func (bexp *NumExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	vl, err := bexp.left.reduce(tx, row, implicitDB, implicitTable)
-	if err != nil {
-		return nil, err
-	}
+func (ce *CaseExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	whens := make([]*whenThen, len(ce.whens))
 
-	vr, err := bexp.right.reduce(tx, row, implicitDB, implicitTable)
-	if err != nil {
-		return nil, err
+	for i, w := range ce.whens {
+		cond, err := w.cond.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := w.result.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		whens[i] = &whenThen{cond: cond, result: result}
 	}
 
-	nl, isNumber := vl.Value().(int64)
-	if !isNumber {
-		return nil, fmt.Errorf("%w (expecting numeric value)", ErrInvalidValue)
+	if ce.elseExp == nil {
+		return &CaseExp{whens: whens}, nil
 	}
 
-	nr, isNumber := vr.Value().(int64)
-	if !isNumber {
-		return nil, fmt.Errorf("%w (expecting numeric value)", ErrInvalidValue)
+	elseExp, err := ce.elseExp.substitute(params)
+	if err != nil {
+		return nil, err
 	}
 
-	switch bexp.op {
-	case ADDOP:
-		{
-			return &Number{val: nl + nr}, nil
-		}
-	case SUBSOP:
-		{
-			return &Number{val: nl - nr}, nil
+	return &CaseExp{whens: whens, elseExp: elseExp}, nil
+}
+
+func (ce *CaseExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	for _, w := range ce.whens {
+		v, err := w.cond.reduce(tx, row, implicitDB, implicitTable)
+		if err != nil {
+			return nil, err
 		}
-	case DIVOP:
-		{
-			if nr == 0 {
-				return nil, ErrDivisionByZero
-			}
 
-			return &Number{val: nl / nr}, nil
+		b, isBool := v.(*Bool)
+		if !isBool && !v.IsNull() {
+			return nil, fmt.Errorf("%w (expecting boolean value)", ErrInvalidValue)
 		}
-	case MULTOP:
-		{
-			return &Number{val: nl * nr}, nil
+
+		// an unknown (NULL) condition does not hold
+		if isBool && b.val {
+			return w.result.reduce(tx, row, implicitDB, implicitTable)
 		}
 	}
 
-	return nil, ErrUnexpected
+	if ce.elseExp == nil {
+		return &NullValue{t: AnyType}, nil
+	}
+
+	return ce.elseExp.reduce(tx, row, implicitDB, implicitTable)
 }
 
-func (bexp *NumExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return &NumExp{
-		op:    bexp.op,
-		left:  bexp.left.reduceSelectors(row, implicitDB, implicitTable),
-		right: bexp.right.reduceSelectors(row, implicitDB, implicitTable),
+func (ce *CaseExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	whens := make([]*whenThen, len(ce.whens))
+
+	for i, w := range ce.whens {
+		whens[i] = &whenThen{
+			cond:   w.cond.reduceSelectors(row, implicitDB, implicitTable),
+			result: w.result.reduceSelectors(row, implicitDB, implicitTable),
+		}
+	}
+
+	if ce.elseExp == nil {
+		return &CaseExp{whens: whens}
 	}
+
+	return &CaseExp{whens: whens, elseExp: ce.elseExp.reduceSelectors(row, implicitDB, implicitTable)}
 }
 
-func (bexp *NumExp) isConstant() bool {
-	return bexp.left.isConstant() && bexp.right.isConstant()
+func (ce *CaseExp) isConstant() bool {
+	for _, w := range ce.whens {
+		if !w.cond.isConstant() || !w.result.isConstant() {
+			return false
+		}
+	}
+
+	return ce.elseExp == nil || ce.elseExp.isConstant()
 }
 
-func (bexp *NumExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+func (ce *CaseExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
 	return nil
 }
 
+// stringify returns the string representation of a non-NULL value when implicitly converted to VARCHAR:
+// integers in decimal notation, booleans as 'true' or 'false', timestamps as 'YYYY-MM-DD HH:MM:SS.ffffff' in UTC
+// without trailing zeros in the fraction of seconds, and blobs as lowercase hexadecimal strings
+func stringify(v TypedValue) (string, error) {
+	switch val := v.Value().(type) {
+	case string:
+		return val, nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case time.Time:
+		return val.UTC().Format("2006-01-02 15:04:05.999999"), nil
+	case []byte:
+		return hex.EncodeToString(val), nil
+	}
+
+	return "", fmt.Errorf("%w: %v can not be converted to %v", ErrInvalidTypes, v.Type(), VarcharType)
+}
+
 type NotBoolExp struct {
 	exp ValueExp
 }
@@ -2919,9 +4738,9 @@ func (bexp *NotBoolExp) substitute(params map[string]interface{}) (ValueExp, err
 		return nil, err
 	}
 
-	bexp.exp = rexp
-
-	return bexp, nil
+	return &NotBoolExp{
+		exp: rexp,
+	}, nil
 }
 
 func (bexp *NotBoolExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
@@ -2930,6 +4749,11 @@ func (bexp *NotBoolExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable st
 		return nil, err
 	}
 
+	if v.IsNull() {
+		// the negation of an unknown value is unknown as well
+		return &NullValue{t: BooleanType}, nil
+	}
+
 	r, isBool := v.Value().(bool)
 	if !isBool {
 		return nil, ErrInvalidCondition
@@ -3038,18 +4862,209 @@ func (bexp *LikeBoolExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable s
 		return nil, fmt.Errorf("error in 'LIKE' clause: %w", err)
 	}
 
-	return &Bool{val: matched != bexp.notLike}, nil
+	return &Bool{val: matched != bexp.notLike}, nil
+}
+
+func (bexp *LikeBoolExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return bexp
+}
+
+func (bexp *LikeBoolExp) isConstant() bool {
+	return false
+}
+
+func (bexp *LikeBoolExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// RegexpBoolExp matches a string against a regular expression (REGEXP, IREGEXP or MATCH).
+// Regular expressions can not be used to narrow index ranges, they are evaluated over scanned rows
+type RegexpBoolExp struct {
+	val             ValueExp
+	notMatch        bool
+	pattern         ValueExp
+	caseInsensitive bool
+
+	// cache keeps the compiled pattern, so it is not compiled again for each row
+	cache *regexpCache
+}
+
+type regexpCache struct {
+	mutex   sync.Mutex
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newRegexpBoolExp(val ValueExp, notMatch bool, pattern ValueExp, caseInsensitive bool) *RegexpBoolExp {
+	return &RegexpBoolExp{
+		val:             val,
+		notMatch:        notMatch,
+		pattern:         pattern,
+		caseInsensitive: caseInsensitive,
+		cache:           &regexpCache{},
+	}
+}
+
+func (bexp *RegexpBoolExp) compile(pattern string) (*regexp.Regexp, error) {
+	if bexp.caseInsensitive {
+		pattern = "(?i)" + pattern
+	}
+
+	if bexp.cache == nil {
+		return regexp.Compile(pattern)
+	}
+
+	bexp.cache.mutex.Lock()
+	defer bexp.cache.mutex.Unlock()
+
+	if bexp.cache.re != nil && bexp.cache.pattern == pattern {
+		return bexp.cache.re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	bexp.cache.pattern = pattern
+	bexp.cache.re = re
+
+	return re, nil
+}
+
+// compilePattern compiles the pattern when it does not depend on the rows being matched,
+// so that invalid patterns are reported before reading any row
+func (bexp *RegexpBoolExp) compilePattern(params map[string]interface{}) error {
+	pattern, err := bexp.pattern.substitute(params)
+	if err != nil {
+		return fmt.Errorf("error in 'REGEXP' clause: %w", err)
+	}
+
+	if !pattern.isConstant() {
+		return nil
+	}
+
+	rpattern, err := pattern.reduce(nil, nil, "", "")
+	if err != nil {
+		return fmt.Errorf("error in 'REGEXP' clause: %w", err)
+	}
+
+	if rpattern.IsNull() {
+		return nil
+	}
+
+	if rpattern.Type() != VarcharType {
+		return fmt.Errorf("error in 'REGEXP' clause: %w (expecting %s)", ErrInvalidTypes, VarcharType)
+	}
+
+	_, err = bexp.compile(rpattern.Value().(string))
+	if err != nil {
+		return fmt.Errorf("error in 'REGEXP' clause: %w: %v", ErrInvalidRegexp, err)
+	}
+
+	return nil
+}
+
+// compileRegexps compiles the constant patterns of the regular expressions used in exp
+func compileRegexps(exp ValueExp, params map[string]interface{}) error {
+	rexp, ok := exp.(*RegexpBoolExp)
+	if ok {
+		err := rexp.compilePattern(params)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, e := range subExps(exp) {
+		err := compileRegexps(e, params)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (bexp *RegexpBoolExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	err := bexp.val.requiresType(VarcharType, cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, fmt.Errorf("error in 'REGEXP' clause: %w", err)
+	}
+
+	err = bexp.pattern.requiresType(VarcharType, cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, fmt.Errorf("error in 'REGEXP' clause: %w", err)
+	}
+
+	return BooleanType, nil
+}
+
+func (bexp *RegexpBoolExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != BooleanType {
+		return fmt.Errorf("error using the value of the REGEXP operator as %s: %w", t, ErrInvalidTypes)
+	}
+
+	_, err := bexp.inferType(cols, params, implicitDB, implicitTable)
+	return err
+}
+
+func (bexp *RegexpBoolExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	val, err := bexp.val.substitute(params)
+	if err != nil {
+		return nil, fmt.Errorf("error in 'REGEXP' clause: %w", err)
+	}
+
+	pattern, err := bexp.pattern.substitute(params)
+	if err != nil {
+		return nil, fmt.Errorf("error in 'REGEXP' clause: %w", err)
+	}
+
+	return &RegexpBoolExp{
+		val:             val,
+		notMatch:        bexp.notMatch,
+		pattern:         pattern,
+		caseInsensitive: bexp.caseInsensitive,
+		cache:           bexp.cache,
+	}, nil
+}
+
+func (bexp *RegexpBoolExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	rval, err := bexp.val.reduce(tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, fmt.Errorf("error in 'REGEXP' clause: %w", err)
+	}
+
+	rpattern, err := bexp.pattern.reduce(tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, fmt.Errorf("error in 'REGEXP' clause: %w", err)
+	}
+
+	if rval.IsNull() || rpattern.IsNull() {
+		return &NullValue{t: BooleanType}, nil
+	}
+
+	if rval.Type() != VarcharType || rpattern.Type() != VarcharType {
+		return nil, fmt.Errorf("error in 'REGEXP' clause: %w (expecting %s)", ErrInvalidTypes, VarcharType)
+	}
+
+	re, err := bexp.compile(rpattern.Value().(string))
+	if err != nil {
+		return nil, fmt.Errorf("error in 'REGEXP' clause: %w: %v", ErrInvalidRegexp, err)
+	}
+
+	return &Bool{val: re.MatchString(rval.Value().(string)) != bexp.notMatch}, nil
 }
 
-func (bexp *LikeBoolExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+func (bexp *RegexpBoolExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
 	return bexp
 }
 
-func (bexp *LikeBoolExp) isConstant() bool {
+func (bexp *RegexpBoolExp) isConstant() bool {
 	return false
 }
 
-func (bexp *LikeBoolExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+func (bexp *RegexpBoolExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	// matching rows can not be delimited by a range of values
 	return nil
 }
 
@@ -3117,10 +5132,11 @@ func (bexp *CmpBoolExp) substitute(params map[string]interface{}) (ValueExp, err
 		return nil, err
 	}
 
-	bexp.left = rlexp
-	bexp.right = rrexp
-
-	return bexp, nil
+	return &CmpBoolExp{
+		op:    bexp.op,
+		left:  rlexp,
+		right: rrexp,
+	}, nil
 }
 
 func (bexp *CmpBoolExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
@@ -3169,7 +5185,7 @@ func (bexp *CmpBoolExp) selectorRanges(table *Table, asTable string, params map[
 	}
 
 	if !ok {
-		return nil
+		return bexp.exprSelectorRanges(table, asTable, params, rangesByColID)
 	}
 
 	aggFn, db, t, col := sel.resolve(table.db.name, table.name)
@@ -3182,6 +5198,30 @@ func (bexp *CmpBoolExp) selectorRanges(table *Table, asTable string, params map[
 		return err
 	}
 
+	return updateRangeWithConstant(column.id, c, bexp.op, table, params, rangesByColID)
+}
+
+// exprSelectorRanges narrows the values of an indexed expression compared with a constant
+func (bexp *CmpBoolExp) exprSelectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	if len(table.exprCols) == 0 || !bexp.right.isConstant() {
+		return nil
+	}
+
+	text, err := indexExprText(bexp.left, table, asTable)
+	if err != nil {
+		// the expression is not indexed
+		return nil
+	}
+
+	exprCol, indexed := table.exprColsByName[text]
+	if !indexed {
+		return nil
+	}
+
+	return updateRangeWithConstant(exprCol.id, bexp.right, bexp.op, table, params, rangesByColID)
+}
+
+func updateRangeWithConstant(colID uint32, c ValueExp, cmp CmpOperator, table *Table, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
 	val, err := c.substitute(params)
 	if errors.Is(err, ErrMissingParameter) {
 		// TODO: not supported when parameters are not provided during query resolution
@@ -3196,7 +5236,7 @@ func (bexp *CmpBoolExp) selectorRanges(table *Table, asTable string, params map[
 		return err
 	}
 
-	return updateRangeFor(column.id, rval, bexp.op, rangesByColID)
+	return updateRangeFor(colID, rval, cmp, rangesByColID)
 }
 
 func updateRangeFor(colID uint32, val TypedValue, cmp CmpOperator, rangesByColID map[uint32]*typedValueRange) error {
@@ -3283,6 +5323,96 @@ func cmpSatisfiesOp(cmp int, op CmpOperator) bool {
 	return false
 }
 
+// DistinctFromBoolExp is a NULL-safe comparison, two NULL values are not distinct from each other
+// while a NULL value is distinct from any other value. The negated form is IS NOT DISTINCT FROM
+type DistinctFromBoolExp struct {
+	left, right ValueExp
+	negate      bool
+}
+
+func (bexp *DistinctFromBoolExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	cmp := &CmpBoolExp{op: EQ, left: bexp.left, right: bexp.right}
+	return cmp.inferType(cols, params, implicitDB, implicitTable)
+}
+
+func (bexp *DistinctFromBoolExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != BooleanType {
+		return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, BooleanType, t)
+	}
+
+	_, err := bexp.inferType(cols, params, implicitDB, implicitTable)
+
+	return err
+}
+
+func (bexp *DistinctFromBoolExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	rlexp, err := bexp.left.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	rrexp, err := bexp.right.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DistinctFromBoolExp{
+		left:   rlexp,
+		right:  rrexp,
+		negate: bexp.negate,
+	}, nil
+}
+
+func (bexp *DistinctFromBoolExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	vl, err := bexp.left.reduce(tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	vr, err := bexp.right.reduce(tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	var equal bool
+
+	if vl.IsNull() || vr.IsNull() {
+		equal = vl.IsNull() && vr.IsNull()
+	} else {
+		r, err := vl.Compare(vr)
+		if err != nil {
+			return nil, err
+		}
+
+		equal = r == 0
+	}
+
+	return &Bool{val: equal == bexp.negate}, nil
+}
+
+func (bexp *DistinctFromBoolExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return &DistinctFromBoolExp{
+		left:   bexp.left.reduceSelectors(row, implicitDB, implicitTable),
+		right:  bexp.right.reduceSelectors(row, implicitDB, implicitTable),
+		negate: bexp.negate,
+	}
+}
+
+func (bexp *DistinctFromBoolExp) isConstant() bool {
+	return bexp.left.isConstant() && bexp.right.isConstant()
+}
+
+func (bexp *DistinctFromBoolExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	if !bexp.negate {
+		return nil
+	}
+
+	// NULL values are equal to each other in indexes, thus ranges are narrowed as in an equality
+	cmp := &CmpBoolExp{op: EQ, left: bexp.left, right: bexp.right}
+
+	return cmp.selectorRanges(table, asTable, params, rangesByColID)
+}
+
 type BinBoolExp struct {
 	op          LogicOperator
 	left, right ValueExp
@@ -3331,10 +5461,11 @@ func (bexp *BinBoolExp) substitute(params map[string]interface{}) (ValueExp, err
 		return nil, err
 	}
 
-	bexp.left = rlexp
-	bexp.right = rrexp
-
-	return bexp, nil
+	return &BinBoolExp{
+		op:    bexp.op,
+		left:  rlexp,
+		right: rrexp,
+	}, nil
 }
 
 func (bexp *BinBoolExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
@@ -3349,23 +5480,41 @@ func (bexp *BinBoolExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable st
 	}
 
 	bl, isBool := vl.(*Bool)
-	if !isBool {
+	if !isBool && !vl.IsNull() {
 		return nil, fmt.Errorf("%w (expecting boolean value)", ErrInvalidValue)
 	}
 
 	br, isBool := vr.(*Bool)
-	if !isBool {
+	if !isBool && !vr.IsNull() {
 		return nil, fmt.Errorf("%w (expecting boolean value)", ErrInvalidValue)
 	}
 
+	// NULL stands for an unknown value (three-valued logic),
+	// the result is NULL only when it depends on the unknown value
 	switch bexp.op {
 	case AND:
 		{
-			return &Bool{val: bl.val && br.val}, nil
+			if (bl != nil && !bl.val) || (br != nil && !br.val) {
+				return &Bool{val: false}, nil
+			}
+
+			if bl == nil || br == nil {
+				return &NullValue{t: BooleanType}, nil
+			}
+
+			return &Bool{val: true}, nil
 		}
 	case OR:
 		{
-			return &Bool{val: bl.val || br.val}, nil
+			if (bl != nil && bl.val) || (br != nil && br.val) {
+				return &Bool{val: true}, nil
+			}
+
+			if bl == nil || br == nil {
+				return &NullValue{t: BooleanType}, nil
+			}
+
+			return &Bool{val: false}, nil
 		}
 	}
 
@@ -3532,13 +5681,25 @@ func (bexp *InListExp) substitute(params map[string]interface{}) (ValueExp, erro
 		return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
 	}
 
-	values := make([]ValueExp, len(bexp.values))
+	values := make([]ValueExp, 0, len(bexp.values))
 
-	for i, val := range bexp.values {
-		values[i], err = val.substitute(params)
+	for _, val := range bexp.values {
+		listValues, isList, err := listParamValues(val, params)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
+		}
+
+		if isList {
+			values = append(values, listValues...)
+			continue
+		}
+
+		sval, err := val.substitute(params)
 		if err != nil {
 			return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
 		}
+
+		values = append(values, sval)
 	}
 
 	return &InListExp{
@@ -3548,6 +5709,52 @@ func (bexp *InListExp) substitute(params map[string]interface{}) (ValueExp, erro
 	}, nil
 }
 
+// listParamValues expands a parameter bound to a list of values e.g. `col IN (@ids)`
+// Every element must be of the same type. An empty list is valid and matches no value.
+func listParamValues(exp ValueExp, params map[string]interface{}) (values []ValueExp, isList bool, err error) {
+	p, isParam := exp.(*Param)
+	if !isParam {
+		return nil, false, nil
+	}
+
+	val, ok := params[p.id]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if _, isBlob := val.([]byte); isBlob {
+		return nil, false, nil
+	}
+
+	rval := reflect.ValueOf(val)
+	if rval.Kind() != reflect.Slice && rval.Kind() != reflect.Array {
+		return nil, false, nil
+	}
+
+	values = make([]ValueExp, rval.Len())
+
+	elemType := AnyType
+
+	for i := 0; i < rval.Len(); i++ {
+		v, err := paramValue(rval.Index(i).Interface())
+		if err != nil {
+			return nil, true, fmt.Errorf("%w (%s[%d])", err, p.id, i)
+		}
+
+		if !v.IsNull() {
+			if elemType != AnyType && elemType != v.Type() {
+				return nil, true, fmt.Errorf("%w: list parameter '%s' contains values of types %s and %s", ErrInvalidTypes, p.id, elemType, v.Type())
+			}
+
+			elemType = v.Type()
+		}
+
+		values[i] = v
+	}
+
+	return values, true, nil
+}
+
 func (bexp *InListExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
 	rval, err := bexp.val.reduce(tx, row, implicitDB, implicitTable)
 	if err != nil {
@@ -3598,6 +5805,204 @@ func (bexp *InListExp) selectorRanges(table *Table, asTable string, params map[s
 	return nil
 }
 
+// TupleInListExp matches a tuple of values against a list of tuples e.g. `(a, b) IN ((1, 2), (3, 4))`.
+// Every tuple in the list must have as many values as the matched one, and values must be of the same
+// type as the ones at the same position of the matched tuple
+type TupleInListExp struct {
+	vals   []ValueExp
+	notIn  bool
+	tuples [][]ValueExp
+}
+
+func (bexp *TupleInListExp) checkArity() error {
+	for i, tuple := range bexp.tuples {
+		if len(tuple) != len(bexp.vals) {
+			return fmt.Errorf("%w: tuple %d in 'IN' clause has %d values but %d were expected", ErrInvalidNumberOfValues, i+1, len(tuple), len(bexp.vals))
+		}
+	}
+
+	return nil
+}
+
+// checkTupleArities ensures the tuples of every tuple 'IN' clause in exp are of the expected size
+func checkTupleArities(exp ValueExp) error {
+	texp, ok := exp.(*TupleInListExp)
+	if ok {
+		err := texp.checkArity()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, e := range subExps(exp) {
+		err := checkTupleArities(e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (bexp *TupleInListExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	err := bexp.checkArity()
+	if err != nil {
+		return AnyType, err
+	}
+
+	for i, val := range bexp.vals {
+		t, err := val.inferType(cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, fmt.Errorf("error inferring type in 'IN' clause: %w", err)
+		}
+
+		for _, tuple := range bexp.tuples {
+			err = tuple[i].requiresType(t, cols, params, implicitDB, implicitTable)
+			if err != nil {
+				return AnyType, fmt.Errorf("error inferring type in 'IN' clause: %w", err)
+			}
+		}
+	}
+
+	return BooleanType, nil
+}
+
+func (bexp *TupleInListExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	_, err := bexp.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return err
+	}
+
+	if t != BooleanType {
+		return fmt.Errorf("error inferring type in 'IN' clause: %w", ErrInvalidTypes)
+	}
+
+	return nil
+}
+
+func substituteAll(exps []ValueExp, params map[string]interface{}) ([]ValueExp, error) {
+	sexps := make([]ValueExp, len(exps))
+
+	for i, exp := range exps {
+		sexp, err := exp.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		sexps[i] = sexp
+	}
+
+	return sexps, nil
+}
+
+func (bexp *TupleInListExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	vals, err := substituteAll(bexp.vals, params)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
+	}
+
+	tuples := make([][]ValueExp, len(bexp.tuples))
+
+	for i, tuple := range bexp.tuples {
+		tuples[i], err = substituteAll(tuple, params)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
+		}
+	}
+
+	return &TupleInListExp{vals: vals, notIn: bexp.notIn, tuples: tuples}, nil
+}
+
+func (bexp *TupleInListExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	err := bexp.checkArity()
+	if err != nil {
+		return nil, err
+	}
+
+	rvals := make([]TypedValue, len(bexp.vals))
+
+	for i, val := range bexp.vals {
+		rvals[i], err = val.reduce(tx, row, implicitDB, implicitTable)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
+		}
+	}
+
+	for _, tuple := range bexp.tuples {
+		matches := true
+
+		for i, v := range tuple {
+			rv, err := v.reduce(tx, row, implicitDB, implicitTable)
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
+			}
+
+			r, err := rvals[i].Compare(rv)
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
+			}
+
+			if r != 0 {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return &Bool{val: !bexp.notIn}, nil
+		}
+	}
+
+	return &Bool{val: bexp.notIn}, nil
+}
+
+func reduceAllSelectors(exps []ValueExp, row *Row, implicitDB, implicitTable string) []ValueExp {
+	rexps := make([]ValueExp, len(exps))
+
+	for i, exp := range exps {
+		rexps[i] = exp.reduceSelectors(row, implicitDB, implicitTable)
+	}
+
+	return rexps
+}
+
+func (bexp *TupleInListExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	tuples := make([][]ValueExp, len(bexp.tuples))
+
+	for i, tuple := range bexp.tuples {
+		tuples[i] = reduceAllSelectors(tuple, row, implicitDB, implicitTable)
+	}
+
+	return &TupleInListExp{
+		vals:   reduceAllSelectors(bexp.vals, row, implicitDB, implicitTable),
+		notIn:  bexp.notIn,
+		tuples: tuples,
+	}
+}
+
+func (bexp *TupleInListExp) isConstant() bool {
+	return false
+}
+
+func (bexp *TupleInListExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	if bexp.notIn || len(bexp.tuples) != 1 || len(bexp.tuples[0]) != len(bexp.vals) {
+		// lists of several tuples are looked up one tuple at a time, see tupleLookups
+		return nil
+	}
+
+	// a single tuple is equivalent to the equality of every value
+	for i, val := range bexp.vals {
+		cmp := &CmpBoolExp{op: EQ, left: val, right: bexp.tuples[0][i]}
+
+		err := cmp.selectorRanges(table, asTable, params, rangesByColID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 type FnDataSourceStmt struct {
 	fnCall *FnCall
 	as     string
@@ -0,0 +1,243 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/codenotary/immudb/embedded/multierr"
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// maxTupleLookups is the largest list of tuples looked up one tuple at a time,
+// rows matching longer lists are filtered while scanning the table
+const maxTupleLookups = 64
+
+// tupleLookups returns the scan specs to look up each of the tuples of a tuple 'IN' clause of the where clause
+// e.g. `WHERE (a, b) IN ((1, 2), (3, 4))`, when an index covers the matched columns as its leading ones.
+//
+// Rows are then read tuple by tuple instead of in the order of an index, thus lookups are only made when
+// rows are not required to be read in a given order. Rows are still filtered by the whole where clause
+func (stmt *SelectStmt) tupleLookups(tx *SQLTx, params map[string]interface{}) ([]*ScanSpecs, error) {
+	tableRef, isTableRef := stmt.ds.(*tableRef)
+	if !isTableRef || tableRef.history || tableRef.sample != nil || stmt.joins != nil ||
+		len(stmt.orderBy) > 0 || stmt.groupBy != nil || stmt.grouping != nil || len(stmt.indexOn) > 0 {
+		return nil, nil
+	}
+
+	texp := conjunctTupleInListExp(stmt.where)
+	if texp == nil || texp.notIn || len(texp.tuples) < 2 || len(texp.tuples) > maxTupleLookups || texp.checkArity() != nil {
+		return nil, nil
+	}
+
+	table, err := tableRef.referencedTable(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]*Column, len(texp.vals))
+
+	for i, val := range texp.vals {
+		sel, isSel := val.(*ColSelector)
+		if !isSel {
+			return nil, nil
+		}
+
+		aggFn, db, t, col := sel.resolve(table.db.name, tableRef.Alias())
+		if aggFn != "" || db != table.db.name || t != tableRef.Alias() {
+			return nil, nil
+		}
+
+		cols[i], err = table.GetColumnByName(col)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	index := table.indexCoveringCols(cols)
+	if index == nil {
+		return nil, nil
+	}
+
+	lookups := make([]*ScanSpecs, 0, len(texp.tuples))
+	lookedUp := make(map[string]struct{}, len(texp.tuples))
+
+	for _, tuple := range texp.tuples {
+		rangesByColID := make(map[uint32]*typedValueRange, len(cols))
+
+		var key []byte
+
+		for i, v := range tuple {
+			val, err := v.substitute(params)
+			if err != nil {
+				return nil, nil
+			}
+
+			rval, err := val.reduce(tx, nil, table.db.name, table.name)
+			if err != nil || rval.IsNull() {
+				// tuples of non-constant or null values are matched while scanning the table
+				return nil, nil
+			}
+
+			encVal, err := EncodeAsKey(rval.Value(), cols[i].colType, cols[i].MaxLen())
+			if err != nil {
+				// invalid values are reported when evaluating the where clause
+				return nil, nil
+			}
+
+			key = append(key, encVal...)
+
+			err = updateRangeFor(cols[i].id, rval, EQ, rangesByColID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// duplicated tuples are looked up once
+		if _, ok := lookedUp[string(key)]; ok {
+			continue
+		}
+		lookedUp[string(key)] = struct{}{}
+
+		lookups = append(lookups, &ScanSpecs{Index: index, rangesByColID: rangesByColID})
+	}
+
+	return lookups, nil
+}
+
+// conjunctTupleInListExp returns the tuple 'IN' clause the where clause is or is a conjunction of, if any
+func conjunctTupleInListExp(where ValueExp) *TupleInListExp {
+	switch exp := where.(type) {
+	case *TupleInListExp:
+		return exp
+	case *BinBoolExp:
+		if exp.op != AND {
+			return nil
+		}
+
+		texp := conjunctTupleInListExp(exp.left)
+		if texp != nil {
+			return texp
+		}
+
+		return conjunctTupleInListExp(exp.right)
+	}
+
+	return nil
+}
+
+// indexCoveringCols returns an index whose leading columns are the given ones, in any order
+func (t *Table) indexCoveringCols(cols []*Column) *Index {
+	for _, index := range t.indexes {
+		if len(index.cols) < len(cols) {
+			continue
+		}
+
+		covered := true
+
+		for _, idxCol := range index.cols[:len(cols)] {
+			found := false
+
+			for _, col := range cols {
+				if col.id == idxCol.id {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				covered = false
+				break
+			}
+		}
+
+		if covered {
+			return index
+		}
+	}
+
+	return nil
+}
+
+func (stmt *SelectStmt) resolveTupleLookups(ctx context.Context, tx *SQLTx, params map[string]interface{}, lookups []*ScanSpecs) (RowReader, error) {
+	readers := make([]RowReader, 0, len(lookups))
+
+	for _, scanSpecs := range lookups {
+		r, err := stmt.ds.Resolve(ctx, tx, params, scanSpecs)
+		if err != nil {
+			for i := len(readers) - 1; i >= 0; i-- {
+				readers[i].Close()
+			}
+
+			return nil, err
+		}
+
+		readers = append(readers, r)
+	}
+
+	return &tupleLookupRowReader{RowReader: readers[0], lookups: readers}, nil
+}
+
+// tupleLookupRowReader reads the rows of each tuple lookup in turn.
+// Lookups are made on the same table, the one of the first tuple describes them all
+type tupleLookupRowReader struct {
+	RowReader
+
+	lookups []RowReader
+	curr    int
+}
+
+func (lr *tupleLookupRowReader) SetParameters(params map[string]interface{}) error {
+	for _, r := range lr.lookups {
+		err := r.SetParameters(params)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// OrderBy returns no order, as rows are read tuple by tuple
+func (lr *tupleLookupRowReader) OrderBy() []ColDescriptor {
+	return nil
+}
+
+func (lr *tupleLookupRowReader) Read(ctx context.Context) (*Row, error) {
+	for {
+		row, err := lr.lookups[lr.curr].Read(ctx)
+		if errors.Is(err, store.ErrNoMoreEntries) && lr.curr+1 < len(lr.lookups) {
+			lr.curr++
+			continue
+		}
+
+		return row, err
+	}
+}
+
+func (lr *tupleLookupRowReader) Close() error {
+	merr := multierr.NewMultiErr()
+
+	// closing in reverse order to ensure the onClose callback
+	// is called after the last reader is closed
+	for i := len(lr.lookups) - 1; i >= 0; i-- {
+		merr.Append(lr.lookups[i].Close())
+	}
+
+	return merr.Reduce()
+}
@@ -47,14 +47,9 @@ func newUnionRowReader(ctx context.Context, rowReaders []RowReader) (*unionRowRe
 			return nil, err
 		}
 
-		if len(cols) != len(cs) {
-			return nil, fmt.Errorf("%w: each subquery must have same number of columns", ErrColumnMismatchInUnionStmt)
-		}
-
-		for c := 0; c < len(cols); c++ {
-			if cols[c].Type != cs[c].Type {
-				return nil, fmt.Errorf("%w: expecting type '%v' for column '%s'", ErrColumnMismatchInUnionStmt, cols[c].Type, cs[c].Column)
-			}
+		err = checkMatchingColumns(cols, cs, ErrColumnMismatchInUnionStmt)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -64,6 +59,21 @@ func newUnionRowReader(ctx context.Context, rowReaders []RowReader) (*unionRowRe
 	}, nil
 }
 
+// checkMatchingColumns ensures the rows of both subqueries can be combined, column types are compared by position
+func checkMatchingColumns(cols, cs []ColDescriptor, errMismatch error) error {
+	if len(cols) != len(cs) {
+		return fmt.Errorf("%w: each subquery must have same number of columns", errMismatch)
+	}
+
+	for c := 0; c < len(cols); c++ {
+		if cols[c].Type != cs[c].Type {
+			return fmt.Errorf("%w: expecting type '%v' for column '%s'", errMismatch, cols[c].Type, cs[c].Column)
+		}
+	}
+
+	return nil
+}
+
 func (ur *unionRowReader) onClose(callback func()) {
 	ur.rowReaders[0].onClose(callback)
 }
@@ -0,0 +1,603 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// View is a named query stored in the catalog, e.g. `CREATE VIEW active_users AS SELECT id, name FROM users WHERE active`.
+// A view is referenced as a data source and resolved as the query it names, as done with CTEs.
+//
+// The query is stored as written along with the ids of the columns it references, the names of the columns
+// are mapped back from their ids when the view is referenced, so a view keeps working after its columns are renamed.
+// Views are limited to queries listing the columns of a single table of the database
+type View struct {
+	db    *Database
+	id    uint32
+	name  string
+	table *Table
+	text  string
+
+	// colIDs holds the id of each column referenced by the query, in the order of viewColRefs.
+	// References to names other than the ones of columns of the table, e.g. aliases, are kept as zero
+	colIDs []uint32
+}
+
+func (v *View) ID() uint32 {
+	return v.id
+}
+
+func (v *View) Name() string {
+	return v.name
+}
+
+func (v *View) Table() *Table {
+	return v.table
+}
+
+func (db *Database) GetViews() []*View {
+	return db.views
+}
+
+func (db *Database) ExistView(name string) bool {
+	_, exists := db.viewsByName[name]
+	return exists
+}
+
+func (db *Database) newView(name, text string) (*View, error) {
+	query, err := parseViewQuery(text)
+	if err != nil {
+		return nil, err
+	}
+
+	tableName := query.ds.(*tableRef).table
+
+	if db.ExistView(tableName) {
+		return nil, fmt.Errorf("%w: view %s can not be queried by another view", ErrLimitedView, tableName)
+	}
+
+	table, err := db.GetTableByName(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := viewColRefs(query)
+
+	colIDs := make([]uint32, len(refs))
+
+	for i, ref := range refs {
+		col, exists := table.colsByName[*ref]
+		if exists {
+			colIDs[i] = col.id
+		}
+	}
+
+	return db.addView(name, table, text, colIDs)
+}
+
+func (db *Database) addView(name string, table *Table, text string, colIDs []uint32) (*View, error) {
+	if len(name) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	if db.ExistTable(name) {
+		return nil, fmt.Errorf("%w (%s)", ErrTableAlreadyExists, name)
+	}
+
+	if db.ExistView(name) {
+		return nil, fmt.Errorf("%w (%s)", ErrViewAlreadyExists, name)
+	}
+
+	view := &View{
+		db:     db,
+		id:     uint32(len(db.views) + 1),
+		name:   name,
+		table:  table,
+		text:   text,
+		colIDs: colIDs,
+	}
+
+	db.views = append(db.views, view)
+	db.viewsByName[view.name] = view
+
+	return view, nil
+}
+
+// query returns the query of the view referencing the columns by their current names.
+// Columns are returned as named when the view was created
+func (v *View) query() (*SelectStmt, error) {
+	query, err := parseViewQuery(v.text)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := viewColRefs(query)
+	if len(refs) != len(v.colIDs) {
+		return nil, ErrCorruptedData
+	}
+
+	for _, sel := range query.selectors {
+		colSel, isColSel := sel.(*ColSelector)
+		if isColSel && colSel.as == "" {
+			colSel.as = colSel.col
+		}
+	}
+
+	for i, ref := range refs {
+		if v.colIDs[i] == 0 {
+			continue
+		}
+
+		col, err := v.table.GetColumnByID(v.colIDs[i])
+		if err != nil {
+			return nil, err
+		}
+
+		*ref = col.colName
+	}
+
+	return query, nil
+}
+
+// parseViewQuery parses the query of a view, which must only read the columns of a single table
+func parseViewQuery(text string) (*SelectStmt, error) {
+	stmts, err := ParseString(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stmts) != 1 {
+		return nil, fmt.Errorf("%w: a single query is expected", ErrLimitedView)
+	}
+
+	query, ok := stmts[0].(*SelectStmt)
+	if !ok {
+		return nil, fmt.Errorf("%w: a single query is expected", ErrLimitedView)
+	}
+
+	ref, ok := query.ds.(*tableRef)
+	if !ok || len(query.joins) > 0 || ref.db != "" || ref.history || ref.sample != nil || ref.period.start != nil || ref.period.end != nil {
+		return nil, fmt.Errorf("%w: a single table of the database must be queried", ErrLimitedView)
+	}
+
+	if len(query.selectors) == 0 {
+		return nil, fmt.Errorf("%w: columns must be listed", ErrLimitedView)
+	}
+
+	if query.grouping != nil {
+		return nil, fmt.Errorf("%w: grouping sets are not supported", ErrLimitedView)
+	}
+
+	exps := []ValueExp{query.where, query.having, query.limit, query.offset}
+	for _, sel := range query.selectors {
+		exps = append(exps, sel)
+	}
+
+	for _, col := range query.groupBy {
+		exps = append(exps, col)
+	}
+
+	for _, col := range query.orderBy {
+		if col.sel != nil {
+			exps = append(exps, col.sel)
+		}
+	}
+
+	for _, exp := range exps {
+		err := checkViewExp(exp, ref.Alias())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return query, nil
+}
+
+func checkViewExp(exp ValueExp, tableAlias string) error {
+	switch e := exp.(type) {
+	case *ColSelector:
+		if e.db != "" || (e.table != "" && e.table != tableAlias) {
+			return fmt.Errorf("%w: column %s is not in table %s", ErrLimitedView, e.col, tableAlias)
+		}
+	case *AggColSelector:
+		if e.db != "" || (e.table != "" && e.table != tableAlias) {
+			return fmt.Errorf("%w: column %s is not in table %s", ErrLimitedView, e.col, tableAlias)
+		}
+	case *Param:
+		return fmt.Errorf("%w: parameters are not supported", ErrLimitedView)
+	case *ExistsBoolExp, *InSubQueryExp:
+		return fmt.Errorf("%w: subqueries are not supported", ErrLimitedView)
+	}
+
+	if exp == nil {
+		return nil
+	}
+
+	for _, e := range subExps(exp) {
+		err := checkViewExp(e, tableAlias)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// viewColRefs returns the names of the columns referenced by the query of a view, in the order they are written.
+// Names are returned by reference, so they are replaced when mapped back from the ids of the columns
+func viewColRefs(query *SelectStmt) []*string {
+	var refs []*string
+
+	for _, sel := range query.selectors {
+		collectViewColRefs(sel, &refs)
+	}
+
+	collectViewColRefs(query.where, &refs)
+
+	for _, col := range query.groupBy {
+		collectViewColRefs(col, &refs)
+	}
+
+	collectViewColRefs(query.having, &refs)
+
+	for _, col := range query.orderBy {
+		collectViewColRefs(col.sel, &refs)
+	}
+
+	return refs
+}
+
+func collectViewColRefs(exp ValueExp, refs *[]*string) {
+	switch e := exp.(type) {
+	case *ColSelector:
+		if e != nil {
+			*refs = append(*refs, &e.col)
+		}
+	case *AggColSelector:
+		if e.exp == nil && e.col != "*" {
+			*refs = append(*refs, &e.col)
+		}
+	}
+
+	if exp == nil {
+		return
+	}
+
+	for _, e := range subExps(exp) {
+		collectViewColRefs(e, refs)
+	}
+}
+
+type CreateViewStmt struct {
+	ifNotExists bool
+	name        string
+
+	// text is the query of the view as written in the statement, set once the statement is parsed
+	text      string
+	textStart int
+}
+
+func (stmt *CreateViewStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *CreateViewStmt) execAt(ctx context.Context, tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	if tx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	if stmt.ifNotExists && tx.currentDB.ExistView(stmt.name) {
+		return tx, nil
+	}
+
+	view, err := tx.currentDB.newView(stmt.name, stmt.text)
+	if err != nil {
+		return nil, err
+	}
+
+	// the query is checked by resolving it as done when the view is referenced
+	query, err := view.query()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := query.Resolve(ctx, tx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.Columns(ctx)
+	r.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	mappedKey := mapKey(tx.sqlPrefix(), catalogViewPrefix, EncodeID(view.db.id), EncodeID(view.id))
+
+	err = tx.set(mappedKey, nil, view.encode())
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// encode returns the catalog entry of the view, v={tableID}{colCount}({colID})*{nameLen}{name}{query}
+func (v *View) encode() []byte {
+	b := make([]byte, 0, 3*EncIDLen+len(v.colIDs)*EncIDLen+len(v.name)+len(v.text))
+
+	b = append(b, EncodeID(v.table.id)...)
+	b = append(b, EncodeID(uint32(len(v.colIDs)))...)
+
+	for _, colID := range v.colIDs {
+		b = append(b, EncodeID(colID)...)
+	}
+
+	b = append(b, EncodeID(uint32(len(v.name)))...)
+	b = append(b, v.name...)
+	b = append(b, v.text...)
+
+	return b
+}
+
+func (db *Database) loadViews(sqlPrefix []byte, tx *store.OngoingTx) error {
+	viewReaderSpec := store.KeyReaderSpec{
+		Prefix:  mapKey(sqlPrefix, catalogViewPrefix, EncodeID(db.id)),
+		Filters: []store.FilterFn{store.IgnoreExpired, store.IgnoreDeleted},
+	}
+
+	viewReader, err := tx.NewKeyReader(viewReaderSpec)
+	if err != nil {
+		return err
+	}
+	defer viewReader.Close()
+
+	for {
+		mkey, vref, err := viewReader.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		dbID, viewID, err := unmapViewID(sqlPrefix, mkey)
+		if err != nil {
+			return err
+		}
+
+		if dbID != db.id {
+			return ErrCorruptedData
+		}
+
+		v, err := vref.Resolve()
+		if err != nil {
+			return err
+		}
+
+		view, err := db.decodeView(v)
+		if err != nil {
+			return err
+		}
+
+		if viewID != view.id {
+			return ErrCorruptedData
+		}
+	}
+
+	return nil
+}
+
+func (db *Database) decodeView(v []byte) (*View, error) {
+	if len(v) < 2*EncIDLen {
+		return nil, ErrCorruptedData
+	}
+
+	table, err := db.GetTableByID(binary.BigEndian.Uint32(v))
+	if err != nil {
+		return nil, ErrCorruptedData
+	}
+
+	colCount := int(binary.BigEndian.Uint32(v[EncIDLen:]))
+	v = v[2*EncIDLen:]
+
+	if len(v) < (colCount+1)*EncIDLen {
+		return nil, ErrCorruptedData
+	}
+
+	colIDs := make([]uint32, colCount)
+
+	for i := range colIDs {
+		colIDs[i] = binary.BigEndian.Uint32(v[i*EncIDLen:])
+	}
+
+	v = v[colCount*EncIDLen:]
+
+	nameLen := int(binary.BigEndian.Uint32(v))
+	v = v[EncIDLen:]
+
+	if len(v) < nameLen {
+		return nil, ErrCorruptedData
+	}
+
+	return db.addView(string(v[:nameLen]), table, string(v[nameLen:]), colIDs)
+}
+
+// viewRef is a reference to a view, resolved as the query of the view.
+// The reference is kept so the view is resolved again each time a prepared statement is executed
+type viewRef struct {
+	ref   *tableRef
+	query *SelectStmt
+}
+
+func (v *viewRef) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
+	return v.query.inferParameters(ctx, tx, params)
+}
+
+func (v *viewRef) execAt(ctx context.Context, tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	return v.query.execAt(ctx, tx, params)
+}
+
+func (v *viewRef) Resolve(ctx context.Context, tx *SQLTx, params map[string]interface{}, scanSpecs *ScanSpecs) (RowReader, error) {
+	return v.query.Resolve(ctx, tx, params, scanSpecs)
+}
+
+func (v *viewRef) Alias() string {
+	return v.query.Alias()
+}
+
+// inlineViews replaces the references to views in the statement with the queries they name,
+// as views are stored in the catalog they are resolved each time the statement is planned
+func (tx *SQLTx) inlineViews(stmt SQLStmt) error {
+	switch s := stmt.(type) {
+	case *UpsertIntoStmt:
+		// rows are only written into tables
+	case *UpdateStmt:
+		ds, err := tx.inlineView(s.from)
+		if err != nil {
+			return err
+		}
+
+		s.from = ds
+
+		return tx.inlineExpViews(s.where)
+	case *DeleteFromStmt:
+		return tx.inlineExpViews(s.where)
+	case *SelectStmt:
+		if s == nil {
+			return nil
+		}
+
+		ds, err := tx.inlineView(s.ds)
+		if err != nil {
+			return err
+		}
+
+		s.ds = ds
+
+		for _, j := range s.joins {
+			ds, err := tx.inlineView(j.ds)
+			if err != nil {
+				return err
+			}
+
+			j.ds = ds
+
+			err = tx.inlineExpViews(j.cond)
+			if err != nil {
+				return err
+			}
+		}
+
+		exps := []ValueExp{s.where, s.having}
+		for _, sel := range s.selectors {
+			exps = append(exps, sel)
+		}
+
+		return tx.inlineExpViews(exps...)
+	case *UnionStmt:
+		left, err := tx.inlineView(s.left)
+		if err != nil {
+			return err
+		}
+
+		right, err := tx.inlineView(s.right)
+		if err != nil {
+			return err
+		}
+
+		s.left, s.right = left, right
+	case *SetOpStmt:
+		left, err := tx.inlineView(s.left)
+		if err != nil {
+			return err
+		}
+
+		right, err := tx.inlineView(s.right)
+		if err != nil {
+			return err
+		}
+
+		s.left, s.right = left, right
+	}
+
+	return nil
+}
+
+func (tx *SQLTx) inlineView(ds DataSource) (DataSource, error) {
+	ref, isViewRef := ds.(*viewRef)
+	if isViewRef {
+		ds = ref.ref
+	}
+
+	tableRef, isTableRef := ds.(*tableRef)
+	if !isTableRef {
+		if ds == nil {
+			return nil, nil
+		}
+
+		return ds, tx.inlineViews(ds)
+	}
+
+	if tx.currentDB == nil || tableRef.db != "" {
+		return ds, nil
+	}
+
+	view, exists := tx.currentDB.viewsByName[tableRef.table]
+	if !exists || tableRef.history || tableRef.sample != nil || tableRef.period.start != nil || tableRef.period.end != nil {
+		return ds, nil
+	}
+
+	query, err := view.query()
+	if err != nil {
+		return nil, err
+	}
+
+	query.as = tableRef.Alias()
+
+	return &viewRef{ref: tableRef, query: query}, nil
+}
+
+func (tx *SQLTx) inlineExpViews(exps ...ValueExp) error {
+	for _, exp := range exps {
+		var err error
+
+		switch e := exp.(type) {
+		case *ExistsBoolExp:
+			err = tx.inlineViews(e.q)
+		case *InSubQueryExp:
+			err = tx.inlineViews(e.q)
+		}
+		if err != nil {
+			return err
+		}
+
+		if exp != nil {
+			err = tx.inlineExpViews(subExps(exp)...)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
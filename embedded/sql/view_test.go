@@ -0,0 +1,168 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCreateView(t *testing.T) {
+	stmts, err := ParseString(`
+		CREATE VIEW v1 AS SELECT id, name FROM users WHERE name = 'a;b';
+		CREATE VIEW IF NOT EXISTS v2 AS
+			SELECT id FROM users
+	`)
+	require.NoError(t, err)
+	require.Len(t, stmts, 2)
+
+	v1 := stmts[0].(*CreateViewStmt)
+	require.Equal(t, "v1", v1.name)
+	require.False(t, v1.ifNotExists)
+	require.Equal(t, "SELECT id, name FROM users WHERE name = 'a;b'", v1.text)
+
+	v2 := stmts[1].(*CreateViewStmt)
+	require.Equal(t, "v2", v2.name)
+	require.True(t, v2.ifNotExists)
+	require.Equal(t, "SELECT id FROM users", v2.text)
+}
+
+func TestViews(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	t.Cleanup(func() { closeStore(t, st) })
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE DATABASE db1;
+		USE DATABASE db1;
+
+		CREATE TABLE users (id INTEGER, name VARCHAR, active BOOLEAN, PRIMARY KEY id);
+
+		INSERT INTO users(id, name, active) VALUES (1, 'user1', true), (2, 'user2', false), (3, 'user3', true);
+
+		CREATE VIEW active_users AS SELECT id, name AS username, active FROM users WHERE active = true;
+	`, nil)
+	require.NoError(t, err)
+
+	queryUsers := func(t *testing.T, engine *Engine, stmt DataSource) []string {
+		r, err := engine.QueryPreparedStmt(context.Background(), nil, stmt, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var names []string
+
+		for {
+			row, err := r.Read(context.Background())
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(t, err)
+
+			names = append(names, row.ValuesByPosition[0].Value().(string))
+		}
+
+		return names
+	}
+
+	prepare := func(t *testing.T, sql string) DataSource {
+		stmts, err := ParseString(sql)
+		require.NoError(t, err)
+		require.Len(t, stmts, 1)
+
+		return stmts[0].(DataSource)
+	}
+
+	stmt := prepare(t, "SELECT v.username FROM active_users AS v WHERE v.active AND id > 0")
+	require.Equal(t, []string{"user1", "user3"}, queryUsers(t, engine, stmt))
+
+	t.Run("views should keep working after their columns are renamed", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, `
+			ALTER TABLE users RENAME COLUMN name TO full_name;
+			ALTER TABLE users RENAME COLUMN active TO enabled;
+		`, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"user1", "user3"}, queryUsers(t, engine, stmt))
+		require.Equal(t, []string{"user3"}, queryUsers(t, engine, prepare(t, "SELECT username FROM active_users WHERE id = 3")))
+
+		r, err := engine.Query(context.Background(), nil, "SELECT * FROM active_users", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns(context.Background())
+		require.NoError(t, err)
+		require.Len(t, cols, 3)
+		require.Equal(t, "id", cols[0].Column)
+		require.Equal(t, "username", cols[1].Column)
+		require.Equal(t, "active", cols[2].Column)
+	})
+
+	t.Run("views should be loaded from the catalog", func(t *testing.T) {
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "USE DATABASE db1", nil)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"user1", "user3"}, queryUsers(t, engine, stmt))
+
+		catalog, err := engine.Catalog(context.Background(), nil)
+		require.NoError(t, err)
+
+		db, err := catalog.GetDatabaseByName("db1")
+		require.NoError(t, err)
+		require.Len(t, db.GetViews(), 1)
+		require.Equal(t, "active_users", db.GetViews()[0].Name())
+		require.Equal(t, "users", db.GetViews()[0].Table().Name())
+	})
+
+	t.Run("views and tables should not share names", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "CREATE VIEW active_users AS SELECT id FROM users", nil)
+		require.ErrorIs(t, err, ErrViewAlreadyExists)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW IF NOT EXISTS active_users AS SELECT id FROM users", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW users AS SELECT id FROM users", nil)
+		require.ErrorIs(t, err, ErrTableAlreadyExists)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE active_users (id INTEGER, PRIMARY KEY id)", nil)
+		require.ErrorIs(t, err, ErrTableAlreadyExists)
+	})
+
+	t.Run("views should only list the columns of a single table", func(t *testing.T) {
+		for _, query := range []string{
+			"SELECT * FROM users",
+			"SELECT id FROM active_users",
+			"SELECT u1.id FROM users AS u1 INNER JOIN users AS u2 ON u1.id = u2.id",
+			"SELECT id FROM users WHERE id = @id",
+			"SELECT id FROM users WHERE id IN (SELECT id FROM users)",
+		} {
+			_, _, err := engine.Exec(context.Background(), nil, "CREATE VIEW invalid AS "+query, nil)
+			require.ErrorIs(t, err, ErrLimitedView, query)
+		}
+
+		_, _, err := engine.Exec(context.Background(), nil, "CREATE VIEW invalid AS SELECT name FROM users", nil)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+	})
+}
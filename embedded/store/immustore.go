@@ -128,6 +128,13 @@ const (
 	metaFileSize     = "FILE_SIZE"
 )
 
+// values truncation flags used by exported transactions
+const (
+	noValueTruncated    byte = 0
+	allValuesTruncated  byte = 1
+	someValuesTruncated byte = 2
+)
+
 const indexDirname = "index"
 const ahtDirname = "aht"
 
@@ -201,6 +208,11 @@ type ImmuStore struct {
 	mutex sync.Mutex
 
 	compactionDisabled bool
+
+	// transactions are synced in batches when syncBatchSize > 1
+	syncBatchMutex       sync.Mutex
+	syncBatchSize        int
+	syncBatchIdleTimeout time.Duration
 }
 
 type refVLog struct {
@@ -530,6 +542,8 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		_valBs: make([]byte, maxValueLen),
 
 		compactionDisabled: opts.CompactionDisabled,
+
+		syncBatchSize: 1,
 	}
 
 	if store.aht.Size() > precommittedTxID {
@@ -589,22 +603,24 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 				// passive wait for one new transaction at least
 				store.inmemPrecommitWHub.WaitFor(context.Background(), committedTxID+1)
 
-				// TODO: waiting on earlier stages of transaction processing may also be possible
-				prevLatestPrecommitedTx := committedTxID + 1
+				if !store.waitForSyncBatch() {
+					// TODO: waiting on earlier stages of transaction processing may also be possible
+					prevLatestPrecommitedTx := committedTxID + 1
 
-				// TODO: parametrize concurrency evaluation
-				for i := 0; i < 4; i++ {
-					// give some time for more transactions to be precommitted
-					time.Sleep(store.syncFrequency / 4)
+					// TODO: parametrize concurrency evaluation
+					for i := 0; i < 4; i++ {
+						// give some time for more transactions to be precommitted
+						time.Sleep(store.syncFrequency / 4)
 
-					latestPrecommitedTx := store.lastPrecommittedTxID()
+						latestPrecommitedTx := store.lastPrecommittedTxID()
 
-					if prevLatestPrecommitedTx == latestPrecommitedTx {
-						// avoid waiting if there are no new transactions
-						break
-					}
+						if prevLatestPrecommitedTx == latestPrecommitedTx {
+							// avoid waiting if there are no new transactions
+							break
+						}
 
-					prevLatestPrecommitedTx = latestPrecommitedTx
+						prevLatestPrecommitedTx = latestPrecommitedTx
+					}
 				}
 
 				// ensure durability
@@ -2030,6 +2046,13 @@ func (r *slicedReaderAt) ReadAt(bs []byte, off int64) (n int, err error) {
 }
 
 func (s *ImmuStore) ExportTx(txID uint64, allowPrecommitted bool, tx *Tx) ([]byte, error) {
+	return s.ExportTxWithValueFilter(txID, allowPrecommitted, nil, tx)
+}
+
+// ExportTxWithValueFilter exports a transaction replacing the value of every entry whose key is
+// matched by excludeValue with its digest. Digests are enough to rebuild the transaction hash tree,
+// so the replicated transaction remains verifiable, but excluded values can not be read from the replica
+func (s *ImmuStore) ExportTxWithValueFilter(txID uint64, allowPrecommitted bool, excludeValue func(key []byte) bool, tx *Tx) ([]byte, error) {
 	err := s.readTx(txID, allowPrecommitted, tx)
 	if err != nil {
 		return nil, err
@@ -2054,8 +2077,10 @@ func (s *ImmuStore) ExportTx(txID uint64, allowPrecommitted bool, tx *Tx) ([]byt
 		return nil, err
 	}
 
-	var isValueTruncated bool
-	for _, e := range tx.Entries() {
+	digestOnly := make([]bool, len(tx.Entries()))
+	digestOnlyCount := 0
+
+	for i, e := range tx.Entries() {
 		var blen [lszSize]byte
 
 		// kLen
@@ -2093,15 +2118,22 @@ func (s *ImmuStore) ExportTx(txID uint64, allowPrecommitted bool, tx *Tx) ([]byt
 		// val
 		// TODO: improve value reading implementation, get rid of _valBs
 		s._valBsMux.Lock()
-		_, err = s.readValueAt(s._valBs[:e.vLen], e.vOff, e.hVal)
-		if err != nil && err != io.EOF {
-			s._valBsMux.Unlock()
-			return nil, err
+
+		if excludeValue != nil && excludeValue(e.Key()) {
+			// excluded values are exported the same way as truncated ones
+			err = io.EOF
+		} else {
+			_, err = s.readValueAt(s._valBs[:e.vLen], e.vOff, e.hVal)
+			if err != nil && err != io.EOF {
+				s._valBsMux.Unlock()
+				return nil, err
+			}
 		}
 
 		// if the error is eof, the value has been truncated, so we do not write the value bytes
 		if err == io.EOF {
-			isValueTruncated = true
+			digestOnly[i] = true
+			digestOnlyCount++
 			// vHashLen
 			binary.BigEndian.PutUint32(blen[:], uint32(len(e.hVal)))
 			_, err = buf.Write(blen[:])
@@ -2136,20 +2168,32 @@ func (s *ImmuStore) ExportTx(txID uint64, allowPrecommitted bool, tx *Tx) ([]byt
 	}
 
 	// NOTE: adding a boolean to the header to indicate if the transaction has values or not,
-	// so that ReplicateTx knows if the transaction should be precommited with no values
-	var truncatedValByte [1]byte
-	truncatedValByte[0] = 0
-	if isValueTruncated {
-		truncatedValByte[0] = 1
+	// so that ReplicateTx knows if the transaction should be precommited with no values.
+	// When only some of the values were replaced by their digests, a bitmap marking such entries follows
+	truncatedVal := []byte{noValueTruncated}
+
+	if digestOnlyCount == len(digestOnly) && digestOnlyCount > 0 {
+		truncatedVal[0] = allValuesTruncated
+	} else if digestOnlyCount > 0 {
+		truncatedVal[0] = someValuesTruncated
+
+		bitmap := make([]byte, (len(digestOnly)+7)/8)
+		for i, truncated := range digestOnly {
+			if truncated {
+				bitmap[i/8] |= 1 << (i % 8)
+			}
+		}
+
+		truncatedVal = append(truncatedVal, bitmap...)
 	}
 
-	binary.BigEndian.PutUint16(b[:], uint16(len(truncatedValByte)))
+	binary.BigEndian.PutUint16(b[:], uint16(len(truncatedVal)))
 	_, err = buf.Write(b[:sszSize])
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = buf.Write(truncatedValByte[:])
+	_, err = buf.Write(truncatedVal)
 	if err != nil {
 		return nil, err
 	}
@@ -2157,49 +2201,44 @@ func (s *ImmuStore) ExportTx(txID uint64, allowPrecommitted bool, tx *Tx) ([]byt
 	return buf.Bytes(), nil
 }
 
-func (s *ImmuStore) ReplicateTx(ctx context.Context, exportedTx []byte, waitForIndexing bool) (*TxHeader, error) {
+// parseExportedTx decodes a transaction exported with ExportTx, isTruncated tells whether the value
+// of an entry was replaced by its digest
+func parseExportedTx(exportedTx []byte) (hdr *TxHeader, entries []*EntrySpec, isTruncated func(int) bool, err error) {
 	if len(exportedTx) == 0 {
-		return nil, ErrIllegalArguments
+		return nil, nil, nil, ErrIllegalArguments
 	}
 
 	i := 0
 
 	if len(exportedTx) < lszSize {
-		return nil, ErrIllegalArguments
+		return nil, nil, nil, ErrIllegalArguments
 	}
 
 	hdrLen := int(binary.BigEndian.Uint32(exportedTx[i:]))
 	i += lszSize
 
 	if len(exportedTx) < i+hdrLen {
-		return nil, ErrIllegalArguments
+		return nil, nil, nil, ErrIllegalArguments
 	}
 
-	hdr := &TxHeader{}
-	err := hdr.ReadFrom(exportedTx[i : i+hdrLen])
+	hdr = &TxHeader{}
+	err = hdr.ReadFrom(exportedTx[i : i+hdrLen])
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 	i += hdrLen
 
-	txSpec, err := s.NewWriteOnlyTx(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	txSpec.metadata = hdr.Metadata
-
-	var entries []*EntrySpec = make([]*EntrySpec, 0)
+	entries = make([]*EntrySpec, 0)
 	for e := 0; e < hdr.NEntries; e++ {
 		if len(exportedTx) < i+2*sszSize+lszSize {
-			return nil, ErrIllegalArguments
+			return nil, nil, nil, ErrIllegalArguments
 		}
 
 		kLen := int(binary.BigEndian.Uint16(exportedTx[i:]))
 		i += sszSize
 
 		if len(exportedTx) < i+sszSize+lszSize+kLen {
-			return nil, ErrIllegalArguments
+			return nil, nil, nil, ErrIllegalArguments
 		}
 
 		key := make([]byte, kLen)
@@ -2210,7 +2249,7 @@ func (s *ImmuStore) ReplicateTx(ctx context.Context, exportedTx []byte, waitForI
 		i += sszSize
 
 		if len(exportedTx) < i+mdLen {
-			return nil, ErrIllegalArguments
+			return nil, nil, nil, ErrIllegalArguments
 		}
 
 		var md *KVMetadata
@@ -2220,7 +2259,7 @@ func (s *ImmuStore) ReplicateTx(ctx context.Context, exportedTx []byte, waitForI
 
 			err := md.unsafeReadFrom(exportedTx[i : i+mdLen])
 			if err != nil {
-				return nil, err
+				return nil, nil, nil, err
 			}
 			i += mdLen
 		}
@@ -2230,7 +2269,7 @@ func (s *ImmuStore) ReplicateTx(ctx context.Context, exportedTx []byte, waitForI
 		i += lszSize
 
 		if len(exportedTx) < i+vLen {
-			return nil, ErrIllegalArguments
+			return nil, nil, nil, ErrIllegalArguments
 		}
 
 		entries = append(entries, &EntrySpec{
@@ -2242,51 +2281,202 @@ func (s *ImmuStore) ReplicateTx(ctx context.Context, exportedTx []byte, waitForI
 		i += vLen
 	}
 
-	var isTruncated bool
+	isTruncated = func(int) bool { return false }
 
 	// check if there is truncated value information in the transaction
 	if i < len(exportedTx) {
 		// information for truncated value
+		if len(exportedTx) < i+sszSize {
+			return nil, nil, nil, ErrIllegalArguments
+		}
+
 		tLen := int(binary.BigEndian.Uint16(exportedTx[i:]))
 		i += sszSize
-		if len(exportedTx) < i+tLen {
-			return nil, ErrIllegalArguments
+		if tLen == 0 || len(exportedTx) < i+tLen {
+			return nil, nil, nil, ErrIllegalArguments
 		}
 
 		v := exportedTx[i : i+tLen]
-		// v[0] == 1 means that the value is truncated
-		// validate that the value is either 0 or 1
-		if v != nil && len(v) > 0 && v[0] > 1 {
-			return nil, ErrIllegalTruncationArgument
+
+		switch v[0] {
+		case noValueTruncated:
+		case allValuesTruncated:
+			{
+				isTruncated = func(int) bool { return true }
+			}
+		case someValuesTruncated:
+			{
+				bitmap := v[1:]
+				if len(bitmap) != (hdr.NEntries+7)/8 {
+					return nil, nil, nil, ErrIllegalTruncationArgument
+				}
+
+				isTruncated = func(e int) bool { return bitmap[e/8]&(1<<(e%8)) != 0 }
+			}
+		default:
+			{
+				return nil, nil, nil, ErrIllegalTruncationArgument
+			}
 		}
-		isTruncated = v[0] == 1
+
 		i += tLen
 	}
 
 	if i != len(exportedTx) {
+		return nil, nil, nil, ErrIllegalArguments
+	}
+
+	return hdr, entries, isTruncated, nil
+}
+
+func (s *ImmuStore) ReplicateTx(ctx context.Context, exportedTx []byte, waitForIndexing bool) (*TxHeader, error) {
+	txHdr, err := s.precommitReplicatedTx(ctx, exportedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.waitForReplicatedTx(ctx, txHdr, waitForIndexing)
+}
+
+// ReplicateTxs replicates consecutive transactions exported with ExportTx as a single batch, each transaction keeps
+// its own id and header but waiting for them to be synced (and indexed) is done once for the whole batch.
+// The headers of the transactions precommitted before any failure are returned along with the error
+func (s *ImmuStore) ReplicateTxs(ctx context.Context, exportedTxs [][]byte, waitForIndexing bool) ([]*TxHeader, error) {
+	if len(exportedTxs) == 0 {
 		return nil, ErrIllegalArguments
 	}
 
+	hdrs := make([]*TxHeader, 0, len(exportedTxs))
+
+	for _, exportedTx := range exportedTxs {
+		txHdr, err := s.precommitReplicatedTx(ctx, exportedTx)
+		if err != nil {
+			return hdrs, err
+		}
+
+		hdrs = append(hdrs, txHdr)
+	}
+
+	_, err := s.waitForReplicatedTx(ctx, hdrs[len(hdrs)-1], waitForIndexing)
+	if err != nil {
+		return hdrs, err
+	}
+
+	return hdrs, nil
+}
+
+func (s *ImmuStore) precommitReplicatedTx(ctx context.Context, exportedTx []byte) (*TxHeader, error) {
+	hdr, entries, isTruncated, err := parseExportedTx(exportedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	txSpec, err := s.NewWriteOnlyTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	txSpec.metadata = hdr.Metadata
+
 	// add entries to tx
-	for _, e := range entries {
+	for i, e := range entries {
 		var err error
-		if isTruncated {
-			err = txSpec.set(e.Key, e.Metadata, nil, byte32(e.Value), isTruncated)
+		if isTruncated(i) {
+			if len(e.Value) != sha256.Size {
+				return nil, ErrIllegalTruncationArgument
+			}
+
+			err = txSpec.set(e.Key, e.Metadata, nil, byte32(e.Value), true)
 		} else {
-			err = txSpec.set(e.Key, e.Metadata, e.Value, e.hashValue, isTruncated)
+			err = txSpec.set(e.Key, e.Metadata, e.Value, e.hashValue, false)
 		}
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	txHdr, err := s.precommit(ctx, txSpec, hdr)
+	return s.precommit(ctx, txSpec, hdr)
+}
+
+// ParseExportedTx returns the header and the entries of a transaction exported with ExportTx.
+// Entries whose value was truncated or excluded from the export hold the digest of their value
+func ParseExportedTx(exportedTx []byte) (*TxHeader, []*EntrySpec, error) {
+	hdr, entries, isTruncated, err := parseExportedTx(exportedTx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, e := range entries {
+		if isTruncated(i) {
+			if len(e.Value) != sha256.Size {
+				return nil, nil, ErrIllegalTruncationArgument
+			}
+
+			e.hashValue = byte32(e.Value)
+			e.isValueTruncated = true
+		}
+	}
+
+	return hdr, entries, nil
+}
+
+// ReplicateTransformedTx commits the entries of a replicated transaction after being transformed by the replica.
+// Transformed entries can not be validated against the header of the original transaction, thus the transaction
+// is committed with the same id as the original one but with the timestamp and the hash chain of the replica.
+// Transformed transactions can not be verified against the primary
+func (s *ImmuStore) ReplicateTransformedTx(ctx context.Context, hdr *TxHeader, entries []*EntrySpec, waitForIndexing bool) (*TxHeader, error) {
+	if hdr == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	if s.lastPrecommittedTxID() >= hdr.ID {
+		return nil, ErrTxAlreadyCommitted
+	}
+
+	// ensure tx is committed in the expected order
+	err := s.inmemPrecommitWHub.WaitFor(ctx, hdr.ID-1)
+	if err == watchers.ErrAlreadyClosed {
+		return nil, ErrAlreadyClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	txSpec, err := s.NewWriteOnlyTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	txSpec.metadata = hdr.Metadata
+
+	for _, e := range entries {
+		err := txSpec.set(e.Key, e.Metadata, e.Value, e.hashValue, e.isValueTruncated)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	txHdr, err := s.precommit(ctx, txSpec, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	if txHdr.ID != hdr.ID {
+		return nil, fmt.Errorf("%w: transformed tx %d was committed as tx %d", ErrUnexpectedError, hdr.ID, txHdr.ID)
+	}
+
+	return s.waitForReplicatedTx(ctx, txHdr, waitForIndexing)
+}
+
+func (s *ImmuStore) waitForReplicatedTx(ctx context.Context, txHdr *TxHeader, waitForIndexing bool) (*TxHeader, error) {
+	batchSize, _ := s.syncBatch()
+	if batchSize > 1 && !waitForIndexing {
+		// durability is relaxed while transactions are synced in batches
+		return txHdr, nil
+	}
+
 	// wait for syncing to happen before exposing the header
-	err = s.durablePrecommitWHub.WaitFor(ctx, txHdr.ID)
+	err := s.durablePrecommitWHub.WaitFor(ctx, txHdr.ID)
 	if err == watchers.ErrAlreadyClosed {
 		return nil, ErrAlreadyClosed
 	}
@@ -2314,6 +2504,63 @@ func (s *ImmuStore) ReplicateTx(ctx context.Context, exportedTx []byte, waitForI
 	return txHdr, nil
 }
 
+// VerifyExportedTx checks the integrity of a transaction exported with ExportTx without committing it.
+// The digest of its entries must match the one included in its header, thus any change to its entries is detected.
+// The header of the transaction is returned so it can be checked against the previous one
+func VerifyExportedTx(exportedTx []byte) (*TxHeader, error) {
+	hdr, entries, isTruncated, err := parseExportedTx(exportedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	txEntryDigest, err := hdr.TxEntryDigest()
+	if err != nil {
+		return nil, err
+	}
+
+	digests := make([][sha256.Size]byte, len(entries))
+
+	for i, e := range entries {
+		var hVal [sha256.Size]byte
+
+		if isTruncated(i) {
+			if len(e.Value) != sha256.Size {
+				return nil, ErrIllegalTruncationArgument
+			}
+
+			hVal = byte32(e.Value)
+		} else {
+			hVal = sha256.Sum256(e.Value)
+		}
+
+		digests[i], err = txEntryDigest(NewTxEntry(e.Key, e.Metadata, len(e.Value), hVal, 0))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	htree, err := htree.New(len(entries))
+	if err != nil {
+		return nil, err
+	}
+
+	err = htree.BuildWith(digests)
+	if err != nil {
+		return nil, err
+	}
+
+	eh, err := htree.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	if eh != hdr.Eh {
+		return nil, fmt.Errorf("%w: entries digest mismatch at tx %d", ErrorCorruptedTxData, hdr.ID)
+	}
+
+	return hdr, nil
+}
+
 func (s *ImmuStore) FirstTxSince(ts time.Time) (*TxHeader, error) {
 	left := uint64(1)
 	right := s.LastCommittedTxID()
@@ -2654,6 +2901,71 @@ func (s *ImmuStore) validatePreconditions(preconditions []Precondition) error {
 	return nil
 }
 
+// SetSyncBatch relaxes durability by syncing transactions in batches: pending transactions are synced
+// once batchSize of them are precommitted or when no new transaction is precommitted within idleTimeout.
+// A batchSize of zero batches as many transactions as active transactions are allowed.
+// Replicated transactions are not waited to become durable while batching, so they may be lost on a crash.
+// A batchSize of one restores the default behaviour and syncs any pending transaction
+func (s *ImmuStore) SetSyncBatch(batchSize int, idleTimeout time.Duration) error {
+	if batchSize < 0 || batchSize > s.maxActiveTransactions || (batchSize != 1 && idleTimeout <= 0) {
+		return ErrIllegalArguments
+	}
+
+	if batchSize == 0 {
+		batchSize = s.maxActiveTransactions
+	}
+
+	s.syncBatchMutex.Lock()
+	wasBatching := s.syncBatchSize > 1
+	s.syncBatchSize = batchSize
+	s.syncBatchIdleTimeout = idleTimeout
+	s.syncBatchMutex.Unlock()
+
+	if wasBatching && batchSize == 1 && s.synced {
+		return s.Sync()
+	}
+
+	return nil
+}
+
+func (s *ImmuStore) syncBatch() (batchSize int, idleTimeout time.Duration) {
+	s.syncBatchMutex.Lock()
+	defer s.syncBatchMutex.Unlock()
+
+	return s.syncBatchSize, s.syncBatchIdleTimeout
+}
+
+// waitForSyncBatch waits until a batch of transactions is ready to be synced,
+// it returns false without waiting if transactions are not synced in batches
+func (s *ImmuStore) waitForSyncBatch() bool {
+	batchSize, _ := s.syncBatch()
+	if batchSize == 1 {
+		return false
+	}
+
+	durablePrecommittedTxID, _, _ := s.durablePrecommitWHub.Status()
+
+	for {
+		// the batch may be changed while waiting
+		batchSize, idleTimeout := s.syncBatch()
+
+		latestPrecommitedTx := s.lastPrecommittedTxID()
+
+		if batchSize == 1 || latestPrecommitedTx-durablePrecommittedTxID >= uint64(batchSize) {
+			return true
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), idleTimeout)
+		err := s.inmemPrecommitWHub.WaitFor(ctx, latestPrecommitedTx+1)
+		cancel()
+
+		if err != nil {
+			// either idle or closed
+			return true
+		}
+	}
+}
+
 func (s *ImmuStore) Sync() error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
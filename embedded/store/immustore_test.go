@@ -2401,6 +2401,355 @@ func TestExportAndReplicateTx(t *testing.T) {
 	require.ErrorIs(t, err, ErrIllegalArguments)
 }
 
+func TestReplicateTxs(t *testing.T) {
+	primaryStore, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, primaryStore)
+
+	replicaStore, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, replicaStore)
+
+	txholder := tempTxHolder(t, primaryStore)
+
+	var etxs [][]byte
+
+	for i := 1; i <= 5; i++ {
+		tx, err := primaryStore.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte("value"))
+		require.NoError(t, err)
+
+		hdr, err := tx.Commit(context.Background())
+		require.NoError(t, err)
+
+		etx, err := primaryStore.ExportTx(hdr.ID, false, txholder)
+		require.NoError(t, err)
+
+		etxs = append(etxs, etx)
+	}
+
+	_, err = replicaStore.ReplicateTxs(context.Background(), nil, false)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	hdrs, err := replicaStore.ReplicateTxs(context.Background(), etxs[:3], true)
+	require.NoError(t, err)
+	require.Len(t, hdrs, 3)
+
+	for i, hdr := range hdrs {
+		require.Equal(t, uint64(i+1), hdr.ID)
+	}
+
+	// transactions replicated before the failure are returned along with the error
+	hdrs, err = replicaStore.ReplicateTxs(context.Background(), [][]byte{etxs[3], etxs[2]}, false)
+	require.ErrorIs(t, err, ErrTxAlreadyCommitted)
+	require.Len(t, hdrs, 1)
+	require.Equal(t, uint64(4), hdrs[0].ID)
+
+	_, err = replicaStore.ReplicateTxs(context.Background(), etxs[4:], true)
+	require.NoError(t, err)
+
+	require.Equal(t, primaryStore.LastCommittedTxID(), replicaStore.LastCommittedTxID())
+
+	primaryTxID, primaryAlh := primaryStore.CommittedAlh()
+	replicaTxID, replicaAlh := replicaStore.CommittedAlh()
+	require.Equal(t, primaryTxID, replicaTxID)
+	require.Equal(t, primaryAlh, replicaAlh)
+}
+
+func TestReplicateTransformedTx(t *testing.T) {
+	primaryStore, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, primaryStore)
+
+	replicaStore, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, replicaStore)
+
+	txholder := tempTxHolder(t, primaryStore)
+
+	for i := 1; i <= 2; i++ {
+		tx, err := primaryStore.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("public%d", i)), nil, []byte("public-value"))
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("secret%d", i)), nil, []byte("secret-value"))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	_, _, err = ParseExportedTx(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = replicaStore.ReplicateTransformedTx(context.Background(), nil, nil, false)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	for txID := uint64(1); txID <= 2; txID++ {
+		etx, err := primaryStore.ExportTx(txID, false, txholder)
+		require.NoError(t, err)
+
+		hdr, entries, err := ParseExportedTx(etx)
+		require.NoError(t, err)
+		require.Equal(t, txID, hdr.ID)
+		require.Len(t, entries, 2)
+
+		for _, e := range entries {
+			if bytes.HasPrefix(e.Key, []byte("secret")) {
+				e.Value = []byte("redacted")
+			}
+		}
+
+		rhdr, err := replicaStore.ReplicateTransformedTx(context.Background(), hdr, entries, true)
+		require.NoError(t, err)
+		require.Equal(t, hdr.ID, rhdr.ID)
+		require.NotEqual(t, hdr.Alh(), rhdr.Alh())
+
+		_, err = replicaStore.ReplicateTransformedTx(context.Background(), hdr, entries, true)
+		require.ErrorIs(t, err, ErrTxAlreadyCommitted)
+	}
+
+	valRef, err := replicaStore.Get([]byte("secret2"))
+	require.NoError(t, err)
+
+	val, err := valRef.Resolve()
+	require.NoError(t, err)
+	require.Equal(t, []byte("redacted"), val)
+
+	valRef, err = replicaStore.Get([]byte("public2"))
+	require.NoError(t, err)
+
+	val, err = valRef.Resolve()
+	require.NoError(t, err)
+	require.Equal(t, []byte("public-value"), val)
+}
+
+func TestExportAndReplicateTxWithValueFilter(t *testing.T) {
+	primaryStore, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, primaryStore)
+
+	replicaStore, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, replicaStore)
+
+	tx, err := primaryStore.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("public1"), nil, []byte("public-value1"))
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("secret1"), nil, []byte("secret-value1"))
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("public2"), nil, []byte("public-value2"))
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit(context.Background())
+	require.NoError(t, err)
+
+	txholder := tempTxHolder(t, primaryStore)
+
+	excludeSecrets := func(key []byte) bool {
+		return bytes.HasPrefix(key, []byte("secret"))
+	}
+
+	etx, err := primaryStore.ExportTxWithValueFilter(hdr.ID, false, excludeSecrets, txholder)
+	require.NoError(t, err)
+	require.NotContains(t, string(etx), "secret-value1")
+	require.Contains(t, string(etx), "public-value1")
+
+	t.Run("invalid truncation info should be rejected", func(t *testing.T) {
+		// trailer: tLen, flag and a single byte bitmap
+		require.Equal(t, []byte{0, 2, someValuesTruncated, 0x02}, etx[len(etx)-4:])
+
+		invalidFlag := make([]byte, len(etx))
+		copy(invalidFlag, etx)
+		invalidFlag[len(etx)-2] = someValuesTruncated + 1
+
+		_, err := replicaStore.ReplicateTx(context.Background(), invalidFlag, false)
+		require.ErrorIs(t, err, ErrIllegalTruncationArgument)
+
+		invalidBitmap := make([]byte, len(etx))
+		copy(invalidBitmap, etx)
+		invalidBitmap[len(etx)-1] = 0x03
+
+		_, err = replicaStore.ReplicateTx(context.Background(), invalidBitmap, false)
+		require.ErrorIs(t, err, ErrIllegalTruncationArgument)
+	})
+
+	rhdr, err := replicaStore.ReplicateTx(context.Background(), etx, false)
+	require.NoError(t, err)
+	require.Equal(t, hdr.Alh(), rhdr.Alh())
+
+	err = replicaStore.WaitForIndexingUpto(context.Background(), rhdr.ID)
+	require.NoError(t, err)
+
+	valRef, err := replicaStore.Get([]byte("public2"))
+	require.NoError(t, err)
+
+	val, err := valRef.Resolve()
+	require.NoError(t, err)
+	require.Equal(t, []byte("public-value2"), val)
+
+	valRef, err = replicaStore.Get([]byte("secret1"))
+	require.NoError(t, err)
+	require.Equal(t, sha256.Sum256([]byte("secret-value1")), valRef.HVal())
+
+	// same as truncated values, excluded values are not stored by the replica
+	val, err = valRef.Resolve()
+	require.NoError(t, err)
+	require.Empty(t, val)
+
+	t.Run("unfiltered export should remain unchanged", func(t *testing.T) {
+		etx1, err := primaryStore.ExportTx(hdr.ID, false, txholder)
+		require.NoError(t, err)
+
+		etx2, err := primaryStore.ExportTxWithValueFilter(hdr.ID, false, func(key []byte) bool { return false }, txholder)
+		require.NoError(t, err)
+		require.Equal(t, etx1, etx2)
+		require.Equal(t, []byte{0, 1, noValueTruncated}, etx1[len(etx1)-3:])
+	})
+}
+
+func TestVerifyExportedTx(t *testing.T) {
+	st, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	tx, err := st.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key1"), nil, []byte("value1"))
+	require.NoError(t, err)
+
+	md := NewKVMetadata()
+
+	err = md.AsNonIndexable(true)
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key2"), md, []byte("value2"))
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit(context.Background())
+	require.NoError(t, err)
+
+	txholder := tempTxHolder(t, st)
+
+	etx, err := st.ExportTx(hdr.ID, false, txholder)
+	require.NoError(t, err)
+
+	t.Run("valid transactions should be verified", func(t *testing.T) {
+		vhdr, err := VerifyExportedTx(etx)
+		require.NoError(t, err)
+		require.Equal(t, hdr.Alh(), vhdr.Alh())
+	})
+
+	t.Run("transactions with values replaced by digests should be verified", func(t *testing.T) {
+		filtered, err := st.ExportTxWithValueFilter(hdr.ID, false, func(key []byte) bool { return bytes.Equal(key, []byte("key2")) }, txholder)
+		require.NoError(t, err)
+
+		vhdr, err := VerifyExportedTx(filtered)
+		require.NoError(t, err)
+		require.Equal(t, hdr.Alh(), vhdr.Alh())
+	})
+
+	t.Run("tampered transactions should fail verification", func(t *testing.T) {
+		tampered := bytes.Replace(etx, []byte("value1"), []byte("value9"), 1)
+
+		_, err := VerifyExportedTx(tampered)
+		require.ErrorIs(t, err, ErrorCorruptedTxData)
+
+		_, err = VerifyExportedTx(etx[:len(etx)-1])
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = VerifyExportedTx(nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
+
+func TestReplicateTxWithSyncBatch(t *testing.T) {
+	primaryStore, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, primaryStore)
+
+	replicaStore, err := Open(t.TempDir(), DefaultOptions().WithMaxActiveTransactions(10))
+	require.NoError(t, err)
+	defer immustoreClose(t, replicaStore)
+
+	err = replicaStore.SetSyncBatch(-1, time.Second)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = replicaStore.SetSyncBatch(11, time.Second)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = replicaStore.SetSyncBatch(2, 0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	txholder := tempTxHolder(t, primaryStore)
+
+	replicateNext := func() {
+		tx, err := primaryStore.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key"), nil, []byte("value"))
+		require.NoError(t, err)
+
+		hdr, err := tx.Commit(context.Background())
+		require.NoError(t, err)
+
+		etx, err := primaryStore.ExportTx(hdr.ID, false, txholder)
+		require.NoError(t, err)
+
+		_, err = replicaStore.ReplicateTx(context.Background(), etx, false)
+		require.NoError(t, err)
+	}
+
+	t.Run("transactions should be synced once the batch is complete", func(t *testing.T) {
+		err := replicaStore.SetSyncBatch(3, time.Hour)
+		require.NoError(t, err)
+
+		replicateNext()
+		replicateNext()
+
+		time.Sleep(100 * time.Millisecond)
+		require.Zero(t, replicaStore.LastCommittedTxID())
+
+		replicateNext()
+
+		require.Eventually(t, func() bool { return replicaStore.LastCommittedTxID() == 3 }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("transactions should be synced when idle", func(t *testing.T) {
+		err := replicaStore.SetSyncBatch(0, 100*time.Millisecond)
+		require.NoError(t, err)
+
+		replicateNext()
+
+		require.Equal(t, uint64(3), replicaStore.LastCommittedTxID())
+		require.Eventually(t, func() bool { return replicaStore.LastCommittedTxID() == 4 }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("pending transactions should be synced when batching is disabled", func(t *testing.T) {
+		err := replicaStore.SetSyncBatch(10, time.Hour)
+		require.NoError(t, err)
+
+		replicateNext()
+
+		err = replicaStore.SetSyncBatch(1, 0)
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), replicaStore.LastCommittedTxID())
+
+		// every transaction is durable once replicated
+		replicateNext()
+		require.Equal(t, uint64(6), replicaStore.LastCommittedTxID())
+	})
+}
+
 func TestExportAndReplicateTxCornerCases(t *testing.T) {
 	primaryDir := t.TempDir()
 
@@ -402,6 +402,26 @@ func (tx *OngoingTx) GetWithPrefixAndFilters(prefix, neq []byte, filters ...Filt
 	return key, valRef, nil
 }
 
+// History returns the ids of the transactions in which the key was set as seen by the snapshot of the transaction.
+// Reading the history does not add the key to the read set of the transaction, and it's not available
+// for keys already set by the transaction, as they have no transaction id until committed
+func (tx *OngoingTx) History(key []byte, offset uint64, descOrder bool, limit int) (txs []uint64, hCount uint64, err error) {
+	if tx.closed {
+		return nil, 0, ErrAlreadyClosed
+	}
+
+	if tx.IsWriteOnly() {
+		return nil, 0, ErrWriteOnlyTx
+	}
+
+	_, pending := tx.entriesByKey[sha256.Sum256(key)]
+	if pending {
+		return nil, 0, fmt.Errorf("%w: the history of a key set by an ongoing transaction is not available", ErrIllegalState)
+	}
+
+	return tx.snap.History(key, offset, descOrder, limit)
+}
+
 func (tx *OngoingTx) NewKeyReader(spec KeyReaderSpec) (KeyReader, error) {
 	if tx.closed {
 		return nil, ErrAlreadyClosed
@@ -17,6 +17,7 @@ limitations under the License.
 package store
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -79,3 +80,43 @@ func TestOngoingTxCheckPreconditionsCornerCases(t *testing.T) {
 	err = otx.checkPreconditions(st)
 	require.ErrorIs(t, err, ErrAlreadyClosed)
 }
+
+func TestOngoingTxHistory(t *testing.T) {
+	st, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+
+	defer immustoreClose(t, st)
+
+	for i := 0; i < 3; i++ {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key"), nil, []byte{byte(i)})
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	tx, err := st.NewTx(context.Background(), DefaultTxOptions())
+	require.NoError(t, err)
+	defer tx.Cancel()
+
+	txs, hCount, err := tx.History([]byte("key"), 0, false, 10)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), hCount)
+	require.Equal(t, []uint64{1, 2, 3}, txs)
+
+	err = tx.Set([]byte("key"), nil, []byte{3})
+	require.NoError(t, err)
+
+	_, _, err = tx.History([]byte("key"), 0, false, 10)
+	require.ErrorIs(t, err, ErrIllegalState)
+
+	wtx, err := st.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+	defer wtx.Cancel()
+
+	_, _, err = wtx.History([]byte("key"), 0, false, 10)
+	require.ErrorIs(t, err, ErrWriteOnlyTx)
+}
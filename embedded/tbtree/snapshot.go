@@ -138,6 +138,10 @@ func (s *Snapshot) GetWithPrefix(prefix []byte, neq []byte) (key []byte, value [
 
 	leafValue := leaf.values[off]
 
+	if len(prefix) > len(leafValue.key) || !bytes.Equal(prefix, leafValue.key[:len(prefix)]) {
+		return nil, nil, 0, 0, ErrKeyNotFound
+	}
+
 	return leafValue.key, cp(leafValue.value), leafValue.ts, leafValue.hCount + uint64(len(leafValue.tss)), nil
 }
 
@@ -170,6 +170,9 @@ func TestSnapshotIsolation(t *testing.T) {
 		_, _, _, _, err = snap1.GetWithPrefix([]byte("key3"), nil)
 		require.ErrorIs(t, err, ErrKeyNotFound)
 
+		_, _, _, _, err = snap1.GetWithPrefix([]byte("a"), nil)
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
 		_, _, _, _, err = snap1.GetWithPrefix([]byte("key1"), []byte("key1"))
 		require.ErrorIs(t, err, ErrKeyNotFound)
 	})
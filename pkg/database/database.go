@@ -33,6 +33,7 @@ import (
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/stream"
 )
 
 const MaxKeyResolutionLimit = 1
@@ -111,6 +112,7 @@ type DB interface {
 	SQLQuery(ctx context.Context, tx *sql.SQLTx, req *schema.SQLQueryRequest) (*schema.SQLQueryResult, error)
 	SQLQueryPrepared(ctx context.Context, tx *sql.SQLTx, stmt sql.DataSource, namedParams []*schema.NamedParam) (*schema.SQLQueryResult, error)
 	SQLQueryRowReader(ctx context.Context, tx *sql.SQLTx, stmt sql.DataSource, params map[string]interface{}) (sql.RowReader, error)
+	SQLQueryStream(ctx context.Context, tx *sql.SQLTx, stmt sql.DataSource, namedParams []*schema.NamedParam, s stream.SQLRowStreamSender) error
 
 	VerifiableSQLGet(ctx context.Context, req *schema.VerifiableSQLGetRequest) (*schema.VerifiableSQLEntry, error)
 
@@ -124,12 +126,17 @@ type DB interface {
 	TxByID(ctx context.Context, req *schema.TxRequest) (*schema.Tx, error)
 	ExportTxByID(ctx context.Context, req *schema.ExportTxRequest) (txbs []byte, mayCommitUpToTxID uint64, mayCommitUpToAlh [sha256.Size]byte, err error)
 	ReplicateTx(ctx context.Context, exportedTx []byte) (*schema.TxHeader, error)
+	ReplicateTxs(ctx context.Context, exportedTxs [][]byte) ([]*schema.TxHeader, error)
+	ReplicateTransformedTx(ctx context.Context, hdr *store.TxHeader, entries []*store.EntrySpec) (*schema.TxHeader, error)
 	AllowCommitUpto(txID uint64, alh [sha256.Size]byte) error
 	DiscardPrecommittedTxsSince(txID uint64) error
+	SetSyncBatch(batchSize int, idleTimeout time.Duration) error
 
 	VerifiableTxByID(ctx context.Context, req *schema.VerifiableTxRequest) (*schema.VerifiableTx, error)
 	TxScan(ctx context.Context, req *schema.TxScanRequest) (*schema.TxList, error)
 
+	RevertTxRange(ctx context.Context, fromTxID, toTxID uint64) (*schema.TxHeader, error)
+
 	// Maintenance
 	FlushIndex(req *schema.FlushIndexRequest) error
 	CompactIndex() error
@@ -1324,6 +1331,44 @@ func (d *db) ReplicateTx(ctx context.Context, exportedTx []byte) (*schema.TxHead
 	return schema.TxHeaderToProto(hdr), nil
 }
 
+// ReplicateTxs is used by replicas to replicate consecutive transactions as a single batch,
+// the headers of the transactions replicated before any failure are returned along with the error
+func (d *db) ReplicateTxs(ctx context.Context, exportedTxs [][]byte) ([]*schema.TxHeader, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if !d.isReplica() {
+		return nil, ErrNotReplica
+	}
+
+	hdrs, err := d.st.ReplicateTxs(ctx, exportedTxs, false)
+
+	txHdrs := make([]*schema.TxHeader, len(hdrs))
+	for i, hdr := range hdrs {
+		txHdrs[i] = schema.TxHeaderToProto(hdr)
+	}
+
+	return txHdrs, err
+}
+
+// ReplicateTransformedTx is used by replicas to commit the entries of a replicated transaction once transformed,
+// the transaction keeps the id of the original one but it can not be verified against the primary
+func (d *db) ReplicateTransformedTx(ctx context.Context, hdr *store.TxHeader, entries []*store.EntrySpec) (*schema.TxHeader, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if !d.isReplica() {
+		return nil, ErrNotReplica
+	}
+
+	txHdr, err := d.st.ReplicateTransformedTx(ctx, hdr, entries, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.TxHeaderToProto(txHdr), nil
+}
+
 // AllowCommitUpto is used by replicas to commit transactions once committed in primary
 func (d *db) AllowCommitUpto(txID uint64, alh [sha256.Size]byte) error {
 	d.mutex.RLock()
@@ -1365,6 +1410,19 @@ func (d *db) DiscardPrecommittedTxsSince(txID uint64) error {
 	return err
 }
 
+// SetSyncBatch is used by replicas to relax durability by syncing replicated transactions in batches,
+// see store.ImmuStore.SetSyncBatch
+func (d *db) SetSyncBatch(batchSize int, idleTimeout time.Duration) error {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if !d.isReplica() {
+		return ErrNotReplica
+	}
+
+	return d.st.SetSyncBatch(batchSize, idleTimeout)
+}
+
 // VerifiableTxByID ...
 func (d *db) VerifiableTxByID(ctx context.Context, req *schema.VerifiableTxRequest) (*schema.VerifiableTx, error) {
 	if req == nil {
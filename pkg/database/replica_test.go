@@ -20,6 +20,7 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
@@ -130,3 +131,21 @@ func TestSwitchToReplica(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestReplicaSyncBatch(t *testing.T) {
+	primary := makeDb(t)
+
+	err := primary.SetSyncBatch(10, time.Second)
+	require.ErrorIs(t, err, ErrNotReplica)
+
+	replica := makeDbWith(t, "replicadb", DefaultOption().WithDBRootPath(t.TempDir()).AsReplica(true))
+
+	err = replica.SetSyncBatch(-1, time.Second)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = replica.SetSyncBatch(10, time.Second)
+	require.NoError(t, err)
+
+	err = replica.SetSyncBatch(1, 0)
+	require.NoError(t, err)
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+var (
+	ErrRevertOfCatalogChanges = errors.New("transactions changing the sql catalog can not be reverted")
+	ErrRevertConflict         = errors.New("reverted keys were modified after the reverted transactions")
+)
+
+// sqlCatalogPrefix is the prefix of the keys holding the sql catalog (tables, columns, indexes...)
+var sqlCatalogPrefix = []byte{SQLPrefix, 'C', 'T', 'L', '.'}
+
+// RevertTxRange logically rolls back the transactions in the range [fromTxID, toTxID] by committing
+// a new transaction with compensating entries: every key written in the range is set back to the
+// value (and metadata) it had right before fromTxID, or deleted if it did not exist by then.
+// Previous transactions are kept untouched, as immutability makes physical rollback impossible.
+//
+// Limitations:
+//   - sql catalog changes (DDL) can not be reverted, ranges including them are rejected
+//     with ErrRevertOfCatalogChanges, while sql rows (and index entries) are reverted as any other key
+//   - keys modified by transactions committed after toTxID are not overwritten, the revert fails
+//     with ErrRevertConflict instead
+//   - non-indexable entries are not part of the indexed state, thus they are ignored
+func (d *db) RevertTxRange(ctx context.Context, fromTxID, toTxID uint64) (*schema.TxHeader, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.isReplica() {
+		return nil, ErrIsReplica
+	}
+
+	if fromTxID == 0 || fromTxID > toTxID || toTxID > d.st.LastCommittedTxID() {
+		return nil, fmt.Errorf("%w: invalid transaction range [%d, %d]", ErrIllegalArguments, fromTxID, toTxID)
+	}
+
+	err := d.st.WaitForIndexingUpto(ctx, d.st.LastCommittedTxID())
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := d.keysWrittenBetween(fromTxID, toTxID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := d.st.NewWriteOnlyTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Cancel()
+
+	for _, key := range keys {
+		prevTxID, err := d.lastWriteBefore(key, fromTxID, toTxID)
+		if err != nil {
+			return nil, err
+		}
+
+		if prevTxID == 0 {
+			md := store.NewKVMetadata()
+			md.AsDeleted(true)
+
+			err = tx.Set(key, md, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		md, val, err := d.readMetadataAndValue(key, prevTxID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = tx.Set(key, md, val)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hdr, err := tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.TxHeaderToProto(hdr), nil
+}
+
+// keysWrittenBetween returns the indexable keys written by the transactions in the range [fromTxID, toTxID]
+func (d *db) keysWrittenBetween(fromTxID, toTxID uint64) ([][]byte, error) {
+	tx, err := d.allocTx()
+	if err != nil {
+		return nil, err
+	}
+	defer d.releaseTx(tx)
+
+	var keys [][]byte
+	seen := make(map[string]struct{})
+
+	for txID := fromTxID; txID <= toTxID; txID++ {
+		err = d.st.ReadTx(txID, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range tx.Entries() {
+			if e.Metadata() != nil && e.Metadata().NonIndexable() {
+				continue
+			}
+
+			if bytes.HasPrefix(e.Key(), sqlCatalogPrefix) {
+				return nil, fmt.Errorf("%w: transaction %d", ErrRevertOfCatalogChanges, txID)
+			}
+
+			_, ok := seen[string(e.Key())]
+			if ok {
+				continue
+			}
+
+			seen[string(e.Key())] = struct{}{}
+			keys = append(keys, e.Key())
+		}
+	}
+
+	return keys, nil
+}
+
+// lastWriteBefore returns the id of the latest transaction before fromTxID writing the key,
+// zero is returned if the key was not written before fromTxID
+func (d *db) lastWriteBefore(key []byte, fromTxID, toTxID uint64) (uint64, error) {
+	var offset uint64
+
+	for {
+		txs, _, err := d.st.History(key, offset, true, d.maxResultSize)
+		if errors.Is(err, store.ErrNoMoreEntries) || errors.Is(err, store.ErrOffsetOutOfRange) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		for _, txID := range txs {
+			if txID > toTxID {
+				return 0, fmt.Errorf("%w: key written at transaction %d", ErrRevertConflict, txID)
+			}
+
+			if txID < fromTxID {
+				return txID, nil
+			}
+		}
+
+		offset += uint64(len(txs))
+	}
+}
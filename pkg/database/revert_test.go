@@ -0,0 +1,142 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevertTxRange(t *testing.T) {
+	db := makeDb(t)
+
+	set := func(key, value string) *schema.TxHeader {
+		hdr, err := db.Set(context.Background(), &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte(key), Value: []byte(value)}}})
+		require.NoError(t, err)
+		return hdr
+	}
+
+	requireValue := func(key, value string) {
+		entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(key)})
+		require.NoError(t, err)
+		require.Equal(t, []byte(value), entry.Value)
+	}
+
+	requireNotFound := func(key string) {
+		_, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(key)})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+	}
+
+	set("key1", "value1")
+	set("key2", "value2")
+	hdr := set("key3", "value3")
+
+	_, err := db.Delete(context.Background(), &schema.DeleteKeysRequest{Keys: [][]byte{[]byte("key3")}})
+	require.NoError(t, err)
+
+	fromHdr := set("key1", "value1_updated")
+
+	_, err = db.Delete(context.Background(), &schema.DeleteKeysRequest{Keys: [][]byte{[]byte("key2")}})
+	require.NoError(t, err)
+
+	set("key3", "value3_recreated")
+	toHdr := set("key4", "value4")
+
+	t.Run("invalid ranges should be rejected", func(t *testing.T) {
+		_, err := db.RevertTxRange(context.Background(), 0, toHdr.Id)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = db.RevertTxRange(context.Background(), toHdr.Id, fromHdr.Id)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = db.RevertTxRange(context.Background(), fromHdr.Id, toHdr.Id+1)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("keys modified after the range should not be overwritten", func(t *testing.T) {
+		_, err := db.RevertTxRange(context.Background(), hdr.Id, fromHdr.Id)
+		require.ErrorIs(t, err, ErrRevertConflict)
+	})
+
+	t.Run("reverting should restore the state prior to the range", func(t *testing.T) {
+		revertHdr, err := db.RevertTxRange(context.Background(), fromHdr.Id, toHdr.Id)
+		require.NoError(t, err)
+		require.Equal(t, toHdr.Id+1, revertHdr.Id)
+		require.Equal(t, int32(4), revertHdr.Nentries)
+
+		requireValue("key1", "value1")
+		requireValue("key2", "value2")
+		requireNotFound("key3")
+		requireNotFound("key4")
+	})
+
+	t.Run("reverted transactions should remain in the history", func(t *testing.T) {
+		history, err := db.History(context.Background(), &schema.HistoryRequest{Key: []byte("key1")})
+		require.NoError(t, err)
+		require.Len(t, history.Entries, 3)
+		require.Equal(t, []byte("value1_updated"), history.Entries[1].Value)
+	})
+}
+
+func TestRevertTxRangeSQL(t *testing.T) {
+	db := makeDb(t)
+
+	_, _, err := db.SQLExec(context.Background(), nil, &schema.SQLExecRequest{Sql: `
+		CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)
+	`})
+	require.NoError(t, err)
+
+	_, _, err = db.SQLExec(context.Background(), nil, &schema.SQLExecRequest{Sql: `
+		INSERT INTO table1 (title) VALUES ('title1'), ('title2')
+	`})
+	require.NoError(t, err)
+
+	_, ctxs, err := db.SQLExec(context.Background(), nil, &schema.SQLExecRequest{Sql: `
+		UPDATE table1 SET title = 'title1_updated' WHERE id = 1
+	`})
+	require.NoError(t, err)
+	fromTxID := ctxs[0].TxHeader().ID
+
+	_, _, err = db.SQLExec(context.Background(), nil, &schema.SQLExecRequest{Sql: `
+		INSERT INTO table1 (title) VALUES ('title3')
+	`})
+	require.NoError(t, err)
+
+	_, ctxs, err = db.SQLExec(context.Background(), nil, &schema.SQLExecRequest{Sql: `
+		DELETE FROM table1 WHERE id = 2
+	`})
+	require.NoError(t, err)
+	toTxID := ctxs[0].TxHeader().ID
+
+	_, err = db.RevertTxRange(context.Background(), fromTxID, toTxID)
+	require.NoError(t, err)
+
+	res, err := db.SQLQuery(context.Background(), nil, &schema.SQLQueryRequest{Sql: "SELECT id, title FROM table1"})
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 2)
+	require.Equal(t, "title1", res.Rows[0].Values[1].GetS())
+	require.Equal(t, "title2", res.Rows[1].Values[1].GetS())
+
+	t.Run("catalog changes should not be reverted", func(t *testing.T) {
+		_, err := db.RevertTxRange(context.Background(), 1, toTxID)
+		require.ErrorIs(t, err, ErrRevertOfCatalogChanges)
+	})
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/codenotary/immudb/embedded/sql"
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/stream"
 )
 
 var ErrSQLNotReady = errors.New("SQL catalog not yet replicated")
@@ -418,23 +419,92 @@ func (d *db) SQLQuery(ctx context.Context, tx *sql.SQLTx, req *schema.SQLQueryRe
 }
 
 func (d *db) SQLQueryPrepared(ctx context.Context, tx *sql.SQLTx, stmt sql.DataSource, namedParams []*schema.NamedParam) (*schema.SQLQueryResult, error) {
-	params := make(map[string]interface{})
-
-	for _, p := range namedParams {
-		params[p.Name] = schema.RawValue(p.Value)
+	r, err := d.SQLQueryRowReader(ctx, tx, stmt, namedParamsToMap(namedParams))
+	if err != nil {
+		return nil, err
 	}
+	defer r.Close()
 
-	r, err := d.SQLQueryRowReader(ctx, tx, stmt, params)
+	colDescriptors, err := r.Columns(ctx)
 	if err != nil {
 		return nil, err
 	}
+
+	res := &schema.SQLQueryResult{Columns: d.resultColumns(colDescriptors)}
+
+	for l := 1; ; l++ {
+		row, err := r.Read(ctx)
+		if err == sql.ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		res.Rows = append(res.Rows, resultRow(res.Columns, row))
+
+		if l == d.maxResultSize {
+			return res, fmt.Errorf("%w: found at least %d rows (the maximum limit). "+
+				"Query constraints can be applied using the LIMIT clause",
+				ErrResultSizeLimitReached, d.maxResultSize)
+		}
+	}
+
+	return res, nil
+}
+
+// SQLQueryStream sends the result of a query through a stream as rows are read, thus the result is not
+// kept in memory and it is not bounded by the maximum result size
+func (d *db) SQLQueryStream(ctx context.Context, tx *sql.SQLTx, stmt sql.DataSource, namedParams []*schema.NamedParam, s stream.SQLRowStreamSender) error {
+	if s == nil {
+		return ErrIllegalArguments
+	}
+
+	r, err := d.SQLQueryRowReader(ctx, tx, stmt, namedParamsToMap(namedParams))
+	if err != nil {
+		return err
+	}
 	defer r.Close()
 
 	colDescriptors, err := r.Columns(ctx)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	cols := d.resultColumns(colDescriptors)
+
+	err = s.SendColumns(cols)
+	if err != nil {
+		return err
+	}
+
+	for {
+		row, err := r.Read(ctx)
+		if err == sql.ErrNoMoreRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = s.SendRow(resultRow(cols, row))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func namedParamsToMap(namedParams []*schema.NamedParam) map[string]interface{} {
+	params := make(map[string]interface{})
+
+	for _, p := range namedParams {
+		params[p.Name] = schema.RawValue(p.Value)
 	}
 
+	return params
+}
+
+func (d *db) resultColumns(colDescriptors []sql.ColDescriptor) []*schema.Column {
 	cols := make([]*schema.Column, len(colDescriptors))
 
 	for i, c := range colDescriptors {
@@ -453,45 +523,29 @@ func (d *db) SQLQueryPrepared(ctx context.Context, tx *sql.SQLTx, stmt sql.DataS
 		cols[i] = &schema.Column{Name: des.Selector(), Type: des.Type}
 	}
 
-	res := &schema.SQLQueryResult{Columns: cols}
-
-	for l := 1; ; l++ {
-		row, err := r.Read(ctx)
-		if err == sql.ErrNoMoreRows {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
-
-		rrow := &schema.Row{
-			Columns: make([]string, len(res.Columns)),
-			Values:  make([]*schema.SQLValue, len(res.Columns)),
-		}
-
-		for i := range colDescriptors {
-			rrow.Columns[i] = cols[i].Name
+	return cols
+}
 
-			v := row.ValuesByPosition[i]
+func resultRow(cols []*schema.Column, row *sql.Row) *schema.Row {
+	rrow := &schema.Row{
+		Columns: make([]string, len(cols)),
+		Values:  make([]*schema.SQLValue, len(cols)),
+	}
 
-			_, isNull := v.(*sql.NullValue)
-			if isNull {
-				rrow.Values[i] = &schema.SQLValue{Value: &schema.SQLValue_Null{}}
-			} else {
-				rrow.Values[i] = typedValueToRowValue(v)
-			}
-		}
+	for i := range cols {
+		rrow.Columns[i] = cols[i].Name
 
-		res.Rows = append(res.Rows, rrow)
+		v := row.ValuesByPosition[i]
 
-		if l == d.maxResultSize {
-			return res, fmt.Errorf("%w: found at least %d rows (the maximum limit). "+
-				"Query constraints can be applied using the LIMIT clause",
-				ErrResultSizeLimitReached, d.maxResultSize)
+		_, isNull := v.(*sql.NullValue)
+		if isNull {
+			rrow.Values[i] = &schema.SQLValue{Value: &schema.SQLValue_Null{}}
+		} else {
+			rrow.Values[i] = typedValueToRowValue(v)
 		}
 	}
 
-	return res, nil
+	return rrow
 }
 
 func (d *db) SQLQueryRowReader(ctx context.Context, tx *sql.SQLTx, stmt sql.DataSource, params map[string]interface{}) (sql.RowReader, error) {
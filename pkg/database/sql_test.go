@@ -18,11 +18,13 @@ package database
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/codenotary/immudb/embedded/sql"
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/require"
 )
 
@@ -181,6 +183,71 @@ func TestSQLExecAndQuery(t *testing.T) {
 
 }
 
+type sqlRowCollector struct {
+	res *schema.SQLQueryResult
+}
+
+func (c *sqlRowCollector) SendColumns(cols []*schema.Column) error {
+	c.res = &schema.SQLQueryResult{Columns: cols}
+	return nil
+}
+
+func (c *sqlRowCollector) SendRow(row *schema.Row) error {
+	c.res.Rows = append(c.res.Rows, row)
+	return nil
+}
+
+func TestSQLQueryStream(t *testing.T) {
+	db := makeDb(t)
+
+	_, _, err := db.SQLExec(context.Background(), nil, &schema.SQLExecRequest{Sql: `
+		CREATE TABLE table1(id INTEGER AUTO_INCREMENT, title VARCHAR, active BOOLEAN, PRIMARY KEY id)
+	`})
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		_, _, err = db.SQLExec(context.Background(), nil, &schema.SQLExecRequest{
+			Sql: "INSERT INTO table1(title, active) VALUES (@title, @active)",
+			Params: []*schema.NamedParam{
+				{Name: "title", Value: &schema.SQLValue{Value: &schema.SQLValue_S{S: "title"}}},
+				{Name: "active", Value: &schema.SQLValue{Value: &schema.SQLValue_B{B: i%2 == 0}}},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	stmts, err := sql.Parse(strings.NewReader("SELECT id, title, active FROM table1 WHERE active = @active"))
+	require.NoError(t, err)
+
+	params := []*schema.NamedParam{{Name: "active", Value: &schema.SQLValue{Value: &schema.SQLValue_B{B: true}}}}
+
+	expected, err := db.SQLQueryPrepared(context.Background(), nil, stmts[0].(sql.DataSource), params)
+	require.NoError(t, err)
+	require.Len(t, expected.Rows, 10)
+
+	err = db.SQLQueryStream(context.Background(), nil, stmts[0].(sql.DataSource), params, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	c := &sqlRowCollector{}
+
+	err = db.SQLQueryStream(context.Background(), nil, stmts[0].(sql.DataSource), params, c)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(expected, c.res))
+
+	t.Run("streamed results should not be bounded by the maximum result size", func(t *testing.T) {
+		db.maxResultSize = 5
+
+		_, err := db.SQLQueryPrepared(context.Background(), nil, stmts[0].(sql.DataSource), params)
+		require.ErrorIs(t, err, ErrResultSizeLimitReached)
+
+		c := &sqlRowCollector{}
+
+		err = db.SQLQueryStream(context.Background(), nil, stmts[0].(sql.DataSource), params, c)
+		require.NoError(t, err)
+		require.Len(t, c.res.Rows, 10)
+	})
+}
+
 func TestVerifiableSQLGet(t *testing.T) {
 	db := makeDb(t)
 
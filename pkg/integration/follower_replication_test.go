@@ -17,16 +17,39 @@ limitations under the License.
 package integration
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
 	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/auth"
 	ic "github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/replication"
 	"github.com/codenotary/immudb/pkg/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/xid"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -281,3 +304,1991 @@ func TestSystemDBAndDefaultDBReplication(t *testing.T) {
 	_, err = replicaClient.Set(context.Background(), []byte("key2"), []byte("value2"))
 	require.Contains(t, err.Error(), "database is read-only because it's a replica")
 }
+
+type fixedDelayer time.Duration
+
+func (d fixedDelayer) DelayAfter(retries int) time.Duration {
+	return time.Duration(d)
+}
+
+func TestReplicationStartAndWaitReady(t *testing.T) {
+	// reserve a port for a primary server which is not yet running
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	primaryPort := l.Addr().(*net.TCPAddr).Port
+
+	err = l.Close()
+	require.NoError(t, err)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+	require.NoError(t, err)
+	defer replicaDB.Close()
+
+	replicatorOpts := replication.DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(primaryPort).
+		WithPrimaryUsername("immudb").
+		WithPrimaryPassword("immudb").
+		WithDelayer(fixedDelayer(100 * time.Millisecond)).
+		WithReadyTimeout(10 * time.Second)
+
+	replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts, logger)
+	require.NoError(t, err)
+
+	readyCh := make(chan error, 1)
+
+	go func() {
+		readyCh <- replicator.StartAndWaitReady(context.Background())
+	}()
+
+	select {
+	case err := <-readyCh:
+		require.Fail(t, "replicator should be waiting for the primary", err)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(primaryPort).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err = primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	defer primaryServer.Stop()
+
+	select {
+	case err := <-readyCh:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "replicator should be started once the primary is reachable")
+	}
+
+	err = replicator.StartAndWaitReady(context.Background())
+	require.ErrorIs(t, err, replication.ErrAlreadyRunning)
+
+	err = replicator.Stop()
+	require.NoError(t, err)
+}
+
+func TestReplicationWithAutomaticDurability(t *testing.T) {
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().WithDir(t.TempDir()).WithPort(primaryPort))
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	for i := 0; i < 10; i++ {
+		_, err = primaryClient.Set(context.Background(), []byte("key"), []byte("value"))
+		require.NoError(t, err)
+	}
+
+	primaryState, err := primaryClient.CurrentState(context.Background())
+	require.NoError(t, err)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+	require.NoError(t, err)
+	defer replicaDB.Close()
+
+	// pending transactions are neither synced because of the batch size nor because of being idle
+	replicatorOpts := replication.DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(primaryPort).
+		WithPrimaryUsername("immudb").
+		WithPrimaryPassword("immudb").
+		WithDurabilityPolicy(replication.FsyncAuto).
+		WithFsyncBatchSize(1000).
+		WithFsyncIdleTimeout(time.Hour)
+
+	replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts, logger)
+	require.NoError(t, err)
+
+	err = replicator.Start()
+	require.NoError(t, err)
+	defer replicator.Stop()
+
+	// transactions are synced once the replica is caught up with the primary
+	require.Eventually(t, func() bool {
+		state, err := replicaDB.CurrentState()
+		require.NoError(t, err)
+
+		return state.TxId == primaryState.TxId
+	}, 10*time.Second, 10*time.Millisecond)
+
+	_, err = primaryClient.Set(context.Background(), []byte("key"), []byte("value"))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		state, err := replicaDB.CurrentState()
+		require.NoError(t, err)
+
+		return state.TxId == primaryState.TxId+1
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+type replicationSpan struct {
+	name   string
+	parent string
+	err    error
+}
+
+type replicationSpanCtxKey struct{}
+
+// replicationSpanRecorder is an in-memory tracer and propagator, span names are used as span identifiers
+type replicationSpanRecorder struct {
+	mutex sync.Mutex
+	spans []replicationSpan
+}
+
+func (r *replicationSpanRecorder) Start(ctx context.Context, spanName string) (context.Context, replication.Span) {
+	parent, _ := ctx.Value(replicationSpanCtxKey{}).(string)
+
+	span := &recordedReplicationSpan{
+		recorder: r,
+		span:     replicationSpan{name: spanName, parent: parent},
+	}
+
+	return context.WithValue(ctx, replicationSpanCtxKey{}, spanName), span
+}
+
+func (r *replicationSpanRecorder) Inject(ctx context.Context, carrier replication.TextMapCarrier) {
+	span, ok := ctx.Value(replicationSpanCtxKey{}).(string)
+	if ok {
+		carrier.Set("traceparent", span)
+	}
+}
+
+func (r *replicationSpanRecorder) recorded(name string) []replicationSpan {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var spans []replicationSpan
+
+	for _, span := range r.spans {
+		if span.name == name {
+			spans = append(spans, span)
+		}
+	}
+
+	return spans
+}
+
+type recordedReplicationSpan struct {
+	recorder *replicationSpanRecorder
+	span     replicationSpan
+}
+
+func (s *recordedReplicationSpan) End(err error) {
+	s.recorder.mutex.Lock()
+	defer s.recorder.mutex.Unlock()
+
+	s.span.err = err
+	s.recorder.spans = append(s.recorder.spans, s.span)
+}
+
+func TestReplicationWithTracing(t *testing.T) {
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().WithDir(t.TempDir()).WithPort(primaryPort))
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	for i := 0; i < 3; i++ {
+		_, err = primaryClient.Set(context.Background(), []byte("key"), []byte("value"))
+		require.NoError(t, err)
+	}
+
+	primaryState, err := primaryClient.CurrentState(context.Background())
+	require.NoError(t, err)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+	require.NoError(t, err)
+	defer replicaDB.Close()
+
+	recorder := &replicationSpanRecorder{}
+
+	replicatorOpts := replication.DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(primaryPort).
+		WithPrimaryUsername("immudb").
+		WithPrimaryPassword("immudb").
+		WithTracer(recorder).
+		WithPropagator(recorder)
+
+	replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts, logger)
+	require.NoError(t, err)
+
+	err = replicator.Start()
+	require.NoError(t, err)
+	defer replicator.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = replicaDB.WaitForIndexingUpto(ctx, primaryState.TxId)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(recorder.recorded("replication.replicate")) == int(primaryState.TxId)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	connectSpans := recorder.recorded("replication.connect")
+	require.Len(t, connectSpans, 1)
+	require.NoError(t, connectSpans[0].err)
+
+	exportSpans := recorder.recorded("replication.export")
+	require.GreaterOrEqual(t, len(exportSpans), int(primaryState.TxId))
+
+	for _, span := range exportSpans {
+		require.NoError(t, span.err)
+	}
+
+	for _, span := range recorder.recorded("replication.replicate") {
+		require.Equal(t, "replication.export", span.parent)
+		require.NoError(t, span.err)
+	}
+}
+
+func TestReplicationVerifyOnly(t *testing.T) {
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().WithDir(t.TempDir()).WithPort(primaryPort))
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	for i := 0; i < 3; i++ {
+		_, err = primaryClient.Set(context.Background(), []byte("key"), []byte("value"))
+		require.NoError(t, err)
+	}
+
+	primaryState, err := primaryClient.CurrentState(context.Background())
+	require.NoError(t, err)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	localDB, err := database.NewDB("localdb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+	require.NoError(t, err)
+	defer localDB.Close()
+
+	recorder := &replicationSpanRecorder{}
+
+	var alertsMutex sync.Mutex
+	var alerts []*replication.IntegrityAlert
+
+	replicatorOpts := replication.DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(primaryPort).
+		WithPrimaryUsername("immudb").
+		WithPrimaryPassword("immudb").
+		WithTracer(recorder).
+		WithVerifyOnly(true).
+		WithAlertHandler(func(alert *replication.IntegrityAlert) {
+			alertsMutex.Lock()
+			defer alertsMutex.Unlock()
+
+			alerts = append(alerts, alert)
+		})
+
+	replicator, err := replication.NewTxReplicator(xid.New(), localDB, replicatorOpts, logger)
+	require.NoError(t, err)
+
+	err = replicator.Start()
+	require.NoError(t, err)
+	defer replicator.Stop()
+
+	require.Eventually(t, func() bool {
+		return len(recorder.recorded("replication.verify")) == int(primaryState.TxId)
+	}, 10*time.Second, 10*time.Millisecond)
+
+	for _, span := range recorder.recorded("replication.verify") {
+		require.NoError(t, span.err)
+	}
+
+	lastErr, _ := replicator.LastError()
+	require.NoError(t, lastErr)
+
+	alertsMutex.Lock()
+	require.Empty(t, alerts)
+	alertsMutex.Unlock()
+
+	// verified transactions are not committed into the local database
+	require.Empty(t, recorder.recorded("replication.replicate"))
+
+	localState, err := localDB.CurrentState()
+	require.NoError(t, err)
+	require.Zero(t, localState.TxId)
+}
+
+func TestReplicationWithPrimaryUUIDCheck(t *testing.T) {
+	startPrimary := func(t *testing.T, port int) *server.ImmuServer {
+		opts := server.DefaultOptions().
+			WithMetricsServer(false).
+			WithWebServer(false).
+			WithPgsqlServer(false).
+			WithPort(port).
+			WithDir(t.TempDir())
+
+		primaryServer := server.DefaultServer().WithOptions(opts).(*server.ImmuServer)
+
+		err := primaryServer.Initialize()
+		require.NoError(t, err)
+
+		go func() {
+			primaryServer.Start()
+		}()
+
+		time.Sleep(1 * time.Second)
+
+		return primaryServer
+	}
+
+	primaryServer := startPrimary(t, 0)
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+	require.NoError(t, err)
+	defer replicaDB.Close()
+
+	replicatorOpts := replication.DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(primaryPort).
+		WithPrimaryUsername("immudb").
+		WithPrimaryPassword("immudb").
+		WithDelayer(fixedDelayer(10 * time.Millisecond)).
+		WithPrimaryUUIDCheck(true)
+
+	replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts, logger)
+	require.NoError(t, err)
+
+	err = replicator.Start()
+	require.NoError(t, err)
+	defer replicator.Stop()
+
+	require.Eventually(t, func() bool {
+		state, err := replicaDB.CurrentState()
+		require.NoError(t, err)
+		return state.TxId > 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// another server, thus with a different UUID, takes the place of the primary
+	primaryServer.Stop()
+
+	otherServer := startPrimary(t, primaryPort)
+	defer otherServer.Stop()
+
+	require.NotEqual(t, primaryServer.UUID, otherServer.UUID)
+
+	require.Eventually(t, func() bool {
+		lastErr, _ := replicator.LastError()
+		return errors.Is(lastErr, replication.ErrPrimaryUUIDMismatch)
+	}, 30*time.Second, 10*time.Millisecond)
+
+	// replication is halted instead of being retried
+	require.Eventually(t, func() bool {
+		for _, log := range logger.GetLogs() {
+			if strings.Contains(log, "Replication of database 'replicadb' successfully stopped") {
+				return true
+			}
+		}
+		return false
+	}, 10*time.Second, 10*time.Millisecond)
+
+	err = replicator.Stop()
+	require.ErrorIs(t, err, replication.ErrAlreadyStopped)
+}
+
+func TestReplicationPrimaries(t *testing.T) {
+	primaryDir := t.TempDir()
+
+	primaryOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir(primaryDir)
+
+	primaryServer := server.DefaultServer().WithOptions(primaryOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().WithDir(t.TempDir()).WithPort(primaryPort))
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	for i := 0; i < 5; i++ {
+		_, err = primaryClient.Set(context.Background(), []byte(fmt.Sprintf("key%d", i)), []byte("value"))
+		require.NoError(t, err)
+	}
+
+	primaryState, err := primaryClient.CurrentState(context.Background())
+	require.NoError(t, err)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+	require.NoError(t, err)
+	defer replicaDB.Close()
+
+	replicatorOpts := replication.DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(primaryPort).
+		WithPrimaryUsername("immudb").
+		WithPrimaryPassword("immudb").
+		WithDelayer(fixedDelayer(10 * time.Millisecond)).
+		WithPrimaryUUIDCheck(true)
+
+	replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts, logger)
+	require.NoError(t, err)
+
+	err = replicator.Start()
+	require.NoError(t, err)
+	defer replicator.Stop()
+
+	require.Eventually(t, func() bool {
+		primaries := replicator.Primaries()
+		return primaries[0].LastFetchedTxID == primaryState.TxId
+	}, 10*time.Second, 10*time.Millisecond)
+
+	primaries := replicator.Primaries()
+	require.Len(t, primaries, 1)
+
+	primary := primaries[0]
+	require.Equal(t, fmt.Sprintf("127.0.0.1:%d", primaryPort), primary.Address)
+	require.Equal(t, "defaultdb", primary.Database)
+	require.Equal(t, "immudb", primary.Username)
+	require.Equal(t, primaryServer.UUID.String(), primary.UUID)
+	require.True(t, primary.Connected)
+	require.GreaterOrEqual(t, primary.CommittedTxID, primaryState.TxId)
+	require.Empty(t, primary.LastError)
+
+	primaryServer.Stop()
+
+	require.Eventually(t, func() bool {
+		primary := replicator.Primaries()[0]
+		return !primary.Connected && primary.LastError != ""
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func TestSyncReplicationWithAckBatch(t *testing.T) {
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().WithDir(t.TempDir()).WithPort(primaryPort))
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+
+	_, err = primaryClient.CreateDatabaseV2(context.Background(), "primarydb", &schema.DatabaseNullableSettings{
+		ReplicationSettings: &schema.ReplicationNullableSettings{
+			SyncReplication: &schema.NullableBool{Value: true},
+			SyncAcks:        &schema.NullableUint32{Value: 2},
+		},
+	})
+	require.NoError(t, err)
+
+	err = primaryClient.CloseSession(context.Background())
+	require.NoError(t, err)
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "primarydb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	startReplica := func(t *testing.T, name string, opts *replication.Options) database.DB {
+		replicaDB, err := database.NewDB(name, nil, database.DefaultOption().AsReplica(true).WithSyncReplication(true).WithDBRootPath(t.TempDir()), logger)
+		require.NoError(t, err)
+		t.Cleanup(func() { replicaDB.Close() })
+
+		opts.WithPrimaryDatabase("primarydb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(primaryPort).
+			WithPrimaryUsername("immudb").
+			WithPrimaryPassword("immudb")
+
+		replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, opts, logger)
+		require.NoError(t, err)
+
+		err = replicator.Start()
+		require.NoError(t, err)
+		t.Cleanup(func() { replicator.Stop() })
+
+		return replicaDB
+	}
+
+	perTxReplica := startReplica(t, "pertxdb", replication.DefaultOptions())
+	batchedReplica := startReplica(t, "batcheddb", replication.DefaultOptions().WithAckBatch(5, 0))
+
+	for i := 0; i < 23; i++ {
+		_, err = primaryClient.Set(context.Background(), []byte(fmt.Sprintf("key%d", i)), []byte("value"))
+		require.NoError(t, err)
+	}
+
+	primaryState, err := primaryClient.CurrentState(context.Background())
+	require.NoError(t, err)
+
+	for _, replicaDB := range []database.DB{perTxReplica, batchedReplica} {
+		require.Eventually(t, func() bool {
+			state, err := replicaDB.CurrentState()
+			require.NoError(t, err)
+			return state.TxId == primaryState.TxId
+		}, 10*time.Second, 10*time.Millisecond)
+	}
+
+	perTxState, err := perTxReplica.CurrentState()
+	require.NoError(t, err)
+
+	batchedState, err := batchedReplica.CurrentState()
+	require.NoError(t, err)
+
+	require.Equal(t, primaryState.TxHash, perTxState.TxHash)
+	require.Equal(t, perTxState.TxId, batchedState.TxId)
+	require.Equal(t, perTxState.TxHash, batchedState.TxHash)
+	require.Equal(t, perTxState.PrecommittedTxId, batchedState.PrecommittedTxId)
+}
+
+func TestReplicationOverUnixSocket(t *testing.T) {
+	unixSocket := filepath.Join(t.TempDir(), "immudb.sock")
+
+	listener, err := net.Listen("unix", unixSocket)
+	require.NoError(t, err)
+
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithListener(listener).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err = primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	unixDialer := grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", unixSocket)
+	})
+
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().
+		WithDir(t.TempDir()).
+		WithDialOptions([]grpc.DialOption{grpc.WithInsecure(), unixDialer}))
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	for i := 0; i < 3; i++ {
+		_, err = primaryClient.Set(context.Background(), []byte("key"), []byte("value"))
+		require.NoError(t, err)
+	}
+
+	primaryState, err := primaryClient.CurrentState(context.Background())
+	require.NoError(t, err)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+	require.NoError(t, err)
+	defer replicaDB.Close()
+
+	replicatorOpts := replication.DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithUnixSocket(unixSocket).
+		WithPrimaryUsername("immudb").
+		WithPrimaryPassword("immudb")
+
+	replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts, logger)
+	require.NoError(t, err)
+
+	err = replicator.Start()
+	require.NoError(t, err)
+	defer replicator.Stop()
+
+	require.Eventually(t, func() bool {
+		state, err := replicaDB.CurrentState()
+		require.NoError(t, err)
+		return state.TxId == primaryState.TxId
+	}, 10*time.Second, 10*time.Millisecond)
+
+	replicaState, err := replicaDB.CurrentState()
+	require.NoError(t, err)
+	require.Equal(t, primaryState.TxHash, replicaState.TxHash)
+}
+
+// selfSignedCert returns a certificate valid for the given DNS names only, along with a pool trusting it
+func selfSignedCert(t *testing.T, dnsNames ...string) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:              dnsNames,
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+func TestReplicationOverTLSWithServerNameOverride(t *testing.T) {
+	// the certificate of the primary does not include the address replicas connect to
+	cert, pool := selfSignedCert(t, "primary.immudb.internal")
+
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithTLS(&tls.Config{Certificates: []tls.Certificate{cert}}).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().
+		WithDir(t.TempDir()).
+		WithPort(primaryPort).
+		WithDialOptions([]grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			ServerName: "primary.immudb.internal",
+			RootCAs:    pool,
+		}))}))
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	for i := 0; i < 3; i++ {
+		_, err = primaryClient.Set(context.Background(), []byte("key"), []byte("value"))
+		require.NoError(t, err)
+	}
+
+	primaryState, err := primaryClient.CurrentState(context.Background())
+	require.NoError(t, err)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	replicatorOpts := func() *replication.Options {
+		return replication.DefaultOptions().
+			WithPrimaryDatabase("defaultdb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(primaryPort).
+			WithPrimaryUsername("immudb").
+			WithPrimaryPassword("immudb").
+			WithReadyTimeout(time.Second).
+			WithTLS(&tls.Config{RootCAs: pool})
+	}
+
+	t.Run("the certificate should not be verified against the dial address", func(t *testing.T) {
+		replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+		require.NoError(t, err)
+		defer replicaDB.Close()
+
+		replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts(), logger)
+		require.NoError(t, err)
+
+		err = replicator.StartAndWaitReady(context.Background())
+		require.ErrorIs(t, err, replication.ErrPrimaryNotReady)
+	})
+
+	t.Run("the certificate should not be verified against a name it does not include", func(t *testing.T) {
+		replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+		require.NoError(t, err)
+		defer replicaDB.Close()
+
+		replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts().WithTLSServerName("other.immudb.internal"), logger)
+		require.NoError(t, err)
+
+		err = replicator.StartAndWaitReady(context.Background())
+		require.ErrorIs(t, err, replication.ErrPrimaryNotReady)
+	})
+
+	t.Run("the certificate should be verified against the overridden name", func(t *testing.T) {
+		replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+		require.NoError(t, err)
+		defer replicaDB.Close()
+
+		replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts().WithTLSServerName("primary.immudb.internal"), logger)
+		require.NoError(t, err)
+
+		err = replicator.StartAndWaitReady(context.Background())
+		require.NoError(t, err)
+		defer replicator.Stop()
+
+		require.Eventually(t, func() bool {
+			state, err := replicaDB.CurrentState()
+			require.NoError(t, err)
+			return state.TxId == primaryState.TxId
+		}, 10*time.Second, 10*time.Millisecond)
+
+		replicaState, err := replicaDB.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, primaryState.TxHash, replicaState.TxHash)
+	})
+}
+
+func TestReplicationIdlePolling(t *testing.T) {
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	replicaDB, err := database.NewDB("idlereplicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+	require.NoError(t, err)
+	defer replicaDB.Close()
+
+	idlePollInterval := 250 * time.Millisecond
+
+	replicatorOpts := replication.DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(primaryPort).
+		WithPrimaryUsername("immudb").
+		WithPrimaryPassword("immudb").
+		WithIdlePollInterval(idlePollInterval)
+
+	replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts, logger)
+	require.NoError(t, err)
+
+	err = replicator.Start()
+	require.NoError(t, err)
+	defer replicator.Stop()
+
+	idlePolls := func() float64 {
+		mfs, err := prometheus.DefaultGatherer.Gather()
+		require.NoError(t, err)
+
+		for _, mf := range mfs {
+			if mf.GetName() != "immudb_replication_idle_polls" {
+				continue
+			}
+
+			for _, m := range mf.GetMetric() {
+				for _, l := range m.GetLabel() {
+					if l.GetName() == "db" && l.GetValue() == "idlereplicadb" {
+						return m.GetCounter().GetValue()
+					}
+				}
+			}
+		}
+
+		return 0
+	}
+
+	// the primary has no new transactions once the replica is up to date
+	require.Eventually(t, func() bool {
+		return idlePolls() > 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	observed := 1500 * time.Millisecond
+
+	pollsBefore := idlePolls()
+	time.Sleep(observed)
+	polls := idlePolls() - pollsBefore
+
+	require.GreaterOrEqual(t, polls, float64(1))
+	require.LessOrEqual(t, polls, float64(observed/idlePollInterval)+1)
+}
+
+func TestReplicationDrainToFile(t *testing.T) {
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().WithDir(t.TempDir()).WithPort(primaryPort))
+	require.NotNil(t, primaryClient)
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	for i := 0; i < 3; i++ {
+		_, err = primaryClient.Set(context.Background(), []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	primaryState, err := primaryClient.CurrentState(context.Background())
+	require.NoError(t, err)
+
+	archive := filepath.Join(t.TempDir(), "defaultdb.archive")
+
+	err = replication.ExportTxsToFile(
+		context.Background(),
+		replication.NewClientTxExporter(primaryClient, replication.DefaultChunkSize),
+		1,
+		primaryState.TxId,
+		archive,
+	)
+	require.NoError(t, err)
+
+	replicaDB, err := database.NewDB("archivedb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger.NewMemoryLogger())
+	require.NoError(t, err)
+	defer replicaDB.Close()
+
+	lastTxID, err := replication.ImportTxsFromFile(context.Background(), replicaDB, archive)
+	require.NoError(t, err)
+	require.Equal(t, primaryState.TxId, lastTxID)
+
+	replicaState, err := replicaDB.CurrentState()
+	require.NoError(t, err)
+	require.Equal(t, primaryState.TxHash, replicaState.TxHash)
+}
+
+func TestReplicationWithPrimaryDatabaseMissing(t *testing.T) {
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+
+	startReplica := func(t *testing.T, policy replication.PrimaryDatabaseMissingPolicy, alias string) (database.DB, *replication.TxReplicator, *logger.MemoryLogger) {
+		logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+		replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+		require.NoError(t, err)
+		t.Cleanup(func() { replicaDB.Close() })
+
+		replicatorOpts := replication.DefaultOptions().
+			WithPrimaryDatabase("renameddb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(primaryPort).
+			WithPrimaryUsername("immudb").
+			WithPrimaryPassword("immudb").
+			WithDelayer(fixedDelayer(10*time.Millisecond)).
+			WithOnPrimaryDatabaseMissing(policy, alias)
+
+		replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts, logger)
+		require.NoError(t, err)
+
+		err = replicator.Start()
+		require.NoError(t, err)
+		t.Cleanup(func() { replicator.Stop() })
+
+		return replicaDB, replicator, logger
+	}
+
+	t.Run("replication is halted", func(t *testing.T) {
+		_, replicator, logger := startReplica(t, replication.HaltOnPrimaryDatabaseMissing, "")
+
+		require.Eventually(t, func() bool {
+			lastErr, _ := replicator.LastError()
+			return errors.Is(lastErr, replication.ErrPrimaryDatabaseMissing)
+		}, 10*time.Second, 10*time.Millisecond)
+
+		require.Eventually(t, func() bool {
+			for _, log := range logger.GetLogs() {
+				if strings.Contains(log, "Replication of database 'replicadb' successfully stopped") {
+					return true
+				}
+			}
+			return false
+		}, 10*time.Second, 10*time.Millisecond)
+
+		err = replicator.Stop()
+		require.ErrorIs(t, err, replication.ErrAlreadyStopped)
+	})
+
+	t.Run("replication follows the alias", func(t *testing.T) {
+		replicaDB, _, _ := startReplica(t, replication.FollowAliasOnPrimaryDatabaseMissing, "defaultdb")
+
+		require.Eventually(t, func() bool {
+			state, err := replicaDB.CurrentState()
+			require.NoError(t, err)
+			return state.TxId > 0
+		}, 10*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("replication is halted when the alias is missing", func(t *testing.T) {
+		_, replicator, _ := startReplica(t, replication.FollowAliasOnPrimaryDatabaseMissing, "otherdb")
+
+		require.Eventually(t, func() bool {
+			lastErr, _ := replicator.LastError()
+			return errors.Is(lastErr, replication.ErrPrimaryDatabaseMissing)
+		}, 10*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestAsyncReplicationOfCommittedTxsOnly(t *testing.T) {
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().WithDir(t.TempDir()).WithPort(primaryPort))
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+
+	_, err = primaryClient.CreateDatabaseV2(context.Background(), "primarydb", &schema.DatabaseNullableSettings{
+		ReplicationSettings: &schema.ReplicationNullableSettings{
+			SyncReplication: &schema.NullableBool{Value: true},
+			SyncAcks:        &schema.NullableUint32{Value: 1},
+		},
+	})
+	require.NoError(t, err)
+
+	err = primaryClient.CloseSession(context.Background())
+	require.NoError(t, err)
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "primarydb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	primaryState, err := primaryClient.CurrentState(context.Background())
+	require.NoError(t, err)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	startReplica := func(t *testing.T, name string, syncReplication bool) database.DB {
+		replicaDB, err := database.NewDB(name, nil, database.DefaultOption().AsReplica(true).WithSyncReplication(syncReplication).WithDBRootPath(t.TempDir()), logger)
+		require.NoError(t, err)
+		t.Cleanup(func() { replicaDB.Close() })
+
+		opts := replication.DefaultOptions().
+			WithPrimaryDatabase("primarydb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(primaryPort).
+			WithPrimaryUsername("immudb").
+			WithPrimaryPassword("immudb").
+			WithIdlePollInterval(10 * time.Millisecond)
+
+		replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, opts, logger)
+		require.NoError(t, err)
+
+		err = replicator.Start()
+		require.NoError(t, err)
+		t.Cleanup(func() { replicator.Stop() })
+
+		return replicaDB
+	}
+
+	asyncReplica := startReplica(t, "asyncdb", false)
+
+	// the transaction is precommitted by the primary but it can not be committed without the ack of a sync replica
+	setErrCh := make(chan error, 1)
+
+	go func() {
+		_, err := primaryClient.Set(context.Background(), []byte("key1"), []byte("value1"))
+		setErrCh <- err
+	}()
+
+	time.Sleep(2 * time.Second)
+
+	state, err := asyncReplica.CurrentState()
+	require.NoError(t, err)
+	require.Equal(t, primaryState.TxId, state.TxId)
+	require.Equal(t, primaryState.TxId, state.PrecommittedTxId)
+
+	select {
+	case err := <-setErrCh:
+		require.Fail(t, "the transaction should not be committed without the ack of a sync replica", err)
+	default:
+	}
+
+	startReplica(t, "syncdb", true)
+
+	select {
+	case err := <-setErrCh:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "the transaction should be committed once acknowledged by a sync replica")
+	}
+
+	// the transaction is replicated by the async replica once committed by the primary
+	require.Eventually(t, func() bool {
+		state, err := asyncReplica.CurrentState()
+		require.NoError(t, err)
+		return state.TxId == primaryState.TxId+1
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func TestReplicationFromPreferredSource(t *testing.T) {
+	newServer := func() *server.ImmuServer {
+		opts := server.DefaultOptions().
+			WithMetricsServer(false).
+			WithWebServer(false).
+			WithPgsqlServer(false).
+			WithPort(0).
+			WithDir(t.TempDir())
+
+		srv := server.DefaultServer().WithOptions(opts).(*server.ImmuServer)
+
+		err := srv.Initialize()
+		require.NoError(t, err)
+
+		go func() {
+			srv.Start()
+		}()
+
+		return srv
+	}
+
+	primaryServer := newServer()
+	readReplicaServer := newServer()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+	defer readReplicaServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+	readReplicaPort := readReplicaServer.Listener.Addr().(*net.TCPAddr).Port
+
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().WithDir(t.TempDir()).WithPort(primaryPort))
+
+	err := primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	_, err = primaryClient.CreateDatabaseV2(context.Background(), "sourcedb", &schema.DatabaseNullableSettings{})
+	require.NoError(t, err)
+
+	_, err = primaryClient.UseDatabase(context.Background(), &schema.Database{DatabaseName: "sourcedb"})
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err = primaryClient.Set(context.Background(), []byte(fmt.Sprintf("key%d", i)), []byte("value"))
+		require.NoError(t, err)
+	}
+
+	primaryState, err := primaryClient.CurrentState(context.Background())
+	require.NoError(t, err)
+
+	// the read-replica follows the primary
+	readReplicaClient := ic.NewClient().WithOptions(ic.DefaultOptions().WithDir(t.TempDir()).WithPort(readReplicaPort))
+
+	err = readReplicaClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer readReplicaClient.CloseSession(context.Background())
+
+	_, err = readReplicaClient.CreateDatabaseV2(context.Background(), "sourcedb", &schema.DatabaseNullableSettings{
+		ReplicationSettings: &schema.ReplicationNullableSettings{
+			Replica:         &schema.NullableBool{Value: true},
+			PrimaryDatabase: &schema.NullableString{Value: "sourcedb"},
+			PrimaryHost:     &schema.NullableString{Value: "127.0.0.1"},
+			PrimaryPort:     &schema.NullableUint32{Value: uint32(primaryPort)},
+			PrimaryUsername: &schema.NullableString{Value: "immudb"},
+			PrimaryPassword: &schema.NullableString{Value: "immudb"},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = readReplicaClient.UseDatabase(context.Background(), &schema.Database{DatabaseName: "sourcedb"})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		readReplicaState, err := readReplicaClient.CurrentState(context.Background())
+		return err == nil && readReplicaState.TxId == primaryState.TxId
+	}, 10*time.Second, 10*time.Millisecond)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+	require.NoError(t, err)
+	defer replicaDB.Close()
+
+	readReplicaAddress := fmt.Sprintf("127.0.0.1:%d", readReplicaPort)
+
+	replicatorOpts := replication.DefaultOptions().
+		WithPrimaryDatabase("sourcedb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(primaryPort).
+		WithPrimaryUsername("immudb").
+		WithPrimaryPassword("immudb").
+		WithDelayer(fixedDelayer(10*time.Millisecond)).
+		WithIdlePollInterval(10*time.Millisecond).
+		WithPreferredSource(readReplicaAddress, 0)
+
+	replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts, logger)
+	require.NoError(t, err)
+
+	err = replicator.Start()
+	require.NoError(t, err)
+	defer replicator.Stop()
+
+	t.Run("transactions are fetched from the read-replica while current", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			return replicator.Primaries()[0].LastFetchedTxID == primaryState.TxId
+		}, 10*time.Second, 10*time.Millisecond)
+
+		require.Equal(t, readReplicaAddress, replicator.Primaries()[0].Source)
+	})
+
+	t.Run("transactions are fetched from the primary once the read-replica lags", func(t *testing.T) {
+		// the read-replica stops following the primary
+		_, err = readReplicaClient.UpdateDatabaseV2(context.Background(), "sourcedb", &schema.DatabaseNullableSettings{
+			ReplicationSettings: &schema.ReplicationNullableSettings{
+				Replica: &schema.NullableBool{Value: false},
+			},
+		})
+		require.NoError(t, err)
+
+		for i := 5; i < 10; i++ {
+			_, err = primaryClient.Set(context.Background(), []byte(fmt.Sprintf("key%d", i)), []byte("value"))
+			require.NoError(t, err)
+		}
+
+		primaryState, err := primaryClient.CurrentState(context.Background())
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			return replicator.Primaries()[0].LastFetchedTxID == primaryState.TxId
+		}, 10*time.Second, 10*time.Millisecond)
+
+		require.Empty(t, replicator.Primaries()[0].Source)
+	})
+}
+
+func TestReplicationForceResync(t *testing.T) {
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().WithDir(t.TempDir()).WithPort(primaryPort))
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	for i := 0; i < 5; i++ {
+		_, err = primaryClient.Set(context.Background(), []byte(fmt.Sprintf("key%d", i)), []byte("value"))
+		require.NoError(t, err)
+	}
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	replicaRootPath := t.TempDir()
+
+	// the replica diverged from the primary, it holds the history of another database
+	otherDB, err := database.NewDB("otherdb", nil, database.DefaultOption().WithDBRootPath(t.TempDir()), logger)
+	require.NoError(t, err)
+	defer otherDB.Close()
+
+	replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(replicaRootPath), logger)
+	require.NoError(t, err)
+	// the replica database is replaced on force resync
+	defer func() { replicaDB.Close() }()
+
+	for i := 0; i < 3; i++ {
+		_, err := otherDB.Set(context.Background(), &schema.SetRequest{
+			KVs: []*schema.KeyValue{{Key: []byte(fmt.Sprintf("other%d", i)), Value: []byte("value")}},
+		})
+		require.NoError(t, err)
+	}
+
+	otherState, err := otherDB.CurrentState()
+	require.NoError(t, err)
+
+	for txID := uint64(1); txID <= otherState.TxId; txID++ {
+		etx, _, _, err := otherDB.ExportTxByID(context.Background(), &schema.ExportTxRequest{Tx: txID})
+		require.NoError(t, err)
+
+		_, err = replicaDB.ReplicateTx(context.Background(), etx)
+		require.NoError(t, err)
+	}
+
+	var resets int32
+
+	replicatorOpts := replication.DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(primaryPort).
+		WithPrimaryUsername("immudb").
+		WithPrimaryPassword("immudb").
+		WithDelayer(fixedDelayer(10*time.Millisecond)).
+		WithIdlePollInterval(10*time.Millisecond).
+		WithApplyRetries(0, 0).
+		WithDatabaseReset(func(ctx context.Context, db database.DB) (database.DB, error) {
+			atomic.AddInt32(&resets, 1)
+
+			err := db.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			err = os.RemoveAll(db.Path())
+			if err != nil {
+				return nil, err
+			}
+
+			replicaDB, err = database.NewDB(db.GetName(), nil, database.DefaultOption().AsReplica(true).WithDBRootPath(replicaRootPath), logger)
+			return replicaDB, err
+		})
+
+	replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts, logger)
+	require.NoError(t, err)
+
+	err = replicator.Start()
+	require.NoError(t, err)
+	defer replicator.Stop()
+
+	require.Eventually(t, func() bool {
+		err, _ := replicator.LastError()
+		return err != nil
+	}, 10*time.Second, 10*time.Millisecond)
+
+	t.Run("force resync should be confirmed", func(t *testing.T) {
+		err := replicator.ForceResync(context.Background(), false)
+		require.ErrorIs(t, err, replication.ErrResyncNotConfirmed)
+		require.Zero(t, atomic.LoadInt32(&resets))
+	})
+
+	t.Run("force resync should rebuild the replica from the primary", func(t *testing.T) {
+		err := replicator.ForceResync(context.Background(), true)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, atomic.LoadInt32(&resets))
+
+		primaryState, err := primaryClient.CurrentState(context.Background())
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			state, err := replicaDB.CurrentState()
+			require.NoError(t, err)
+			return state.TxId == primaryState.TxId
+		}, 10*time.Second, 10*time.Millisecond)
+
+		state, err := replicaDB.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, primaryState.TxHash, state.TxHash)
+
+		_, err = replicaDB.Get(context.Background(), &schema.KeyRequest{Key: []byte("key4")})
+		require.NoError(t, err)
+
+		_, err = replicaDB.Get(context.Background(), &schema.KeyRequest{Key: []byte("other0")})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+	})
+}
+
+// testProxy forwards connections to a server. It can replace a byte sequence sent by the server
+// with another one of the same length, as a buggy proxy would do, and it can silently stop
+// forwarding on established connections, as a NAT or firewall dropping idle connections would do
+type testProxy struct {
+	listener net.Listener
+	target   string
+
+	from, to []byte
+
+	mutex   sync.Mutex
+	dropped []*int32
+}
+
+func newTestProxy(t *testing.T, target string) *testProxy {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	p := &testProxy{listener: listener, target: target}
+
+	go p.serve()
+
+	t.Cleanup(func() { listener.Close() })
+
+	return p
+}
+
+func newCorruptingProxy(t *testing.T, target string, from, to []byte) *testProxy {
+	p := newTestProxy(t, target)
+	p.from = from
+	p.to = to
+	return p
+}
+
+func (p *testProxy) port() int {
+	return p.listener.Addr().(*net.TCPAddr).Port
+}
+
+// blackhole stops forwarding on the established connections without closing them,
+// connections established afterwards are forwarded as usual
+func (p *testProxy) blackhole() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, dropped := range p.dropped {
+		atomic.StoreInt32(dropped, 1)
+	}
+}
+
+func (p *testProxy) forward(dst, src net.Conn, dropped *int32, transform func([]byte) []byte) {
+	defer dst.Close()
+
+	buf := make([]byte, 64*1024)
+
+	for {
+		n, err := src.Read(buf)
+		if n > 0 && atomic.LoadInt32(dropped) == 0 {
+			_, werr := dst.Write(transform(buf[:n]))
+			if werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (p *testProxy) serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		upstream, err := net.Dial("tcp", p.target)
+		if err != nil {
+			conn.Close()
+			continue
+		}
+
+		dropped := new(int32)
+
+		p.mutex.Lock()
+		p.dropped = append(p.dropped, dropped)
+		p.mutex.Unlock()
+
+		go p.forward(upstream, conn, dropped, func(b []byte) []byte { return b })
+
+		go p.forward(conn, upstream, dropped, func(b []byte) []byte {
+			if p.from == nil {
+				return b
+			}
+			return bytes.ReplaceAll(b, p.from, p.to)
+		})
+	}
+}
+
+func TestReplicationWithPayloadChecksum(t *testing.T) {
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().WithDir(t.TempDir()).WithPort(primaryPort))
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	_, err = primaryClient.Set(context.Background(), []byte("key1"), []byte("intact-value"))
+	require.NoError(t, err)
+
+	primaryState, err := primaryClient.CurrentState(context.Background())
+	require.NoError(t, err)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	proxy := newCorruptingProxy(t, fmt.Sprintf("127.0.0.1:%d", primaryPort), []byte("corrupt-value"), []byte("CORRUPT-VALUE"))
+
+	startReplica := func(t *testing.T, payloadChecksum bool, alertHandler replication.AlertHandler) (database.DB, *replication.TxReplicator) {
+		replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+		require.NoError(t, err)
+		t.Cleanup(func() { replicaDB.Close() })
+
+		replicatorOpts := replication.DefaultOptions().
+			WithPrimaryDatabase("defaultdb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(proxy.port()).
+			WithPrimaryUsername("immudb").
+			WithPrimaryPassword("immudb").
+			WithDelayer(fixedDelayer(10 * time.Millisecond)).
+			WithIdlePollInterval(10 * time.Millisecond).
+			WithPayloadChecksum(payloadChecksum).
+			WithAlertHandler(alertHandler)
+
+		replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts, logger)
+		require.NoError(t, err)
+
+		err = replicator.Start()
+		require.NoError(t, err)
+		t.Cleanup(func() { replicator.Stop() })
+
+		return replicaDB, replicator
+	}
+
+	t.Run("intact transactions should be replicated", func(t *testing.T) {
+		replicaDB, _ := startReplica(t, true, nil)
+
+		require.Eventually(t, func() bool {
+			state, err := replicaDB.CurrentState()
+			require.NoError(t, err)
+			return state.TxId == primaryState.TxId
+		}, 10*time.Second, 10*time.Millisecond)
+	})
+
+	// the value is corrupted by the proxy in every transaction exported from now on
+	_, err = primaryClient.Set(context.Background(), []byte("key2"), []byte("corrupt-value"))
+	require.NoError(t, err)
+
+	t.Run("replication should halt on corrupted transactions", func(t *testing.T) {
+		alerts := make(chan *replication.IntegrityAlert, 1)
+
+		replicaDB, replicator := startReplica(t, true, func(alert *replication.IntegrityAlert) {
+			select {
+			case alerts <- alert:
+			default:
+			}
+		})
+
+		select {
+		case alert := <-alerts:
+			require.Equal(t, primaryState.TxId+1, alert.TxID)
+		case <-time.After(10 * time.Second):
+			require.Fail(t, "the corrupted transaction should be reported")
+		}
+
+		require.Eventually(t, func() bool {
+			err, _ := replicator.LastError()
+			return errors.Is(err, replication.ErrTxPayloadCorrupted)
+		}, 10*time.Second, 10*time.Millisecond)
+
+		// replication is stopped by itself
+		require.Eventually(t, func() bool {
+			return len(replicator.Primaries()) > 0 && !replicator.Primaries()[0].Connected
+		}, 10*time.Second, 10*time.Millisecond)
+
+		// the corrupted transaction was not applied
+		state, err := replicaDB.CurrentState()
+		require.NoError(t, err)
+		require.LessOrEqual(t, state.TxId, primaryState.TxId)
+
+		_, err = replicaDB.Get(context.Background(), &schema.KeyRequest{Key: []byte("key2")})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+	})
+}
+
+func TestReplicationIdleProbe(t *testing.T) {
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().WithDir(t.TempDir()).WithPort(primaryPort))
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	_, err = primaryClient.Set(context.Background(), []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	proxy := newTestProxy(t, fmt.Sprintf("127.0.0.1:%d", primaryPort))
+
+	replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+	require.NoError(t, err)
+	defer replicaDB.Close()
+
+	replicatorOpts := replication.DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(proxy.port()).
+		WithPrimaryUsername("immudb").
+		WithPrimaryPassword("immudb").
+		WithDelayer(fixedDelayer(10 * time.Millisecond)).
+		WithIdlePollInterval(10 * time.Millisecond).
+		WithIdleProbeInterval(500 * time.Millisecond)
+
+	replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts, logger)
+	require.NoError(t, err)
+
+	err = replicator.Start()
+	require.NoError(t, err)
+	defer replicator.Stop()
+
+	waitForPrimaryState := func() {
+		primaryState, err := primaryClient.CurrentState(context.Background())
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			state, err := replicaDB.CurrentState()
+			require.NoError(t, err)
+			return state.TxId == primaryState.TxId
+		}, 10*time.Second, 10*time.Millisecond)
+	}
+
+	waitForPrimaryState()
+
+	// the connection is only probed once the replicator finds no new transactions
+	time.Sleep(time.Second)
+
+	// the connection in use by the replicator is silently dropped, as a NAT or firewall would do
+	proxy.blackhole()
+
+	_, err = primaryClient.Set(context.Background(), []byte("key2"), []byte("value2"))
+	require.NoError(t, err)
+
+	// the lost connection is detected and replication continues over a new one
+	waitForPrimaryState()
+
+	_, err = replicaDB.Get(context.Background(), &schema.KeyRequest{Key: []byte("key2")})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		for _, line := range logger.GetLogs() {
+			if strings.Contains(line, "lost while idle") {
+				return true
+			}
+		}
+		return false
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func TestReplicationWithSchedule(t *testing.T) {
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir(t.TempDir())
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().WithDir(t.TempDir()).WithPort(primaryPort))
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	_, err = primaryClient.Set(context.Background(), []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	replicaDB, err := database.NewDB("replicadb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+	require.NoError(t, err)
+	defer replicaDB.Close()
+
+	// a compressed schedule: the window opens in two seconds and stays open for three seconds
+	now := time.Now().UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	windowStart := now.Add(2 * time.Second)
+	windowEnd := now.Add(5 * time.Second)
+
+	window := replication.ScheduleWindow{
+		From: windowStart.Sub(midnight) % (24 * time.Hour),
+		To:   windowEnd.Sub(midnight) % (24 * time.Hour),
+	}
+
+	replicatorOpts := replication.DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(primaryPort).
+		WithPrimaryUsername("immudb").
+		WithPrimaryPassword("immudb").
+		WithDelayer(fixedDelayer(10*time.Millisecond)).
+		WithIdlePollInterval(10*time.Millisecond).
+		WithSchedule(replication.DisconnectOutsideSchedule, window)
+
+	replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts, logger)
+	require.NoError(t, err)
+
+	err = replicator.Start()
+	require.NoError(t, err)
+	defer replicator.Stop()
+
+	replicaTxID := func() uint64 {
+		state, err := replicaDB.CurrentState()
+		require.NoError(t, err)
+		return state.TxId
+	}
+
+	// nothing is replicated before the window opens
+	require.True(t, replicator.Paused())
+
+	time.Sleep(time.Until(windowStart.Add(-500 * time.Millisecond)))
+	require.Zero(t, replicaTxID())
+
+	// the replication catches up while the window is open
+	primaryState, err := primaryClient.CurrentState(context.Background())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return replicaTxID() == primaryState.TxId
+	}, time.Until(windowEnd), 10*time.Millisecond)
+
+	require.False(t, replicator.Paused())
+	require.True(t, time.Now().After(windowStart))
+
+	// nothing is replicated once the window is closed
+	time.Sleep(time.Until(windowEnd.Add(500 * time.Millisecond)))
+	require.True(t, replicator.Paused())
+
+	_, err = primaryClient.Set(context.Background(), []byte("key2"), []byte("value2"))
+	require.NoError(t, err)
+
+	time.Sleep(time.Second)
+	require.Equal(t, primaryState.TxId, replicaTxID())
+
+	require.Eventually(t, func() bool {
+		for _, line := range logger.GetLogs() {
+			if strings.Contains(line, "Disconnecting from") {
+				return true
+			}
+		}
+		return false
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func TestReplicationWithExportsLimit(t *testing.T) {
+	primaryServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir(t.TempDir()).
+		WithReplicationOptions((&server.ReplicationOptions{}).WithMaxConcurrentExports(1))
+
+	primaryServer := server.DefaultServer().WithOptions(primaryServerOpts).(*server.ImmuServer)
+
+	err := primaryServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		primaryServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer primaryServer.Stop()
+
+	primaryPort := primaryServer.Listener.Addr().(*net.TCPAddr).Port
+	primaryClient := ic.NewClient().WithOptions(ic.DefaultOptions().WithDir(t.TempDir()).WithPort(primaryPort))
+
+	err = primaryClient.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer primaryClient.CloseSession(context.Background())
+
+	for i := 0; i < 10; i++ {
+		_, err = primaryClient.Set(context.Background(), []byte(fmt.Sprintf("key%d", i)), []byte("value"))
+		require.NoError(t, err)
+	}
+
+	primaryState, err := primaryClient.CurrentState(context.Background())
+	require.NoError(t, err)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	// replicas catching up at once exceed the limit of exports, they're rejected and retry later
+	replicaDBs := make([]database.DB, 3)
+
+	for i := range replicaDBs {
+		replicaDB, err := database.NewDB(fmt.Sprintf("replicadb%d", i), nil, database.DefaultOption().AsReplica(true).WithDBRootPath(t.TempDir()), logger)
+		require.NoError(t, err)
+		defer replicaDB.Close()
+
+		replicatorOpts := replication.DefaultOptions().
+			WithPrimaryDatabase("defaultdb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(primaryPort).
+			WithPrimaryUsername("immudb").
+			WithPrimaryPassword("immudb").
+			WithDelayer(fixedDelayer(10 * time.Millisecond)).
+			WithIdlePollInterval(10 * time.Millisecond)
+
+		replicator, err := replication.NewTxReplicator(xid.New(), replicaDB, replicatorOpts, logger)
+		require.NoError(t, err)
+
+		err = replicator.Start()
+		require.NoError(t, err)
+		defer replicator.Stop()
+
+		replicaDBs[i] = replicaDB
+	}
+
+	for _, replicaDB := range replicaDBs {
+		require.Eventually(t, func() bool {
+			state, err := replicaDB.CurrentState()
+			require.NoError(t, err)
+			return state.TxId == primaryState.TxId
+		}, 20*time.Second, 10*time.Millisecond)
+	}
+
+	require.Eventually(t, func() bool {
+		for _, line := range logger.GetLogs() {
+			if strings.Contains(line, replication.ErrPrimaryBusy.Error()) {
+				return true
+			}
+		}
+		return false
+	}, 10*time.Second, 10*time.Millisecond)
+}
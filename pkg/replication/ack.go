@@ -0,0 +1,53 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"crypto/sha256"
+	"time"
+)
+
+type pendingAck struct {
+	txID  uint64
+	alh   [sha256.Size]byte
+	since time.Time
+}
+
+// allowCommitUpto acknowledges the commit state allowed by the primary according to the ack batch options.
+// Only the most recent commit state is kept while the batch is not complete, it's acknowledged once
+// enough transactions are pending, the batch interval elapses or there are no more transactions to fetch.
+// Batching never commits beyond the precommitted state as the replica checks the acknowledged
+// transaction was precommitted with the same Alh
+func (txr *TxReplicator) allowCommitUpto(txID uint64, alh [sha256.Size]byte, committedTxID uint64, flush bool) error {
+	if txr.pendingAck == nil {
+		txr.pendingAck = &pendingAck{since: time.Now()}
+	}
+
+	txr.pendingAck.txID = txID
+	txr.pendingAck.alh = alh
+
+	batchCompleted := txID-committedTxID >= uint64(txr.opts.ackBatchSize) ||
+		(txr.opts.ackBatchInterval > 0 && time.Since(txr.pendingAck.since) >= txr.opts.ackBatchInterval)
+
+	if !flush && !batchCompleted {
+		return nil
+	}
+
+	txr.pendingAck = nil
+
+	return txr.db.AllowCommitUpto(txID, alh)
+}
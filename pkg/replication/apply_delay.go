@@ -0,0 +1,62 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// applyDelayDeadline returns the time at which a transaction committed by the primary with the given header
+// may be applied when replication is delayed by delay.
+//
+// Transaction timestamps are taken from the primary's clock and truncated to seconds, they are thus rounded
+// up so a transaction is never applied before delay has elapsed since its actual commit. For the same reason
+// no attempt is made to compensate a primary clock ahead of the replica's, which can only lengthen the delay.
+// A replica clock ahead of the primary's shortens it by the difference, clocks are expected to be synchronized
+func applyDelayDeadline(hdr *store.TxHeader, delay time.Duration) time.Time {
+	return time.Unix(hdr.Ts+1, 0).Add(delay)
+}
+
+// waitApplyDelay holds the exported transaction until it may be applied according to the configured apply delay.
+// It returns false if replication was stopped meanwhile
+func (txr *TxReplicator) waitApplyDelay(etx []byte) bool {
+	if txr.opts.applyDelay <= 0 {
+		return true
+	}
+
+	hdr, err := exportedTxHeader(etx)
+	if err != nil {
+		// the transaction is not held back, applying it reports the failure
+		return true
+	}
+
+	wait := time.Until(applyDelayDeadline(hdr, txr.opts.applyDelay))
+	if wait <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(wait)
+	select {
+	case <-txr.context.Done():
+		timer.Stop()
+		return false
+	case <-timer.C:
+		return true
+	}
+}
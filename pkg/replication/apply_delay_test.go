@@ -0,0 +1,128 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyDelayDeadline(t *testing.T) {
+	hdr := &store.TxHeader{Ts: 1000}
+
+	// timestamps are truncated to seconds, the actual commit may have happened up to a second later
+	require.Equal(t, time.Unix(1001, 0), applyDelayDeadline(hdr, 0))
+	require.Equal(t, time.Unix(1061, 0), applyDelayDeadline(hdr, time.Minute))
+}
+
+func TestApplyDelay(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+	setTestKeys(t, primary, "key", 1)
+
+	etx, _, _, err := primary.ExportTxByID(context.Background(), &schema.ExportTxRequest{Tx: 1})
+	require.NoError(t, err)
+
+	hdr, err := exportedTxHeader(etx)
+	require.NoError(t, err)
+
+	newReplicator := func(t *testing.T, replica database.DB, delay time.Duration) *TxReplicator {
+		rOpts := DefaultOptions().
+			WithPrimaryDatabase("primarydb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(3322).
+			WithApplyDelay(delay)
+
+		txReplicator, err := NewTxReplicator(xid.New(), replica, rOpts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		txReplicator.context, txReplicator.cancelFunc = context.WithCancel(context.Background())
+		t.Cleanup(txReplicator.cancelFunc)
+
+		return txReplicator
+	}
+
+	t.Run("transactions should be applied once the delay has elapsed", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+
+		delay := time.Second
+		deadline := applyDelayDeadline(hdr, delay)
+
+		txReplicator := newReplicator(t, replica, delay)
+
+		done := make(chan bool)
+		go func() {
+			done <- txReplicator.replicateSingleTx(context.Background(), etx)
+		}()
+
+		time.Sleep(time.Until(deadline.Add(-200 * time.Millisecond)))
+
+		state, err := replica.CurrentState()
+		require.NoError(t, err)
+		require.Zero(t, state.TxId)
+
+		select {
+		case replicated := <-done:
+			require.True(t, replicated)
+		case <-time.After(time.Until(deadline.Add(time.Second))):
+			require.Fail(t, "transaction not applied after the delay")
+		}
+
+		require.False(t, time.Now().Before(deadline))
+
+		state, err = replica.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), state.TxId)
+	})
+
+	t.Run("transactions older than the delay should be applied without waiting", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+
+		txReplicator := newReplicator(t, replica, time.Millisecond)
+
+		time.Sleep(time.Until(applyDelayDeadline(hdr, time.Millisecond)))
+
+		start := time.Now()
+		require.True(t, txReplicator.replicateSingleTx(context.Background(), etx))
+		require.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("delayed transactions should not be applied once replication is stopped", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+
+		txReplicator := newReplicator(t, replica, time.Hour)
+
+		done := make(chan bool)
+		go func() {
+			done <- txReplicator.replicateSingleTx(context.Background(), etx)
+		}()
+
+		txReplicator.cancelFunc()
+		require.False(t, <-done)
+
+		state, err := replica.CurrentState()
+		require.NoError(t, err)
+		require.Zero(t, state.TxId)
+	})
+}
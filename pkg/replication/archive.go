@@ -0,0 +1,272 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/stream"
+)
+
+var ErrInvalidArchive = errors.New("invalid transaction archive")
+
+// archiveMagic identifies transaction archives, it's followed by the version of the archive format
+var archiveMagic = []byte("IMMUTXAR")
+
+const archiveVersion = 1
+
+// each transaction is framed by its id and the length of the exported transaction,
+// and followed by the sha256 digest of the exported transaction
+const archiveFrameHeaderLen = 8 + 4
+
+// ExportTxs writes the transactions in the range [fromTxID, toTxID] provided by the primary into w.
+// Transactions are archived in the same format used for replication, so they can be replayed
+// later on by ImportTxs without a connection to the primary.
+//
+// The archive starts with a magic string and the format version, then each transaction is written as:
+//   - tx id (8 bytes)
+//   - length of the exported transaction (4 bytes)
+//   - exported transaction
+//   - sha256 digest of the exported transaction (32 bytes)
+func ExportTxs(ctx context.Context, primary TxExporter, fromTxID, toTxID uint64, w io.Writer) error {
+	if primary == nil || w == nil || fromTxID == 0 || fromTxID > toTxID {
+		return ErrIllegalArguments
+	}
+
+	bw := bufio.NewWriter(w)
+
+	var version [2]byte
+	binary.BigEndian.PutUint16(version[:], archiveVersion)
+
+	_, err := bw.Write(append(archiveMagic, version[:]...))
+	if err != nil {
+		return err
+	}
+
+	for txID := fromTxID; txID <= toTxID; txID++ {
+		err := ctx.Err()
+		if err != nil {
+			return err
+		}
+
+		etx, err := primary.ExportTx(ctx, txID)
+		if err != nil {
+			return err
+		}
+
+		if len(etx) == 0 {
+			return fmt.Errorf("%w: transaction %d was not provided by the primary", ErrIllegalArguments, txID)
+		}
+
+		hdr, err := exportedTxHeader(etx)
+		if err != nil {
+			return err
+		}
+
+		if hdr.ID != txID {
+			return fmt.Errorf("%w: transaction %d expected but %d was provided", ErrInvalidExportedTx, txID, hdr.ID)
+		}
+
+		var frameHdr [archiveFrameHeaderLen]byte
+		binary.BigEndian.PutUint64(frameHdr[:], txID)
+		binary.BigEndian.PutUint32(frameHdr[8:], uint32(len(etx)))
+
+		digest := sha256.Sum256(etx)
+
+		for _, b := range [][]byte{frameHdr[:], etx, digest[:]} {
+			_, err = bw.Write(b)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// ExportTxsToFile archives the transactions in the range [fromTxID, toTxID] into a new file,
+// the file is synced to disk before returning
+func ExportTxsToFile(ctx context.Context, primary TxExporter, fromTxID, toTxID uint64, path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	err = ExportTxs(ctx, primary, fromTxID, toTxID, f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Sync()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// ImportTxs replicates the transactions of an archive created by ExportTxs into db, which must be a replica.
+// The integrity of every transaction is checked before it's replicated.
+// Transactions already committed by db are skipped if they are identical to the archived ones,
+// so an archive can be imported again after a failure or overlap previously imported ones.
+// The id of the last transaction of the archive is returned.
+func ImportTxs(ctx context.Context, db database.DB, r io.Reader) (uint64, error) {
+	if db == nil || r == nil {
+		return 0, ErrIllegalArguments
+	}
+
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(archiveMagic)+2)
+
+	_, err := io.ReadFull(br, header)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	if !bytes.Equal(header[:len(archiveMagic)], archiveMagic) {
+		return 0, fmt.Errorf("%w: unknown format", ErrInvalidArchive)
+	}
+
+	version := binary.BigEndian.Uint16(header[len(archiveMagic):])
+	if version != archiveVersion {
+		return 0, fmt.Errorf("%w: unsupported version %d", ErrInvalidArchive, version)
+	}
+
+	var lastTxID uint64
+
+	for {
+		err := ctx.Err()
+		if err != nil {
+			return lastTxID, err
+		}
+
+		var frameHdr [archiveFrameHeaderLen]byte
+
+		_, err = io.ReadFull(br, frameHdr[:])
+		if errors.Is(err, io.EOF) {
+			return lastTxID, nil
+		}
+		if err != nil {
+			return lastTxID, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		txID := binary.BigEndian.Uint64(frameHdr[:])
+
+		if lastTxID > 0 && txID != lastTxID+1 {
+			return lastTxID, fmt.Errorf("%w: transaction %d expected but %d was found", ErrInvalidArchive, lastTxID+1, txID)
+		}
+
+		etx := make([]byte, binary.BigEndian.Uint32(frameHdr[8:]))
+
+		_, err = io.ReadFull(br, etx)
+		if err != nil {
+			return lastTxID, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		var digest [sha256.Size]byte
+
+		_, err = io.ReadFull(br, digest[:])
+		if err != nil {
+			return lastTxID, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		if sha256.Sum256(etx) != digest {
+			return lastTxID, fmt.Errorf("%w: digest mismatch at transaction %d", ErrInvalidArchive, txID)
+		}
+
+		err = importTx(ctx, db, txID, etx)
+		if err != nil {
+			return lastTxID, err
+		}
+
+		lastTxID = txID
+	}
+}
+
+func importTx(ctx context.Context, db database.DB, txID uint64, etx []byte) error {
+	hdr, err := exportedTxHeader(etx)
+	if err != nil {
+		return err
+	}
+
+	if hdr.ID != txID {
+		return fmt.Errorf("%w: transaction %d was framed as %d", ErrInvalidArchive, hdr.ID, txID)
+	}
+
+	state, err := db.CurrentState()
+	if err != nil {
+		return err
+	}
+
+	if txID > state.TxId+1 {
+		return fmt.Errorf("%w: transaction %d can not be imported before transaction %d", ErrIllegalArguments, txID, state.TxId+1)
+	}
+
+	if txID == state.TxId+1 {
+		_, err = db.ReplicateTx(ctx, etx)
+		return err
+	}
+
+	localTx, err := (&dbTxExporter{db: db}).ExportTx(ctx, txID)
+	if err != nil {
+		return err
+	}
+
+	localHdr, err := exportedTxHeader(localTx)
+	if err != nil {
+		return err
+	}
+
+	if localHdr.Alh() != hdr.Alh() {
+		return fmt.Errorf("%w: transaction %d differs from the one already committed", ErrReplicaDivergedFromPrimary, txID)
+	}
+
+	return nil
+}
+
+// ImportTxsFromFile replicates the transactions archived in a file into db
+func ImportTxsFromFile(ctx context.Context, db database.DB, path string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return ImportTxs(ctx, db, f)
+}
+
+// NewClientTxExporter returns a TxExporter streaming transactions from the primary
+// through the given client, which must have an open session
+func NewClientTxExporter(c client.ImmuClient, streamChunkSize int) TxExporter {
+	return &clientTxExporter{
+		client:           c,
+		streamSrvFactory: stream.NewStreamServiceFactory(streamChunkSize),
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxArchive(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+	setTestKeys(t, primary, "key", 4)
+
+	// the first transaction is committed when the database is created
+	archive := filepath.Join(t.TempDir(), "txs.archive")
+
+	err := ExportTxsToFile(context.Background(), &dbTxExporter{db: primary}, 1, 5, archive)
+	require.NoError(t, err)
+
+	t.Run("invalid ranges should be rejected", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		err := ExportTxs(context.Background(), &dbTxExporter{db: primary}, 0, 5, &buf)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = ExportTxs(context.Background(), &dbTxExporter{db: primary}, 3, 2, &buf)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("existing files should not be overwritten", func(t *testing.T) {
+		err := ExportTxsToFile(context.Background(), &dbTxExporter{db: primary}, 1, 5, archive)
+		require.ErrorIs(t, err, os.ErrExist)
+	})
+
+	t.Run("importing an archive should produce the same state", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+
+		lastTxID, err := ImportTxsFromFile(context.Background(), replica, archive)
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), lastTxID)
+
+		primaryState, err := primary.CurrentState()
+		require.NoError(t, err)
+
+		replicaState, err := replica.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, primaryState.TxId, replicaState.TxId)
+		require.Equal(t, primaryState.TxHash, replicaState.TxHash)
+
+		report, err := VerifyReplicaConsistency(context.Background(), replica, &dbTxExporter{db: primary}, nil)
+		require.NoError(t, err)
+		require.True(t, report.Consistent())
+
+		entry, err := replica.Get(context.Background(), &schema.KeyRequest{Key: []byte("key3")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("value3"), entry.Value)
+
+		t.Run("already imported transactions should be skipped", func(t *testing.T) {
+			lastTxID, err := ImportTxsFromFile(context.Background(), replica, archive)
+			require.NoError(t, err)
+			require.Equal(t, uint64(5), lastTxID)
+		})
+	})
+
+	t.Run("ranges should be imported after previous ones", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+
+		var first, second bytes.Buffer
+
+		err := ExportTxs(context.Background(), &dbTxExporter{db: primary}, 1, 2, &first)
+		require.NoError(t, err)
+
+		err = ExportTxs(context.Background(), &dbTxExporter{db: primary}, 3, 5, &second)
+		require.NoError(t, err)
+
+		_, err = ImportTxs(context.Background(), replica, bytes.NewReader(second.Bytes()))
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = ImportTxs(context.Background(), replica, &first)
+		require.NoError(t, err)
+
+		lastTxID, err := ImportTxs(context.Background(), replica, &second)
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), lastTxID)
+	})
+
+	t.Run("corrupted archives should be rejected", func(t *testing.T) {
+		data, err := os.ReadFile(archive)
+		require.NoError(t, err)
+
+		corrupted := bytes.Replace(data, []byte("value2"), []byte("value9"), 1)
+
+		replica := newTestDB(t, "replicadb", true)
+
+		lastTxID, err := ImportTxs(context.Background(), replica, bytes.NewReader(corrupted))
+		require.ErrorIs(t, err, ErrInvalidArchive)
+		require.Equal(t, uint64(3), lastTxID)
+
+		_, err = ImportTxs(context.Background(), replica, bytes.NewReader(data[:len(data)-1]))
+		require.ErrorIs(t, err, ErrInvalidArchive)
+
+		_, err = ImportTxs(context.Background(), replica, bytes.NewReader([]byte("unknown")))
+		require.ErrorIs(t, err, ErrInvalidArchive)
+	})
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+var ErrInvalidCheckpoint = errors.New("invalid replication checkpoint")
+var ErrUnsupportedCheckpointVersion = errors.New("unsupported replication checkpoint version")
+var ErrCheckpointMismatch = errors.New("replica does not match its replication checkpoint")
+
+// checkpointFilename is the file holding the replication checkpoint, within the directory of the replica database
+const checkpointFilename = "replication.checkpoint"
+
+// checkpointVersion is the version of the checkpoint layout written by this replicator.
+// Every prior version must still be decoded, so checkpoints are migrated by just writing them again
+const checkpointVersion byte = 1
+
+// checkpoint is the replication state persisted by the replica so it's kept across restarts.
+//
+// Layout (version 1), integers are big endian:
+//
+//	version       1 byte
+//	uuid length   2 bytes
+//	primary uuid  uuid length bytes
+//	last tx id    8 bytes
+//	last alh      32 bytes
+type checkpoint struct {
+	// primaryUUID is the UUID pinned by the primary UUID check, empty if not pinned
+	primaryUUID string
+	// lastTxID and lastAlh identify the last transaction committed by the replica
+	lastTxID uint64
+	lastAlh  [sha256.Size]byte
+}
+
+func (cp *checkpoint) encode() ([]byte, error) {
+	if len(cp.primaryUUID) > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: primary uuid is too long", ErrInvalidCheckpoint)
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteByte(checkpointVersion)
+
+	var b [8]byte
+
+	binary.BigEndian.PutUint16(b[:], uint16(len(cp.primaryUUID)))
+	buf.Write(b[:2])
+	buf.WriteString(cp.primaryUUID)
+
+	binary.BigEndian.PutUint64(b[:], cp.lastTxID)
+	buf.Write(b[:])
+
+	buf.Write(cp.lastAlh[:])
+
+	return buf.Bytes(), nil
+}
+
+func decodeCheckpoint(bs []byte) (*checkpoint, error) {
+	if len(bs) == 0 {
+		return nil, fmt.Errorf("%w: empty checkpoint", ErrInvalidCheckpoint)
+	}
+
+	switch bs[0] {
+	case 1:
+		return decodeCheckpointV1(bs[1:])
+	}
+
+	return nil, fmt.Errorf("%w: %d", ErrUnsupportedCheckpointVersion, bs[0])
+}
+
+func decodeCheckpointV1(bs []byte) (*checkpoint, error) {
+	if len(bs) < 2 {
+		return nil, fmt.Errorf("%w: truncated checkpoint", ErrInvalidCheckpoint)
+	}
+
+	uuidLen := int(binary.BigEndian.Uint16(bs))
+	bs = bs[2:]
+
+	if len(bs) != uuidLen+8+sha256.Size {
+		return nil, fmt.Errorf("%w: unexpected checkpoint size", ErrInvalidCheckpoint)
+	}
+
+	cp := &checkpoint{
+		primaryUUID: string(bs[:uuidLen]),
+		lastTxID:    binary.BigEndian.Uint64(bs[uuidLen:]),
+	}
+
+	copy(cp.lastAlh[:], bs[uuidLen+8:])
+
+	return cp, nil
+}
+
+// readCheckpoint returns the checkpoint stored in the given directory, or nil if there is none
+func readCheckpoint(dir string) (*checkpoint, error) {
+	bs, err := os.ReadFile(filepath.Join(dir, checkpointFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeCheckpoint(bs)
+}
+
+// writeCheckpoint replaces the checkpoint stored in the given directory,
+// the previous checkpoint is kept if the new one can not be completely written
+func writeCheckpoint(dir string, cp *checkpoint) error {
+	bs, err := cp.encode()
+	if err != nil {
+		return err
+	}
+
+	tmpFile := filepath.Join(dir, checkpointFilename+".tmp")
+
+	f, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(bs)
+	if err == nil {
+		err = f.Sync()
+	}
+
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+
+	return os.Rename(tmpFile, filepath.Join(dir, checkpointFilename))
+}
+
+// loadCheckpoint restores the replication state persisted by a previous run, the replica must still
+// hold the last transaction recorded in the checkpoint, otherwise the database is not the one being replicated
+func (txr *TxReplicator) loadCheckpoint(ctx context.Context) error {
+	cp, err := readCheckpoint(txr.db.Path())
+	if err != nil || cp == nil {
+		return err
+	}
+
+	state, err := txr.db.CurrentState()
+	if err != nil {
+		return err
+	}
+
+	if state.TxId < cp.lastTxID {
+		return fmt.Errorf("%w: transaction %d was committed but the replica is at transaction %d", ErrCheckpointMismatch, cp.lastTxID, state.TxId)
+	}
+
+	if cp.lastTxID > 0 {
+		tx, err := txr.db.TxByID(ctx, &schema.TxRequest{Tx: cp.lastTxID})
+		if err != nil {
+			return err
+		}
+
+		if schema.TxHeaderFromProto(tx.Header).Alh() != cp.lastAlh {
+			return fmt.Errorf("%w: transaction %d differs from the committed one", ErrCheckpointMismatch, cp.lastTxID)
+		}
+	}
+
+	if cp.primaryUUID != "" && txr.primaryUUID == "" {
+		txr.primaryUUID = cp.primaryUUID
+		txr.updateStatus(func(st *replicatorStatus) { st.primaryUUID = cp.primaryUUID })
+		txr.logger.Infof("Primary '%s' pinned with UUID '%s' by the replication checkpoint", txr._primaryDB, cp.primaryUUID)
+	}
+
+	return nil
+}
+
+// saveCheckpoint persists the pinned primary UUID along with the last transaction committed by the replica
+func (txr *TxReplicator) saveCheckpoint() error {
+	state, err := txr.db.CurrentState()
+	if err != nil {
+		return err
+	}
+
+	cp := &checkpoint{
+		primaryUUID: txr.primaryUUID,
+		lastTxID:    state.TxId,
+	}
+
+	copy(cp.lastAlh[:], state.TxHash)
+
+	return writeCheckpoint(txr.db.Path(), cp)
+}
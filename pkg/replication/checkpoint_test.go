@@ -0,0 +1,194 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointEncoding(t *testing.T) {
+	cp := &checkpoint{
+		primaryUUID: "cc7a8s4u6ejb3tm6mfd0",
+		lastTxID:    1234,
+		lastAlh:     sha256.Sum256([]byte("alh")),
+	}
+
+	bs, err := cp.encode()
+	require.NoError(t, err)
+	require.Equal(t, checkpointVersion, bs[0])
+	require.Len(t, bs, 1+2+len(cp.primaryUUID)+8+sha256.Size)
+
+	decoded, err := decodeCheckpoint(bs)
+	require.NoError(t, err)
+	require.Equal(t, cp, decoded)
+
+	t.Run("checkpoints without a pinned uuid should be encoded", func(t *testing.T) {
+		bs, err := (&checkpoint{lastTxID: 1}).encode()
+		require.NoError(t, err)
+
+		decoded, err := decodeCheckpoint(bs)
+		require.NoError(t, err)
+		require.Empty(t, decoded.primaryUUID)
+		require.Equal(t, uint64(1), decoded.lastTxID)
+	})
+
+	t.Run("version 1 checkpoints should be decoded", func(t *testing.T) {
+		v1 := []byte{
+			1,                        // version
+			0, 4, 'u', 'u', 'i', 'd', // primary uuid
+			0, 0, 0, 0, 0, 0, 0x01, 0x02, // last tx id
+		}
+		v1 = append(v1, make([]byte, sha256.Size)...)
+		v1[len(v1)-1] = 0xff
+
+		decoded, err := decodeCheckpoint(v1)
+		require.NoError(t, err)
+		require.Equal(t, "uuid", decoded.primaryUUID)
+		require.Equal(t, uint64(0x0102), decoded.lastTxID)
+		require.Equal(t, byte(0xff), decoded.lastAlh[sha256.Size-1])
+	})
+
+	t.Run("invalid checkpoints should be rejected", func(t *testing.T) {
+		_, err := decodeCheckpoint(nil)
+		require.ErrorIs(t, err, ErrInvalidCheckpoint)
+
+		_, err = decodeCheckpoint(bs[:1])
+		require.ErrorIs(t, err, ErrInvalidCheckpoint)
+
+		_, err = decodeCheckpoint(bs[:len(bs)-1])
+		require.ErrorIs(t, err, ErrInvalidCheckpoint)
+
+		_, err = decodeCheckpoint(append(bs, 0))
+		require.ErrorIs(t, err, ErrInvalidCheckpoint)
+
+		_, err = decodeCheckpoint(append([]byte{checkpointVersion + 1}, bs[1:]...))
+		require.ErrorIs(t, err, ErrUnsupportedCheckpointVersion)
+
+		_, err = (&checkpoint{primaryUUID: strings.Repeat("u", 1<<16)}).encode()
+		require.ErrorIs(t, err, ErrInvalidCheckpoint)
+	})
+}
+
+func TestCheckpointFile(t *testing.T) {
+	dir := t.TempDir()
+
+	cp, err := readCheckpoint(dir)
+	require.NoError(t, err)
+	require.Nil(t, cp)
+
+	err = writeCheckpoint(dir, &checkpoint{primaryUUID: "uuid1", lastTxID: 1})
+	require.NoError(t, err)
+
+	err = writeCheckpoint(dir, &checkpoint{primaryUUID: "uuid2", lastTxID: 2})
+	require.NoError(t, err)
+
+	cp, err = readCheckpoint(dir)
+	require.NoError(t, err)
+	require.Equal(t, "uuid2", cp.primaryUUID)
+	require.Equal(t, uint64(2), cp.lastTxID)
+
+	_, err = os.Stat(filepath.Join(dir, checkpointFilename+".tmp"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	err = os.WriteFile(filepath.Join(dir, checkpointFilename), []byte{0}, 0600)
+	require.NoError(t, err)
+
+	_, err = readCheckpoint(dir)
+	require.ErrorIs(t, err, ErrUnsupportedCheckpointVersion)
+}
+
+func TestReplicatorCheckpoint(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+	setTestKeys(t, primary, "key", 3)
+
+	replica := newTestDB(t, "replicadb", true)
+	replicateTestTxs(t, primary, replica, 1, 2)
+
+	newReplicator := func(t *testing.T) *TxReplicator {
+		opts := DefaultOptions().
+			WithPrimaryDatabase("primarydb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(3322).
+			WithPrimaryUUIDCheck(true).
+			WithCheckpointPersistence(true)
+
+		txr, err := NewTxReplicator(xid.New(), replica, opts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		return txr
+	}
+
+	t.Run("the replication state should be restored from the checkpoint", func(t *testing.T) {
+		txr := newReplicator(t)
+		txr.primaryUUID = "uuid1"
+
+		err := txr.saveCheckpoint()
+		require.NoError(t, err)
+
+		txr = newReplicator(t)
+
+		err = txr.loadCheckpoint(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "uuid1", txr.primaryUUID)
+		require.Equal(t, "uuid1", txr.currentStatus().primaryUUID)
+
+		// the replica may commit more transactions after the checkpoint was saved
+		replicateTestTxs(t, primary, replica, 3, 3)
+
+		txr = newReplicator(t)
+
+		err = txr.loadCheckpoint(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "uuid1", txr.primaryUUID)
+	})
+
+	t.Run("replicas not holding the checkpointed transaction should be detected", func(t *testing.T) {
+		state, err := replica.CurrentState()
+		require.NoError(t, err)
+
+		err = writeCheckpoint(replica.Path(), &checkpoint{primaryUUID: "uuid1", lastTxID: state.TxId + 1})
+		require.NoError(t, err)
+
+		err = newReplicator(t).loadCheckpoint(context.Background())
+		require.ErrorIs(t, err, ErrCheckpointMismatch)
+
+		tx, err := replica.TxByID(context.Background(), &schema.TxRequest{Tx: state.TxId})
+		require.NoError(t, err)
+
+		alh := schema.TxHeaderFromProto(tx.Header).Alh()
+		alh[0] ^= 0xff
+
+		err = writeCheckpoint(replica.Path(), &checkpoint{primaryUUID: "uuid1", lastTxID: state.TxId, lastAlh: alh})
+		require.NoError(t, err)
+
+		err = newReplicator(t).loadCheckpoint(context.Background())
+		require.ErrorIs(t, err, ErrCheckpointMismatch)
+
+		err = newReplicator(t).Start()
+		require.ErrorIs(t, err, ErrCheckpointMismatch)
+	})
+}
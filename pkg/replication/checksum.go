@@ -0,0 +1,56 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/metadata"
+)
+
+var ErrTxPayloadCorrupted = errors.New("transaction payload corrupted")
+
+// verifyPayloadChecksum checks an exported transaction against the checksum computed by the primary,
+// sent in the trailer of the export. The transaction is not verified if the primary does not send it
+func (txr *TxReplicator) verifyPayloadChecksum(txID uint64, etx []byte, md metadata.MD) error {
+	checksums := md.Get("tx-checksum-bin")
+	if len(checksums) == 0 {
+		if !txr.payloadChecksumUnsupported {
+			txr.payloadChecksumUnsupported = true
+			txr.logger.Warningf("Primary '%s' does not send checksums of exported transactions, they are replicated to '%s' unverified", txr._primaryDB, txr.db.GetName())
+		}
+		return nil
+	}
+
+	checksum := sha256.Sum256(etx)
+
+	if checksums[0] == string(checksum[:]) {
+		return nil
+	}
+
+	err := fmt.Errorf("%w: checksum mismatch of transaction %d exported by '%s'", ErrTxPayloadCorrupted, txID, txr._primaryDB)
+
+	txr.logger.Errorf("Transaction %d can not be replicated to '%s'. Reason: %s", txID, txr.db.GetName(), err.Error())
+
+	if txr.opts.alertHandler != nil {
+		txr.opts.alertHandler(&IntegrityAlert{TxID: txID, Reason: err.Error()})
+	}
+
+	return err
+}
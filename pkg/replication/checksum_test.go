@@ -0,0 +1,68 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestVerifyPayloadChecksum(t *testing.T) {
+	var alerts []*IntegrityAlert
+
+	rOpts := DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(3322).
+		WithPayloadChecksum(true).
+		WithAlertHandler(func(alert *IntegrityAlert) { alerts = append(alerts, alert) })
+
+	txReplicator, err := NewTxReplicator(xid.New(), newTestDB(t, "replicadb", true), rOpts, logger.NewMemoryLogger())
+	require.NoError(t, err)
+
+	etx := []byte("exported transaction")
+	checksum := sha256.Sum256(etx)
+
+	t.Run("transactions matching their checksum should be accepted", func(t *testing.T) {
+		err := txReplicator.verifyPayloadChecksum(1, etx, metadata.Pairs("tx-checksum-bin", string(checksum[:])))
+		require.NoError(t, err)
+		require.Empty(t, alerts)
+	})
+
+	t.Run("corrupted transactions should be rejected", func(t *testing.T) {
+		corrupted := append([]byte{}, etx...)
+		corrupted[0] ^= 0xFF
+
+		err := txReplicator.verifyPayloadChecksum(2, corrupted, metadata.Pairs("tx-checksum-bin", string(checksum[:])))
+		require.ErrorIs(t, err, ErrTxPayloadCorrupted)
+		require.True(t, isTerminalError(err))
+
+		require.Len(t, alerts, 1)
+		require.Equal(t, uint64(2), alerts[0].TxID)
+	})
+
+	t.Run("transactions without checksum should be accepted", func(t *testing.T) {
+		err := txReplicator.verifyPayloadChecksum(3, etx, metadata.MD{})
+		require.NoError(t, err)
+		require.True(t, txReplicator.payloadChecksumUnsupported)
+	})
+}
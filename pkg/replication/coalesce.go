@@ -0,0 +1,156 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"time"
+)
+
+// coalesceMaxTxs returns the maximum number of transactions applied as a group, coalescing is disabled when lower than 2.
+// Synchronous replicas acknowledge transactions once durably precommitted, a group never spans more transactions
+// than the ack batch so that no acknowledgment waits for the rest of the group to be applied
+func (txr *TxReplicator) coalesceMaxTxs() int {
+	maxTxs := txr.opts.applyCoalesceMaxTxs
+
+	if txr.db.IsSyncReplicationEnabled() && txr.opts.ackBatchSize < maxTxs {
+		maxTxs = txr.opts.ackBatchSize
+	}
+
+	return maxTxs
+}
+
+// applyCoalesced applies the fetched transactions in order, coalescing consecutive transactions already fetched
+// into groups of up to maxTxs transactions. It returns once the prefetch buffer is closed or a transaction is rejected
+func (txr *TxReplicator) applyCoalesced(maxTxs int) {
+	txr.metrics.replicators.Inc()
+	defer txr.metrics.replicators.Dec()
+
+	// next holds the transaction which did not fit into the previous group
+	var next *prefetchTxEntry
+
+	for {
+		var etx prefetchTxEntry
+
+		if next != nil {
+			etx, next = *next, nil
+		} else {
+			var ok bool
+
+			etx, ok = <-txr.prefetchTxBuffer
+			if !ok {
+				return
+			}
+
+			txr.metrics.txWaitQueueHistogram.Observe(time.Since(etx.addedAt).Seconds())
+		}
+
+		group := []prefetchTxEntry{etx}
+		size := len(etx.data)
+
+		closed := false
+
+	coalesce:
+		for len(group) < maxTxs && txr.fitsTxGroup(size, etx) {
+			select {
+			case e, ok := <-txr.prefetchTxBuffer:
+				if !ok {
+					closed = true
+					break coalesce
+				}
+
+				txr.metrics.txWaitQueueHistogram.Observe(time.Since(e.addedAt).Seconds())
+
+				if !txr.fitsTxGroup(size, e) {
+					next = &e
+					break coalesce
+				}
+
+				group = append(group, e)
+				size += len(e.data)
+			default:
+				// transactions are not held back waiting for the group to be completed
+				break coalesce
+			}
+		}
+
+		if !txr.replicateTxGroup(group) || closed {
+			return
+		}
+	}
+}
+
+// fitsTxGroup returns true if the transaction can be added to a group of transactions taking size bytes
+func (txr *TxReplicator) fitsTxGroup(size int, etx prefetchTxEntry) bool {
+	return txr.opts.applyCoalesceMaxBytes == 0 || size+len(etx.data) <= txr.opts.applyCoalesceMaxBytes
+}
+
+// replicateTxGroup applies a group of consecutive transactions at once. When the group can not be applied,
+// its transactions are retried one by one, the ones already replicated are then skipped
+func (txr *TxReplicator) replicateTxGroup(group []prefetchTxEntry) bool {
+	if len(group) == 1 {
+		return txr.replicateSingleTx(group[0].ctx, group[0].data)
+	}
+
+	// transactions are committed in order, once the last one may be applied so may the whole group
+	if !txr.waitResumed() || !txr.waitApplyDelay(group[len(group)-1].data) {
+		return false
+	}
+
+	ctx, span := txr.tracer.Start(group[0].ctx, replicateSpanName)
+
+	etxs := make([][]byte, len(group))
+	for i, e := range group {
+		etxs[i] = e.data
+	}
+
+	txr.metrics.replicatorsActive.Inc()
+	timer := txr.metrics.replicationTimeHistogramTimer()
+
+	_, err := txr.db.ReplicateTxs(ctx, etxs)
+
+	timer.ObserveDuration()
+	txr.metrics.replicatorsActive.Dec()
+
+	span.End(err)
+
+	if err != nil {
+		txr.logger.Infof("Failed to replicate %d coalesced transactions from '%s' to '%s', replicating them one by one. Reason: %s",
+			len(group), txr._primaryDB, txr.db.GetName(), err.Error())
+
+		for _, e := range group {
+			if !txr.replicateSingleTx(e.ctx, e.data) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	txr.updateStatus(func(st *replicatorStatus) {
+		st.appliedTxs += uint64(len(group))
+		st.applyRate.observe(st.appliedTxs, time.Now())
+		st.setLastError(nil)
+	})
+
+	txr.trackLiveness()
+
+	for _, etx := range etxs {
+		txr.enqueueShadowTx(etx)
+	}
+
+	return true
+}
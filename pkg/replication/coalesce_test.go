@@ -0,0 +1,154 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+// groupRecordingDB records the size of the groups of transactions replicated at once
+type groupRecordingDB struct {
+	database.DB
+	groups []int
+}
+
+func (db *groupRecordingDB) ReplicateTxs(ctx context.Context, exportedTxs [][]byte) ([]*schema.TxHeader, error) {
+	db.groups = append(db.groups, len(exportedTxs))
+	return db.DB.ReplicateTxs(ctx, exportedTxs)
+}
+
+func TestApplyCoalesce(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+
+	setTestKeys(t, primary, "key", 20)
+
+	primaryState, err := primary.CurrentState()
+	require.NoError(t, err)
+
+	var etxs [][]byte
+
+	for txID := uint64(1); txID <= primaryState.TxId; txID++ {
+		etx, _, _, err := primary.ExportTxByID(context.Background(), &schema.ExportTxRequest{Tx: txID})
+		require.NoError(t, err)
+
+		etxs = append(etxs, etx)
+	}
+
+	replicateCoalesced := func(t *testing.T, replica database.DB, maxTxs, maxBytes int, fromTxID uint64) *groupRecordingDB {
+		recorder := &groupRecordingDB{DB: replica}
+
+		rOpts := DefaultOptions().
+			WithPrimaryDatabase("defaultdb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(3322).
+			WithApplyCoalesce(maxTxs, maxBytes).
+			WithApplyRetries(0, 0)
+
+		txReplicator, err := NewTxReplicator(xid.New(), recorder, rOpts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		txReplicator.context, txReplicator.cancelFunc = context.WithCancel(context.Background())
+		t.Cleanup(txReplicator.cancelFunc)
+
+		for _, etx := range etxs[fromTxID-1:] {
+			txReplicator.prefetchTxBuffer <- prefetchTxEntry{data: etx, addedAt: time.Now(), ctx: context.Background()}
+		}
+		close(txReplicator.prefetchTxBuffer)
+
+		txReplicator.applyCoalesced(txReplicator.coalesceMaxTxs())
+
+		require.EqualValues(t, primaryState.TxId-fromTxID+1, txReplicator.currentStatus().appliedTxs)
+
+		return recorder
+	}
+
+	requireSameHistory := func(t *testing.T, replica database.DB) {
+		replicaState, err := replica.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, primaryState.TxId, replicaState.TxId)
+		require.Equal(t, primaryState.TxHash, replicaState.TxHash)
+
+		for txID := uint64(1); txID <= primaryState.TxId; txID++ {
+			primaryTx, err := primary.TxByID(context.Background(), &schema.TxRequest{Tx: txID})
+			require.NoError(t, err)
+
+			replicaTx, err := replica.TxByID(context.Background(), &schema.TxRequest{Tx: txID})
+			require.NoError(t, err)
+
+			require.Equal(t, primaryTx.Header.Id, replicaTx.Header.Id)
+			require.Equal(t, primaryTx.Header.EH, replicaTx.Header.EH)
+			require.Equal(t, primaryTx.Header.PrevAlh, replicaTx.Header.PrevAlh)
+			require.Equal(t, primaryTx.Header.Ts, replicaTx.Header.Ts)
+			require.Len(t, replicaTx.Entries, 1)
+			require.Equal(t, primaryTx.Entries[0].Key, replicaTx.Entries[0].Key)
+		}
+	}
+
+	t.Run("coalesced transactions should keep their own history", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+
+		recorder := replicateCoalesced(t, replica, 8, 0, 1)
+		require.Equal(t, []int{8, 8, 5}, recorder.groups)
+
+		requireSameHistory(t, replica)
+	})
+
+	t.Run("groups should not exceed the size threshold", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+
+		recorder := replicateCoalesced(t, replica, 8, 2*len(etxs[0])+1, 1)
+		require.NotEmpty(t, recorder.groups)
+
+		for _, size := range recorder.groups {
+			require.Equal(t, 2, size)
+		}
+
+		requireSameHistory(t, replica)
+	})
+
+	t.Run("transactions already replicated should be skipped one by one", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+
+		replicateTestTxs(t, primary, replica, 1, 3)
+
+		recorder := replicateCoalesced(t, replica, 8, 0, 2)
+		require.Equal(t, []int{8, 8}, recorder.groups[:2])
+
+		requireSameHistory(t, replica)
+	})
+
+	t.Run("synchronous replicas should not coalesce beyond the ack batch", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+		replica.AsReplica(true, true, 1)
+
+		txReplicator, err := NewTxReplicator(xid.New(), replica, DefaultOptions().WithApplyCoalesce(8, 0).WithAckBatch(4, 0), logger.NewMemoryLogger())
+		require.NoError(t, err)
+		require.Equal(t, 4, txReplicator.coalesceMaxTxs())
+
+		txReplicator, err = NewTxReplicator(xid.New(), replica, DefaultOptions().WithApplyCoalesce(8, 0), logger.NewMemoryLogger())
+		require.NoError(t, err)
+		require.Equal(t, 1, txReplicator.coalesceMaxTxs())
+	})
+}
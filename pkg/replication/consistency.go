@@ -0,0 +1,208 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/stream"
+)
+
+var ErrInvalidExportedTx = errors.New("invalid exported transaction")
+
+// TxExporter provides transactions in the same format used for replication
+type TxExporter interface {
+	ExportTx(ctx context.Context, txID uint64) ([]byte, error)
+}
+
+// ConsistencyProgressFunc is called after each transaction has been checked
+type ConsistencyProgressFunc func(checkedTxID, upToTxID uint64)
+
+// ConsistencyReport holds the outcome of a replica consistency check
+type ConsistencyReport struct {
+	// UpToTxID is the last transaction committed by the replica when the check started
+	UpToTxID uint64
+	// CheckedTxs is the number of transactions found to be consistent
+	CheckedTxs uint64
+	// FirstDivergentTxID is the first inconsistent transaction, zero if all of them are consistent
+	FirstDivergentTxID uint64
+	// Reason describes why the divergent transaction is considered inconsistent
+	Reason string
+}
+
+func (r *ConsistencyReport) Consistent() bool {
+	return r.FirstDivergentTxID == 0
+}
+
+// VerifyReplicaConsistency checks the hash chain of every transaction committed by the replica
+// and compares each of them with the one exported by the primary
+func VerifyReplicaConsistency(ctx context.Context, replica database.DB, primary TxExporter, progress ConsistencyProgressFunc) (*ConsistencyReport, error) {
+	if replica == nil || primary == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	state, err := replica.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+
+	return checkConsistency(ctx, &dbTxExporter{db: replica}, primary, state.TxId, progress)
+}
+
+// VerifyConsistency checks the replicated database against the primary using a dedicated session,
+// the ongoing replication is not affected by the check
+func (txr *TxReplicator) VerifyConsistency(ctx context.Context, progress ConsistencyProgressFunc) (*ConsistencyReport, error) {
+	c := txr.newPrimaryClient()
+
+	err := c.OpenSession(ctx, []byte(txr.opts.primaryUsername), []byte(txr.opts.primaryPassword), txr.primaryDatabaseName())
+	if err != nil {
+		return nil, err
+	}
+	defer c.CloseSession(context.Background())
+
+	txr.logger.Infof("Verifying consistency of database '%s' against '%s'...", txr.db.GetName(), txr._primaryDB)
+
+	report, err := VerifyReplicaConsistency(ctx, txr.db, &clientTxExporter{client: c, streamSrvFactory: txr.streamSrvFactory}, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	if report.Consistent() {
+		txr.logger.Infof("Database '%s' is consistent with '%s' up to tx %d", txr.db.GetName(), txr._primaryDB, report.UpToTxID)
+	} else {
+		txr.logger.Errorf("Database '%s' diverged from '%s' at tx %d. Reason: %s", txr.db.GetName(), txr._primaryDB, report.FirstDivergentTxID, report.Reason)
+	}
+
+	return report, nil
+}
+
+func checkConsistency(ctx context.Context, replica, primary TxExporter, upToTxID uint64, progress ConsistencyProgressFunc) (*ConsistencyReport, error) {
+	report := &ConsistencyReport{UpToTxID: upToTxID}
+
+	var prevAlh [sha256.Size]byte
+
+	for txID := uint64(1); txID <= upToTxID; txID++ {
+		err := ctx.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		replicaTx, err := replica.ExportTx(ctx, txID)
+		if err != nil {
+			return nil, err
+		}
+
+		hdr, err := exportedTxHeader(replicaTx)
+		if err != nil {
+			report.FirstDivergentTxID = txID
+			report.Reason = err.Error()
+			return report, nil
+		}
+
+		if hdr.ID != txID {
+			report.FirstDivergentTxID = txID
+			report.Reason = fmt.Sprintf("unexpected tx id %d", hdr.ID)
+			return report, nil
+		}
+
+		if txID > 1 && hdr.PrevAlh != prevAlh {
+			report.FirstDivergentTxID = txID
+			report.Reason = "broken hash chain"
+			return report, nil
+		}
+
+		primaryTx, err := primary.ExportTx(ctx, txID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Equal(replicaTx, primaryTx) {
+			report.FirstDivergentTxID = txID
+			report.Reason = "transaction differs from primary's"
+			return report, nil
+		}
+
+		prevAlh = hdr.Alh()
+
+		report.CheckedTxs++
+
+		if progress != nil {
+			progress(txID, upToTxID)
+		}
+	}
+
+	return report, nil
+}
+
+func exportedTxHeader(etx []byte) (*store.TxHeader, error) {
+	if len(etx) < 4 {
+		return nil, ErrInvalidExportedTx
+	}
+
+	hdrLen := int(binary.BigEndian.Uint32(etx))
+
+	if len(etx) < 4+hdrLen {
+		return nil, ErrInvalidExportedTx
+	}
+
+	hdr := &store.TxHeader{}
+
+	err := hdr.ReadFrom(etx[4 : 4+hdrLen])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExportedTx, err)
+	}
+
+	return hdr, nil
+}
+
+type dbTxExporter struct {
+	db database.DB
+}
+
+func (e *dbTxExporter) ExportTx(ctx context.Context, txID uint64) ([]byte, error) {
+	etx, _, _, err := e.db.ExportTxByID(ctx, &schema.ExportTxRequest{Tx: txID})
+	return etx, err
+}
+
+type clientTxExporter struct {
+	client           client.ImmuClient
+	streamSrvFactory stream.ServiceFactory
+}
+
+func (e *clientTxExporter) ExportTx(ctx context.Context, txID uint64) ([]byte, error) {
+	exportTxStream, err := e.client.ExportTx(ctx, &schema.ExportTxRequest{Tx: txID})
+	if err != nil {
+		return nil, err
+	}
+
+	etx, err := e.streamSrvFactory.NewMsgReceiver(exportTxStream).ReadFully()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return etx, nil
+}
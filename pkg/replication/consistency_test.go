@@ -0,0 +1,174 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDB(t *testing.T, name string, replica bool) database.DB {
+	db, err := database.NewDB(name, nil, database.DefaultOption().AsReplica(replica).WithDBRootPath(t.TempDir()), logger.NewMemoryLogger())
+	require.NoError(t, err)
+
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func setTestKeys(t *testing.T, db database.DB, prefix string, count int) {
+	for i := 0; i < count; i++ {
+		_, err := db.Set(context.Background(), &schema.SetRequest{
+			KVs: []*schema.KeyValue{{Key: []byte(fmt.Sprintf("%s%d", prefix, i)), Value: []byte(fmt.Sprintf("value%d", i))}},
+		})
+		require.NoError(t, err)
+	}
+}
+
+func replicateTestTxs(t *testing.T, src, dst database.DB, fromTxID, toTxID uint64) {
+	for txID := fromTxID; txID <= toTxID; txID++ {
+		etx, _, _, err := src.ExportTxByID(context.Background(), &schema.ExportTxRequest{Tx: txID})
+		require.NoError(t, err)
+
+		_, err = dst.ReplicateTx(context.Background(), etx)
+		require.NoError(t, err)
+	}
+}
+
+type corruptingTxExporter struct {
+	TxExporter
+	corruptedTxID uint64
+	offset        int
+}
+
+func (e *corruptingTxExporter) ExportTx(ctx context.Context, txID uint64) ([]byte, error) {
+	etx, err := e.TxExporter.ExportTx(ctx, txID)
+	if err != nil || txID != e.corruptedTxID {
+		return etx, err
+	}
+
+	corrupted := make([]byte, len(etx))
+	copy(corrupted, etx)
+	corrupted[e.offset] ^= 0xFF
+
+	return corrupted, nil
+}
+
+func TestReplicaConsistency(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+	setTestKeys(t, primary, "key", 10)
+
+	primaryState, err := primary.CurrentState()
+	require.NoError(t, err)
+
+	replica := newTestDB(t, "replicadb", true)
+	replicateTestTxs(t, primary, replica, 1, primaryState.TxId)
+
+	err = replica.WaitForTx(context.Background(), primaryState.TxId, false)
+	require.NoError(t, err)
+
+	_, err = VerifyReplicaConsistency(context.Background(), nil, &dbTxExporter{db: primary}, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	t.Run("consistent replica", func(t *testing.T) {
+		var progress []uint64
+
+		report, err := VerifyReplicaConsistency(context.Background(), replica, &dbTxExporter{db: primary}, func(checkedTxID, upToTxID uint64) {
+			require.Equal(t, primaryState.TxId, upToTxID)
+			progress = append(progress, checkedTxID)
+		})
+		require.NoError(t, err)
+		require.True(t, report.Consistent())
+		require.Equal(t, primaryState.TxId, report.UpToTxID)
+		require.Equal(t, primaryState.TxId, report.CheckedTxs)
+		require.Len(t, progress, int(primaryState.TxId))
+	})
+
+	t.Run("corrupted replica transaction entries", func(t *testing.T) {
+		replicaExporter := &corruptingTxExporter{
+			TxExporter:    &dbTxExporter{db: replica},
+			corruptedTxID: 5,
+		}
+
+		etx, err := replicaExporter.TxExporter.ExportTx(context.Background(), 5)
+		require.NoError(t, err)
+
+		// corrupt last byte of the exported tx i.e. a value
+		replicaExporter.offset = len(etx) - 1
+
+		report, err := checkConsistency(context.Background(), replicaExporter, &dbTxExporter{db: primary}, primaryState.TxId, nil)
+		require.NoError(t, err)
+		require.False(t, report.Consistent())
+		require.Equal(t, uint64(5), report.FirstDivergentTxID)
+		require.Equal(t, uint64(4), report.CheckedTxs)
+	})
+
+	t.Run("corrupted replica transaction header", func(t *testing.T) {
+		replicaExporter := &corruptingTxExporter{
+			TxExporter:    &dbTxExporter{db: replica},
+			corruptedTxID: 3,
+			offset:        4 + 8, // previous alh within the header
+		}
+
+		report, err := checkConsistency(context.Background(), replicaExporter, &dbTxExporter{db: primary}, primaryState.TxId, nil)
+		require.NoError(t, err)
+		require.False(t, report.Consistent())
+		require.Equal(t, uint64(3), report.FirstDivergentTxID)
+		require.Equal(t, "broken hash chain", report.Reason)
+	})
+
+	t.Run("replica diverged from primary", func(t *testing.T) {
+		// a primary sharing only the first transactions with the original one
+		divergentPrimary := newTestDB(t, "divergentdb", true)
+		replicateTestTxs(t, primary, divergentPrimary, 1, 3)
+
+		err = divergentPrimary.WaitForTx(context.Background(), 3, false)
+		require.NoError(t, err)
+
+		divergentPrimary.AsReplica(false, false, 0)
+		setTestKeys(t, divergentPrimary, "divergentKey", 7)
+
+		divergentReplica := newTestDB(t, "divergentreplicadb", true)
+		replicateTestTxs(t, divergentPrimary, divergentReplica, 1, 10)
+
+		err = divergentReplica.WaitForTx(context.Background(), 10, false)
+		require.NoError(t, err)
+
+		report, err := VerifyReplicaConsistency(context.Background(), divergentReplica, &dbTxExporter{db: primary}, nil)
+		require.NoError(t, err)
+		require.False(t, report.Consistent())
+		require.Equal(t, uint64(4), report.FirstDivergentTxID)
+		require.Equal(t, uint64(3), report.CheckedTxs)
+	})
+
+	t.Run("cancelled check", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		_, err := VerifyReplicaConsistency(ctx, replica, &dbTxExporter{db: primary}, func(checkedTxID, upToTxID uint64) {
+			if checkedTxID == 2 {
+				cancel()
+			}
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+// DurabilityPolicy defines when replicated transactions are synced to disk.
+// Relaxed policies speed up replication but transactions not yet synced are lost on a crash,
+// such transactions are fetched again from the primary once the replication is restarted
+type DurabilityPolicy int
+
+const (
+	// FsyncEveryTx syncs every replicated transaction
+	FsyncEveryTx DurabilityPolicy = iota
+	// FsyncEveryN syncs replicated transactions in batches of the configured size
+	FsyncEveryN
+	// FsyncOnIdle syncs replicated transactions once no new transaction is replicated within the idle timeout
+	FsyncOnIdle
+	// FsyncAuto syncs in batches while catching up with the primary and every transaction once caught up
+	FsyncAuto
+)
+
+func (p DurabilityPolicy) valid() bool {
+	return p >= FsyncEveryTx && p <= FsyncAuto
+}
+
+// relaxDurability applies the durability policy when replication starts
+func (txr *TxReplicator) relaxDurability() error {
+	txr.caughtUp = false
+
+	switch txr.opts.durabilityPolicy {
+	case FsyncEveryN, FsyncAuto:
+		return txr.db.SetSyncBatch(txr.opts.fsyncBatchSize, txr.opts.fsyncIdleTimeout)
+	case FsyncOnIdle:
+		return txr.db.SetSyncBatch(0, txr.opts.fsyncIdleTimeout)
+	}
+
+	return nil
+}
+
+// markCaughtUp is called when there are no more transactions to be fetched from the primary
+func (txr *TxReplicator) markCaughtUp() {
+	if txr.caughtUp {
+		return
+	}
+
+	txr.caughtUp = true
+
+	txr.updateStatus(func(st *replicatorStatus) { st.caughtUp = true })
+
+	if txr.opts.durabilityPolicy != FsyncAuto {
+		return
+	}
+
+	err := txr.db.SetSyncBatch(1, 0)
+	if err != nil {
+		txr.logger.Warningf("Failed to tighten durability of '%s'. Reason: %s", txr.db.GetName(), err.Error())
+		txr.caughtUp = false
+		return
+	}
+
+	txr.logger.Infof("Replica '%s' caught up with '%s', every transaction is synced from now on", txr.db.GetName(), txr._primaryDB)
+}
+
+// restoreDurability syncs any pending transaction when replication stops
+func (txr *TxReplicator) restoreDurability() {
+	if txr.opts.durabilityPolicy == FsyncEveryTx {
+		return
+	}
+
+	err := txr.db.SetSyncBatch(1, 0)
+	if err != nil {
+		txr.logger.Warningf("Failed to restore durability of '%s'. Reason: %s", txr.db.GetName(), err.Error())
+	}
+}
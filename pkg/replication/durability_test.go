@@ -0,0 +1,147 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func committedTxID(t *testing.T, db database.DB) uint64 {
+	state, err := db.CurrentState()
+	require.NoError(t, err)
+
+	return state.TxId
+}
+
+func TestDurabilityPolicy(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+	setTestKeys(t, primary, "key", 20)
+
+	newReplicator := func(t *testing.T, replica database.DB, policy DurabilityPolicy) *TxReplicator {
+		opts := DefaultOptions().
+			WithDurabilityPolicy(policy).
+			WithFsyncBatchSize(5).
+			WithFsyncIdleTimeout(time.Hour)
+
+		txr, err := NewTxReplicator(xid.New(), replica, opts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		err = txr.relaxDurability()
+		require.NoError(t, err)
+
+		return txr
+	}
+
+	t.Run("every transaction should be synced by default", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+		txr := newReplicator(t, replica, FsyncEveryTx)
+
+		replicateTestTxs(t, primary, replica, 1, 3)
+		require.Equal(t, uint64(3), committedTxID(t, replica))
+
+		txr.markCaughtUp()
+		txr.restoreDurability()
+		require.Equal(t, uint64(3), committedTxID(t, replica))
+	})
+
+	t.Run("transactions should be synced in batches", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+		txr := newReplicator(t, replica, FsyncEveryN)
+
+		replicateTestTxs(t, primary, replica, 1, 4)
+
+		time.Sleep(100 * time.Millisecond)
+		require.Zero(t, committedTxID(t, replica))
+
+		replicateTestTxs(t, primary, replica, 5, 5)
+		require.Eventually(t, func() bool { return committedTxID(t, replica) == 5 }, 5*time.Second, 10*time.Millisecond)
+
+		// caught up replicas keep syncing in batches
+		txr.markCaughtUp()
+
+		replicateTestTxs(t, primary, replica, 6, 9)
+
+		time.Sleep(100 * time.Millisecond)
+		require.Equal(t, uint64(5), committedTxID(t, replica))
+
+		replicateTestTxs(t, primary, replica, 10, 10)
+		require.Eventually(t, func() bool { return committedTxID(t, replica) == 10 }, 5*time.Second, 10*time.Millisecond)
+
+		replicateTestTxs(t, primary, replica, 11, 12)
+
+		txr.restoreDurability()
+		require.Equal(t, uint64(12), committedTxID(t, replica))
+	})
+
+	t.Run("transactions should be synced when idle", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+
+		opts := DefaultOptions().
+			WithDurabilityPolicy(FsyncOnIdle).
+			WithFsyncIdleTimeout(200 * time.Millisecond)
+
+		txr, err := NewTxReplicator(xid.New(), replica, opts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		err = txr.relaxDurability()
+		require.NoError(t, err)
+
+		replicateTestTxs(t, primary, replica, 1, 12)
+		require.Less(t, committedTxID(t, replica), uint64(12))
+
+		require.Eventually(t, func() bool { return committedTxID(t, replica) == 12 }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("automatic policy should tighten durability once caught up", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+		txr := newReplicator(t, replica, FsyncAuto)
+
+		replicateTestTxs(t, primary, replica, 1, 7)
+		require.Eventually(t, func() bool { return committedTxID(t, replica) >= 5 }, 5*time.Second, 10*time.Millisecond)
+
+		replicateTestTxs(t, primary, replica, 8, 8)
+
+		time.Sleep(100 * time.Millisecond)
+		require.Less(t, committedTxID(t, replica), uint64(8))
+
+		txr.markCaughtUp()
+		require.Equal(t, uint64(8), committedTxID(t, replica))
+
+		replicateTestTxs(t, primary, replica, 9, 9)
+		require.Equal(t, uint64(9), committedTxID(t, replica))
+	})
+
+	t.Run("relaxed durability requires a replica", func(t *testing.T) {
+		opts := DefaultOptions().WithDurabilityPolicy(FsyncAuto)
+
+		txr, err := NewTxReplicator(xid.New(), primary, opts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		err = txr.Start()
+		require.ErrorIs(t, err, database.ErrNotReplica)
+
+		err = txr.Stop()
+		require.ErrorIs(t, err, ErrAlreadyStopped)
+	})
+
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import "time"
+
+// EventType identifies the kind of a replication event
+type EventType string
+
+const (
+	// EventPrecommitDivergence is emitted when the precommit state of a synchronous replica
+	// diverged from the primary's, see Event.Outcome for how the replica recovered from it
+	EventPrecommitDivergence EventType = "precommit_divergence"
+	// EventCaughtUp is emitted when the transactions committed by the replica reach the ones committed by
+	// the primary after catching up, the replica is then following the primary live
+	EventCaughtUp EventType = "caught_up"
+	// EventFellBehind is emitted when a replica which caught up lags behind the primary by more than
+	// the configured threshold, EventCaughtUp is emitted again once it catches up
+	EventFellBehind EventType = "fell_behind"
+)
+
+// EventOutcome describes how the replication proceeded after an event
+type EventOutcome string
+
+const (
+	// OutcomeRecovered means the replication continues, e.g. after discarding the divergent precommitted transactions
+	OutcomeRecovered EventOutcome = "recovered"
+	// OutcomeRejected means the replication can not continue as the recovery is not allowed, e.g. tx discarding is disabled
+	OutcomeRejected EventOutcome = "rejected"
+	// OutcomeFailed means the recovery was attempted but failed, it's attempted again when the replication is retried
+	OutcomeFailed EventOutcome = "failed"
+)
+
+// Event describes a noteworthy occurrence during the replication. Events are emitted once per occurrence,
+// separately from the logging of failures, so they can be audited
+type Event struct {
+	Type EventType
+	// At is the time the event occurred
+	At time.Time
+	// TxID is the transaction the event refers to, for EventPrecommitDivergence
+	// the last transaction precommitted by the replica, otherwise the last one it committed
+	TxID uint64
+	// Lag is the number of transactions committed by the primary but not yet by the replica
+	Lag uint64
+	// DiscardedTxs is the number of precommitted transactions discarded by the replica
+	DiscardedTxs uint64
+	Outcome      EventOutcome
+	// Err is the cause of the event, or of the failed recovery
+	Err error
+}
+
+// EventHandler is called synchronously by the replicator, thus it should return promptly
+type EventHandler func(event *Event)
+
+func (txr *TxReplicator) emitEvent(event *Event) {
+	if txr.opts.eventHandler == nil {
+		return
+	}
+
+	event.At = time.Now()
+
+	txr.opts.eventHandler(event)
+}
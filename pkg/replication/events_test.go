@@ -0,0 +1,129 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrecommitDivergenceEvent(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+	setTestKeys(t, primary, "key", 5)
+
+	newSyncReplica := func(t *testing.T) database.DB {
+		replica, err := database.NewDB("replicadb", nil,
+			database.DefaultOption().AsReplica(true).WithSyncReplication(true).WithDBRootPath(t.TempDir()),
+			logger.NewMemoryLogger(),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { replica.Close() })
+
+		replicateTestTxs(t, primary, replica, 1, 5)
+
+		// only the first transactions are committed, the remaining ones are discarded on divergence
+		etx, _, _, err := primary.ExportTxByID(context.Background(), &schema.ExportTxRequest{Tx: 2})
+		require.NoError(t, err)
+
+		hdr, err := exportedTxHeader(etx)
+		require.NoError(t, err)
+
+		err = replica.AllowCommitUpto(2, hdr.Alh())
+		require.NoError(t, err)
+
+		err = replica.WaitForTx(context.Background(), 2, false)
+		require.NoError(t, err)
+
+		return replica
+	}
+
+	newReplicator := func(t *testing.T, replica database.DB, allowTxDiscarding bool) (*TxReplicator, *[]*Event) {
+		var events []*Event
+
+		rOpts := DefaultOptions().
+			WithPrimaryDatabase("primarydb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(3322).
+			WithAllowTxDiscarding(allowTxDiscarding).
+			WithEventHandler(func(event *Event) {
+				events = append(events, event)
+			})
+
+		txReplicator, err := NewTxReplicator(xid.New(), replica, rOpts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		return txReplicator, &events
+	}
+
+	cause := errors.New("replica precommit state diverged from primary's")
+
+	t.Run("divergent precommitted txs should be discarded and reported", func(t *testing.T) {
+		replica := newSyncReplica(t)
+
+		state, err := replica.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), state.TxId)
+		require.Equal(t, uint64(5), state.PrecommittedTxId)
+
+		txReplicator, events := newReplicator(t, replica, true)
+
+		progress, err := txReplicator.handlePrecommitDivergence(state, state.PrecommittedTxId+1, cause)
+		require.NoError(t, err)
+		require.True(t, progress)
+
+		require.Len(t, *events, 1)
+
+		event := (*events)[0]
+		require.Equal(t, EventPrecommitDivergence, event.Type)
+		require.Equal(t, uint64(5), event.TxID)
+		require.Equal(t, uint64(3), event.DiscardedTxs)
+		require.Equal(t, OutcomeRecovered, event.Outcome)
+		require.Equal(t, cause, event.Err)
+		require.False(t, event.At.IsZero())
+
+		// transactions are fetched again from the first discarded one
+		require.Equal(t, state.TxId, txReplicator.lastTx)
+	})
+
+	t.Run("divergence should be reported when tx discarding is not allowed", func(t *testing.T) {
+		replica := newSyncReplica(t)
+
+		state, err := replica.CurrentState()
+		require.NoError(t, err)
+
+		txReplicator, events := newReplicator(t, replica, false)
+
+		_, err = txReplicator.handlePrecommitDivergence(state, state.PrecommittedTxId+1, cause)
+		require.ErrorIs(t, err, ErrReplicaDivergedFromPrimary)
+
+		require.Len(t, *events, 1)
+		require.Equal(t, EventPrecommitDivergence, (*events)[0].Type)
+		require.Equal(t, OutcomeRejected, (*events)[0].Outcome)
+		require.Zero(t, (*events)[0].DiscardedTxs)
+
+		newState, err := replica.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, state.PrecommittedTxId, newState.PrecommittedTxId)
+	})
+}
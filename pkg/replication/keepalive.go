@@ -0,0 +1,94 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrIdleConnectionLost = errors.New("idle connection to the primary lost")
+
+// idleExportContext bounds fetching from the primary while idle by the probe interval. A connection
+// silently dropped, e.g. by a NAT or firewall timeout, would otherwise block fetching indefinitely
+func (txr *TxReplicator) idleExportContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if txr.opts.idleProbeInterval == 0 || txr.idleSince.IsZero() {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, txr.opts.idleProbeInterval)
+}
+
+// markActive is called when a transaction is fetched, the connection is only probed while idle
+func (txr *TxReplicator) markActive() {
+	txr.idleSince = time.Time{}
+}
+
+// probeIdleConnection reads the state of the primary once per probe interval while there are no
+// transactions to be fetched, the connection is re-established if the primary does not answer in time
+func (txr *TxReplicator) probeIdleConnection() error {
+	if txr.opts.idleProbeInterval == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	if txr.idleSince.IsZero() {
+		txr.idleSince = now
+		txr.lastProbeAt = now
+		return nil
+	}
+
+	if now.Sub(txr.lastProbeAt) < txr.opts.idleProbeInterval {
+		return nil
+	}
+
+	txr.lastProbeAt = now
+
+	ctx, cancel := context.WithTimeout(txr.context, txr.opts.idleProbeInterval)
+	defer cancel()
+
+	_, err := txr.client.CurrentState(ctx)
+	if err != nil {
+		return txr.idleConnectionLost(err)
+	}
+
+	return nil
+}
+
+// idleConnectionLost closes the connection to the primary without waiting for it to answer,
+// so that it's established again on the next fetch
+func (txr *TxReplicator) idleConnectionLost(reason error) error {
+	err := fmt.Errorf("%w: %v", ErrIdleConnectionLost, reason)
+
+	txr.logger.Warningf("Connection to '%s' for database '%s' was lost while idle, reconnecting... Reason: %s",
+		txr.opts.primaryAddress(), txr.db.GetName(), reason.Error())
+
+	ctx, cancel := context.WithTimeout(txr.context, txr.opts.idleProbeInterval)
+	defer cancel()
+
+	// the session is closed first with a bounded wait, closing it again once disconnected returns right away
+	txr.client.CloseSession(ctx)
+
+	txr.disconnect()
+
+	txr.idleSince = time.Time{}
+
+	return err
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+// trackLiveness emits EventCaughtUp the first time the transactions committed by the replica reach the latest
+// one known to be committed by the primary, once there are no more transactions to be fetched from it.
+// EventFellBehind is then emitted if the replica lags behind by more than the configured threshold, after
+// which EventCaughtUp is emitted again once it catches up
+func (txr *TxReplicator) trackLiveness() {
+	if txr.opts.eventHandler == nil || txr.opts.verifyOnly {
+		return
+	}
+
+	state, err := txr.db.CurrentState()
+	if err != nil {
+		return
+	}
+
+	var event *Event
+
+	txr.updateStatus(func(st *replicatorStatus) {
+		event = st.livenessTransition(state.TxId, uint64(txr.opts.fellBehindThreshold))
+	})
+
+	if event != nil {
+		txr.emitEvent(event)
+	}
+}
+
+// livenessTransition updates whether the replica is following the primary live given the transaction
+// it committed, it returns the event to be emitted if that changed
+func (st *replicatorStatus) livenessTransition(replicaTxID uint64, fellBehindThreshold uint64) *Event {
+	primaryTxID := st.primaryTxID()
+
+	var lag uint64
+	if primaryTxID > replicaTxID {
+		lag = primaryTxID - replicaTxID
+	}
+
+	if !st.live {
+		// the primary's latest transaction is only known once there are no more transactions to be fetched
+		if !st.caughtUp || lag > 0 {
+			return nil
+		}
+
+		st.live = true
+
+		return &Event{Type: EventCaughtUp, TxID: replicaTxID}
+	}
+
+	if fellBehindThreshold == 0 || lag <= fellBehindThreshold {
+		return nil
+	}
+
+	st.live = false
+
+	return &Event{Type: EventFellBehind, TxID: replicaTxID, Lag: lag}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLivenessEvents(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+	setTestKeys(t, primary, "key", 5)
+
+	replica := newTestDB(t, "replicadb", true)
+
+	var events []*Event
+
+	rOpts := DefaultOptions().
+		WithPrimaryDatabase("primarydb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(3322).
+		WithFellBehindThreshold(2).
+		WithEventHandler(func(event *Event) {
+			events = append(events, event)
+		})
+
+	txReplicator, err := NewTxReplicator(xid.New(), replica, rOpts, logger.NewMemoryLogger())
+	require.NoError(t, err)
+
+	txReplicator.context, txReplicator.cancelFunc = context.WithCancel(context.Background())
+	t.Cleanup(txReplicator.cancelFunc)
+
+	applyTxs := func(t *testing.T, fromTxID, toTxID uint64) {
+		for txID := fromTxID; txID <= toTxID; txID++ {
+			etx, _, _, err := primary.ExportTxByID(context.Background(), &schema.ExportTxRequest{Tx: txID})
+			require.NoError(t, err)
+
+			require.True(t, txReplicator.replicateSingleTx(context.Background(), etx))
+		}
+	}
+
+	// fetched the transactions committed by the primary
+	fetched := func(txID uint64) {
+		txReplicator.updateStatus(func(st *replicatorStatus) { st.lastFetchedTxID = txID })
+		txReplicator.trackLiveness()
+	}
+
+	t.Run("caught up event should not be emitted while transactions are still being fetched", func(t *testing.T) {
+		fetched(3)
+		applyTxs(t, 1, 3)
+
+		require.Empty(t, events)
+	})
+
+	t.Run("caught up event should be emitted once the replica commits the primary's latest transaction", func(t *testing.T) {
+		fetched(5)
+		txReplicator.updateStatus(func(st *replicatorStatus) { st.caughtUp = true })
+
+		applyTxs(t, 4, 4)
+		require.Empty(t, events)
+
+		applyTxs(t, 5, 5)
+		require.Len(t, events, 1)
+		require.Equal(t, EventCaughtUp, events[0].Type)
+		require.Equal(t, uint64(5), events[0].TxID)
+		require.Zero(t, events[0].Lag)
+		require.False(t, events[0].At.IsZero())
+
+		// the event is emitted only once while following the primary
+		txReplicator.trackLiveness()
+		fetched(5)
+		require.Len(t, events, 1)
+	})
+
+	t.Run("fell behind event should be emitted when lagging by more than the threshold", func(t *testing.T) {
+		setTestKeys(t, primary, "key", 3)
+
+		fetched(7)
+		require.Len(t, events, 1)
+
+		fetched(8)
+		require.Len(t, events, 2)
+		require.Equal(t, EventFellBehind, events[1].Type)
+		require.Equal(t, uint64(5), events[1].TxID)
+		require.Equal(t, uint64(3), events[1].Lag)
+
+		fetched(8)
+		require.Len(t, events, 2)
+	})
+
+	t.Run("caught up event should be emitted again after falling behind", func(t *testing.T) {
+		applyTxs(t, 6, 7)
+		require.Len(t, events, 2)
+
+		applyTxs(t, 8, 8)
+		require.Len(t, events, 3)
+		require.Equal(t, EventCaughtUp, events[2].Type)
+		require.Equal(t, uint64(8), events[2].TxID)
+	})
+}
+
+func TestLivenessTransitionWithoutThreshold(t *testing.T) {
+	st := &replicatorStatus{caughtUp: true, lastFetchedTxID: 10}
+
+	event := st.livenessTransition(10, 0)
+	require.NotNil(t, event)
+	require.Equal(t, EventCaughtUp, event.Type)
+
+	st.lastFetchedTxID = 1000
+
+	require.Nil(t, st.livenessTransition(10, 0))
+	require.True(t, st.live)
+}
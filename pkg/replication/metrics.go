@@ -56,6 +56,11 @@ var (
 		Help: "number of retries while replicating transactions caused by errors",
 	}, []string{"db"})
 
+	_metricsReplicationIdlePolls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "immudb_replication_idle_polls",
+		Help: "number of fetches from the primary that found no new transaction to replicate",
+	}, []string{"db"})
+
 	_metricsReplicationPrimaryCommittedTxID = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "immudb_replication_primary_committed_tx_id",
 		Help: "the latest know transaction ID committed on the primary node",
@@ -71,6 +76,7 @@ type metrics struct {
 	txWaitQueueHistogram     prometheus.Observer
 	replicationTimeHistogram prometheus.Observer
 	replicationRetries       prometheus.Counter
+	idlePolls                prometheus.Counter
 	replicators              prometheus.Gauge
 	replicatorsActive        prometheus.Gauge
 	replicatorsInRetryDelay  prometheus.Gauge
@@ -84,6 +90,7 @@ func metricsForDb(dbName string) metrics {
 		txWaitQueueHistogram:     _metricsTxWaitQueueHistogram.WithLabelValues(dbName),
 		replicationTimeHistogram: _metricsReplicationTimeHistogram.WithLabelValues(dbName),
 		replicationRetries:       _metricsReplicationRetries.WithLabelValues(dbName),
+		idlePolls:                _metricsReplicationIdlePolls.WithLabelValues(dbName),
 		replicators:              _metricsReplicators.WithLabelValues(dbName),
 		replicatorsActive:        _metricsReplicatorsActive.WithLabelValues(dbName),
 		replicatorsInRetryDelay:  _metricsReplicatorsInRetryDelay.WithLabelValues(dbName),
@@ -0,0 +1,84 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"fmt"
+	"strings"
+)
+
+var metricsTextLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// MetricsText returns the current state of the replication in the OpenMetrics text format,
+// so it can be exposed without depending on a metrics library. Counters are reset when the
+// replication is started again
+func (txr *TxReplicator) MetricsText() string {
+	var replicaTxID uint64
+
+	state, err := txr.db.CurrentState()
+	if err == nil {
+		replicaTxID = state.TxId
+	}
+
+	st := txr.currentStatus()
+
+	primaryTxID := st.primaryTxID()
+
+	var lag uint64
+	if primaryTxID > replicaTxID {
+		lag = primaryTxID - replicaTxID
+	}
+
+	labels := fmt.Sprintf(`{db="%s",primary="%s"}`,
+		metricsTextLabelEscaper.Replace(txr.db.GetName()),
+		metricsTextLabelEscaper.Replace(txr._primaryDB),
+	)
+
+	var sb strings.Builder
+
+	writeMetric := func(name, metricType, help string, value interface{}) {
+		sample := name
+		if metricType == "counter" {
+			sample += "_total"
+		}
+
+		fmt.Fprintf(&sb, "# TYPE %s %s\n", name, metricType)
+		fmt.Fprintf(&sb, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(&sb, "%s%s %v\n", sample, labels, value)
+	}
+
+	writeMetric("immudb_replication_connected", "gauge", "Whether the replica is connected to the primary.", boolToInt(st.connected))
+	writeMetric("immudb_replication_caught_up", "gauge", "Whether the replica has caught up with the primary.", boolToInt(st.caughtUp))
+	writeMetric("immudb_replication_lag_txs", "gauge", "Number of transactions committed by the primary not yet committed by the replica.", lag)
+	writeMetric("immudb_replication_replica_committed_tx_id", "gauge", "Latest transaction committed by the replica.", replicaTxID)
+	writeMetric("immudb_replication_primary_committed_tx_id", "gauge", "Latest transaction known to be committed by the primary.", primaryTxID)
+	writeMetric("immudb_replication_last_fetched_tx_id", "gauge", "Latest transaction fetched from the primary.", st.lastFetchedTxID)
+	writeMetric("immudb_replication_applied_txs", "counter", "Number of transactions applied since the replication started.", st.appliedTxs)
+	writeMetric("immudb_replication_failures", "counter", "Number of failed attempts to replicate since the replication started.", st.failures)
+	writeMetric("immudb_replication_consecutive_failures", "gauge", "Number of failed attempts to replicate since the last successful one.", st.consecutiveFailures)
+
+	sb.WriteString("# EOF\n")
+
+	return sb.String()
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	openMetricsNameRegexp   = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	openMetricsSampleRegexp = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{([^}]*)\})? (\S+)$`)
+	openMetricsLabelsRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\\n]|\\.)*"(,[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\\n]|\\.)*")*$`)
+)
+
+// parseOpenMetricsText validates the OpenMetrics text exposition of gauges and counters,
+// and returns the value of every sample by sample name
+func parseOpenMetricsText(t *testing.T, text string) map[string]float64 {
+	require.True(t, strings.HasSuffix(text, "# EOF\n"), "the exposition must end with '# EOF'")
+
+	lines := strings.Split(strings.TrimSuffix(text, "# EOF\n"), "\n")
+	require.Equal(t, "", lines[len(lines)-1])
+
+	samples := make(map[string]float64)
+	families := make(map[string]string)
+
+	var family string
+
+	for _, line := range lines[:len(lines)-1] {
+		if strings.HasPrefix(line, "# TYPE ") {
+			fields := strings.SplitN(strings.TrimPrefix(line, "# TYPE "), " ", 2)
+			require.Len(t, fields, 2, line)
+			require.Regexp(t, openMetricsNameRegexp, fields[0])
+			require.Contains(t, []string{"gauge", "counter"}, fields[1])
+
+			_, duplicated := families[fields[0]]
+			require.False(t, duplicated, "duplicated metric family %s", fields[0])
+
+			family = fields[0]
+			families[family] = fields[1]
+			continue
+		}
+
+		if strings.HasPrefix(line, "# HELP ") {
+			fields := strings.SplitN(strings.TrimPrefix(line, "# HELP "), " ", 2)
+			require.Len(t, fields, 2, line)
+			require.Equal(t, family, fields[0], "help of a metric family must follow its type")
+			continue
+		}
+
+		m := openMetricsSampleRegexp.FindStringSubmatch(line)
+		require.NotNil(t, m, "invalid sample: %s", line)
+
+		name := m[1]
+
+		if families[family] == "counter" {
+			require.Equal(t, family+"_total", name)
+		} else {
+			require.Equal(t, family, name)
+		}
+
+		if m[3] != "" {
+			require.Regexp(t, openMetricsLabelsRegexp, m[3])
+		}
+
+		v, err := strconv.ParseFloat(m[4], 64)
+		require.NoError(t, err)
+
+		samples[name] = v
+	}
+
+	return samples
+}
+
+func TestMetricsText(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+	replica := newTestDB(t, "replicadb", true)
+
+	setTestKeys(t, primary, "key", 4)
+	replicateTestTxs(t, primary, replica, 1, 3)
+
+	rOpts := DefaultOptions().
+		WithPrimaryDatabase(`prim"arydb`).
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(3322)
+
+	txReplicator, err := NewTxReplicator(xid.New(), replica, rOpts, logger.NewMemoryLogger())
+	require.NoError(t, err)
+
+	txReplicator.updateStatus(func(st *replicatorStatus) {
+		st.connected = true
+		st.lastFetchedTxID = 4
+		st.primaryCommittedTxID = 5
+		st.appliedTxs = 3
+		st.failures = 2
+		st.consecutiveFailures = 1
+	})
+
+	text := txReplicator.MetricsText()
+
+	require.Contains(t, text, `{db="replicadb",primary="prim\"arydb@127.0.0.1:3322"}`)
+
+	samples := parseOpenMetricsText(t, text)
+
+	require.Equal(t, map[string]float64{
+		"immudb_replication_connected":               1,
+		"immudb_replication_caught_up":               0,
+		"immudb_replication_lag_txs":                 2,
+		"immudb_replication_replica_committed_tx_id": 3,
+		"immudb_replication_primary_committed_tx_id": 5,
+		"immudb_replication_last_fetched_tx_id":      4,
+		"immudb_replication_applied_txs_total":       3,
+		"immudb_replication_failures_total":          2,
+		"immudb_replication_consecutive_failures":    1,
+	}, samples)
+
+	t.Run("metrics should follow the state of the replication", func(t *testing.T) {
+		replicateTestTxs(t, primary, replica, 4, 5)
+
+		txReplicator.updateStatus(func(st *replicatorStatus) {
+			st.connected = false
+			st.caughtUp = true
+			st.appliedTxs += 2
+		})
+
+		samples := parseOpenMetricsText(t, txReplicator.MetricsText())
+		require.Zero(t, samples["immudb_replication_connected"])
+		require.Equal(t, float64(1), samples["immudb_replication_caught_up"])
+		require.Zero(t, samples["immudb_replication_lag_txs"])
+		require.Equal(t, float64(5), samples["immudb_replication_applied_txs_total"])
+	})
+}
@@ -16,20 +16,39 @@ limitations under the License.
 
 package replication
 
-import "time"
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
 
 const DefaultChunkSize int = 64 * 1024 // 64 * 1024 64 KiB
 const DefaultPrefetchTxBufferSize int = 100
 const DefaultReplicationCommitConcurrency int = 10
 const DefaultAllowTxDiscarding = false
+const DefaultReadyTimeout = 30 * time.Second
+const DefaultDurabilityPolicy = FsyncEveryTx
+const DefaultFsyncBatchSize = 100
+const DefaultFsyncIdleTimeout = 100 * time.Millisecond
+const DefaultAckBatchSize = 1
+const DefaultApplyRetries = 3
+const DefaultApplyRetryDelay = 100 * time.Millisecond
+const DefaultStorageHeadroom = 0.2
+const DefaultFellBehindThreshold = 1000
 
 type Options struct {
 	primaryDatabase string
 	primaryHost     string
 	primaryPort     int
+	unixSocket      string
 	primaryUsername string
 	primaryPassword string
 
+	tlsConfig     *tls.Config
+	tlsServerName string
+
 	streamChunkSize int
 
 	prefetchTxBufferSize         int
@@ -38,6 +57,69 @@ type Options struct {
 	allowTxDiscarding bool
 
 	delayer Delayer
+
+	statusLogInterval time.Duration
+
+	readyTimeout time.Duration
+
+	primaryUUIDCheck bool
+
+	checkpointPersistence bool
+
+	durabilityPolicy DurabilityPolicy
+	fsyncBatchSize   int
+	fsyncIdleTimeout time.Duration
+
+	ackBatchSize     int
+	ackBatchInterval time.Duration
+
+	idlePollInterval  time.Duration
+	idleProbeInterval time.Duration
+
+	maxTxPerSecond int
+
+	applyRetries    int
+	applyRetryDelay time.Duration
+
+	applyDelay time.Duration
+
+	schedule              []ScheduleWindow
+	outsideSchedulePolicy OutsideSchedulePolicy
+
+	storagePreflight bool
+	storageHeadroom  float64
+	storageProbe     StorageProbe
+
+	clientPool *ClientPool
+
+	tracer     Tracer
+	propagator Propagator
+
+	verifyOnly   bool
+	alertHandler AlertHandler
+
+	eventHandler EventHandler
+
+	fellBehindThreshold int
+
+	primaryDatabaseMissingPolicy PrimaryDatabaseMissingPolicy
+	primaryDatabaseAlias         string
+
+	applyTransform ApplyTransform
+
+	shadowApplier ShadowApplier
+
+	preferredSource       string
+	preferredSourceMaxLag uint64
+
+	applyCoalesceMaxTxs   int
+	applyCoalesceMaxBytes int
+
+	leastPrivilegeCheck bool
+
+	databaseReset DatabaseResetFunc
+
+	payloadChecksum bool
 }
 
 func DefaultOptions() *Options {
@@ -54,6 +136,15 @@ func DefaultOptions() *Options {
 		prefetchTxBufferSize:         DefaultPrefetchTxBufferSize,
 		replicationCommitConcurrency: DefaultReplicationCommitConcurrency,
 		allowTxDiscarding:            DefaultAllowTxDiscarding,
+		readyTimeout:                 DefaultReadyTimeout,
+		durabilityPolicy:             DefaultDurabilityPolicy,
+		fsyncBatchSize:               DefaultFsyncBatchSize,
+		fsyncIdleTimeout:             DefaultFsyncIdleTimeout,
+		ackBatchSize:                 DefaultAckBatchSize,
+		applyRetries:                 DefaultApplyRetries,
+		applyRetryDelay:              DefaultApplyRetryDelay,
+		fellBehindThreshold:          DefaultFellBehindThreshold,
+		storageHeadroom:              DefaultStorageHeadroom,
 	}
 }
 
@@ -62,7 +153,34 @@ func (opts *Options) Valid() bool {
 		opts.streamChunkSize > 0 &&
 		opts.prefetchTxBufferSize > 0 &&
 		opts.replicationCommitConcurrency > 0 &&
-		opts.delayer != nil
+		opts.delayer != nil &&
+		opts.statusLogInterval >= 0 &&
+		opts.readyTimeout > 0 &&
+		opts.durabilityPolicy.valid() &&
+		opts.fsyncBatchSize > 0 &&
+		opts.fsyncIdleTimeout > 0 &&
+		opts.ackBatchSize > 0 &&
+		opts.ackBatchInterval >= 0 &&
+		opts.idlePollInterval >= 0 &&
+		opts.idleProbeInterval >= 0 &&
+		opts.maxTxPerSecond >= 0 &&
+		opts.applyRetries >= 0 &&
+		opts.applyRetryDelay >= 0 &&
+		opts.applyDelay >= 0 &&
+		opts.fellBehindThreshold >= 0 &&
+		opts.outsideSchedulePolicy.valid() &&
+		opts.validSchedule() &&
+		opts.storageHeadroom >= 0 &&
+		opts.primaryDatabaseMissingPolicy.valid() &&
+		(opts.tlsServerName == "" || opts.tlsConfig != nil) &&
+		(opts.preferredSource == "" || opts.validPreferredSource()) &&
+		opts.applyCoalesceMaxTxs >= 0 &&
+		opts.applyCoalesceMaxBytes >= 0 &&
+		(opts.applyCoalesceMaxTxs <= 1 || (!opts.verifyOnly && opts.applyTransform == nil)) &&
+		(opts.primaryDatabaseAlias != "") == (opts.primaryDatabaseMissingPolicy == FollowAliasOnPrimaryDatabaseMissing) &&
+		(!opts.verifyOnly || opts.durabilityPolicy == FsyncEveryTx) &&
+		(!opts.verifyOnly || opts.applyTransform == nil) &&
+		(opts.shadowApplier == nil || (!opts.verifyOnly && opts.applyTransform == nil))
 }
 
 // WithPrimaryDatabase sets the source database name
@@ -83,6 +201,29 @@ func (o *Options) WithPrimaryPort(primaryPort int) *Options {
 	return o
 }
 
+// WithUnixSocket sets the path of the Unix domain socket used to connect to the primary,
+// primary host and port are not used when set
+func (o *Options) WithUnixSocket(unixSocket string) *Options {
+	o.unixSocket = unixSocket
+	return o
+}
+
+// WithTLS sets the TLS configuration used to connect to the primary, e.g. the CAs trusted to verify its certificate.
+// Connections to the primary are not encrypted when unset
+func (o *Options) WithTLS(tlsConfig *tls.Config) *Options {
+	o.tlsConfig = tlsConfig
+	return o
+}
+
+// WithTLSServerName overrides the name used to verify the certificate of the primary, which is otherwise
+// verified against the primary host. It is meant for primaries reached through an IP address or a load balancer
+// whose name is not in their certificate: the name must match a subject alternative name of the certificate,
+// as certificate verification is not disabled. It requires TLS to be enabled
+func (o *Options) WithTLSServerName(serverName string) *Options {
+	o.tlsServerName = serverName
+	return o
+}
+
 // WithPrimaryUsername sets username used for replication
 func (o *Options) WithPrimaryUsername(primaryUsername string) *Options {
 	o.primaryUsername = primaryUsername
@@ -124,3 +265,283 @@ func (o *Options) WithDelayer(delayer Delayer) *Options {
 	o.delayer = delayer
 	return o
 }
+
+// WithStatusLogInterval sets the interval at which a replication status summary is logged, zero disables it
+func (o *Options) WithStatusLogInterval(statusLogInterval time.Duration) *Options {
+	o.statusLogInterval = statusLogInterval
+	return o
+}
+
+// WithReadyTimeout sets how long StartAndWaitReady waits for the primary to be reachable
+func (o *Options) WithReadyTimeout(readyTimeout time.Duration) *Options {
+	o.readyTimeout = readyTimeout
+	return o
+}
+
+// WithPrimaryUUIDCheck pins the UUID of the primary server on first connection,
+// replication is halted if a later connection is established with a server with a different UUID
+func (o *Options) WithPrimaryUUIDCheck(primaryUUIDCheck bool) *Options {
+	o.primaryUUIDCheck = primaryUUIDCheck
+	return o
+}
+
+// WithCheckpointPersistence keeps the replication state, i.e. the pinned primary UUID and the last committed transaction,
+// in a checkpoint file within the directory of the replica database. Replication does not start if the replica
+// no longer holds the transaction recorded in the checkpoint
+func (o *Options) WithCheckpointPersistence(checkpointPersistence bool) *Options {
+	o.checkpointPersistence = checkpointPersistence
+	return o
+}
+
+// WithDurabilityPolicy sets when replicated transactions are synced to disk
+func (o *Options) WithDurabilityPolicy(durabilityPolicy DurabilityPolicy) *Options {
+	o.durabilityPolicy = durabilityPolicy
+	return o
+}
+
+// WithFsyncBatchSize sets the number of transactions synced at once when durability is relaxed
+func (o *Options) WithFsyncBatchSize(fsyncBatchSize int) *Options {
+	o.fsyncBatchSize = fsyncBatchSize
+	return o
+}
+
+// WithFsyncIdleTimeout sets how long pending transactions wait for new ones before being synced when durability is relaxed
+func (o *Options) WithFsyncIdleTimeout(fsyncIdleTimeout time.Duration) *Options {
+	o.fsyncIdleTimeout = fsyncIdleTimeout
+	return o
+}
+
+// WithAckBatch sets how commits allowed by the primary are acknowledged on synchronous replication.
+// The replica commits once every batchSize transactions or once interval has elapsed since the
+// first pending acknowledgment, whichever comes first. Zero interval disables the time bound.
+// Pending acknowledgments are always done when there are no more transactions to be fetched
+func (o *Options) WithAckBatch(batchSize int, interval time.Duration) *Options {
+	o.ackBatchSize = batchSize
+	o.ackBatchInterval = interval
+	return o
+}
+
+// WithIdlePollInterval sets how long the replicator waits before fetching again from the primary
+// when the last fetch found no new transaction. Zero means fetching again right away
+func (o *Options) WithIdlePollInterval(idlePollInterval time.Duration) *Options {
+	o.idlePollInterval = idlePollInterval
+	return o
+}
+
+// WithIdleProbeInterval sets how often the connection to the primary is probed while there are no transactions
+// to be fetched. A connection silently dropped, e.g. by a NAT or firewall timeout, is detected when the primary
+// does not answer within the interval, and it's then re-established. The interval should be longer than
+// the primary waits for new transactions before answering an export request. Zero disables probing
+func (o *Options) WithIdleProbeInterval(idleProbeInterval time.Duration) *Options {
+	o.idleProbeInterval = idleProbeInterval
+	return o
+}
+
+// WithMaxTxPerSecond limits the number of transactions fetched from the primary per second,
+// so the replica paces itself regardless of the size of the transactions. Zero means unlimited
+func (o *Options) WithMaxTxPerSecond(maxTxPerSecond int) *Options {
+	o.maxTxPerSecond = maxTxPerSecond
+	return o
+}
+
+// WithApplyRetries sets how many times a transaction that failed to be applied is retried in place,
+// waiting retries times delay between attempts. Once retries are exhausted, the connection to the
+// primary is re-established and the transaction keeps being retried with the replication backoff
+func (o *Options) WithApplyRetries(retries int, delay time.Duration) *Options {
+	o.applyRetries = retries
+	o.applyRetryDelay = delay
+	return o
+}
+
+// WithApplyDelay keeps the replica intentionally behind the primary: transactions are not applied until
+// delay has elapsed since they were committed by the primary, see applyDelayDeadline. Zero disables it
+func (o *Options) WithApplyDelay(delay time.Duration) *Options {
+	o.applyDelay = delay
+	return o
+}
+
+// WithSchedule restricts the replication to the given daily time windows, it's paused outside them and
+// the connection to the primary is kept or closed according to policy. The replication is not restricted
+// when no window is given
+func (o *Options) WithSchedule(policy OutsideSchedulePolicy, windows ...ScheduleWindow) *Options {
+	o.outsideSchedulePolicy = policy
+	o.schedule = windows
+	return o
+}
+
+// WithStoragePreflight makes Start check there is enough storage to replicate all the transactions
+// committed by the primary, the replication is not started if there is not. The required storage
+// is estimated from the size of a sample of the transactions to be replicated
+func (o *Options) WithStoragePreflight(storagePreflight bool) *Options {
+	o.storagePreflight = storagePreflight
+	return o
+}
+
+// WithStorageHeadroom sets the margin added to the storage estimated by the preflight check,
+// as a fraction of the estimation e.g. 0.2 requires 20% more storage than estimated
+func (o *Options) WithStorageHeadroom(storageHeadroom float64) *Options {
+	o.storageHeadroom = storageHeadroom
+	return o
+}
+
+// WithStorageProbe sets the function used by the preflight check to get the storage available
+// for the replicated database. The free space of the filesystem is used if not set
+func (o *Options) WithStorageProbe(storageProbe StorageProbe) *Options {
+	o.storageProbe = storageProbe
+	return o
+}
+
+// WithClientPool sets the pool from which connections to the primary are acquired, so they can be shared
+// with other replicators. A dedicated connection is opened if not set
+func (o *Options) WithClientPool(clientPool *ClientPool) *Options {
+	o.clientPool = clientPool
+	return o
+}
+
+// WithTracer sets the tracer used to record spans around connections to the primary,
+// transaction exports and replicated transactions. No span is recorded if not set
+func (o *Options) WithTracer(tracer Tracer) *Options {
+	o.tracer = tracer
+	return o
+}
+
+// WithPropagator sets the propagator used to send the trace context to the primary when exporting transactions
+func (o *Options) WithPropagator(propagator Propagator) *Options {
+	o.propagator = propagator
+	return o
+}
+
+// WithVerifyOnly makes the replicator verify transactions exported by the primary instead of committing them.
+// Transactions are checked against their own digests, the hash chain and the ones already committed into the
+// local database, which is never modified. Durability policies do not apply as nothing is written
+func (o *Options) WithVerifyOnly(verifyOnly bool) *Options {
+	o.verifyOnly = verifyOnly
+	return o
+}
+
+// WithAlertHandler sets the function called when a transaction fails verification in verify-only mode,
+// when the shadow apply diverges from the replica or when the least privilege check fails
+func (o *Options) WithAlertHandler(alertHandler AlertHandler) *Options {
+	o.alertHandler = alertHandler
+	return o
+}
+
+// WithEventHandler sets the function called on replication events, see EventType
+func (o *Options) WithEventHandler(eventHandler EventHandler) *Options {
+	o.eventHandler = eventHandler
+	return o
+}
+
+// WithFellBehindThreshold sets how many transactions the replica may lag behind the primary once caught up
+// before EventFellBehind is emitted. Zero disables the event
+func (o *Options) WithFellBehindThreshold(threshold int) *Options {
+	o.fellBehindThreshold = threshold
+	return o
+}
+
+// primaryTLSConfig returns the TLS configuration used to connect to the primary, verifying its certificate
+// against the overridden server name, if any
+func (opts *Options) primaryTLSConfig() *tls.Config {
+	cfg := opts.tlsConfig.Clone()
+
+	if opts.tlsServerName != "" {
+		cfg.ServerName = opts.tlsServerName
+	}
+
+	return cfg
+}
+
+// WithApplyCoalesce coalesces consecutive fetched transactions into a single apply, up to maxTxs transactions
+// taking up to maxBytes once exported (no size limit when zero). Each transaction keeps its own id and header,
+// but waiting for them to be synced is done once per group, which reduces the overhead of applying
+// many tiny transactions while catching up. Only transactions already fetched are coalesced, applying them is never
+// delayed to complete a group. Synchronous replicas never coalesce more transactions than the ack batch size.
+// Coalescing is disabled when maxTxs is lower than 2, transactions are then applied one by one
+func (o *Options) WithApplyCoalesce(maxTxs int, maxBytes int) *Options {
+	o.applyCoalesceMaxTxs = maxTxs
+	o.applyCoalesceMaxBytes = maxBytes
+	return o
+}
+
+// WithLeastPrivilegeCheck enables checking the permissions granted to the replication user once connected to the primary.
+// Privileges not needed to replicate, i.e. system admin rights or permissions on other databases, are logged and
+// reported to the alert handler. The check is advisory, replication is not stopped
+func (o *Options) WithLeastPrivilegeCheck(leastPrivilegeCheck bool) *Options {
+	o.leastPrivilegeCheck = leastPrivilegeCheck
+	return o
+}
+
+// WithDatabaseReset sets the function used to discard the data of the replica on a force resync, see ForceResync
+func (o *Options) WithDatabaseReset(databaseReset DatabaseResetFunc) *Options {
+	o.databaseReset = databaseReset
+	return o
+}
+
+// WithPayloadChecksum enables the verification of the checksum of each transaction exported by the primary
+// before it's applied, so that a transaction corrupted in transit is never applied. Replication is halted
+// with ErrTxPayloadCorrupted on mismatch. Transactions are applied unverified when not supported by the primary
+func (o *Options) WithPayloadChecksum(payloadChecksum bool) *Options {
+	o.payloadChecksum = payloadChecksum
+	return o
+}
+
+// preferredSourceHostPort splits the address of the preferred source into its host and port
+func (opts *Options) preferredSourceHostPort() (string, int, error) {
+	host, portStr, err := net.SplitHostPort(opts.preferredSource)
+	if err != nil {
+		return "", 0, err
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return host, port, nil
+}
+
+func (opts *Options) validPreferredSource() bool {
+	host, port, err := opts.preferredSourceHostPort()
+	return err == nil && host != "" && port > 0
+}
+
+// primaryAddress returns the address of the primary, either a Unix socket or host and port
+func (opts *Options) primaryAddress() string {
+	if opts.unixSocket != "" {
+		return "unix:" + opts.unixSocket
+	}
+
+	return fmt.Sprintf("%s:%d", opts.primaryHost, opts.primaryPort)
+}
+
+// WithOnPrimaryDatabaseMissing sets how the replication proceeds when the database does not exist on the primary.
+// The alias is the database replicated instead when following it, and must be empty for any other policy
+func (o *Options) WithOnPrimaryDatabaseMissing(policy PrimaryDatabaseMissingPolicy, alias string) *Options {
+	o.primaryDatabaseMissingPolicy = policy
+	o.primaryDatabaseAlias = alias
+	return o
+}
+
+// WithApplyTransform sets a function invoked with every transaction received from the primary before it is applied,
+// see ApplyTransform for details. Transformed replicas can not be verified against the primary
+func (o *Options) WithApplyTransform(applyTransform ApplyTransform) *Options {
+	o.applyTransform = applyTransform
+	return o
+}
+
+// WithShadowApply sets an alternative apply path run asynchronously on every replicated transaction, see ShadowApplier.
+// Divergences are logged and reported to the alert handler, the data committed by the replica is never affected
+func (o *Options) WithShadowApply(shadowApplier ShadowApplier) *Options {
+	o.shadowApplier = shadowApplier
+	return o
+}
+
+// WithPreferredSource sets the host:port of a read-replica of the primary to fetch transactions from, offloading the primary.
+// It is used only while its committed transaction is at most maxAcceptableLag transactions behind the one committed by the primary,
+// transactions are fetched from the primary otherwise, until reconnecting. The replica must serve the replicated database
+// with the same credentials
+func (o *Options) WithPreferredSource(endpoint string, maxAcceptableLag uint64) *Options {
+	o.preferredSource = endpoint
+	o.preferredSourceMaxLag = maxAcceptableLag
+	return o
+}
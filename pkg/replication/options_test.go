@@ -17,9 +17,12 @@ limitations under the License.
 package replication
 
 import (
+	"context"
+	"crypto/tls"
 	"testing"
 	"time"
 
+	"github.com/codenotary/immudb/pkg/database"
 	"github.com/stretchr/testify/require"
 )
 
@@ -37,17 +40,45 @@ func TestOptions(t *testing.T) {
 	opts.WithPrimaryDatabase("defaultdb").
 		WithPrimaryHost("127.0.0.1").
 		WithPrimaryPort(3322).
+		WithUnixSocket("/tmp/immudb.sock").
 		WithPrimaryUsername("immudbUsr").
 		WithPrimaryPassword("immdubPwd").
 		WithStreamChunkSize(DefaultChunkSize).
 		WithPrefetchTxBufferSize(DefaultPrefetchTxBufferSize).
 		WithReplicationCommitConcurrency(DefaultReplicationCommitConcurrency).
 		WithAllowTxDiscarding(true).
-		WithDelayer(delayer)
+		WithDelayer(delayer).
+		WithStatusLogInterval(time.Minute).
+		WithReadyTimeout(time.Second).
+		WithPrimaryUUIDCheck(true).
+		WithCheckpointPersistence(true).
+		WithDurabilityPolicy(FsyncAuto).
+		WithFsyncBatchSize(10).
+		WithFsyncIdleTimeout(time.Second).
+		WithAckBatch(10, time.Second).
+		WithIdlePollInterval(time.Second).
+		WithIdleProbeInterval(time.Minute).
+		WithMaxTxPerSecond(100).
+		WithApplyRetries(5, time.Second).
+		WithApplyDelay(time.Hour).
+		WithSchedule(DisconnectOutsideSchedule, ScheduleWindow{From: 22 * time.Hour, To: 6 * time.Hour}).
+		WithStoragePreflight(true).
+		WithStorageHeadroom(0.5).
+		WithStorageProbe(func(path string) (uint64, error) { return 0, nil }).
+		WithClientPool(&ClientPool{}).
+		WithTracer(noopTracer{}).
+		WithPropagator(noopPropagator{}).
+		WithVerifyOnly(true).
+		WithAlertHandler(func(alert *IntegrityAlert) {}).
+		WithEventHandler(func(event *Event) {}).
+		WithFellBehindThreshold(10).
+		WithOnPrimaryDatabaseMissing(FollowAliasOnPrimaryDatabaseMissing, "aliasdb")
 
 	require.Equal(t, "defaultdb", opts.primaryDatabase)
 	require.Equal(t, "127.0.0.1", opts.primaryHost)
 	require.Equal(t, 3322, opts.primaryPort)
+	require.Equal(t, "/tmp/immudb.sock", opts.unixSocket)
+	require.Equal(t, "unix:/tmp/immudb.sock", opts.primaryAddress())
 	require.Equal(t, "immudbUsr", opts.primaryUsername)
 	require.Equal(t, "immdubPwd", opts.primaryPassword)
 	require.Equal(t, DefaultChunkSize, opts.streamChunkSize)
@@ -55,8 +86,123 @@ func TestOptions(t *testing.T) {
 	require.Equal(t, DefaultReplicationCommitConcurrency, opts.replicationCommitConcurrency)
 	require.True(t, opts.allowTxDiscarding)
 	require.Equal(t, delayer, opts.delayer)
+	require.Equal(t, time.Minute, opts.statusLogInterval)
+	require.Equal(t, time.Second, opts.readyTimeout)
+	require.True(t, opts.primaryUUIDCheck)
+	require.True(t, opts.checkpointPersistence)
+	require.Equal(t, FsyncAuto, opts.durabilityPolicy)
+	require.Equal(t, 10, opts.fsyncBatchSize)
+	require.Equal(t, time.Second, opts.fsyncIdleTimeout)
+	require.Equal(t, 10, opts.ackBatchSize)
+	require.Equal(t, time.Second, opts.ackBatchInterval)
+	require.Equal(t, time.Second, opts.idlePollInterval)
+	require.Equal(t, 100, opts.maxTxPerSecond)
+	require.Equal(t, 5, opts.applyRetries)
+	require.Equal(t, time.Second, opts.applyRetryDelay)
+	require.Equal(t, time.Hour, opts.applyDelay)
+	require.Equal(t, DisconnectOutsideSchedule, opts.outsideSchedulePolicy)
+	require.Equal(t, []ScheduleWindow{{From: 22 * time.Hour, To: 6 * time.Hour}}, opts.schedule)
+	require.True(t, opts.storagePreflight)
+	require.Equal(t, 0.5, opts.storageHeadroom)
+	require.NotNil(t, opts.storageProbe)
+	require.NotNil(t, opts.clientPool)
+	require.Equal(t, noopTracer{}, opts.tracer)
+	require.Equal(t, noopPropagator{}, opts.propagator)
+	require.True(t, opts.verifyOnly)
+	require.NotNil(t, opts.alertHandler)
+	require.NotNil(t, opts.eventHandler)
+	require.Equal(t, 10, opts.fellBehindThreshold)
+	require.Equal(t, FollowAliasOnPrimaryDatabaseMissing, opts.primaryDatabaseMissingPolicy)
+	require.Equal(t, "aliasdb", opts.primaryDatabaseAlias)
 
-	require.True(t, opts.Valid())
+	require.False(t, opts.Valid())
+	require.True(t, opts.WithDurabilityPolicy(FsyncEveryTx).Valid())
+
+	require.False(t, opts.WithDurabilityPolicy(FsyncAuto+1).Valid())
+	opts.WithDurabilityPolicy(FsyncEveryTx)
+
+	require.False(t, opts.WithAckBatch(0, time.Second).Valid())
+	require.False(t, opts.WithAckBatch(1, -time.Second).Valid())
+	require.True(t, opts.WithAckBatch(1, 0).Valid())
+
+	require.False(t, opts.WithIdlePollInterval(-time.Second).Valid())
+	require.True(t, opts.WithIdlePollInterval(0).Valid())
+
+	require.False(t, opts.WithIdleProbeInterval(-time.Second).Valid())
+	require.True(t, opts.WithIdleProbeInterval(0).Valid())
+
+	require.False(t, opts.WithMaxTxPerSecond(-1).Valid())
+	require.True(t, opts.WithMaxTxPerSecond(0).Valid())
+
+	require.False(t, opts.WithApplyRetries(-1, time.Second).Valid())
+	require.False(t, opts.WithApplyRetries(1, -time.Second).Valid())
+	require.True(t, opts.WithApplyRetries(0, 0).Valid())
+
+	require.False(t, opts.WithFellBehindThreshold(-1).Valid())
+	require.True(t, opts.WithFellBehindThreshold(0).Valid())
+
+	require.False(t, opts.WithApplyDelay(-time.Second).Valid())
+	require.True(t, opts.WithApplyDelay(0).Valid())
+
+	require.False(t, opts.WithSchedule(DisconnectOutsideSchedule+1).Valid())
+	require.False(t, opts.WithSchedule(KeepConnectionOutsideSchedule, ScheduleWindow{From: time.Hour, To: time.Hour}).Valid())
+	require.False(t, opts.WithSchedule(KeepConnectionOutsideSchedule, ScheduleWindow{From: time.Hour, To: 24 * time.Hour}).Valid())
+	require.False(t, opts.WithSchedule(KeepConnectionOutsideSchedule, ScheduleWindow{From: -time.Hour, To: time.Hour}).Valid())
+	require.True(t, opts.WithSchedule(KeepConnectionOutsideSchedule).Valid())
+
+	require.False(t, opts.WithStorageHeadroom(-0.1).Valid())
+	require.True(t, opts.WithStorageHeadroom(0).Valid())
+
+	require.False(t, opts.WithOnPrimaryDatabaseMissing(FollowAliasOnPrimaryDatabaseMissing, "").Valid())
+	require.False(t, opts.WithOnPrimaryDatabaseMissing(HaltOnPrimaryDatabaseMissing, "aliasdb").Valid())
+	require.False(t, opts.WithOnPrimaryDatabaseMissing(FollowAliasOnPrimaryDatabaseMissing+1, "").Valid())
+	require.True(t, opts.WithOnPrimaryDatabaseMissing(HaltOnPrimaryDatabaseMissing, "").Valid())
+
+	transform := func(tx *ReplicatedTx) (*ReplicatedTx, error) { return tx, nil }
+
+	// verify-only replicas must not transform transactions
+	require.False(t, opts.WithApplyTransform(transform).Valid())
+	require.True(t, opts.WithVerifyOnly(false).Valid())
+	require.NotNil(t, opts.applyTransform)
+
+	// shadow apply is only compatible with untransformed replication
+	require.False(t, opts.WithShadowApply(&divergentShadow{}).Valid())
+	require.True(t, opts.WithApplyTransform(nil).Valid())
+	require.False(t, opts.WithVerifyOnly(true).Valid())
+	require.True(t, opts.WithVerifyOnly(false).Valid())
+	require.NotNil(t, opts.shadowApplier)
+
+	// the server name override requires TLS
+	require.False(t, opts.WithTLSServerName("primary.immudb.internal").Valid())
+	require.True(t, opts.WithTLS(&tls.Config{}).Valid())
+	require.Equal(t, "primary.immudb.internal", opts.primaryTLSConfig().ServerName)
+	require.Empty(t, opts.tlsConfig.ServerName)
+
+	require.False(t, opts.WithPreferredSource("replica", 10).Valid())
+	require.False(t, opts.WithPreferredSource("replica:port", 10).Valid())
+	require.False(t, opts.WithPreferredSource(":3322", 10).Valid())
+	require.True(t, opts.WithPreferredSource("replica:3322", 10).Valid())
+	require.Equal(t, uint64(10), opts.preferredSourceMaxLag)
+
+	require.False(t, opts.WithApplyCoalesce(-1, 0).Valid())
+	require.False(t, opts.WithApplyCoalesce(10, -1).Valid())
+	require.True(t, opts.WithApplyCoalesce(10, 64*1024).Valid())
+	require.Equal(t, 10, opts.applyCoalesceMaxTxs)
+	require.Equal(t, 64*1024, opts.applyCoalesceMaxBytes)
+
+	// coalesced transactions are replicated as they are
+	require.False(t, opts.WithShadowApply(nil).WithApplyTransform(transform).Valid())
+	require.True(t, opts.WithApplyCoalesce(1, 0).Valid())
+	require.True(t, opts.WithApplyTransform(nil).Valid())
+
+	require.True(t, opts.WithLeastPrivilegeCheck(true).Valid())
+	require.True(t, opts.leastPrivilegeCheck)
+
+	require.True(t, opts.WithDatabaseReset(func(ctx context.Context, db database.DB) (database.DB, error) { return db, nil }).Valid())
+	require.NotNil(t, opts.databaseReset)
+
+	require.True(t, opts.WithPayloadChecksum(true).Valid())
+	require.True(t, opts.payloadChecksum)
 
 	defaultOpts := DefaultOptions()
 	require.NotNil(t, defaultOpts)
@@ -0,0 +1,246 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/client"
+)
+
+var ErrClientPoolClosed = errors.New("client pool closed")
+
+// ClientPool is a bounded pool of connections to primaries which can be shared by many replicators,
+// so a process replicating several databases does not keep a connection per replicator.
+//
+// Connections hold a session, thus they are only reused to replicate from the same primary database
+// with the same user. When the pool is full, an idle connection to a different primary is closed to make
+// room for a new one, otherwise replicators wait for a connection to be released.
+// Idle connections are closed once they have not been used for the idle timeout.
+type ClientPool struct {
+	maxSize     int
+	idleTimeout time.Duration
+
+	mutex sync.Mutex
+	// size is the number of open connections, either idle or in use
+	size int
+	// idle connections, the least recently used first
+	idle []*pooledClient
+	// released is closed and replaced every time a connection is released or closed
+	released chan struct{}
+	closed   bool
+
+	done chan struct{}
+}
+
+type pooledClient struct {
+	client.ImmuClient
+
+	key      string
+	lastUsed time.Time
+}
+
+// NewClientPool returns a pool of at most maxSize connections, idle connections are closed after idleTimeout.
+// Zero idle timeout keeps idle connections open until the pool is closed
+func NewClientPool(maxSize int, idleTimeout time.Duration) (*ClientPool, error) {
+	if maxSize <= 0 || idleTimeout < 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	p := &ClientPool{
+		maxSize:     maxSize,
+		idleTimeout: idleTimeout,
+		released:    make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+
+	if idleTimeout > 0 {
+		go p.reapIdlePeriodically()
+	}
+
+	return p, nil
+}
+
+// acquire returns an idle connection with the given key, or a new one created by dial if there is room for it.
+// It waits for a connection to be released when the pool is full
+func (p *ClientPool) acquire(ctx context.Context, key string, dial func(ctx context.Context) (client.ImmuClient, error)) (*pooledClient, error) {
+	for {
+		p.mutex.Lock()
+
+		if p.closed {
+			p.mutex.Unlock()
+			return nil, ErrClientPoolClosed
+		}
+
+		for i := len(p.idle) - 1; i >= 0; i-- {
+			c := p.idle[i]
+
+			if c.key == key {
+				p.idle = append(p.idle[:i], p.idle[i+1:]...)
+				p.mutex.Unlock()
+
+				return c, nil
+			}
+		}
+
+		var evicted *pooledClient
+
+		if p.size == p.maxSize && len(p.idle) > 0 {
+			// the room of the least recently used connection is taken by the new one
+			evicted = p.idle[0]
+			p.idle = p.idle[1:]
+			p.size--
+		}
+
+		if p.size < p.maxSize {
+			p.size++
+			p.mutex.Unlock()
+
+			if evicted != nil {
+				evicted.CloseSession(context.Background())
+			}
+
+			return p.dial(ctx, key, dial)
+		}
+
+		released := p.released
+
+		p.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+func (p *ClientPool) dial(ctx context.Context, key string, dial func(ctx context.Context) (client.ImmuClient, error)) (*pooledClient, error) {
+	c, err := dial(ctx)
+	if err != nil {
+		p.mutex.Lock()
+		p.size--
+		p.notifyRelease()
+		p.mutex.Unlock()
+
+		return nil, err
+	}
+
+	return &pooledClient{ImmuClient: c, key: key}, nil
+}
+
+// release returns a connection to the pool so it can be reused
+func (p *ClientPool) release(c *pooledClient) {
+	p.mutex.Lock()
+
+	if p.closed {
+		p.size--
+		p.mutex.Unlock()
+
+		c.CloseSession(context.Background())
+
+		return
+	}
+
+	c.lastUsed = time.Now()
+	p.idle = append(p.idle, c)
+	p.notifyRelease()
+
+	p.mutex.Unlock()
+}
+
+// discard closes a connection which should not be reused, e.g. after a failure
+func (p *ClientPool) discard(c *pooledClient) {
+	p.mutex.Lock()
+	p.size--
+	p.notifyRelease()
+	p.mutex.Unlock()
+
+	c.CloseSession(context.Background())
+}
+
+func (p *ClientPool) notifyRelease() {
+	close(p.released)
+	p.released = make(chan struct{})
+}
+
+func (p *ClientPool) reapIdlePeriodically() {
+	ticker := time.NewTicker(p.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.reapIdle()
+		}
+	}
+}
+
+// reapIdle closes the connections which have been idle for longer than the idle timeout
+func (p *ClientPool) reapIdle() {
+	p.mutex.Lock()
+
+	var expired []*pooledClient
+
+	for len(p.idle) > 0 && time.Since(p.idle[0].lastUsed) >= p.idleTimeout {
+		expired = append(expired, p.idle[0])
+		p.idle = p.idle[1:]
+		p.size--
+	}
+
+	if len(expired) > 0 {
+		p.notifyRelease()
+	}
+
+	p.mutex.Unlock()
+
+	for _, c := range expired {
+		c.CloseSession(context.Background())
+	}
+}
+
+// Close closes all the idle connections, connections in use are closed once released
+func (p *ClientPool) Close() error {
+	p.mutex.Lock()
+
+	if p.closed {
+		p.mutex.Unlock()
+		return ErrClientPoolClosed
+	}
+
+	p.closed = true
+	close(p.done)
+
+	idle := p.idle
+	p.idle = nil
+	p.size -= len(idle)
+
+	p.notifyRelease()
+
+	p.mutex.Unlock()
+
+	for _, c := range idle {
+		c.CloseSession(context.Background())
+	}
+
+	return nil
+}
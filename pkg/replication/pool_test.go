@@ -0,0 +1,268 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePoolClient struct {
+	client.ImmuClient
+	closed int32
+}
+
+func (c *fakePoolClient) CloseSession(ctx context.Context) error {
+	atomic.AddInt32(&c.closed, 1)
+	return nil
+}
+
+func (c *fakePoolClient) isClosed() bool {
+	return atomic.LoadInt32(&c.closed) > 0
+}
+
+type fakeDialer struct {
+	dials int32
+}
+
+func (d *fakeDialer) dial(ctx context.Context) (client.ImmuClient, error) {
+	atomic.AddInt32(&d.dials, 1)
+	return &fakePoolClient{}, nil
+}
+
+func (d *fakeDialer) count() int {
+	return int(atomic.LoadInt32(&d.dials))
+}
+
+func TestClientPool(t *testing.T) {
+	_, err := NewClientPool(0, time.Second)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewClientPool(1, -time.Second)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	t.Run("released connections should be reused for the same primary", func(t *testing.T) {
+		pool, err := NewClientPool(2, 0)
+		require.NoError(t, err)
+		defer pool.Close()
+
+		dialer := &fakeDialer{}
+
+		c1, err := pool.acquire(context.Background(), "db1", dialer.dial)
+		require.NoError(t, err)
+
+		pool.release(c1)
+
+		c2, err := pool.acquire(context.Background(), "db1", dialer.dial)
+		require.NoError(t, err)
+		require.Same(t, c1, c2)
+		require.Equal(t, 1, dialer.count())
+
+		c3, err := pool.acquire(context.Background(), "db2", dialer.dial)
+		require.NoError(t, err)
+		require.NotSame(t, c2, c3)
+		require.Equal(t, 2, dialer.count())
+
+		pool.discard(c2)
+		require.True(t, c2.ImmuClient.(*fakePoolClient).isClosed())
+
+		c4, err := pool.acquire(context.Background(), "db1", dialer.dial)
+		require.NoError(t, err)
+		require.NotSame(t, c2, c4)
+		require.Equal(t, 3, dialer.count())
+	})
+
+	t.Run("idle connections should be evicted to make room for other primaries", func(t *testing.T) {
+		pool, err := NewClientPool(1, 0)
+		require.NoError(t, err)
+		defer pool.Close()
+
+		dialer := &fakeDialer{}
+
+		c1, err := pool.acquire(context.Background(), "db1", dialer.dial)
+		require.NoError(t, err)
+
+		pool.release(c1)
+
+		c2, err := pool.acquire(context.Background(), "db2", dialer.dial)
+		require.NoError(t, err)
+		require.True(t, c1.ImmuClient.(*fakePoolClient).isClosed())
+		require.False(t, c2.ImmuClient.(*fakePoolClient).isClosed())
+		require.Equal(t, 1, pool.size)
+	})
+
+	t.Run("the size of the pool should be enforced under concurrent acquisition", func(t *testing.T) {
+		const maxSize = 3
+
+		pool, err := NewClientPool(maxSize, 0)
+		require.NoError(t, err)
+		defer pool.Close()
+
+		dialer := &fakeDialer{}
+
+		var inUse, maxInUse int32
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				for j := 0; j < 10; j++ {
+					c, err := pool.acquire(context.Background(), fmt.Sprintf("db%d", (i+j)%5), dialer.dial)
+					if !assert.NoError(t, err) {
+						return
+					}
+
+					n := atomic.AddInt32(&inUse, 1)
+					for {
+						max := atomic.LoadInt32(&maxInUse)
+						if n <= max || atomic.CompareAndSwapInt32(&maxInUse, max, n) {
+							break
+						}
+					}
+
+					time.Sleep(time.Millisecond)
+
+					atomic.AddInt32(&inUse, -1)
+
+					if j%3 == 0 {
+						pool.discard(c)
+					} else {
+						pool.release(c)
+					}
+				}
+			}(i)
+		}
+
+		wg.Wait()
+
+		require.LessOrEqual(t, atomic.LoadInt32(&maxInUse), int32(maxSize))
+		require.LessOrEqual(t, pool.size, maxSize)
+	})
+
+	t.Run("acquiring from a full pool should wait for a release", func(t *testing.T) {
+		pool, err := NewClientPool(1, 0)
+		require.NoError(t, err)
+		defer pool.Close()
+
+		dialer := &fakeDialer{}
+
+		c1, err := pool.acquire(context.Background(), "db1", dialer.dial)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err = pool.acquire(ctx, "db2", dialer.dial)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			pool.release(c1)
+		}()
+
+		c2, err := pool.acquire(context.Background(), "db1", dialer.dial)
+		require.NoError(t, err)
+		require.Same(t, c1, c2)
+	})
+
+	t.Run("failing to connect should not take room from the pool", func(t *testing.T) {
+		pool, err := NewClientPool(1, 0)
+		require.NoError(t, err)
+		defer pool.Close()
+
+		errDial := errors.New("dial error")
+
+		_, err = pool.acquire(context.Background(), "db1", func(ctx context.Context) (client.ImmuClient, error) {
+			return nil, errDial
+		})
+		require.ErrorIs(t, err, errDial)
+		require.Zero(t, pool.size)
+
+		_, err = pool.acquire(context.Background(), "db1", (&fakeDialer{}).dial)
+		require.NoError(t, err)
+	})
+
+	t.Run("idle connections should be closed after the idle timeout", func(t *testing.T) {
+		pool, err := NewClientPool(2, 20*time.Millisecond)
+		require.NoError(t, err)
+		defer pool.Close()
+
+		dialer := &fakeDialer{}
+
+		c1, err := pool.acquire(context.Background(), "db1", dialer.dial)
+		require.NoError(t, err)
+
+		c2, err := pool.acquire(context.Background(), "db1", dialer.dial)
+		require.NoError(t, err)
+
+		pool.release(c1)
+
+		require.Eventually(t, func() bool {
+			return c1.ImmuClient.(*fakePoolClient).isClosed()
+		}, time.Second, 5*time.Millisecond)
+
+		require.False(t, c2.ImmuClient.(*fakePoolClient).isClosed())
+
+		pool.mutex.Lock()
+		require.Equal(t, 1, pool.size)
+		require.Empty(t, pool.idle)
+		pool.mutex.Unlock()
+	})
+
+	t.Run("closing the pool should close its connections", func(t *testing.T) {
+		pool, err := NewClientPool(2, time.Minute)
+		require.NoError(t, err)
+
+		dialer := &fakeDialer{}
+
+		c1, err := pool.acquire(context.Background(), "db1", dialer.dial)
+		require.NoError(t, err)
+
+		c2, err := pool.acquire(context.Background(), "db2", dialer.dial)
+		require.NoError(t, err)
+
+		pool.release(c1)
+
+		err = pool.Close()
+		require.NoError(t, err)
+		require.True(t, c1.ImmuClient.(*fakePoolClient).isClosed())
+		require.False(t, c2.ImmuClient.(*fakePoolClient).isClosed())
+
+		pool.release(c2)
+		require.True(t, c2.ImmuClient.(*fakePoolClient).isClosed())
+		require.Zero(t, pool.size)
+
+		_, err = pool.acquire(context.Background(), "db1", dialer.dial)
+		require.ErrorIs(t, err, ErrClientPoolClosed)
+
+		err = pool.Close()
+		require.ErrorIs(t, err, ErrClientPoolClosed)
+	})
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"errors"
+
+	"github.com/codenotary/immudb/pkg/client"
+)
+
+var ErrPreferredSourceWithSyncReplication = errors.New("synchronous replicas can not fetch transactions from a preferred source")
+
+// connectPreferredSource switches to the preferred source, if any, once connected to the primary.
+// The primary is kept connected to check the lag of the preferred source, which is not used
+// when unreachable or lagging behind the primary more than acceptable
+func (txr *TxReplicator) connectPreferredSource(ctx context.Context) {
+	if txr.opts.preferredSource == "" {
+		return
+	}
+
+	host, port, err := txr.opts.preferredSourceHostPort()
+	if err != nil {
+		txr.logger.Warningf("Invalid preferred source '%s'. Reason: %s", txr.opts.preferredSource, err.Error())
+		return
+	}
+
+	c := client.NewClient().WithOptions(newClientOptions(host, port, txr.opts.tlsConfig))
+
+	err = c.OpenSession(ctx, []byte(txr.opts.primaryUsername), []byte(txr.opts.primaryPassword), txr.primaryDatabaseName())
+	if err != nil {
+		txr.logger.Warningf("Failed to connect to preferred source '%s', fetching from '%s'. Reason: %s",
+			txr.opts.preferredSource, txr._primaryDB, err.Error())
+		return
+	}
+
+	sourceState, err := c.CurrentState(ctx)
+	if err != nil {
+		c.CloseSession(ctx)
+
+		txr.logger.Warningf("Failed to read the state of preferred source '%s', fetching from '%s'. Reason: %s",
+			txr.opts.preferredSource, txr._primaryDB, err.Error())
+		return
+	}
+
+	primaryTxID := txr.currentStatus().primaryCommittedTxID
+
+	if txr.preferredSourceLags(sourceState.TxId, primaryTxID) {
+		c.CloseSession(ctx)
+
+		txr.logger.Infof("Preferred source '%s' lags behind '%s' (tx %d committed, %d on primary), fetching from primary",
+			txr.opts.preferredSource, txr._primaryDB, sourceState.TxId, primaryTxID)
+		return
+	}
+
+	txr.primaryClient = txr.client
+	txr.client = c
+
+	txr.updateStatus(func(st *replicatorStatus) { st.source = txr.opts.preferredSource })
+
+	txr.logger.Infof("Fetching transactions for database '%s' from preferred source '%s' (tx %d committed, %d on primary)",
+		txr.db.GetName(), txr.opts.preferredSource, sourceState.TxId, primaryTxID)
+}
+
+// fallBackToPrimaryIfLagging switches back to the primary once the preferred source has no
+// more transactions to be fetched but lags behind the primary more than acceptable
+func (txr *TxReplicator) fallBackToPrimaryIfLagging(ctx context.Context) bool {
+	if txr.primaryClient == nil {
+		return false
+	}
+
+	primaryState, err := txr.primaryClient.CurrentState(ctx)
+	if err != nil {
+		txr.logger.Warningf("Failed to read the state of '%s'. Reason: %s", txr._primaryDB, err.Error())
+		return false
+	}
+
+	txr.metrics.primaryCommittedTxID.Set(float64(primaryState.TxId))
+
+	txr.updateStatus(func(st *replicatorStatus) {
+		if primaryState.TxId > st.primaryCommittedTxID {
+			st.primaryCommittedTxID = primaryState.TxId
+		}
+	})
+
+	// all the transactions committed by the preferred source were fetched
+	if !txr.preferredSourceLags(txr.lastTx, primaryState.TxId) {
+		return false
+	}
+
+	txr.logger.Infof("Preferred source '%s' lags behind '%s' (tx %d committed, %d on primary), fetching from primary",
+		txr.opts.preferredSource, txr._primaryDB, txr.lastTx, primaryState.TxId)
+
+	txr.closePreferredSource()
+
+	return true
+}
+
+func (txr *TxReplicator) preferredSourceLags(sourceTxID, primaryTxID uint64) bool {
+	return primaryTxID > sourceTxID && primaryTxID-sourceTxID > txr.opts.preferredSourceMaxLag
+}
+
+// closePreferredSource closes the connection to the preferred source, transactions are fetched from the primary afterwards
+func (txr *TxReplicator) closePreferredSource() {
+	if txr.primaryClient == nil {
+		return
+	}
+
+	txr.client.CloseSession(txr.context)
+
+	txr.client = txr.primaryClient
+	txr.primaryClient = nil
+
+	txr.updateStatus(func(st *replicatorStatus) { st.source = "" })
+}
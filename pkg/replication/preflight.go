@@ -0,0 +1,110 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrInsufficientStorage = errors.New("insufficient storage")
+
+// StorageProbe returns the number of bytes available to store the database at the given path
+type StorageProbe func(path string) (uint64, error)
+
+// preflightSampleTxs is the maximum number of transactions exported from the primary
+// to estimate the average size of the transactions to be replicated
+const preflightSampleTxs = 10
+
+// checkStorage ensures there is enough storage to replicate the transactions committed by the primary.
+// It's done before starting the replication, so a far behind replica does not fill the disk while catching up
+func (txr *TxReplicator) checkStorage(ctx context.Context) error {
+	c := txr.newPrimaryClient()
+
+	err := c.OpenSession(ctx, []byte(txr.opts.primaryUsername), []byte(txr.opts.primaryPassword), txr.primaryDatabaseName())
+	if err != nil {
+		return err
+	}
+	defer c.CloseSession(context.Background())
+
+	primaryState, err := c.CurrentState(ctx)
+	if err != nil {
+		return err
+	}
+
+	return txr.checkStorageFor(ctx, &clientTxExporter{client: c, streamSrvFactory: txr.streamSrvFactory}, primaryState.TxId)
+}
+
+// checkStorageFor estimates the bytes needed to replicate the transactions up to primaryTxID from
+// the average size of a sample of them. The estimation, increased by the storage headroom, must not
+// exceed the storage available for the database
+func (txr *TxReplicator) checkStorageFor(ctx context.Context, primary TxExporter, primaryTxID uint64) error {
+	state, err := txr.db.CurrentState()
+	if err != nil {
+		return err
+	}
+
+	if primaryTxID <= state.PrecommittedTxId {
+		return nil
+	}
+
+	fromTxID := state.PrecommittedTxId + 1
+	pendingTxs := primaryTxID - state.PrecommittedTxId
+
+	sampleTxs := pendingTxs
+	if sampleTxs > preflightSampleTxs {
+		sampleTxs = preflightSampleTxs
+	}
+
+	var sampledBytes uint64
+
+	// transactions are sampled evenly over the pending range
+	for i := uint64(0); i < sampleTxs; i++ {
+		etx, err := primary.ExportTx(ctx, fromTxID+i*pendingTxs/sampleTxs)
+		if err != nil {
+			return err
+		}
+
+		sampledBytes += uint64(len(etx))
+	}
+
+	requiredBytes := uint64(float64(sampledBytes/sampleTxs*pendingTxs) * (1 + txr.opts.storageHeadroom))
+
+	probe := txr.opts.storageProbe
+	if probe == nil {
+		probe = availableStorage
+	}
+
+	availableBytes, err := probe(txr.db.Path())
+	if err != nil {
+		return err
+	}
+
+	if requiredBytes > availableBytes {
+		txr.logger.Errorf("Replication of %d transactions from '%s' to '%s' requires about %d bytes but only %d are available",
+			pendingTxs, txr._primaryDB, txr.db.GetName(), requiredBytes, availableBytes)
+
+		return fmt.Errorf("%w: about %d bytes are required to replicate %d transactions but %d are available",
+			ErrInsufficientStorage, requiredBytes, pendingTxs, availableBytes)
+	}
+
+	txr.logger.Infof("Replication of %d transactions from '%s' to '%s' requires about %d bytes, %d are available",
+		pendingTxs, txr._primaryDB, txr.db.GetName(), requiredBytes, availableBytes)
+
+	return nil
+}
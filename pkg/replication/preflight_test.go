@@ -0,0 +1,156 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+type countingTxExporter struct {
+	TxExporter
+	exportedTxs  int
+	exportedSize int
+}
+
+func (e *countingTxExporter) ExportTx(ctx context.Context, txID uint64) ([]byte, error) {
+	etx, err := e.TxExporter.ExportTx(ctx, txID)
+	if err == nil {
+		e.exportedTxs++
+		e.exportedSize += len(etx)
+	}
+
+	return etx, err
+}
+
+func TestStoragePreflight(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+	setTestKeys(t, primary, "key", 99)
+
+	// the first transaction is committed when the database is created
+	const primaryTxID = 100
+
+	newReplicator := func(t *testing.T, db database.DB, headroom float64, probe StorageProbe) *TxReplicator {
+		rOpts := DefaultOptions().
+			WithPrimaryDatabase("defaultdb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(3322).
+			WithStoragePreflight(true).
+			WithStorageHeadroom(headroom).
+			WithStorageProbe(probe)
+
+		txReplicator, err := NewTxReplicator(xid.New(), db, rOpts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		return txReplicator
+	}
+
+	// estimates required storage without headroom and checks it against the probed storage
+	estimate := func(t *testing.T, db database.DB) uint64 {
+		exporter := &countingTxExporter{TxExporter: &dbTxExporter{db: primary}}
+
+		var probedPath string
+
+		txReplicator := newReplicator(t, db, 0, func(path string) (uint64, error) {
+			probedPath = path
+			return 1 << 40, nil
+		})
+
+		err := txReplicator.checkStorageFor(context.Background(), exporter, primaryTxID)
+		require.NoError(t, err)
+		require.Equal(t, db.Path(), probedPath)
+		require.Equal(t, preflightSampleTxs, exporter.exportedTxs)
+
+		return uint64(exporter.exportedSize / exporter.exportedTxs)
+	}
+
+	t.Run("an oversized catch-up should be rejected", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+
+		required := estimate(t, replica) * primaryTxID
+
+		txReplicator := newReplicator(t, replica, 0, func(path string) (uint64, error) {
+			return required - 1, nil
+		})
+
+		err := txReplicator.checkStorageFor(context.Background(), &dbTxExporter{db: primary}, primaryTxID)
+		require.ErrorIs(t, err, ErrInsufficientStorage)
+
+		txReplicator = newReplicator(t, replica, 0, func(path string) (uint64, error) {
+			return required, nil
+		})
+
+		err = txReplicator.checkStorageFor(context.Background(), &dbTxExporter{db: primary}, primaryTxID)
+		require.NoError(t, err)
+
+		t.Run("the headroom should be required on top of the estimation", func(t *testing.T) {
+			txReplicator := newReplicator(t, replica, 0.5, func(path string) (uint64, error) {
+				return required, nil
+			})
+
+			err := txReplicator.checkStorageFor(context.Background(), &dbTxExporter{db: primary}, primaryTxID)
+			require.ErrorIs(t, err, ErrInsufficientStorage)
+		})
+	})
+
+	t.Run("only pending transactions should be taken into account", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+		replicateTestTxs(t, primary, replica, 1, 95)
+
+		exporter := &countingTxExporter{TxExporter: &dbTxExporter{db: primary}}
+
+		txReplicator := newReplicator(t, replica, 0, func(path string) (uint64, error) {
+			return uint64(exporter.exportedSize), nil
+		})
+
+		err := txReplicator.checkStorageFor(context.Background(), exporter, primaryTxID)
+		require.NoError(t, err)
+		require.Equal(t, 5, exporter.exportedTxs)
+
+		replicateTestTxs(t, primary, replica, 96, primaryTxID)
+
+		txReplicator = newReplicator(t, replica, 0, func(path string) (uint64, error) {
+			return 0, errors.New("storage should not be probed")
+		})
+
+		err = txReplicator.checkStorageFor(context.Background(), exporter, primaryTxID)
+		require.NoError(t, err)
+	})
+
+	t.Run("probing errors should be returned", func(t *testing.T) {
+		errProbe := errors.New("probe error")
+
+		txReplicator := newReplicator(t, newTestDB(t, "replicadb", true), 0, func(path string) (uint64, error) {
+			return 0, errProbe
+		})
+
+		err := txReplicator.checkStorageFor(context.Background(), &dbTxExporter{db: primary}, primaryTxID)
+		require.ErrorIs(t, err, errProbe)
+	})
+
+	t.Run("available storage should be probed by default", func(t *testing.T) {
+		available, err := availableStorage(t.TempDir())
+		require.NoError(t, err)
+		require.NotZero(t, available)
+	})
+}
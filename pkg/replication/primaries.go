@@ -0,0 +1,76 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"time"
+)
+
+// PrimaryInfo describes a primary the replica is configured to replicate from,
+// along with the state of the replication from it
+type PrimaryInfo struct {
+	// Address of the primary, either host:port or unix:path
+	Address string `json:"address"`
+	// Database replicated from the primary, which is the configured alias once followed
+	Database string `json:"database"`
+	Username string `json:"username"`
+	// UUID of the primary server, only known once pinned by the primary UUID check
+	UUID string `json:"uuid,omitempty"`
+
+	// Source is the preferred source transactions are fetched from instead of the primary, if any
+	Source string `json:"source,omitempty"`
+
+	Connected           bool `json:"connected"`
+	ConsecutiveFailures int  `json:"consecutiveFailures"`
+
+	// LastFetchedTxID is the last transaction fetched from the primary
+	LastFetchedTxID uint64 `json:"lastFetchedTxId"`
+	// CommittedTxID is the latest transaction known to be committed by the primary
+	CommittedTxID uint64 `json:"committedTxId"`
+
+	// LastError is the most recent replication failure, empty once the replication succeeds again
+	LastError   string    `json:"lastError,omitempty"`
+	LastErrorAt time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// Primaries returns the primaries the replica is configured to replicate from and the state of the
+// replication from each of them. A replicator currently replicates from a single primary
+func (txr *TxReplicator) Primaries() []PrimaryInfo {
+	txr.statusMutex.Lock()
+	defer txr.statusMutex.Unlock()
+
+	st := txr.status
+
+	info := PrimaryInfo{
+		Address:             txr.opts.primaryAddress(),
+		Database:            txr.primaryDatabase,
+		Username:            txr.opts.primaryUsername,
+		UUID:                st.primaryUUID,
+		Source:              st.source,
+		Connected:           st.connected,
+		ConsecutiveFailures: st.consecutiveFailures,
+		LastFetchedTxID:     st.lastFetchedTxID,
+		CommittedTxID:       st.primaryTxID(),
+		LastErrorAt:         st.lastErrAt,
+	}
+
+	if st.lastErr != nil {
+		info.LastError = st.lastErr.Error()
+	}
+
+	return []PrimaryInfo{info}
+}
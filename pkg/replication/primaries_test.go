@@ -0,0 +1,77 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrimaries(t *testing.T) {
+	db := newTestDB(t, "replicadb", true)
+
+	rOpts := DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(1).
+		WithPrimaryUsername("replicator").
+		WithDelayer(&expBackoff{retryMinDelay: time.Millisecond, retryMaxDelay: 10 * time.Millisecond, retryDelayExp: 2})
+
+	txReplicator, err := NewTxReplicator(xid.New(), db, rOpts, logger.NewMemoryLogger())
+	require.NoError(t, err)
+
+	primaries := txReplicator.Primaries()
+	require.Equal(t, []PrimaryInfo{{
+		Address:  "127.0.0.1:1",
+		Database: "defaultdb",
+		Username: "replicator",
+	}}, primaries)
+
+	err = txReplicator.Start()
+	require.NoError(t, err)
+	defer txReplicator.Stop()
+
+	require.Eventually(t, func() bool {
+		primaries = txReplicator.Primaries()
+		return primaries[0].ConsecutiveFailures > 1
+	}, 10*time.Second, 10*time.Millisecond)
+
+	require.Len(t, primaries, 1)
+	require.False(t, primaries[0].Connected)
+	require.NotEmpty(t, primaries[0].LastError)
+	require.False(t, primaries[0].LastErrorAt.IsZero())
+
+	t.Run("primary info should be serializable", func(t *testing.T) {
+		data, err := json.Marshal(primaries)
+		require.NoError(t, err)
+
+		var decoded []PrimaryInfo
+
+		err = json.Unmarshal(data, &decoded)
+		require.NoError(t, err)
+		require.Len(t, decoded, 1)
+		require.True(t, primaries[0].LastErrorAt.Equal(decoded[0].LastErrorAt))
+
+		decoded[0].LastErrorAt = primaries[0].LastErrorAt
+		require.Equal(t, primaries, decoded)
+	})
+}
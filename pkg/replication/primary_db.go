@@ -0,0 +1,88 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+var ErrPrimaryDatabaseMissing = errors.New("primary database does not exist")
+
+// PrimaryDatabaseMissingPolicy defines how the replication proceeds when the database
+// does not exist on the primary, e.g. because it was renamed or replaced
+type PrimaryDatabaseMissingPolicy int
+
+const (
+	// RetryOnPrimaryDatabaseMissing keeps retrying to connect, as done on any other failure
+	RetryOnPrimaryDatabaseMissing PrimaryDatabaseMissingPolicy = iota
+	// HaltOnPrimaryDatabaseMissing stops the replication with ErrPrimaryDatabaseMissing
+	HaltOnPrimaryDatabaseMissing
+	// FollowAliasOnPrimaryDatabaseMissing replicates from the configured alias instead,
+	// the replication is stopped with ErrPrimaryDatabaseMissing if the alias does not exist either
+	FollowAliasOnPrimaryDatabaseMissing
+)
+
+func (p PrimaryDatabaseMissingPolicy) valid() bool {
+	return p >= RetryOnPrimaryDatabaseMissing && p <= FollowAliasOnPrimaryDatabaseMissing
+}
+
+// primaryDatabaseName returns the name of the database replicated from the primary,
+// which is the configured alias once the alias is followed
+func (txr *TxReplicator) primaryDatabaseName() string {
+	txr.statusMutex.Lock()
+	defer txr.statusMutex.Unlock()
+
+	return txr.primaryDatabase
+}
+
+// isPrimaryDatabaseMissing tells if the primary refused a session because the database does not exist.
+// The typed error is not preserved by the client so the message is checked instead
+func isPrimaryDatabaseMissing(err error) bool {
+	return err != nil && strings.Contains(err.Error(), database.ErrDatabaseNotExists.Error())
+}
+
+// primaryDatabaseMissing applies the configured policy once the database was found to be missing on the primary
+func (txr *TxReplicator) primaryDatabaseMissing(dbName string, err error) error {
+	switch txr.opts.primaryDatabaseMissingPolicy {
+	case HaltOnPrimaryDatabaseMissing:
+		{
+			txr.logger.Errorf("Database '%s' does not exist on '%s'", dbName, txr.opts.primaryAddress())
+			return fmt.Errorf("%w: '%s'", ErrPrimaryDatabaseMissing, dbName)
+		}
+	case FollowAliasOnPrimaryDatabaseMissing:
+		{
+			alias := txr.opts.primaryDatabaseAlias
+
+			if dbName == alias {
+				txr.logger.Errorf("Database '%s' and its alias '%s' do not exist on '%s'", txr.opts.primaryDatabase, alias, txr.opts.primaryAddress())
+				return fmt.Errorf("%w: neither '%s' nor its alias '%s'", ErrPrimaryDatabaseMissing, txr.opts.primaryDatabase, alias)
+			}
+
+			txr.logger.Warningf("Database '%s' does not exist on '%s', replication follows its alias '%s'", dbName, txr.opts.primaryAddress(), alias)
+
+			txr.statusMutex.Lock()
+			txr.primaryDatabase = alias
+			txr.statusMutex.Unlock()
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+)
+
+// userLister lists the users known to the primary along with their permissions
+type userLister interface {
+	ListUsers(ctx context.Context) (*schema.UserList, error)
+}
+
+// checkLeastPrivilege warns when the replication user is granted more privileges than needed to replicate.
+// Exporting transactions requires admin permission on the replicated database, any permission on other databases
+// and system admin rights are not needed. The check is advisory, replication proceeds regardless of its outcome
+func (txr *TxReplicator) checkLeastPrivilege(ctx context.Context, users userLister) {
+	excess, err := excessPrivileges(ctx, users, txr.opts.primaryUsername, txr.primaryDatabaseName())
+	if err != nil {
+		txr.logger.Warningf("Privileges of replication user '%s' on '%s' could not be checked. Reason: %s",
+			txr.opts.primaryUsername, txr._primaryDB, err.Error())
+		return
+	}
+
+	for _, privilege := range excess {
+		reason := fmt.Sprintf("replication user '%s' has unneeded privileges: %s", txr.opts.primaryUsername, privilege)
+
+		txr.logger.Warningf("Least privilege check of '%s' failed, %s", txr._primaryDB, reason)
+
+		if txr.opts.alertHandler != nil {
+			txr.opts.alertHandler(&IntegrityAlert{Reason: reason})
+		}
+	}
+}
+
+// excessPrivileges returns the privileges of the user which are not needed to replicate the database
+func excessPrivileges(ctx context.Context, users userLister, username, dbName string) ([]string, error) {
+	if username == auth.SysAdminUsername {
+		return []string{"system admin"}, nil
+	}
+
+	userList, err := users.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range userList.Users {
+		if string(user.User) != username {
+			continue
+		}
+
+		var excess []string
+
+		for _, p := range user.Permissions {
+			if p.Database == dbName {
+				// admin permission is needed to export transactions
+				continue
+			}
+
+			excess = append(excess, fmt.Sprintf("%s permission on database '%s'", permissionName(p.Permission), p.Database))
+		}
+
+		return excess, nil
+	}
+
+	return nil, fmt.Errorf("user '%s' not found", username)
+}
+
+func permissionName(permission uint32) string {
+	switch permission {
+	case auth.PermissionR:
+		return "read"
+	case auth.PermissionRW:
+		return "read-write"
+	case auth.PermissionAdmin:
+		return "admin"
+	case auth.PermissionSysAdmin:
+		return "system admin"
+	}
+
+	return fmt.Sprintf("unknown (%d)", permission)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+type mockUserLister struct {
+	users *schema.UserList
+	err   error
+}
+
+func (l *mockUserLister) ListUsers(ctx context.Context) (*schema.UserList, error) {
+	return l.users, l.err
+}
+
+func TestLeastPrivilegeCheck(t *testing.T) {
+	replica := newTestDB(t, "replicadb", true)
+
+	checkPrivileges := func(t *testing.T, username string, users userLister) []*IntegrityAlert {
+		var alerts []*IntegrityAlert
+
+		rOpts := DefaultOptions().
+			WithPrimaryDatabase("primarydb").
+			WithPrimaryUsername(username).
+			WithLeastPrivilegeCheck(true).
+			WithAlertHandler(func(alert *IntegrityAlert) { alerts = append(alerts, alert) })
+
+		txReplicator, err := NewTxReplicator(xid.New(), replica, rOpts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		txReplicator.checkLeastPrivilege(context.Background(), users)
+
+		return alerts
+	}
+
+	users := &mockUserLister{
+		users: &schema.UserList{
+			Users: []*schema.User{
+				{
+					User:        []byte("replicator"),
+					Permissions: []*schema.Permission{{Database: "primarydb", Permission: auth.PermissionAdmin}},
+				},
+				{
+					User: []byte("overprivileged"),
+					Permissions: []*schema.Permission{
+						{Database: "primarydb", Permission: auth.PermissionAdmin},
+						{Database: "otherdb", Permission: auth.PermissionRW},
+						{Database: "thirddb", Permission: auth.PermissionAdmin},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("admin permission on the replicated database only should not be reported", func(t *testing.T) {
+		require.Empty(t, checkPrivileges(t, "replicator", users))
+	})
+
+	t.Run("permissions on other databases should be reported", func(t *testing.T) {
+		alerts := checkPrivileges(t, "overprivileged", users)
+		require.Len(t, alerts, 2)
+		require.Zero(t, alerts[0].TxID)
+		require.Contains(t, alerts[0].Reason, "read-write permission on database 'otherdb'")
+		require.Contains(t, alerts[1].Reason, "admin permission on database 'thirddb'")
+	})
+
+	t.Run("system admin should be reported", func(t *testing.T) {
+		alerts := checkPrivileges(t, auth.SysAdminUsername, users)
+		require.Len(t, alerts, 1)
+		require.Contains(t, alerts[0].Reason, "system admin")
+	})
+
+	t.Run("failed checks should not be reported", func(t *testing.T) {
+		require.Empty(t, checkPrivileges(t, "unknown", users))
+		require.Empty(t, checkPrivileges(t, "replicator", &mockUserLister{err: errors.New("permission denied")}))
+	})
+}
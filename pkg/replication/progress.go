@@ -0,0 +1,146 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"time"
+)
+
+// progressRateWindow is the period over which the apply rate is measured
+// to estimate the time left to catch up with the primary
+const progressRateWindow = 10 * time.Second
+
+// CatchUpProgress reports how far the replica is from catching up with the primary
+type CatchUpProgress struct {
+	// CaughtUp is set once there are no more transactions to be fetched from the primary,
+	// the remaining fields are only meaningful while catching up
+	CaughtUp bool
+
+	// StartTxID is the transaction committed by the replica when the replication started
+	StartTxID uint64
+	// ReplicaTxID is the transaction currently committed by the replica
+	ReplicaTxID uint64
+	// PrimaryTxID is the latest transaction known to be committed by the primary
+	PrimaryTxID uint64
+
+	// Percentage of the transactions replicated since the replication started, from 0 to 100.
+	// It never decreases while catching up, even if the primary commits new transactions
+	Percentage float64
+	// ETA is the estimated time left to catch up at the recent apply rate, zero when unknown
+	ETA time.Duration
+}
+
+// CatchUpProgress returns the progress of the replica catching up with the primary
+func (txr *TxReplicator) CatchUpProgress() CatchUpProgress {
+	var replicaTxID uint64
+
+	state, err := txr.db.CurrentState()
+	if err == nil {
+		replicaTxID = state.TxId
+	}
+
+	txr.statusMutex.Lock()
+	defer txr.statusMutex.Unlock()
+
+	return txr.status.catchUpProgress(replicaTxID, time.Now())
+}
+
+func (st *replicatorStatus) catchUpProgress(replicaTxID uint64, now time.Time) CatchUpProgress {
+	progress := CatchUpProgress{
+		CaughtUp:    st.caughtUp,
+		StartTxID:   st.startTxID,
+		ReplicaTxID: replicaTxID,
+		PrimaryTxID: st.primaryTxID(),
+	}
+
+	if st.caughtUp {
+		progress.Percentage = 100
+		return progress
+	}
+
+	if progress.PrimaryTxID > st.startTxID {
+		replicated := float64(0)
+		if replicaTxID > st.startTxID {
+			replicated = float64(replicaTxID - st.startTxID)
+		}
+
+		percentage := 100 * replicated / float64(progress.PrimaryTxID-st.startTxID)
+		if percentage > 100 {
+			percentage = 100
+		}
+
+		// the primary may commit new transactions while catching up,
+		// the progress is not set back but the ETA accounts for them
+		if percentage > st.catchUpPercentage {
+			st.catchUpPercentage = percentage
+		}
+	}
+
+	progress.Percentage = st.catchUpPercentage
+
+	txsPerSec := st.applyRate.txsPerSec(st.appliedTxs, now)
+
+	if progress.PrimaryTxID > replicaTxID && txsPerSec > 0 {
+		pendingTxs := float64(progress.PrimaryTxID - replicaTxID)
+		progress.ETA = time.Duration(pendingTxs / txsPerSec * float64(time.Second))
+	}
+
+	return progress
+}
+
+// primaryTxID returns the latest transaction known to be committed by the primary,
+// which holds at least the transactions already fetched from it
+func (st *replicatorStatus) primaryTxID() uint64 {
+	if st.lastFetchedTxID > st.primaryCommittedTxID {
+		return st.lastFetchedTxID
+	}
+
+	return st.primaryCommittedTxID
+}
+
+// applyRate measures the rate at which transactions are applied over the last
+// one to two rate windows, so the rate follows recent changes
+type applyRate struct {
+	windowStartedAt time.Time
+	windowStartTxs  uint64
+
+	prevWindowStartedAt time.Time
+	prevWindowStartTxs  uint64
+}
+
+func (r *applyRate) observe(appliedTxs uint64, now time.Time) {
+	if r.windowStartedAt.IsZero() {
+		r.windowStartedAt, r.windowStartTxs = now, appliedTxs
+		r.prevWindowStartedAt, r.prevWindowStartTxs = now, appliedTxs
+		return
+	}
+
+	if now.Sub(r.windowStartedAt) >= progressRateWindow {
+		r.prevWindowStartedAt, r.prevWindowStartTxs = r.windowStartedAt, r.windowStartTxs
+		r.windowStartedAt, r.windowStartTxs = now, appliedTxs
+	}
+}
+
+func (r *applyRate) txsPerSec(appliedTxs uint64, now time.Time) float64 {
+	elapsed := now.Sub(r.prevWindowStartedAt)
+
+	if r.prevWindowStartedAt.IsZero() || elapsed <= 0 || appliedTxs < r.prevWindowStartTxs {
+		return 0
+	}
+
+	return float64(appliedTxs-r.prevWindowStartTxs) / elapsed.Seconds()
+}
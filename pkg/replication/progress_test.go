@@ -0,0 +1,160 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatchUpProgress(t *testing.T) {
+	t.Run("progress should be unknown until the primary state is known", func(t *testing.T) {
+		st := &replicatorStatus{startTxID: 100}
+		st.applyRate.observe(0, time.Now())
+
+		progress := st.catchUpProgress(100, time.Now())
+		require.False(t, progress.CaughtUp)
+		require.Zero(t, progress.Percentage)
+		require.Zero(t, progress.ETA)
+	})
+
+	t.Run("progress should not decrease when the primary advances", func(t *testing.T) {
+		const txsPerSec = 10
+
+		t0 := time.Now()
+
+		st := &replicatorStatus{startTxID: 100, primaryCommittedTxID: 1100}
+		st.applyRate.observe(0, t0)
+
+		replicaTxID := uint64(100)
+		lastPercentage := float64(0)
+
+		for sec := 1; sec <= 60; sec++ {
+			now := t0.Add(time.Duration(sec) * time.Second)
+
+			for i := 1; i <= txsPerSec; i++ {
+				st.appliedTxs++
+				st.applyRate.observe(st.appliedTxs, now.Add(time.Duration(i-txsPerSec)*time.Second/txsPerSec))
+			}
+
+			replicaTxID += txsPerSec
+			st.lastFetchedTxID = replicaTxID + 5
+
+			if sec == 30 {
+				// the primary committed new transactions during the catch-up
+				st.primaryCommittedTxID += 500
+			}
+
+			progress := st.catchUpProgress(replicaTxID, now)
+			require.False(t, progress.CaughtUp)
+			require.Equal(t, uint64(100), progress.StartTxID)
+			require.Equal(t, replicaTxID, progress.ReplicaTxID)
+			require.Equal(t, st.primaryCommittedTxID, progress.PrimaryTxID)
+
+			require.GreaterOrEqual(t, progress.Percentage, lastPercentage)
+			require.LessOrEqual(t, progress.Percentage, float64(100))
+			lastPercentage = progress.Percentage
+
+			// the ETA is estimated at the constant apply rate
+			expectedETA := time.Duration(progress.PrimaryTxID-replicaTxID) * time.Second / txsPerSec
+			require.InDelta(t, expectedETA.Seconds(), progress.ETA.Seconds(), 1)
+
+			if sec == 25 {
+				require.InDelta(t, 25, progress.Percentage, 0.01)
+			}
+
+			if sec == 30 {
+				// 300 of 1500 transactions replicated, but the progress is not set back from 29%
+				require.InDelta(t, 29, progress.Percentage, 0.01)
+			}
+
+			if sec == 60 {
+				require.InDelta(t, 40, progress.Percentage, 0.01)
+			}
+		}
+	})
+
+	t.Run("the ETA should follow the recent apply rate", func(t *testing.T) {
+		t0 := time.Now()
+
+		st := &replicatorStatus{startTxID: 0, primaryCommittedTxID: 10000}
+		st.applyRate.observe(0, t0)
+
+		// 100 tx/sec for a minute, then 10 tx/sec
+		for sec := 1; sec <= 90; sec++ {
+			rate := 100
+			if sec > 60 {
+				rate = 10
+			}
+
+			for i := 1; i <= rate; i++ {
+				st.appliedTxs++
+				st.applyRate.observe(st.appliedTxs, t0.Add(time.Duration(sec-1)*time.Second+time.Duration(i)*time.Second/time.Duration(rate)))
+			}
+		}
+
+		progress := st.catchUpProgress(st.appliedTxs, t0.Add(90*time.Second))
+		require.InDelta(t, 63, progress.Percentage, 0.01)
+
+		// the rate is measured over the last 20 seconds at most
+		require.InDelta(t, (10000-6300)/10, progress.ETA.Seconds(), 1)
+
+		// the ETA grows while no transaction is applied
+		stalled := st.catchUpProgress(st.appliedTxs, t0.Add(120*time.Second))
+		require.Greater(t, stalled.ETA, progress.ETA)
+	})
+
+	t.Run("progress should be complete once caught up", func(t *testing.T) {
+		st := &replicatorStatus{startTxID: 100, primaryCommittedTxID: 1100, caughtUp: true}
+		st.applyRate.observe(0, time.Now())
+
+		progress := st.catchUpProgress(1100, time.Now())
+		require.True(t, progress.CaughtUp)
+		require.Equal(t, float64(100), progress.Percentage)
+		require.Zero(t, progress.ETA)
+	})
+
+	t.Run("progress should start from the state of the replica", func(t *testing.T) {
+		db := newTestDB(t, "replicadb", true)
+
+		rOpts := DefaultOptions().
+			WithPrimaryDatabase("defaultdb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(1)
+
+		txReplicator, err := NewTxReplicator(xid.New(), db, rOpts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		err = txReplicator.Start()
+		require.NoError(t, err)
+		defer txReplicator.Stop()
+
+		state, err := db.CurrentState()
+		require.NoError(t, err)
+
+		progress := txReplicator.CatchUpProgress()
+		require.False(t, progress.CaughtUp)
+		require.Equal(t, state.TxId, progress.StartTxID)
+		require.Equal(t, state.TxId, progress.ReplicaTxID)
+		require.Zero(t, progress.Percentage)
+		require.Zero(t, progress.ETA)
+	})
+}
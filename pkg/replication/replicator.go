@@ -19,20 +19,27 @@ package replication
 import (
 	"context"
 	"crypto/sha256"
+	"crypto/tls"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/state"
 	"github.com/codenotary/immudb/pkg/database"
 	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/codenotary/immudb/pkg/stream"
 	"github.com/rs/xid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 )
 
 var ErrIllegalArguments = errors.New("illegal arguments")
@@ -41,10 +48,16 @@ var ErrAlreadyStopped = errors.New("already stopped")
 var ErrReplicaDivergedFromPrimary = errors.New("replica diverged from primary")
 var ErrNoSynchronousReplicationOnPrimary = errors.New("primary is not running with synchronous replication")
 var ErrInvalidReplicationMetadata = errors.New("invalid replication metadata retrieved")
+var ErrPrimaryNotReady = errors.New("primary is not ready")
+var ErrPrimaryBusy = errors.New("primary is too busy exporting transactions")
+var ErrPrimaryUUIDMismatch = errors.New("primary server UUID mismatch")
 
 type prefetchTxEntry struct {
 	data    []byte
 	addedAt time.Time
+
+	// ctx holds the trace context of the export
+	ctx context.Context
 }
 
 type TxReplicator struct {
@@ -55,6 +68,10 @@ type TxReplicator struct {
 
 	_primaryDB string // just a string denoting primary database i.e. db@host:port
 
+	// primaryDatabase is the name of the replicated database, it's switched to the configured alias
+	// when following it. Guarded by statusMutex
+	primaryDatabase string
+
 	logger logger.Logger
 
 	context    context.Context
@@ -62,10 +79,19 @@ type TxReplicator struct {
 
 	client client.ImmuClient
 
+	// primaryClient holds the connection to the primary while transactions are fetched from the preferred source
+	primaryClient client.ImmuClient
+
 	streamSrvFactory stream.ServiceFactory
 
 	lastTx uint64
 
+	// primaryUUID is pinned on first connection when the primary UUID check is enabled
+	primaryUUID string
+
+	// pendingAck holds the commit state allowed by the primary but not yet acknowledged
+	pendingAck *pendingAck
+
 	prefetchTxBuffer       chan prefetchTxEntry // buffered channel of exported txs
 	replicationConcurrency int
 
@@ -74,11 +100,75 @@ type TxReplicator struct {
 	delayer             Delayer
 	consecutiveFailures int
 
+	// txRateLimiter paces the fetching of transactions when a maximum rate is set
+	txRateLimiter *txRateLimiter
+
+	// reconnectRequested is set when a transaction could not be applied after retrying it in place,
+	// the connection to the primary is then re-established before fetching again
+	reconnectRequested int32
+
 	running bool
 
+	// caughtUp is set once there are no more transactions to be fetched from the primary
+	caughtUp bool
+
 	mutex sync.Mutex
 
+	// status is guarded by its own mutex so it can be read without
+	// waiting for the fetching loop, which may hold the main mutex while retrying
+	statusMutex sync.Mutex
+	status      replicatorStatus
+
+	// resumed is closed once the replication is resumed, it's nil unless paused. Guarded by statusMutex
+	resumed chan struct{}
+
 	metrics metrics
+
+	tracer     Tracer
+	propagator Propagator
+
+	// verifier replaces the replication of transactions in verify-only mode
+	verifier *txVerifier
+
+	// shadowTxs holds the transactions applied by the replica to be applied by the shadow, if enabled
+	shadowTxs chan []byte
+
+	// idleSince is when the last fetch found no new transaction, zero while transactions are being fetched.
+	// lastProbeAt is when the connection was last probed while idle
+	idleSince   time.Time
+	lastProbeAt time.Time
+
+	// payloadChecksumUnsupported is set once the primary is found not to send checksums of exported transactions
+	payloadChecksumUnsupported bool
+
+	// workers tracks the goroutines fetching and applying transactions, which may still be running once stopped
+	workers sync.WaitGroup
+}
+
+type replicatorStatus struct {
+	connected            bool
+	consecutiveFailures  int
+	failures             uint64
+	lastFetchedTxID      uint64
+	primaryCommittedTxID uint64
+	appliedTxs           uint64
+	lastErr              error
+	lastErrAt            time.Time
+
+	// primaryUUID is the UUID pinned by the primary UUID check
+	primaryUUID string
+
+	// source is the preferred source transactions are fetched from, empty when fetched from the primary
+	source string
+
+	// catch-up progress, see CatchUpProgress
+	caughtUp          bool
+	startTxID         uint64
+	catchUpPercentage float64
+	applyRate         applyRate
+
+	// live is set while the replica follows the primary after catching up, see trackLiveness
+	live bool
 }
 
 func NewTxReplicator(uuid xid.ID, db database.DB, opts *Options, logger logger.Logger) (*TxReplicator, error) {
@@ -86,18 +176,31 @@ func NewTxReplicator(uuid xid.ID, db database.DB, opts *Options, logger logger.L
 		return nil, ErrIllegalArguments
 	}
 
+	var tracer Tracer = noopTracer{}
+	if opts.tracer != nil {
+		tracer = opts.tracer
+	}
+
+	var propagator Propagator = noopPropagator{}
+	if opts.propagator != nil {
+		propagator = opts.propagator
+	}
+
 	return &TxReplicator{
 		uuid:                   uuid,
 		db:                     db,
 		opts:                   opts,
 		logger:                 logger,
-		_primaryDB:             fullAddress(opts.primaryDatabase, opts.primaryHost, opts.primaryPort),
+		_primaryDB:             opts.primaryDatabase + "@" + opts.primaryAddress(),
+		primaryDatabase:        opts.primaryDatabase,
 		streamSrvFactory:       stream.NewStreamServiceFactory(opts.streamChunkSize),
 		prefetchTxBuffer:       make(chan prefetchTxEntry, opts.prefetchTxBufferSize),
 		replicationConcurrency: opts.replicationCommitConcurrency,
 		allowTxDiscarding:      opts.allowTxDiscarding,
 		delayer:                opts.delayer,
 		metrics:                metricsForDb(db.GetName()),
+		tracer:                 tracer,
+		propagator:             propagator,
 	}, nil
 }
 
@@ -107,14 +210,33 @@ func (txr *TxReplicator) handleError(err error) (terminate bool) {
 
 	if err == nil {
 		txr.consecutiveFailures = 0
+		txr.updateStatus(func(st *replicatorStatus) {
+			st.consecutiveFailures = 0
+			st.setLastError(nil)
+		})
 		return false
 	}
 
-	if errors.Is(err, ErrAlreadyStopped) || errors.Is(err, ErrReplicaDivergedFromPrimary) {
+	if errors.Is(err, ErrAlreadyStopped) {
+		return true
+	}
+
+	if txr.context.Err() != nil {
+		// replication was cancelled, the outcome of the last attempt is not relevant
+		return true
+	}
+
+	if isTerminalError(err) {
+		txr.updateStatus(func(st *replicatorStatus) { st.setLastError(err) })
 		return true
 	}
 
 	txr.consecutiveFailures++
+	txr.updateStatus(func(st *replicatorStatus) {
+		st.consecutiveFailures = txr.consecutiveFailures
+		st.failures++
+		st.setLastError(err)
+	})
 
 	txr.logger.Infof("Replication error on database '%s' from '%s' (%d consecutive failures). Reason: %s",
 		txr.db.GetName(),
@@ -132,13 +254,24 @@ func (txr *TxReplicator) handleError(err error) (terminate bool) {
 
 	retryableError := !strings.Contains(err.Error(), "no session found")
 
-	if txr.consecutiveFailures >= 3 || !retryableError {
+	// the connection is kept while the primary is busy, it's working as expected
+	busyPrimary := errors.Is(err, ErrPrimaryBusy)
+
+	if (txr.consecutiveFailures >= 3 && !busyPrimary) || !retryableError {
 		txr.disconnect()
 	}
 
 	return false
 }
 
+// isTerminalError tells if the replication must be stopped instead of being retried
+func isTerminalError(err error) bool {
+	return errors.Is(err, ErrReplicaDivergedFromPrimary) ||
+		errors.Is(err, ErrPrimaryUUIDMismatch) ||
+		errors.Is(err, ErrPrimaryDatabaseMissing) ||
+		errors.Is(err, ErrTxPayloadCorrupted)
+}
+
 func (txr *TxReplicator) Start() error {
 	txr.mutex.Lock()
 	defer txr.mutex.Unlock()
@@ -149,40 +282,155 @@ func (txr *TxReplicator) Start() error {
 
 	txr.logger.Infof("Initializing replication from '%s' to '%s'...", txr._primaryDB, txr.db.GetName())
 
+	if txr.opts.storagePreflight {
+		err := txr.checkStorage(context.Background())
+		if err != nil {
+			return err
+		}
+	}
+
+	if txr.opts.applyTransform != nil && txr.db.IsSyncReplicationEnabled() {
+		return ErrTransformWithSyncReplication
+	}
+
+	if txr.opts.preferredSource != "" && txr.db.IsSyncReplicationEnabled() {
+		return ErrPreferredSourceWithSyncReplication
+	}
+
+	if txr.opts.checkpointPersistence {
+		err := txr.loadCheckpoint(context.Background())
+		if err != nil {
+			return err
+		}
+	}
+
+	err := txr.relaxDurability()
+	if err != nil {
+		return err
+	}
+
 	txr.context, txr.cancelFunc = context.WithCancel(context.Background())
 
 	txr.running = true
 
+	txr.pendingAck = nil
+
+	txr.txRateLimiter = nil
+	if txr.opts.maxTxPerSecond > 0 {
+		txr.txRateLimiter = newTxRateLimiter(txr.opts.maxTxPerSecond)
+	}
+
+	ctx := txr.context
+
+	if len(txr.opts.schedule) > 0 {
+		active := scheduleActive(txr.opts.schedule, time.Now())
+		if active {
+			txr.Resume()
+		} else {
+			txr.Pause()
+		}
+
+		go txr.runSchedule(ctx, active)
+	}
+
+	txr.workers.Add(1)
+
 	go func() {
+		defer txr.workers.Done()
+
 		txr.logger.Infof("Replication for '%s' started fetching transaction from '%s'...", txr.db.GetName(), txr._primaryDB)
 
 		var err error
 
 		for {
-			err := txr.fetchNextTx()
+			if !txr.waitResumedFetching() {
+				break
+			}
+
+			var progress bool
+
+			progress, err = txr.fetchNextTx()
 			if txr.handleError(err) {
 				break
 			}
+
+			if err == nil {
+				txr.trackLiveness()
+			}
+
+			if err == nil && !progress {
+				txr.metrics.idlePolls.Inc()
+				txr.waitIdlePollInterval(ctx)
+			}
 		}
 
 		txr.logger.Infof("Replication for '%s' stopped fetching transaction from '%s'", txr.db.GetName(), txr._primaryDB)
 
-		if errors.Is(err, ErrReplicaDivergedFromPrimary) {
+		if isTerminalError(err) {
 			txr.Stop()
 		}
 	}()
 
 	txr.metrics.reset()
 
-	for i := 0; i < txr.replicationConcurrency; i++ {
+	var startTxID, precommittedTxID uint64
+
+	state, err := txr.db.CurrentState()
+	if err == nil {
+		startTxID = state.TxId
+		precommittedTxID = state.PrecommittedTxId
+	}
+
+	txr.updateStatus(func(st *replicatorStatus) {
+		*st = replicatorStatus{startTxID: startTxID}
+		st.applyRate.observe(0, time.Now())
+	})
+
+	applyTx := txr.replicateSingleTx
+	concurrency := txr.replicationConcurrency
+
+	if txr.opts.verifyOnly {
+		txr.verifier = &txVerifier{db: txr.db}
+		txr.lastTx = 0
+		applyTx = txr.verifySingleTx
+		// transactions must be verified in order
+		concurrency = 1
+	}
+
+	txr.shadowTxs = nil
+
+	if txr.opts.shadowApplier != nil {
+		txr.startShadowApply(txr.context, precommittedTxID)
+	}
+
+	if txr.opts.statusLogInterval > 0 {
+		go txr.logStatusPeriodically(txr.context, txr.opts.statusLogInterval)
+	}
+
+	if maxTxs := txr.coalesceMaxTxs(); maxTxs > 1 && !txr.opts.verifyOnly {
+		// coalesced transactions must be consecutive, thus they are applied by a single replicator
+		txr.workers.Add(1)
+
+		go func() {
+			defer txr.workers.Done()
+			txr.applyCoalesced(maxTxs)
+		}()
+		concurrency = 0
+	}
+
+	txr.workers.Add(concurrency)
+
+	for i := 0; i < concurrency; i++ {
 		go func() {
+			defer txr.workers.Done()
+
 			txr.metrics.replicators.Inc()
 			defer txr.metrics.replicators.Dec()
 
 			for etx := range txr.prefetchTxBuffer {
 				txr.metrics.txWaitQueueHistogram.Observe(time.Since(etx.addedAt).Seconds())
 
-				if !txr.replicateSingleTx(etx.data) {
+				if !applyTx(etx.ctx, etx.data) {
 					break
 				}
 			}
@@ -194,39 +442,187 @@ func (txr *TxReplicator) Start() error {
 	return nil
 }
 
-func (txr *TxReplicator) replicateSingleTx(data []byte) bool {
+// StartAndWaitReady starts the replication once the primary is reachable.
+// It fails with ErrPrimaryNotReady if the primary can not be reached within the configured ready timeout
+func (txr *TxReplicator) StartAndWaitReady(ctx context.Context) error {
+	txr.mutex.Lock()
+	running := txr.running
+	txr.mutex.Unlock()
+
+	if running {
+		return ErrAlreadyRunning
+	}
+
+	err := txr.waitForPrimary(ctx)
+	if err != nil {
+		return err
+	}
+
+	return txr.Start()
+}
+
+func (txr *TxReplicator) waitForPrimary(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, txr.opts.readyTimeout)
+	defer cancel()
+
+	txr.logger.Infof("Waiting for '%s' to be reachable before replicating to '%s'...", txr._primaryDB, txr.db.GetName())
+
+	for attempts := 1; ; attempts++ {
+		err := txr.probePrimary(ctx)
+		if err == nil {
+			txr.logger.Infof("Primary '%s' is reachable after %d attempts", txr._primaryDB, attempts)
+			return nil
+		}
+
+		timer := time.NewTimer(txr.delayer.DelayAfter(attempts))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: '%s' could not be reached after %d attempts. Reason: %v", ErrPrimaryNotReady, txr._primaryDB, attempts, err)
+		case <-timer.C:
+		}
+	}
+}
+
+func (txr *TxReplicator) probePrimary(ctx context.Context) error {
+	c := txr.newPrimaryClient()
+
+	err := c.OpenSession(ctx, []byte(txr.opts.primaryUsername), []byte(txr.opts.primaryPassword), txr.primaryDatabaseName())
+	if err != nil {
+		return err
+	}
+
+	return c.CloseSession(context.Background())
+}
+
+func (txr *TxReplicator) replicateSingleTx(ctx context.Context, data []byte) bool {
+	if !txr.waitResumed() || !txr.waitApplyDelay(data) {
+		return false
+	}
+
 	txr.metrics.replicatorsActive.Inc()
 	defer txr.metrics.replicatorsActive.Dec()
 	defer txr.metrics.replicationTimeHistogramTimer().ObserveDuration()
 
+	ctx, span := txr.tracer.Start(ctx, replicateSpanName)
+
 	consecutiveFailures := 0
 
+	applyTx := func() error {
+		_, err := txr.db.ReplicateTx(ctx, data)
+		return err
+	}
+
+	if txr.opts.applyTransform != nil {
+		tx, err := txr.transformTx(data)
+		if err != nil {
+			txr.haltOnRejectedTx(err)
+			span.End(err)
+			return false
+		}
+
+		applyTx = func() error {
+			_, err := txr.db.ReplicateTransformedTx(ctx, tx.Header, tx.Entries)
+			return err
+		}
+	}
+
 	// replication must be retried as many times as necessary
 	for {
-		_, err := txr.db.ReplicateTx(txr.context, data)
+		err := applyTx()
 		if err == nil {
 			break // transaction successfully replicated
 		}
 		if errors.Is(err, ErrAlreadyStopped) {
+			span.End(err)
 			return false
 		}
 
-		if strings.Contains(err.Error(), "tx already committed") {
+		if txr.alreadyReplicated(data, err) {
 			break // transaction successfully replicated
 		}
 
+		if txr.context.Err() != nil {
+			// replication was stopped meanwhile, the failure must not hide the reason why it was stopped
+			span.End(err)
+			return false
+		}
+
 		txr.logger.Infof("Failed to replicate transaction from '%s' to '%s'. Reason: %s", txr._primaryDB, txr.db.GetName(), err.Error())
 
+		txr.updateStatus(func(st *replicatorStatus) { st.setLastError(err) })
+
 		consecutiveFailures++
 
-		if !txr.replicationFailureDelay(consecutiveFailures) {
+		if consecutiveFailures <= txr.opts.applyRetries {
+			// transient errors are retried in place, without reconnecting to the primary
+			if !txr.applyRetryDelay(consecutiveFailures) {
+				span.End(err)
+				return false
+			}
+
+			continue
+		}
+
+		if consecutiveFailures == txr.opts.applyRetries+1 {
+			txr.logger.Infof("Transaction from '%s' could not be replicated to '%s' after %d retries, reconnecting...", txr._primaryDB, txr.db.GetName(), txr.opts.applyRetries)
+			atomic.StoreInt32(&txr.reconnectRequested, 1)
+		}
+
+		if !txr.replicationFailureDelay(consecutiveFailures - txr.opts.applyRetries) {
+			span.End(err)
 			return false
 		}
 	}
 
+	span.End(nil)
+
+	txr.updateStatus(func(st *replicatorStatus) {
+		st.appliedTxs++
+		st.applyRate.observe(st.appliedTxs, time.Now())
+		st.setLastError(nil)
+	})
+
+	txr.trackLiveness()
+
+	txr.enqueueShadowTx(data)
+
 	return true
 }
 
+// alreadyReplicated returns true if the transaction was committed by the replica despite err,
+// re-applying a transaction is then a no-op, so that retries never apply a transaction twice
+func (txr *TxReplicator) alreadyReplicated(data []byte, err error) bool {
+	if strings.Contains(err.Error(), "tx already committed") {
+		return true
+	}
+
+	hdr, hdrErr := exportedTxHeader(data)
+	if hdrErr != nil {
+		return false
+	}
+
+	state, stateErr := txr.db.CurrentState()
+	if stateErr != nil {
+		return false
+	}
+
+	return hdr.ID <= state.PrecommittedTxId
+}
+
+func (txr *TxReplicator) applyRetryDelay(attempt int) bool {
+	txr.metrics.replicationRetries.Inc()
+
+	timer := time.NewTimer(time.Duration(attempt) * txr.opts.applyRetryDelay)
+	select {
+	case <-txr.context.Done():
+		timer.Stop()
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
 func (txr *TxReplicator) replicationFailureDelay(consecutiveFailures int) bool {
 	txr.metrics.replicationRetries.Inc()
 
@@ -243,73 +639,277 @@ func (txr *TxReplicator) replicationFailureDelay(consecutiveFailures int) bool {
 	}
 }
 
-func fullAddress(db, address string, port int) string {
-	return fmt.Sprintf("%s@%s:%d", db, address, port)
-}
+func (txr *TxReplicator) connect() (err error) {
+	ctx, span := txr.tracer.Start(txr.context, connectSpanName)
+	defer func() { span.End(err) }()
 
-func (txr *TxReplicator) connect() error {
-	txr.logger.Infof("Connecting to '%s':'%d' for database '%s'...",
-		txr.opts.primaryHost,
-		txr.opts.primaryPort,
+	txr.logger.Infof("Connecting to '%s' for database '%s'...",
+		txr.opts.primaryAddress(),
 		txr.db.GetName())
 
-	opts := client.DefaultOptions().
-		WithAddress(txr.opts.primaryHost).
-		WithPort(txr.opts.primaryPort).
-		WithDisableIdentityCheck(true)
-	txr.client = client.NewClient().WithOptions(opts)
+	dbName := txr.primaryDatabaseName()
 
-	err := txr.client.OpenSession(
-		txr.context, []byte(txr.opts.primaryUsername), []byte(txr.opts.primaryPassword), txr.opts.primaryDatabase)
+	if txr.opts.clientPool == nil {
+		txr.client, err = txr.openSession(ctx)
+	} else {
+		poolKey := dbName + "@" + txr.opts.primaryAddress() + "#" + txr.opts.primaryUsername
+		if txr.opts.tlsConfig != nil {
+			// connections verifying the primary with different names are not shared
+			poolKey += "#tls:" + txr.opts.tlsServerName
+		}
+		txr.client, err = txr.opts.clientPool.acquire(ctx, poolKey, txr.openSession)
+	}
+	if isPrimaryDatabaseMissing(err) {
+		return txr.primaryDatabaseMissing(dbName, err)
+	}
 	if err != nil {
 		return err
 	}
 
-	txr.logger.Infof("Connection to '%s':'%d' for database '%s' successfully established",
-		txr.opts.primaryHost,
-		txr.opts.primaryPort,
+	if txr.opts.primaryUUIDCheck {
+		err = txr.checkPrimaryUUID(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if txr.opts.leastPrivilegeCheck {
+		txr.checkLeastPrivilege(ctx, txr.client)
+	}
+
+	txr.updateStatus(func(st *replicatorStatus) { st.connected = true })
+
+	txr.updatePrimaryCommittedTxID(ctx)
+
+	txr.logger.Infof("Connection to '%s' for database '%s' successfully established",
+		txr.opts.primaryAddress(),
 		txr.db.GetName())
 
+	txr.connectPreferredSource(ctx)
+
+	return nil
+}
+
+// checkPrimaryUUID pins the UUID of the primary server on first connection
+// and ensures later connections are established with the same server
+func (txr *TxReplicator) checkPrimaryUUID(ctx context.Context) error {
+	primaryUUID, err := state.NewUUIDProvider(txr.client.GetServiceClient()).CurrentUUID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if txr.primaryUUID == "" {
+		txr.primaryUUID = primaryUUID
+		txr.updateStatus(func(st *replicatorStatus) { st.primaryUUID = primaryUUID })
+		txr.logger.Infof("Primary '%s' pinned with UUID '%s'", txr._primaryDB, primaryUUID)
+
+		if txr.opts.checkpointPersistence {
+			return txr.saveCheckpoint()
+		}
+
+		return nil
+	}
+
+	if primaryUUID != txr.primaryUUID {
+		txr.logger.Errorf("Primary '%s' changed its UUID from '%s' to '%s'", txr._primaryDB, txr.primaryUUID, primaryUUID)
+		return fmt.Errorf("%w: '%s' expected but '%s' found", ErrPrimaryUUIDMismatch, txr.primaryUUID, primaryUUID)
+	}
+
 	return nil
 }
 
+// updatePrimaryCommittedTxID refreshes the latest transaction known to be committed by the primary,
+// which is otherwise only received along with exported transactions when using synchronous replication
+func (txr *TxReplicator) updatePrimaryCommittedTxID(ctx context.Context) {
+	primaryState, err := txr.client.CurrentState(ctx)
+	if err != nil {
+		txr.logger.Warningf("Failed to read the state of '%s'. Reason: %s", txr._primaryDB, err.Error())
+		return
+	}
+
+	txr.metrics.primaryCommittedTxID.Set(float64(primaryState.TxId))
+
+	txr.updateStatus(func(st *replicatorStatus) {
+		if primaryState.TxId > st.primaryCommittedTxID {
+			st.primaryCommittedTxID = primaryState.TxId
+		}
+	})
+}
+
+func (txr *TxReplicator) openSession(ctx context.Context) (client.ImmuClient, error) {
+	c := txr.newPrimaryClient()
+
+	err := c.OpenSession(ctx, []byte(txr.opts.primaryUsername), []byte(txr.opts.primaryPassword), txr.primaryDatabaseName())
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (txr *TxReplicator) newPrimaryClient() client.ImmuClient {
+	var tlsConfig *tls.Config
+	if txr.opts.tlsConfig != nil {
+		tlsConfig = txr.opts.primaryTLSConfig()
+	}
+
+	opts := newClientOptions(txr.opts.primaryHost, txr.opts.primaryPort, tlsConfig)
+
+	if txr.opts.unixSocket != "" {
+		unixSocket := txr.opts.unixSocket
+
+		opts.WithDialOptions(append(opts.DialOptions,
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", unixSocket)
+			}),
+		))
+	}
+
+	return client.NewClient().WithOptions(opts)
+}
+
+func newClientOptions(host string, port int, tlsConfig *tls.Config) *client.Options {
+	opts := client.DefaultOptions().
+		WithAddress(host).
+		WithPort(port).
+		WithDisableIdentityCheck(true)
+
+	if tlsConfig != nil {
+		opts.WithDialOptions([]grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))})
+	}
+
+	return opts
+}
+
+// disconnect closes the connection to the primary, pooled connections are not reused as
+// disconnections are due to failures
 func (txr *TxReplicator) disconnect() {
+	txr.closeConnection(false)
+}
+
+// closeConnection closes the connection to the primary or, if reusable,
+// returns it to the pool when connections are pooled
+func (txr *TxReplicator) closeConnection(reusable bool) {
 	if txr.client == nil {
 		return
 	}
 
-	txr.logger.Infof("Disconnecting from '%s':'%d' for database '%s'...", txr.opts.primaryHost, txr.opts.primaryPort, txr.db.GetName())
+	txr.logger.Infof("Disconnecting from '%s' for database '%s'...", txr.opts.primaryAddress(), txr.db.GetName())
+
+	txr.closePreferredSource()
+
+	pooled, isPooled := txr.client.(*pooledClient)
 
-	txr.client.CloseSession(txr.context)
+	switch {
+	case isPooled && reusable:
+		txr.opts.clientPool.release(pooled)
+	case isPooled:
+		txr.opts.clientPool.discard(pooled)
+	default:
+		txr.client.CloseSession(txr.context)
+	}
 
 	txr.client = nil
 
-	txr.logger.Infof("Disconnected from '%s':'%d' for database '%s'", txr.opts.primaryHost, txr.opts.primaryPort, txr.db.GetName())
+	txr.updateStatus(func(st *replicatorStatus) { st.connected = false })
+
+	txr.logger.Infof("Disconnected from '%s' for database '%s'", txr.opts.primaryAddress(), txr.db.GetName())
 }
 
-func (txr *TxReplicator) fetchNextTx() error {
+// waitIdlePollInterval avoids fetching again right away from a primary with no new transactions
+func (txr *TxReplicator) waitIdlePollInterval(ctx context.Context) {
+	if txr.opts.idlePollInterval == 0 {
+		return
+	}
+
+	timer := time.NewTimer(txr.opts.idlePollInterval)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// handlePrecommitDivergence discards the transactions precommitted by the replica when its precommit state
+// diverged from the primary's, if tx discarding is allowed. An EventPrecommitDivergence is emitted in any case
+func (txr *TxReplicator) handlePrecommitDivergence(commitState *schema.ImmutableState, nextTx uint64, cause error) (bool, error) {
+	event := &Event{
+		Type: EventPrecommitDivergence,
+		TxID: commitState.PrecommittedTxId,
+		Err:  cause,
+	}
+
+	if !txr.allowTxDiscarding {
+		txr.logger.Errorf("replica precommit state at '%s' diverged from primary's", txr.db.GetName())
+
+		event.Outcome = OutcomeRejected
+		txr.emitEvent(event)
+
+		return false, ErrReplicaDivergedFromPrimary
+	}
+
+	txr.logger.Infof("discarding precommit txs since %d from '%s'. Reason: %s", nextTx, txr.db.GetName(), cause.Error())
+
+	err := txr.db.DiscardPrecommittedTxsSince(commitState.TxId + 1)
+	if err != nil {
+		event.Outcome = OutcomeFailed
+		event.Err = err
+		txr.emitEvent(event)
+
+		return false, err
+	}
+
+	txr.lastTx = commitState.TxId
+	txr.pendingAck = nil
+
+	txr.logger.Infof("precommit txs successfully discarded from '%s'", txr.db.GetName())
+
+	event.DiscardedTxs = commitState.PrecommittedTxId - commitState.TxId
+	event.Outcome = OutcomeRecovered
+	txr.emitEvent(event)
+
+	return true, nil
+}
+
+// fetchNextTx fetches the next transaction from the primary, progress is false when
+// there was no new transaction to be replicated
+func (txr *TxReplicator) fetchNextTx() (progress bool, err error) {
 	txr.mutex.Lock()
 	defer txr.mutex.Unlock()
 
 	if !txr.running {
-		return ErrAlreadyStopped
+		return false, ErrAlreadyStopped
+	}
+
+	if atomic.CompareAndSwapInt32(&txr.reconnectRequested, 1, 0) {
+		txr.disconnect()
 	}
 
 	if txr.client == nil {
 		err := txr.connect()
 		if err != nil {
-			return err
+			return false, err
+		}
+	}
+
+	if txr.txRateLimiter != nil {
+		err := txr.txRateLimiter.wait(txr.context)
+		if err != nil {
+			return false, err
 		}
 	}
 
 	commitState, err := txr.db.CurrentState()
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	syncReplicationEnabled := txr.db.IsSyncReplicationEnabled()
+	// transactions are verified from the first one in verify-only mode
+	syncReplicationEnabled := !txr.opts.verifyOnly && txr.db.IsSyncReplicationEnabled()
 
-	if txr.lastTx == 0 {
+	if txr.lastTx == 0 && !txr.opts.verifyOnly {
 		txr.lastTx = commitState.PrecommittedTxId
 	}
 
@@ -327,46 +927,70 @@ func (txr *TxReplicator) fetchNextTx() error {
 		}
 	}
 
-	exportTxStream, err := txr.client.ExportTx(txr.context, &schema.ExportTxRequest{
+	spanCtx, span := txr.tracer.Start(txr.context, exportSpanName)
+
+	ctx := txr.withTraceContext(spanCtx)
+
+	if txr.opts.payloadChecksum {
+		ctx = metadata.AppendToOutgoingContext(ctx, "payload-checksum", "sha256")
+	}
+
+	exportCtx, cancelExport := txr.idleExportContext(ctx)
+	defer cancelExport()
+
+	// precommitted transactions may still be discarded by the primary, they are only fetched
+	// by sync replicas which take part in their commit. Async replicas only mirror committed ones
+	exportTxStream, err := txr.client.ExportTx(exportCtx, &schema.ExportTxRequest{
 		Tx:                nextTx,
 		ReplicaState:      state,
 		AllowPreCommitted: syncReplicationEnabled,
 	})
 	if err != nil {
-		return err
+		span.End(err)
+
+		if errors.Is(exportCtx.Err(), context.DeadlineExceeded) {
+			return false, txr.idleConnectionLost(err)
+		}
+
+		return false, err
 	}
 
 	receiver := txr.streamSrvFactory.NewMsgReceiver(exportTxStream)
 	etx, err := receiver.ReadFully()
 
+	if errors.Is(err, io.EOF) {
+		span.End(nil)
+	} else {
+		span.End(err)
+	}
+
+	if err != nil && !errors.Is(err, io.EOF) && errors.Is(exportCtx.Err(), context.DeadlineExceeded) {
+		return false, txr.idleConnectionLost(err)
+	}
+
 	if err != nil && !errors.Is(err, io.EOF) {
+		if strings.Contains(err.Error(), "too many concurrent exports") {
+			// the export is retried with a backoff
+			return false, fmt.Errorf("%w: %v", ErrPrimaryBusy, err)
+		}
+
 		if strings.Contains(err.Error(), "commit state diverged from") {
 			txr.logger.Errorf("replica commit state at '%s' diverged from primary's", txr.db.GetName())
-			return ErrReplicaDivergedFromPrimary
+			return false, ErrReplicaDivergedFromPrimary
 		}
 
 		if strings.Contains(err.Error(), "precommit state diverged from") {
+			return txr.handlePrecommitDivergence(commitState, nextTx, err)
+		}
 
-			if !txr.allowTxDiscarding {
-				txr.logger.Errorf("replica precommit state at '%s' diverged from primary's", txr.db.GetName())
-				return ErrReplicaDivergedFromPrimary
-			}
-
-			txr.logger.Infof("discarding precommit txs since %d from '%s'. Reason: %s", nextTx, txr.db.GetName(), err.Error())
-
-			err = txr.db.DiscardPrecommittedTxsSince(commitState.TxId + 1)
-			if err != nil {
-				return err
-			}
-
-			txr.lastTx = commitState.TxId
-
-			txr.logger.Infof("precommit txs successfully discarded from '%s'", txr.db.GetName())
+		return false, err
+	}
 
-			return nil
+	if txr.opts.payloadChecksum && len(etx) > 0 {
+		err = txr.verifyPayloadChecksum(nextTx, etx, exportTxStream.Trailer())
+		if err != nil {
+			return false, err
 		}
-
-		return err
 	}
 
 	if syncReplicationEnabled {
@@ -375,13 +999,13 @@ func (txr *TxReplicator) fetchNextTx() error {
 		if len(md.Get("may-commit-up-to-txid-bin")) == 0 ||
 			len(md.Get("may-commit-up-to-alh-bin")) == 0 ||
 			len(md.Get("committed-txid-bin")) == 0 {
-			return ErrNoSynchronousReplicationOnPrimary
+			return false, ErrNoSynchronousReplicationOnPrimary
 		}
 
 		if len(md.Get("may-commit-up-to-txid-bin")[0]) != 8 ||
 			len(md.Get("may-commit-up-to-alh-bin")[0]) != sha256.Size ||
 			len(md.Get("committed-txid-bin")[0]) != 8 {
-			return ErrInvalidReplicationMetadata
+			return false, ErrInvalidReplicationMetadata
 		}
 
 		mayCommitUpToTxID := binary.BigEndian.Uint64([]byte(md.Get("may-commit-up-to-txid-bin")[0]))
@@ -393,29 +1017,55 @@ func (txr *TxReplicator) fetchNextTx() error {
 		txr.metrics.primaryCommittedTxID.Set(float64(committedTxID))
 		txr.metrics.allowCommitUpToTxID.Set(float64(mayCommitUpToTxID))
 
+		txr.updateStatus(func(st *replicatorStatus) { st.primaryCommittedTxID = committedTxID })
+
 		if mayCommitUpToTxID > commitState.TxId {
-			err = txr.db.AllowCommitUpto(mayCommitUpToTxID, mayCommitUpToAlh)
+			err = txr.allowCommitUpto(mayCommitUpToTxID, mayCommitUpToAlh, commitState.TxId, len(etx) == 0)
 			if err != nil {
 				if strings.Contains(err.Error(), "commit state diverged from") {
 					txr.logger.Errorf("replica commit state at '%s' diverged from primary's", txr.db.GetName())
-					return ErrReplicaDivergedFromPrimary
+					return false, ErrReplicaDivergedFromPrimary
 				}
 
-				return err
+				return false, err
 			}
 		}
 	}
 
 	if len(etx) > 0 {
 		// in some cases the transaction is not provided but only the primary commit state
-		txr.prefetchTxBuffer <- prefetchTxEntry{
+		select {
+		case txr.prefetchTxBuffer <- prefetchTxEntry{
 			data:    etx,
 			addedAt: time.Now(),
+			ctx:     spanCtx,
+		}:
+		case <-txr.context.Done():
+			// replicators may have stopped consuming transactions e.g. after a transaction was rejected
+			return false, ErrAlreadyStopped
 		}
 		txr.lastTx++
+
+		txr.markActive()
+
+		txr.updateStatus(func(st *replicatorStatus) { st.lastFetchedTxID = txr.lastTx })
+
+		return true, nil
 	}
 
-	return nil
+	// no transaction was provided because the replica is up to date, unless fetched from a lagging preferred source
+	if txr.fallBackToPrimaryIfLagging(txr.context) {
+		return true, nil
+	}
+
+	txr.markCaughtUp()
+
+	err = txr.probeIdleConnection()
+	if err != nil {
+		return false, err
+	}
+
+	return false, nil
 }
 
 func (txr *TxReplicator) Stop() error {
@@ -434,7 +1084,16 @@ func (txr *TxReplicator) Stop() error {
 
 	close(txr.prefetchTxBuffer)
 
-	txr.disconnect()
+	txr.closeConnection(true)
+
+	txr.restoreDurability()
+
+	if txr.opts.checkpointPersistence {
+		err := txr.saveCheckpoint()
+		if err != nil {
+			txr.logger.Warningf("Replication checkpoint of database '%s' could not be saved: %v", txr.db.GetName(), err)
+		}
+	}
 
 	txr.running = false
 
@@ -442,3 +1101,87 @@ func (txr *TxReplicator) Stop() error {
 
 	return nil
 }
+
+func (txr *TxReplicator) updateStatus(update func(st *replicatorStatus)) {
+	txr.statusMutex.Lock()
+	defer txr.statusMutex.Unlock()
+
+	update(&txr.status)
+}
+
+// LastError returns the most recent replication failure and when it occurred.
+// It returns a nil error once fetching or replicating a transaction succeeds again
+func (txr *TxReplicator) LastError() (error, time.Time) {
+	st := txr.currentStatus()
+	return st.lastErr, st.lastErrAt
+}
+
+func (st *replicatorStatus) setLastError(err error) {
+	st.lastErr = err
+
+	if err == nil {
+		st.lastErrAt = time.Time{}
+	} else {
+		st.lastErrAt = time.Now()
+	}
+}
+
+func (txr *TxReplicator) currentStatus() replicatorStatus {
+	txr.statusMutex.Lock()
+	defer txr.statusMutex.Unlock()
+
+	return txr.status
+}
+
+func (txr *TxReplicator) logStatusPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastLoggedAt := time.Now()
+	lastAppliedTxs := uint64(0)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			st := txr.currentStatus()
+
+			txr.logStatus(st, st.appliedTxs-lastAppliedTxs, now.Sub(lastLoggedAt))
+
+			lastLoggedAt = now
+			lastAppliedTxs = st.appliedTxs
+		}
+	}
+}
+
+func (txr *TxReplicator) logStatus(st replicatorStatus, appliedSinceLastLog uint64, elapsed time.Duration) {
+	var replicaTxID uint64
+
+	state, err := txr.db.CurrentState()
+	if err == nil {
+		replicaTxID = state.TxId
+	}
+
+	primaryTxID := st.primaryTxID()
+
+	var lag uint64
+	if primaryTxID > replicaTxID {
+		lag = primaryTxID - replicaTxID
+	}
+
+	var txsPerSec float64
+	if elapsed > 0 {
+		txsPerSec = float64(appliedSinceLastLog) / elapsed.Seconds()
+	}
+
+	txr.logger.Infof("Replication status of '%s' from '%s': connected=%t, lag=%d, applied=%d (%.2f tx/sec), failed attempts=%d",
+		txr.db.GetName(),
+		txr._primaryDB,
+		st.connected,
+		lag,
+		st.appliedTxs,
+		txsPerSec,
+		st.consecutiveFailures,
+	)
+}
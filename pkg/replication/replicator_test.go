@@ -17,9 +17,15 @@ limitations under the License.
 package replication
 
 import (
+	"context"
+	"errors"
 	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/database"
 	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/rs/xid"
@@ -60,3 +66,274 @@ func TestReplication(t *testing.T) {
 	err = txReplicator.Stop()
 	require.NoError(t, err)
 }
+
+func TestReplicationStatusLog(t *testing.T) {
+	path := t.TempDir()
+
+	delayer := &expBackoff{
+		retryMinDelay: 10 * time.Millisecond,
+		retryMaxDelay: 10 * time.Millisecond,
+		retryDelayExp: 1,
+	}
+
+	rOpts := DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(1).
+		WithPrimaryUsername("immudb").
+		WithPrimaryPassword("immudb").
+		WithDelayer(delayer).
+		WithStatusLogInterval(100 * time.Millisecond)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	db, err := database.NewDB("replicated_defaultdb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(path), logger)
+	require.NoError(t, err)
+
+	txReplicator, err := NewTxReplicator(xid.New(), db, rOpts, logger)
+	require.NoError(t, err)
+
+	err = txReplicator.Start()
+	require.NoError(t, err)
+
+	time.Sleep(550 * time.Millisecond)
+
+	err = txReplicator.Stop()
+	require.NoError(t, err)
+
+	var statusLines []string
+	for _, line := range logger.GetLogs() {
+		if strings.Contains(line, "Replication status of 'replicated_defaultdb' from 'defaultdb@127.0.0.1:1'") {
+			statusLines = append(statusLines, line)
+		}
+	}
+
+	require.GreaterOrEqual(t, len(statusLines), 3)
+	require.LessOrEqual(t, len(statusLines), 6)
+
+	lastLine := statusLines[len(statusLines)-1]
+	require.Contains(t, lastLine, "connected=false")
+	require.Contains(t, lastLine, "lag=0")
+	require.Contains(t, lastLine, "applied=0 (0.00 tx/sec)")
+	require.NotContains(t, lastLine, "failed attempts=0")
+}
+
+func TestReplicationStatusLogDisabled(t *testing.T) {
+	path := t.TempDir()
+
+	rOpts := DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(1)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	db, err := database.NewDB("replicated_defaultdb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(path), logger)
+	require.NoError(t, err)
+
+	txReplicator, err := NewTxReplicator(xid.New(), db, rOpts, logger)
+	require.NoError(t, err)
+
+	err = txReplicator.Start()
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	err = txReplicator.Stop()
+	require.NoError(t, err)
+
+	for _, line := range logger.GetLogs() {
+		require.NotContains(t, line, "Replication status of")
+	}
+}
+
+func TestReplicationStartAndWaitReadyTimeout(t *testing.T) {
+	path := t.TempDir()
+
+	delayer := &expBackoff{
+		retryMinDelay: 10 * time.Millisecond,
+		retryMaxDelay: 10 * time.Millisecond,
+		retryDelayExp: 1,
+	}
+
+	rOpts := DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(1).
+		WithDelayer(delayer).
+		WithReadyTimeout(200 * time.Millisecond)
+
+	logger := logger.NewMemoryLoggerWithLevel(logger.LogInfo)
+
+	db, err := database.NewDB("replicated_defaultdb", nil, database.DefaultOption().AsReplica(true).WithDBRootPath(path), logger)
+	require.NoError(t, err)
+
+	txReplicator, err := NewTxReplicator(xid.New(), db, rOpts, logger)
+	require.NoError(t, err)
+
+	err = txReplicator.StartAndWaitReady(context.Background())
+	require.ErrorIs(t, err, ErrPrimaryNotReady)
+
+	// the replicator was not started
+	err = txReplicator.Stop()
+	require.ErrorIs(t, err, ErrAlreadyStopped)
+
+	for _, line := range logger.GetLogs() {
+		require.NotContains(t, line, "Replication error on database")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = txReplicator.StartAndWaitReady(ctx)
+	require.ErrorIs(t, err, ErrPrimaryNotReady)
+}
+
+func TestReplicationLastError(t *testing.T) {
+	delayer := &expBackoff{
+		retryMinDelay: 10 * time.Millisecond,
+		retryMaxDelay: 10 * time.Millisecond,
+		retryDelayExp: 1,
+	}
+
+	rOpts := DefaultOptions().
+		WithPrimaryDatabase("defaultdb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(1).
+		WithDelayer(delayer)
+
+	txReplicator, err := NewTxReplicator(xid.New(), newTestDB(t, "replicadb", true), rOpts, logger.NewMemoryLogger())
+	require.NoError(t, err)
+
+	lastErr, lastErrAt := txReplicator.LastError()
+	require.NoError(t, lastErr)
+	require.True(t, lastErrAt.IsZero())
+
+	startedAt := time.Now()
+
+	err = txReplicator.Start()
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		lastErr, _ := txReplicator.LastError()
+		return lastErr != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	err = txReplicator.Stop()
+	require.NoError(t, err)
+
+	lastErr, lastErrAt = txReplicator.LastError()
+	require.Error(t, lastErr)
+	require.False(t, lastErrAt.Before(startedAt))
+
+	primary := newTestDB(t, "primarydb", false)
+	setTestKeys(t, primary, "key", 1)
+
+	etx, _, _, err := primary.ExportTxByID(context.Background(), &schema.ExportTxRequest{Tx: 1})
+	require.NoError(t, err)
+
+	require.True(t, txReplicator.replicateSingleTx(context.Background(), etx))
+
+	lastErr, lastErrAt = txReplicator.LastError()
+	require.NoError(t, lastErr)
+	require.True(t, lastErrAt.IsZero())
+}
+
+type flakyReplicaDB struct {
+	database.DB
+
+	// failures is the number of calls to ReplicateTx failing before the transaction is replicated
+	failures int
+	// failAfterCommit makes failing calls commit the transaction before returning the error
+	failAfterCommit bool
+
+	calls int
+}
+
+func (db *flakyReplicaDB) ReplicateTx(ctx context.Context, exportedTx []byte) (*schema.TxHeader, error) {
+	db.calls++
+
+	if db.calls > db.failures {
+		return db.DB.ReplicateTx(ctx, exportedTx)
+	}
+
+	if db.failAfterCommit {
+		_, err := db.DB.ReplicateTx(ctx, exportedTx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, errors.New("transient error")
+}
+
+func TestReplicationApplyRetries(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+
+	etx, _, _, err := primary.ExportTxByID(context.Background(), &schema.ExportTxRequest{Tx: 1})
+	require.NoError(t, err)
+
+	delayer := &expBackoff{
+		retryMinDelay: 10 * time.Millisecond,
+		retryMaxDelay: 10 * time.Millisecond,
+		retryDelayExp: 1,
+	}
+
+	newReplicator := func(t *testing.T, db database.DB, retries int) *TxReplicator {
+		rOpts := DefaultOptions().
+			WithPrimaryDatabase("defaultdb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(3322).
+			WithDelayer(delayer).
+			WithApplyRetries(retries, time.Millisecond)
+
+		txReplicator, err := NewTxReplicator(xid.New(), db, rOpts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		txReplicator.context, txReplicator.cancelFunc = context.WithCancel(context.Background())
+		t.Cleanup(txReplicator.cancelFunc)
+
+		return txReplicator
+	}
+
+	requireReplicatedOnce := func(t *testing.T, db database.DB) {
+		state, err := db.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), state.TxId)
+	}
+
+	t.Run("transient errors should be retried in place", func(t *testing.T) {
+		db := &flakyReplicaDB{DB: newTestDB(t, "replicadb", true), failures: 1}
+
+		txReplicator := newReplicator(t, db, 3)
+
+		require.True(t, txReplicator.replicateSingleTx(context.Background(), etx))
+		require.Equal(t, 2, db.calls)
+		require.Zero(t, atomic.LoadInt32(&txReplicator.reconnectRequested))
+
+		requireReplicatedOnce(t, db)
+	})
+
+	t.Run("a reconnection should be requested once retries are exhausted", func(t *testing.T) {
+		db := &flakyReplicaDB{DB: newTestDB(t, "replicadb", true), failures: 3}
+
+		txReplicator := newReplicator(t, db, 1)
+
+		require.True(t, txReplicator.replicateSingleTx(context.Background(), etx))
+		require.Equal(t, 4, db.calls)
+		require.Equal(t, int32(1), atomic.LoadInt32(&txReplicator.reconnectRequested))
+
+		requireReplicatedOnce(t, db)
+	})
+
+	t.Run("transactions committed despite an error should not be applied again", func(t *testing.T) {
+		db := &flakyReplicaDB{DB: newTestDB(t, "replicadb", true), failures: 1, failAfterCommit: true}
+
+		txReplicator := newReplicator(t, db, 3)
+
+		require.True(t, txReplicator.replicateSingleTx(context.Background(), etx))
+		require.Equal(t, 1, db.calls)
+
+		requireReplicatedOnce(t, db)
+	})
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+var ErrResyncNotConfirmed = errors.New("force resync discards the data of the replica and must be explicitly confirmed")
+var ErrResyncNotSupported = errors.New("force resync requires a database reset")
+
+// DatabaseResetFunc discards the data of a replica database and returns the database to replicate into.
+// The returned database is usually empty, thus the whole history of the primary is replicated from the first
+// transaction, but it may be seeded with a prefix of that history, e.g. restored from a backup,
+// replication is then resumed from its last transaction
+type DatabaseResetFunc func(ctx context.Context, db database.DB) (database.DB, error)
+
+// ForceResync discards the data of the replica and replicates the history of the primary again,
+// as needed to recover a corrupted or diverged replica. Nothing is discarded unless confirm is set.
+// Replication is stopped while the database is reset and started again if it was running
+func (txr *TxReplicator) ForceResync(ctx context.Context, confirm bool) error {
+	if !confirm {
+		return ErrResyncNotConfirmed
+	}
+
+	if txr.opts.databaseReset == nil {
+		return ErrResyncNotSupported
+	}
+
+	err := txr.Stop()
+	wasRunning := err == nil
+	if err != nil && !errors.Is(err, ErrAlreadyStopped) {
+		return err
+	}
+
+	// the database must not be in use while it's reset
+	txr.workers.Wait()
+
+	txr.logger.Warningf("Force resync of database '%s' from '%s', replicated data is discarded...", txr.db.GetName(), txr._primaryDB)
+
+	err = txr.resetDatabase(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !wasRunning {
+		return nil
+	}
+
+	return txr.Start()
+}
+
+// resetDatabase replaces the database of the replica and forgets the replication state tied to its data
+func (txr *TxReplicator) resetDatabase(ctx context.Context) error {
+	txr.mutex.Lock()
+	defer txr.mutex.Unlock()
+
+	if txr.running {
+		return ErrAlreadyRunning
+	}
+
+	db, err := txr.opts.databaseReset(ctx, txr.db)
+	if err != nil {
+		return fmt.Errorf("database '%s' could not be reset: %w", txr.db.GetName(), err)
+	}
+	if db == nil {
+		return fmt.Errorf("%w: no database to resync into", ErrIllegalArguments)
+	}
+
+	txr.db = db
+
+	// the buffer of exported transactions is closed when replication stops
+	txr.prefetchTxBuffer = make(chan prefetchTxEntry, txr.opts.prefetchTxBufferSize)
+
+	txr.lastTx = 0
+	txr.pendingAck = nil
+	txr.primaryUUID = ""
+	txr.caughtUp = false
+	txr.consecutiveFailures = 0
+	atomic.StoreInt32(&txr.reconnectRequested, 0)
+
+	state, err := db.CurrentState()
+	if err != nil {
+		return err
+	}
+
+	txr.updateStatus(func(st *replicatorStatus) {
+		*st = replicatorStatus{startTxID: state.TxId}
+	})
+
+	// the checkpoint of the discarded database no longer applies
+	if txr.opts.checkpointPersistence {
+		err = txr.saveCheckpoint()
+		if err != nil {
+			return err
+		}
+	}
+
+	txr.logger.Infof("Database '%s' reset, replication resumes after transaction %d", db.GetName(), state.TxId)
+
+	return nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// DivergentTx describes a sampled transaction which does not match the primary's
+type DivergentTx struct {
+	TxID   uint64
+	Reason string
+}
+
+// SampledReport holds the outcome of a sampled replica consistency check
+type SampledReport struct {
+	// UpToTxID is the last transaction committed by the replica when the check started
+	UpToTxID uint64
+	// SampledTxIDs are the checked transactions, in ascending order
+	SampledTxIDs []uint64
+	// Divergent are the sampled transactions found to be inconsistent, in ascending order
+	Divergent []*DivergentTx
+}
+
+func (r *SampledReport) Consistent() bool {
+	return len(r.Divergent) == 0
+}
+
+// VerifyReplicaSampled compares k transactions, randomly chosen among the ones committed by the replica,
+// with the ones exported by the primary. Unlike VerifyReplicaConsistency, the hash chain is not checked,
+// thus it's a quick spot-check e.g. after a large catch-up rather than a proof of consistency
+func VerifyReplicaSampled(ctx context.Context, replica database.DB, primary TxExporter, k int) (*SampledReport, error) {
+	if replica == nil || primary == nil || k <= 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	state, err := replica.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+
+	return checkSampled(ctx, &dbTxExporter{db: replica}, primary, state.TxId, sampleTxIDs(state.TxId, k))
+}
+
+// VerifySampled checks k randomly chosen transactions of the replicated database against the primary
+// using a dedicated session, the ongoing replication is not affected by the check
+func (txr *TxReplicator) VerifySampled(ctx context.Context, k int) (*SampledReport, error) {
+	c := txr.newPrimaryClient()
+
+	err := c.OpenSession(ctx, []byte(txr.opts.primaryUsername), []byte(txr.opts.primaryPassword), txr.primaryDatabaseName())
+	if err != nil {
+		return nil, err
+	}
+	defer c.CloseSession(context.Background())
+
+	report, err := VerifyReplicaSampled(ctx, txr.db, &clientTxExporter{client: c, streamSrvFactory: txr.streamSrvFactory}, k)
+	if err != nil {
+		return nil, err
+	}
+
+	if report.Consistent() {
+		txr.logger.Infof("Database '%s' matches '%s' on %d sampled txs up to tx %d", txr.db.GetName(), txr._primaryDB, len(report.SampledTxIDs), report.UpToTxID)
+	} else {
+		txr.logger.Errorf("Database '%s' diverged from '%s' on %d out of %d sampled txs, first at tx %d. Reason: %s",
+			txr.db.GetName(), txr._primaryDB, len(report.Divergent), len(report.SampledTxIDs), report.Divergent[0].TxID, report.Divergent[0].Reason)
+	}
+
+	return report, nil
+}
+
+// sampleTxIDs returns k distinct transaction ids within [1, upToTxID] in ascending order,
+// all of them when there are no more than k
+func sampleTxIDs(upToTxID uint64, k int) []uint64 {
+	if uint64(k) >= upToTxID {
+		txIDs := make([]uint64, upToTxID)
+		for i := range txIDs {
+			txIDs[i] = uint64(i + 1)
+		}
+		return txIDs
+	}
+
+	// Floyd's algorithm, memory is proportional to k regardless of the size of the range
+	sampled := make(map[uint64]struct{}, k)
+
+	for j := upToTxID - uint64(k) + 1; j <= upToTxID; j++ {
+		txID := uint64(rand.Int63n(int64(j))) + 1
+
+		_, ok := sampled[txID]
+		if ok {
+			txID = j
+		}
+
+		sampled[txID] = struct{}{}
+	}
+
+	txIDs := make([]uint64, 0, k)
+	for txID := range sampled {
+		txIDs = append(txIDs, txID)
+	}
+
+	sort.Slice(txIDs, func(i, j int) bool { return txIDs[i] < txIDs[j] })
+
+	return txIDs
+}
+
+func checkSampled(ctx context.Context, replica, primary TxExporter, upToTxID uint64, txIDs []uint64) (*SampledReport, error) {
+	report := &SampledReport{UpToTxID: upToTxID, SampledTxIDs: txIDs}
+
+	for _, txID := range txIDs {
+		err := ctx.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		replicaTx, err := replica.ExportTx(ctx, txID)
+		if err != nil {
+			return nil, err
+		}
+
+		hdr, err := store.VerifyExportedTx(replicaTx)
+		if err != nil {
+			report.Divergent = append(report.Divergent, &DivergentTx{TxID: txID, Reason: err.Error()})
+			continue
+		}
+
+		if hdr.ID != txID {
+			report.Divergent = append(report.Divergent, &DivergentTx{TxID: txID, Reason: fmt.Sprintf("unexpected tx id %d", hdr.ID)})
+			continue
+		}
+
+		primaryTx, err := primary.ExportTx(ctx, txID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Equal(replicaTx, primaryTx) {
+			report.Divergent = append(report.Divergent, &DivergentTx{TxID: txID, Reason: "transaction differs from primary's"})
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleTxIDs(t *testing.T) {
+	require.Equal(t, []uint64{1, 2, 3}, sampleTxIDs(3, 5))
+	require.Empty(t, sampleTxIDs(0, 5))
+
+	for i := 0; i < 100; i++ {
+		txIDs := sampleTxIDs(1000, 10)
+		require.Len(t, txIDs, 10)
+
+		for j, txID := range txIDs {
+			require.GreaterOrEqual(t, txID, uint64(1))
+			require.LessOrEqual(t, txID, uint64(1000))
+
+			if j > 0 {
+				require.Greater(t, txID, txIDs[j-1])
+			}
+		}
+	}
+}
+
+func TestReplicaSampledConsistency(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+	setTestKeys(t, primary, "key", 20)
+
+	primaryState, err := primary.CurrentState()
+	require.NoError(t, err)
+
+	replica := newTestDB(t, "replicadb", true)
+	replicateTestTxs(t, primary, replica, 1, primaryState.TxId)
+
+	err = replica.WaitForTx(context.Background(), primaryState.TxId, false)
+	require.NoError(t, err)
+
+	_, err = VerifyReplicaSampled(context.Background(), nil, &dbTxExporter{db: primary}, 5)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = VerifyReplicaSampled(context.Background(), replica, &dbTxExporter{db: primary}, 0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	t.Run("intact replica", func(t *testing.T) {
+		report, err := VerifyReplicaSampled(context.Background(), replica, &dbTxExporter{db: primary}, 5)
+		require.NoError(t, err)
+		require.True(t, report.Consistent())
+		require.Equal(t, primaryState.TxId, report.UpToTxID)
+		require.Len(t, report.SampledTxIDs, 5)
+	})
+
+	t.Run("corrupted replica transaction", func(t *testing.T) {
+		replicaExporter := &corruptingTxExporter{
+			TxExporter:    &dbTxExporter{db: replica},
+			corruptedTxID: 7,
+		}
+
+		etx, err := replicaExporter.TxExporter.ExportTx(context.Background(), 7)
+		require.NoError(t, err)
+
+		// corrupt last byte of the exported tx i.e. a value
+		replicaExporter.offset = len(etx) - 1
+
+		// sampling every transaction the corruption can not go unnoticed
+		report, err := checkSampled(context.Background(), replicaExporter, &dbTxExporter{db: primary},
+			primaryState.TxId, sampleTxIDs(primaryState.TxId, int(primaryState.TxId)))
+		require.NoError(t, err)
+		require.False(t, report.Consistent())
+		require.Len(t, report.SampledTxIDs, int(primaryState.TxId))
+		require.Len(t, report.Divergent, 1)
+		require.Equal(t, uint64(7), report.Divergent[0].TxID)
+
+		report, err = checkSampled(context.Background(), replicaExporter, &dbTxExporter{db: primary},
+			primaryState.TxId, []uint64{2, 9, 12})
+		require.NoError(t, err)
+		require.True(t, report.Consistent())
+	})
+
+	t.Run("replica diverged from primary", func(t *testing.T) {
+		divergentPrimary := newTestDB(t, "divergentdb", true)
+		replicateTestTxs(t, primary, divergentPrimary, 1, 3)
+
+		err = divergentPrimary.WaitForTx(context.Background(), 3, false)
+		require.NoError(t, err)
+
+		divergentPrimary.AsReplica(false, false, 0)
+		setTestKeys(t, divergentPrimary, "divergentKey", 7)
+
+		report, err := VerifyReplicaSampled(context.Background(), divergentPrimary, &dbTxExporter{db: primary}, 10)
+		require.NoError(t, err)
+		require.False(t, report.Consistent())
+		require.Len(t, report.Divergent, 7)
+		require.Equal(t, uint64(4), report.Divergent[0].TxID)
+		require.Equal(t, "transaction differs from primary's", report.Divergent[0].Reason)
+	})
+
+	t.Run("cancelled check", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := VerifyReplicaSampled(ctx, replica, &dbTxExporter{db: primary}, 5)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
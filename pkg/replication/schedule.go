@@ -0,0 +1,272 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"time"
+)
+
+// maxScheduleCheckInterval bounds the time between two evaluations of the schedule,
+// so that adjustments of the system clock are eventually taken into account
+const maxScheduleCheckInterval = time.Minute
+
+// OutsideSchedulePolicy defines what is done with the connection to the primary
+// while the replication is paused outside the scheduled windows
+type OutsideSchedulePolicy int
+
+const (
+	// KeepConnectionOutsideSchedule keeps the connection open, probing it once per idle probe interval
+	KeepConnectionOutsideSchedule OutsideSchedulePolicy = iota
+	// DisconnectOutsideSchedule closes the connection, it's established again once the replication is resumed
+	DisconnectOutsideSchedule
+)
+
+func (p OutsideSchedulePolicy) valid() bool {
+	return p >= KeepConnectionOutsideSchedule && p <= DisconnectOutsideSchedule
+}
+
+// ScheduleWindow is a daily time window during which the replication is active. From and To are
+// the wall clock times at which the window opens and closes, as offsets since midnight in Location,
+// UTC being used when no location is set. A window closing before it opens spans midnight
+type ScheduleWindow struct {
+	From     time.Duration
+	To       time.Duration
+	Location *time.Location
+}
+
+func (w ScheduleWindow) valid() bool {
+	return w.From >= 0 && w.From < 24*time.Hour &&
+		w.To >= 0 && w.To < 24*time.Hour &&
+		w.From != w.To
+}
+
+func (w ScheduleWindow) location() *time.Location {
+	if w.Location == nil {
+		return time.UTC
+	}
+
+	return w.Location
+}
+
+// contains tells if the window is open at t
+func (w ScheduleWindow) contains(t time.Time) bool {
+	t = t.In(w.location())
+
+	h, m, s := t.Clock()
+	offset := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second + time.Duration(t.Nanosecond())
+
+	if w.From < w.To {
+		return w.From <= offset && offset < w.To
+	}
+
+	return w.From <= offset || offset < w.To
+}
+
+// nextBoundary returns the first time after t at which the window opens or closes
+func (w ScheduleWindow) nextBoundary(t time.Time) time.Time {
+	t = t.In(w.location())
+
+	var next time.Time
+
+	for day := 0; day <= 1; day++ {
+		for _, offset := range []time.Duration{w.From, w.To} {
+			b := time.Date(t.Year(), t.Month(), t.Day()+day, 0, 0, 0, int(offset), w.location())
+
+			if b.After(t) && (next.IsZero() || b.Before(next)) {
+				next = b
+			}
+		}
+	}
+
+	return next
+}
+
+func (opts *Options) validSchedule() bool {
+	for _, w := range opts.schedule {
+		if !w.valid() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// scheduleActive tells if the replication is scheduled at t, i.e. if any of the windows is open
+func scheduleActive(windows []ScheduleWindow, t time.Time) bool {
+	for _, w := range windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nextScheduleCheck returns when the schedule has to be evaluated again after t
+func nextScheduleCheck(windows []ScheduleWindow, t time.Time) time.Time {
+	next := t.Add(maxScheduleCheckInterval)
+
+	for _, w := range windows {
+		b := w.nextBoundary(t)
+		if b.Before(next) {
+			next = b
+		}
+	}
+
+	return next
+}
+
+// Pause holds the fetching and applying of transactions until Resume is called.
+// Transactions being fetched or applied when pausing are completed
+func (txr *TxReplicator) Pause() {
+	txr.statusMutex.Lock()
+	defer txr.statusMutex.Unlock()
+
+	if txr.resumed != nil {
+		return
+	}
+
+	txr.resumed = make(chan struct{})
+
+	txr.logger.Infof("Replication of database '%s' from '%s' paused", txr.db.GetName(), txr._primaryDB)
+}
+
+// Resume continues the replication paused by Pause
+func (txr *TxReplicator) Resume() {
+	txr.statusMutex.Lock()
+	defer txr.statusMutex.Unlock()
+
+	if txr.resumed == nil {
+		return
+	}
+
+	close(txr.resumed)
+	txr.resumed = nil
+
+	txr.logger.Infof("Replication of database '%s' from '%s' resumed", txr.db.GetName(), txr._primaryDB)
+}
+
+// Paused tells if the replication is paused
+func (txr *TxReplicator) Paused() bool {
+	return txr.resumedChan() != nil
+}
+
+// resumedChan returns the channel closed once the replication is resumed, nil if it is not paused
+func (txr *TxReplicator) resumedChan() chan struct{} {
+	txr.statusMutex.Lock()
+	defer txr.statusMutex.Unlock()
+
+	return txr.resumed
+}
+
+// waitResumed blocks while the replication is paused.
+// It returns false if replication was stopped meanwhile
+func (txr *TxReplicator) waitResumed() bool {
+	resumed := txr.resumedChan()
+	if resumed == nil {
+		return true
+	}
+
+	select {
+	case <-txr.context.Done():
+		return false
+	case <-resumed:
+		return true
+	}
+}
+
+// waitResumedFetching blocks the fetching of transactions while the replication is paused, handling
+// the connection to the primary as defined by the outside schedule policy.
+// It returns false if replication was stopped meanwhile
+func (txr *TxReplicator) waitResumedFetching() bool {
+	resumed := txr.resumedChan()
+	if resumed == nil {
+		return true
+	}
+
+	var probe <-chan time.Time
+
+	if txr.opts.outsideSchedulePolicy == DisconnectOutsideSchedule {
+		txr.mutex.Lock()
+		txr.disconnect()
+		txr.mutex.Unlock()
+	} else if txr.opts.idleProbeInterval > 0 {
+		ticker := time.NewTicker(txr.opts.idleProbeInterval)
+		defer ticker.Stop()
+
+		probe = ticker.C
+	}
+
+	for {
+		select {
+		case <-txr.context.Done():
+			return false
+		case <-resumed:
+			return true
+		case <-probe:
+			txr.probePausedConnection()
+		}
+	}
+}
+
+// probePausedConnection keeps the connection to the primary alive while paused,
+// it's established again once resumed if the primary does not answer in time
+func (txr *TxReplicator) probePausedConnection() {
+	txr.mutex.Lock()
+	defer txr.mutex.Unlock()
+
+	if txr.client == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(txr.context, txr.opts.idleProbeInterval)
+	defer cancel()
+
+	_, err := txr.client.CurrentState(ctx)
+	if err != nil {
+		txr.idleConnectionLost(err)
+	}
+}
+
+// runSchedule pauses and resumes the replication as the scheduled windows close and open.
+// The replication may be paused or resumed in between, it's only overridden on the next transition
+func (txr *TxReplicator) runSchedule(ctx context.Context, active bool) {
+	for {
+		timer := time.NewTimer(time.Until(nextScheduleCheck(txr.opts.schedule, time.Now())))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		scheduled := scheduleActive(txr.opts.schedule, time.Now())
+		if scheduled == active {
+			continue
+		}
+
+		active = scheduled
+
+		if active {
+			txr.Resume()
+		} else {
+			txr.Pause()
+		}
+	}
+}
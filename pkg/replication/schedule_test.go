@@ -0,0 +1,126 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleWindow(t *testing.T) {
+	cet := time.FixedZone("CET", 3600)
+
+	at := func(hour, min int) time.Time {
+		return time.Date(2022, 3, 10, hour, min, 0, 0, time.UTC)
+	}
+
+	t.Run("a window should be open from its start until its end", func(t *testing.T) {
+		w := ScheduleWindow{From: 9 * time.Hour, To: 17 * time.Hour}
+
+		require.False(t, w.contains(at(8, 59)))
+		require.True(t, w.contains(at(9, 0)))
+		require.True(t, w.contains(at(16, 59)))
+		require.False(t, w.contains(at(17, 0)))
+
+		require.Equal(t, at(17, 0), w.nextBoundary(at(9, 0)))
+		require.Equal(t, at(9, 0).AddDate(0, 0, 1), w.nextBoundary(at(17, 0)))
+	})
+
+	t.Run("a window closing before it opens should span midnight", func(t *testing.T) {
+		w := ScheduleWindow{From: 22 * time.Hour, To: 6 * time.Hour}
+
+		require.True(t, w.contains(at(23, 0)))
+		require.True(t, w.contains(at(5, 59)))
+		require.False(t, w.contains(at(6, 0)))
+		require.False(t, w.contains(at(21, 59)))
+
+		require.Equal(t, at(6, 0).AddDate(0, 0, 1), w.nextBoundary(at(23, 0)))
+	})
+
+	t.Run("a window should be evaluated in its location", func(t *testing.T) {
+		w := ScheduleWindow{From: 9 * time.Hour, To: 17 * time.Hour, Location: cet}
+
+		require.False(t, w.contains(at(16, 30)))
+		require.True(t, w.contains(at(8, 0)))
+
+		require.True(t, w.nextBoundary(at(7, 0)).Equal(at(8, 0)))
+	})
+
+	t.Run("the schedule should be active when any of its windows is open", func(t *testing.T) {
+		windows := []ScheduleWindow{
+			{From: 1 * time.Hour, To: 2 * time.Hour},
+			{From: 20 * time.Hour, To: 21 * time.Hour},
+		}
+
+		require.True(t, scheduleActive(windows, at(1, 30)))
+		require.True(t, scheduleActive(windows, at(20, 30)))
+		require.False(t, scheduleActive(windows, at(12, 0)))
+
+		require.Equal(t, at(20, 0), nextScheduleCheck(windows, at(19, 59)))
+		require.Equal(t, at(12, 0).Add(maxScheduleCheckInterval), nextScheduleCheck(windows, at(12, 0)))
+	})
+}
+
+func TestPausedReplicationHoldsTxs(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+	setTestKeys(t, primary, "key", 1)
+
+	etx, _, _, err := primary.ExportTxByID(context.Background(), &schema.ExportTxRequest{Tx: 1})
+	require.NoError(t, err)
+
+	replica := newTestDB(t, "replicadb", true)
+
+	rOpts := DefaultOptions().
+		WithPrimaryDatabase("primarydb").
+		WithPrimaryHost("127.0.0.1").
+		WithPrimaryPort(3322)
+
+	txReplicator, err := NewTxReplicator(xid.New(), replica, rOpts, logger.NewMemoryLogger())
+	require.NoError(t, err)
+
+	txReplicator.context, txReplicator.cancelFunc = context.WithCancel(context.Background())
+	t.Cleanup(txReplicator.cancelFunc)
+
+	txReplicator.Pause()
+	require.True(t, txReplicator.Paused())
+
+	done := make(chan bool)
+	go func() {
+		done <- txReplicator.replicateSingleTx(context.Background(), etx)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	state, err := replica.CurrentState()
+	require.NoError(t, err)
+	require.Zero(t, state.TxId)
+
+	txReplicator.Resume()
+	require.False(t, txReplicator.Paused())
+
+	require.True(t, <-done)
+
+	state, err = replica.CurrentState()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), state.TxId)
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+var ErrShadowApplyDivergence = errors.New("shadow apply divergence")
+
+// ShadowApplier is an alternative apply path run alongside the replication, e.g. a new storage engine being
+// rolled out. Every transaction applied by the replica is applied by the shadow as well, and the resulting
+// transaction headers are compared. Any database.DB can be used as a shadow
+type ShadowApplier interface {
+	ReplicateTx(ctx context.Context, exportedTx []byte) (*schema.TxHeader, error)
+}
+
+// startShadowApply starts applying to the shadow the transactions applied by the replica after txID
+func (txr *TxReplicator) startShadowApply(ctx context.Context, txID uint64) {
+	txr.shadowTxs = make(chan []byte, txr.opts.prefetchTxBufferSize)
+
+	go txr.runShadowApply(ctx, txr.shadowTxs, txID+1)
+}
+
+// enqueueShadowTx hands a transaction applied by the replica over to the shadow apply, if enabled
+func (txr *TxReplicator) enqueueShadowTx(data []byte) {
+	if txr.shadowTxs == nil {
+		return
+	}
+
+	select {
+	case txr.shadowTxs <- data:
+	case <-txr.context.Done():
+	}
+}
+
+// runShadowApply applies transactions to the shadow strictly in order, as they may be applied by the replica
+// concurrently. The shadow apply never affects the replica: divergences are only logged and reported
+func (txr *TxReplicator) runShadowApply(ctx context.Context, txs <-chan []byte, nextTxID uint64) {
+	pending := make(map[uint64][]byte)
+
+	// diverged is set once a divergence is found, the shadow can not apply further transactions
+	diverged := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case etx := <-txs:
+			if diverged {
+				// transactions are still consumed so that the replication is never blocked
+				continue
+			}
+
+			hdr, err := exportedTxHeader(etx)
+			if err != nil || hdr.ID < nextTxID {
+				continue
+			}
+
+			pending[hdr.ID] = etx
+		}
+
+		for !diverged {
+			etx, ok := pending[nextTxID]
+			if !ok {
+				break
+			}
+
+			delete(pending, nextTxID)
+
+			alert := txr.shadowApplyTx(ctx, nextTxID, etx)
+			if alert != nil {
+				txr.reportShadowDivergence(alert)
+				diverged = true
+				pending = nil
+			}
+
+			nextTxID++
+		}
+	}
+}
+
+// shadowApplyTx applies a transaction to the shadow and compares its outcome with the one of the replica
+func (txr *TxReplicator) shadowApplyTx(ctx context.Context, txID uint64, etx []byte) *IntegrityAlert {
+	shadowHdr, err := txr.opts.shadowApplier.ReplicateTx(ctx, etx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return &IntegrityAlert{TxID: txID, Reason: fmt.Sprintf("shadow apply failed: %v", err)}
+	}
+
+	localEtx, err := (&dbTxExporter{db: txr.db}).ExportTx(ctx, txID)
+	if err == nil {
+		localHdr, hdrErr := exportedTxHeader(localEtx)
+		if hdrErr == nil {
+			if shadowHdr == nil || shadowHdr.Id != localHdr.ID {
+				return &IntegrityAlert{TxID: txID, Reason: "shadow apply returned an unexpected transaction"}
+			}
+
+			shadowAlh := schema.TxHeaderFromProto(shadowHdr).Alh()
+			localAlh := localHdr.Alh()
+
+			if shadowAlh != localAlh {
+				return &IntegrityAlert{
+					TxID:   txID,
+					Reason: fmt.Sprintf("shadow alh %x differs from replica alh %x", shadowAlh, localAlh),
+				}
+			}
+
+			return nil
+		}
+
+		err = hdrErr
+	}
+
+	if ctx.Err() == nil {
+		txr.logger.Warningf("Transaction %d could not be compared with its shadow apply. Reason: %s", txID, err.Error())
+	}
+
+	return nil
+}
+
+// reportShadowDivergence logs and reports a divergence of the shadow apply, the replication is not affected
+func (txr *TxReplicator) reportShadowDivergence(alert *IntegrityAlert) {
+	err := fmt.Errorf("%w at tx %d: %s", ErrShadowApplyDivergence, alert.TxID, alert.Reason)
+
+	txr.logger.Errorf("Shadow apply of transactions replicated from '%s' to '%s' diverged. Reason: %s", txr._primaryDB, txr.db.GetName(), err.Error())
+
+	if txr.opts.alertHandler != nil {
+		txr.opts.alertHandler(alert)
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+// divergentShadow applies transactions to a database but reports a different outcome from the given tx on
+type divergentShadow struct {
+	db        database.DB
+	divergeAt uint64
+}
+
+func (s *divergentShadow) ReplicateTx(ctx context.Context, exportedTx []byte) (*schema.TxHeader, error) {
+	hdr, err := s.db.ReplicateTx(ctx, exportedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	if hdr.Id >= s.divergeAt {
+		hdr.EH = make([]byte, len(hdr.EH))
+	}
+
+	return hdr, nil
+}
+
+func TestShadowApply(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+
+	for i := 0; i < 3; i++ {
+		_, err := primary.Set(context.Background(), &schema.SetRequest{
+			KVs: []*schema.KeyValue{{Key: []byte("key"), Value: []byte{byte(i)}}},
+		})
+		require.NoError(t, err)
+	}
+
+	primaryState, err := primary.CurrentState()
+	require.NoError(t, err)
+
+	var etxs [][]byte
+
+	for txID := uint64(1); txID <= primaryState.TxId; txID++ {
+		etx, _, _, err := primary.ExportTxByID(context.Background(), &schema.ExportTxRequest{Tx: txID})
+		require.NoError(t, err)
+
+		etxs = append(etxs, etx)
+	}
+
+	type alerts struct {
+		mutex  sync.Mutex
+		alerts []*IntegrityAlert
+	}
+
+	replicateWithShadow := func(t *testing.T, shadow ShadowApplier) (database.DB, *alerts) {
+		replica := newTestDB(t, "replicadb", true)
+
+		reported := &alerts{}
+
+		rOpts := DefaultOptions().
+			WithPrimaryDatabase("defaultdb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(3322).
+			WithShadowApply(shadow).
+			WithAlertHandler(func(alert *IntegrityAlert) {
+				reported.mutex.Lock()
+				defer reported.mutex.Unlock()
+
+				reported.alerts = append(reported.alerts, alert)
+			})
+
+		txReplicator, err := NewTxReplicator(xid.New(), replica, rOpts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		txReplicator.context, txReplicator.cancelFunc = context.WithCancel(context.Background())
+		t.Cleanup(txReplicator.cancelFunc)
+
+		txReplicator.startShadowApply(txReplicator.context, 0)
+
+		for _, etx := range etxs {
+			require.True(t, txReplicator.replicateSingleTx(context.Background(), etx))
+		}
+
+		return replica, reported
+	}
+
+	requireShadowState := func(t *testing.T, db database.DB) {
+		require.Eventually(t, func() bool {
+			state, err := db.CurrentState()
+			return err == nil && state.TxId == primaryState.TxId
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	t.Run("matching shadow apply should not be reported", func(t *testing.T) {
+		shadow := newTestDB(t, "shadowdb", true)
+
+		replica, reported := replicateWithShadow(t, shadow)
+
+		requireShadowState(t, shadow)
+
+		replicaState, err := replica.CurrentState()
+		require.NoError(t, err)
+
+		shadowState, err := shadow.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, replicaState.TxHash, shadowState.TxHash)
+
+		reported.mutex.Lock()
+		defer reported.mutex.Unlock()
+
+		require.Empty(t, reported.alerts)
+	})
+
+	t.Run("divergent shadow apply should be reported", func(t *testing.T) {
+		shadow := &divergentShadow{db: newTestDB(t, "shadowdb", true), divergeAt: 2}
+
+		replica, reported := replicateWithShadow(t, shadow)
+
+		require.Eventually(t, func() bool {
+			reported.mutex.Lock()
+			defer reported.mutex.Unlock()
+
+			return len(reported.alerts) > 0
+		}, 5*time.Second, 10*time.Millisecond)
+
+		reported.mutex.Lock()
+		require.Len(t, reported.alerts, 1)
+		require.Equal(t, uint64(2), reported.alerts[0].TxID)
+		require.Contains(t, reported.alerts[0].Reason, "differs from replica")
+		reported.mutex.Unlock()
+
+		// the data committed by the replica is not affected
+		replicaState, err := replica.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, primaryState.TxId, replicaState.TxId)
+		require.Equal(t, primaryState.TxHash, replicaState.TxHash)
+	})
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"time"
+)
+
+// txRateLimiter is a token bucket limiting the number of transactions replicated per second.
+// The bucket holds a single token so transactions are evenly paced, with no bursts after idle periods
+type txRateLimiter struct {
+	// interval is the time needed to earn a token
+	interval time.Duration
+	// nextAt is when the next token is available
+	nextAt time.Time
+}
+
+func newTxRateLimiter(maxTxPerSecond int) *txRateLimiter {
+	return &txRateLimiter{interval: time.Second / time.Duration(maxTxPerSecond)}
+}
+
+// wait takes a token from the bucket, waiting until one is available or ctx is done
+func (l *txRateLimiter) wait(ctx context.Context) error {
+	now := time.Now()
+
+	if l.nextAt.Before(now) {
+		// the token earned while idle is available right away
+		l.nextAt = now
+	}
+
+	delay := l.nextAt.Sub(now)
+
+	l.nextAt = l.nextAt.Add(l.interval)
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		// the token was not used
+		l.nextAt = l.nextAt.Add(-l.interval)
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
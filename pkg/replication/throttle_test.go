@@ -0,0 +1,119 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxRateLimiter(t *testing.T) {
+	t.Run("the applied transaction rate should stay under the limit", func(t *testing.T) {
+		const maxTxPerSecond = 50
+		const txCount = 25
+
+		primary := newTestDB(t, "primarydb", false)
+		setTestKeys(t, primary, "key", txCount-1)
+
+		etxs := exportTestTxs(t, &dbTxExporter{db: primary}, txCount)
+
+		replica := newTestDB(t, "replicadb", true)
+
+		limiter := newTxRateLimiter(maxTxPerSecond)
+
+		start := time.Now()
+
+		for i, etx := range etxs {
+			err := limiter.wait(context.Background())
+			require.NoError(t, err)
+
+			_, err = replica.ReplicateTx(context.Background(), etx)
+			require.NoError(t, err)
+
+			// the first transaction is not delayed
+			appliedTxs := float64(i)
+			if i > 0 {
+				require.LessOrEqual(t, appliedTxs/time.Since(start).Seconds(), float64(maxTxPerSecond))
+			}
+		}
+
+		require.GreaterOrEqual(t, time.Since(start), (txCount-1)*time.Second/maxTxPerSecond)
+		require.Equal(t, uint64(txCount), committedTxID(t, replica))
+	})
+
+	t.Run("transactions should not be delayed after idle periods", func(t *testing.T) {
+		limiter := newTxRateLimiter(10)
+
+		err := limiter.wait(context.Background())
+		require.NoError(t, err)
+
+		time.Sleep(150 * time.Millisecond)
+
+		start := time.Now()
+
+		err = limiter.wait(context.Background())
+		require.NoError(t, err)
+		require.Less(t, time.Since(start), 50*time.Millisecond)
+
+		// a single token is earned while idle, next transactions are paced again
+		err = limiter.wait(context.Background())
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("waiting should be cancellable", func(t *testing.T) {
+		limiter := newTxRateLimiter(1)
+
+		err := limiter.wait(context.Background())
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err = limiter.wait(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// the token not taken remains available
+		require.LessOrEqual(t, time.Until(limiter.nextAt), time.Second)
+	})
+
+	t.Run("the limiter should only be set when a rate is configured", func(t *testing.T) {
+		for _, maxTxPerSecond := range []int{0, 10} {
+			rOpts := DefaultOptions().
+				WithPrimaryDatabase("defaultdb").
+				WithPrimaryHost("127.0.0.1").
+				WithPrimaryPort(1).
+				WithMaxTxPerSecond(maxTxPerSecond)
+
+			txReplicator, err := NewTxReplicator(xid.New(), newTestDB(t, "replicadb", true), rOpts, logger.NewMemoryLogger())
+			require.NoError(t, err)
+
+			err = txReplicator.Start()
+			require.NoError(t, err)
+
+			require.Equal(t, maxTxPerSecond > 0, txReplicator.txRateLimiter != nil)
+
+			err = txReplicator.Stop()
+			require.NoError(t, err)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Tracer creates the spans recorded while replicating transactions.
+// It's meant to be backed by an OpenTelemetry tracer or any other tracing library
+type Tracer interface {
+	// Start creates a span as a child of the one found in ctx, if any,
+	// the returned context holds the new span
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// Span is a traced operation
+type Span interface {
+	// End completes the span, err is the outcome of the traced operation
+	End(err error)
+}
+
+// TextMapCarrier holds the propagated trace context as key-value pairs
+type TextMapCarrier interface {
+	Get(key string) string
+	Set(key string, value string)
+	Keys() []string
+}
+
+// Propagator serializes the trace context so it can be sent to the primary
+type Propagator interface {
+	Inject(ctx context.Context, carrier TextMapCarrier)
+}
+
+const (
+	connectSpanName   = "replication.connect"
+	exportSpanName    = "replication.export"
+	replicateSpanName = "replication.replicate"
+	verifySpanName    = "replication.verify"
+)
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, spanName string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) End(err error) {}
+
+type noopPropagator struct{}
+
+func (noopPropagator) Inject(ctx context.Context, carrier TextMapCarrier) {}
+
+// metadataCarrier adapts gRPC metadata to the TextMapCarrier interface
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	vs := metadata.MD(c).Get(key)
+	if len(vs) == 0 {
+		return ""
+	}
+
+	return vs[0]
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// withTraceContext returns a context where the trace context is included into the outgoing gRPC metadata
+func (txr *TxReplicator) withTraceContext(ctx context.Context) context.Context {
+	md := metadata.MD{}
+
+	txr.propagator.Inject(ctx, metadataCarrier(md))
+
+	for k, vs := range md {
+		for _, v := range vs {
+			ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+		}
+	}
+
+	return ctx
+}
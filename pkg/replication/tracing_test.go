@@ -0,0 +1,161 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+type recordedSpan struct {
+	name   string
+	parent string
+	err    error
+}
+
+type spanCtxKey struct{}
+
+// memTracer records ended spans in memory, span names are used as span identifiers
+type memTracer struct {
+	mutex sync.Mutex
+	spans []recordedSpan
+}
+
+func (tr *memTracer) Start(ctx context.Context, spanName string) (context.Context, Span) {
+	parent, _ := ctx.Value(spanCtxKey{}).(string)
+
+	return context.WithValue(ctx, spanCtxKey{}, spanName), &memSpan{
+		tracer: tr,
+		span:   recordedSpan{name: spanName, parent: parent},
+	}
+}
+
+func (tr *memTracer) recorded() []recordedSpan {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+
+	return append([]recordedSpan(nil), tr.spans...)
+}
+
+type memSpan struct {
+	tracer *memTracer
+	span   recordedSpan
+}
+
+func (s *memSpan) End(err error) {
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+
+	s.span.err = err
+	s.tracer.spans = append(s.tracer.spans, s.span)
+}
+
+type memPropagator struct{}
+
+func (memPropagator) Inject(ctx context.Context, carrier TextMapCarrier) {
+	span, ok := ctx.Value(spanCtxKey{}).(string)
+	if ok {
+		carrier.Set("traceparent", span)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	t.Run("no span should be recorded by default", func(t *testing.T) {
+		txr, err := NewTxReplicator(xid.New(), newTestDB(t, "replicadb", true), DefaultOptions(), logger.NewMemoryLogger())
+		require.NoError(t, err)
+		require.Equal(t, noopTracer{}, txr.tracer)
+		require.Equal(t, noopPropagator{}, txr.propagator)
+
+		ctx := txr.withTraceContext(context.Background())
+
+		_, ok := metadata.FromOutgoingContext(ctx)
+		require.False(t, ok)
+	})
+
+	t.Run("connection attempts should be traced", func(t *testing.T) {
+		tracer := &memTracer{}
+
+		opts := DefaultOptions().
+			WithPrimaryDatabase("defaultdb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(1).
+			WithDelayer(&expBackoff{retryMinDelay: 10 * time.Millisecond, retryMaxDelay: 10 * time.Millisecond, retryDelayExp: 1}).
+			WithTracer(tracer)
+
+		txr, err := NewTxReplicator(xid.New(), newTestDB(t, "replicadb", true), opts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		err = txr.Start()
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool { return len(tracer.recorded()) > 0 }, 5*time.Second, 10*time.Millisecond)
+
+		err = txr.Stop()
+		require.NoError(t, err)
+
+		span := tracer.recorded()[0]
+		require.Equal(t, connectSpanName, span.name)
+		require.Empty(t, span.parent)
+		require.Error(t, span.err)
+	})
+
+	t.Run("trace context should be included in the outgoing metadata", func(t *testing.T) {
+		tracer := &memTracer{}
+
+		opts := DefaultOptions().
+			WithTracer(tracer).
+			WithPropagator(memPropagator{})
+
+		txr, err := NewTxReplicator(xid.New(), newTestDB(t, "replicadb", true), opts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		ctx, _ := tracer.Start(context.Background(), exportSpanName)
+		ctx = metadata.AppendToOutgoingContext(ctx, "payload-checksum", "sha256")
+
+		md, ok := metadata.FromOutgoingContext(txr.withTraceContext(ctx))
+		require.True(t, ok)
+		require.Equal(t, []string{exportSpanName}, md.Get("traceparent"))
+		require.Equal(t, []string{"sha256"}, md.Get("payload-checksum"))
+	})
+
+	t.Run("replicated transactions should be traced as children of their export", func(t *testing.T) {
+		primary := newTestDB(t, "primarydb", false)
+		setTestKeys(t, primary, "key", 1)
+
+		etx, _, _, err := primary.ExportTxByID(context.Background(), &schema.ExportTxRequest{Tx: 1})
+		require.NoError(t, err)
+
+		tracer := &memTracer{}
+
+		txr, err := NewTxReplicator(xid.New(), newTestDB(t, "replicadb", true), DefaultOptions().WithTracer(tracer), logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		ctx, _ := tracer.Start(context.Background(), exportSpanName)
+
+		require.True(t, txr.replicateSingleTx(ctx, etx))
+
+		require.Equal(t, []recordedSpan{{name: replicateSpanName, parent: exportSpanName}}, tracer.recorded())
+	})
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+var ErrTxRejected = errors.New("transaction rejected by the apply transform")
+var ErrTransformWithSyncReplication = errors.New("transactions can not be transformed by synchronous replicas")
+
+// ReplicatedTx is a transaction received from the primary.
+// Its entries are the ones of the underlying store, i.e. keys and values include the prefixes used by the database
+type ReplicatedTx struct {
+	Header *store.TxHeader
+	// Entries whose value was excluded from replication hold the digest of their value
+	Entries []*store.EntrySpec
+}
+
+// ApplyTransform is invoked with every transaction received from the primary before it is applied.
+// The entries of the returned transaction are applied instead of the original ones, which makes it possible
+// to e.g. redact values on a replica. Returning an error rejects the transaction and halts the replication.
+//
+// Transformed transactions are committed with the same ids as the original ones, but their hash chain is the
+// one of the replica: a transformed replica can not be verified against the primary and it can not take part
+// in synchronous replication
+type ApplyTransform func(tx *ReplicatedTx) (*ReplicatedTx, error)
+
+func (txr *TxReplicator) transformTx(data []byte) (*ReplicatedTx, error) {
+	hdr, entries, err := store.ParseExportedTx(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := txr.opts.applyTransform(&ReplicatedTx{Header: hdr, Entries: entries})
+	if err != nil {
+		return nil, fmt.Errorf("%w: tx %d: %v", ErrTxRejected, hdr.ID, err)
+	}
+	if tx == nil {
+		return nil, fmt.Errorf("%w: tx %d: no transaction returned", ErrTxRejected, hdr.ID)
+	}
+
+	// only entries can be transformed, the transaction must be applied in place of the original one
+	return &ReplicatedTx{Header: hdr, Entries: tx.Entries}, nil
+}
+
+// haltOnRejectedTx stops the replication as the transactions following a rejected one can not be applied
+func (txr *TxReplicator) haltOnRejectedTx(err error) {
+	txr.logger.Errorf("Replication from '%s' to '%s' halted. Reason: %s", txr._primaryDB, txr.db.GetName(), err.Error())
+
+	txr.updateStatus(func(st *replicatorStatus) { st.setLastError(err) })
+
+	go txr.Stop()
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyTransform(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+
+	_, err := primary.Set(context.Background(), &schema.SetRequest{
+		KVs: []*schema.KeyValue{
+			{Key: []byte("public"), Value: []byte("public-value")},
+			{Key: []byte("secret"), Value: []byte("secret-value")},
+		},
+	})
+	require.NoError(t, err)
+
+	primaryState, err := primary.CurrentState()
+	require.NoError(t, err)
+
+	var etxs [][]byte
+
+	for txID := uint64(1); txID <= primaryState.TxId; txID++ {
+		etx, _, _, err := primary.ExportTxByID(context.Background(), &schema.ExportTxRequest{Tx: txID})
+		require.NoError(t, err)
+
+		etxs = append(etxs, etx)
+	}
+
+	replicateAll := func(t *testing.T, txReplicator *TxReplicator) {
+		for _, etx := range etxs {
+			require.True(t, txReplicator.replicateSingleTx(context.Background(), etx))
+		}
+	}
+
+	newReplicator := func(t *testing.T, db database.DB, transform ApplyTransform) *TxReplicator {
+		rOpts := DefaultOptions().
+			WithPrimaryDatabase("defaultdb").
+			WithPrimaryHost("127.0.0.1").
+			WithPrimaryPort(3322).
+			WithApplyTransform(transform)
+
+		txReplicator, err := NewTxReplicator(xid.New(), db, rOpts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		txReplicator.context, txReplicator.cancelFunc = context.WithCancel(context.Background())
+		t.Cleanup(txReplicator.cancelFunc)
+
+		return txReplicator
+	}
+
+	requireValue := func(t *testing.T, db database.DB, key, value string) {
+		entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(key), SinceTx: primaryState.TxId})
+		require.NoError(t, err)
+		require.Equal(t, []byte(value), entry.Value)
+	}
+
+	t.Run("transformed entries should be applied", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+
+		txReplicator := newReplicator(t, replica, func(tx *ReplicatedTx) (*ReplicatedTx, error) {
+			for _, e := range tx.Entries {
+				if bytes.HasSuffix(e.Key, []byte("secret")) {
+					// the encoding prefix of the value is kept
+					e.Value = append(e.Value[:1:1], "redacted"...)
+				}
+			}
+
+			return tx, nil
+		})
+
+		replicateAll(t, txReplicator)
+
+		requireValue(t, replica, "public", "public-value")
+		requireValue(t, replica, "secret", "redacted")
+
+		replicaState, err := replica.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, primaryState.TxId, replicaState.TxId)
+		require.NotEqual(t, primaryState.TxHash, replicaState.TxHash)
+	})
+
+	t.Run("untransformed entries should be applied as received", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+
+		txReplicator := newReplicator(t, replica, func(tx *ReplicatedTx) (*ReplicatedTx, error) {
+			return tx, nil
+		})
+
+		replicateAll(t, txReplicator)
+
+		requireValue(t, replica, "public", "public-value")
+		requireValue(t, replica, "secret", "secret-value")
+	})
+
+	t.Run("rejected transactions should halt the replication", func(t *testing.T) {
+		replica := newTestDB(t, "replicadb", true)
+
+		txReplicator := newReplicator(t, replica, func(tx *ReplicatedTx) (*ReplicatedTx, error) {
+			return nil, errors.New("unexpected entries")
+		})
+
+		require.False(t, txReplicator.replicateSingleTx(context.Background(), etxs[0]))
+
+		lastErr, _ := txReplicator.LastError()
+		require.ErrorIs(t, lastErr, ErrTxRejected)
+
+		require.Eventually(t, func() bool {
+			return txReplicator.context.Err() != nil
+		}, 5*time.Second, 10*time.Millisecond)
+
+		state, err := replica.CurrentState()
+		require.NoError(t, err)
+		require.Zero(t, state.TxId)
+	})
+
+	t.Run("transformed transactions should not be synchronously replicated", func(t *testing.T) {
+		replica, err := database.NewDB("replicadb", nil,
+			database.DefaultOption().AsReplica(true).WithSyncReplication(true).WithDBRootPath(t.TempDir()),
+			logger.NewMemoryLogger(),
+		)
+		require.NoError(t, err)
+		defer replica.Close()
+
+		txReplicator := newReplicator(t, replica, func(tx *ReplicatedTx) (*ReplicatedTx, error) {
+			return tx, nil
+		})
+
+		err = txReplicator.Start()
+		require.ErrorIs(t, err, ErrTransformWithSyncReplication)
+	})
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+var ErrIntegrityDiscrepancy = errors.New("integrity discrepancy")
+
+// IntegrityAlert describes a discrepancy found while verifying transactions exported by the primary
+type IntegrityAlert struct {
+	// TxID is the transaction where the discrepancy was found, zero when not related to a transaction
+	TxID uint64
+	// Reason describes the discrepancy
+	Reason string
+}
+
+// AlertHandler is called when a transaction exported by the primary fails verification or its checksum,
+// when the shadow apply diverges from the replica or when the replication user has unneeded privileges
+type AlertHandler func(alert *IntegrityAlert)
+
+// txVerifier checks transactions exported by the primary in verify-only mode.
+// Transactions must be verified in order, each of them is checked against:
+//   - the digest of its entries, recomputed from the exported entries
+//   - the hash chain of the previously verified transactions
+//   - the same transaction in the local database, if already committed there
+type txVerifier struct {
+	db database.DB
+
+	lastTxID uint64
+	lastAlh  [sha256.Size]byte
+
+	// failed is set once a discrepancy is found, further transactions can not be verified
+	failed bool
+}
+
+func (v *txVerifier) verify(ctx context.Context, etx []byte) (*IntegrityAlert, error) {
+	expectedTxID := v.lastTxID + 1
+
+	hdr, err := store.VerifyExportedTx(etx)
+	if err != nil {
+		return &IntegrityAlert{TxID: expectedTxID, Reason: err.Error()}, nil
+	}
+
+	if hdr.ID != expectedTxID {
+		return &IntegrityAlert{TxID: expectedTxID, Reason: fmt.Sprintf("unexpected tx id %d", hdr.ID)}, nil
+	}
+
+	if hdr.ID > 1 && hdr.PrevAlh != v.lastAlh {
+		return &IntegrityAlert{TxID: hdr.ID, Reason: "broken hash chain"}, nil
+	}
+
+	state, err := v.db.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+
+	if hdr.ID <= state.TxId {
+		localTx, err := (&dbTxExporter{db: v.db}).ExportTx(ctx, hdr.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		localHdr, err := exportedTxHeader(localTx)
+		if err != nil {
+			return nil, err
+		}
+
+		if localHdr.Alh() != hdr.Alh() {
+			return &IntegrityAlert{TxID: hdr.ID, Reason: "transaction differs from local copy"}, nil
+		}
+	}
+
+	v.lastTxID = hdr.ID
+	v.lastAlh = hdr.Alh()
+
+	return nil, nil
+}
+
+// verifySingleTx replaces replicateSingleTx in verify-only mode, transactions are checked but never committed
+func (txr *TxReplicator) verifySingleTx(ctx context.Context, data []byte) bool {
+	if txr.verifier.failed {
+		// remaining transactions are discarded as the hash chain is already broken
+		return true
+	}
+
+	ctx, span := txr.tracer.Start(ctx, verifySpanName)
+
+	consecutiveFailures := 0
+
+	for {
+		alert, err := txr.verifier.verify(ctx, data)
+		if err == nil {
+			if alert != nil {
+				txr.raiseAlert(alert)
+				span.End(fmt.Errorf("%w: %s", ErrIntegrityDiscrepancy, alert.Reason))
+				return true
+			}
+
+			break
+		}
+
+		txr.logger.Infof("Failed to verify transaction from '%s' against '%s'. Reason: %s", txr._primaryDB, txr.db.GetName(), err.Error())
+
+		txr.updateStatus(func(st *replicatorStatus) { st.setLastError(err) })
+
+		consecutiveFailures++
+
+		if !txr.replicationFailureDelay(consecutiveFailures) {
+			span.End(err)
+			return false
+		}
+	}
+
+	span.End(nil)
+
+	txr.updateStatus(func(st *replicatorStatus) {
+		st.appliedTxs++
+		st.applyRate.observe(st.appliedTxs, time.Now())
+		st.setLastError(nil)
+	})
+
+	return true
+}
+
+func (txr *TxReplicator) raiseAlert(alert *IntegrityAlert) {
+	txr.verifier.failed = true
+
+	err := fmt.Errorf("%w at tx %d: %s", ErrIntegrityDiscrepancy, alert.TxID, alert.Reason)
+
+	txr.logger.Errorf("Transactions exported by '%s' failed verification against '%s'. Reason: %s", txr._primaryDB, txr.db.GetName(), err.Error())
+
+	txr.updateStatus(func(st *replicatorStatus) { st.setLastError(err) })
+
+	if txr.opts.alertHandler != nil {
+		txr.opts.alertHandler(alert)
+	}
+
+	// no further transaction is fetched from the primary
+	txr.cancelFunc()
+}
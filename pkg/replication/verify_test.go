@@ -0,0 +1,203 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func exportTestTxs(t *testing.T, db TxExporter, count uint64) [][]byte {
+	etxs := make([][]byte, count)
+
+	for txID := uint64(1); txID <= count; txID++ {
+		etx, err := db.ExportTx(context.Background(), txID)
+		require.NoError(t, err)
+
+		etxs[txID-1] = etx
+	}
+
+	return etxs
+}
+
+func TestTxVerifier(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+	setTestKeys(t, primary, "key", 2)
+
+	// the first transaction is committed when the database is created
+	etxs := exportTestTxs(t, &dbTxExporter{db: primary}, 3)
+
+	t.Run("valid transactions should be verified", func(t *testing.T) {
+		v := &txVerifier{db: newTestDB(t, "localdb", true)}
+
+		for _, etx := range etxs {
+			alert, err := v.verify(context.Background(), etx)
+			require.NoError(t, err)
+			require.Nil(t, alert)
+		}
+
+		require.Equal(t, uint64(3), v.lastTxID)
+	})
+
+	t.Run("transactions should be verified against the local copy", func(t *testing.T) {
+		copyDB := newTestDB(t, "copydb", true)
+		replicateTestTxs(t, primary, copyDB, 1, 3)
+
+		v := &txVerifier{db: copyDB}
+
+		for _, etx := range etxs {
+			alert, err := v.verify(context.Background(), etx)
+			require.NoError(t, err)
+			require.Nil(t, alert)
+		}
+
+		otherDB := newTestDB(t, "otherdb", false)
+		setTestKeys(t, otherDB, "other", 1)
+
+		hdr, err := exportedTxHeader(etxs[0])
+		require.NoError(t, err)
+
+		v = &txVerifier{db: otherDB, lastTxID: hdr.ID, lastAlh: hdr.Alh()}
+
+		alert, err := v.verify(context.Background(), etxs[1])
+		require.NoError(t, err)
+		require.Equal(t, &IntegrityAlert{TxID: 2, Reason: "transaction differs from local copy"}, alert)
+	})
+
+	t.Run("tampered transactions should raise an alert", func(t *testing.T) {
+		v := &txVerifier{db: newTestDB(t, "localdb", true)}
+
+		tampered := bytes.Replace(etxs[1], []byte("value0"), []byte("value9"), 1)
+
+		alert, err := v.verify(context.Background(), etxs[0])
+		require.NoError(t, err)
+		require.Nil(t, alert)
+
+		alert, err = v.verify(context.Background(), tampered)
+		require.NoError(t, err)
+		require.NotNil(t, alert)
+		require.Equal(t, uint64(2), alert.TxID)
+		require.Contains(t, alert.Reason, "entries digest mismatch")
+	})
+
+	t.Run("missing transactions should raise an alert", func(t *testing.T) {
+		v := &txVerifier{db: newTestDB(t, "localdb", true)}
+
+		alert, err := v.verify(context.Background(), etxs[0])
+		require.NoError(t, err)
+		require.Nil(t, alert)
+
+		alert, err = v.verify(context.Background(), etxs[2])
+		require.NoError(t, err)
+		require.Equal(t, &IntegrityAlert{TxID: 2, Reason: "unexpected tx id 3"}, alert)
+	})
+
+	t.Run("transactions from a different history should raise an alert", func(t *testing.T) {
+		other := newTestDB(t, "otherdb", false)
+		setTestKeys(t, other, "other", 2)
+
+		otherTxs := exportTestTxs(t, &dbTxExporter{db: other}, 3)
+
+		v := &txVerifier{db: newTestDB(t, "localdb", true)}
+
+		for _, etx := range etxs[:2] {
+			alert, err := v.verify(context.Background(), etx)
+			require.NoError(t, err)
+			require.Nil(t, alert)
+		}
+
+		alert, err := v.verify(context.Background(), otherTxs[2])
+		require.NoError(t, err)
+		require.Equal(t, &IntegrityAlert{TxID: 3, Reason: "broken hash chain"}, alert)
+	})
+}
+
+func TestVerifyOnlyReplication(t *testing.T) {
+	primary := newTestDB(t, "primarydb", false)
+	setTestKeys(t, primary, "key", 2)
+
+	etxs := exportTestTxs(t, &dbTxExporter{db: primary}, 3)
+
+	newVerifier := func(t *testing.T, alerts *[]*IntegrityAlert) *TxReplicator {
+		opts := DefaultOptions().
+			WithVerifyOnly(true).
+			WithAlertHandler(func(alert *IntegrityAlert) { *alerts = append(*alerts, alert) })
+
+		txr, err := NewTxReplicator(xid.New(), newTestDB(t, "localdb", true), opts, logger.NewMemoryLogger())
+		require.NoError(t, err)
+
+		txr.context, txr.cancelFunc = context.WithCancel(context.Background())
+		txr.verifier = &txVerifier{db: txr.db}
+
+		return txr
+	}
+
+	t.Run("valid transactions should not raise alerts", func(t *testing.T) {
+		var alerts []*IntegrityAlert
+
+		txr := newVerifier(t, &alerts)
+
+		for _, etx := range etxs {
+			require.True(t, txr.verifySingleTx(context.Background(), etx))
+		}
+
+		require.Empty(t, alerts)
+		require.NoError(t, txr.context.Err())
+
+		lastErr, _ := txr.LastError()
+		require.NoError(t, lastErr)
+
+		// transactions are never committed into the local database
+		state, err := txr.db.CurrentState()
+		require.NoError(t, err)
+		require.Zero(t, state.TxId)
+	})
+
+	t.Run("tampered transactions should raise an alert", func(t *testing.T) {
+		var alerts []*IntegrityAlert
+
+		txr := newVerifier(t, &alerts)
+
+		tampered := bytes.Replace(etxs[1], []byte("value0"), []byte("value9"), 1)
+
+		require.True(t, txr.verifySingleTx(context.Background(), etxs[0]))
+		require.True(t, txr.verifySingleTx(context.Background(), tampered))
+		require.Len(t, alerts, 1)
+		require.Equal(t, uint64(2), alerts[0].TxID)
+
+		// no further transaction is fetched nor verified
+		require.Error(t, txr.context.Err())
+		require.True(t, txr.verifySingleTx(context.Background(), etxs[2]))
+		require.Len(t, alerts, 1)
+
+		lastErr, _ := txr.LastError()
+		require.ErrorIs(t, lastErr, ErrIntegrityDiscrepancy)
+	})
+
+	t.Run("durability policies should not be accepted", func(t *testing.T) {
+		opts := DefaultOptions().WithVerifyOnly(true).WithDurabilityPolicy(FsyncAuto)
+		require.False(t, opts.Valid())
+
+		_, err := NewTxReplicator(xid.New(), newTestDB(t, "localdb", false), opts, logger.NewMemoryLogger())
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/stream"
 )
 
 // work-around until a DBManager is in-place, taking care of all db-related stuff
@@ -176,6 +177,10 @@ func (db *closedDB) SQLQueryRowReader(ctx context.Context, tx *sql.SQLTx, stmt s
 	return nil, store.ErrAlreadyClosed
 }
 
+func (db *closedDB) SQLQueryStream(ctx context.Context, tx *sql.SQLTx, stmt sql.DataSource, namedParams []*schema.NamedParam, s stream.SQLRowStreamSender) error {
+	return store.ErrAlreadyClosed
+}
+
 func (db *closedDB) VerifiableSQLGet(ctx context.Context, req *schema.VerifiableSQLGetRequest) (*schema.VerifiableSQLEntry, error) {
 	return nil, store.ErrAlreadyClosed
 }
@@ -208,10 +213,22 @@ func (db *closedDB) ReplicateTx(ctx context.Context, exportedTx []byte) (*schema
 	return nil, store.ErrAlreadyClosed
 }
 
+func (db *closedDB) ReplicateTxs(ctx context.Context, exportedTxs [][]byte) ([]*schema.TxHeader, error) {
+	return nil, store.ErrAlreadyClosed
+}
+
+func (db *closedDB) ReplicateTransformedTx(ctx context.Context, hdr *store.TxHeader, entries []*store.EntrySpec) (*schema.TxHeader, error) {
+	return nil, store.ErrAlreadyClosed
+}
+
 func (db *closedDB) AllowCommitUpto(txID uint64, alh [sha256.Size]byte) error {
 	return store.ErrAlreadyClosed
 }
 
+func (db *closedDB) SetSyncBatch(batchSize int, idleTimeout time.Duration) error {
+	return store.ErrAlreadyClosed
+}
+
 func (db *closedDB) DiscardPrecommittedTxsSince(txID uint64) error {
 	return store.ErrAlreadyClosed
 }
@@ -224,6 +241,10 @@ func (db *closedDB) TxScan(ctx context.Context, req *schema.TxScanRequest) (*sch
 	return nil, store.ErrAlreadyClosed
 }
 
+func (db *closedDB) RevertTxRange(ctx context.Context, fromTxID, toTxID uint64) (*schema.TxHeader, error) {
+	return nil, store.ErrAlreadyClosed
+}
+
 func (db *closedDB) FlushIndex(req *schema.FlushIndexRequest) error {
 	return store.ErrAlreadyClosed
 }
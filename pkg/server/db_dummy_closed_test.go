@@ -154,6 +154,9 @@ func TestDummyClosedDatabase(t *testing.T) {
 	err = cdb.DiscardPrecommittedTxsSince(1)
 	require.ErrorIs(t, err, store.ErrAlreadyClosed)
 
+	err = cdb.SetSyncBatch(1, 0)
+	require.ErrorIs(t, err, store.ErrAlreadyClosed)
+
 	_, err = cdb.VerifiableTxByID(context.Background(), nil)
 	require.ErrorIs(t, err, store.ErrAlreadyClosed)
 
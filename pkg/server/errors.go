@@ -46,6 +46,7 @@ var (
 	ErrReplicationInProgress       = errors.New("replication already in progress")
 	ErrReplicatorNotNeeded         = errors.New("replicator is not needed")
 	ErrReplicationNotInProgress    = errors.New("replication is not in progress")
+	ErrTooManyExports              = status.Error(codes.ResourceExhausted, "too many concurrent exports, retry later")
 	ErrSessionAlreadyPresent       = errors.New("session already present").WithCode(errors.CodInternalError)
 	ErrSessionNotFound             = errors.New("session not found").WithCode(errors.CodSqlserverRejectedEstablishmentOfSqlSession)
 	ErrOngoingReadWriteTx          = sessions.ErrOngoingReadWriteTx
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// exportLimiter bounds the number of transactions being exported at once, so replicas catching up
+// don't starve the rest of the traffic. Exports exceeding the limit wait for a slot in arrival order
+type exportLimiter struct {
+	mutex sync.Mutex
+
+	maxActive    int
+	queueTimeout time.Duration
+
+	active  int
+	waiting *list.List
+}
+
+func newExportLimiter(maxActive int, queueTimeout time.Duration) *exportLimiter {
+	return &exportLimiter{
+		maxActive:    maxActive,
+		queueTimeout: queueTimeout,
+		waiting:      list.New(),
+	}
+}
+
+// acquire takes a slot, waiting for one up to the queue timeout. It fails with ErrTooManyExports
+// if no slot was released in time, or right away if there is no queue timeout
+func (l *exportLimiter) acquire(ctx context.Context) error {
+	l.mutex.Lock()
+
+	if l.active < l.maxActive && l.waiting.Len() == 0 {
+		l.active++
+		l.mutex.Unlock()
+		return nil
+	}
+
+	if l.queueTimeout <= 0 {
+		l.mutex.Unlock()
+		return ErrTooManyExports
+	}
+
+	ready := make(chan struct{})
+	waiter := l.waiting.PushBack(ready)
+
+	l.mutex.Unlock()
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	var err error
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer.C:
+		err = ErrTooManyExports
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	select {
+	case <-ready:
+		// the slot was handed over while giving up
+		return nil
+	default:
+		l.waiting.Remove(waiter)
+		return err
+	}
+}
+
+// release frees a slot, which is handed over to the export waiting for the longest time if any
+func (l *exportLimiter) release() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	next := l.waiting.Front()
+	if next == nil {
+		l.active--
+		return
+	}
+
+	l.waiting.Remove(next)
+	close(next.Value.(chan struct{}))
+}
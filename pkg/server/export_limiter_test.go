@@ -0,0 +1,96 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestExportLimiterRejects(t *testing.T) {
+	l := newExportLimiter(2, 0)
+
+	require.NoError(t, l.acquire(context.Background()))
+	require.NoError(t, l.acquire(context.Background()))
+
+	err := l.acquire(context.Background())
+	require.ErrorIs(t, err, ErrTooManyExports)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	l.release()
+
+	require.NoError(t, l.acquire(context.Background()))
+}
+
+func TestExportLimiterQueuesInArrivalOrder(t *testing.T) {
+	l := newExportLimiter(1, 10*time.Second)
+
+	require.NoError(t, l.acquire(context.Background()))
+
+	acquired := make(chan int, 3)
+
+	for i := 0; i < 3; i++ {
+		go func(i int) {
+			err := l.acquire(context.Background())
+			require.NoError(t, err)
+			acquired <- i
+		}(i)
+
+		// waiters are queued one after the other
+		require.Eventually(t, func() bool {
+			l.mutex.Lock()
+			defer l.mutex.Unlock()
+			return l.waiting.Len() == i+1
+		}, time.Second, time.Millisecond)
+	}
+
+	for i := 0; i < 3; i++ {
+		l.release()
+		require.Equal(t, i, <-acquired)
+	}
+
+	l.release()
+
+	require.Zero(t, l.active)
+}
+
+func TestExportLimiterQueueTimeout(t *testing.T) {
+	l := newExportLimiter(1, 10*time.Millisecond)
+
+	require.NoError(t, l.acquire(context.Background()))
+
+	err := l.acquire(context.Background())
+	require.ErrorIs(t, err, ErrTooManyExports)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = l.acquire(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// waiters giving up don't take the released slot
+	require.Zero(t, l.waiting.Len())
+
+	l.release()
+
+	require.NoError(t, l.acquire(context.Background()))
+}
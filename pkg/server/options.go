@@ -22,6 +22,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/codenotary/immudb/pkg/server/sessions"
@@ -94,6 +95,12 @@ type ReplicationOptions struct {
 	PrefetchTxBufferSize         int    // only if IsReplica
 	ReplicationCommitConcurrency int    // only if IsReplica
 	AllowTxDiscarding            bool   // only if IsReplica
+
+	// MaxConcurrentExports limits the transactions exported at once to asynchronous replicas, zero means no limit.
+	// Exports exceeding the limit wait in arrival order up to ExportQueueTimeout, they're rejected right away
+	// when it's zero. Exports to synchronous replicas are never limited as commits depend on them
+	MaxConcurrentExports int
+	ExportQueueTimeout   time.Duration
 }
 
 // DefaultOptions returns default server options
@@ -538,3 +545,13 @@ func (opts *ReplicationOptions) WithAllowTxDiscarding(allowTxDiscarding bool) *R
 	opts.AllowTxDiscarding = allowTxDiscarding
 	return opts
 }
+
+func (opts *ReplicationOptions) WithMaxConcurrentExports(maxConcurrentExports int) *ReplicationOptions {
+	opts.MaxConcurrentExports = maxConcurrentExports
+	return opts
+}
+
+func (opts *ReplicationOptions) WithExportQueueTimeout(exportQueueTimeout time.Duration) *ReplicationOptions {
+	opts.ExportQueueTimeout = exportQueueTimeout
+	return opts
+}
@@ -19,6 +19,7 @@ package server
 import (
 	"crypto/tls"
 	"testing"
+	"time"
 
 	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/logger"
@@ -89,11 +90,15 @@ func TestReplicationOptions(t *testing.T) {
 	repOpts.
 		WithIsReplica(false).
 		WithSyncReplication(true).
-		WithSyncAcks(1)
+		WithSyncAcks(1).
+		WithMaxConcurrentExports(2).
+		WithExportQueueTimeout(time.Second)
 
 	require.False(t, repOpts.IsReplica)
 	require.True(t, repOpts.SyncReplication)
 	require.Equal(t, 1, repOpts.SyncAcks)
+	require.Equal(t, 2, repOpts.MaxConcurrentExports)
+	require.Equal(t, time.Second, repOpts.ExportQueueTimeout)
 }
 
 func TestSetOptions(t *testing.T) {
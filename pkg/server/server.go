@@ -160,6 +160,10 @@ func (s *ImmuServer) Initialize() error {
 		return err
 	}
 
+	if repOpts := s.Options.ReplicationOptions; repOpts != nil && repOpts.MaxConcurrentExports > 0 {
+		s.exportLimiter = newExportLimiter(repOpts.MaxConcurrentExports, repOpts.ExportQueueTimeout)
+	}
+
 	grpcSrvOpts := []grpc.ServerOption{}
 	if s.Options.TLSConfig != nil {
 		grpcSrvOpts = []grpc.ServerOption{grpc.Creds(credentials.NewTLS(s.Options.TLSConfig))}
@@ -638,6 +642,20 @@ func (s *ImmuServer) replicationInProgressFor(db string) bool {
 	return ok
 }
 
+// ReplicationPrimaries returns the primaries replicated by each replica database, by database name
+func (s *ImmuServer) ReplicationPrimaries() map[string][]replication.PrimaryInfo {
+	s.replicationMutex.Lock()
+	defer s.replicationMutex.Unlock()
+
+	primaries := make(map[string][]replication.PrimaryInfo, len(s.replicators))
+
+	for db, f := range s.replicators {
+		primaries[db] = f.Primaries()
+	}
+
+	return primaries
+}
+
 func (s *ImmuServer) startReplicationFor(db database.DB, dbOpts *dbOptions) error {
 	if !dbOpts.isReplicatorRequired() {
 		s.Logger.Infof("Replication for database '%s' is not required.", db.GetName())
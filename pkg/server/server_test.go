@@ -669,6 +669,61 @@ func TestServerUpdateDatabaseV2AuthEnabled(t *testing.T) {
 	require.ErrorIs(t, err, ErrIllegalArguments)
 }
 
+func TestServerReplicationPrimaries(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithDir(t.TempDir()).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithAuth(true)
+
+	s, closer := testServer(serverOptions)
+	defer closer()
+
+	err := s.Initialize()
+	require.NoError(t, err)
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	require.Empty(t, s.ReplicationPrimaries())
+
+	_, err = s.CreateDatabaseV2(ctx, &schema.CreateDatabaseRequest{
+		Name: "replicadb",
+		Settings: &schema.DatabaseNullableSettings{
+			ReplicationSettings: &schema.ReplicationNullableSettings{
+				Replica:         &schema.NullableBool{Value: true},
+				PrimaryDatabase: &schema.NullableString{Value: "primarydb"},
+				PrimaryHost:     &schema.NullableString{Value: "127.0.0.1"},
+				PrimaryPort:     &schema.NullableUint32{Value: 1},
+				PrimaryUsername: &schema.NullableString{Value: "replicator"},
+				PrimaryPassword: &schema.NullableString{Value: "secret"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	primaries := s.ReplicationPrimaries()
+	require.Len(t, primaries, 1)
+	require.Len(t, primaries["replicadb"], 1)
+
+	primary := primaries["replicadb"][0]
+	require.Equal(t, "127.0.0.1:1", primary.Address)
+	require.Equal(t, "primarydb", primary.Database)
+	require.Equal(t, "replicator", primary.Username)
+	require.False(t, primary.Connected)
+
+	_, err = s.UnloadDatabase(ctx, &schema.UnloadDatabaseRequest{Database: "replicadb"})
+	require.NoError(t, err)
+
+	require.Empty(t, s.ReplicationPrimaries())
+}
+
 func TestServerLoaduserDatabase(t *testing.T) {
 	serverOptions := DefaultOptions().
 		WithDir(t.TempDir()).
@@ -18,6 +18,7 @@ package server
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
@@ -34,7 +35,18 @@ func (s *ImmuServer) ExportTx(req *schema.ExportTxRequest, txsServer schema.Immu
 		return err
 	}
 
-	txbs, mayCommitUpToTxID, mayCommitUpToAlh, err := db.ExportTxByID(txsServer.Context(), req)
+	ctx := txsServer.Context()
+
+	// synchronous replicas are not limited as commits wait for their acknowledgement
+	if s.exportLimiter != nil && req.ReplicaState == nil {
+		err := s.exportLimiter.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		defer s.exportLimiter.release()
+	}
+
+	txbs, mayCommitUpToTxID, mayCommitUpToAlh, err := db.ExportTxByID(ctx, req)
 
 	defer func() {
 		if req.ReplicaState != nil {
@@ -65,6 +77,13 @@ func (s *ImmuServer) ExportTx(req *schema.ExportTxRequest, txsServer schema.Immu
 		return nil
 	}
 
+	// replicas may request a checksum of the exported transaction to detect its corruption in transit
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok && len(md.Get("payload-checksum")) > 0 {
+		checksum := sha256.Sum256(txbs)
+		txsServer.SetTrailer(metadata.Pairs("tx-checksum-bin", string(checksum[:])))
+	}
+
 	sender := s.StreamServiceFactory.NewMsgSender(txsServer)
 
 	err = sender.Send(bytes.NewReader(txbs), len(txbs))
@@ -64,6 +64,9 @@ type ImmuServer struct {
 	truncators     map[string]*truncator.Truncator
 	truncatorMutex sync.Mutex
 
+	// exportLimiter is nil when exports are not limited
+	exportLimiter *exportLimiter
+
 	Logger      logger.Logger
 	Options     *Options
 	Listener    net.Listener
@@ -31,6 +31,7 @@ var ErrChunkTooSmall = fmt.Sprintf("minimum chunk size is %d", MinChunkSize)
 var ErrMissingExpectedData = "expected data on stream is missing"
 var ErrRefOptNotImplemented = "reference operation is not implemented"
 var ErrUnableToReassembleExecAllMessage = "unable to reassemble ZAdd message on a streamExecAll"
+var ErrUnexpectedSQLMessage = "unexpected message on a SQL result stream"
 
 func init() {
 	errors.CodeMap[ErrMaxValueLenExceeded] = errors.CodDataException
@@ -41,4 +42,5 @@ func init() {
 	errors.CodeMap[ErrMissingExpectedData] = errors.CodInternalError
 	errors.CodeMap[ErrRefOptNotImplemented] = errors.CodUndefinedFunction
 	errors.CodeMap[ErrUnableToReassembleExecAllMessage] = errors.CodInternalError
+	errors.CodeMap[ErrUnexpectedSQLMessage] = errors.CodInternalError
 }
@@ -37,6 +37,9 @@ type ServiceFactory interface {
 
 	NewExecAllStreamSender(str MsgSender) ExecAllStreamSender
 	NewExecAllStreamReceiver(str MsgReceiver) ExecAllStreamReceiver
+
+	NewSQLRowStreamSender(str MsgSender) SQLRowStreamSender
+	NewSQLRowStreamReceiver(str MsgReceiver) SQLRowStreamReceiver
 }
 
 // NewStreamServiceFactory returns a new ServiceFactory
@@ -91,3 +94,13 @@ func (s *serviceFactory) NewExecAllStreamReceiver(mr MsgReceiver) ExecAllStreamR
 func (s *serviceFactory) NewExecAllStreamSender(ms MsgSender) ExecAllStreamSender {
 	return NewExecAllStreamSender(ms)
 }
+
+// NewSQLRowStreamSender returns a SQLRowStreamSender
+func (s *serviceFactory) NewSQLRowStreamSender(ms MsgSender) SQLRowStreamSender {
+	return NewSQLRowStreamSender(ms)
+}
+
+// NewSQLRowStreamReceiver returns a SQLRowStreamReceiver
+func (s *serviceFactory) NewSQLRowStreamReceiver(mr MsgReceiver) SQLRowStreamReceiver {
+	return NewSQLRowStreamReceiver(mr, s.ChunkSize)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stream
+
+import (
+	"io"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/errors"
+	"github.com/golang/protobuf/proto"
+)
+
+type sqlRowStreamReceiver struct {
+	s          io.Reader
+	BufferSize int
+
+	cols []*schema.Column
+}
+
+// NewSQLRowStreamReceiver returns a new sqlRowStreamReceiver
+func NewSQLRowStreamReceiver(s io.Reader, bs int) SQLRowStreamReceiver {
+	return &sqlRowStreamReceiver{
+		s:          s,
+		BufferSize: bs,
+	}
+}
+
+// Columns returns the columns of the result, which are read from stream on the first call
+func (sr *sqlRowStreamReceiver) Columns() ([]*schema.Column, error) {
+	if sr.cols != nil {
+		return sr.cols, nil
+	}
+
+	var res schema.SQLQueryResult
+
+	err := sr.readMsg(sqlColumnsMsg, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	sr.cols = res.Columns
+	if sr.cols == nil {
+		sr.cols = []*schema.Column{}
+	}
+
+	return sr.cols, nil
+}
+
+// Next returns the following row found on stream. If no more rows are present on stream it returns io.EOF
+func (sr *sqlRowStreamReceiver) Next() (*schema.Row, error) {
+	_, err := sr.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var row schema.Row
+
+	err = sr.readMsg(sqlRowMsg, &row)
+	if err != nil {
+		return nil, err
+	}
+
+	return &row, nil
+}
+
+func (sr *sqlRowStreamReceiver) readMsg(kind byte, m proto.Message) error {
+	msg, err := ReadValue(sr.s, sr.BufferSize)
+	if err != nil {
+		return err
+	}
+
+	if len(msg) == 0 || msg[0] != kind {
+		return errors.New(ErrUnexpectedSQLMessage)
+	}
+
+	return proto.Unmarshal(msg[1:], m)
+}
+
+// ReadSQLQueryResult reads a whole SQL result from stream
+func ReadSQLQueryResult(sr SQLRowStreamReceiver) (*schema.SQLQueryResult, error) {
+	cols, err := sr.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &schema.SQLQueryResult{Columns: cols}
+
+	for {
+		row, err := sr.Next()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		res.Rows = append(res.Rows, row)
+	}
+}
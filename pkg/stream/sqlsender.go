@@ -0,0 +1,73 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stream
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/golang/protobuf/proto"
+)
+
+// every message of a SQL result starts with its kind, so that empty rows can be sent as well
+const (
+	sqlColumnsMsg byte = iota + 1
+	sqlRowMsg
+)
+
+type sqlRowStreamSender struct {
+	s MsgSender
+}
+
+// NewSQLRowStreamSender returns a new sqlRowStreamSender
+func NewSQLRowStreamSender(s MsgSender) *sqlRowStreamSender {
+	return &sqlRowStreamSender{
+		s: s,
+	}
+}
+
+// SendColumns sends the columns of the result on stream
+func (st *sqlRowStreamSender) SendColumns(cols []*schema.Column) error {
+	return st.send(sqlColumnsMsg, &schema.SQLQueryResult{Columns: cols})
+}
+
+// SendRow sends a row of the result on stream
+func (st *sqlRowStreamSender) SendRow(row *schema.Row) error {
+	return st.send(sqlRowMsg, row)
+}
+
+func (st *sqlRowStreamSender) send(kind byte, m proto.Message) error {
+	bs, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	msg := make([]byte, 1+len(bs))
+	msg[0] = kind
+	copy(msg[1:], bs)
+
+	err = st.s.Send(bytes.NewReader(msg), len(msg))
+	if err != nil {
+		if err == io.EOF {
+			return st.s.RecvMsg(nil)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/stream/streamtest"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLRowStream(t *testing.T) {
+	chunkSize := MinChunkSize
+
+	var chunks []*schema.Chunk
+
+	sm := streamtest.DefaultImmuServiceSenderStreamMock()
+	sm.SendF = func(c *schema.Chunk) error {
+		chunks = append(chunks, c)
+		return nil
+	}
+
+	sf := NewStreamServiceFactory(chunkSize)
+	s := sf.NewSQLRowStreamSender(sf.NewMsgSender(sm))
+
+	res := &schema.SQLQueryResult{
+		Columns: []*schema.Column{
+			{Name: "(db1.table1.id)", Type: "INTEGER"},
+			{Name: "(db1.table1.title)", Type: "VARCHAR"},
+			{Name: "(db1.table1.payload)", Type: "BLOB"},
+		},
+	}
+
+	for i := 0; i < 100; i++ {
+		res.Rows = append(res.Rows, &schema.Row{
+			Columns: []string{"(db1.table1.id)", "(db1.table1.title)", "(db1.table1.payload)"},
+			Values: []*schema.SQLValue{
+				{Value: &schema.SQLValue_N{N: int64(i)}},
+				{Value: &schema.SQLValue_S{S: fmt.Sprintf("title%d", i)}},
+				{Value: &schema.SQLValue_Bs{Bs: make([]byte, i*10)}},
+			},
+		})
+	}
+
+	res.Rows = append(res.Rows, &schema.Row{})
+
+	err := s.SendColumns(res.Columns)
+	require.NoError(t, err)
+
+	for _, row := range res.Rows {
+		err = s.SendRow(row)
+		require.NoError(t, err)
+	}
+
+	require.Greater(t, len(chunks), len(res.Rows))
+
+	for _, c := range chunks {
+		require.LessOrEqual(t, len(c.Content), chunkSize)
+	}
+
+	rm := streamtest.DefaultImmuServiceReceiverStreamMock(nil)
+	rm.RecvF = func() (*schema.Chunk, error) {
+		if len(chunks) == 0 {
+			return nil, io.EOF
+		}
+
+		c := chunks[0]
+		chunks = chunks[1:]
+
+		return c, nil
+	}
+
+	r := sf.NewSQLRowStreamReceiver(sf.NewMsgReceiver(rm))
+
+	received, err := ReadSQLQueryResult(r)
+	require.NoError(t, err)
+	require.Len(t, received.Rows, len(res.Rows))
+	require.True(t, proto.Equal(res, received))
+
+	_, err = r.Next()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestSQLRowStreamSender_SendErr(t *testing.T) {
+	sm := streamtest.DefaultImmuServiceSenderStreamMock()
+	s := streamtest.DefaultMsgSenderMock(sm, MinChunkSize)
+	s.SendF = func(reader io.Reader, payloadSize int) (err error) {
+		return errors.New("custom")
+	}
+
+	err := NewSQLRowStreamSender(s).SendRow(&schema.Row{})
+	require.Error(t, err)
+}
+
+func TestSQLRowStreamReceiver_UnexpectedMessage(t *testing.T) {
+	msg := []byte{sqlRowMsg}
+
+	me := []*streamtest.MsgError{
+		{M: msg, E: io.EOF},
+	}
+
+	r := NewSQLRowStreamReceiver(streamtest.DefaultMsgReceiverMock(me), MinChunkSize)
+
+	_, err := r.Columns()
+	require.ErrorContains(t, err, ErrUnexpectedSQLMessage)
+}
@@ -0,0 +1,34 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stream
+
+import "github.com/codenotary/immudb/pkg/api/schema"
+
+// SQLRowStreamSender sends the result of a SQL query row by row, thus the result does not need to be kept in memory
+type SQLRowStreamSender interface {
+	// SendColumns sends the columns of the result, it must be called once before sending any row
+	SendColumns(cols []*schema.Column) error
+	SendRow(row *schema.Row) error
+}
+
+// SQLRowStreamReceiver receives the result of a SQL query sent by a SQLRowStreamSender
+type SQLRowStreamReceiver interface {
+	// Columns returns the columns of the result
+	Columns() ([]*schema.Column, error)
+	// Next returns the following row of the result. If no more rows are present on stream it returns io.EOF
+	Next() (*schema.Row, error)
+}